@@ -0,0 +1,24 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sighupio/furyctl/cmd/airgap"
+)
+
+func NewAirgapCmd() *cobra.Command {
+	airgapCmd := &cobra.Command{
+		Use:   "airgap",
+		Short: "Work with the air-gapped bundles that 'furyctl download air-gapped-bundle' creates",
+	}
+
+	airgapCmd.AddCommand(airgap.NewInspectCmd())
+	airgapCmd.AddCommand(airgap.NewPushImagesCmd())
+	airgapCmd.AddCommand(airgap.NewVerifyCmd())
+
+	return airgapCmd
+}
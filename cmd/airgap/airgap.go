@@ -0,0 +1,33 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package airgap
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/flags"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+)
+
+const tabPadding = 3
+
+// preRun is the PreRun every `furyctl airgap` subcommand shares.
+func preRun(cmd *cobra.Command) analytics.Event {
+	cmdEvent := analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+	// Bind the flags first: a flag on the command line has precedence over the configuration file.
+	if err := viper.BindPFlags(cmd.Flags()); err != nil {
+		logrus.Fatalf("error while binding flags: %v", err)
+	}
+
+	if err := flags.LoadAndMergeCommandFlags("airgap"); err != nil {
+		logrus.Fatalf("failed to load flags from configuration: %v", err)
+	}
+
+	return cmdEvent
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package airgap
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sighupio/furyctl/internal/airgap"
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+)
+
+func NewInspectCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	inspectCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "inspect <bundle>",
+		Short: "Show what an air-gapped bundle was built for, and its tools",
+		Long: `Show the manifest of an air-gapped bundle: the furyctl that built it, the distribution version, kind and platform it is for, and its tools with their versions.
+furyctl reads the manifest only, without extracting the bundle. Use 'furyctl airgap verify' to check the files of the bundle against the manifest.`,
+		Example: `  furyctl airgap inspect ./bundle.tar.gz
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = preRun(cmd)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			m, _, sig, err := airgap.ReadManifest(args[0])
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while reading the air-gapped bundle: %w", err)
+			}
+
+			fmt.Print(formatManifest(m, sig != nil))
+
+			cmdEvent.AddSuccessMessage("air-gapped bundle inspected")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	inspectCmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file, furyctl reads the flags section from it",
+	)
+
+	return inspectCmd
+}
+
+// formatManifest returns the summary of the manifest of a bundle and the table of its tools.
+func formatManifest(m airgap.Manifest, signed bool) string {
+	var sb strings.Builder
+
+	w := tabwriter.NewWriter(&sb, 0, 0, tabPadding, ' ', 0)
+
+	kind := "full"
+	if m.IsDelta() {
+		kind = "delta of the bundle with checksum " + m.Base
+	}
+
+	fmt.Fprintf(w, "Bundle:\t%s\n", kind)
+	fmt.Fprintf(w, "Kind:\t%s\n", m.Kind)
+	fmt.Fprintf(w, "Distribution version:\t%s\n", m.DistributionVersion)
+	fmt.Fprintf(w, "Platform:\t%s\n", m.Platform)
+	fmt.Fprintf(w, "Built by furyctl:\t%s\n", m.FuryctlVersion)
+	fmt.Fprintf(w, "Created at:\t%s\n", m.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "Signed:\t%t\n", signed)
+	fmt.Fprintf(w, "Files:\t%d\n", len(m.Files))
+	w.Flush()
+
+	if len(m.Tools) == 0 {
+		return sb.String()
+	}
+
+	sb.WriteString("\n")
+
+	w = tabwriter.NewWriter(&sb, 0, 0, tabPadding, ' ', 0)
+
+	fmt.Fprintln(w, "TOOL\tVERSION\tPLATFORM")
+
+	for _, t := range m.Tools {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", t.Name, t.Version, t.Platform)
+	}
+
+	w.Flush()
+
+	return sb.String()
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package airgap
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/airgap"
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/images"
+)
+
+var ErrRegistryRequired = errors.New("--registry is required")
+
+func NewPushImagesCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	pushImagesCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "push-images",
+		Short: "Load the container images of an air-gapped bundle into an internal registry",
+		Long: `Load the container images of an air-gapped bundle into an internal registry, and list the images pushed.
+furyctl pushes the images unchanged, so their digests on the internal registry are the same. An image keeps its repository on the registry, unless a --rewrite-prefix rule matches it: the rule replaces the longest source prefix with the target prefix.
+Set the rules in the airgap section of the flags of furyctl.yaml to use the same rules on each push. The registry password can be set with the FURYCTL_REGISTRY_PASSWORD environment variable.`,
+		Example: `  furyctl airgap push-images --airgap-bundle ./bundle.tar.gz --registry registry.internal:5000
+  furyctl airgap push-images --registry registry.internal --rewrite-prefix registry.sighup.io/fury=mirror/fury
+  furyctl airgap push-images --images-dir ./images --registry localhost:5000 --insecure-registry
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = preRun(cmd)
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			registry := viper.GetString("registry")
+			if registry == "" {
+				return ErrRegistryRequired
+			}
+
+			if err := airgap.MaybePrepare(); err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while preparing the air-gapped bundle: %w", err)
+			}
+
+			imagesDir := viper.GetString("images-dir")
+			if imagesDir == "" {
+				imagesDir = filepath.Join(viper.GetString("outdir"), airgap.ImagesSubdir)
+			}
+
+			rw, err := images.ParseRewrite(registry, viper.GetStringSlice("rewrite-prefix"))
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			pushed, err := pushImages(imagesDir, rw, images.RegistryOptions{
+				Insecure: viper.GetBool("insecure-registry"),
+				Username: viper.GetString("registry-username"),
+				Password: viper.GetString("registry-password"),
+			})
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			if len(pushed) == 0 {
+				logrus.Warnf("No images found in %s", imagesDir)
+			} else {
+				fmt.Print(formatPushed(pushed))
+			}
+
+			cmdEvent.AddSuccessMessage("images successfully pushed")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	pushImagesCmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file, furyctl reads the flags section from it",
+	)
+
+	pushImagesCmd.Flags().String(
+		"registry",
+		"",
+		"Host, and optional path prefix, of the registry to push the images to (eg: registry.internal:5000/fury)",
+	)
+
+	pushImagesCmd.Flags().StringSlice(
+		"rewrite-prefix",
+		[]string{},
+		"Rules that rewrite the repository of the images on the registry, in the <source prefix>=<target prefix> form "+
+			"(eg: registry.sighup.io/fury=mirror/fury). It can be repeated",
+	)
+
+	pushImagesCmd.Flags().String(
+		"images-dir",
+		"",
+		"Path to the OCI image layout with the images to push. Defaults to the images folder of the extracted bundle",
+	)
+
+	pushImagesCmd.Flags().Bool(
+		"insecure-registry",
+		false,
+		"Reach the registry with plain HTTP",
+	)
+
+	pushImagesCmd.Flags().String(
+		"registry-username",
+		"",
+		"Username to authenticate to the registry",
+	)
+
+	pushImagesCmd.Flags().String(
+		"registry-password",
+		"",
+		"Password to authenticate to the registry. Prefer the FURYCTL_REGISTRY_PASSWORD environment variable",
+	)
+
+	airgap.RegisterFlags(pushImagesCmd)
+
+	return pushImagesCmd
+}
+
+// pushImages pushes the images of the layout in imagesDir to the registry of the rewrite.
+func pushImages(imagesDir string, rw images.Rewrite, opts images.RegistryOptions) ([]images.Pushed, error) {
+	layout, err := images.OpenLayout(imagesDir)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening the images: %w", err)
+	}
+
+	logrus.Infof("Pushing the images of %s to %s...", imagesDir, rw.Registry)
+
+	pushed, err := images.Push(images.NewRegistryClient(opts), layout, rw)
+	if err != nil {
+		return pushed, fmt.Errorf("error while pushing the images: %w", err)
+	}
+
+	return pushed, nil
+}
+
+// formatPushed returns the table of the images pushed.
+func formatPushed(pushed []images.Pushed) string {
+	var sb strings.Builder
+
+	w := tabwriter.NewWriter(&sb, 0, 0, tabPadding, ' ', 0)
+
+	fmt.Fprintln(w, "SOURCE\tTARGET")
+
+	for _, p := range pushed {
+		fmt.Fprintf(w, "%s\t%s\n", p.Source, p.Target)
+	}
+
+	w.Flush()
+
+	return sb.String()
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package airgap
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/airgap"
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+)
+
+var ErrBundleVerificationFailed = errors.New("the air-gapped bundle does not match its manifest")
+
+func NewVerifyCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	verifyCmd := &cobra.Command{
+		Args:  cobra.ExactArgs(1),
+		Use:   "verify <bundle>",
+		Short: "Check offline that an air-gapped bundle matches its manifest, and the signature of the manifest",
+		Long: `Check offline that an air-gapped bundle matches its manifest: each file of the bundle has the checksum of the manifest, and the bundle has no other files.
+With --public-key, furyctl also checks the signature that 'furyctl download air-gapped-bundle --signing-key' made, and fails for an unsigned bundle.
+For a delta bundle, furyctl checks the contents of the delta. The files that come from the base bundle are checked when the delta is applied.`,
+		Example: `  furyctl airgap verify ./bundle.tar.gz
+  furyctl airgap verify ./bundle.tar.gz --public-key ./bundle.pub
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = preRun(cmd)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			publicKey := ""
+
+			if path := viper.GetString("public-key"); path != "" {
+				content, err := os.ReadFile(path)
+				if err != nil {
+					return fmt.Errorf("error while reading public key: %w", err)
+				}
+
+				publicKey = string(content)
+			}
+
+			logrus.Infof("Verifying air-gapped bundle %s ...", args[0])
+
+			v, err := airgap.Verify(args[0], publicKey)
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while verifying the air-gapped bundle: %w", err)
+			}
+
+			fmt.Print(formatVerification(v))
+
+			if len(v.Problems) > 0 {
+				cmdEvent.AddErrorMessage(ErrBundleVerificationFailed)
+				tracker.Track(cmdEvent)
+
+				return ErrBundleVerificationFailed
+			}
+
+			cmdEvent.AddSuccessMessage("air-gapped bundle verified")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	verifyCmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file, furyctl reads the flags section from it",
+	)
+
+	verifyCmd.Flags().String(
+		"public-key",
+		"",
+		"Path to the PEM public key that checks the signature of the manifest of the bundle",
+	)
+
+	return verifyCmd
+}
+
+// formatVerification returns the outcome of the verification of a bundle.
+func formatVerification(v airgap.Verification) string {
+	var sb strings.Builder
+
+	switch {
+	case v.SignatureVerified:
+		sb.WriteString("Signature: verified\n")
+
+	case v.Signed:
+		sb.WriteString("Signature: present, not checked (use --public-key)\n")
+
+	default:
+		sb.WriteString("Signature: none\n")
+	}
+
+	fmt.Fprintf(&sb, "Files checked: %d\n", v.Files)
+
+	if len(v.Problems) == 0 {
+		sb.WriteString("The bundle matches its manifest\n")
+
+		return sb.String()
+	}
+
+	sb.WriteString("Problems:\n")
+
+	for _, p := range v.Problems {
+		fmt.Fprintf(&sb, "  - %s\n", p)
+	}
+
+	return sb.String()
+}
@@ -25,9 +25,15 @@ import (
 	"github.com/sighupio/furyctl/internal/app"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/config"
+	"github.com/sighupio/furyctl/internal/encryption"
 	"github.com/sighupio/furyctl/internal/flags"
 	"github.com/sighupio/furyctl/internal/git"
-	"github.com/sighupio/furyctl/internal/lockfile"
+	"github.com/sighupio/furyctl/internal/lock"
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/policy"
+	"github.com/sighupio/furyctl/internal/runreport"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
 	"github.com/sighupio/furyctl/pkg/dependencies"
@@ -68,6 +74,20 @@ type ClusterCmdFlags struct {
 	UpgradeNode           string
 	DistroPatchesLocation string
 	PostApplyPhases       []string
+	LockBackend           string
+	SavePlan              string
+	PlanFile              string
+	StoreRunReport        bool
+	ClusterStateCmdFlags
+}
+
+// ClusterStateCmdFlags are the flags on how the state of the cluster is stored and checked, that apply, plan and
+// drift read in the same way.
+type ClusterStateCmdFlags struct {
+	EncryptionKey   encryption.Key
+	StateBackend    backend.Config
+	PolicyRules     []policy.Rule
+	StrictTemplates cluster.StrictTemplates
 }
 
 var (
@@ -87,6 +107,8 @@ func NewApplyCmd() *cobra.Command {
   furyctl apply --phase distribution                Apply a single phase, for example the distribution phase
   furyctl apply --post-apply-phases distribution    Apply all the phases, and repeat the distribution phase afterwards
   furyctl apply --upgrade                           Upgrade a cluster after bumping it's version in the configuration file.
+  furyctl apply --save-plan plan.tgz                Save what the apply would do, for review, without changing the cluster
+  furyctl apply --plan-file plan.tgz                Apply exactly the reviewed plan
 `,
 		PreRun: func(cmd *cobra.Command, _ []string) {
 			cmdEvent = analytics.NewCommandEvent(cobrax.GetFullname(cmd))
@@ -104,7 +126,7 @@ func NewApplyCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
 			// Air-gapped: if --airgap-bundle is set, extract it and rewire to run offline before
 			// reading the other flags (it sets skip-deps-download and distro-location).
@@ -128,202 +150,342 @@ func NewApplyCmd() *cobra.Command {
 				logrus.Info("Dry run mode enabled, no changes will be applied")
 			}
 
-			var distrodl *dist.Downloader
+			report, err := applyConfiguration(cmdFlags, cmdEvent, tracker, cmdFlags.SavePlan != "")
+			if err != nil {
+				return err
+			}
+
+			if cmdFlags.SavePlan != "" {
+				if err := report.Save(cmdFlags.SavePlan); err != nil {
+					cmdEvent.AddErrorMessage(err)
+					tracker.Track(cmdEvent)
 
-			logrus.Debugf("Using configuration file from path %s", cmdFlags.FuryctlPath)
+					return err
+				}
 
-			// Init first half of collaborators.
-			client := netx.NewGoGetterClient()
-			executor := execx.NewStdExecutor()
-			depsvl := dependencies.NewValidator(executor, cmdFlags.BinPath, cmdFlags.FuryctlPath)
+				fmt.Print(report.String())
 
-			if cmdFlags.DistroLocation == "" {
-				distrodl = dist.NewCachingDownloader(client, cmdFlags.Outdir, cmdFlags.GitProtocol, cmdFlags.DistroPatchesLocation)
-			} else {
-				distrodl = dist.NewDownloader(client, cmdFlags.GitProtocol, cmdFlags.DistroPatchesLocation)
+				logrus.Infof("Plan saved to %s, run `furyctl apply --plan-file %s` to apply it",
+					cmdFlags.SavePlan, cmdFlags.SavePlan)
 			}
 
-			// Init packages.
-			execx.NoTTY = cmdFlags.NoTTY
+			cmdEvent.AddSuccessMessage("apply configuration succeeded")
+			tracker.Track(cmdEvent)
 
-			// Validate base requirements.
-			if err := depsvl.ValidateBaseReqs(); err != nil {
-				cmdEvent.AddErrorMessage(err)
-				tracker.Track(cmdEvent)
+			return nil
+		},
+	}
 
-				return fmt.Errorf("error while validating requirements: %w", err)
-			}
+	setupApplyCmdFlags(applyCmd)
 
-			// Download the distribution.
-			logrus.Info("Downloading distribution...")
-			res, err := distrodl.Download(cmdFlags.DistroLocation, cmdFlags.FuryctlPath)
-			if err != nil {
-				cmdEvent.AddErrorMessage(err)
-				tracker.Track(cmdEvent)
+	return applyCmd
+}
 
-				return fmt.Errorf("error while downloading distribution: %w", err)
-			}
+// applyConfiguration downloads the distribution and the dependencies, validates the configuration and
+// runs the phases. When planning, the run must be a dry run: the phases record what they would do into
+// the returned report. With a plan file, the phases execute the saved plan. The timeline of the run is
+// written to a run report in the workdir.
+func applyConfiguration(
+	cmdFlags ClusterCmdFlags,
+	cmdEvent analytics.Event,
+	tracker *analytics.Tracker,
+	planning bool,
+) (_ *plan.Report, err error) {
+	var distrodl *dist.Downloader
+
+	logrus.Debugf("Using configuration file from path %s", cmdFlags.FuryctlPath)
+
+	// Init first half of collaborators.
+	client := netx.NewGoGetterClient()
+	executor := execx.NewStdExecutor()
+	depsvl := dependencies.NewValidator(executor, cmdFlags.BinPath, cmdFlags.FuryctlPath)
+
+	if cmdFlags.DistroLocation == "" {
+		distrodl = dist.NewCachingDownloader(client, cmdFlags.Outdir, cmdFlags.GitProtocol, cmdFlags.DistroPatchesLocation)
+	} else {
+		distrodl = dist.NewDownloader(client, cmdFlags.GitProtocol, cmdFlags.DistroPatchesLocation)
+	}
 
-			cmdEvent.AddClusterDetails(analytics.ClusterDetails{
-				Provider:   res.MinimalConf.Kind,
-				KFDVersion: res.DistroManifest.Version,
-				Phase:      cmdFlags.Phase,
-				DryRun:     cmdFlags.DryRun,
-			})
+	// Init packages.
+	execx.NoTTY = cmdFlags.NoTTY
 
-			lockFileHandler := lockfile.NewLockFile(res.MinimalConf.Metadata.Name)
-			sigs := make(chan os.Signal, 1)
+	// Validate base requirements.
+	if err := depsvl.ValidateBaseReqs(); err != nil {
+		cmdEvent.AddErrorMessage(err)
+		tracker.Track(cmdEvent)
 
-			go func() {
-				<-sigs
+		return nil, fmt.Errorf("error while validating requirements: %w", err)
+	}
 
-				if lockFileHandler != nil {
-					logrus.Debugf("Removing lock file %s", lockFileHandler.Path)
+	// Download the distribution.
+	logrus.Info("Downloading distribution...")
+	res, err := distrodl.Download(cmdFlags.DistroLocation, cmdFlags.FuryctlPath)
+	if err != nil {
+		cmdEvent.AddErrorMessage(err)
+		tracker.Track(cmdEvent)
 
-					if err := lockFileHandler.Remove(); err != nil {
-						logrus.Errorf("error while removing lock file %s: %v", lockFileHandler.Path, err)
-					}
-				}
+		return nil, fmt.Errorf("error while downloading distribution: %w", err)
+	}
 
-				os.Exit(1) //nolint:revive // ignore error
-			}()
+	cmdEvent.AddClusterDetails(analytics.ClusterDetails{
+		Provider:   res.MinimalConf.Kind,
+		KFDVersion: res.DistroManifest.Version,
+		Phase:      cmdFlags.Phase,
+		DryRun:     cmdFlags.DryRun,
+	})
 
-			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	lockInfo := lock.NewInfo()
+	clusterLock := lock.NewMulti(lock.NewLocal(res.MinimalConf.Metadata.Name, lockInfo))
+	sigs := make(chan os.Signal, 1)
 
-			err = lockFileHandler.Verify()
-			if err != nil {
-				cmdEvent.AddErrorMessage(err)
-				tracker.Track(cmdEvent)
+	go func() {
+		<-sigs
 
-				return fmt.Errorf("error while verifying lock file %s: %w", lockFileHandler.Path, err)
-			}
+		logrus.Debug("Releasing cluster lock...")
 
-			err = lockFileHandler.Create()
-			if err != nil {
-				cmdEvent.AddErrorMessage(err)
-				tracker.Track(cmdEvent)
+		if err := clusterLock.Release(); err != nil {
+			logrus.Errorf("error while releasing cluster lock: %v", err)
+		}
 
-				return fmt.Errorf("error while creating lock file %s: %w", lockFileHandler.Path, err)
-			}
-			defer lockFileHandler.Remove() //nolint:errcheck // ignore error
+		os.Exit(1) //nolint:revive // ignore error
+	}()
 
-			basePath := filepath.Join(cmdFlags.Outdir, ".furyctl", res.MinimalConf.Metadata.Name)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
-			// Init second half of collaborators.
-			depsdl := dependencies.NewCachingDownloader(client, cmdFlags.Outdir, basePath, cmdFlags.BinPath, cmdFlags.GitProtocol)
+	if err := clusterLock.Acquire(); err != nil {
+		cmdEvent.AddErrorMessage(err)
+		tracker.Track(cmdEvent)
 
-			// Validate the furyctl.yaml file.
-			logrus.Info("Validating configuration file...")
-			if err := config.Validate(cmdFlags.FuryctlPath, res.RepoPath); err != nil {
-				cmdEvent.AddErrorMessage(err)
-				tracker.Track(cmdEvent)
+		return nil, fmt.Errorf("error while locking cluster: %w", err)
+	}
+	defer clusterLock.Release() //nolint:errcheck // ignore error
 
-				return fmt.Errorf("error while validating configuration file: %w", err)
-			}
+	basePath := filepath.Join(cmdFlags.Outdir, ".furyctl", res.MinimalConf.Metadata.Name)
 
-			// The infrastructure and kubernetes phases copy the CA certificates and keys to the nodes.
-			// The check runs before the apply reaches Ansible. The other phases read no local PKI: an
-			// apply of the distribution phase alone must not ask for the CA keys.
-			switch {
-			case !phasesReadPKI(cmdFlags.Phase, cmdFlags.StartFrom, cmdFlags.PostApplyPhases):
-				logrus.Debug("The selected phases read no local PKI. The PKI folder check does not run")
-
-			case cmdFlags.DryRun:
-				// A dry run stops before Ansible, so it reads no CA file. The warning is necessary,
-				// because the folder can still stop the apply that comes after the dry run.
-				logrus.Warn("In dry run mode, the PKI folder check does not run. " +
-					"To check the folder, run `furyctl validate config`")
-
-			default:
-				if err := config.ValidatePKI(cmdFlags.FuryctlPath); err != nil {
-					cmdEvent.AddErrorMessage(err)
-					tracker.Track(cmdEvent)
+	runCommand := "apply"
+	if planning {
+		runCommand = "plan"
+	}
 
-					return fmt.Errorf("the PKI folder check failed: %w", err)
-				}
-			}
+	runReport := runreport.New(
+		runCommand,
+		basePath,
+		res.MinimalConf.Metadata.Name,
+		res.MinimalConf.Kind,
+		res.DistroManifest.Version,
+	)
+	runReport.Activate()
 
-			// Download the dependencies.
-			if !cmdFlags.SkipDepsDownload {
-				logrus.Info("Downloading dependencies...")
-				if errs, _ := depsdl.DownloadAll(res.DistroManifest, res.MinimalConf.Kind); len(errs) > 0 {
-					cmdEvent.AddErrorMessage(ErrDownloadDependenciesFailed)
-					tracker.Track(cmdEvent)
+	defer func() {
+		runReport.Close(err)
 
-					return fmt.Errorf("%w: %v", ErrDownloadDependenciesFailed, errs)
-				}
-			} else {
-				logrus.Info("Dependencies download skipped")
-			}
+		if cmdFlags.StoreRunReport && !cmdFlags.DryRun {
+			storeRunReport(runReport, cmdFlags, res, basePath)
+		}
+	}()
 
-			// Validate the dependencies, unless explicitly told to skip it.
-			if !cmdFlags.SkipDepsValidation {
-				logrus.Info("Validating dependencies...")
-				if err := depsvl.Validate(res); err != nil {
-					cmdEvent.AddErrorMessage(err)
-					tracker.Track(cmdEvent)
+	// Init second half of collaborators.
+	depsdl := dependencies.NewCachingDownloader(client, cmdFlags.Outdir, basePath, cmdFlags.BinPath, cmdFlags.GitProtocol)
+	if cluster.IsForceEnabledForFeature(cmdFlags.Force, cluster.ForceFeatureUnverifiedDownloads) {
+		depsdl.AllowUnverifiedDownloads()
+	}
 
-					return fmt.Errorf("error while validating dependencies: %w", err)
-				}
-			} else {
-				logrus.Info("Dependencies validation skipped")
-			}
+	// Validate the furyctl.yaml file.
+	logrus.Info("Validating configuration file...")
+	if err := config.Validate(cmdFlags.FuryctlPath, res.RepoPath); err != nil {
+		cmdEvent.AddErrorMessage(err)
+		tracker.Track(cmdEvent)
 
-			// Define cluster creation paths.
-			paths := cluster.CreatorPaths{
-				ConfigPath: cmdFlags.FuryctlPath,
-				WorkDir:    basePath,
-				DistroPath: res.RepoPath,
-				BinPath:    cmdFlags.BinPath,
-			}
+		return nil, fmt.Errorf("error while validating configuration file: %w", err)
+	}
+
+	// The infrastructure and kubernetes phases copy the CA certificates and keys to the nodes.
+	// The check runs before the apply reaches Ansible. The other phases read no local PKI: an
+	// apply of the distribution phase alone must not ask for the CA keys.
+	switch {
+	case !phasesReadPKI(cmdFlags.Phase, cmdFlags.StartFrom, cmdFlags.PostApplyPhases):
+		logrus.Debug("The selected phases read no local PKI. The PKI folder check does not run")
+
+	case cmdFlags.DryRun:
+		// A dry run stops before Ansible, so it reads no CA file. The warning is necessary,
+		// because the folder can still stop the apply that comes after the dry run.
+		logrus.Warn("In dry run mode, the PKI folder check does not run. " +
+			"To check the folder, run `furyctl validate config`")
+
+	default:
+		if err := config.ValidatePKI(cmdFlags.FuryctlPath); err != nil {
+			cmdEvent.AddErrorMessage(err)
+			tracker.Track(cmdEvent)
+
+			return nil, fmt.Errorf("the PKI folder check failed: %w", err)
+		}
+	}
+
+	// Download the dependencies.
+	if !cmdFlags.SkipDepsDownload {
+		logrus.Info("Downloading dependencies...")
+		if errs, _ := depsdl.DownloadAll(res.DistroManifest, res.MinimalConf.Kind); len(errs) > 0 {
+			cmdEvent.AddErrorMessage(ErrDownloadDependenciesFailed)
+			tracker.Track(cmdEvent)
+
+			return nil, fmt.Errorf("%w: %v", ErrDownloadDependenciesFailed, errs)
+		}
+	} else {
+		logrus.Info("Dependencies download skipped")
+	}
+
+	// Validate the dependencies, unless explicitly told to skip it.
+	if !cmdFlags.SkipDepsValidation {
+		logrus.Info("Validating dependencies...")
+		if err := depsvl.Validate(res); err != nil {
+			cmdEvent.AddErrorMessage(err)
+			tracker.Track(cmdEvent)
+
+			return nil, fmt.Errorf("error while validating dependencies: %w", err)
+		}
+	} else {
+		logrus.Info("Dependencies validation skipped")
+	}
+
+	// The cluster lock needs kubectl, that comes with the dependencies. A dry run does not write
+	// to the cluster, so it does not take the lock there either.
+	if cmdFlags.LockBackend == lock.BackendCluster && !cmdFlags.DryRun {
+		kubectlPath := filepath.Join(cmdFlags.BinPath, "kubectl", res.DistroManifest.Tools.Common.Kubectl.Version, "kubectl")
+
+		if err := clusterLock.Extend(lock.NewCluster(kubectlPath, lock.Kubeconfig(), "", lockInfo)); err != nil {
+			cmdEvent.AddErrorMessage(err)
+			tracker.Track(cmdEvent)
+
+			return nil, fmt.Errorf("error while locking cluster: %w", err)
+		}
+	}
+
+	// Define cluster creation paths.
+	paths := cluster.CreatorPaths{
+		ConfigPath: cmdFlags.FuryctlPath,
+		WorkDir:    basePath,
+		DistroPath: res.RepoPath,
+		BinPath:    cmdFlags.BinPath,
+	}
+
+	// Set debug mode.
+	execx.Debug = cmdFlags.Debug
+
+	// Create the cluster.
+	clusterCreator, err := cluster.NewCreator(
+		res.MinimalConf,
+		res.DistroManifest,
+		paths,
+		cmdFlags.Phase,
+		cmdFlags.SkipVpn,
+		cmdFlags.VpnAutoConnect,
+		cmdFlags.SkipNodesUpgrade,
+		cmdFlags.DryRun,
+		cmdFlags.Force,
+		cmdFlags.Upgrade,
+		cmdFlags.UpgradePathLocation,
+		cmdFlags.UpgradeNode,
+		cmdFlags.PostApplyPhases,
+		cmdFlags.EncryptionKey,
+		cmdFlags.StateBackend,
+		cmdFlags.StrictTemplates,
+	)
+	if err != nil {
+		cmdEvent.AddErrorMessage(err)
+		tracker.Track(cmdEvent)
+
+		return nil, fmt.Errorf("error while initializing cluster creation: %w", err)
+	}
+
+	clusterCreator.SetProperty(cluster.CreatorPropertyPolicyRules, cmdFlags.PolicyRules)
+
+	var planReport *plan.Report
+
+	if planning || cmdFlags.PlanFile != "" {
+		fp, err := planFingerprint(cmdFlags.FuryctlPath, res)
+		if err != nil {
+			cmdEvent.AddErrorMessage(err)
+			tracker.Track(cmdEvent)
+
+			return nil, err
+		}
+
+		if planning {
+			planReport = plan.New(res.MinimalConf.Metadata.Name, res.MinimalConf.Kind, res.DistroManifest.Version)
+			planReport.Phase = cmdFlags.Phase
+			planReport.Upgrade = cmdFlags.Upgrade
+			planReport.Fingerprint = fp
 
-			// Set debug mode.
-			execx.Debug = cmdFlags.Debug
-
-			// Create the cluster.
-			clusterCreator, err := cluster.NewCreator(
-				res.MinimalConf,
-				res.DistroManifest,
-				paths,
-				cmdFlags.Phase,
-				cmdFlags.SkipVpn,
-				cmdFlags.VpnAutoConnect,
-				cmdFlags.SkipNodesUpgrade,
-				cmdFlags.DryRun,
-				cmdFlags.Force,
-				cmdFlags.Upgrade,
-				cmdFlags.UpgradePathLocation,
-				cmdFlags.UpgradeNode,
-				cmdFlags.PostApplyPhases,
-			)
+			clusterCreator.SetProperty(cluster.CreatorPropertyPlanReport, planReport)
+		} else {
+			savedPlan, err := plan.Open(cmdFlags.PlanFile)
 			if err != nil {
 				cmdEvent.AddErrorMessage(err)
 				tracker.Track(cmdEvent)
 
-				return fmt.Errorf("error while initializing cluster creation: %w", err)
+				return nil, fmt.Errorf("error while reading plan file: %w", err)
 			}
 
-			if err := clusterCreator.Create(
-				cmdFlags.StartFrom,
-				cmdFlags.ProcessTimeout,
-				cmdFlags.PodRunningCheckTimeout,
-			); err != nil {
+			if err := savedPlan.Verify(fp, cmdFlags.Phase, cmdFlags.Upgrade); err != nil {
 				cmdEvent.AddErrorMessage(err)
 				tracker.Track(cmdEvent)
 
-				return fmt.Errorf("error while creating cluster: %w", err)
+				return nil, fmt.Errorf("cannot apply plan file: %w", err)
 			}
 
-			cmdEvent.AddSuccessMessage("apply configuration succeeded")
-			tracker.Track(cmdEvent)
+			clusterCreator.SetProperty(cluster.CreatorPropertySavedPlan, savedPlan)
+		}
+	}
 
-			return nil
-		},
+	if err := clusterCreator.Create(
+		cmdFlags.StartFrom,
+		cmdFlags.ProcessTimeout,
+		cmdFlags.PodRunningCheckTimeout,
+	); err != nil {
+		cmdEvent.AddErrorMessage(err)
+		tracker.Track(cmdEvent)
+
+		return nil, fmt.Errorf("error while creating cluster: %w", err)
 	}
 
-	setupApplyCmdFlags(applyCmd)
+	return planReport, nil
+}
 
-	return applyCmd
+// storeRunReport saves the run report in the cluster. The cluster can be unreachable after a failed
+// run, so a report that cannot be saved does not fail the apply.
+func storeRunReport(runReport *runreport.Report, cmdFlags ClusterCmdFlags, res dist.DownloadResult, basePath string) {
+	out, err := runReport.JSON()
+	if err != nil {
+		logrus.Warnf("error while saving the run report in the cluster: %v", err)
+
+		return
+	}
+
+	stateStore := state.NewStore(
+		res.RepoPath,
+		cmdFlags.FuryctlPath,
+		cmdFlags.EncryptionKey,
+		cmdFlags.StateBackend,
+	)
+
+	if err := stateStore.StoreRunReport(out); err != nil {
+		logrus.Warnf("error while saving the run report in the cluster: %v", err)
+	}
+}
+
+// planFingerprint identifies the configuration file and the distribution that a saved plan applies to.
+func planFingerprint(furyctlPath string, res dist.DownloadResult) (plan.Fingerprint, error) {
+	rendered, err := config.Render(furyctlPath)
+	if err != nil {
+		return plan.Fingerprint{}, fmt.Errorf("error while rendering configuration file: %w", err)
+	}
+
+	fp, err := plan.NewFingerprint(rendered, res.RepoPath, res.DistroManifest.Version)
+	if err != nil {
+		return plan.Fingerprint{}, fmt.Errorf("error while computing plan fingerprint: %w", err)
+	}
+
+	return fp, nil
 }
 
 func getSkipsClusterCmdFlags() ClusterSkipsCmdFlags {
@@ -335,6 +497,36 @@ func getSkipsClusterCmdFlags() ClusterSkipsCmdFlags {
 	}
 }
 
+func getClusterStateCmdFlags() (ClusterStateCmdFlags, error) {
+	var err error
+
+	policyDir := viper.GetString("policy-dir")
+	if policyDir != "" {
+		policyDir, err = filepath.Abs(policyDir)
+		if err != nil {
+			return ClusterStateCmdFlags{}, fmt.Errorf("error while getting absolute path for policies directory: %w", err)
+		}
+	}
+
+	// The policy rules are loaded once, before any work, so that an invalid rules file fails fast.
+	policyRules, err := policy.Load(policyDir)
+	if err != nil {
+		return ClusterStateCmdFlags{}, fmt.Errorf("%w: %s: %w", ErrParsingFlag, "policy-dir", err)
+	}
+
+	clusterState, err := flags.GetClusterStateFlagsFromViper()
+	if err != nil {
+		return ClusterStateCmdFlags{}, fmt.Errorf("%w: %w", ErrParsingFlag, err)
+	}
+
+	return ClusterStateCmdFlags{
+		EncryptionKey:   clusterState.EncryptionKey,
+		StateBackend:    clusterState.StateBackend,
+		PolicyRules:     policyRules,
+		StrictTemplates: clusterState.StrictTemplates,
+	}, nil
+}
+
 func getApplyCmdFlags() (ClusterCmdFlags, error) {
 	var err error
 
@@ -362,7 +554,7 @@ func getApplyCmdFlags() (ClusterCmdFlags, error) {
 		}
 	}
 
-	furyctlPath := viper.GetString("config")
+	furyctlPath := flags.GetComposedConfigPathFromViper()
 
 	if furyctlPath == "" {
 		return ClusterCmdFlags{}, fmt.Errorf("%w --config: cannot be an empty string", ErrParsingFlag)
@@ -432,6 +624,29 @@ func getApplyCmdFlags() (ClusterCmdFlags, error) {
 		return ClusterCmdFlags{}, fmt.Errorf("%w: %s %w", ErrParsingFlag, "post-apply-phases", err)
 	}
 
+	dryRun := viper.GetBool("dry-run")
+
+	savePlan, planFile, err := getPlanFileFlags(dryRun, startFrom, postApplyPhases)
+	if err != nil {
+		return ClusterCmdFlags{}, err
+	}
+
+	// Saving a plan is a dry run that records what the apply would do.
+	if savePlan != "" {
+		dryRun = true
+	}
+
+	lockBackend := viper.GetString("lock-backend")
+
+	if !slices.Contains(lock.Backends(), lockBackend) {
+		return ClusterCmdFlags{}, fmt.Errorf("%w: %s: %w", ErrParsingFlag, "lock-backend", lock.ErrUnsupportedBackend)
+	}
+
+	clusterState, err := getClusterStateCmdFlags()
+	if err != nil {
+		return ClusterCmdFlags{}, err
+	}
+
 	return ClusterCmdFlags{
 		Debug:          viper.GetBool("debug"),
 		FuryctlPath:    furyctlPath,
@@ -440,7 +655,7 @@ func getApplyCmdFlags() (ClusterCmdFlags, error) {
 		StartFrom:      startFrom,
 		BinPath:        binPath,
 		VpnAutoConnect: vpnAutoConnect,
-		DryRun:         viper.GetBool("dry-run"),
+		DryRun:         dryRun,
 		NoTTY:          viper.GetBool("no-tty"),
 		Force:          viper.GetStringSlice("force"),
 		GitProtocol:    typedGitProtocol,
@@ -455,9 +670,58 @@ func getApplyCmdFlags() (ClusterCmdFlags, error) {
 		DistroPatchesLocation: distroPatchesLocation,
 		ClusterSkipsCmdFlags:  skips,
 		PostApplyPhases:       postApplyPhases,
+		LockBackend:           lockBackend,
+		SavePlan:              savePlan,
+		PlanFile:              planFile,
+		StoreRunReport:        viper.GetBool("store-run-report"),
+		ClusterStateCmdFlags:  clusterState,
 	}, nil
 }
 
+// getPlanFileFlags reads the save-plan and plan-file flags. A saved plan covers all the phases that the
+// apply runs in one go, so both refuse start-from and post-apply-phases.
+func getPlanFileFlags(dryRun bool, startFrom string, postApplyPhases []string) (string, string, error) {
+	var err error
+
+	savePlan := viper.GetString("save-plan")
+	planFile := viper.GetString("plan-file")
+
+	if savePlan == "" && planFile == "" {
+		return "", "", nil
+	}
+
+	if savePlan != "" && planFile != "" {
+		return "", "", fmt.Errorf("%w: save-plan and plan-file cannot be used at the same time", ErrParsingFlag)
+	}
+
+	if planFile != "" && dryRun {
+		return "", "", fmt.Errorf("%w: %s: cannot use together with dry-run flag", ErrParsingFlag, "plan-file")
+	}
+
+	if startFrom != "" || len(postApplyPhases) > 0 {
+		return "", "", fmt.Errorf(
+			"%w: save-plan and plan-file cannot be used together with start-from or post-apply-phases flags",
+			ErrParsingFlag,
+		)
+	}
+
+	if savePlan != "" {
+		savePlan, err = filepath.Abs(savePlan)
+		if err != nil {
+			return "", "", fmt.Errorf("error while getting absolute path of plan file: %w", err)
+		}
+	}
+
+	if planFile != "" {
+		planFile, err = filepath.Abs(planFile)
+		if err != nil {
+			return "", "", fmt.Errorf("error while getting absolute path of plan file: %w", err)
+		}
+	}
+
+	return savePlan, planFile, nil
+}
+
 func validatePostApplyPhasesFlag(phases []string) error {
 	for _, phase := range phases {
 		if err := cluster.ValidateMainPhases(phase); err != nil {
@@ -613,7 +877,9 @@ func setupApplyCmdFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSlice(
 		"force",
 		[]string{},
-		"WARNING: furyctl won't ask for confirmation and will proceed applying upgrades and migrations. Options are: all, upgrades, migrations, pods-running-check",
+		"WARNING: furyctl won't ask for confirmation and will proceed applying upgrades and migrations. Options are: all, upgrades, migrations, pods-running-check, "+
+			"unverified-downloads. 'all' does not include unverified-downloads, which lets the tools that do not match "+
+			"their pinned checksums through",
 	)
 
 	if err := cmd.RegisterFlagCompletionFunc("force", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
@@ -621,6 +887,7 @@ func setupApplyCmdFlags(cmd *cobra.Command) {
 			cluster.ForceFeatureAll,
 			cluster.ForceFeatureMigrations,
 			cluster.ForceFeaturePodsRunningCheck,
+			cluster.ForceFeatureUnverifiedDownloads,
 			cluster.ForceFeatureUpgrades,
 		}, cobra.ShellCompDirectiveDefault
 	}); err != nil {
@@ -684,4 +951,39 @@ func setupApplyCmdFlags(cmd *cobra.Command) {
 		"",
 		"On kind OnPremises, this will upgrade one specific node passed as parameter",
 	)
+
+	cmd.Flags().String(
+		"lock-backend",
+		lock.BackendLocal,
+		"Where furyctl locks the cluster while it runs. Options are: "+strings.Join(lock.Backends(), ", ")+". "+
+			"With cluster, furyctl also holds a Lease in the kube-system namespace of the cluster, "+
+			"so that operators on other machines cannot run at the same time",
+	)
+
+	if err := cmd.RegisterFlagCompletionFunc("lock-backend", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return lock.Backends(), cobra.ShellCompDirectiveDefault
+	}); err != nil {
+		logrus.Fatalf("error while registering flag completion: %v", err)
+	}
+
+	cmd.Flags().String(
+		"save-plan",
+		"",
+		"Run the apply in dry-run mode and save what it would do, with the Terraform plans and the rendered manifests, "+
+			"to a tar.gz archive that --plan-file can apply after review",
+	)
+
+	cmd.Flags().String(
+		"plan-file",
+		"",
+		"Apply a plan saved with --save-plan. The apply refuses the plan if the configuration file, "+
+			"the distribution or the configuration stored in the cluster changed since it was saved",
+	)
+
+	cmd.Flags().Bool(
+		"store-run-report",
+		false,
+		"Save the run report, the timeline of the phases and of the commands they ran, in the cluster "+
+			"as the furyctl-run-report secret in the kube-system namespace, next to furyctl-config",
+	)
 }
@@ -7,11 +7,21 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/lock"
+	"github.com/sighupio/furyctl/internal/policy"
+	"github.com/sighupio/furyctl/internal/state/backend"
 )
 
 func TestPhasesReadPKI(t *testing.T) {
@@ -139,3 +149,77 @@ func TestPhasesReadPKI(t *testing.T) {
 		})
 	}
 }
+
+//nolint:paralleltest // the flags are read from the global viper.
+func TestPlanAndDriftReadTheClusterStateFlags(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	encryptionKey := strings.Repeat("ab", 32)
+	policyDir := t.TempDir()
+	stateDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(policyDir, "rules.yaml"), []byte(`rules:
+  - name: mandatory-tags
+    config: has(config.spec.tags)
+`), 0o600))
+
+	viper.Set("config", "furyctl.yaml")
+	viper.Set("git-protocol", "https")
+	viper.Set("output", planOutputText)
+	viper.Set("lock-backend", lock.BackendLocal)
+	viper.Set("encryption-key", encryptionKey)
+	viper.Set("state-backend", backend.TypeLocal)
+	viper.Set("state-dir", stateDir)
+	viper.Set("policy-dir", policyDir)
+	viper.Set("strict-templates", []string{cluster.OperationPhasePlugins})
+	viper.Set("strict-templates-allow", []string{"spec.tags"})
+
+	wantKey, err := encryption.ParseKey([]byte(encryptionKey))
+	require.NoError(t, err)
+
+	wantStrictTemplates := cluster.StrictTemplates{
+		Phases: []string{cluster.OperationPhasePlugins},
+		Allow:  []string{"spec.tags"},
+	}
+
+	assertClusterStateFlags := func(t *testing.T, got ClusterStateCmdFlags) {
+		t.Helper()
+
+		assert.Equal(t, wantKey, got.EncryptionKey)
+		assert.Equal(t, backend.Config{Type: backend.TypeLocal, Dir: stateDir}, got.StateBackend)
+		assert.Equal(t, wantStrictTemplates, got.StrictTemplates)
+		// The compiled rules cannot be compared, their names can.
+		assert.Equal(t, []string{"mandatory-tags"}, lo.Map(got.PolicyRules, func(r policy.Rule, _ int) string {
+			return r.Name
+		}))
+	}
+
+	applyFlags, err := getApplyCmdFlags()
+	require.NoError(t, err)
+	assertClusterStateFlags(t, applyFlags.ClusterStateCmdFlags)
+
+	planFlags, err := getPlanCmdFlags()
+	require.NoError(t, err)
+	assertClusterStateFlags(t, planFlags.ClusterStateCmdFlags)
+
+	driftFlags, err := getDriftCmdFlags()
+	require.NoError(t, err)
+	assertClusterStateFlags(t, driftFlags.ClusterStateCmdFlags)
+}
+
+//nolint:paralleltest // the flags are read from the global viper.
+func TestClusterStateFlagsFailOnInvalidPolicyRules(t *testing.T) {
+	t.Cleanup(viper.Reset)
+
+	policyDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(policyDir, "rules.yaml"), []byte(`rules:
+  - name: broken
+    config: config.spec.(
+`), 0o600))
+
+	viper.Set("policy-dir", policyDir)
+
+	_, err := getClusterStateCmdFlags()
+	require.ErrorIs(t, err, ErrParsingFlag)
+}
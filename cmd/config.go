@@ -0,0 +1,22 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sighupio/furyctl/cmd/config"
+)
+
+func NewConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Work with the configuration file",
+	}
+
+	configCmd.AddCommand(config.NewMigrateCmd())
+
+	return configCmd
+}
@@ -0,0 +1,286 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/configs"
+	"github.com/sighupio/furyctl/internal/analytics"
+	distroconf "github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/compose"
+	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/git"
+	"github.com/sighupio/furyctl/internal/migrate"
+	"github.com/sighupio/furyctl/internal/semver"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+	logrusx "github.com/sighupio/furyctl/internal/x/logrus"
+	dist "github.com/sighupio/furyctl/pkg/distribution"
+	netx "github.com/sighupio/furyctl/pkg/x/net"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+var ErrParsingFlag = errors.New("error while parsing flag")
+
+func NewMigrateCmd() *cobra.Command {
+	var (
+		cmdEvent    analytics.Event
+		furyctlPath string
+	)
+
+	migrateCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "migrate",
+		Short: "Rewrite the configuration file for another distribution version",
+		Long: `Rewrite the configuration file for another distribution version: move, rename, delete and set its fields as the migration steps of each upgrade path from its distribution version to the target one ask, and set its distribution version to the target one.
+The upgrade paths are the ones that "furyctl get upgrade-paths" shows. The migration steps of an upgrade path are the ones that the distribution of the target version ships in migrations/<kind>/<from>-<to>.yaml, or the ones of furyctl, in the migration.yaml file of the folder of the upgrade path.
+The comments and the order of the fields of the configuration file are kept.`,
+		Example: `  furyctl config migrate --to v1.31.1             rewrite furyctl.yaml for the distribution version v1.31.1
+  furyctl config migrate --to v1.31.1 --dry-run   print the changes and the rewritten configuration, without writing it
+ `,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+			// Bind the flags first: a flag on the command line has precedence over the configuration file.
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				logrus.Fatalf("error while binding flags: %v", err)
+			}
+
+			// The migration rewrites the configuration file of --config itself, not the composed one.
+			furyctlPath = viper.GetString("config")
+
+			if err := flags.LoadAndMergeCommandFlags("config"); err != nil {
+				logrus.Fatalf("failed to load flags from configuration: %v", err)
+			}
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			dryRun := viper.GetBool("dry-run")
+
+			if dryRun {
+				// The logs must not mix with the configuration.
+				logrusx.RedirectStdout(os.Stderr)
+			}
+
+			typedGitProtocol, err := git.ParseProtocol(viper.GetString("git-protocol"))
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("%w: %w", ErrParsingFlag, err)
+			}
+
+			distroLocation := viper.GetString("distro-location")
+
+			var distrodl *dist.Downloader
+
+			client := netx.NewGoGetterClient()
+
+			if distroLocation == "" {
+				distrodl = dist.NewCachingDownloader(client, viper.GetString("outdir"), typedGitProtocol, "")
+			} else {
+				distrodl = dist.NewDownloader(client, typedGitProtocol, "")
+			}
+
+			if err := migrateConfig(
+				furyctlPath,
+				viper.GetString("to"),
+				viper.GetString("upgrade-path-location"),
+				distrodl,
+				distroLocation,
+				dryRun,
+			); err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			cmdEvent.AddSuccessMessage("configuration file migrated successfully")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	migrateCmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file",
+	)
+
+	migrateCmd.Flags().String(
+		"to",
+		"",
+		"Distribution version to migrate the configuration file to (eg. v1.31.1)",
+	)
+
+	migrateCmd.Flags().String(
+		"upgrade-path-location",
+		"",
+		"Location where the upgrade paths and their migration steps are located, if not set the embedded ones will be used",
+	)
+
+	migrateCmd.Flags().String(
+		"distro-location",
+		"",
+		"Location where to download the distribution of the target version from, with its migration steps. "+
+			"It can either be a local path(eg: /path/to/distribution) or "+
+			"a remote URL(eg: git::git@github.com:sighupio/distribution?depth=1&ref=BRANCH_NAME)."+
+			"Any format supported by hashicorp/go-getter can be used",
+	)
+
+	migrateCmd.Flags().Bool(
+		"dry-run",
+		false,
+		"Print the changes and the rewritten configuration file without writing it",
+	)
+
+	if err := migrateCmd.MarkFlagRequired("to"); err != nil {
+		logrus.Fatalf("error while marking flag as required: %v", err)
+	}
+
+	return migrateCmd
+}
+
+// migrateConfig rewrites the configuration file at path for the distribution version to, with the migration
+// steps of the distribution of that version, that distrodl downloads, and the ones of the upgrade paths.
+func migrateConfig(
+	path,
+	to,
+	upgradePathLocation string,
+	distrodl *dist.Downloader,
+	distroLocation string,
+	dryRun bool,
+) error {
+	if _, err := semver.NewVersion(to); err != nil {
+		return fmt.Errorf("%w: to: %w", ErrParsingFlag, err)
+	}
+
+	furyctlConf, err := yamlx.FromFileV3[distroconf.Furyctl](path)
+	if err != nil {
+		return fmt.Errorf("error while reading configuration file: %w", err)
+	}
+
+	if _, err := distribution.ValidateConfigKind(furyctlConf.Kind); err != nil {
+		return fmt.Errorf("error while validating kind: %w", err)
+	}
+
+	upgrades, err := upgradesFS(upgradePathLocation)
+	if err != nil {
+		return err
+	}
+
+	if semver.EnsureNoPrefix(furyctlConf.Spec.DistributionVersion) == semver.EnsureNoPrefix(to) {
+		logrus.Infof("The configuration file is already for version %s, nothing to migrate", to)
+
+		return nil
+	}
+
+	// The distribution of the target version ships the migration steps to it.
+	targetConf := furyctlConf
+	targetConf.Spec.DistributionVersion = semver.EnsurePrefix(to)
+
+	logrus.Info("Downloading distribution...")
+
+	res, err := distrodl.DoDownload(distroLocation, targetConf)
+	if err != nil {
+		return fmt.Errorf("error while downloading distribution: %w", err)
+	}
+
+	migrations, err := migrate.Plan(
+		upgrades,
+		os.DirFS(res.RepoPath),
+		furyctlConf.Kind,
+		furyctlConf.Spec.DistributionVersion,
+		to,
+	)
+	if err != nil {
+		return fmt.Errorf("error while planning the migration: %w", err)
+	}
+
+	if composed, err := compose.IsComposed(path); err == nil && composed {
+		logrus.Warn("The configuration file extends base files or has overlays: only the file itself is migrated")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error while reading configuration file: %w", err)
+	}
+
+	out, results, err := migrate.Apply(content, migrations)
+	if err != nil {
+		return fmt.Errorf("error while migrating configuration file: %w", err)
+	}
+
+	for _, r := range results {
+		if len(r.Changes) == 0 {
+			logrus.Infof("Migration from %s to %s: no changes", r.From, r.To)
+
+			continue
+		}
+
+		logrus.Infof("Migration from %s to %s:", r.From, r.To)
+
+		for _, c := range r.Changes {
+			logrus.Infof("  %s", c)
+		}
+	}
+
+	if dryRun {
+		fmt.Print(string(out))
+
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("error while reading configuration file: %w", err)
+	}
+
+	if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("error while writing configuration file: %w", err)
+	}
+
+	logrus.Infof("Configuration file %s migrated to version %s", path, semver.EnsurePrefix(to))
+
+	return nil
+}
+
+// upgradesFS returns the upgrade paths in the location, or the embedded ones.
+func upgradesFS(location string) (fs.FS, error) {
+	if location == "" {
+		upgrades, err := fs.Sub(configs.Tpl, "upgrades")
+		if err != nil {
+			return nil, fmt.Errorf("error getting subfs: %w", err)
+		}
+
+		return upgrades, nil
+	}
+
+	location, err := filepath.Abs(location)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting absolute path of upgrade path location: %w", err)
+	}
+
+	return os.DirFS(location), nil
+}
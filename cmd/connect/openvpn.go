@@ -58,7 +58,7 @@ func NewOpenVPNCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
 			logrus.Info("Connecting to OpenVPN...")
 
@@ -141,7 +141,7 @@ func NewOpenVPNCmd() *cobra.Command {
 func getOpenVPNCmdFlags() OpenVPNCmdFlags {
 	return OpenVPNCmdFlags{
 		Profile:     viper.GetString("profile"),
-		FuryctlPath: viper.GetString("config"),
+		FuryctlPath: flags.GetComposedConfigPathFromViper(),
 		Outdir:      viper.GetString("outdir"),
 	}
 }
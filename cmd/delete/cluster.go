@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 
@@ -20,18 +21,24 @@ import (
 
 	"github.com/sighupio/furyctl/internal/airgap"
 	"github.com/sighupio/furyctl/internal/analytics"
+	distroconf "github.com/sighupio/furyctl/internal/apis/config"
 	"github.com/sighupio/furyctl/internal/app"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/config"
+	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/encryption"
 	"github.com/sighupio/furyctl/internal/flags"
 	"github.com/sighupio/furyctl/internal/git"
-	"github.com/sighupio/furyctl/internal/lockfile"
+	"github.com/sighupio/furyctl/internal/lock"
+	"github.com/sighupio/furyctl/internal/runreport"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
 	iox "github.com/sighupio/furyctl/internal/x/io"
 	"github.com/sighupio/furyctl/pkg/dependencies"
 	dist "github.com/sighupio/furyctl/pkg/distribution"
 	netx "github.com/sighupio/furyctl/pkg/x/net"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
 type ClusterCmdFlags struct {
@@ -50,6 +57,10 @@ type ClusterCmdFlags struct {
 	SkipDepsDownload      bool
 	SkipDepsValidation    bool
 	DistroPatchesLocation string
+	LockBackend           string
+	EncryptionKey         encryption.Key
+	StateBackend          backend.Config
+	StrictTemplates       cluster.StrictTemplates
 }
 
 var (
@@ -76,11 +87,11 @@ func NewClusterCmd() *cobra.Command {
 				logrus.Fatalf("%v", err)
 			}
 		},
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(_ *cobra.Command, _ []string) (err error) {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
 			// Air-gapped: extract --airgap-bundle (if set) and rewire to run offline before reading flags.
 			if err := airgap.MaybePrepare(); err != nil {
@@ -142,6 +153,15 @@ func NewClusterCmd() *cobra.Command {
 				return err
 			}
 
+			if flags.Phase == cluster.OperationPhasePlugins && !slices.Contains(deletePhases(res.MinimalConf.Kind), flags.Phase) {
+				err := fmt.Errorf("%w: phase: %s is not supported for the %s kind", ErrParsingFlag, flags.Phase, res.MinimalConf.Kind)
+
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
 			cmdEvent.AddClusterDetails(analytics.ClusterDetails{
 				Provider:   res.MinimalConf.Kind,
 				KFDVersion: res.DistroManifest.Version,
@@ -149,18 +169,17 @@ func NewClusterCmd() *cobra.Command {
 				DryRun:     flags.DryRun,
 			})
 
-			lockFileHandler := lockfile.NewLockFile(res.MinimalConf.Metadata.Name)
+			lockInfo := lock.NewInfo()
+			clusterLock := lock.NewMulti(lock.NewLocal(res.MinimalConf.Metadata.Name, lockInfo))
 			sigs := make(chan os.Signal, 1)
 
 			go func() {
 				<-sigs
 
-				if lockFileHandler != nil {
-					logrus.Debugf("Removing lock file %s", lockFileHandler.Path)
+				logrus.Debug("Releasing cluster lock...")
 
-					if err := lockFileHandler.Remove(); err != nil {
-						logrus.Errorf("error while removing lock file %s: %v", lockFileHandler.Path, err)
-					}
+				if err := clusterLock.Release(); err != nil {
+					logrus.Errorf("error while releasing cluster lock: %v", err)
 				}
 
 				os.Exit(1) //nolint:revive // deep-exit acceptable in signal handler
@@ -168,24 +187,26 @@ func NewClusterCmd() *cobra.Command {
 
 			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
-			err = lockFileHandler.Verify()
-			if err != nil {
+			if err := clusterLock.Acquire(); err != nil {
 				cmdEvent.AddErrorMessage(err)
 				tracker.Track(cmdEvent)
 
-				return fmt.Errorf("error while verifying lock file %s: %w", lockFileHandler.Path, err)
+				return fmt.Errorf("error while locking cluster: %w", err)
 			}
+			defer clusterLock.Release() //nolint:errcheck // ignore error
 
-			err = lockFileHandler.Create()
-			if err != nil {
-				cmdEvent.AddErrorMessage(err)
-				tracker.Track(cmdEvent)
+			basePath := filepath.Join(outDir, ".furyctl", res.MinimalConf.Metadata.Name)
 
-				return fmt.Errorf("error while creating lock file %s: %w", lockFileHandler.Path, err)
-			}
-			defer lockFileHandler.Remove() //nolint:errcheck // ignore error
+			runReport := runreport.New(
+				"delete-cluster",
+				basePath,
+				res.MinimalConf.Metadata.Name,
+				res.MinimalConf.Kind,
+				res.DistroManifest.Version,
+			)
+			runReport.Activate()
 
-			basePath := filepath.Join(outDir, ".furyctl", res.MinimalConf.Metadata.Name)
+			defer func() { runReport.Close(err) }()
 
 			// Init second half of collaborators.
 			depsdl := dependencies.NewCachingDownloader(client, outDir, basePath, flags.BinPath, flags.GitProtocol)
@@ -225,6 +246,19 @@ func NewClusterCmd() *cobra.Command {
 				logrus.Info("Dependencies validation skipped")
 			}
 
+			// The cluster lock needs kubectl, that comes with the dependencies. A dry run does not write
+			// to the cluster, so it does not take the lock there either.
+			if flags.LockBackend == lock.BackendCluster && !flags.DryRun {
+				kubectlPath := filepath.Join(flags.BinPath, "kubectl", res.DistroManifest.Tools.Common.Kubectl.Version, "kubectl")
+
+				if err := clusterLock.Extend(lock.NewCluster(kubectlPath, lock.Kubeconfig(), "", lockInfo)); err != nil {
+					cmdEvent.AddErrorMessage(err)
+					tracker.Track(cmdEvent)
+
+					return fmt.Errorf("error while locking cluster: %w", err)
+				}
+			}
+
 			// Define cluster deletion paths.
 			paths := cluster.DeleterPaths{
 				ConfigPath: flags.FuryctlPath,
@@ -244,6 +278,9 @@ func NewClusterCmd() *cobra.Command {
 				flags.SkipVpn,
 				flags.VpnAutoConnect,
 				flags.DryRun,
+				flags.EncryptionKey,
+				flags.StateBackend,
+				flags.StrictTemplates,
 			)
 			if err != nil {
 				cmdEvent.AddErrorMessage(err)
@@ -335,19 +372,17 @@ func NewClusterCmd() *cobra.Command {
 		"phase",
 		"p",
 		"",
-		"Limit execution to a specific phase. Options are: "+strings.Join([]string{
-			cluster.OperationPhaseInfrastructure,
-			cluster.OperationPhaseKubernetes,
-			cluster.OperationPhaseDistribution,
-		}, ", "),
+		"Limit execution to a specific phase. Options are: "+strings.Join(deletePhases(""), ", ")+
+			", and "+cluster.OperationPhasePlugins+" for the "+distribution.ImmutableKind+" kind",
 	)
 
-	if err := clusterCmd.RegisterFlagCompletionFunc("phase", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
-		return []string{
-			cluster.OperationPhaseInfrastructure,
-			cluster.OperationPhaseKubernetes,
-			cluster.OperationPhaseDistribution,
-		}, cobra.ShellCompDirectiveDefault
+	if err := clusterCmd.RegisterFlagCompletionFunc("phase", func(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		furyctlConf, err := yamlx.FromFileV3[distroconf.Furyctl](cmd.Flag("config").Value.String())
+		if err != nil {
+			return deletePhases(""), cobra.ShellCompDirectiveDefault
+		}
+
+		return deletePhases(furyctlConf.Kind), cobra.ShellCompDirectiveDefault
 	}); err != nil {
 		logrus.Fatalf("error while registering flag completion: %v", err)
 	}
@@ -391,6 +426,20 @@ func NewClusterCmd() *cobra.Command {
 		"Skip validating dependencies",
 	)
 
+	clusterCmd.Flags().String(
+		"lock-backend",
+		lock.BackendLocal,
+		"Where furyctl locks the cluster while it runs. Options are: "+strings.Join(lock.Backends(), ", ")+". "+
+			"With cluster, furyctl also holds a Lease in the kube-system namespace of the cluster, "+
+			"so that operators on other machines cannot run at the same time",
+	)
+
+	if err := clusterCmd.RegisterFlagCompletionFunc("lock-backend", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return lock.Backends(), cobra.ShellCompDirectiveDefault
+	}); err != nil {
+		logrus.Fatalf("error while registering flag completion: %v", err)
+	}
+
 	return clusterCmd
 }
 
@@ -419,7 +468,7 @@ func getDeleteClusterCmdFlags() (ClusterCmdFlags, error) {
 		}
 	}
 
-	furyctlPath := viper.GetString("config")
+	furyctlPath := flags.GetComposedConfigPathFromViper()
 
 	if furyctlPath == "" {
 		return ClusterCmdFlags{}, fmt.Errorf("%w --config: cannot be an empty string", ErrParsingFlag)
@@ -453,6 +502,18 @@ func getDeleteClusterCmdFlags() (ClusterCmdFlags, error) {
 		return ClusterCmdFlags{}, fmt.Errorf("%w: %w", ErrParsingFlag, err)
 	}
 
+	lockBackend := viper.GetString("lock-backend")
+
+	if !slices.Contains(lock.Backends(), lockBackend) {
+		return ClusterCmdFlags{}, fmt.Errorf("%w: %s: %w", ErrParsingFlag, "lock-backend", lock.ErrUnsupportedBackend)
+	}
+
+	// The same flags of the state of the cluster as apply.
+	clusterState, err := flags.GetClusterStateFlagsFromViper()
+	if err != nil {
+		return ClusterCmdFlags{}, fmt.Errorf("%w: %w", ErrParsingFlag, err)
+	}
+
 	return ClusterCmdFlags{
 		Debug:                 viper.GetBool("debug"),
 		FuryctlPath:           furyctlPath,
@@ -469,5 +530,25 @@ func getDeleteClusterCmdFlags() (ClusterCmdFlags, error) {
 		SkipDepsDownload:      viper.GetBool("skip-deps-download"),
 		SkipDepsValidation:    viper.GetBool("skip-deps-validation"),
 		DistroPatchesLocation: distroPatchesLocation,
+		LockBackend:           lockBackend,
+		EncryptionKey:         clusterState.EncryptionKey,
+		StateBackend:          clusterState.StateBackend,
+		StrictTemplates:       clusterState.StrictTemplates,
 	}, nil
 }
+
+// deletePhases returns the phases that the cluster of the kind can delete one by one, the ones of all the kinds
+// when the kind is empty: only the Immutable kind deletes the plugins by themselves.
+func deletePhases(kind string) []string {
+	phases := []string{
+		cluster.OperationPhaseInfrastructure,
+		cluster.OperationPhaseKubernetes,
+		cluster.OperationPhaseDistribution,
+	}
+
+	if kind == distribution.ImmutableKind {
+		phases = append(phases, cluster.OperationPhasePlugins)
+	}
+
+	return phases
+}
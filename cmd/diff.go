@@ -5,8 +5,12 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/r3labs/diff/v3"
@@ -17,19 +21,39 @@ import (
 	"github.com/sighupio/furyctl/internal/airgap"
 	"github.com/sighupio/furyctl/internal/analytics"
 	"github.com/sighupio/furyctl/internal/apis/config"
+	eksupported "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/ekscluster/supported"
+	immsupported "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/supported"
+	distrosupported "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/kfddistribution/supported"
+	premsupported "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/onpremises/supported"
 	"github.com/sighupio/furyctl/internal/app"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/encryption"
 	"github.com/sighupio/furyctl/internal/flags"
 	"github.com/sighupio/furyctl/internal/git"
+	parserx "github.com/sighupio/furyctl/internal/parser"
+	"github.com/sighupio/furyctl/internal/redact"
 	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
+	logrusx "github.com/sighupio/furyctl/internal/x/logrus"
 	"github.com/sighupio/furyctl/pkg/diffs"
 	dist "github.com/sighupio/furyctl/pkg/distribution"
+	rules "github.com/sighupio/furyctl/pkg/rulesextractor"
 	netx "github.com/sighupio/furyctl/pkg/x/net"
 	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
+const (
+	diffOutputText    = "text"
+	diffOutputJSON    = "json"
+	diffOutputYAML    = "yaml"
+	diffOutputUnified = "unified"
+)
+
+var ErrInvalidDiffOutput = errors.New("invalid output format, supported values are: text, json, yaml, unified")
+
 type DiffCommandFlags struct {
 	Debug                 bool
 	FuryctlPath           string
@@ -41,6 +65,9 @@ type DiffCommandFlags struct {
 	Outdir                string
 	UpgradePathLocation   string
 	DistroPatchesLocation string
+	Output                string
+	EncryptionKey         encryption.Key
+	StateBackend          backend.Config
 }
 
 func NewDiffCmd() *cobra.Command {
@@ -50,6 +77,10 @@ func NewDiffCmd() *cobra.Command {
 		Args:  cobra.NoArgs,
 		Use:   "diff",
 		Short: "Diff the current configuration with the one in the cluster",
+		Example: `  furyctl diff                     print the changes as a list of paths
+  furyctl diff --output json       print the changes with their phase and the immutable and unsupported verdicts
+  furyctl diff --output unified    print a unified diff of the configuration in the cluster and the new one
+ `,
 		PreRun: func(cmd *cobra.Command, _ []string) {
 			cmdEvent = analytics.NewCommandEvent(cobrax.GetFullname(cmd))
 
@@ -86,6 +117,11 @@ func NewDiffCmd() *cobra.Command {
 
 			execx.Debug = flags.Debug
 
+			// The logs must not mix with a machine-readable output.
+			if flags.Output == diffOutputJSON || flags.Output == diffOutputYAML {
+				logrusx.RedirectStdout(os.Stderr)
+			}
+
 			client := netx.NewGoGetterClient()
 
 			distrodl := dist.NewDownloader(client, flags.GitProtocol, flags.DistroPatchesLocation)
@@ -108,9 +144,8 @@ func NewDiffCmd() *cobra.Command {
 			stateStore := state.NewStore(
 				res.RepoPath,
 				flags.FuryctlPath,
-				basePath,
-				res.DistroManifest.Tools.Common.Kubectl.Version,
-				flags.BinPath,
+				flags.EncryptionKey,
+				flags.StateBackend,
 			)
 
 			diffChecker, err := createDiffChecker(stateStore, flags.FuryctlPath)
@@ -146,13 +181,19 @@ func NewDiffCmd() *cobra.Command {
 				return fmt.Errorf("error while getting diffs: %w", err)
 			}
 
-			if len(d) > 0 {
-				fmt.Printf(
-					"Differences found from previous cluster configuration:\n%s",
-					diffChecker.DiffToString(d),
-				)
-			} else {
-				logrus.Info("No differences found from previous cluster configuration")
+			if err := printDiffs(
+				diffChecker,
+				d,
+				flags.Output,
+				phasePath,
+				!flags.NoTTY,
+				res.MinimalConf.Kind,
+				res.RepoPath,
+			); err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while printing diffs: %w", err)
 			}
 
 			cmdEvent.AddSuccessMessage("diff command executed successfully")
@@ -218,11 +259,132 @@ func NewDiffCmd() *cobra.Command {
 		"Location where the upgrade scripts are located, if not set the embedded ones will be used",
 	)
 
+	diffCmd.Flags().String(
+		"output",
+		diffOutputText,
+		"Output format. Options are: "+strings.Join(diffOutputs(), ", ")+". "+
+			"json and yaml print a changelog with the path, type, old and new value, owning phase and "+
+			"the immutable and unsupported verdicts of each change. unified prints a unified diff of the YAML "+
+			"configuration in the cluster and the new one",
+	)
+
+	if err := diffCmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return diffOutputs(), cobra.ShellCompDirectiveDefault
+	}); err != nil {
+		logrus.Fatalf("error while registering flag completion: %v", err)
+	}
+
 	airgap.RegisterFlags(diffCmd)
 
 	return diffCmd
 }
 
+func diffOutputs() []string {
+	return []string{diffOutputText, diffOutputJSON, diffOutputYAML, diffOutputUnified}
+}
+
+//nolint:revive // colored is a boolean flag
+func printDiffs(
+	diffChecker diffs.Checker,
+	d diff.Changelog,
+	output string,
+	phasePath string,
+	colored bool,
+	kind string,
+	distroPath string,
+) error {
+	switch output {
+	case diffOutputJSON, diffOutputYAML:
+		extractor, err := newRulesExtractor(kind, distroPath, diffChecker.GetCurrentConfig())
+		if err != nil {
+			return err
+		}
+
+		changes := diffs.NewChanges(d, extractor)
+
+		var out []byte
+
+		if output == diffOutputJSON {
+			out, err = json.MarshalIndent(changes, "", "  ")
+			out = append(out, '\n')
+		} else {
+			out, err = yamlx.MarshalV3(changes)
+		}
+
+		if err != nil {
+			return fmt.Errorf("error while marshalling diffs: %w", err)
+		}
+
+		fmt.Print(redact.String(string(out)))
+
+	case diffOutputUnified:
+		if len(d) == 0 {
+			logrus.Info("No differences found from previous cluster configuration")
+
+			return nil
+		}
+
+		out, err := diffs.UnifiedDiff(diffChecker.GetCurrentConfig(), diffChecker.GetNewConfig(), phasePath, colored)
+		if err != nil {
+			return fmt.Errorf("error while generating unified diff: %w", err)
+		}
+
+		fmt.Print(redact.String(out))
+
+	default:
+		if len(d) == 0 {
+			logrus.Info("No differences found from previous cluster configuration")
+
+			return nil
+		}
+
+		fmt.Printf(
+			"Differences found from previous cluster configuration:\n%s",
+			redact.String(diffChecker.DiffToString(d)),
+		)
+	}
+
+	return nil
+}
+
+// newRulesExtractor returns the rules extractor of the cluster kind, or nil when the distribution has no
+// rules file for it.
+func newRulesExtractor(kind, distroPath string, currentConfig map[string]any) (rules.Extractor, error) {
+	var (
+		extractor rules.Extractor
+		err       error
+	)
+
+	switch kind {
+	case distribution.EKSClusterKind:
+		extractor, err = rules.NewEKSClusterRulesExtractor(distroPath, currentConfig, eksupported.Phases())
+
+	case distribution.KFDDistributionKind:
+		extractor, err = rules.NewDistroClusterRulesExtractor(distroPath, currentConfig, distrosupported.Phases())
+
+	case distribution.OnPremisesKind:
+		extractor, err = rules.NewOnPremClusterRulesExtractor(distroPath, currentConfig, premsupported.Phases())
+
+	case distribution.ImmutableKind:
+		extractor, err = rules.NewImmutableClusterRulesExtractor(distroPath, currentConfig, immsupported.Phases())
+
+	default:
+		return nil, nil //nolint:nilnil // a kind without rules has no extractor
+	}
+
+	if err != nil {
+		if errors.Is(err, rules.ErrReadingRulesFile) {
+			logrus.Warn("No rules file found, skipping immutable and unsupported checks")
+
+			return nil, nil //nolint:nilnil // a distribution without rules has no extractor
+		}
+
+		return nil, fmt.Errorf("error while creating rules extractor: %w", err)
+	}
+
+	return extractor, nil
+}
+
 func getPhasePath(
 	furyctlPath string,
 	workDir string,
@@ -259,6 +421,9 @@ func getPhasePath(
 		upgradePathLocation,
 		"",
 		[]string{},
+		nil,
+		backend.Config{},
+		cluster.StrictTemplates{},
 	)
 	if err != nil {
 		return "", fmt.Errorf("error while initializing cluster creator: %w", err)
@@ -291,6 +456,9 @@ func createDiffChecker(stateStore state.Storer, furyctlPath string) (diffs.Check
 		return diffChecker, fmt.Errorf("error while reading config file: %w", err)
 	}
 
+	// The stored configuration has the values of the secrets as they were at the last apply.
+	parserx.RegisterStoredSecrets(storedCfg, newCfg)
+
 	return diffs.NewBaseChecker(storedCfg, newCfg), nil
 }
 
@@ -336,9 +504,19 @@ func getDiffCommandFlags() (DiffCommandFlags, error) {
 		return DiffCommandFlags{}, fmt.Errorf("%w: %w", ErrParsingFlag, err)
 	}
 
+	output := viper.GetString("output")
+	if !slices.Contains(diffOutputs(), output) {
+		return DiffCommandFlags{}, fmt.Errorf("%w: %s: %w", ErrParsingFlag, "output", ErrInvalidDiffOutput)
+	}
+
+	encryptionKey, err := flags.GetEncryptionKeyFromViper()
+	if err != nil {
+		return DiffCommandFlags{}, fmt.Errorf("%w: %w", ErrParsingFlag, err)
+	}
+
 	return DiffCommandFlags{
 		Debug:                 viper.GetBool("debug"),
-		FuryctlPath:           viper.GetString("config"),
+		FuryctlPath:           flags.GetComposedConfigPathFromViper(),
 		DistroLocation:        viper.GetString("distro-location"),
 		Phase:                 phase,
 		NoTTY:                 viper.GetBool("no-tty"),
@@ -347,5 +525,8 @@ func getDiffCommandFlags() (DiffCommandFlags, error) {
 		Outdir:                viper.GetString("outdir"),
 		UpgradePathLocation:   viper.GetString("upgrade-path-location"),
 		DistroPatchesLocation: distroPatchesLocation,
+		Output:                output,
+		EncryptionKey:         encryptionKey,
+		StateBackend:          flags.GetStateBackendFromViper(),
 	}, nil
 }
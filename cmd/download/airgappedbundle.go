@@ -9,6 +9,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -17,9 +19,11 @@ import (
 	"github.com/sighupio/furyctl/internal/airgap"
 	"github.com/sighupio/furyctl/internal/analytics"
 	"github.com/sighupio/furyctl/internal/apis/config"
+	immutablecreate "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/create"
 	"github.com/sighupio/furyctl/internal/app"
 	"github.com/sighupio/furyctl/internal/flags"
 	"github.com/sighupio/furyctl/internal/git"
+	"github.com/sighupio/furyctl/internal/images"
 	"github.com/sighupio/furyctl/internal/tool/helmfile"
 	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
@@ -78,16 +82,139 @@ func preinstallHelmPlugins(kfd config.KFD, binPath string) error {
 	return nil
 }
 
+// imageKinds are the kinds whose distribution phase renders offline. The distribution of an EKSCluster
+// needs the outputs of the Terraform of the infrastructure and kubernetes phases.
+//
+//nolint:gochecknoglobals // constant set of kinds.
+var imageKinds = []string{"KFDDistribution", "OnPremises", "Immutable"}
+
+// bundleImages collects the images that the cluster of the configuration runs and stores them as an OCI
+// image layout in imagesDir, from scratch.
+func bundleImages(
+	dres dist.DownloadResult,
+	furyctlPath,
+	basePath,
+	binPath,
+	imagesDir string,
+	platforms []images.Platform,
+) error {
+	kind := dres.MinimalConf.Kind
+
+	if !slices.Contains(imageKinds, kind) {
+		logrus.Warnf("furyctl cannot collect the images of the %s kind, the bundle has no images", kind)
+
+		return nil
+	}
+
+	logrus.Info("Collecting the container images of the distribution...")
+
+	manifests, err := images.RenderDistribution(images.DistributionPaths{
+		DistroPath: dres.RepoPath,
+		ConfigPath: furyctlPath,
+		WorkDir:    filepath.Join(basePath, "images-render"),
+		BinPath:    binPath,
+	}, kind, dres.DistroManifest)
+	if err != nil {
+		return fmt.Errorf("%w, use --skip-images to create the bundle without the images", err)
+	}
+
+	refs, err := images.FromManifests(manifests)
+	if err != nil {
+		return err
+	}
+
+	set := images.Set{}
+	set.Add(refs...)
+
+	if kind == "Immutable" {
+		nodeRefs, err := immutablecreate.ImageReferences(
+			filepath.Join(basePath, "kubernetes"),
+			dres.DistroManifest.Kubernetes.Immutable.Version,
+		)
+		if err != nil {
+			return fmt.Errorf("error while collecting the images of the nodes: %w", err)
+		}
+
+		set.Add(nodeRefs...)
+	}
+
+	if err := os.RemoveAll(imagesDir); err != nil {
+		return fmt.Errorf("error while removing the images of the previous bundle: %w", err)
+	}
+
+	layout, err := images.NewLayout(imagesDir)
+	if err != nil {
+		return err
+	}
+
+	client := images.NewRegistryClient(images.RegistryOptions{})
+	sorted := set.Sorted()
+
+	for i, ref := range sorted {
+		logrus.Infof("Pulling image %d/%d %s ...", i+1, len(sorted), ref)
+
+		if err := images.Pull(client, layout, ref, platforms); err != nil {
+			return fmt.Errorf("error while pulling image %s: %w", ref, err)
+		}
+	}
+
+	return nil
+}
+
+// bundleManifest describes the bundle of the distribution, with the tools that the download fetched.
+func bundleManifest(furyctlVersion string, dres dist.DownloadResult, report *dependencies.Report) airgap.Manifest {
+	m := airgap.Manifest{
+		FuryctlVersion:      furyctlVersion,
+		DistributionVersion: dres.DistroManifest.Version,
+		Kind:                dres.MinimalConf.Kind,
+		Platform:            report.Platform,
+		Tools:               []airgap.Tool{},
+	}
+
+	for _, a := range report.Artifacts {
+		if a.Kind == dependencies.ArtifactKindTool {
+			m.Tools = append(m.Tools, airgap.Tool{Name: a.Name, Version: a.Version, Platform: report.Platform})
+		}
+	}
+
+	slices.SortFunc(m.Tools, func(a, b airgap.Tool) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return m
+}
+
+// readSigningKey returns the content of the private key that signs the manifest of the bundle, nil when
+// no key is given.
+func readSigningKey(path string) ([]byte, error) {
+	if path == "" {
+		logrus.Warn("No --signing-key given, the manifest of the bundle is not signed")
+
+		return nil, nil
+	}
+
+	key, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading signing key: %w", err)
+	}
+
+	return key, nil
+}
+
 func NewAirGappedBundleCmd() *cobra.Command {
 	var cmdEvent analytics.Event
 
 	airGappedBundleCmd := &cobra.Command{
 		Args:  cobra.NoArgs,
 		Use:   "air-gapped-bundle",
-		Short: "Build a self-contained bundle with the distribution, modules, installers and tools to run furyctl offline",
+		Short: "Build a self-contained bundle with the distribution, modules, installers, tools and images to run furyctl offline",
 		Long: "Build a self-contained bundle with everything necessary to run furyctl on an air-gapped machine. " +
 			"The bundle holds the distribution manifests, the modules, the installers and all the tools from " +
 			"the bundled mise. furyctl builds the bundle for the host platform only. " +
+			"The bundle also holds the container images of the distribution as an OCI image layout, " +
+			"load them into the internal registry with 'furyctl airgap push-images'. " +
+			"The bundle embeds a manifest with the versions of its contents and the checksum of each file, " +
+			"signed with --signing-key, check it offline with 'furyctl airgap verify'. " +
 			"On the target machine, copy the bundle and your furyctl.yaml. " +
 			"Then run 'furyctl apply --airgap-bundle /path/to/bundle.tar.gz'. " +
 			"furyctl extracts the bundle in the working directory and runs offline.",
@@ -107,14 +234,17 @@ func NewAirGappedBundleCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
-			furyctlPath := viper.GetString("config")
+			furyctlPath := flags.GetComposedConfigPathFromViper()
 			distroLocation := viper.GetString("distro-location")
 			gitProtocol := viper.GetString("git-protocol")
 			outDir := viper.GetString("outdir")
 			distroPatchesLocation := viper.GetString("distro-patches")
 			bundleOutput := viper.GetString("bundle-output")
+			skipImages := viper.GetBool("skip-images")
+			baseBundle := viper.GetString("base")
+			signingKeyPath := viper.GetString("signing-key")
 
 			if bundleOutput == "" {
 				return ErrBundleOutputRequired
@@ -125,6 +255,28 @@ func NewAirGappedBundleCmd() *cobra.Command {
 				return fmt.Errorf("error while getting absolute path for bundle output: %w", err)
 			}
 
+			if baseBundle != "" {
+				if _, err := os.Stat(baseBundle); err != nil {
+					return fmt.Errorf("%w: %s", airgap.ErrBundleNotFound, baseBundle)
+				}
+			}
+
+			signingKey, err := readSigningKey(signingKeyPath)
+			if err != nil {
+				return err
+			}
+
+			platforms := []images.Platform{}
+
+			for _, p := range viper.GetStringSlice("image-platforms") {
+				platform, err := images.ParsePlatform(p)
+				if err != nil {
+					return fmt.Errorf("%w: %w", ErrParsingFlag, err)
+				}
+
+				platforms = append(platforms, platform)
+			}
+
 			typedGitProtocol, err := git.ParseProtocol(gitProtocol)
 			if err != nil {
 				cmdEvent.AddErrorMessage(err)
@@ -210,28 +362,70 @@ func NewAirGappedBundleCmd() *cobra.Command {
 				return err
 			}
 
+			imagesDir := filepath.Join(basePath, airgap.ImagesSubdir)
+
+			if !skipImages {
+				if err := bundleImages(dres, furyctlPath, basePath, binPath, imagesDir, platforms); err != nil {
+					cmdEvent.AddErrorMessage(err)
+					tracker.Track(cmdEvent)
+
+					return err
+				}
+			}
+
 			logrus.Infof("Packaging air-gapped bundle into %s ...", bundleOutput)
 
 			// The bundle carries the tool layout (.furyctl/bin, including the mise binary + installed
 			// tool data), the git-vendored modules and installers (.furyctl/<cluster>/vendor) and the
 			// distribution manifests (distro/, used as --distro-location). The user brings their own
-			// furyctl.yaml on the target, so it is intentionally not bundled.
+			// furyctl.yaml on the target, so it is intentionally not bundled. The images (images/) are
+			// an OCI image layout, that `furyctl airgap push-images` loads into the internal registry.
 			entries := []iox.TarGzEntry{
 				{Src: binPath, Prefix: filepath.Join(".furyctl", "bin")},
 				{Src: filepath.Join(basePath, "vendor"), Prefix: filepath.Join(".furyctl", clusterName, "vendor")},
 				{Src: dres.RepoPath, Prefix: airgap.DistroSubdir},
 			}
 
-			if err := iox.CreateTarGz(bundleOutput, entries); err != nil {
+			if !skipImages {
+				if _, err := os.Stat(imagesDir); err == nil {
+					entries = append(entries, iox.TarGzEntry{Src: imagesDir, Prefix: airgap.ImagesSubdir})
+				}
+			}
+
+			// The manifest describes what the bundle is for, and the checksum of each of its files.
+			manifest := bundleManifest(ctn.Version, dres, depsdl.Report())
+
+			if baseBundle != "" {
+				// A delta holds only the contents that the base bundle does not have.
+				if _, err := airgap.CreateDelta(bundleOutput, baseBundle, manifest, signingKey, entries); err != nil {
+					cmdEvent.AddErrorMessage(err)
+					tracker.Track(cmdEvent)
+
+					return fmt.Errorf("error creating air-gapped delta bundle: %w", err)
+				}
+
+				logrus.Infof("The air-gapped delta bundle is ready: %s", bundleOutput)
+				logrus.Infof("On the target machine, copy the delta bundle next to the bundles of %s. "+
+					"Then give the full bundle and the deltas after it, in order: "+
+					"furyctl apply --airgap-bundle <full bundle> --airgap-delta [<previous deltas>,]%s", baseBundle, bundleOutput)
+
+				cmdEvent.AddSuccessMessage("Air-gapped delta bundle created successfully")
+				tracker.Track(cmdEvent)
+
+				return nil
+			}
+
+			if _, err := airgap.CreateBundle(bundleOutput, manifest, signingKey, entries); err != nil {
 				cmdEvent.AddErrorMessage(err)
 				tracker.Track(cmdEvent)
 
-				return fmt.Errorf("error creating air-gapped bundle: %w", err)
+				return err
 			}
 
 			logrus.Infof("The air-gapped bundle is ready: %s", bundleOutput)
 			logrus.Infof("On the target machine, copy the bundle and your furyctl.yaml. "+
-				"Then run: furyctl apply --airgap-bundle %s", bundleOutput)
+				"Load the images into your registry with: furyctl airgap push-images --airgap-bundle %s --registry <registry>. "+
+				"Then run: furyctl apply --airgap-bundle %s", bundleOutput, bundleOutput)
 			logrus.Info("furyctl extracts the bundle in the working directory and runs offline. " +
 				"You can use the same --airgap-bundle flag with these commands: diff, delete cluster, " +
 				"get kubeconfig, get upgrade-paths, renew certificates.")
@@ -277,5 +471,31 @@ func NewAirGappedBundleCmd() *cobra.Command {
 			"must have the same structure as the distribution's repository",
 	)
 
+	airGappedBundleCmd.Flags().String(
+		"base",
+		"",
+		"Path to a previous air-gapped bundle, full or delta. furyctl creates a delta bundle with only the files "+
+			"that the base does not have. Apply it with --airgap-bundle <full bundle> --airgap-delta <delta bundle>",
+	)
+
+	airGappedBundleCmd.Flags().String(
+		"signing-key",
+		"",
+		"Path to a PEM private key (PKCS#8, ECDSA, Ed25519 or RSA) that signs the manifest of the bundle. "+
+			"Check the bundle on the target machine with 'furyctl airgap verify --public-key'",
+	)
+
+	airGappedBundleCmd.Flags().Bool(
+		"skip-images",
+		false,
+		"Create the bundle without the container images of the distribution",
+	)
+
+	airGappedBundleCmd.Flags().StringSlice(
+		"image-platforms",
+		[]string{"linux/amd64"},
+		"Platforms of the container images to bundle, in the <os>/<arch>[/<variant>] form (eg: linux/amd64,linux/arm64)",
+	)
+
 	return airGappedBundleCmd
 }
@@ -0,0 +1,344 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/airgap"
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/drift"
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/git"
+	"github.com/sighupio/furyctl/internal/lock"
+	"github.com/sighupio/furyctl/internal/plan"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+	logrusx "github.com/sighupio/furyctl/internal/x/logrus"
+)
+
+var ErrNoRenderedManifests = errors.New("the dry run did not render the distribution manifests")
+
+type DriftCmdFlags struct {
+	ClusterCmdFlags
+
+	Output   string
+	ShowDiff bool
+}
+
+func NewDriftCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	driftCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "drift",
+		Short: "Detect the changes made to the distribution resources outside of furyctl",
+		Long: `Render the distribution and the plugins phases exactly as the apply would, and compare the result with the
+live objects of the cluster through a server-side dry-run diff, so the fields that the API server defaults and
+the fields that other managers own are ignored.
+
+The report lists, for each module, the resources that are missing from the cluster, the ones that were modified
+and the extra ones that were created by hand next to them.
+
+The command exits with 0 when there is no drift, 2 when there is drift and 1 on errors, so that it can run in a
+scheduled CI job.`,
+		Example: `  furyctl drift                            Detect the drift of the cluster of the default configuration file
+  furyctl drift --show-diff                Include the diff of the modified resources
+  furyctl drift --output json              Print the report as JSON
+`,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				logrus.Fatalf("error while binding flags: %v", err)
+			}
+
+			// Load and merge flags from configuration file.
+			if err := flags.LoadAndMergeCommandFlags("drift"); err != nil {
+				logrus.Fatalf("%v", err)
+			}
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			if err := airgap.MaybePrepare(); err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error preparing air-gapped bundle: %w", err)
+			}
+
+			cmdFlags, err := getDriftCmdFlags()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			// The logs must not mix with a machine-readable output.
+			if cmdFlags.Output == planOutputJSON {
+				logrusx.RedirectStdout(os.Stderr)
+			}
+
+			report, err := detectDrift(cmdFlags, cmdEvent, tracker)
+			if err != nil {
+				return err
+			}
+
+			if cmdFlags.Output == planOutputJSON {
+				out, err := report.JSON()
+				if err != nil {
+					cmdEvent.AddErrorMessage(err)
+					tracker.Track(cmdEvent)
+
+					return err
+				}
+
+				fmt.Print(string(out))
+			} else {
+				fmt.Print(report.Text(cmdFlags.ShowDiff))
+			}
+
+			cmdEvent.AddSuccessMessage("drift command executed successfully")
+			tracker.Track(cmdEvent)
+
+			if report.HasDrift() {
+				return ErrDriftDetected
+			}
+
+			return nil
+		},
+	}
+
+	setupDriftCmdFlags(driftCmd)
+
+	return driftCmd
+}
+
+// detectDrift renders the distribution phase and then the plugins phase with a dry run of the apply, and
+// compares what they rendered with the cluster.
+func detectDrift(cmdFlags DriftCmdFlags, cmdEvent analytics.Event, tracker *analytics.Tracker) (*drift.Report, error) {
+	distroFlags := cmdFlags.ClusterCmdFlags
+	distroFlags.Phase = cluster.OperationPhaseDistribution
+
+	distroPlan, err := applyConfiguration(distroFlags, cmdEvent, tracker, true)
+	if err != nil {
+		return nil, err
+	}
+
+	distroRendered, ok := distroPlan.RenderedPhase(cluster.OperationPhaseDistribution)
+	if !ok {
+		cmdEvent.AddErrorMessage(ErrNoRenderedManifests)
+		tracker.Track(cmdEvent)
+
+		return nil, ErrNoRenderedManifests
+	}
+
+	// The first run downloaded and validated the dependencies already.
+	pluginsFlags := cmdFlags.ClusterCmdFlags
+	pluginsFlags.Phase = cluster.OperationPhasePlugins
+	pluginsFlags.SkipDepsDownload = true
+	pluginsFlags.SkipDepsValidation = true
+
+	pluginsPlan, err := applyConfiguration(pluginsFlags, cmdEvent, tracker, true)
+	if err != nil {
+		return nil, err
+	}
+
+	workDir := filepath.Join(cmdFlags.Outdir, ".furyctl", distroPlan.Cluster, "drift")
+	if err := iox.EnsureDir(filepath.Join(workDir, "manifests.yaml")); err != nil {
+		cmdEvent.AddErrorMessage(err)
+		tracker.Track(cmdEvent)
+
+		return nil, fmt.Errorf("error while creating drift folder: %w", err)
+	}
+
+	logrus.Info("Comparing the rendered manifests with the cluster...")
+
+	report := drift.NewReport(distroPlan.Cluster)
+	detector := drift.NewDetector(workDir)
+
+	rendered := []plan.RenderedPhase{distroRendered}
+	if pluginsRendered, ok := pluginsPlan.RenderedPhase(cluster.OperationPhasePlugins); ok {
+		rendered = append(rendered, pluginsRendered)
+	}
+
+	for _, r := range rendered {
+		if err := detector.Detect(report, r); err != nil {
+			cmdEvent.AddErrorMessage(err)
+			tracker.Track(cmdEvent)
+
+			return nil, fmt.Errorf("error while detecting drift: %w", err)
+		}
+	}
+
+	return report, nil
+}
+
+func getDriftCmdFlags() (DriftCmdFlags, error) {
+	var err error
+
+	binPath := viper.GetString("bin-path")
+	if binPath == "" {
+		binPath = filepath.Join(viper.GetString("outdir"), ".furyctl", "bin")
+	} else {
+		binPath, err = filepath.Abs(binPath)
+		if err != nil {
+			return DriftCmdFlags{}, fmt.Errorf("error while getting absolute path for bin folder: %w", err)
+		}
+	}
+
+	distroPatchesLocation := viper.GetString("distro-patches")
+	if distroPatchesLocation != "" {
+		distroPatchesLocation, err = filepath.Abs(distroPatchesLocation)
+		if err != nil {
+			return DriftCmdFlags{}, fmt.Errorf("error while getting absolute path of distro patches location: %w", err)
+		}
+	}
+
+	furyctlPath := flags.GetComposedConfigPathFromViper()
+	if furyctlPath == "" {
+		return DriftCmdFlags{}, fmt.Errorf("%w --config: cannot be an empty string", ErrParsingFlag)
+	}
+
+	furyctlPath, err = filepath.Abs(furyctlPath)
+	if err != nil {
+		return DriftCmdFlags{}, fmt.Errorf("error while getting configuration file absolute path: %w", err)
+	}
+
+	typedGitProtocol, err := git.ParseProtocol(viper.GetString("git-protocol"))
+	if err != nil {
+		return DriftCmdFlags{}, fmt.Errorf("%w: %w", ErrParsingFlag, err)
+	}
+
+	output := viper.GetString("output")
+	if !slices.Contains(planOutputs(), output) {
+		return DriftCmdFlags{}, fmt.Errorf("%w: %s: %w", ErrParsingFlag, "output", ErrInvalidPlanOutput)
+	}
+
+	clusterState, err := getClusterStateCmdFlags()
+	if err != nil {
+		return DriftCmdFlags{}, err
+	}
+
+	return DriftCmdFlags{
+		ClusterCmdFlags: ClusterCmdFlags{
+			ClusterSkipsCmdFlags: ClusterSkipsCmdFlags{
+				// A dry run neither connects to the VPN nor upgrades nodes.
+				SkipVpn:            true,
+				SkipDepsDownload:   viper.GetBool("skip-deps-download"),
+				SkipDepsValidation: viper.GetBool("skip-deps-validation"),
+			},
+			Timeouts: Timeouts{
+				ProcessTimeout: viper.GetInt("timeout"),
+			},
+			Debug:                 viper.GetBool("debug"),
+			FuryctlPath:           furyctlPath,
+			DistroLocation:        viper.GetString("distro-location"),
+			BinPath:               binPath,
+			DryRun:                true,
+			NoTTY:                 viper.GetBool("no-tty"),
+			GitProtocol:           typedGitProtocol,
+			Force:                 []string{},
+			Outdir:                viper.GetString("outdir"),
+			DistroPatchesLocation: distroPatchesLocation,
+			PostApplyPhases:       []string{},
+			LockBackend:           lock.BackendLocal,
+			ClusterStateCmdFlags:  clusterState,
+		},
+		Output:   output,
+		ShowDiff: viper.GetBool("show-diff"),
+	}, nil
+}
+
+func setupDriftCmdFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file",
+	)
+
+	cmd.Flags().StringP(
+		"distro-location",
+		"",
+		"",
+		"Location where to download schemas, defaults, and the distribution manifests from. "+
+			"It can either be a local path (eg: /path/to/distribution) or "+
+			"a remote URL (eg: git::git@github.com:sighupio/distribution?depth=1&ref=BRANCH_NAME). "+
+			"Any format supported by hashicorp/go-getter can be used",
+	)
+
+	cmd.Flags().String(
+		"distro-patches",
+		"",
+		"Location where the distribution's user-made patches can be downloaded from. "+
+			"This can be either a local path (eg: /path/to/distro-patches) or "+
+			"a remote URL (eg: git::git@github.com:your-org/distro-patches?depth=1&ref=BRANCH_NAME). "+
+			"Any format supported by hashicorp/go-getter can be used."+
+			" Patches within this location must be in a folder named after the distribution version (eg: v1.29.0) and "+
+			"must have the same structure as the distribution's repository",
+	)
+
+	cmd.Flags().StringP(
+		"bin-path",
+		"b",
+		"",
+		"Path to the folder where all the dependencies' binaries are downloaded",
+	)
+
+	cmd.Flags().Bool(
+		"skip-deps-download",
+		false,
+		"Skip downloading the distribution modules, installers and binaries",
+	)
+
+	cmd.Flags().Bool(
+		"skip-deps-validation",
+		false,
+		"Skip validating dependencies",
+	)
+
+	airgap.RegisterFlags(cmd)
+
+	cmd.Flags().Int(
+		"timeout",
+		3600, //nolint:mnd,revive // ignore magic number linters
+		"Timeout for the whole drift detection, expressed in seconds",
+	)
+
+	cmd.Flags().String(
+		"output",
+		planOutputText,
+		"Output format. Options are: "+strings.Join(planOutputs(), ", "),
+	)
+
+	if err := cmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return planOutputs(), cobra.ShellCompDirectiveDefault
+	}); err != nil {
+		logrus.Fatalf("error while registering flag completion: %v", err)
+	}
+
+	cmd.Flags().Bool(
+		"show-diff",
+		false,
+		"Include the diff of the modified resources in the text report",
+	)
+}
@@ -16,6 +16,7 @@ func NewDumpCmd() *cobra.Command {
 		Short: "Dump rendered templates or other useful objects to the filesystem",
 	}
 
+	dumpCmd.AddCommand(dump.NewConfigCmd())
 	dumpCmd.AddCommand(dump.NewTemplateCmd())
 	dumpCmd.AddCommand(dump.NewDumpCLIReferenceCmd())
 
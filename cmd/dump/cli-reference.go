@@ -52,7 +52,7 @@ func NewDumpCLIReferenceCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
 			flags := getDumpCliReferenceCmdFlags()
 
@@ -0,0 +1,116 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dump
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/config"
+	"github.com/sighupio/furyctl/internal/flags"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+	logrusx "github.com/sighupio/furyctl/internal/x/logrus"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+func NewConfigCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	configCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "config",
+		Short: "Prints the configuration file as furyctl reads it",
+		Long: `Prints the configuration file as furyctl reads it: merged over the base files that it extends and with the overlays of the environment merged over it.
+This is the configuration that furyctl validates, diffs and stores in the cluster.`,
+		Example: `  furyctl dump config                                   print the composed configuration
+  furyctl dump config --rendered                        print it with the dynamic values expanded
+  furyctl dump config --environment production          print it with the overlays of the production environment
+ `,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+			// Bind the flags first: a flag on the command line has precedence over the configuration file.
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				logrus.Fatalf("error while binding flags: %v", err)
+			}
+
+			// The composition of the configuration happens while loading the flags.
+			if err := flags.LoadAndMergeCommandFlags("dump"); err != nil {
+				logrus.Fatalf("failed to load flags from configuration: %v", err)
+			}
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			// The logs must not mix with the configuration.
+			logrusx.RedirectStdout(os.Stderr)
+
+			out, err := dumpConfig(flags.GetComposedConfigPathFromViper(), viper.GetBool("rendered"))
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			fmt.Print(string(out))
+
+			cmdEvent.AddSuccessMessage("configuration dumped successfully")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	configCmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file",
+	)
+
+	configCmd.Flags().Bool(
+		"rendered",
+		false,
+		"Expand the dynamic values (eg: {env://VAR}, {file://./path}) and drop the flags section",
+	)
+
+	return configCmd
+}
+
+// dumpConfig returns the configuration at path, that is already the composed one, rendered if asked.
+func dumpConfig(path string, rendered bool) ([]byte, error) {
+	if !rendered {
+		out, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading configuration file: %w", err)
+		}
+
+		return out, nil
+	}
+
+	conf, err := config.Render(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while rendering configuration file: %w", err)
+	}
+
+	out, err := yamlx.MarshalV3(conf)
+	if err != nil {
+		return nil, fmt.Errorf("error while marshalling configuration: %w", err)
+	}
+
+	return out, nil
+}
@@ -71,7 +71,7 @@ The command will dump into a 'distribution' folder in the working directory all
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
 			flags, err := getDumpTemplateCmdFlags()
 			if err != nil {
@@ -233,7 +233,7 @@ func getDumpTemplateCmdFlags() (TemplateCmdFlags, error) {
 		return TemplateCmdFlags{}, fmt.Errorf("%w: %w", ErrParsingFlag, err)
 	}
 
-	furyctlPath := viper.GetString("config")
+	furyctlPath := flags.GetComposedConfigPathFromViper()
 
 	if furyctlPath == "" {
 		return TemplateCmdFlags{}, fmt.Errorf("%w --config: cannot be an empty string", ErrParsingFlag)
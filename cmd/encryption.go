@@ -0,0 +1,22 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sighupio/furyctl/cmd/encryption"
+)
+
+func NewEncryptionCmd() *cobra.Command {
+	encryptionCmd := &cobra.Command{
+		Use:   "encryption",
+		Short: "Manage the encryption of the configuration that furyctl stores in the cluster",
+	}
+
+	encryptionCmd.AddCommand(encryption.NewRotateCmd())
+
+	return encryptionCmd
+}
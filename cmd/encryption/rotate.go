@@ -0,0 +1,175 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package encryption
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
+	"github.com/sighupio/furyctl/internal/upgrade"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+)
+
+var (
+	ErrNewKeyRequired = errors.New("set the new key with --new-key-file, or --decrypt to store the configuration in clear")
+	ErrNewKeyAndClear = errors.New("--new-key-file and --decrypt cannot be used together")
+	ErrPartialRotate  = errors.New("the rotation stopped part-way, some payloads are encrypted with the new key and " +
+		"the others with the current one: put back the copy of the state taken before the rotation")
+)
+
+func NewRotateCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	rotateCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "rotate",
+		Short: "Encrypt again the configuration stored in the cluster with a new key",
+		Long: `Encrypt again with a new key the configuration that furyctl stores in the cluster: the furyctl-config secret, the revisions of the configuration history and the state of an upgrade in progress.
+The current key is the one of --encryption-key-file or of the FURYCTL_ENCRYPTION_KEY environment variable, without it the command encrypts the configuration stored in clear. Use the new key for the next commands, the current one no longer decrypts the configuration.
+The command decrypts every payload with the current key before it writes any of them. If writing stops part-way, some payloads are encrypted with the new key and the others with the current one: copy the state before the rotation with furyctl state migrate --to local --to-dir ./backup, and put it back with furyctl state migrate --state-backend local --state-dir ./backup --to cluster.
+The cluster backend reaches the cluster with the kubeconfig in the KUBECONFIG environment variable.`,
+		Example: `  furyctl encryption rotate --new-key-file new.key                              encrypt with new.key the configuration stored in clear
+  furyctl encryption rotate --encryption-key-file old.key --new-key-file new.key  encrypt again with new.key the configuration encrypted with old.key
+  furyctl encryption rotate --encryption-key-file old.key --decrypt             store in clear the configuration encrypted with old.key
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+			// Bind the flags first: a flag on the command line has precedence over the configuration file.
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				logrus.Fatalf("error while binding flags: %v", err)
+			}
+
+			if err := flags.LoadAndMergeCommandFlags("encryption"); err != nil {
+				logrus.Fatalf("failed to load flags from configuration: %v", err)
+			}
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			execx.Debug = viper.GetBool("debug")
+
+			currentKey, err := flags.GetEncryptionKeyFromViper()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while loading the current key: %w", err)
+			}
+
+			newKey, err := loadNewKey(viper.GetString("new-key-file"), viper.GetBool("decrypt"))
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			if err := rotate(currentKey, newKey, flags.GetStateBackendFromViper()); err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			if newKey == nil {
+				logrus.Info("The configuration stored in the cluster is now in clear")
+			} else {
+				logrus.Infof("The configuration stored in the cluster is now encrypted with the %s key %s",
+					newKey.Scheme(), newKey.ID())
+			}
+
+			cmdEvent.AddSuccessMessage("encryption key successfully rotated")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	rotateCmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file, furyctl reads the flags section from it",
+	)
+
+	rotateCmd.Flags().String(
+		"new-key-file",
+		"",
+		"Path to the key that encrypts the configuration from now on: an age identity, as written by age-keygen, "+
+			"or a base64 or hex encoded 32 bytes AES key",
+	)
+
+	rotateCmd.Flags().Bool(
+		"decrypt",
+		false,
+		"Store the configuration in clear instead of encrypting it with a new key",
+	)
+
+	return rotateCmd
+}
+
+func loadNewKey(newKeyFile string, decrypt bool) (encryption.Key, error) {
+	switch {
+	case newKeyFile != "" && decrypt:
+		return nil, ErrNewKeyAndClear
+
+	case decrypt:
+		return nil, nil //nolint:nilnil // without a key the configuration is stored in clear.
+
+	case newKeyFile == "":
+		return nil, ErrNewKeyRequired
+	}
+
+	newKey, err := encryption.LoadKey(newKeyFile, "")
+	if err != nil {
+		return nil, fmt.Errorf("error while loading the new encryption key: %w", err)
+	}
+
+	return newKey, nil
+}
+
+// rotate decrypts with the current key the payloads that furyctl stores in the state backend, and encrypts
+// them again with the new one. It opens all of them before writing any, so that a wrong key leaves the state
+// untouched.
+func rotate(currentKey, newKey encryption.Key, stateBackend backend.Config) error {
+	// The stores create the client of the cluster only for the cluster backend.
+	stateStore := state.NewStore("", "", currentKey, stateBackend)
+	upgradeStore := upgrade.NewStateStore(currentKey, stateBackend)
+
+	if err := stateStore.CheckKey(); err != nil {
+		return fmt.Errorf("error while decrypting the furyctl configuration with the current key: %w", err)
+	}
+
+	if err := upgradeStore.CheckKey(); err != nil {
+		return fmt.Errorf("error while decrypting the furyctl upgrade state with the current key: %w", err)
+	}
+
+	logrus.Info("Encrypting again the furyctl configuration stored in the cluster...")
+
+	if err := stateStore.Reencrypt(newKey); err != nil {
+		return fmt.Errorf("%w: error while encrypting again the furyctl configuration: %w", ErrPartialRotate, err)
+	}
+
+	if err := upgradeStore.Reencrypt(newKey); err != nil {
+		return fmt.Errorf("%w: error while encrypting again the furyctl upgrade state: %w", ErrPartialRotate, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package encryption
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
+	"github.com/sighupio/furyctl/internal/upgrade"
+)
+
+func newKey(t *testing.T, b string) encryption.Key {
+	t.Helper()
+
+	key, err := encryption.ParseKey([]byte(strings.Repeat(b, 32)))
+	require.NoError(t, err)
+
+	return key
+}
+
+func storeState(t *testing.T, stateBackend backend.Config, configKey, upgradeKey encryption.Key) {
+	t.Helper()
+
+	configPath := filepath.Join(t.TempDir(), "furyctl.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte("kind: KFDDistribution\n"), 0o600))
+
+	require.NoError(t, state.NewStore("", configPath, configKey, stateBackend).StoreConfig(map[string]any{}))
+	require.NoError(t, upgrade.NewStateStore(upgradeKey, stateBackend).Store(&upgrade.State{}))
+}
+
+func TestRotate(t *testing.T) {
+	t.Parallel()
+
+	currentKey := newKey(t, "ab")
+	nextKey := newKey(t, "cd")
+	stateBackend := backend.Config{Type: backend.TypeLocal, Dir: t.TempDir()}
+
+	storeState(t, stateBackend, currentKey, currentKey)
+
+	require.NoError(t, rotate(currentKey, nextKey, stateBackend))
+
+	assert.NoError(t, state.NewStore("", "", nextKey, stateBackend).CheckKey())
+	assert.NoError(t, upgrade.NewStateStore(nextKey, stateBackend).CheckKey())
+}
+
+func TestRotate_WrongKeyLeavesTheStateUntouched(t *testing.T) {
+	t.Parallel()
+
+	currentKey := newKey(t, "ab")
+	otherKey := newKey(t, "ef")
+	stateBackend := backend.Config{Type: backend.TypeLocal, Dir: t.TempDir()}
+
+	// The current key opens the configuration but not the upgrade state.
+	storeState(t, stateBackend, currentKey, otherKey)
+
+	err := rotate(currentKey, newKey(t, "cd"), stateBackend)
+	require.ErrorIs(t, err, encryption.ErrWrongKey)
+	require.NotErrorIs(t, err, ErrPartialRotate)
+
+	assert.NoError(t, state.NewStore("", "", currentKey, stateBackend).CheckKey())
+}
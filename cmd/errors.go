@@ -6,4 +6,24 @@ package cmd
 
 import "errors"
 
-var ErrParsingFlag = errors.New("error while parsing flag")
+// ExitCodeDrift is the exit code of the drift command when the cluster drifted from the configuration.
+const ExitCodeDrift = 2
+
+var (
+	ErrParsingFlag   = errors.New("error while parsing flag")
+	ErrDriftDetected = errors.New("the cluster drifted from the configuration")
+)
+
+// ExitCode returns the exit code of a command that returned the error.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return 0
+
+	case errors.Is(err, ErrDriftDetected):
+		return ExitCodeDrift
+
+	default:
+		return 1
+	}
+}
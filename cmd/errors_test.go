@@ -0,0 +1,25 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package cmd_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sighupio/furyctl/cmd"
+)
+
+func TestExitCode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 0, cmd.ExitCode(nil))
+	assert.Equal(t, 1, cmd.ExitCode(errors.New("boom")))
+	assert.Equal(t, cmd.ExitCodeDrift, cmd.ExitCode(fmt.Errorf("drift: %w", cmd.ErrDriftDetected)))
+}
@@ -10,8 +10,6 @@ import (
 	"fmt"
 	"math"
 	"os"
-	"path"
-	"path/filepath"
 	"strings"
 	"text/tabwriter"
 
@@ -25,6 +23,7 @@ import (
 	"github.com/sighupio/furyctl/internal/app"
 	"github.com/sighupio/furyctl/internal/clusterinfo"
 	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/kubernetes"
 	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
 )
@@ -66,13 +65,10 @@ func NewClusterInfoCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
-			binPath := viper.GetString("bin-path")
-			currentDir := viper.GetString("workdir")
 			debug := viper.GetBool("debug")
 			format := viper.GetString("format")
-			outDir := viper.GetString("outdir")
 
 			execx.Debug = debug
 
@@ -83,9 +79,23 @@ func NewClusterInfoCmd() *cobra.Command {
 				return errInvalidOutputFormat
 			}
 
-			kubectlBin := resolveKubectlBin(binPath, outDir)
+			encryptionKey, err := flags.GetEncryptionKeyFromViper()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("%w: %w", ErrParsingFlag, err)
+			}
+
+			client, err := kubernetes.NewClient("")
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
 
-			collector := clusterinfo.NewCollector(kubectlBin, currentDir)
+				return fmt.Errorf("error while creating kubernetes client: %w", err)
+			}
+
+			collector := clusterinfo.NewCollector(client, encryptionKey)
 
 			info, err := collector.Collect()
 			if err != nil {
@@ -118,6 +128,13 @@ func NewClusterInfoCmd() *cobra.Command {
 			"If not set, kubectl is resolved from PATH.",
 	)
 
+	if err := clusterInfoCmd.Flags().MarkDeprecated(
+		"bin-path",
+		"furyctl reads the cluster information with the Kubernetes API and no longer runs kubectl",
+	); err != nil {
+		logrus.Fatalf("error while deprecating flag: %v", err)
+	}
+
 	clusterInfoCmd.Flags().StringP(
 		"format",
 		"f",
@@ -135,25 +152,6 @@ func NewClusterInfoCmd() *cobra.Command {
 	return clusterInfoCmd
 }
 
-func resolveKubectlBin(binPath, outDir string) string {
-	searchDir := binPath
-
-	if searchDir == "" && outDir != "" {
-		searchDir = path.Join(outDir, ".furyctl", "bin")
-	}
-
-	if searchDir != "" {
-		pattern := filepath.Join(searchDir, "kubectl", "*", "kubectl")
-
-		matches, err := filepath.Glob(pattern)
-		if err == nil && len(matches) > 0 {
-			return matches[len(matches)-1]
-		}
-	}
-
-	return "kubectl"
-}
-
 func printInfo(info *clusterinfo.Info, format string) error {
 	switch format {
 	case outputFormatJSON:
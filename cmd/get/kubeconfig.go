@@ -62,7 +62,7 @@ func NewKubeconfigCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
 			// Air-gapped: extract --airgap-bundle (if set) and rewire to run offline before reading flags.
 			if err := airgap.MaybePrepare(); err != nil {
@@ -77,7 +77,7 @@ func NewKubeconfigCmd() *cobra.Command {
 			currentDir := viper.GetString("workdir")
 			debug := viper.GetBool("debug")
 			distroLocation := viper.GetString("distro-location")
-			furyctlPath := viper.GetString("config")
+			furyctlPath := flags.GetComposedConfigPathFromViper()
 			gitProtocol := viper.GetString("git-protocol")
 			outDir := viper.GetString("outdir")
 			skipDepsDownload := viper.GetBool("skip-deps-download")
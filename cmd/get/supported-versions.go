@@ -49,7 +49,7 @@ func NewSupportedVersionsCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctn := app.GetContainerInstance()
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
 			releases, err := distribution.GetSupportedVersions(git.NewGitHubClient())
 			if err != nil {
@@ -62,7 +62,7 @@ func NewUpgradePathsCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
 			// Air-gapped: extract --airgap-bundle (if set) and rewire to run offline before reading flags.
 			if err := airgap.MaybePrepare(); err != nil {
@@ -75,7 +75,7 @@ func NewUpgradePathsCmd() *cobra.Command {
 			// Get flags.
 			debug := viper.GetBool("debug")
 			binPath := viper.GetString("bin-path")
-			furyctlPath := viper.GetString("config")
+			furyctlPath := flags.GetComposedConfigPathFromViper()
 			outDir := viper.GetString("outdir")
 			distroLocation := viper.GetString("distro-location")
 			gitProtocol := viper.GetString("git-protocol")
@@ -0,0 +1,62 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package cmd_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/cmd"
+)
+
+// Cobra merges the persistent flags of the parents into the flags of a command when it parses them, and it
+// panics when a flag of the command has the shorthand of a persistent flag, as -o of --outdir. The help
+// parses the flags too, so every command must print its help.
+//
+//nolint:paralleltest // NewRootCmd binds the flags in the global viper.
+func TestCommandsPrintTheirHelp(t *testing.T) {
+	paths := [][]string{}
+
+	var walk func(c *cobra.Command, path []string)
+
+	walk = func(c *cobra.Command, path []string) {
+		for _, sub := range c.Commands() {
+			subPath := append(append([]string{}, path...), sub.Name())
+
+			paths = append(paths, subPath)
+
+			walk(sub, subPath)
+		}
+	}
+
+	walk(cmd.NewRootCmd().Command, nil)
+
+	require.NotEmpty(t, paths)
+
+	for _, path := range paths {
+		t.Run(strings.Join(path, " "), func(t *testing.T) {
+			root := cmd.NewRootCmd()
+
+			var out bytes.Buffer
+
+			root.SetOut(&out)
+			root.SetErr(&out)
+			root.SetArgs(append(path, "--help"))
+
+			require.NotPanics(t, func() {
+				require.NoError(t, root.Execute())
+			})
+
+			assert.Contains(t, out.String(), "Usage:")
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+)
+
+const historyTabPadding = 3
+
+func NewHistoryCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	historyCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "history",
+		Short: "List the revisions of the configuration applied to the cluster, or show one of them",
+		Long: `List the revisions of the configuration applied to the cluster, or show one of them.
+Each apply that completes stores the configuration file in the cluster as a new revision, with the furyctl and the distribution versions, the user and host that applied it and the time of the apply. The cluster keeps the last ` + strconv.Itoa(state.HistoryLimit) + ` revisions.
+The command reaches the cluster with the kubeconfig in the KUBECONFIG environment variable.`,
+		Example: `  furyctl history                          list the revisions
+  furyctl history --revision 3             print the configuration file of revision 3
+  furyctl history --revision 3 --rendered  print the configuration of revision 3 with the dynamic values resolved
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+			// Bind the flags first: a flag on the command line has precedence over the configuration file.
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				logrus.Fatalf("error while binding flags: %v", err)
+			}
+
+			if err := flags.LoadAndMergeCommandFlags("history"); err != nil {
+				logrus.Fatalf("failed to load flags from configuration: %v", err)
+			}
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			execx.Debug = viper.GetBool("debug")
+
+			encryptionKey, err := flags.GetEncryptionKeyFromViper()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("%w: %w", ErrParsingFlag, err)
+			}
+
+			stateStore := newHistoryStore(encryptionKey, flags.GetStateBackendFromViper())
+
+			number := viper.GetInt("revision")
+
+			if number == 0 {
+				revisions, err := stateStore.ListRevisions()
+				if err != nil {
+					cmdEvent.AddErrorMessage(err)
+					tracker.Track(cmdEvent)
+
+					return fmt.Errorf("error while listing configuration revisions: %w", err)
+				}
+
+				if len(revisions) == 0 {
+					logrus.Info("The cluster has no configuration revisions yet, they are stored by the next apply")
+				} else {
+					fmt.Print(formatRevisions(revisions))
+				}
+
+				cmdEvent.AddSuccessMessage("history command executed successfully")
+				tracker.Track(cmdEvent)
+
+				return nil
+			}
+
+			rev, err := stateStore.GetRevision(number)
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while getting configuration revision: %w", err)
+			}
+
+			logrus.Infof("Revision %d, %s", rev.Number, formatRevisionOrigin(rev))
+
+			if viper.GetBool("rendered") {
+				fmt.Print(string(rev.Rendered))
+			} else {
+				fmt.Print(string(rev.Config))
+			}
+
+			cmdEvent.AddSuccessMessage("history command executed successfully")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	historyCmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file, furyctl reads the flags section from it",
+	)
+
+	historyCmd.Flags().Int(
+		"revision",
+		0,
+		"Print the configuration file of this revision instead of listing the revisions",
+	)
+
+	historyCmd.Flags().Bool(
+		"rendered",
+		false,
+		"With --revision, print the configuration with its dynamic values resolved, as furyctl applied it",
+	)
+
+	return historyCmd
+}
+
+// newHistoryStore returns a store that reads the configuration history from the state backend, and
+// decrypts it with the key.
+func newHistoryStore(key encryption.Key, backendConf backend.Config) *state.Store {
+	return &state.Store{Key: key, BackendConfig: backendConf}
+}
+
+func formatRevisions(revisions []state.Revision) string {
+	var sb strings.Builder
+
+	w := tabwriter.NewWriter(&sb, 0, 0, historyTabPadding, ' ', 0)
+
+	_, _ = fmt.Fprintln(w, "REVISION\tAPPLIED AT\tDISTRIBUTION\tFURYCTL\tAPPLIED BY")
+
+	for _, rev := range revisions {
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			rev.Number,
+			rev.AppliedAt.UTC().Format("2006-01-02 15:04:05 (UTC)"),
+			rev.DistributionVersion,
+			rev.FuryctlVersion,
+			rev.User,
+		)
+	}
+
+	_ = w.Flush()
+
+	return sb.String()
+}
+
+func formatRevisionOrigin(rev state.Revision) string {
+	return fmt.Sprintf(
+		"distribution %s, applied at %s by %s with furyctl %s",
+		rev.DistributionVersion,
+		rev.AppliedAt.UTC().Format("2006-01-02 15:04:05 (UTC)"),
+		rev.User,
+		rev.FuryctlVersion,
+	)
+}
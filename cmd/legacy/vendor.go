@@ -50,7 +50,7 @@ func NewVendorCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
 			flags := getLegacyVendorCmdFlags()
 
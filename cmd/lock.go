@@ -0,0 +1,23 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sighupio/furyctl/cmd/lock"
+)
+
+func NewLockCmd() *cobra.Command {
+	lockCmd := &cobra.Command{
+		Use:   "lock",
+		Short: "Inspect or break the lock that stops concurrent furyctl executions on a cluster",
+	}
+
+	lockCmd.AddCommand(lock.NewStatusCmd())
+	lockCmd.AddCommand(lock.NewBreakCmd())
+
+	return lockCmd
+}
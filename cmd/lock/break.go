@@ -0,0 +1,98 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lock
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/cluster"
+)
+
+func NewBreakCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	breakCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "break",
+		Short: "Remove the lock of a cluster, whoever holds it",
+		Long: `Remove the lock of the cluster in the configuration file, whoever holds it.
+Use it when an execution on another machine ended without releasing the Lease in the cluster: furyctl cannot tell if an execution of another host is still running. An execution that still runs is not stopped, make sure that it is not there before breaking its lock.`,
+		Example: `  furyctl lock break                    remove the local lock of the cluster
+  furyctl lock break --backend cluster  remove the local lock and the Lease in the cluster
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = preRun(cmd)
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			clusterName, locker, err := newLocker()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			statuses, err := locker.Status()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while getting the lock status of cluster %s: %w", clusterName, err)
+			}
+
+			msg := fmt.Sprintf("\nWARNING: You are about to break the lock of cluster %s:", clusterName)
+
+			for _, s := range statuses {
+				msg += "\n  " + formatStatus(s)
+			}
+
+			confirmed, err := cluster.AskConfirmationWithMessage(viper.GetBool("force"), msg)
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			if !confirmed {
+				return nil
+			}
+
+			if err := locker.Break(); err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while breaking the lock of cluster %s: %w", clusterName, err)
+			}
+
+			fmt.Printf("Lock of cluster %s removed\n", clusterName)
+
+			cmdEvent.AddSuccessMessage("lock successfully broken")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	setupLockCmdFlags(breakCmd)
+
+	breakCmd.Flags().Bool(
+		"force",
+		false,
+		"WARNING: furyctl won't ask for confirmation and will remove the lock",
+	)
+
+	return breakCmd
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lock
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/flags"
+	clusterlock "github.com/sighupio/furyctl/internal/lock"
+	"github.com/sighupio/furyctl/internal/tool/kubectl"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+// preRun is the PreRun every `furyctl lock` subcommand shares.
+func preRun(cmd *cobra.Command) analytics.Event {
+	cmdEvent := analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+	// Bind the flags first: a flag on the command line has precedence over the configuration file.
+	if err := viper.BindPFlags(cmd.Flags()); err != nil {
+		logrus.Fatalf("error while binding flags: %v", err)
+	}
+
+	if err := flags.LoadAndMergeCommandFlags("lock"); err != nil {
+		logrus.Fatalf("failed to load flags from configuration: %v", err)
+	}
+
+	return cmdEvent
+}
+
+// newLocker reads the cluster name from the configuration file and returns the locker of the backend
+// selected with the --backend flag.
+func newLocker() (string, clusterlock.Locker, error) {
+	furyctlPath, err := filepath.Abs(flags.GetComposedConfigPathFromViper())
+	if err != nil {
+		return "", nil, fmt.Errorf("error while getting configuration file absolute path: %w", err)
+	}
+
+	furyctlConf, err := yamlx.FromFileV3[config.Furyctl](furyctlPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("error while reading configuration file: %w", err)
+	}
+
+	binPath := viper.GetString("bin-path")
+	if binPath == "" {
+		binPath = filepath.Join(viper.GetString("outdir"), ".furyctl", "bin")
+	}
+
+	locker, err := clusterlock.New(
+		viper.GetString("backend"),
+		furyctlConf.Metadata.Name,
+		clusterlock.NewInfo(),
+		clusterlock.ClusterOptions{
+			KubectlPath: kubectl.FindBin(binPath),
+			Kubeconfig:  clusterlock.Kubeconfig(),
+		},
+	)
+	if err != nil {
+		return "", nil, fmt.Errorf("error while creating locker: %w", err)
+	}
+
+	return furyctlConf.Metadata.Name, locker, nil
+}
+
+func setupLockCmdFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file",
+	)
+
+	cmd.Flags().String(
+		"backend",
+		clusterlock.BackendLocal,
+		"Lock backend to use. Options are: local, cluster. The cluster backend also reads the local lock, "+
+			"and reaches the cluster with the kubeconfig in the KUBECONFIG environment variable",
+	)
+
+	cmd.Flags().StringP(
+		"bin-path",
+		"b",
+		"",
+		"Path to the folder where all the dependencies' binaries are installed. "+
+			"The cluster backend looks for kubectl inside this folder, and falls back to the one in PATH",
+	)
+
+	if err := cmd.RegisterFlagCompletionFunc("backend", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return clusterlock.Backends(), cobra.ShellCompDirectiveDefault
+	}); err != nil {
+		logrus.Fatalf("error while registering flag completion: %v", err)
+	}
+}
+
+// formatStatus renders the status of one backend as a single line.
+func formatStatus(s clusterlock.Status) string {
+	switch {
+	case s.Locked && s.Holder != nil:
+		return fmt.Sprintf("%s: locked, %s", s.Backend, s.Holder)
+
+	case s.Locked:
+		return s.Backend + ": locked"
+
+	case s.Stale:
+		return fmt.Sprintf("%s: not locked, stale lock left by an execution that is no longer running, %s", s.Backend, s.Holder)
+
+	default:
+		return s.Backend + ": not locked"
+	}
+}
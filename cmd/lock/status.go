@@ -0,0 +1,69 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lock
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+)
+
+func NewStatusCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	statusCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "status",
+		Short: "Show which furyctl execution holds the lock of a cluster",
+		Long: `Show which furyctl execution holds the lock of the cluster in the configuration file: the user and host that run it, its PID, its command and its start time.
+A stale lock is the record of an execution that ended without releasing the lock, the next apply or delete recovers it automatically.`,
+		Example: `  furyctl lock status                    show the local lock of the cluster
+  furyctl lock status --backend cluster  show the local lock and the Lease in the cluster
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = preRun(cmd)
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			clusterName, locker, err := newLocker()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			statuses, err := locker.Status()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while getting the lock status of cluster %s: %w", clusterName, err)
+			}
+
+			fmt.Printf("Lock status of cluster %s:\n", clusterName)
+
+			for _, s := range statuses {
+				fmt.Println("  " + formatStatus(s))
+			}
+
+			cmdEvent.AddSuccessMessage("lock status successfully retrieved")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	setupLockCmdFlags(statusCmd)
+
+	return statusCmd
+}
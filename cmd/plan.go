@@ -0,0 +1,340 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/airgap"
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/git"
+	"github.com/sighupio/furyctl/internal/lock"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+	logrusx "github.com/sighupio/furyctl/internal/x/logrus"
+)
+
+const (
+	planOutputText = "text"
+	planOutputJSON = "json"
+)
+
+var ErrInvalidPlanOutput = errors.New("invalid output format, supported values are: text, json")
+
+type PlanCmdFlags struct {
+	ClusterCmdFlags
+
+	Output    string
+	ReportDir string
+}
+
+func NewPlanCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	planCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "plan",
+		Short: "Show what an apply would change in the cluster, without changing it",
+		Long: `Run every phase of the apply in dry-run mode and collect what the apply would do into a single report:
+the configuration changes, the reducers and migrations, the resources that Terraform adds, changes and
+destroys, and the manifests that the distribution phase creates, changes or prunes in the cluster.
+
+The report is printed and saved as plan.txt and plan.json in the report folder.`,
+		Example: `  furyctl plan                             Plan the apply of the default configuration file
+  furyctl plan --phase distribution        Plan the distribution phase only
+  furyctl plan --output json               Print the report as JSON
+  furyctl plan --upgrade                   Plan the upgrade to the version in the configuration file
+`,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				logrus.Fatalf("error while binding flags: %v", err)
+			}
+
+			// Load and merge flags from configuration file.
+			if err := flags.LoadAndMergeCommandFlags("plan"); err != nil {
+				logrus.Fatalf("%v", err)
+			}
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			if err := airgap.MaybePrepare(); err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error preparing air-gapped bundle: %w", err)
+			}
+
+			cmdFlags, err := getPlanCmdFlags()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			// The logs must not mix with a machine-readable output.
+			if cmdFlags.Output == planOutputJSON {
+				logrusx.RedirectStdout(os.Stderr)
+			}
+
+			report, err := applyConfiguration(cmdFlags.ClusterCmdFlags, cmdEvent, tracker, true)
+			if err != nil {
+				return err
+			}
+
+			reportDir := cmdFlags.ReportDir
+			if reportDir == "" {
+				reportDir = filepath.Join(cmdFlags.Outdir, ".furyctl", report.Cluster, "plan")
+			}
+
+			files, err := report.Write(reportDir)
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			if cmdFlags.Output == planOutputJSON {
+				out, err := report.JSON()
+				if err != nil {
+					cmdEvent.AddErrorMessage(err)
+					tracker.Track(cmdEvent)
+
+					return err
+				}
+
+				fmt.Print(string(out))
+			} else {
+				fmt.Print(report.String())
+			}
+
+			logrus.Infof("Plan saved to %s", strings.Join(files, " and "))
+
+			cmdEvent.AddSuccessMessage("plan command executed successfully")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	setupPlanCmdFlags(planCmd)
+
+	return planCmd
+}
+
+func getPlanCmdFlags() (PlanCmdFlags, error) {
+	var err error
+
+	binPath := viper.GetString("bin-path")
+	if binPath == "" {
+		binPath = filepath.Join(viper.GetString("outdir"), ".furyctl", "bin")
+	} else {
+		binPath, err = filepath.Abs(binPath)
+		if err != nil {
+			return PlanCmdFlags{}, fmt.Errorf("error while getting absolute path for bin folder: %w", err)
+		}
+	}
+
+	distroPatchesLocation := viper.GetString("distro-patches")
+	if distroPatchesLocation != "" {
+		distroPatchesLocation, err = filepath.Abs(distroPatchesLocation)
+		if err != nil {
+			return PlanCmdFlags{}, fmt.Errorf("error while getting absolute path of distro patches location: %w", err)
+		}
+	}
+
+	furyctlPath := flags.GetComposedConfigPathFromViper()
+	if furyctlPath == "" {
+		return PlanCmdFlags{}, fmt.Errorf("%w --config: cannot be an empty string", ErrParsingFlag)
+	}
+
+	furyctlPath, err = filepath.Abs(furyctlPath)
+	if err != nil {
+		return PlanCmdFlags{}, fmt.Errorf("error while getting configuration file absolute path: %w", err)
+	}
+
+	phase := viper.GetString("phase")
+	if err := cluster.CheckPhase(phase); err != nil {
+		return PlanCmdFlags{}, fmt.Errorf("%w: %s: %s", ErrParsingFlag, "phase", err.Error())
+	}
+
+	typedGitProtocol, err := git.ParseProtocol(viper.GetString("git-protocol"))
+	if err != nil {
+		return PlanCmdFlags{}, fmt.Errorf("%w: %w", ErrParsingFlag, err)
+	}
+
+	output := viper.GetString("output")
+	if !slices.Contains(planOutputs(), output) {
+		return PlanCmdFlags{}, fmt.Errorf("%w: %s: %w", ErrParsingFlag, "output", ErrInvalidPlanOutput)
+	}
+
+	clusterState, err := getClusterStateCmdFlags()
+	if err != nil {
+		return PlanCmdFlags{}, err
+	}
+
+	reportDir := viper.GetString("report-dir")
+	if reportDir != "" {
+		reportDir, err = filepath.Abs(reportDir)
+		if err != nil {
+			return PlanCmdFlags{}, fmt.Errorf("error while getting absolute path of report folder: %w", err)
+		}
+	}
+
+	return PlanCmdFlags{
+		ClusterCmdFlags: ClusterCmdFlags{
+			ClusterSkipsCmdFlags: ClusterSkipsCmdFlags{
+				// A dry run neither connects to the VPN nor upgrades nodes.
+				SkipVpn:            true,
+				SkipDepsDownload:   viper.GetBool("skip-deps-download"),
+				SkipDepsValidation: viper.GetBool("skip-deps-validation"),
+			},
+			Timeouts: Timeouts{
+				ProcessTimeout: viper.GetInt("timeout"),
+			},
+			Debug:                 viper.GetBool("debug"),
+			FuryctlPath:           furyctlPath,
+			DistroLocation:        viper.GetString("distro-location"),
+			Phase:                 phase,
+			BinPath:               binPath,
+			DryRun:                true,
+			NoTTY:                 viper.GetBool("no-tty"),
+			GitProtocol:           typedGitProtocol,
+			Force:                 []string{},
+			Outdir:                viper.GetString("outdir"),
+			Upgrade:               viper.GetBool("upgrade"),
+			UpgradePathLocation:   viper.GetString("upgrade-path-location"),
+			DistroPatchesLocation: distroPatchesLocation,
+			PostApplyPhases:       []string{},
+			LockBackend:           lock.BackendLocal,
+			ClusterStateCmdFlags:  clusterState,
+		},
+		Output:    output,
+		ReportDir: reportDir,
+	}, nil
+}
+
+func planOutputs() []string {
+	return []string{planOutputText, planOutputJSON}
+}
+
+func setupPlanCmdFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file",
+	)
+
+	cmd.Flags().StringP(
+		"phase",
+		"p",
+		"",
+		"Limit the plan to a specific phase. Options are: "+strings.Join(cluster.MainPhases(), ", "),
+	)
+
+	if err := cmd.RegisterFlagCompletionFunc("phase", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return cluster.MainPhases(), cobra.ShellCompDirectiveDefault
+	}); err != nil {
+		logrus.Fatalf("error while registering flag completion: %v", err)
+	}
+
+	cmd.Flags().StringP(
+		"distro-location",
+		"",
+		"",
+		"Location where to download schemas, defaults, and the distribution manifests from. "+
+			"It can either be a local path (eg: /path/to/distribution) or "+
+			"a remote URL (eg: git::git@github.com:sighupio/distribution?depth=1&ref=BRANCH_NAME). "+
+			"Any format supported by hashicorp/go-getter can be used",
+	)
+
+	cmd.Flags().String(
+		"distro-patches",
+		"",
+		"Location where the distribution's user-made patches can be downloaded from. "+
+			"This can be either a local path (eg: /path/to/distro-patches) or "+
+			"a remote URL (eg: git::git@github.com:your-org/distro-patches?depth=1&ref=BRANCH_NAME). "+
+			"Any format supported by hashicorp/go-getter can be used."+
+			" Patches within this location must be in a folder named after the distribution version (eg: v1.29.0) and "+
+			"must have the same structure as the distribution's repository",
+	)
+
+	cmd.Flags().StringP(
+		"bin-path",
+		"b",
+		"",
+		"Path to the folder where all the dependencies' binaries are downloaded",
+	)
+
+	cmd.Flags().Bool(
+		"skip-deps-download",
+		false,
+		"Skip downloading the distribution modules, installers and binaries",
+	)
+
+	cmd.Flags().Bool(
+		"skip-deps-validation",
+		false,
+		"Skip validating dependencies",
+	)
+
+	airgap.RegisterFlags(cmd)
+
+	cmd.Flags().Int(
+		"timeout",
+		3600, //nolint:mnd,revive // ignore magic number linters
+		"Timeout for the whole plan process, expressed in seconds",
+	)
+
+	cmd.Flags().Bool(
+		"upgrade",
+		false,
+		"When set will plan the upgrade to the version in the configuration file, including the upgrade migrations",
+	)
+
+	cmd.Flags().StringP(
+		"upgrade-path-location",
+		"",
+		"",
+		"Set to use a custom location for the upgrade scripts instead of the embedded ones",
+	)
+
+	cmd.Flags().String(
+		"output",
+		planOutputText,
+		"Output format. Options are: "+strings.Join(planOutputs(), ", "),
+	)
+
+	if err := cmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return planOutputs(), cobra.ShellCompDirectiveDefault
+	}); err != nil {
+		logrus.Fatalf("error while registering flag completion: %v", err)
+	}
+
+	cmd.Flags().String(
+		"report-dir",
+		"",
+		"Folder where to save plan.txt and plan.json. Defaults to the plan folder of the cluster in the furyctl outdir",
+	)
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/sighupio/furyctl/internal/analytics"
 	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/runreport"
 )
 
 func NewCertificatesCmd() *cobra.Command {
@@ -31,18 +32,22 @@ func NewCertificatesCmd() *cobra.Command {
 		PreRun: func(cmd *cobra.Command, _ []string) {
 			cmdEvent = preRun(cmd)
 		},
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(_ *cobra.Command, _ []string) (err error) {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
-			renewer, err := newRenewer(cmdEvent, tracker)
+			renewer, runReport, err := newRenewer("renew-certificates", cmdEvent, tracker)
 			if err != nil {
 				return err
 			}
 
-			if err := renewer.RenewCertificates(); err != nil {
+			runReport.Activate()
+
+			defer func() { runReport.Close(err) }()
+
+			if err := runreport.Track("certificates", renewer.RenewCertificates); err != nil {
 				cmdEvent.AddErrorMessage(err)
 				tracker.Track(cmdEvent)
 
@@ -15,6 +15,7 @@ import (
 
 	"github.com/sighupio/furyctl/internal/analytics"
 	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/runreport"
 )
 
 var ErrUnknownKubeconfig = errors.New("unknown kubeconfig")
@@ -38,17 +39,21 @@ func NewKubeconfigsCmd() *cobra.Command {
 		PreRun: func(cmd *cobra.Command, _ []string) {
 			cmdEvent = preRun(cmd)
 		},
-		RunE: func(_ *cobra.Command, args []string) error {
+		RunE: func(_ *cobra.Command, args []string) (err error) {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
-			renewer, err := newRenewer(cmdEvent, tracker)
+			renewer, runReport, err := newRenewer("renew-kubeconfigs", cmdEvent, tracker)
 			if err != nil {
 				return err
 			}
 
+			runReport.Activate()
+
+			defer func() { runReport.Close(err) }()
+
 			users, err := selectKubeconfigs(args, renewer.Users())
 			if err != nil {
 				cmdEvent.AddErrorMessage(err)
@@ -57,7 +62,9 @@ func NewKubeconfigsCmd() *cobra.Command {
 				return err
 			}
 
-			if err := renewer.RenewKubeconfigs(users); err != nil {
+			if err := runreport.Track("kubeconfigs", func() error {
+				return renewer.RenewKubeconfigs(users)
+			}); err != nil {
 				cmdEvent.AddErrorMessage(err)
 				tracker.Track(cmdEvent)
 
@@ -21,6 +21,7 @@ import (
 	"github.com/sighupio/furyctl/internal/distribution"
 	"github.com/sighupio/furyctl/internal/flags"
 	"github.com/sighupio/furyctl/internal/git"
+	"github.com/sighupio/furyctl/internal/runreport"
 	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
 	"github.com/sighupio/furyctl/pkg/dependencies"
@@ -47,13 +48,17 @@ func preRun(cmd *cobra.Command) analytics.Event {
 }
 
 // newRenewer downloads the distribution and the dependencies, validates the configuration file and
-// the dependencies, then builds the renewer for the cluster kind.
-func newRenewer(cmdEvent analytics.Event, tracker *analytics.Tracker) (cluster.Renewer, error) {
-	fail := func(err error) (cluster.Renewer, error) {
+// the dependencies, then builds the renewer for the cluster kind and the run report of the command.
+func newRenewer(
+	command string,
+	cmdEvent analytics.Event,
+	tracker *analytics.Tracker,
+) (cluster.Renewer, *runreport.Report, error) {
+	fail := func(err error) (cluster.Renewer, *runreport.Report, error) {
 		cmdEvent.AddErrorMessage(err)
 		tracker.Track(cmdEvent)
 
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Air-gapped: extract --airgap-bundle (if set) and rewire to run offline before reading flags.
@@ -64,7 +69,7 @@ func newRenewer(cmdEvent analytics.Event, tracker *analytics.Tracker) (cluster.R
 	// Get flags.
 	debug := viper.GetBool("debug")
 	binPath := viper.GetString("bin-path")
-	furyctlPath := viper.GetString("config")
+	furyctlPath := flags.GetComposedConfigPathFromViper()
 	outDir := viper.GetString("outdir")
 	distroLocation := viper.GetString("distro-location")
 	gitProtocol := viper.GetString("git-protocol")
@@ -177,7 +182,15 @@ func newRenewer(cmdEvent analytics.Event, tracker *analytics.Tracker) (cluster.R
 		renewer.SetProperty(cluster.RenewerPropertyWorkDir, basePath)
 	}
 
-	return renewer, nil
+	runReport := runreport.New(
+		command,
+		basePath,
+		res.MinimalConf.Metadata.Name,
+		res.MinimalConf.Kind,
+		res.DistroManifest.Version,
+	)
+
+	return renewer, runReport, nil
 }
 
 // registerFlags adds the flags every `furyctl renew` subcommand shares.
@@ -0,0 +1,207 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/airgap"
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/state"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+var (
+	ErrRollbackPlanFile = errors.New("a rollback cannot save or apply a plan file")
+	ErrRollbackComposed = errors.New("the configuration file extends other files or has overlays, a rollback " +
+		"cannot write the configuration of the revision to it: use --dry-run, or write the revision to the files " +
+		"of the composition, see furyctl history --revision")
+)
+
+func NewRollbackCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	rollbackCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "rollback",
+		Short: "Apply again a configuration revision stored in the cluster",
+		Long: `Apply again a configuration revision stored in the cluster, see furyctl history.
+The command writes the configuration file of the revision to the path of --config, then applies it. The previous file, if any, is kept next to it, with the time of the rollback and the .bak extension in its name.
+The apply compares the revision with the configuration in the cluster as usual: the reducers and the migrations run, and the changes to immutable paths or the unsupported changes stop the rollback.
+A configuration file that extends other files or has overlays cannot take the composed configuration of the revision: the rollback of such a file works only with --dry-run.
+The command accepts the flags of furyctl apply, and reads them from the apply section of the flags field of the configuration file.`,
+		Example: `  furyctl rollback --to-revision 3              apply again the configuration of revision 3
+  furyctl rollback --to-revision 3 --dry-run    write the configuration of revision 3 and check what its apply would do
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				logrus.Fatalf("error while binding flags: %v", err)
+			}
+
+			// A rollback is an apply: it takes the flags of the apply section.
+			if err := flags.LoadAndMergeCommandFlags("apply"); err != nil {
+				logrus.Fatalf("%v", err)
+			}
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			// Air-gapped: if --airgap-bundle is set, extract it and rewire to run offline before
+			// reading the other flags (it sets skip-deps-download and distro-location).
+			if err := airgap.MaybePrepare(); err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error preparing air-gapped bundle: %w", err)
+			}
+
+			cmdFlags, err := getApplyCmdFlags()
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			if cmdFlags.SavePlan != "" || cmdFlags.PlanFile != "" {
+				cmdEvent.AddErrorMessage(ErrRollbackPlanFile)
+				tracker.Track(cmdEvent)
+
+				return ErrRollbackPlanFile
+			}
+
+			// The revision is the composed configuration: writing it over the file of --config would drop the
+			// composition, and writing it over the composed file would not last past this command.
+			if flags.IsConfigComposed() && !cmdFlags.DryRun {
+				cmdEvent.AddErrorMessage(ErrRollbackComposed)
+				tracker.Track(cmdEvent)
+
+				return ErrRollbackComposed
+			}
+
+			number := viper.GetInt("to-revision")
+			if number <= 0 {
+				err := fmt.Errorf("%w --to-revision: must be a revision number, see furyctl history", ErrParsingFlag)
+
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			rev, err := newHistoryStore(cmdFlags.EncryptionKey, cmdFlags.StateBackend).GetRevision(number)
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while getting configuration revision: %w", err)
+			}
+
+			logrus.Infof("Rolling back to revision %d, %s", rev.Number, formatRevisionOrigin(rev))
+
+			if cmdFlags.DryRun {
+				logrus.Info("Dry run mode enabled, no changes will be applied")
+
+				// The configuration file stays as it is: the dry run applies the revision from a copy.
+				revisionPath, err := writeDryRunRevisionConfig(cmdFlags.FuryctlPath, rev)
+				if err != nil {
+					cmdEvent.AddErrorMessage(err)
+					tracker.Track(cmdEvent)
+
+					return err
+				}
+				defer os.Remove(revisionPath)
+
+				cmdFlags.FuryctlPath = revisionPath
+			} else if err := writeRevisionConfig(cmdFlags.FuryctlPath, rev); err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			if _, err := applyConfiguration(cmdFlags, cmdEvent, tracker, false); err != nil {
+				return err
+			}
+
+			cmdEvent.AddSuccessMessage("rollback succeeded")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	setupApplyCmdFlags(rollbackCmd)
+
+	rollbackCmd.Flags().Int(
+		"to-revision",
+		0,
+		"Number of the configuration revision to apply again, see furyctl history",
+	)
+
+	if err := rollbackCmd.MarkFlagRequired("to-revision"); err != nil {
+		logrus.Fatalf("error while marking flag as required: %v", err)
+	}
+
+	return rollbackCmd
+}
+
+// writeRevisionConfig writes the configuration file of the revision to the given path. The file already
+// there is kept with the .bak extension.
+func writeRevisionConfig(furyctlPath string, rev state.Revision) error {
+	current, err := os.ReadFile(furyctlPath)
+
+	switch {
+	case err == nil:
+		backupPath := fmt.Sprintf("%s.%s.bak", furyctlPath, time.Now().UTC().Format("20060102T150405Z"))
+
+		if err := os.WriteFile(backupPath, current, iox.RWPermAccess); err != nil {
+			return fmt.Errorf("error while saving a copy of the configuration file: %w", err)
+		}
+
+		logrus.Infof("The previous configuration file is saved to %s", backupPath)
+
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("error while reading configuration file: %w", err)
+	}
+
+	if err := os.WriteFile(furyctlPath, rev.Config, iox.RWPermAccess); err != nil {
+		return fmt.Errorf("error while writing the configuration file of revision %d: %w", rev.Number, err)
+	}
+
+	return nil
+}
+
+// writeDryRunRevisionConfig writes the configuration file of the revision to a temporary file next to the
+// given path, so that the paths relative to the configuration file stay the same, and returns its path.
+func writeDryRunRevisionConfig(furyctlPath string, rev state.Revision) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(furyctlPath), fmt.Sprintf(".furyctl-revision-%d-*.yaml", rev.Number))
+	if err != nil {
+		return "", fmt.Errorf("error while creating the configuration file of revision %d: %w", rev.Number, err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(rev.Config); err != nil {
+		return "", fmt.Errorf("error while writing the configuration file of revision %d: %w", rev.Number, err)
+	}
+
+	return f.Name(), nil
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/state"
+)
+
+func TestWriteRevisionConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	furyctlPath := filepath.Join(dir, "furyctl.yaml")
+	rev := state.Revision{Number: 3, Config: []byte("revision: 3\n")}
+
+	// Without a configuration file there is nothing to keep.
+	require.NoError(t, writeRevisionConfig(furyctlPath, rev))
+
+	backups, err := filepath.Glob(furyctlPath + ".*.bak")
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+
+	require.NoError(t, os.WriteFile(furyctlPath, []byte("revision: 4\n"), 0o600))
+	require.NoError(t, writeRevisionConfig(furyctlPath, rev))
+
+	got, err := os.ReadFile(furyctlPath)
+	require.NoError(t, err)
+	assert.Equal(t, "revision: 3\n", string(got))
+
+	backups, err = filepath.Glob(furyctlPath + ".*.bak")
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+
+	backup, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "revision: 4\n", string(backup))
+}
+
+func TestWriteDryRunRevisionConfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	furyctlPath := filepath.Join(dir, "furyctl.yaml")
+	rev := state.Revision{Number: 3, Config: []byte("revision: 3\n")}
+
+	require.NoError(t, os.WriteFile(furyctlPath, []byte("revision: 4\n"), 0o600))
+
+	revisionPath, err := writeDryRunRevisionConfig(furyctlPath, rev)
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(revisionPath))
+
+	got, err := os.ReadFile(revisionPath)
+	require.NoError(t, err)
+	assert.Equal(t, "revision: 3\n", string(got))
+
+	// The configuration file is untouched and has no backup.
+	current, err := os.ReadFile(furyctlPath)
+	require.NoError(t, err)
+	assert.Equal(t, "revision: 4\n", string(current))
+
+	backups, err := filepath.Glob(furyctlPath + ".*.bak")
+	require.NoError(t, err)
+	assert.Empty(t, backups)
+}
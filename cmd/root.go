@@ -19,23 +19,45 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/sighupio/furyctl/internal/analytics"
 	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/flags"
 	"github.com/sighupio/furyctl/internal/git"
+	"github.com/sighupio/furyctl/internal/state/backend"
+	"github.com/sighupio/furyctl/internal/tracing"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
 	iox "github.com/sighupio/furyctl/internal/x/io"
 	logrusx "github.com/sighupio/furyctl/internal/x/logrus"
 )
 
 type rootConfig struct {
-	Debug            bool
-	DisableAnalytics bool
-	DisableTty       bool
-	GitProtocol      git.Protocol
-	Log              string
-	Outdir           string
-	Spinner          *spinner.Spinner
-	Workdir          string
+	AnalyticsFile          string
+	AnalyticsSink          string
+	AnalyticsWebhookSecret string
+	AnalyticsWebhookURL    string
+	Debug                  bool
+	DisableAnalytics       bool
+	DisableTty             bool
+	EncryptionKeyFile      string
+	Environment            string
+	GitProtocol            git.Protocol
+	Log                    string
+	OTLPEndpoint           string
+	OTLPInsecure           bool
+	Outdir                 string
+	PolicyDir              string
+	Spinner                *spinner.Spinner
+	StateBackend           string
+	StateDir               string
+	StateS3Bucket          string
+	StateS3Endpoint        string
+	StateS3Prefix          string
+	StateS3Region          string
+	StrictTemplates        []string
+	StrictTemplatesAllow   []string
+	Workdir                string
 }
 
 type RootCommand struct {
@@ -65,13 +87,6 @@ furyctl is a command line interface tool to manage the full lifecycle of SIGHUP
 
 				ctn := app.GetContainerInstance()
 
-				// Configure analytics.
-				tracker := ctn.Tracker()
-				if viper.GetBool("disable-analytics") {
-					tracker.Disable()
-				}
-				defer tracker.Flush()
-
 				// Tab-autocompletion.
 				if cmd.Name() == "__complete" {
 					oldPreRunFunc := cmd.PreRun
@@ -164,6 +179,49 @@ furyctl is a command line interface tool to manage the full lifecycle of SIGHUP
 
 				logrus.Debugf("Writing logs to %s", logPath)
 
+				// Configure analytics. The sink comes from the flags, so it must be set after loading them.
+				tracker := ctn.Tracker()
+				if viper.GetBool("disable-analytics") {
+					tracker.Disable()
+				} else if cmd.Name() != "__complete" {
+					if err := configureAnalyticsSink(tracker, outDir); err != nil {
+						logrus.Fatalf("%v", err)
+					}
+				}
+
+				// Check where the stores keep the state of the cluster. The state directory must be an absolute
+				// path, as the outdir, because the current directory can change during execution.
+				if cmd.Name() != "__complete" {
+					stateBackend := flags.GetStateBackendFromViper()
+
+					if stateBackend.Dir != "" {
+						stateBackend.Dir, err = filepath.Abs(stateBackend.Dir)
+						if err != nil {
+							logrus.Fatalf("error while getting absolute path for state directory: %v", err)
+						}
+
+						viper.Set("state-dir", stateBackend.Dir)
+					}
+
+					if err := stateBackend.Validate(); err != nil {
+						logrus.Fatalf("%v", err)
+					}
+				}
+
+				// Configure the export of the traces, the completion of the command line does not run one.
+				if cmd.Name() != "__complete" {
+					if err := tracing.Start(
+						tracing.Config{
+							Endpoint: viper.GetString("otlp-endpoint"),
+							Insecure: viper.GetBool("otlp-insecure"),
+						},
+						"furyctl "+cobrax.GetFullname(cmd),
+						ctn.Version,
+					); err != nil {
+						logrus.Warnf("Traces will not be exported: %v", err)
+					}
+				}
+
 				// Deprected flags.
 				https := viper.GetBool("https")
 				if !https {
@@ -192,6 +250,37 @@ furyctl is a command line interface tool to manage the full lifecycle of SIGHUP
 		"Disable analytics",
 	)
 
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.AnalyticsSink,
+		"analytics-sink",
+		analytics.SinkMixpanel,
+		"Where to send the analytics events. Options are: "+strings.Join(analytics.Sinks(), ", ")+
+			". The file and stderr sinks write one JSON record for each line. stdout is an alias of stderr: "+
+			"the events go to the standard error, so that they do not mix with the output of the commands",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.AnalyticsFile,
+		"analytics-file",
+		"",
+		"Path of the file where the file analytics sink appends the events. Default is '<outdir>/.furyctl/analytics.jsonl'",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.AnalyticsWebhookURL,
+		"analytics-webhook-url",
+		"",
+		"URL where the webhook analytics sink posts the events",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.AnalyticsWebhookSecret,
+		"analytics-webhook-secret",
+		"",
+		"Secret used to sign the requests of the webhook analytics sink with HMAC-SHA256, in the "+
+			analytics.SignatureHeader+" header. Prefer the FURYCTL_ANALYTICS_WEBHOOK_SECRET environment variable",
+	)
+
 	rootCmd.PersistentFlags().BoolVarP(
 		&rootCmd.config.DisableTty,
 		"no-tty",
@@ -224,6 +313,113 @@ furyctl is a command line interface tool to manage the full lifecycle of SIGHUP
 		"Path to a file or folder where to write logs to. Set to 'stdout' write to standard output. Target path will be created if it does not exists. Path is relative to --workdir. Default is '<outdir>/.furyctl/furyctl.<timestamp>-<random number>.log'",
 	)
 
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.EncryptionKeyFile,
+		"encryption-key-file",
+		"",
+		"Path to the key that encrypts the configuration and the upgrade state that furyctl stores in the cluster: "+
+			"an age identity, as written by age-keygen, or a base64 or hex encoded 32 bytes AES key. "+
+			"The FURYCTL_ENCRYPTION_KEY environment variable can hold the key itself instead",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.Environment,
+		"environment",
+		"",
+		"Environment whose overlays furyctl merges over the configuration file, see the overlays field of "+
+			"furyctl.yaml. The FURYCTL_ENVIRONMENT environment variable can set it too",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.PolicyDir,
+		"policy-dir",
+		"",
+		"Path to a directory of policy rules, YAML files of CEL expressions that check the configuration and its "+
+			"changes on top of the schema of the distribution. furyctl validate config reports their violations, the "+
+			"preflight phase stops on the fatal ones",
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootCmd.config.StrictTemplates,
+		"strict-templates",
+		[]string{},
+		"Phases whose templates stop the phase, before any of its tools runs, when they read keys that the "+
+			"configuration does not set, instead of rendering them as <no value>. Options are: "+
+			strings.Join(cluster.StrictTemplatesPhases(), ", "),
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootCmd.config.StrictTemplatesAllow,
+		"strict-templates-allow",
+		[]string{},
+		"Keys that the templates of the strict phases can read even if the configuration does not set them, "+
+			"for the intentionally optional ones (eg: .spec.distribution.modules.auth.**). * stands for a key, "+
+			"** for any number of keys",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.StateBackend,
+		"state-backend",
+		backend.TypeCluster,
+		"Where furyctl keeps the state of the cluster: the configuration, its history and the upgrade state. "+
+			"Options are: "+strings.Join(backend.Types(), ", ")+". Use local or s3 to keep working when the "+
+			"API server of the cluster is not reachable",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.StateDir,
+		"state-dir",
+		"",
+		"Path to the directory where the local state backend keeps the state, one YAML file for each object. "+
+			"The files are in clear unless the configuration is encrypted, see --encryption-key-file",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.StateS3Bucket,
+		"state-s3-bucket",
+		"",
+		"Bucket where the s3 state backend keeps the state. The credentials come from the usual AWS environment "+
+			"variables and files",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.StateS3Prefix,
+		"state-s3-prefix",
+		"",
+		"Prefix of the keys of the objects of the s3 state backend, for example the name of the cluster",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.StateS3Endpoint,
+		"state-s3-endpoint",
+		"",
+		"Endpoint of an S3-compatible service for the s3 state backend, for example http://minio:9000. "+
+			"Empty for AWS S3",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.StateS3Region,
+		"state-s3-region",
+		"",
+		"Region of the bucket of the s3 state backend. Default is the one of the AWS configuration, or us-east-1",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootCmd.config.OTLPEndpoint,
+		"otlp-endpoint",
+		"",
+		"Export the traces of the run to this OTLP/HTTP endpoint, for example https://collector:4318. The command is "+
+			"the root span, the phases are its children and the tool commands they run are the leaves. "+
+			"The standard OTEL_EXPORTER_OTLP_ENDPOINT environment variable also enables the export",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootCmd.config.OTLPInsecure,
+		"otlp-insecure",
+		false,
+		"Send the traces to the OTLP endpoint over plain HTTP instead of HTTPS",
+	)
+
 	rootCmd.PersistentFlags().VarP(
 		&git.ProtocolFlag{Protocol: git.ProtocolHTTPS},
 		"git-protocol",
@@ -249,20 +445,29 @@ furyctl is a command line interface tool to manage the full lifecycle of SIGHUP
 		logrus.Fatalf("error while binding flags: %v", err)
 	}
 
+	rootCmd.AddCommand(NewAirgapCmd())
 	rootCmd.AddCommand(NewApplyCmd())
 	rootCmd.AddCommand(NewCompletionCmd(rootCmd.Root()))
+	rootCmd.AddCommand(NewConfigCmd())
 	rootCmd.AddCommand(NewConnectCmd())
 	rootCmd.AddCommand(NewCreateCmd())
 	rootCmd.AddCommand(NewDeleteCmd())
 	rootCmd.AddCommand(NewDiffCmd())
+	rootCmd.AddCommand(NewDriftCmd())
 	rootCmd.AddCommand(NewDownloadCmd())
 	rootCmd.AddCommand(NewDumpCmd())
+	rootCmd.AddCommand(NewEncryptionCmd())
 	rootCmd.AddCommand(NewGetCmd())
+	rootCmd.AddCommand(NewHistoryCmd())
 	rootCmd.AddCommand(NewLegacyCmd())
+	rootCmd.AddCommand(NewLockCmd())
+	rootCmd.AddCommand(NewPlanCmd())
+	rootCmd.AddCommand(NewRollbackCmd())
 	rootCmd.AddCommand(NewValidateCmd())
 	rootCmd.AddCommand(NewVersionCmd())
 	rootCmd.AddCommand(NewRenewCmd())
 	rootCmd.AddCommand(NewServeCmd())
+	rootCmd.AddCommand(NewStateCmd())
 
 	return rootCmd
 }
@@ -275,6 +480,40 @@ func initConfig() {
 	viper.AutomaticEnv()
 }
 
+// configureAnalyticsSink makes the tracker send the events to the sink selected with the flags. Mixpanel,
+// the default, is already the sink of the tracker.
+func configureAnalyticsSink(tracker *analytics.Tracker, outDir string) error {
+	kind := viper.GetString("analytics-sink")
+	if kind == analytics.SinkMixpanel {
+		return nil
+	}
+
+	file := viper.GetString("analytics-file")
+	if file == "" {
+		file = filepath.Join(outDir, ".furyctl", "analytics.jsonl")
+	}
+
+	// The current directory can change during the run.
+	file, err := filepath.Abs(file)
+	if err != nil {
+		return fmt.Errorf("error while getting absolute path for analytics file: %w", err)
+	}
+
+	sink, err := analytics.NewSink(analytics.SinkConfig{
+		Kind:          kind,
+		File:          file,
+		WebhookURL:    viper.GetString("analytics-webhook-url"),
+		WebhookSecret: viper.GetString("analytics-webhook-secret"),
+	})
+	if err != nil {
+		return fmt.Errorf("error while configuring analytics: %w", err)
+	}
+
+	tracker.UseSink(sink)
+
+	return nil
+}
+
 func createLogFile(path string) (*os.File, error) {
 	// Safety check: prevent creating directories with unexpanded dynamic values.
 	if strings.Contains(path, "{env://") || strings.Contains(path, "{file://") || strings.Contains(path, "{path://") {
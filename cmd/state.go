@@ -0,0 +1,23 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/sighupio/furyctl/cmd/state"
+)
+
+func NewStateCmd() *cobra.Command {
+	stateCmd := &cobra.Command{
+		Use:   "state",
+		Short: "Copy the state of the cluster between the state backends, or pull it to inspect it offline",
+	}
+
+	stateCmd.AddCommand(state.NewMigrateCmd())
+	stateCmd.AddCommand(state.NewPullCmd())
+
+	return stateCmd
+}
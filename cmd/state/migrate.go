@@ -0,0 +1,173 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+)
+
+var (
+	ErrDestinationRequired = errors.New("set the destination backend with --to")
+	ErrSameBackend         = errors.New("the source and the destination backends are the same")
+)
+
+func NewMigrateCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	migrateCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "migrate",
+		Short: "Copy the state of the cluster from the current state backend to another one",
+		Long: `Copy the state of the cluster from the current state backend, the one of --state-backend, to the one of --to: the configuration, the distribution, the report of the last run, the configuration history and the upgrade state.
+The state is copied as it is, encrypted if it is, and the source backend is left untouched. Use the destination backend with --state-backend for the next commands.
+The cluster backend reaches the cluster with the kubeconfig in the KUBECONFIG environment variable.`,
+		Example: `  furyctl state migrate --to local --to-dir ./state                              copy the state from the cluster to the state directory
+  furyctl state migrate --to s3 --to-s3-bucket furyctl --to-s3-prefix prod          copy the state from the cluster to an S3 bucket
+  furyctl state migrate --state-backend local --state-dir ./state --to cluster      copy the state from the state directory back to the cluster
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = preRun(cmd)
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			execx.Debug = viper.GetBool("debug")
+
+			objs, src, dst, err := migrate(flags.GetStateBackendFromViper(), backend.Config{
+				Type:       viper.GetString("to"),
+				Dir:        viper.GetString("to-dir"),
+				S3Bucket:   viper.GetString("to-s3-bucket"),
+				S3Prefix:   viper.GetString("to-s3-prefix"),
+				S3Endpoint: viper.GetString("to-s3-endpoint"),
+				S3Region:   viper.GetString("to-s3-region"),
+			})
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			if len(objs) == 0 {
+				logrus.Warnf("No state found in %s, nothing to copy", src)
+			} else {
+				fmt.Print(formatObjects(objs))
+
+				logrus.Infof("State copied from %s to %s, use it with --state-backend from now on", src, dst)
+			}
+
+			cmdEvent.AddSuccessMessage("state successfully migrated")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	migrateCmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file, furyctl reads the flags section from it",
+	)
+
+	migrateCmd.Flags().String(
+		"to",
+		"",
+		"Backend where to copy the state. Options are: "+strings.Join(backend.Types(), ", "),
+	)
+
+	migrateCmd.Flags().String(
+		"to-dir",
+		"",
+		"Path to the directory of the local destination backend",
+	)
+
+	migrateCmd.Flags().String(
+		"to-s3-bucket",
+		"",
+		"Bucket of the s3 destination backend",
+	)
+
+	migrateCmd.Flags().String(
+		"to-s3-prefix",
+		"",
+		"Prefix of the keys of the objects of the s3 destination backend",
+	)
+
+	migrateCmd.Flags().String(
+		"to-s3-endpoint",
+		"",
+		"Endpoint of an S3-compatible service for the s3 destination backend, empty for AWS S3",
+	)
+
+	migrateCmd.Flags().String(
+		"to-s3-region",
+		"",
+		"Region of the bucket of the s3 destination backend",
+	)
+
+	return migrateCmd
+}
+
+// migrate copies the state from the source backend to the destination one, and returns the objects it copied
+// and the two backends.
+func migrate(srcConfig, dstConfig backend.Config) ([]backend.Object, backend.Backend, backend.Backend, error) {
+	if dstConfig.Type == "" {
+		return nil, nil, nil, ErrDestinationRequired
+	}
+
+	if dstConfig.Dir != "" {
+		dir, err := filepath.Abs(dstConfig.Dir)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error while getting absolute path for state directory: %w", err)
+		}
+
+		dstConfig.Dir = dir
+	}
+
+	if srcConfig.Type == "" {
+		srcConfig.Type = backend.TypeCluster
+	}
+
+	if srcConfig == dstConfig {
+		return nil, nil, nil, ErrSameBackend
+	}
+
+	src, err := newBackend(srcConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	dst, err := newBackend(dstConfig)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	logrus.Infof("Copying the state from %s to %s...", src, dst)
+
+	objs, err := state.Copy(src, dst)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error while copying the state: %w", err)
+	}
+
+	return objs, src, dst, nil
+}
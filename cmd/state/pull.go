@@ -0,0 +1,110 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+)
+
+func NewPullCmd() *cobra.Command {
+	var cmdEvent analytics.Event
+
+	pullCmd := &cobra.Command{
+		Args:  cobra.NoArgs,
+		Use:   "pull",
+		Short: "Copy the state of the cluster to a local directory, to inspect it offline",
+		Long: `Copy the state of the cluster from the current state backend, the one of --state-backend, to a local directory, one YAML file for each object, and list the objects copied.
+The files are in clear unless the configuration is encrypted, see --encryption-key-file. The directory can be used as a local state backend with --state-backend local --state-dir.
+The cluster backend reaches the cluster with the kubeconfig in the KUBECONFIG environment variable.`,
+		Example: `  furyctl state pull                                                      copy the state from the cluster to ./furyctl-state
+  furyctl state pull --state-backend s3 --state-s3-bucket furyctl -O ./prod  copy the state from an S3 bucket to ./prod
+ `,
+		PreRun: func(cmd *cobra.Command, _ []string) {
+			cmdEvent = preRun(cmd)
+		},
+		RunE: func(_ *cobra.Command, _ []string) error {
+			ctn := app.GetContainerInstance()
+
+			tracker := ctn.Tracker()
+			defer tracker.Flush()
+
+			execx.Debug = viper.GetBool("debug")
+
+			objs, src, err := pull(flags.GetStateBackendFromViper(), viper.GetString("output-dir"))
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return err
+			}
+
+			if len(objs) == 0 {
+				logrus.Warnf("No state found in %s", src)
+			} else {
+				fmt.Print(formatObjects(objs))
+			}
+
+			cmdEvent.AddSuccessMessage("state successfully pulled")
+			tracker.Track(cmdEvent)
+
+			return nil
+		},
+	}
+
+	pullCmd.Flags().StringP(
+		"config",
+		"c",
+		"furyctl.yaml",
+		"Path to the configuration file, furyctl reads the flags section from it",
+	)
+
+	pullCmd.Flags().StringP(
+		"output-dir",
+		"O",
+		"furyctl-state",
+		"Path to the directory where to copy the state",
+	)
+
+	return pullCmd
+}
+
+// pull copies the state from the source backend to the output directory, and returns the objects it copied
+// and the source backend.
+func pull(srcConfig backend.Config, outputDir string) ([]backend.Object, backend.Backend, error) {
+	outputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while getting absolute path for output directory: %w", err)
+	}
+
+	if srcConfig.Type == backend.TypeLocal && filepath.Clean(srcConfig.Dir) == outputDir {
+		return nil, nil, ErrSameBackend
+	}
+
+	src, err := newBackend(srcConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logrus.Infof("Copying the state from %s to %s...", src, outputDir)
+
+	objs, err := state.Copy(src, backend.NewLocal(outputDir))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while pulling the state: %w", err)
+	}
+
+	return objs, src, nil
+}
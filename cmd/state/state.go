@@ -0,0 +1,74 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/state/backend"
+	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
+)
+
+const tabPadding = 3
+
+// preRun is the PreRun every `furyctl state` subcommand shares.
+func preRun(cmd *cobra.Command) analytics.Event {
+	cmdEvent := analytics.NewCommandEvent(cobrax.GetFullname(cmd))
+
+	// Bind the flags first: a flag on the command line has precedence over the configuration file.
+	if err := viper.BindPFlags(cmd.Flags()); err != nil {
+		logrus.Fatalf("error while binding flags: %v", err)
+	}
+
+	if err := flags.LoadAndMergeCommandFlags("state"); err != nil {
+		logrus.Fatalf("failed to load flags from configuration: %v", err)
+	}
+
+	return cmdEvent
+}
+
+// newBackend returns the backend of the configuration. The cluster backend reaches the cluster with the
+// kubeconfig in the KUBECONFIG environment variable.
+func newBackend(c backend.Config) (backend.Backend, error) {
+	b, err := backend.New(c, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating state backend: %w", err)
+	}
+
+	return b, nil
+}
+
+// formatObjects returns the table of the objects of the state.
+func formatObjects(objs []backend.Object) string {
+	var sb strings.Builder
+
+	w := tabwriter.NewWriter(&sb, 0, 0, tabPadding, ' ', 0)
+
+	fmt.Fprintln(w, "KIND\tNAME\tKEYS")
+
+	for _, obj := range objs {
+		keys := make([]string, 0, len(obj.Data))
+		for k := range obj.Data {
+			keys = append(keys, k)
+		}
+
+		slices.Sort(keys)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", obj.Kind, obj.Name, strings.Join(keys, ","))
+	}
+
+	w.Flush()
+
+	return sb.String()
+}
@@ -5,10 +5,15 @@
 package validate
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -18,6 +23,7 @@ import (
 	"github.com/sighupio/furyctl/internal/config"
 	"github.com/sighupio/furyctl/internal/flags"
 	"github.com/sighupio/furyctl/internal/git"
+	"github.com/sighupio/furyctl/internal/policy"
 	cobrax "github.com/sighupio/furyctl/internal/x/cobra"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
 	"github.com/sighupio/furyctl/pkg/dependencies"
@@ -25,11 +31,25 @@ import (
 	netx "github.com/sighupio/furyctl/pkg/x/net"
 )
 
+const (
+	outputText  = "text"
+	outputJSON  = "json"
+	outputSARIF = "sarif"
+)
+
 var (
 	ErrValidationFailed = errors.New("configuration file validation failed")
 	ErrParsingFlag      = errors.New("error while parsing flag")
+	ErrInvalidOutput    = errors.New("invalid output format, supported values are: text, json, sarif")
 )
 
+// configReport is the JSON output of the validation of a configuration file.
+type configReport struct {
+	File        string              `json:"file"`
+	Valid       bool                `json:"valid"`
+	Diagnostics []config.Diagnostic `json:"diagnostics"`
+}
+
 func NewConfigCmd() *cobra.Command {
 	var cmdEvent analytics.Event
 
@@ -53,13 +73,19 @@ func NewConfigCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
-			furyctlPath := viper.GetString("config")
+			furyctlPath := flags.GetComposedConfigPathFromViper()
 			distroLocation := viper.GetString("distro-location")
 			gitProtocol := viper.GetString("git-protocol")
 			outDir := viper.GetString("outdir")
 			distroPatchesLocation := viper.GetString("distro-patches")
+			policyDir := viper.GetString("policy-dir")
+			output := viper.GetString("output")
+
+			if !slices.Contains(outputs(), output) {
+				return fmt.Errorf("%w: %s: %w", ErrParsingFlag, "output", ErrInvalidOutput)
+			}
 
 			typedGitProtocol, err := git.ParseProtocol(gitProtocol)
 			if err != nil {
@@ -76,6 +102,25 @@ func NewConfigCmd() *cobra.Command {
 				}
 			}
 
+			if policyDir != "" {
+				policyDir, err = filepath.Abs(policyDir)
+				if err != nil {
+					cmdEvent.AddErrorMessage(err)
+					tracker.Track(cmdEvent)
+
+					return fmt.Errorf("error while getting absolute path for policies directory: %w", err)
+				}
+			}
+
+			// The policy rules are loaded before any work, so that an invalid rules file fails fast.
+			policyRules, err := policy.Load(policyDir)
+			if err != nil {
+				cmdEvent.AddErrorMessage(err)
+				tracker.Track(cmdEvent)
+
+				return fmt.Errorf("error while loading policies: %w", err)
+			}
+
 			var distrodl *dist.Downloader
 
 			client := netx.NewGoGetterClient()
@@ -111,21 +156,44 @@ func NewConfigCmd() *cobra.Command {
 				KFDVersion: res.DistroManifest.Version,
 			})
 
-			if err := config.Validate(furyctlPath, res.RepoPath); err != nil {
+			err = config.Validate(furyctlPath, res.RepoPath)
+			if err != nil {
 				logrus.Debugf("Repository path: %s", res.RepoPath)
+			} else {
+				// The PKI folder check is not a rule of config.Validate, because the other commands that
+				// validate a configuration do not read the PKI. See config.ValidatePKI.
+				err = config.ValidatePKI(furyctlPath)
+			}
 
-				logrus.Error(err)
+			// The policies of the organisation check a configuration that is valid for the distribution.
+			warnings := []config.Diagnostic{}
 
-				cmdEvent.AddErrorMessage(ErrValidationFailed)
-				tracker.Track(cmdEvent)
+			if err == nil {
+				var policyDiags []config.Diagnostic
 
-				return ErrValidationFailed
+				policyDiags, err = config.ValidatePolicies(furyctlPath, res.MinimalConf.Kind, policyRules)
+
+				warnings = lo.Filter(policyDiags, func(d config.Diagnostic, _ int) bool {
+					return d.Severity == flags.ValidationSeverityWarning
+				})
 			}
 
-			// The PKI folder check is not a rule of config.Validate, because the other commands that
-			// validate a configuration do not read the PKI. See config.ValidatePKI.
-			if err := config.ValidatePKI(furyctlPath); err != nil {
-				logrus.Error(err)
+			if output != outputText {
+				diags := append(config.Diagnostics(furyctlPath, err), warnings...)
+
+				if perr := printDiagnostics(output, flags.GetConfigPathFromViper(), err == nil, diags, ctn.Version); perr != nil {
+					return perr
+				}
+			} else {
+				for _, w := range warnings {
+					logrus.Warn(w.String())
+				}
+			}
+
+			if err != nil {
+				if output == outputText {
+					logrus.Error(err)
+				}
 
 				cmdEvent.AddErrorMessage(ErrValidationFailed)
 				tracker.Track(cmdEvent)
@@ -170,5 +238,48 @@ func NewConfigCmd() *cobra.Command {
 			"must have the same structure as the distribution's repository",
 	)
 
+	configCmd.Flags().String(
+		"output",
+		outputText,
+		"Output format of the violations. Options are: "+strings.Join(outputs(), ", ")+
+			". json and sarif print the position of each violation in the configuration file, for editors and CI",
+	)
+
+	if err := configCmd.RegisterFlagCompletionFunc("output", func(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+		return outputs(), cobra.ShellCompDirectiveDefault
+	}); err != nil {
+		logrus.Fatalf("error while registering flag completion: %v", err)
+	}
+
 	return configCmd
 }
+
+func outputs() []string {
+	return []string{outputText, outputJSON, outputSARIF}
+}
+
+// printDiagnostics prints the violations of the configuration file in the json or sarif format.
+func printDiagnostics(output, furyctlPath string, valid bool, diags []config.Diagnostic, furyctlVersion string) error {
+	var (
+		out []byte
+		err error
+	)
+
+	switch output {
+	case outputSARIF:
+		out, err = config.SARIF(diags, furyctlVersion)
+
+	default:
+		out, err = json.MarshalIndent(configReport{File: furyctlPath, Valid: valid, Diagnostics: diags}, "", "  ")
+	}
+
+	if err != nil {
+		return fmt.Errorf("error while formatting the violations: %w", err)
+	}
+
+	if _, err := fmt.Fprintln(os.Stdout, string(out)); err != nil {
+		return fmt.Errorf("error writing output: %w", err)
+	}
+
+	return nil
+}
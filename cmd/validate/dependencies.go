@@ -53,9 +53,9 @@ func NewDependenciesCmd() *cobra.Command {
 			ctn := app.GetContainerInstance()
 
 			tracker := ctn.Tracker()
-			tracker.Flush()
+			defer tracker.Flush()
 
-			furyctlPath := viper.GetString("config")
+			furyctlPath := flags.GetComposedConfigPathFromViper()
 			distroLocation := viper.GetString("distro-location")
 			distroPatchesLocation := viper.GetString("distro-patches")
 
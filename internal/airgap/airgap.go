@@ -19,7 +19,10 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/flags"
 	iox "github.com/sighupio/furyctl/internal/x/io"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
 const (
@@ -31,6 +34,9 @@ const (
 	// --distro-location.
 	DistroSubdir = "distro"
 
+	// ImagesSubdir is the folder, inside the bundle, holding the container images as an OCI image layout.
+	ImagesSubdir = "images"
+
 	// File mode of a venv's pyvenv.cfg.
 	pyvenvPerm = 0o644
 )
@@ -40,8 +46,8 @@ var (
 	ErrAnsiblePythonMissing = errors.New("ansible venv present in the bundle but its python is missing")
 )
 
-// RegisterFlags adds the --airgap-bundle and --force-extract flags to a command that can consume a
-// bundle. Use together with MaybePrepare at the start of the command's RunE.
+// RegisterFlags adds the --airgap-bundle, --airgap-delta and --force-extract flags to a command that can
+// consume a bundle. Use together with MaybePrepare at the start of the command's RunE.
 func RegisterFlags(cmd *cobra.Command) {
 	cmd.Flags().String(
 		"airgap-bundle",
@@ -51,6 +57,13 @@ func RegisterFlags(cmd *cobra.Command) {
 			"furyctl also sets --skip-deps-download and --distro-location",
 	)
 
+	cmd.Flags().StringSlice(
+		"airgap-delta",
+		[]string{},
+		"Paths to the delta bundles that 'furyctl download air-gapped-bundle --base' creates, in the order "+
+			"they were built. furyctl applies them on top of the --airgap-bundle, and verifies each file",
+	)
+
 	cmd.Flags().Bool(
 		"force-extract",
 		false,
@@ -58,20 +71,37 @@ func RegisterFlags(cmd *cobra.Command) {
 	)
 }
 
-// MaybePrepare extracts the bundle referenced by --airgap-bundle (if any) into the outdir and rewires
-// viper so the command runs fully offline. It is a no-op when --airgap-bundle is unset. Extraction is
-// idempotent: it is skipped when the bundle checksum matches the recorded marker, unless
-// --force-extract is set.
+// MaybePrepare extracts the bundle referenced by --airgap-bundle (if any), and the deltas of
+// --airgap-delta on top of it, into the outdir and rewires viper so the command runs fully offline. It is
+// a no-op when --airgap-bundle is unset. It refuses a bundle built for another kind, distribution version
+// or platform than the ones of the configuration and of this machine. Extraction is idempotent: it is skipped when the checksum of
+// the last bundle matches the recorded marker, unless --force-extract is set.
 func MaybePrepare() error {
 	bundle := viper.GetString("airgap-bundle")
+	deltas := viper.GetStringSlice("airgap-delta")
+
 	if bundle == "" {
+		if len(deltas) > 0 {
+			return ErrDeltaWithoutBundle
+		}
+
 		return nil
 	}
 
 	outDir := viper.GetString("outdir")
 	force := viper.GetBool("force-extract")
 
-	distroLocation, err := prepare(bundle, outDir, force)
+	// The last bundle describes the tree that the chain extracts.
+	last := bundle
+	if len(deltas) > 0 {
+		last = deltas[len(deltas)-1]
+	}
+
+	if err := checkCompatible(last, flags.GetComposedConfigPathFromViper()); err != nil {
+		return err
+	}
+
+	distroLocation, err := prepare(bundle, deltas, outDir, force)
 	if err != nil {
 		return err
 	}
@@ -84,21 +114,33 @@ func MaybePrepare() error {
 }
 
 //revive:disable:flag-parameter // force is an explicit user choice (--force-extract), not an internal mode toggle.
-func prepare(bundle, outDir string, force bool) (string, error) {
-	bundle, err := filepath.Abs(bundle)
-	if err != nil {
-		return "", fmt.Errorf("error resolving bundle path: %w", err)
-	}
+func prepare(bundle string, deltas []string, outDir string, force bool) (string, error) {
+	bundles := append([]string{bundle}, deltas...)
+	sums := make([]string, len(bundles))
+
+	for i, b := range bundles {
+		b, err := filepath.Abs(b)
+		if err != nil {
+			return "", fmt.Errorf("error resolving bundle path: %w", err)
+		}
 
-	if _, err := os.Stat(bundle); err != nil {
-		return "", fmt.Errorf("%w: %s", ErrBundleNotFound, bundle)
-	}
+		if _, err := os.Stat(b); err != nil {
+			return "", fmt.Errorf("%w: %s", ErrBundleNotFound, b)
+		}
 
-	sum, err := iox.Sha256File(bundle)
-	if err != nil {
-		return "", fmt.Errorf("error checksumming bundle: %w", err)
+		bundles[i] = b
+
+		if sums[i], err = iox.Sha256File(b); err != nil {
+			return "", fmt.Errorf("error checksumming bundle: %w", err)
+		}
 	}
 
+	bundle = bundles[0]
+
+	// The last delta pins the checksum of its base, and so on down to the full bundle: its checksum
+	// identifies the whole chain.
+	sum := sums[len(sums)-1]
+
 	marker := filepath.Join(outDir, ".furyctl", markerFile)
 	distroLocation := filepath.Join(outDir, DistroSubdir)
 
@@ -108,12 +150,41 @@ func prepare(bundle, outDir string, force bool) (string, error) {
 		return distroLocation, nil
 	}
 
+	m, _, _, err := ReadManifest(bundle)
+	if err != nil && !errors.Is(err, ErrNoManifest) {
+		return "", err
+	}
+
+	if m.IsDelta() {
+		return "", fmt.Errorf("%w: %s", ErrDeltaIsNotFull, bundle)
+	}
+
 	logrus.Infof("Extracting air-gapped bundle %s ...", bundle)
 
 	if err := iox.ExtractTarGz(bundle, outDir); err != nil {
 		return "", fmt.Errorf("error extracting air-gapped bundle: %w", err)
 	}
 
+	// The bundles of the previous releases have no manifest, and no checksums to verify.
+	if err := verifyExtracted(outDir, m.Files); err != nil {
+		return "", err
+	}
+
+	if len(deltas) > 0 {
+		tree, err := ReadTree(bundle)
+		if err != nil {
+			return "", err
+		}
+
+		for i, delta := range bundles[1:] {
+			logrus.Infof("Applying air-gapped delta bundle %s ...", delta)
+
+			if tree, err = applyDelta(delta, tree, sums[i], outDir); err != nil {
+				return "", fmt.Errorf("error applying air-gapped delta bundle: %w", err)
+			}
+		}
+	}
+
 	// The mise/pipx ansible venv carries absolute paths (pyvenv.cfg + python symlink) to the build host;
 	// fix them to the extracted location so the bundled ansible runs after relocation.
 	if err := fixupAnsibleVenv(outDir); err != nil {
@@ -127,6 +198,36 @@ func prepare(bundle, outDir string, force bool) (string, error) {
 	return distroLocation, nil
 }
 
+// checkCompatible refuses a bundle built for another kind, distribution version or platform than the
+// ones of the configuration and of this machine. The bundles of the previous releases have no manifest to
+// check.
+func checkCompatible(bundle, configPath string) error {
+	if _, err := os.Stat(bundle); err != nil {
+		// prepare reports the missing bundle.
+		return nil //nolint:nilerr // see above.
+	}
+
+	m, _, _, err := ReadManifest(bundle)
+	if errors.Is(err, ErrNoManifest) {
+		logrus.Warnf("The air-gapped bundle %s has no manifest, furyctl cannot check that it matches the configuration", bundle)
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	conf, err := yamlx.FromFileV3[config.Furyctl](configPath)
+	if err != nil {
+		logrus.Debugf("Skipping the air-gapped bundle compatibility check, cannot read %s: %v", configPath, err)
+
+		return nil
+	}
+
+	return m.Check(conf.Kind, conf.Spec.DistributionVersion, HostPlatform())
+}
+
 // fixupAnsibleVenv repoints the extracted ansible venv at the bundled (relocated) mise python. No-op
 // when the bundle does not contain ansible.
 func fixupAnsibleVenv(outDir string) error {
@@ -52,7 +52,7 @@ func Test_prepare_ExtractsAndReturnsDistroLocation(t *testing.T) {
 	bundle := makeBundle(t)
 	outDir := t.TempDir()
 
-	distroLoc, err := prepare(bundle, outDir, false)
+	distroLoc, err := prepare(bundle, nil, outDir, false)
 	require.NoError(t, err, "prepare")
 
 	require.Equal(t, filepath.Join(outDir, DistroSubdir), distroLoc, "unexpected distro location")
@@ -70,7 +70,7 @@ func Test_prepare_SkipsWhenAlreadyExtracted(t *testing.T) {
 	bundle := makeBundle(t)
 	outDir := t.TempDir()
 
-	_, err := prepare(bundle, outDir, false)
+	_, err := prepare(bundle, nil, outDir, false)
 	require.NoError(t, err, "first prepare")
 
 	// Remove an extracted file; a skipped run (marker matches) must NOT recreate it.
@@ -78,7 +78,7 @@ func Test_prepare_SkipsWhenAlreadyExtracted(t *testing.T) {
 	err = os.Remove(extracted)
 	require.NoError(t, err, "remove")
 
-	_, err = prepare(bundle, outDir, false)
+	_, err = prepare(bundle, nil, outDir, false)
 	require.NoError(t, err, "second prepare")
 
 	_, err = os.Stat(extracted)
@@ -91,14 +91,14 @@ func Test_prepare_ForceReExtracts(t *testing.T) {
 	bundle := makeBundle(t)
 	outDir := t.TempDir()
 
-	_, err := prepare(bundle, outDir, false)
+	_, err := prepare(bundle, nil, outDir, false)
 	require.NoError(t, err, "first prepare")
 
 	extracted := filepath.Join(outDir, "distro", "kfd.yaml")
 	err = os.Remove(extracted)
 	require.NoError(t, err, "remove")
 
-	_, err = prepare(bundle, outDir, true)
+	_, err = prepare(bundle, nil, outDir, true)
 	require.NoError(t, err, "forced prepare")
 
 	_, err = os.Stat(extracted)
@@ -108,6 +108,6 @@ func Test_prepare_ForceReExtracts(t *testing.T) {
 func Test_prepare_MissingBundle(t *testing.T) {
 	t.Parallel()
 
-	_, err := prepare(filepath.Join(t.TempDir(), "nope.tar.gz"), t.TempDir(), false)
+	_, err := prepare(filepath.Join(t.TempDir(), "nope.tar.gz"), nil, t.TempDir(), false)
 	require.Error(t, err, "expected error for missing bundle")
 }
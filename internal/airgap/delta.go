@@ -0,0 +1,433 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package airgap
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+const (
+	// The folder, inside a delta bundle, holding the contents that the base does not have, named after
+	// their SHA-256.
+	deltaBlobsDir = "blobs"
+)
+
+var (
+	ErrNotDelta           = errors.New("not a delta bundle")
+	ErrDeltaIsNotFull     = errors.New("a delta bundle cannot be used as --airgap-bundle, give its base bundle")
+	ErrDeltaBaseMismatch  = errors.New("the delta bundle was not built on this base bundle")
+	ErrDeltaWithoutBundle = errors.New("--airgap-delta requires the base bundle in --airgap-bundle")
+	ErrDeltaMissingFile   = errors.New("the delta bundle and its base do not have the content of a file")
+	ErrDeltaFileMismatch  = errors.New("a file of the delta bundle does not match its checksum")
+)
+
+// CreateDelta writes at output a delta bundle with the entries: the bundle holds only the contents that
+// the base bundle does not have, once each, and the manifest of the full tree with the checksum of the
+// base. The manifest is signed when signingKey is not empty.
+func CreateDelta(output, base string, m Manifest, signingKey []byte, entries []iox.TarGzEntry) (Manifest, error) {
+	baseSum, err := iox.Sha256File(base)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("error while checksumming base bundle: %w", err)
+	}
+
+	baseTree, err := ReadTree(base)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	have := map[string]bool{}
+
+	for _, f := range baseTree {
+		if f.SHA256 != "" {
+			have[f.SHA256] = true
+		}
+	}
+
+	tree, sources, err := treeOfEntries(entries)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	staging, err := os.MkdirTemp("", "furyctl-airgap-delta-")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("error while creating delta staging dir: %w", err)
+	}
+
+	defer os.RemoveAll(staging)
+
+	blobs := filepath.Join(staging, deltaBlobsDir)
+	if err := os.MkdirAll(blobs, iox.FullPermAccess); err != nil {
+		return Manifest{}, fmt.Errorf("error while creating delta staging dir: %w", err)
+	}
+
+	for name, f := range tree {
+		if f.SHA256 == "" || have[f.SHA256] {
+			continue
+		}
+
+		have[f.SHA256] = true
+
+		if err := linkOrCopy(sources[name], filepath.Join(blobs, f.SHA256)); err != nil {
+			return Manifest{}, err
+		}
+	}
+
+	m.Base = baseSum
+	m.Files = tree
+
+	manifestEntries, err := writeManifest(staging, &m, signingKey)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if err := iox.CreateTarGz(output, append(manifestEntries, iox.TarGzEntry{Src: blobs, Prefix: deltaBlobsDir})); err != nil {
+		return Manifest{}, fmt.Errorf("error while creating delta bundle: %w", err)
+	}
+
+	return m, nil
+}
+
+// ReadDeltaManifest returns the manifest of a delta bundle, or ErrNotDelta for a full bundle.
+func ReadDeltaManifest(bundle string) (Manifest, error) {
+	m, _, _, err := ReadManifest(bundle)
+	if errors.Is(err, ErrNoManifest) || (err == nil && !m.IsDelta()) {
+		return Manifest{}, fmt.Errorf("%w: %s", ErrNotDelta, bundle)
+	}
+
+	return m, err
+}
+
+// ReadTree returns the tree of a bundle: the one of its manifest, or the files of the archive with their
+// checksum for a full bundle that an older furyctl created.
+func ReadTree(bundle string) (Tree, error) {
+	m, _, _, err := ReadManifest(bundle)
+	if err == nil {
+		return m.Files, nil
+	}
+
+	if !errors.Is(err, ErrNoManifest) {
+		return nil, err
+	}
+
+	tree := Tree{}
+
+	err = walkTarGz(bundle, func(hdr *tar.Header, r io.Reader) (bool, error) {
+		switch hdr.Typeflag {
+		case tar.TypeDir, tar.TypeSymlink, tar.TypeReg:
+			f, err := fileOfHeader(hdr, r)
+			if err != nil {
+				return false, fmt.Errorf("error while reading %s of %s: %w", hdr.Name, bundle, err)
+			}
+
+			tree[filepath.Clean(hdr.Name)] = f
+		}
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// walkTarGz calls fn for each entry of the archive, until fn returns false.
+func walkTarGz(path string, fn func(hdr *tar.Header, r io.Reader) (bool, error)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("error opening archive %s: %w", path, err)
+	}
+
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("error reading gzip %s: %w", path, err)
+	}
+
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("error reading tar entry of %s: %w", path, err)
+		}
+
+		next, err := fn(hdr, tr)
+		if err != nil || !next {
+			return err
+		}
+	}
+}
+
+// treeOfEntries returns the tree that CreateTarGz writes for the entries, and the path on disk of each
+// of its files.
+func treeOfEntries(entries []iox.TarGzEntry) (Tree, map[string]string, error) {
+	tree := Tree{}
+	sources := map[string]string{}
+
+	for _, e := range entries {
+		err := filepath.Walk(e.Src, func(path string, info os.FileInfo, walkErr error) error {
+			if walkErr != nil {
+				return fmt.Errorf("error walking %s: %w", path, walkErr)
+			}
+
+			name := filepath.Clean(e.Prefix)
+
+			if rel, err := filepath.Rel(e.Src, path); err == nil && rel != "." {
+				name = filepath.Join(e.Prefix, rel)
+			}
+
+			switch {
+			case info.Mode()&os.ModeSymlink != 0:
+				link, err := os.Readlink(path)
+				if err != nil {
+					return fmt.Errorf("error reading symlink %s: %w", path, err)
+				}
+
+				tree[name] = File{Link: link}
+
+			case info.IsDir():
+				tree[name] = File{Dir: true, Mode: uint32(info.Mode().Perm())}
+
+			case info.Mode().IsRegular():
+				sum, err := iox.Sha256File(path)
+				if err != nil {
+					return fmt.Errorf("error while checksumming %s: %w", path, err)
+				}
+
+				tree[name] = File{SHA256: sum, Mode: uint32(info.Mode().Perm())}
+				sources[name] = path
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("error while reading %s: %w", e.Src, err)
+		}
+	}
+
+	return tree, sources, nil
+}
+
+// applyDelta turns the tree of the previous bundle, extracted in outDir, into the tree of the delta.
+// Each file is checked against the checksum of the manifest. The files of the previous tree that the
+// delta does not have are removed.
+func applyDelta(delta string, prev Tree, prevSum, outDir string) (Tree, error) {
+	m, err := ReadDeltaManifest(delta)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Base != prevSum {
+		return nil, fmt.Errorf("%w: %s is for the base %s, not %s", ErrDeltaBaseMismatch, delta, m.Base, prevSum)
+	}
+
+	stagingParent := filepath.Join(outDir, ".furyctl")
+	if err := os.MkdirAll(stagingParent, iox.FullPermAccess); err != nil {
+		return nil, fmt.Errorf("error while creating delta staging dir: %w", err)
+	}
+
+	// The staging dir is next to the tree, so the files are renamed into place.
+	staging, err := os.MkdirTemp(stagingParent, "airgap-delta-")
+	if err != nil {
+		return nil, fmt.Errorf("error while creating delta staging dir: %w", err)
+	}
+
+	defer os.RemoveAll(staging)
+
+	if err := iox.ExtractTarGz(delta, staging); err != nil {
+		return nil, fmt.Errorf("error extracting delta bundle: %w", err)
+	}
+
+	// The contents of the previous tree, by checksum.
+	contents := map[string]string{}
+
+	for name, f := range prev {
+		if f.SHA256 != "" {
+			contents[f.SHA256] = filepath.Join(outDir, name)
+		}
+	}
+
+	treeDir := filepath.Join(staging, "tree")
+	names := sortedNames(m.Files)
+
+	for _, name := range names {
+		if err := checkTreePath(name); err != nil {
+			return nil, err
+		}
+
+		f := m.Files[name]
+		dest := filepath.Join(treeDir, name)
+
+		if err := os.MkdirAll(filepath.Dir(dest), iox.FullPermAccess); err != nil {
+			return nil, fmt.Errorf("error while creating parent of %s: %w", name, err)
+		}
+
+		switch {
+		case f.Dir:
+			// The directories are created in place, they have no content to verify.
+
+		case f.Link != "":
+			if err := os.Symlink(f.Link, dest); err != nil {
+				return nil, fmt.Errorf("error while creating symlink %s: %w", name, err)
+			}
+
+		default:
+			src := filepath.Join(staging, deltaBlobsDir, f.SHA256)
+			if _, err := os.Stat(src); err != nil {
+				src = contents[f.SHA256]
+			}
+
+			if src == "" {
+				return nil, fmt.Errorf("%w: %s", ErrDeltaMissingFile, name)
+			}
+
+			if err := copyVerified(src, dest, f); err != nil {
+				return nil, fmt.Errorf("%w: %s", err, name)
+			}
+		}
+	}
+
+	for name, f := range prev {
+		if _, ok := m.Files[name]; !ok && !f.Dir {
+			if err := os.Remove(filepath.Join(outDir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("error while removing %s: %w", name, err)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if m.Files[name].Dir {
+			if err := os.MkdirAll(filepath.Join(outDir, name), os.FileMode(m.Files[name].Mode)); err != nil {
+				return nil, fmt.Errorf("error while creating dir %s: %w", name, err)
+			}
+
+			continue
+		}
+
+		target := filepath.Join(outDir, name)
+
+		if err := os.MkdirAll(filepath.Dir(target), iox.FullPermAccess); err != nil {
+			return nil, fmt.Errorf("error while creating parent of %s: %w", name, err)
+		}
+
+		_ = os.Remove(target)
+
+		if err := os.Rename(filepath.Join(treeDir, name), target); err != nil {
+			return nil, fmt.Errorf("error while moving %s into place: %w", name, err)
+		}
+	}
+
+	// The manifest of the delta describes the tree now.
+	for _, name := range []string{ManifestFile, SignatureFile} {
+		target := filepath.Join(outDir, name)
+
+		_ = os.Remove(target)
+
+		if err := os.Rename(filepath.Join(staging, name), target); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("error while moving %s into place: %w", name, err)
+		}
+	}
+
+	return m.Files, nil
+}
+
+// copyVerified copies src to dest and fails when the content does not match the checksum of the file.
+func copyVerified(src, dest string, f File) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("error while opening %s: %w", src, err)
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(f.Mode))
+	if err != nil {
+		return fmt.Errorf("error while creating %s: %w", dest, err)
+	}
+
+	defer out.Close()
+
+	h := sha256.New()
+
+	if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+		return fmt.Errorf("error while copying %s: %w", src, err)
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != f.SHA256 {
+		return ErrDeltaFileMismatch
+	}
+
+	return nil
+}
+
+// checkTreePath rejects the paths of a manifest that would escape the tree.
+func checkTreePath(name string) error {
+	clean := filepath.Clean(name)
+
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(os.PathSeparator)) {
+		return fmt.Errorf("%w: %s", iox.ErrIllegalArchivePath, name)
+	}
+
+	return nil
+}
+
+// linkOrCopy hard links src to dest, or copies it when the two are on different file systems.
+func linkOrCopy(src, dest string) error {
+	if err := os.Link(src, dest); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("error while opening %s: %w", src, err)
+	}
+
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return fmt.Errorf("error while creating %s: %w", dest, err)
+	}
+
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("error while copying %s: %w", src, err)
+	}
+
+	return nil
+}
+
+func sortedNames(tree Tree) []string {
+	names := make([]string, 0, len(tree))
+
+	for name := range tree {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
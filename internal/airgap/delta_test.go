@@ -0,0 +1,186 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package airgap //nolint:testpackage // exercises the unexported prepare logic with deltas.
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+// writeTree writes the files of a bundle source and returns its entries.
+func writeTree(t *testing.T, files map[string]string) []iox.TarGzEntry {
+	t.Helper()
+
+	src := t.TempDir()
+
+	for name, content := range files {
+		mustWrite(t, filepath.Join(src, name), content)
+	}
+
+	require.NoError(t, os.Symlink("tool", filepath.Join(src, ".furyctl", "bin", "tool-link")))
+
+	return []iox.TarGzEntry{
+		{Src: filepath.Join(src, "distro"), Prefix: "distro"},
+		{Src: filepath.Join(src, ".furyctl"), Prefix: ".furyctl"},
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	return string(content)
+}
+
+func Test_prepare_AppliesDeltas(t *testing.T) {
+	t.Parallel()
+
+	out := t.TempDir()
+
+	base := filepath.Join(out, "v1.tar.gz")
+	require.NoError(t, iox.CreateTarGz(base, writeTree(t, map[string]string{
+		"distro/kfd.yaml":          "version: v1.0.0",
+		".furyctl/bin/tool":        "binary v1",
+		".furyctl/bin/kept":        "kept",
+		".furyctl/bin/removed":     "removed",
+		"distro/modules/README.md": "readme",
+	})))
+
+	delta1 := filepath.Join(out, "v2.delta.tar.gz")
+	m1, err := CreateDelta(delta1, base, Manifest{}, nil, writeTree(t, map[string]string{
+		"distro/kfd.yaml":          "version: v2.0.0",
+		".furyctl/bin/tool":        "binary v1",
+		".furyctl/bin/kept":        "kept",
+		"distro/modules/README.md": "readme",
+		"distro/modules/moved.md":  "removed",
+	}))
+	require.NoError(t, err)
+
+	// Only the new kfd.yaml is in the delta: the moved file has the content of a file of the base.
+	delta1Tree, err := ReadTree(delta1)
+	require.NoError(t, err)
+	assert.Equal(t, m1.Files, delta1Tree)
+
+	blobs := 0
+
+	require.NoError(t, walkTarGz(delta1, func(hdr *tar.Header, _ io.Reader) (bool, error) {
+		if filepath.Dir(hdr.Name) == deltaBlobsDir {
+			blobs++
+		}
+
+		return true, nil
+	}))
+	assert.Equal(t, 1, blobs)
+
+	delta2 := filepath.Join(out, "v3.delta.tar.gz")
+	_, err = CreateDelta(delta2, delta1, Manifest{}, nil, writeTree(t, map[string]string{
+		"distro/kfd.yaml":          "version: v3.0.0",
+		".furyctl/bin/tool":        "binary v3",
+		".furyctl/bin/kept":        "kept",
+		"distro/modules/README.md": "readme",
+		"distro/modules/moved.md":  "removed",
+	}))
+	require.NoError(t, err)
+
+	outDir := t.TempDir()
+
+	_, err = prepare(base, []string{delta1, delta2}, outDir, false)
+	require.NoError(t, err)
+
+	assert.Equal(t, "version: v3.0.0", readFile(t, filepath.Join(outDir, "distro", "kfd.yaml")))
+	assert.Equal(t, "binary v3", readFile(t, filepath.Join(outDir, ".furyctl", "bin", "tool")))
+	assert.Equal(t, "removed", readFile(t, filepath.Join(outDir, "distro", "modules", "moved.md")))
+	assert.Equal(t, "binary v3", readFile(t, filepath.Join(outDir, ".furyctl", "bin", "tool-link")))
+
+	_, err = os.Stat(filepath.Join(outDir, ".furyctl", "bin", "removed"))
+	assert.True(t, os.IsNotExist(err), "expected the file removed by the delta to be gone, got err=%v", err)
+
+	sum, err := iox.Sha256File(delta2)
+	require.NoError(t, err)
+	assert.Equal(t, sum, readFile(t, filepath.Join(outDir, ".furyctl", markerFile)))
+
+	// The manifest of the last delta describes the extracted tree.
+	delta1Sum, err := iox.Sha256File(delta1)
+	require.NoError(t, err)
+	assert.Contains(t, readFile(t, filepath.Join(outDir, ManifestFile)), delta1Sum)
+
+	// The chain is already extracted: a second run skips it.
+	require.NoError(t, os.Remove(filepath.Join(outDir, "distro", "kfd.yaml")))
+
+	_, err = prepare(base, []string{delta1, delta2}, outDir, false)
+	require.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(outDir, "distro", "kfd.yaml"))
+	assert.True(t, os.IsNotExist(err), "expected extraction to be skipped, got err=%v", err)
+}
+
+func Test_prepare_RejectsWrongDeltaChain(t *testing.T) {
+	t.Parallel()
+
+	out := t.TempDir()
+	files := map[string]string{"distro/kfd.yaml": "version: v1.0.0", ".furyctl/bin/tool": "binary"}
+
+	base := filepath.Join(out, "v1.tar.gz")
+	require.NoError(t, iox.CreateTarGz(base, writeTree(t, files)))
+
+	other := filepath.Join(out, "other.tar.gz")
+	require.NoError(t, iox.CreateTarGz(other, writeTree(t, map[string]string{
+		"distro/kfd.yaml":   "version: v0.9.0",
+		".furyctl/bin/tool": "binary",
+	})))
+
+	delta := filepath.Join(out, "v2.delta.tar.gz")
+	files["distro/kfd.yaml"] = "version: v2.0.0"
+	_, err := CreateDelta(delta, base, Manifest{}, nil, writeTree(t, files))
+	require.NoError(t, err)
+
+	_, err = prepare(other, []string{delta}, t.TempDir(), false)
+	require.ErrorIs(t, err, ErrDeltaBaseMismatch)
+
+	_, err = prepare(delta, nil, t.TempDir(), false)
+	require.ErrorIs(t, err, ErrDeltaIsNotFull)
+}
+
+func Test_applyDelta_VerifiesFiles(t *testing.T) {
+	t.Parallel()
+
+	out := t.TempDir()
+	files := map[string]string{"distro/kfd.yaml": "version: v1.0.0", ".furyctl/bin/tool": "binary"}
+
+	base := filepath.Join(out, "v1.tar.gz")
+	require.NoError(t, iox.CreateTarGz(base, writeTree(t, files)))
+
+	delta := filepath.Join(out, "v2.delta.tar.gz")
+	files["distro/kfd.yaml"] = "version: v2.0.0"
+	_, err := CreateDelta(delta, base, Manifest{}, nil, writeTree(t, files))
+	require.NoError(t, err)
+
+	outDir := t.TempDir()
+	require.NoError(t, iox.ExtractTarGz(base, outDir))
+
+	// A file of the base that changed after the extraction is not a valid source.
+	mustWrite(t, filepath.Join(outDir, ".furyctl", "bin", "tool"), "tampered")
+
+	tree, err := ReadTree(base)
+	require.NoError(t, err)
+
+	sum, err := iox.Sha256File(base)
+	require.NoError(t, err)
+
+	_, err = applyDelta(delta, tree, sum, outDir)
+	require.ErrorIs(t, err, ErrDeltaFileMismatch)
+}
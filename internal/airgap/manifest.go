@@ -0,0 +1,374 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package airgap
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sighupio/furyctl/internal/signature"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+const (
+	// ManifestFile is the first entry of a bundle. It describes what the bundle was built for, and lists
+	// every file of the tree that the bundle extracts with its checksum.
+	ManifestFile = ".furyctl/airgap-manifest.json"
+	// SignatureFile is the second entry of a signed bundle: the cosign signature of the manifest.
+	SignatureFile = ".furyctl/airgap-manifest.json.sig"
+
+	manifestVersion = 1
+
+	// The problems that Verify reports at most.
+	maxProblems = 20
+)
+
+var (
+	ErrNoManifest         = errors.New("the bundle has no manifest, an older furyctl created it")
+	ErrUnsigned           = errors.New("the bundle manifest is not signed")
+	ErrIncompatibleBundle = errors.New("the air-gapped bundle was not built for this configuration")
+	ErrBundleMismatch     = errors.New("the bundle does not match its manifest")
+)
+
+// File is a file, a directory or a symlink of the tree of a bundle.
+type File struct {
+	SHA256 string `json:"sha256,omitempty"`
+	Mode   uint32 `json:"mode,omitempty"`
+	Link   string `json:"link,omitempty"`
+	Dir    bool   `json:"dir,omitempty"`
+}
+
+// Tree is the content of a bundle, keyed by the path of each file inside the bundle.
+type Tree map[string]File
+
+// Tool is a tool of the bundle.
+type Tool struct {
+	Name     string `json:"name"`
+	Version  string `json:"version"`
+	Platform string `json:"platform"`
+}
+
+// Manifest describes a bundle: the furyctl that built it, the distribution, kind and platform it is for,
+// its tools and its files. The manifest of a delta bundle has the checksum of its base bundle.
+type Manifest struct {
+	Version             int       `json:"version"`
+	FuryctlVersion      string    `json:"furyctlVersion"`
+	DistributionVersion string    `json:"distributionVersion"`
+	Kind                string    `json:"kind"`
+	Platform            string    `json:"platform"`
+	CreatedAt           time.Time `json:"createdAt"`
+	Tools               []Tool    `json:"tools"`
+	Base                string    `json:"base,omitempty"`
+	Files               Tree      `json:"files"`
+}
+
+// HostPlatform returns the platform of the running furyctl, the one of the tools of the bundles it builds.
+func HostPlatform() string {
+	return runtime.GOOS + "/" + runtime.GOARCH
+}
+
+// IsDelta tells whether the manifest is the one of a delta bundle.
+func (m Manifest) IsDelta() bool {
+	return m.Base != ""
+}
+
+// Check returns ErrIncompatibleBundle when the bundle was built for another kind, distribution version
+// or platform.
+func (m Manifest) Check(kind, distributionVersion, platform string) error {
+	problems := []string{}
+
+	if m.Kind != kind {
+		problems = append(problems, fmt.Sprintf("the bundle is for the %s kind, the configuration is %s", m.Kind, kind))
+	}
+
+	if strings.TrimPrefix(m.DistributionVersion, "v") != strings.TrimPrefix(distributionVersion, "v") {
+		problems = append(problems, fmt.Sprintf(
+			"the bundle is for the distribution %s, the configuration is %s",
+			m.DistributionVersion,
+			distributionVersion,
+		))
+	}
+
+	if m.Platform != platform {
+		problems = append(problems, fmt.Sprintf("the bundle is for %s, this machine is %s", m.Platform, platform))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrIncompatibleBundle, strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// CreateBundle writes at output a full bundle with the entries, and its manifest first. The manifest is
+// signed when signingKey is not empty.
+func CreateBundle(output string, m Manifest, signingKey []byte, entries []iox.TarGzEntry) (Manifest, error) {
+	tree, _, err := treeOfEntries(entries)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	m.Base = ""
+	m.Files = tree
+
+	staging, err := os.MkdirTemp("", "furyctl-airgap-bundle-")
+	if err != nil {
+		return Manifest{}, fmt.Errorf("error while creating bundle staging dir: %w", err)
+	}
+
+	defer os.RemoveAll(staging)
+
+	manifestEntries, err := writeManifest(staging, &m, signingKey)
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	if err := iox.CreateTarGz(output, append(manifestEntries, entries...)); err != nil {
+		return Manifest{}, fmt.Errorf("error creating air-gapped bundle: %w", err)
+	}
+
+	return m, nil
+}
+
+// writeManifest writes the manifest and its signature in dir, and returns the entries that put them at
+// the start of the bundle, so reading them does not decompress the whole bundle.
+func writeManifest(dir string, m *Manifest, signingKey []byte) ([]iox.TarGzEntry, error) {
+	m.Version = manifestVersion
+
+	if m.CreatedAt.IsZero() {
+		m.CreatedAt = time.Now().UTC()
+	}
+
+	content, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error while marshalling bundle manifest: %w", err)
+	}
+
+	manifestPath := filepath.Join(dir, filepath.Base(ManifestFile))
+	if err := os.WriteFile(manifestPath, content, iox.RWPermAccess); err != nil {
+		return nil, fmt.Errorf("error while writing bundle manifest: %w", err)
+	}
+
+	entries := []iox.TarGzEntry{{Src: manifestPath, Prefix: ManifestFile}}
+
+	if len(signingKey) == 0 {
+		return entries, nil
+	}
+
+	sig, err := signature.SignCosign(signingKey, content)
+	if err != nil {
+		return nil, fmt.Errorf("error while signing bundle manifest: %w", err)
+	}
+
+	sigPath := filepath.Join(dir, filepath.Base(SignatureFile))
+	if err := os.WriteFile(sigPath, sig, iox.RWPermAccess); err != nil {
+		return nil, fmt.Errorf("error while writing bundle manifest signature: %w", err)
+	}
+
+	return append(entries, iox.TarGzEntry{Src: sigPath, Prefix: SignatureFile}), nil
+}
+
+// ReadManifest returns the manifest of a bundle, its content and its signature, nil for an unsigned
+// bundle. It returns ErrNoManifest for the bundles of the previous releases.
+func ReadManifest(bundle string) (Manifest, []byte, []byte, error) {
+	var content, sig []byte
+
+	err := walkTarGz(bundle, func(hdr *tar.Header, r io.Reader) (bool, error) {
+		var err error
+
+		switch {
+		case content == nil && hdr.Name == ManifestFile:
+			content, err = io.ReadAll(r)
+
+			return true, err
+
+		case content != nil && hdr.Name == SignatureFile:
+			sig, err = io.ReadAll(r)
+		}
+
+		return false, err
+	})
+	if err != nil {
+		return Manifest{}, nil, nil, err
+	}
+
+	if content == nil {
+		return Manifest{}, nil, nil, fmt.Errorf("%w: %s", ErrNoManifest, bundle)
+	}
+
+	m := Manifest{}
+	if err := json.Unmarshal(content, &m); err != nil {
+		return Manifest{}, nil, nil, fmt.Errorf("error while parsing manifest of %s: %w", bundle, err)
+	}
+
+	return m, content, sig, nil
+}
+
+// Verification is the result of the verification of a bundle.
+type Verification struct {
+	Manifest Manifest
+	Signed   bool
+	// SignatureVerified is set when the signature was checked with a public key.
+	SignatureVerified bool
+	Files             int
+	Problems          []string
+}
+
+// Verify checks the files of the bundle against its manifest, and the signature of the manifest when
+// publicKey is not empty. The content mismatches are in the Problems of the result; the error is for a
+// bundle that cannot be read or an invalid signature.
+func Verify(bundle, publicKey string) (Verification, error) {
+	m, content, sig, err := ReadManifest(bundle)
+	if err != nil {
+		return Verification{}, err
+	}
+
+	v := Verification{Manifest: m, Signed: sig != nil}
+
+	if publicKey != "" {
+		if sig == nil {
+			return v, ErrUnsigned
+		}
+
+		if err := signature.VerifyCosign(publicKey, content, sig); err != nil {
+			return v, fmt.Errorf("error while verifying the signature of the bundle manifest: %w", err)
+		}
+
+		v.SignatureVerified = true
+	}
+
+	if m.IsDelta() {
+		err = verifyDeltaBlobs(bundle, &v)
+	} else {
+		err = verifyFull(bundle, &v)
+	}
+
+	return v, err
+}
+
+func (v *Verification) addProblem(format string, args ...any) {
+	if len(v.Problems) < maxProblems {
+		v.Problems = append(v.Problems, fmt.Sprintf(format, args...))
+	}
+}
+
+// verifyFull checks that the archive has the files of the manifest, and nothing else.
+func verifyFull(bundle string, v *Verification) error {
+	seen := map[string]bool{}
+
+	err := walkTarGz(bundle, func(hdr *tar.Header, r io.Reader) (bool, error) {
+		name := filepath.Clean(hdr.Name)
+		if name == ManifestFile || name == SignatureFile {
+			return true, nil
+		}
+
+		seen[name] = true
+
+		want, ok := v.Manifest.Files[name]
+		if !ok {
+			v.addProblem("%s is not in the manifest", name)
+
+			return true, nil
+		}
+
+		got, err := fileOfHeader(hdr, r)
+		if err != nil {
+			return false, fmt.Errorf("error while reading %s of %s: %w", hdr.Name, bundle, err)
+		}
+
+		if got.SHA256 != want.SHA256 || got.Link != want.Link || got.Dir != want.Dir {
+			v.addProblem("%s does not match the manifest", name)
+		}
+
+		v.Files++
+
+		return true, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range sortedNames(v.Manifest.Files) {
+		if !seen[name] {
+			v.addProblem("%s is in the manifest, not in the bundle", name)
+		}
+	}
+
+	return nil
+}
+
+// verifyDeltaBlobs checks that the contents of a delta bundle match their name. The files that come from
+// the base bundle are verified when the delta is applied.
+func verifyDeltaBlobs(bundle string, v *Verification) error {
+	return walkTarGz(bundle, func(hdr *tar.Header, r io.Reader) (bool, error) {
+		if filepath.Dir(hdr.Name) != deltaBlobsDir || hdr.Typeflag != tar.TypeReg {
+			return true, nil
+		}
+
+		got, err := fileOfHeader(hdr, r)
+		if err != nil {
+			return false, fmt.Errorf("error while reading %s of %s: %w", hdr.Name, bundle, err)
+		}
+
+		if got.SHA256 != filepath.Base(hdr.Name) {
+			v.addProblem("%s does not match its checksum", hdr.Name)
+		}
+
+		v.Files++
+
+		return true, nil
+	})
+}
+
+// fileOfHeader returns the tree entry of a tar entry, and reads the content of a regular file.
+func fileOfHeader(hdr *tar.Header, r io.Reader) (File, error) {
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		return File{Dir: true, Mode: uint32(hdr.FileInfo().Mode().Perm())}, nil
+
+	case tar.TypeSymlink:
+		return File{Link: hdr.Linkname}, nil
+
+	default:
+		h := sha256.New()
+
+		if _, err := io.Copy(h, r); err != nil { //nolint:gosec // the bundle is read in chunks by io.Copy.
+			return File{}, err
+		}
+
+		return File{SHA256: hex.EncodeToString(h.Sum(nil)), Mode: uint32(hdr.FileInfo().Mode().Perm())}, nil
+	}
+}
+
+// verifyExtracted checks the files that a full bundle extracted in outDir against the manifest.
+func verifyExtracted(outDir string, tree Tree) error {
+	for _, name := range sortedNames(tree) {
+		f := tree[name]
+		if f.SHA256 == "" {
+			continue
+		}
+
+		sum, err := iox.Sha256File(filepath.Join(outDir, name))
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrBundleMismatch, err)
+		}
+
+		if sum != f.SHA256 {
+			return fmt.Errorf("%w: %s", ErrBundleMismatch, name)
+		}
+	}
+
+	return nil
+}
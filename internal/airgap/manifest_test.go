@@ -0,0 +1,186 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package airgap //nolint:testpackage // exercises the unexported compatibility check.
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/signature"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+// testKeys returns a PEM private key and its PEM public key.
+func testKeys(t *testing.T) ([]byte, string) {
+	t.Helper()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	pubDer, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}))
+}
+
+func testManifest() Manifest {
+	return Manifest{
+		FuryctlVersion:      "0.33.0",
+		DistributionVersion: "v1.31.0",
+		Kind:                "OnPremises",
+		Platform:            "linux/amd64",
+		Tools:               []Tool{{Name: "kubectl", Version: "1.31.4", Platform: "linux/amd64"}},
+	}
+}
+
+func TestCreateBundle_Verify(t *testing.T) {
+	t.Parallel()
+
+	key, pub := testKeys(t)
+	_, otherPub := testKeys(t)
+
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+	m, err := CreateBundle(bundle, testManifest(), key, writeTree(t, map[string]string{
+		"distro/kfd.yaml":   "version: v1.31.0",
+		".furyctl/bin/tool": "binary",
+	}))
+	require.NoError(t, err)
+
+	got, _, sig, err := ReadManifest(bundle)
+	require.NoError(t, err)
+	assert.NotNil(t, sig)
+	assert.False(t, got.IsDelta())
+	assert.Equal(t, m.Files, got.Files)
+	assert.Equal(t, testManifest().Tools, got.Tools)
+	assert.Contains(t, got.Files, filepath.Join(".furyctl", "bin", "tool"))
+
+	v, err := Verify(bundle, pub)
+	require.NoError(t, err)
+	assert.True(t, v.SignatureVerified)
+	assert.Empty(t, v.Problems)
+
+	_, err = Verify(bundle, otherPub)
+	require.ErrorIs(t, err, signature.ErrVerification)
+
+	// A bundle whose files do not match the manifest, for example repacked after a change.
+	extracted := t.TempDir()
+	require.NoError(t, iox.ExtractTarGz(bundle, extracted))
+	mustWrite(t, filepath.Join(extracted, ".furyctl", "bin", "tool"), "tampered")
+	mustWrite(t, filepath.Join(extracted, "distro", "extra.yaml"), "extra")
+
+	tampered := filepath.Join(t.TempDir(), "tampered.tar.gz")
+	require.NoError(t, iox.CreateTarGz(tampered, []iox.TarGzEntry{
+		{Src: filepath.Join(extracted, ManifestFile), Prefix: ManifestFile},
+		{Src: filepath.Join(extracted, SignatureFile), Prefix: SignatureFile},
+		{Src: filepath.Join(extracted, "distro"), Prefix: "distro"},
+		{Src: filepath.Join(extracted, ".furyctl"), Prefix: ".furyctl"},
+	}))
+
+	v, err = Verify(tampered, pub)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		filepath.Join(".furyctl", "bin", "tool") + " does not match the manifest",
+		filepath.Join("distro", "extra.yaml") + " is not in the manifest",
+	}, v.Problems)
+
+	_, err = prepare(tampered, nil, t.TempDir(), false)
+	require.ErrorIs(t, err, ErrBundleMismatch)
+}
+
+func TestVerify_Unsigned(t *testing.T) {
+	t.Parallel()
+
+	_, pub := testKeys(t)
+
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+	_, err := CreateBundle(bundle, testManifest(), nil, writeTree(t, map[string]string{
+		"distro/kfd.yaml":   "version: v1.31.0",
+		".furyctl/bin/tool": "binary",
+	}))
+	require.NoError(t, err)
+
+	v, err := Verify(bundle, "")
+	require.NoError(t, err)
+	assert.False(t, v.Signed)
+	assert.Empty(t, v.Problems)
+
+	_, err = Verify(bundle, pub)
+	require.ErrorIs(t, err, ErrUnsigned)
+
+	_, _, _, err = ReadManifest(makeBundle(t))
+	require.ErrorIs(t, err, ErrNoManifest)
+}
+
+func Test_checkCompatible(t *testing.T) {
+	t.Parallel()
+
+	m := testManifest()
+	m.Platform = HostPlatform()
+
+	bundle := filepath.Join(t.TempDir(), "bundle.tar.gz")
+
+	_, err := CreateBundle(bundle, m, nil, writeTree(t, map[string]string{
+		"distro/kfd.yaml":   "version: v1.31.0",
+		".furyctl/bin/tool": "binary",
+	}))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc    string
+		config  string
+		wantErr error
+	}{
+		{
+			desc:   "same kind and version",
+			config: "kind: OnPremises\nspec:\n  distributionVersion: 1.31.0\n",
+		},
+		{
+			desc:    "other kind",
+			config:  "kind: EKSCluster\nspec:\n  distributionVersion: v1.31.0\n",
+			wantErr: ErrIncompatibleBundle,
+		},
+		{
+			desc:    "other version",
+			config:  "kind: OnPremises\nspec:\n  distributionVersion: v1.31.1\n",
+			wantErr: ErrIncompatibleBundle,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := filepath.Join(t.TempDir(), "furyctl.yaml")
+			mustWrite(t, config, tc.config)
+
+			err := checkCompatible(bundle, config)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	require.ErrorIs(t, m.Check("OnPremises", "v1.31.0", "plan9/386"), ErrIncompatibleBundle)
+
+	// A bundle without a manifest cannot be checked.
+	require.NoError(t, checkCompatible(makeBundle(t), filepath.Join(t.TempDir(), "furyctl.yaml")))
+}
@@ -0,0 +1,266 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dukex/mixpanel"
+
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+const (
+	SinkMixpanel = "mixpanel"
+	SinkFile     = "file"
+	SinkWebhook  = "webhook"
+	SinkStderr   = "stderr"
+	// SinkStdout is an alias of SinkStderr: the events go to the standard error anyway, so that they do not
+	// mix with the json, yaml and sarif outputs of the commands.
+	SinkStdout = "stdout"
+
+	// SignatureHeader is the header of the webhook requests that holds the HMAC-SHA256 of the body.
+	SignatureHeader = "X-Furyctl-Signature"
+
+	sendTimeout = time.Second * 5
+
+	mixpanelEndpoint = "https://api-eu.mixpanel.com"
+)
+
+var (
+	ErrUnknownSink        = errors.New("unknown analytics sink")
+	ErrMissingFile        = errors.New("the file analytics sink needs a file path")
+	ErrMissingWebhookURL  = errors.New("the webhook analytics sink needs a URL")
+	ErrUnexpectedResponse = errors.New("unexpected response from the analytics webhook")
+)
+
+// Sinks returns the names of the supported analytics sinks.
+func Sinks() []string {
+	return []string{SinkMixpanel, SinkFile, SinkWebhook, SinkStderr, SinkStdout}
+}
+
+// Sink delivers the tracked events. The tracker calls Send from a single goroutine.
+type Sink interface {
+	Send(trackID string, event Event) error
+}
+
+// SinkConfig selects the sink of the events and its settings.
+type SinkConfig struct {
+	Kind          string
+	Token         string
+	File          string
+	WebhookURL    string
+	WebhookSecret string
+}
+
+// Record is the representation of an event written by the file, webhook and stderr sinks.
+type Record struct {
+	Event      string         `json:"event"`
+	TrackID    string         `json:"trackId"`
+	Time       time.Time      `json:"time"`
+	Properties map[string]any `json:"properties"`
+}
+
+func newRecord(trackID string, event Event) Record {
+	return Record{
+		Event:      event.Name(),
+		TrackID:    trackID,
+		Time:       time.Now().UTC(),
+		Properties: event.Properties(),
+	}
+}
+
+// NewSink creates the sink of the given kind.
+func NewSink(cfg SinkConfig) (Sink, error) {
+	switch cfg.Kind {
+	case SinkMixpanel, "":
+		return NewMixpanelSink(cfg.Token), nil
+
+	case SinkFile:
+		if cfg.File == "" {
+			return nil, ErrMissingFile
+		}
+
+		return NewFileSink(cfg.File), nil
+
+	case SinkWebhook:
+		if cfg.WebhookURL == "" {
+			return nil, ErrMissingWebhookURL
+		}
+
+		return NewWebhookSink(cfg.WebhookURL, cfg.WebhookSecret), nil
+
+	case SinkStderr, SinkStdout:
+		// Not the standard output: the events must not mix with the json, yaml and sarif outputs.
+		return NewWriterSink(os.Stderr), nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s, must be one of: %s", ErrUnknownSink, cfg.Kind, strings.Join(Sinks(), ", "))
+	}
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: sendTimeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: sendTimeout,
+			}).DialContext,
+			TLSHandshakeTimeout: sendTimeout,
+		},
+	}
+}
+
+// MixpanelSink sends the events to the EU endpoint of Mixpanel.
+type MixpanelSink struct {
+	client mixpanel.Mixpanel
+}
+
+func NewMixpanelSink(token string) *MixpanelSink {
+	return &MixpanelSink{
+		client: mixpanel.NewFromClient(newHTTPClient(), token, mixpanelEndpoint),
+	}
+}
+
+func (s *MixpanelSink) Send(trackID string, event Event) error {
+	e := &mixpanel.Event{Properties: event.Properties()}
+	if err := s.client.Track(trackID, event.Name(), e); err != nil {
+		return fmt.Errorf("failed to track event: %w", err)
+	}
+
+	return nil
+}
+
+// FileSink appends the events to a local file, one JSON record for each line.
+type FileSink struct {
+	path string
+}
+
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+func (s *FileSink) Send(trackID string, event Event) error {
+	line, err := json.Marshal(newRecord(trackID, event))
+	if err != nil {
+		return fmt.Errorf("error while marshalling event: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), iox.UserGroupPerm); err != nil {
+		return fmt.Errorf("error while creating analytics folder: %w", err)
+	}
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, iox.RWPermAccess)
+	if err != nil {
+		return fmt.Errorf("error while opening analytics file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error while writing analytics file: %w", err)
+	}
+
+	return nil
+}
+
+// WebhookSink posts each event as a JSON record to a URL. With a secret, the SignatureHeader of the
+// request holds the HMAC-SHA256 of the body, so that the receiver can verify the sender.
+type WebhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewWebhookSink(url, secret string) *WebhookSink {
+	return &WebhookSink{
+		url:    url,
+		secret: secret,
+		client: newHTTPClient(),
+	}
+}
+
+func (s *WebhookSink) Send(trackID string, event Event) error {
+	body, err := json.Marshal(newRecord(trackID, event))
+	if err != nil {
+		return fmt.Errorf("error while marshalling event: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error while creating analytics webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(s.secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error while calling analytics webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("%w: %s", ErrUnexpectedResponse, resp.Status)
+	}
+
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the body, computed with the secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WriterSink writes the events to a writer, one JSON record for each line.
+type WriterSink struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Send(trackID string, event Event) error {
+	line, err := json.Marshal(newRecord(trackID, event))
+	if err != nil {
+		return fmt.Errorf("error while marshalling event: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error while writing event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package analytics_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+)
+
+var errTest = errors.New("test error")
+
+func newEvent() analytics.Event {
+	e := analytics.NewCommandEvent("apply")
+	e.AddClusterDetails(analytics.ClusterDetails{Phase: "distribution", Provider: "OnPremises", KFDVersion: "v1.31.0"})
+	e.AddErrorMessage(errTest)
+
+	return e
+}
+
+func TestNewSink(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc    string
+		cfg     analytics.SinkConfig
+		wantErr error
+	}{
+		{desc: "mixpanel", cfg: analytics.SinkConfig{Kind: analytics.SinkMixpanel}},
+		{desc: "file", cfg: analytics.SinkConfig{Kind: analytics.SinkFile, File: "events.jsonl"}},
+		{desc: "file without path", cfg: analytics.SinkConfig{Kind: analytics.SinkFile}, wantErr: analytics.ErrMissingFile},
+		{desc: "webhook", cfg: analytics.SinkConfig{Kind: analytics.SinkWebhook, WebhookURL: "https://example.com"}},
+		{
+			desc:    "webhook without url",
+			cfg:     analytics.SinkConfig{Kind: analytics.SinkWebhook},
+			wantErr: analytics.ErrMissingWebhookURL,
+		},
+		{desc: "stderr", cfg: analytics.SinkConfig{Kind: analytics.SinkStderr}},
+		{desc: "stdout", cfg: analytics.SinkConfig{Kind: analytics.SinkStdout}},
+		{desc: "unknown", cfg: analytics.SinkConfig{Kind: "syslog"}, wantErr: analytics.ErrUnknownSink},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			sink, err := analytics.NewSink(tC.cfg)
+			if tC.wantErr != nil {
+				require.ErrorIs(t, err, tC.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, sink)
+		})
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "events.jsonl")
+	sink := analytics.NewFileSink(path)
+
+	require.NoError(t, sink.Send("track-id", newEvent()))
+	require.NoError(t, sink.Send("track-id", analytics.NewCommandEvent("diff")))
+
+	out, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	require.Len(t, lines, 2)
+
+	var rec analytics.Record
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &rec))
+
+	assert.Equal(t, "apply", rec.Event)
+	assert.Equal(t, "track-id", rec.TrackID)
+	assert.False(t, rec.Time.IsZero())
+	assert.Equal(t, errTest.Error(), rec.Properties["errorMessage"])
+	assert.Equal(t, false, rec.Properties["success"])
+	assert.Equal(t, map[string]any{
+		"Phase":      "distribution",
+		"Provider":   "OnPremises",
+		"KFDVersion": "v1.31.0",
+		"DryRun":     false,
+	}, rec.Properties["clusterDetails"])
+}
+
+func TestWebhookSink(t *testing.T) {
+	t.Parallel()
+
+	var (
+		body      []byte
+		signature string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(analytics.SignatureHeader)
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	require.NoError(t, analytics.NewWebhookSink(srv.URL, "s3cr3t").Send("track-id", newEvent()))
+
+	assert.Equal(t, "sha256="+analytics.Sign("s3cr3t", body), signature)
+
+	var rec analytics.Record
+	require.NoError(t, json.Unmarshal(body, &rec))
+	assert.Equal(t, "apply", rec.Event)
+
+	require.NoError(t, analytics.NewWebhookSink(srv.URL, "").Send("track-id", newEvent()))
+	assert.Empty(t, signature)
+}
+
+func TestWebhookSink_ErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	err := analytics.NewWebhookSink(srv.URL, "s3cr3t").Send("track-id", newEvent())
+	require.ErrorIs(t, err, analytics.ErrUnexpectedResponse)
+}
+
+func TestTracker_UseSink(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	// Without a token the tracker is disabled, a sink other than Mixpanel enables it.
+	tracker := analytics.NewTracker("", "v0.0.0", "amd64", "linux", "SIGHUP", "host")
+	tracker.UseSink(analytics.NewWriterSink(&buf))
+
+	tracker.Track(newEvent())
+	tracker.Flush()
+
+	var rec analytics.Record
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &rec))
+
+	assert.Equal(t, "apply", rec.Event)
+	assert.NotEmpty(t, rec.TrackID)
+	assert.Equal(t, "furyctl", rec.Properties["origin"])
+	assert.Equal(t, "v0.0.0", rec.Properties["version"])
+}
+
+func TestTracker_UseSinkDisabled(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	tracker := analytics.NewTracker("", "v0.0.0", "amd64", "linux", "SIGHUP", "host")
+	tracker.Disable()
+	tracker.UseSink(analytics.NewWriterSink(&buf))
+
+	tracker.Track(newEvent())
+	tracker.Flush()
+
+	assert.Empty(t, buf.String())
+}
@@ -5,15 +5,11 @@
 package analytics
 
 import (
-	"fmt"
-	"net"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/denisbrodbeck/machineid"
-	"github.com/dukex/mixpanel"
 	"github.com/sirupsen/logrus"
 )
 
@@ -22,26 +18,13 @@ const isNext = true
 type Tracker struct {
 	trackingInfo
 
-	client mixpanel.Mixpanel
-	events chan Event
-	enable bool
+	sink    Sink
+	events  chan Event
+	enable  bool
+	started bool
 }
 
 func NewTracker(token, version, arch, os, org, hostname string) *Tracker {
-	const timeout = time.Second * 5
-
-	const apiEndpoint = "https://api-eu.mixpanel.com"
-
-	c := &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
-			Dial: (&net.Dialer{
-				Timeout: timeout,
-			}).Dial,
-			TLSHandshakeTimeout: timeout,
-		},
-	}
-
 	isDevelopBuild := strings.Contains(version, "develop")
 
 	t := map[string]string{
@@ -57,7 +40,7 @@ func NewTracker(token, version, arch, os, org, hostname string) *Tracker {
 	}
 
 	tracker := &Tracker{
-		client:       mixpanel.NewFromClient(c, token, apiEndpoint),
+		sink:         NewMixpanelSink(token),
 		trackingInfo: t,
 		enable:       true,
 		events:       make(chan Event),
@@ -69,24 +52,48 @@ func NewTracker(token, version, arch, os, org, hostname string) *Tracker {
 		return tracker
 	}
 
-	// Start the event processor, this will listen for new tracked events and send them to mixpanel.
-	go tracker.processEvents()
+	// Start the event processor, this will listen for new tracked events and send them to the sink.
+	tracker.start()
 
 	return tracker
 }
 
+// UseSink makes the tracker send the events to the given sink instead of Mixpanel. The other sinks do
+// not need the Mixpanel token, so UseSink also enables a tracker created without it. It must be called
+// before tracking any event, and does nothing on a disabled tracker.
+func (a *Tracker) UseSink(s Sink) {
+	if !a.enable && a.started {
+		return
+	}
+
+	a.sink = s
+	a.enable = true
+
+	a.start()
+}
+
+func (a *Tracker) start() {
+	if a.started {
+		return
+	}
+
+	a.started = true
+
+	go a.processEvents()
+}
+
 type trackingInfo map[string]string
 
 // Track collects the event to be consumed by the event processor.
 func (a *Tracker) Track(event Event) {
-	// // add a channel to send events to a goroutine that will send them to mixpanel
+	// // add a channel to send events to a goroutine that will send them to the sink
 	// // this will allow us to send events in a non-blocking way.
 	if a.enable {
 		a.events <- event
 	}
 }
 
-// Flush flushes the events queue, guaranteeing that all events are sent to mixpanel.
+// Flush flushes the events queue, guaranteeing that all events are sent to the sink.
 // This method uses a timeout to send a GuardEvent to the event processor to close the process.
 func (a *Tracker) Flush() {
 	const timeout = time.Millisecond * 500
@@ -106,11 +113,12 @@ func (a *Tracker) Flush() {
 // Disable disables the tracker.
 func (a *Tracker) Disable() {
 	a.enable = false
+	a.started = true
 
 	a.close()
 }
 
-// processEvents is the event processor: it will listen for new events and send them to mixpanel.
+// processEvents is the event processor: it will listen for new events and send them to the sink.
 // This method will stop when a Stop event is received.
 func (a *Tracker) processEvents() {
 	for {
@@ -148,17 +156,13 @@ func (a *Tracker) processEvents() {
 	}
 }
 
-// sendEvent sends the event to mixpanel.
+// sendEvent sends the event to the sink.
 func (a *Tracker) sendEvent(event Event) error {
 	// Event Properties with machine info.
-	p := appendMachineInfo(a.trackingInfo, event.Properties())
-
-	e := &mixpanel.Event{Properties: p}
-	if err := a.client.Track(a.trackingInfo["trackID"], event.Name(), e); err != nil {
-		return fmt.Errorf("failed to track event: %w", err)
-	}
+	appendMachineInfo(a.trackingInfo, event.Properties())
 
-	return nil
+	//nolint:wrapcheck // The sinks already wrap their errors.
+	return a.sink.Send(a.trackingInfo["trackID"], event)
 }
 
 // close closes the tracker's event chan.
@@ -115,6 +115,20 @@ type KFDToolsEks struct {
 }
 
 type KFDTool struct {
-	Version   string            `yaml:"version"`
+	Version string `yaml:"version"`
+	// Checksums pins the SHA-256 of the tool binary for each "<os>/<arch>" platform, for example
+	// linux/amd64. furyctl refuses a binary that does not match.
 	Checksums map[string]string `yaml:"checksums"`
+	// Signature optionally signs a checksums file that pins the binaries of the tool.
+	Signature KFDToolSignature `yaml:"signature"`
+}
+
+// KFDToolSignature is a minisign or cosign signature over a checksums file in the sha256sum format. The
+// file has a "<sha256>  <tool>-<version>-<os>-<arch>" line for the binary of each platform.
+type KFDToolSignature struct {
+	Type         string `yaml:"type"         validate:"omitempty,oneof=minisign cosign"`
+	ChecksumsURL string `yaml:"checksumsUrl" validate:"required_with=Type"`
+	SignatureURL string `yaml:"signatureUrl" validate:"required_with=Type"`
+	// PublicKey is the minisign public key, or the PEM encoded cosign public key.
+	PublicKey string `yaml:"publicKey" validate:"required_with=Type"`
 }
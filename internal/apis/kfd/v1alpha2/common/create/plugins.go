@@ -16,6 +16,7 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/config"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/plan"
 	"github.com/sighupio/furyctl/internal/tool/helmfile"
 	"github.com/sighupio/furyctl/internal/tool/shell"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
@@ -33,6 +34,7 @@ type Plugins struct {
 	kfd            config.KFD
 	kind           string
 	paths          cluster.CreatorPaths
+	planReport     *plan.Report
 }
 
 func NewPlugins(
@@ -40,6 +42,7 @@ func NewPlugins(
 	kfdManifest config.KFD,
 	kind string,
 	dryRun bool,
+	planReport *plan.Report,
 ) *Plugins {
 	phaseOp := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhasePlugins),
@@ -66,8 +69,9 @@ func NewPlugins(
 				WorkDir: phaseOp.Path,
 			},
 		),
-		kfd:   kfdManifest,
-		paths: paths,
+		kfd:        kfdManifest,
+		paths:      paths,
+		planReport: planReport,
 	}
 }
 
@@ -131,6 +135,8 @@ func (p *Plugins) Exec() error {
 		return fmt.Errorf("error creating template model: %w", err)
 	}
 
+	p.StrictTemplates.Apply(templateModel, cluster.OperationPhasePlugins)
+
 	err = templateModel.Generate()
 	if err != nil {
 		return fmt.Errorf("error generating from template files: %w", err)
@@ -156,6 +162,16 @@ func (p *Plugins) Exec() error {
 	specPluginsKustomize, hasSpecPluginsKustomize := specPlugins["kustomize"].([]any)
 
 	if p.dryRun {
+		p.planReport.RecordRenderedPhase(plan.RenderedPhase{
+			Phase:          cluster.OperationPhasePlugins,
+			Dir:            p.Path,
+			KubectlPath:    p.KubectlPath,
+			KustomizePath:  p.KustomizePath,
+			HelmfilePath:   p.HelmfilePath,
+			Kustomizations: kustomizeFolders(specPluginsKustomize),
+			HelmReleases:   len(specPluginsHelmReleases) > 0,
+		})
+
 		logrus.Info("Plugins installed successfully (dry-run mode)")
 
 		return nil
@@ -181,3 +197,21 @@ func (p *Plugins) Exec() error {
 
 	return nil
 }
+
+// kustomizeFolders returns the folders of the kustomize plugins.
+func kustomizeFolders(plugins []any) []string {
+	folders := []string{}
+
+	for _, plugin := range plugins {
+		p, ok := plugin.(map[any]any)
+		if !ok {
+			continue
+		}
+
+		if folder, ok := p["folder"].(string); ok && folder != "" {
+			folders = append(folders, folder)
+		}
+	}
+
+	return folders
+}
@@ -9,12 +9,13 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/sighupio/furyctl/internal/apis/config"
-	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/onpremises/public"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/kubernetes"
 	"github.com/sighupio/furyctl/internal/state"
 	"github.com/sighupio/furyctl/internal/tool/kubectl"
 	"github.com/sighupio/furyctl/internal/tool/shell"
@@ -24,11 +25,13 @@ import (
 	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
+// Distribution deletes the distribution of the kinds that render their distribution phase from the templates
+// in the distribution folder of their kind, as OnPremises and Immutable.
 type Distribution struct {
 	*cluster.OperationPhase
 
-	furyctlConf public.OnpremisesKfdV1Alpha2
 	kfdManifest config.KFD
+	kind        string
 	paths       cluster.DeleterPaths
 	dryRun      bool
 	shellRunner *shell.Runner
@@ -37,9 +40,10 @@ type Distribution struct {
 }
 
 func NewDistribution(
-	furyctlConf public.OnpremisesKfdV1Alpha2,
-	kfdManifest config.KFD,
 	paths cluster.DeleterPaths,
+	stateStore state.Storer,
+	kfdManifest config.KFD,
+	kind string,
 	dryRun bool,
 ) *Distribution {
 	phase := cluster.NewOperationPhase(
@@ -50,8 +54,8 @@ func NewDistribution(
 
 	return &Distribution{
 		OperationPhase: phase,
-		furyctlConf:    furyctlConf,
 		kfdManifest:    kfdManifest,
+		kind:           kind,
 		paths:          paths,
 		dryRun:         dryRun,
 		shellRunner: shell.NewRunner(
@@ -71,13 +75,7 @@ func NewDistribution(
 			true,
 			false,
 		),
-		stateStore: state.NewStore(
-			paths.DistroPath,
-			paths.ConfigPath,
-			paths.WorkDir,
-			kfdManifest.Tools.Common.Kubectl.Version,
-			paths.BinPath,
-		),
+		stateStore: stateStore,
 	}
 }
 
@@ -98,7 +96,7 @@ func (d *Distribution) Exec() error {
 		d.paths.DistroPath,
 		d.paths.ConfigPath,
 		"kfd-v1alpha2",
-		"onpremises",
+		strings.ToLower(d.kind),
 	)
 	if err != nil {
 		return fmt.Errorf("error creating furyctl merger: %w", err)
@@ -116,7 +114,12 @@ func (d *Distribution) Exec() error {
 
 	logrus.Info("Checking that the cluster is reachable...")
 
-	if _, err := d.kubeRunner.Version(); err != nil {
+	kubeClient, err := kubernetes.NewClient("")
+	if err != nil {
+		return fmt.Errorf("error connecting to cluster: %w", err)
+	}
+
+	if _, err := kubeClient.ServerVersion(); err != nil {
 		logrus.Debugf("Got error while running cluster reachability check: %s", err)
 
 		return fmt.Errorf("error connecting to cluster: %w", err)
@@ -124,12 +127,12 @@ func (d *Distribution) Exec() error {
 
 	logrus.Info("Checking storage classes...")
 
-	getStorageClassesOutput, err := d.kubeRunner.Get(false, "", "storageclasses")
+	storageClasses, err := kubeClient.StorageClasses()
 	if err != nil {
 		return fmt.Errorf("error while checking storage class: %w", err)
 	}
 
-	if getStorageClassesOutput == "No resources found" {
+	if !storageClasses.Available {
 		storageClassAvailable = false
 	}
 
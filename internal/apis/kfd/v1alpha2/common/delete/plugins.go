@@ -0,0 +1,225 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//nolint:predeclared // We want to use delete as package name.
+package delete
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/tool/helmfile"
+	"github.com/sighupio/furyctl/internal/tool/kubectl"
+	"github.com/sighupio/furyctl/internal/tool/kustomize"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+	templatex "github.com/sighupio/furyctl/pkg/template"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+type Plugins struct {
+	*cluster.OperationPhase
+
+	helmfileRunner  *helmfile.Runner
+	kustomizeRunner *kustomize.Runner
+	kubeRunner      *kubectl.Runner
+	dryRun          bool
+	kfd             config.KFD
+	kind            string
+	paths           cluster.DeleterPaths
+}
+
+func NewPlugins(
+	paths cluster.DeleterPaths,
+	kfdManifest config.KFD,
+	kind string,
+	dryRun bool,
+) *Plugins {
+	phase := cluster.NewOperationPhase(
+		path.Join(paths.WorkDir, cluster.OperationPhasePlugins),
+		kfdManifest.Tools,
+		paths.BinPath,
+	)
+
+	return &Plugins{
+		OperationPhase: phase,
+		dryRun:         dryRun,
+		kind:           kind,
+		helmfileRunner: helmfile.NewRunner(
+			execx.NewStdExecutor(),
+			helmfile.Paths{
+				Helmfile:   phase.HelmfilePath,
+				WorkDir:    phase.Path,
+				PluginsDir: path.Join(paths.BinPath, "helm", "plugins"),
+			},
+		),
+		kustomizeRunner: kustomize.NewRunner(
+			execx.NewStdExecutor(),
+			kustomize.Paths{
+				Kustomize: phase.KustomizePath,
+				WorkDir:   phase.Path,
+			},
+		),
+		kubeRunner: kubectl.NewRunner(
+			execx.NewStdExecutor(),
+			kubectl.Paths{
+				Kubectl: phase.KubectlPath,
+				WorkDir: phase.Path,
+			},
+			false,
+			true,
+			false,
+		),
+		kfd:   kfdManifest,
+		paths: paths,
+	}
+}
+
+func (p *Plugins) Exec() error {
+	logrus.Info("Deleting plugins...")
+
+	if err := p.CreateRootFolder(); err != nil {
+		return fmt.Errorf("error creating plugins phase folder: %w", err)
+	}
+
+	furyctlMerger, err := p.CreateFuryctlMerger(
+		p.paths.DistroPath,
+		p.paths.ConfigPath,
+		"kfd-v1alpha2",
+		strings.ToLower(p.kind),
+	)
+	if err != nil {
+		return fmt.Errorf("error creating furyctl merger: %w", err)
+	}
+
+	mCfg, err := templatex.NewConfigWithoutData(furyctlMerger, []string{})
+	if err != nil {
+		return fmt.Errorf("error creating template config: %w", err)
+	}
+
+	p.CopyPathsToConfig(&mCfg)
+
+	if !distribution.HasFeature(p.kfd, distribution.FeatureKubeconfigInSchema) {
+		mCfg.Data["paths"]["kubeconfig"] = os.Getenv("KUBECONFIG")
+	}
+
+	outYaml, err := yamlx.MarshalV2(mCfg)
+	if err != nil {
+		return fmt.Errorf("error marshaling template config: %w", err)
+	}
+
+	outDirPath, err := os.MkdirTemp("", "furyctl-plugins-")
+	if err != nil {
+		return fmt.Errorf("error creating temp dir: %w", err)
+	}
+	defer os.RemoveAll(outDirPath)
+
+	confPath := filepath.Join(outDirPath, "config.yaml")
+
+	if err = os.WriteFile(confPath, outYaml, iox.FullRWPermAccess); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+
+	templateModel, err := templatex.NewTemplateModel(
+		path.Join(p.paths.DistroPath, "templates", cluster.OperationPhasePlugins),
+		p.Path,
+		confPath,
+		outDirPath,
+		p.paths.ConfigPath,
+		".tpl",
+		false,
+		p.dryRun,
+	)
+	if err != nil {
+		return fmt.Errorf("error creating template model: %w", err)
+	}
+
+	p.StrictTemplates.Apply(templateModel, cluster.OperationPhasePlugins)
+
+	if err := templateModel.Generate(); err != nil {
+		return fmt.Errorf("error generating from template files: %w", err)
+	}
+
+	// The plugins as the rendered templates have them, with their dynamic values resolved: the kustomize
+	// folders are the ones that the apply script of the rendered phase builds.
+	specPlugins, hasPlugins := templateModel.Context["spec"]["plugins"].(map[any]any)
+	if !hasPlugins {
+		logrus.Info("Skipping plugins deletion as spec.plugins is not defined")
+
+		return nil
+	}
+
+	helmReleases := []any{}
+
+	if specPluginsHelm, ok := specPlugins["helm"].(map[any]any); ok {
+		if releases, ok := specPluginsHelm["releases"].([]any); ok {
+			helmReleases = releases
+		}
+	}
+
+	kustomizePlugins, _ := specPlugins["kustomize"].([]any)
+
+	if p.dryRun {
+		logrus.Info("Plugins deleted successfully (dry-run mode)")
+
+		return nil
+	}
+
+	// Kustomize plugins are removed first, as they may depend on CRDs installed by the helm releases.
+	for _, plugin := range kustomizePlugins {
+		if err := p.deleteKustomizePlugin(plugin, outDirPath); err != nil {
+			return err
+		}
+	}
+
+	if len(helmReleases) > 0 {
+		if err := p.helmfileRunner.Init(p.HelmPath); err != nil {
+			return fmt.Errorf("error deleting plugins with helmfile: %w", err)
+		}
+
+		if err := p.helmfileRunner.Destroy(); err != nil {
+			return fmt.Errorf("error deleting plugins with helmfile: %w", err)
+		}
+	}
+
+	logrus.Info("Plugins deleted successfully")
+
+	return nil
+}
+
+func (p *Plugins) deleteKustomizePlugin(plugin any, outDirPath string) error {
+	pluginMap, ok := plugin.(map[any]any)
+	if !ok {
+		return nil
+	}
+
+	name, _ := pluginMap["name"].(string)
+	folder, _ := pluginMap["folder"].(string)
+
+	if folder == "" {
+		logrus.Warnf("Skipping kustomize plugin %q deletion as no folder is defined", name)
+
+		return nil
+	}
+
+	outPath := filepath.Join(outDirPath, name+".yaml")
+
+	if err := p.kustomizeRunner.Build(folder, outPath); err != nil {
+		return fmt.Errorf("error building kustomize plugin %s: %w", name, err)
+	}
+
+	if err := p.kubeRunner.Delete("-f", outPath); err != nil {
+		return fmt.Errorf("error deleting kustomize plugin %s: %w", name, err)
+	}
+
+	return nil
+}
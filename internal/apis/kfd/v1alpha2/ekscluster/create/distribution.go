@@ -18,6 +18,8 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/ekscluster/phases"
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/ekscluster/private"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/runreport"
 	"github.com/sighupio/furyctl/internal/state"
 	"github.com/sighupio/furyctl/internal/tool/kubectl"
 	"github.com/sighupio/furyctl/internal/tool/shell"
@@ -49,16 +51,21 @@ type Distribution struct {
 	phase       string
 	upgrade     *upgrade.Upgrade
 	paths       cluster.CreatorPaths
+	planReport  *plan.Report
+	savedPlan   *plan.Saved
 }
 
 func NewDistribution(
 	paths cluster.CreatorPaths,
+	stateStore state.Storer,
 	furyctlConf private.EksclusterKfdV1Alpha2,
 	kfdManifest config.KFD,
 	infraOutputsPath string,
 	dryRun bool,
 	phase string,
 	upgr *upgrade.Upgrade,
+	planReport *plan.Report,
+	savedPlan *plan.Saved,
 ) *Distribution {
 	phaseOp := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhaseDistribution),
@@ -74,13 +81,7 @@ func NewDistribution(
 			ConfigPath:                         paths.ConfigPath,
 			InfrastructureTerraformOutputsPath: infraOutputsPath,
 			FuryctlConf:                        furyctlConf,
-			StateStore: state.NewStore(
-				paths.DistroPath,
-				paths.ConfigPath,
-				paths.WorkDir,
-				kfdManifest.Tools.Common.Kubectl.Version,
-				paths.BinPath,
-			),
+			StateStore:                         stateStore,
 			TFRunner: terraform.NewRunner(
 				execx.NewStdExecutor(),
 				terraform.Paths{
@@ -110,9 +111,11 @@ func NewDistribution(
 			true,
 			false,
 		),
-		phase:   phase,
-		upgrade: upgr,
-		paths:   paths,
+		phase:      phase,
+		upgrade:    upgr,
+		paths:      paths,
+		planReport: planReport,
+		savedPlan:  savedPlan,
 	}
 }
 
@@ -258,11 +261,37 @@ func (d *Distribution) coreDistribution(
 			return fmt.Errorf("error running pre-tf reducers: %w", err)
 		}
 
-		if _, err := d.TFRunner.Plan(timestampSec); err != nil && !d.DryRun {
-			return fmt.Errorf("error running terraform plan: %w", err)
+		tfPlan, restored, err := d.savedPlan.RestoreTerraformPlan(cluster.OperationPhaseDistribution, d.TFRunner.PlanFile())
+		if err != nil {
+			return fmt.Errorf("error restoring terraform plan: %w", err)
+		}
+
+		if !restored {
+			tfPlan, err = d.TFRunner.Plan(timestampSec)
+			if err != nil && !d.DryRun {
+				return fmt.Errorf("error running terraform plan: %w", err)
+			}
 		}
 
 		if d.DryRun {
+			// Without infrastructure outputs, as for a cluster that does not exist yet, the plan fails: a
+			// saved plan cannot carry it.
+			if err == nil {
+				tfPlanJSON, err := d.TFRunner.ShowPlan(timestampSec)
+				if err != nil {
+					return fmt.Errorf("error running terraform show: %w", err)
+				}
+
+				if err := d.planReport.AddTerraformPlan(
+					cluster.OperationPhaseDistribution,
+					tfPlanJSON,
+					tfPlan,
+					d.TFRunner.PlanFile(),
+				); err != nil {
+					return fmt.Errorf("error adding terraform plan to the plan report: %w", err)
+				}
+			}
+
 			if err := d.createDummyOutput(); err != nil {
 				return fmt.Errorf("error creating dummy output: %w", err)
 			}
@@ -293,6 +322,15 @@ func (d *Distribution) coreDistribution(
 				return fmt.Errorf("error copying from template: %w", err)
 			}
 
+			if err := d.planReport.AddManifests(
+				cluster.OperationPhaseDistribution,
+				d.KustomizePath,
+				d.KubectlPath,
+				path.Join(d.Path, "manifests"),
+			); err != nil {
+				return fmt.Errorf("error while planning distribution manifests: %w", err)
+			}
+
 			return nil
 		}
 
@@ -314,6 +352,16 @@ func (d *Distribution) coreDistribution(
 			return fmt.Errorf("error preparing distribution phase (post terraform): %w", err)
 		}
 
+		// The manifests render with the outputs of terraform, so they are checked against the saved plan once
+		// terraform has applied.
+		if err := d.savedPlan.VerifyManifests(
+			cluster.OperationPhaseDistribution,
+			d.KustomizePath,
+			path.Join(d.Path, "manifests"),
+		); err != nil {
+			return fmt.Errorf("error while checking the saved plan: %w", err)
+		}
+
 		if err := d.runReducers(rdcs, mCfg, LifecyclePostTf, []string{"manifests", ".gitignore"}); err != nil {
 			return fmt.Errorf("error running post-tf reducers: %w", err)
 		}
@@ -411,6 +459,8 @@ func (d *Distribution) runReducers(
 		); err != nil {
 			return fmt.Errorf("error applying manifests: %w", err)
 		}
+
+		runreport.AddReducers(r)
 	}
 
 	return nil
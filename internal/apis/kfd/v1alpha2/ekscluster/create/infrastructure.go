@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/ekscluster/private"
 	"github.com/sighupio/furyctl/internal/cluster"
 	parserx "github.com/sighupio/furyctl/internal/parser"
+	"github.com/sighupio/furyctl/internal/plan"
 	"github.com/sighupio/furyctl/internal/tool/terraform"
 	"github.com/sighupio/furyctl/internal/upgrade"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
@@ -37,6 +39,8 @@ type Infrastructure struct {
 	dryRun      bool
 	upgrade     *upgrade.Upgrade
 	paths       cluster.CreatorPaths
+	planReport  *plan.Report
+	savedPlan   *plan.Saved
 }
 
 func NewInfrastructure(
@@ -45,6 +49,8 @@ func NewInfrastructure(
 	paths cluster.CreatorPaths,
 	dryRun bool,
 	upgr *upgrade.Upgrade,
+	planReport *plan.Report,
+	savedPlan *plan.Saved,
 ) *Infrastructure {
 	phase := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhaseInfrastructure),
@@ -72,9 +78,11 @@ func NewInfrastructure(
 				Terraform: phase.TerraformPath,
 			},
 		),
-		dryRun:  dryRun,
-		upgrade: upgr,
-		paths:   paths,
+		dryRun:     dryRun,
+		upgrade:    upgr,
+		paths:      paths,
+		planReport: planReport,
+		savedPlan:  savedPlan,
 	}
 }
 
@@ -155,20 +163,45 @@ func (i *Infrastructure) coreInfrastructure(
 	timestampSec int64,
 ) error {
 	if startFrom != cluster.OperationSubPhasePostInfrastructure {
-		plan, err := i.tfRunner.Plan(timestampSec)
+		tfPlan, restored, err := i.savedPlan.RestoreTerraformPlan(
+			cluster.OperationPhaseInfrastructure,
+			i.tfRunner.PlanFile(),
+		)
 		if err != nil {
-			return fmt.Errorf("error running terraform/tofu plan: %w", err)
+			return fmt.Errorf("error restoring terraform/tofu plan: %w", err)
+		}
+
+		if !restored {
+			tfPlan, err = i.tfRunner.Plan(timestampSec)
+			if err != nil {
+				return fmt.Errorf("error running terraform/tofu plan: %w", err)
+			}
+		}
+
+		tfPlanJSON, err := i.tfRunner.ShowPlan(timestampSec)
+		if err != nil {
+			return fmt.Errorf("error running terraform/tofu show: %w", err)
 		}
 
 		if i.dryRun {
+			if err := i.planReport.AddTerraformPlan(
+				cluster.OperationPhaseInfrastructure,
+				tfPlanJSON,
+				tfPlan,
+				i.tfRunner.PlanFile(),
+			); err != nil {
+				return fmt.Errorf("error adding terraform plan to the plan report: %w", err)
+			}
+
 			return nil
 		}
 
-		tfParser := parserx.NewTfPlanParser(string(plan))
-
-		parsedPlan := tfParser.Parse()
+		parsedPlan, err := parserx.NewTfPlanJSONParser(tfPlanJSON).Parse()
+		if err != nil {
+			return fmt.Errorf("error parsing terraform/tofu plan: %w", err)
+		}
 
-		criticalResources := lo.Intersect(parsedPlan.Destroy, i.getCriticalTFResourceTypes())
+		criticalResources := criticalDeletions(parsedPlan, i.getCriticalTFResourceTypes())
 
 		if len(criticalResources) > 0 {
 			logrus.Warnf("Deletion of the following critical resources has been detected: %s. See the logs for more details.",
@@ -213,6 +246,14 @@ func (*Infrastructure) getCriticalTFResourceTypes() []string {
 	return []string{"aws_vpc", "aws_subnet"}
 }
 
+// criticalDeletions returns the resources of the critical types that the plan deletes or replaces, with
+// their address and the reason of the change.
+func criticalDeletions(parsedPlan *parserx.TfPlan, criticalTypes []string) []string {
+	return lo.FilterMap(parsedPlan.ResourceChanges, func(c parserx.TfResourceChange, _ int) (string, bool) {
+		return c.String(), c.Deletes() && slices.Contains(criticalTypes, c.Type)
+	})
+}
+
 func (i *Infrastructure) postInfrastructure(
 	upgradeState *upgrade.State,
 ) error {
@@ -14,7 +14,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/samber/lo"
 	"github.com/sirupsen/logrus"
 
 	"github.com/sighupio/furyctl/internal/apis/config"
@@ -22,6 +21,7 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/ekscluster/private"
 	"github.com/sighupio/furyctl/internal/cluster"
 	parserx "github.com/sighupio/furyctl/internal/parser"
+	"github.com/sighupio/furyctl/internal/plan"
 	"github.com/sighupio/furyctl/internal/tool/awscli"
 	"github.com/sighupio/furyctl/internal/tool/terraform"
 	"github.com/sighupio/furyctl/internal/upgrade"
@@ -50,10 +50,12 @@ const (
 type Kubernetes struct {
 	*phases.Kubernetes
 
-	tfRunner  *terraform.Runner
-	awsRunner *awscli.Runner
-	upgrade   *upgrade.Upgrade
-	paths     cluster.CreatorPaths
+	tfRunner   *terraform.Runner
+	awsRunner  *awscli.Runner
+	upgrade    *upgrade.Upgrade
+	paths      cluster.CreatorPaths
+	planReport *plan.Report
+	savedPlan  *plan.Saved
 }
 
 func NewKubernetes(
@@ -63,6 +65,8 @@ func NewKubernetes(
 	paths cluster.CreatorPaths,
 	dryRun bool,
 	upgr *upgrade.Upgrade,
+	planReport *plan.Report,
+	savedPlan *plan.Saved,
 ) *Kubernetes {
 	phase := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhaseKubernetes),
@@ -97,8 +101,10 @@ func NewKubernetes(
 				WorkDir: phase.Path,
 			},
 		),
-		upgrade: upgr,
-		paths:   paths,
+		upgrade:    upgr,
+		paths:      paths,
+		planReport: planReport,
+		savedPlan:  savedPlan,
 	}
 }
 
@@ -198,20 +204,42 @@ func (k *Kubernetes) coreKubernetes(
 	timestampSec int64,
 ) error {
 	if startFrom != cluster.OperationSubPhasePostKubernetes {
-		plan, err := k.tfRunner.Plan(timestampSec)
+		tfPlan, restored, err := k.savedPlan.RestoreTerraformPlan(cluster.OperationPhaseKubernetes, k.tfRunner.PlanFile())
 		if err != nil {
-			return fmt.Errorf("error running terraform plan: %w", err)
+			return fmt.Errorf("error restoring terraform plan: %w", err)
+		}
+
+		if !restored {
+			tfPlan, err = k.tfRunner.Plan(timestampSec)
+			if err != nil {
+				return fmt.Errorf("error running terraform plan: %w", err)
+			}
+		}
+
+		tfPlanJSON, err := k.tfRunner.ShowPlan(timestampSec)
+		if err != nil {
+			return fmt.Errorf("error running terraform show: %w", err)
 		}
 
 		if k.DryRun {
+			if err := k.planReport.AddTerraformPlan(
+				cluster.OperationPhaseKubernetes,
+				tfPlanJSON,
+				tfPlan,
+				k.tfRunner.PlanFile(),
+			); err != nil {
+				return fmt.Errorf("error adding terraform plan to the plan report: %w", err)
+			}
+
 			return nil
 		}
 
-		tfParser := parserx.NewTfPlanParser(string(plan))
-
-		parsedPlan := tfParser.Parse()
+		parsedPlan, err := parserx.NewTfPlanJSONParser(tfPlanJSON).Parse()
+		if err != nil {
+			return fmt.Errorf("error parsing terraform plan: %w", err)
+		}
 
-		criticalResources := lo.Intersect(parsedPlan.Destroy, k.getCriticalTFResourceTypes())
+		criticalResources := criticalDeletions(parsedPlan, k.getCriticalTFResourceTypes())
 
 		if len(criticalResources) > 0 {
 			logrus.Warnf("Deletion of the following critical resources has been detected: %s. See the logs for more details.",
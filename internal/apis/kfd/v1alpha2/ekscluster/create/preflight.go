@@ -20,7 +20,10 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/ekscluster/vpn"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/distribution"
+	parserx "github.com/sighupio/furyctl/internal/parser"
+	"github.com/sighupio/furyctl/internal/policy"
 	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	"github.com/sighupio/furyctl/internal/tool/awscli"
 	"github.com/sighupio/furyctl/internal/tool/kubectl"
 	"github.com/sighupio/furyctl/internal/tool/terraform"
@@ -56,12 +59,14 @@ type PreFlight struct {
 	phase          string
 	startFrom      string
 	upgradeEnabled bool
+	policyRules    []policy.Rule
 }
 
 func NewPreFlight( //nolint:revive // ignore maximum number of arguments
 	furyctlConf private.EksclusterKfdV1Alpha2,
 	kfdManifest config.KFD,
 	paths cluster.CreatorPaths,
+	stateStore state.Storer,
 	dryRun bool,
 	vpnAutoConnect bool,
 	skipVpn bool,
@@ -70,6 +75,7 @@ func NewPreFlight( //nolint:revive // ignore maximum number of arguments
 	phase string,
 	startFrom string,
 	upgradeEnabled bool,
+	policyRules []policy.Rule,
 ) (*PreFlight, error) {
 	p := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhasePreFlight),
@@ -117,13 +123,7 @@ func NewPreFlight( //nolint:revive // ignore maximum number of arguments
 			),
 			InfrastructureTerraformOutputsPath: infraOutputsPath,
 		},
-		stateStore: state.NewStore(
-			paths.DistroPath,
-			paths.ConfigPath,
-			paths.WorkDir,
-			kfdManifest.Tools.Common.Kubectl.Version,
-			paths.BinPath,
-		),
+		stateStore: stateStore,
 		tfRunnerKube: terraform.NewRunner(
 			execx.NewStdExecutor(),
 			terraform.Paths{
@@ -147,6 +147,7 @@ func NewPreFlight( //nolint:revive // ignore maximum number of arguments
 		phase:          phase,
 		startFrom:      startFrom,
 		upgradeEnabled: upgradeEnabled,
+		policyRules:    policyRules,
 	}, nil
 }
 
@@ -156,6 +157,10 @@ func (p *PreFlight) Exec(renderedConfig map[string]any) (*Status, error) {
 		Success: false,
 	}
 
+	if err := policy.EnforceConfig(p.policyRules, string(p.FuryctlConf.Kind), renderedConfig); err != nil {
+		return status, fmt.Errorf("error checking policies: %w", err)
+	}
+
 	logrus.Info("Ensure prerequisites are in place...")
 
 	if err := p.EnsureTerraformStateAWSS3Bucket(); err != nil {
@@ -217,6 +222,12 @@ func (p *PreFlight) Exec(renderedConfig map[string]any) (*Status, error) {
 
 	storedCfg, err := p.stateStore.GetConfig()
 	if err != nil {
+		// Only a missing state means that there is nothing to check: a state that cannot be read, for example
+		// with the wrong encryption key, stops the apply before anything runs.
+		if !errors.Is(err, backend.ErrNotFound) {
+			return status, fmt.Errorf("error while getting cluster state: %w", err)
+		}
+
 		logrus.Debug("error while getting cluster state: ", err)
 
 		logrus.Info("Cannot find state in cluster, skipping...")
@@ -263,6 +274,10 @@ func (p *PreFlight) Exec(renderedConfig map[string]any) (*Status, error) {
 				return status, fmt.Errorf("error checking reducer diffs: %w", err)
 			}
 
+			if err := policy.EnforceChanges(p.policyRules, string(p.FuryctlConf.Kind), renderedConfig, d); err != nil {
+				return status, fmt.Errorf("error checking policies: %w", err)
+			}
+
 			if (p.phase != cluster.OperationPhaseAll || p.startFrom != cluster.OperationPhaseAll) && !p.upgradeEnabled {
 				logrus.Info("Cluster configuration has changed, checking if changes are supported in the phases to apply...")
 
@@ -292,6 +307,14 @@ func (p *PreFlight) CreateDiffChecker(
 			return nil, fmt.Errorf("error while unmarshalling rendered config file: %w", err)
 		}
 
+		cfg, err := yamlx.FromFileV3[map[string]any](p.paths.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading config file: %w", err)
+		}
+
+		// The stored configuration has the values of the secrets as they were at the last apply.
+		parserx.RegisterStoredSecrets(clusterCfg, cfg)
+
 		return diffs.NewBaseChecker(clusterCfg, renderedConfig), nil
 	}
 
@@ -24,7 +24,12 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/ekscluster/vpn"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/policy"
+	"github.com/sighupio/furyctl/internal/runreport"
 	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	"github.com/sighupio/furyctl/internal/upgrade"
 	"github.com/sighupio/furyctl/pkg/reducers"
 	eksrules "github.com/sighupio/furyctl/pkg/rulesextractor"
@@ -62,6 +67,12 @@ type ClusterCreator struct {
 	upgrade              bool
 	externalUpgradesPath string
 	postApplyPhases      []string
+	planReport           *plan.Report
+	savedPlan            *plan.Saved
+	encryptionKey        encryption.Key
+	stateBackend         backend.Config
+	strictTemplates      cluster.StrictTemplates
+	policyRules          []policy.Rule
 }
 
 type Phases struct {
@@ -81,16 +92,11 @@ func (v *ClusterCreator) SetProperties(props []cluster.CreatorProperty) {
 	v.stateStore = state.NewStore(
 		v.paths.DistroPath,
 		v.paths.ConfigPath,
-		v.paths.WorkDir,
-		v.kfdManifest.Tools.Common.Kubectl.Version,
-		v.paths.BinPath,
+		v.encryptionKey,
+		v.stateBackend,
 	)
 
-	v.upgradeStateStore = upgrade.NewStateStore(
-		v.paths.WorkDir,
-		v.kfdManifest.Tools.Common.Kubectl.Version,
-		v.paths.BinPath,
-	)
+	v.upgradeStateStore = upgrade.NewStateStore(v.encryptionKey, v.stateBackend)
 }
 
 func (v *ClusterCreator) SetProperty(name string, value any) {
@@ -123,6 +129,18 @@ func (v *ClusterCreator) SetProperty(name string, value any) {
 		cluster.SetPropertyValue(value, &v.externalUpgradesPath)
 	case cluster.CreatorPropertyPostApplyPhases:
 		cluster.SetPropertyValue(value, &v.postApplyPhases)
+	case cluster.CreatorPropertyPlanReport:
+		cluster.SetPropertyValue(value, &v.planReport)
+	case cluster.CreatorPropertySavedPlan:
+		cluster.SetPropertyValue(value, &v.savedPlan)
+	case cluster.CreatorPropertyEncryptionKey:
+		cluster.SetPropertyValue(value, &v.encryptionKey)
+	case cluster.CreatorPropertyStateBackend:
+		cluster.SetPropertyValue(value, &v.stateBackend)
+	case cluster.CreatorPropertyStrictTemplates:
+		cluster.SetPropertyValue(value, &v.strictTemplates)
+	case cluster.CreatorPropertyPolicyRules:
+		cluster.SetPropertyValue(value, &v.policyRules)
 	default:
 		logrus.Debugf("ignoring unknown property %q", name)
 	}
@@ -274,7 +292,19 @@ func (v *ClusterCreator) CreateAsync(
 		return
 	}
 
-	status, err := phases.PreFlight.Exec(renderedConfig)
+	status, err := runreport.TrackValue(cluster.OperationPhasePreFlight, func() (*create.Status, error) {
+		if err := phases.PreFlight.Self().CheckTemplates(
+			v.paths.DistroPath,
+			v.paths.ConfigPath,
+			"kfd-v1alpha2",
+			"ekscluster",
+			v.templateSources(startFrom),
+		); err != nil {
+			return nil, fmt.Errorf("error while checking the templates of the phases: %w", err)
+		}
+
+		return phases.PreFlight.Exec(renderedConfig)
+	})
 	if err != nil {
 		errCh <- fmt.Errorf("error while executing preflight phase: %w", err)
 
@@ -299,6 +329,21 @@ func (v *ClusterCreator) CreateAsync(
 		status.Diffs,
 	)
 
+	if err := v.savedPlan.VerifyState(v.stateStore); err != nil {
+		errCh <- fmt.Errorf("error while checking the saved plan: %w", err)
+
+		return
+	}
+
+	if err := v.planReport.RecordState(v.stateStore); err != nil {
+		errCh <- fmt.Errorf("error while recording the cluster state in the plan: %w", err)
+
+		return
+	}
+
+	v.planReport.AddConfigChanges(status.Diffs, r)
+	v.planReport.AddReducers(cluster.OperationPhaseDistribution, rdcs, unsafeReducers)
+
 	if len(rdcs) > 0 {
 		logrus.Infof("Differences found from previous cluster configuration, "+
 			"handling the following changes:\n%s", rdcs.ToString())
@@ -319,7 +364,7 @@ func (v *ClusterCreator) CreateAsync(
 			false,
 		)
 
-		if err := preupgrade.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePreUpgrade, preupgrade.Exec); err != nil {
 			errCh <- fmt.Errorf("error while executing preupgrade phase: %w", err)
 
 			return
@@ -343,7 +388,7 @@ func (v *ClusterCreator) CreateAsync(
 
 	case cluster.OperationPhaseDistribution:
 		if len(rdcs) > 0 && len(unsafeReducers) > 0 {
-			confirm, err := cluster.AskConfirmation(cluster.IsForceEnabledForFeature(v.force, cluster.ForceFeatureMigrations))
+			confirm, err := cluster.AskConfirmation(v.forceMigrations())
 			if err != nil {
 				errCh <- err
 
@@ -370,7 +415,7 @@ func (v *ClusterCreator) CreateAsync(
 			return
 		}
 
-		if err := phases.Plugins.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePlugins, phases.Plugins.Exec); err != nil {
 			errCh <- err
 
 			return
@@ -378,7 +423,7 @@ func (v *ClusterCreator) CreateAsync(
 
 	case cluster.OperationPhaseAll:
 		if len(rdcs) > 0 && len(unsafeReducers) > 0 {
-			confirm, err := cluster.AskConfirmation(cluster.IsForceEnabledForFeature(v.force, cluster.ForceFeatureMigrations))
+			confirm, err := cluster.AskConfirmation(v.forceMigrations())
 			if err != nil {
 				errCh <- err
 
@@ -477,7 +522,9 @@ func (v *ClusterCreator) infraPhase(infra upgrade.OperatorPhaseAsync, vpnConnect
 		return fmt.Errorf("%w: check at %s", ErrInfraNotPresent, absPath)
 	}
 
-	if err := infra.Exec(StartFromFlagNotSet, &upgradeState); err != nil {
+	if err := runreport.Track(cluster.OperationPhaseInfrastructure, func() error {
+		return infra.Exec(StartFromFlagNotSet, &upgradeState)
+	}); err != nil {
 		return fmt.Errorf("error while executing infrastructure phase: %w", err)
 	}
 
@@ -522,7 +569,9 @@ func (v *ClusterCreator) kubernetesPhase(
 	logrus.Warn("Please make sure that the Kubernetes API is reachable before continuing" +
 		" (e.g. check VPN connection is active`), otherwise the installation will fail.")
 
-	if err := kube.Exec(StartFromFlagNotSet, &upgradeState); err != nil {
+	if err := runreport.Track(cluster.OperationPhaseKubernetes, func() error {
+		return kube.Exec(StartFromFlagNotSet, &upgradeState)
+	}); err != nil {
 		return fmt.Errorf("error while executing kubernetes phase: %w", err)
 	}
 
@@ -575,7 +624,9 @@ func (v *ClusterCreator) distributionPhase(
 		}
 	}
 
-	if err := distro.Exec(rdcs, StartFromFlagNotSet, &upgradeState); err != nil {
+	if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+		return distro.Exec(rdcs, StartFromFlagNotSet, &upgradeState)
+	}); err != nil {
 		return fmt.Errorf("error while installing SIGHUP Distribution: %w", err)
 	}
 
@@ -710,31 +761,33 @@ func (v *ClusterCreator) extraPhases(phases *Phases, upgradeState *upgrade.State
 		case cluster.OperationPhaseInfrastructure:
 			phases.Infrastructure.SetUpgrade(false)
 
-			if err := phases.Infrastructure.Exec(StartFromFlagNotSet, upgradeState); err != nil {
+			if err := runreport.Track(cluster.OperationPhaseInfrastructure, func() error {
+				return phases.Infrastructure.Exec(StartFromFlagNotSet, upgradeState)
+			}); err != nil {
 				return fmt.Errorf("error while executing post infrastructure phase: %w", err)
 			}
 
 		case cluster.OperationPhaseKubernetes:
 			phases.Kubernetes.SetUpgrade(false)
 
-			if err := phases.Kubernetes.Exec(StartFromFlagNotSet, upgradeState); err != nil {
+			if err := runreport.Track(cluster.OperationPhaseKubernetes, func() error {
+				return phases.Kubernetes.Exec(StartFromFlagNotSet, upgradeState)
+			}); err != nil {
 				return fmt.Errorf("error while executing post kubernetes phase: %w", err)
 			}
 
 		case cluster.OperationPhaseDistribution:
 			phases.Distribution.SetUpgrade(false)
 
-			if err := phases.Distribution.Exec(
-				reducers.Reducers{},
-				StartFromFlagNotSet,
-				upgradeState,
-			); err != nil {
+			if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+				return phases.Distribution.Exec(reducers.Reducers{}, StartFromFlagNotSet, upgradeState)
+			}); err != nil {
 				return fmt.Errorf("error while executing post distribution phase: %w", err)
 			}
 
 		case cluster.OperationPhasePlugins:
 			if distribution.HasFeature(v.kfdManifest, distribution.FeaturePlugins) {
-				if err := phases.Plugins.Exec(); err != nil {
+				if err := runreport.Track(cluster.OperationPhasePlugins, phases.Plugins.Exec); err != nil {
 					return fmt.Errorf("error while executing plugins phase: %w", err)
 				}
 			}
@@ -759,7 +812,9 @@ func (v *ClusterCreator) allPhasesExec(
 			startFrom == cluster.OperationPhaseInfrastructure ||
 			startFrom == cluster.OperationSubPhasePreInfrastructure ||
 			startFrom == cluster.OperationSubPhasePostInfrastructure) {
-		if err := phases.Infrastructure.Exec(v.getInfrastructureSubPhase(startFrom), upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseInfrastructure, func() error {
+			return phases.Infrastructure.Exec(v.getInfrastructureSubPhase(startFrom), upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing infrastructure phase: %w", err)
 		}
 
@@ -782,19 +837,23 @@ func (v *ClusterCreator) allPhasesExec(
 		startFrom != cluster.OperationPhaseDistribution &&
 		startFrom != cluster.OperationSubPhasePostDistribution &&
 		startFrom != cluster.OperationPhasePlugins {
-		if err := phases.Kubernetes.Exec(v.getKubernetesSubPhase(startFrom), upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, func() error {
+			return phases.Kubernetes.Exec(v.getKubernetesSubPhase(startFrom), upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing kubernetes phase: %w", err)
 		}
 	}
 
 	if startFrom != cluster.OperationPhasePlugins {
-		if err := phases.Distribution.Exec(rdcs, v.getDistributionSubPhase(startFrom), upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+			return phases.Distribution.Exec(rdcs, v.getDistributionSubPhase(startFrom), upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing distribution phase: %w", err)
 		}
 	}
 
 	if distribution.HasFeature(v.kfdManifest, distribution.FeaturePlugins) {
-		if err := phases.Plugins.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePlugins, phases.Plugins.Exec); err != nil {
 			return fmt.Errorf("error while executing plugins phase: %w", err)
 		}
 	}
@@ -847,6 +906,8 @@ func (v *ClusterCreator) setupPhases(upgr *upgrade.Upgrade, upgradeFlag bool, st
 			v.paths,
 			v.dryRun,
 			upgr,
+			v.planReport,
+			v.savedPlan,
 		),
 		v.dryRun,
 		upgr,
@@ -861,6 +922,8 @@ func (v *ClusterCreator) setupPhases(upgr *upgrade.Upgrade, upgradeFlag bool, st
 			v.paths,
 			v.dryRun,
 			upgr,
+			v.planReport,
+			v.savedPlan,
 		),
 		v.dryRun,
 		upgr,
@@ -870,12 +933,15 @@ func (v *ClusterCreator) setupPhases(upgr *upgrade.Upgrade, upgradeFlag bool, st
 		v.upgradeStateStore,
 		create.NewDistribution(
 			v.paths,
+			v.stateStore,
 			v.furyctlConf,
 			v.kfdManifest,
 			infra.Self().TerraformOutputsPath,
 			v.dryRun,
 			v.phase,
 			upgr,
+			v.planReport,
+			v.savedPlan,
 		),
 		v.dryRun,
 		upgr,
@@ -886,12 +952,14 @@ func (v *ClusterCreator) setupPhases(upgr *upgrade.Upgrade, upgradeFlag bool, st
 		v.kfdManifest,
 		string(v.furyctlConf.Kind),
 		v.dryRun,
+		v.planReport,
 	)
 
 	preflight, err := create.NewPreFlight(
 		v.furyctlConf,
 		v.kfdManifest,
 		v.paths,
+		v.stateStore,
 		v.dryRun,
 		v.vpnAutoConnect,
 		v.skipVpn,
@@ -900,11 +968,21 @@ func (v *ClusterCreator) setupPhases(upgr *upgrade.Upgrade, upgradeFlag bool, st
 		v.phase,
 		startFrom,
 		upgradeFlag,
+		v.policyRules,
 	)
 	if err != nil {
 		return nil, nil, nil, nil, nil, fmt.Errorf("error while initiating preflight phase: %w", err)
 	}
 
+	cluster.SetStrictTemplates(
+		v.strictTemplates,
+		preflight.Self(),
+		infra.Self(),
+		kube.Self(),
+		distro.Self(),
+		plugins.Self(),
+	)
+
 	return infra, kube, distro, plugins, preflight, nil
 }
 
@@ -934,3 +1012,45 @@ func (*ClusterCreator) logVPNKill(vpnConnector *vpn.Connector) error {
 
 	return nil
 }
+
+// templateSources returns the templates of the phases that the apply runs from startFrom on, that the
+// preflight checks in strict mode before any of them runs.
+func (v *ClusterCreator) templateSources(startFrom string) []cluster.TemplateSource {
+	sources := []cluster.TemplateSource{}
+
+	// The infrastructure phase runs only for a configuration with an infrastructure.
+	if v.furyctlConf.Spec.Infrastructure != nil {
+		sources = append(sources, cluster.TemplateSource{
+			Phase: cluster.OperationPhaseInfrastructure,
+			Path:  path.Join(v.paths.DistroPath, "templates", cluster.OperationPhaseInfrastructure, "ekscluster", "terraform"),
+		})
+	}
+
+	sources = append(sources,
+		cluster.TemplateSource{
+			Phase: cluster.OperationPhaseKubernetes,
+			Path:  path.Join(v.paths.DistroPath, "templates", cluster.OperationPhaseKubernetes, "ekscluster", "terraform"),
+		},
+		cluster.TemplateSource{
+			Phase: cluster.OperationPhaseDistribution,
+			Path:  path.Join(v.paths.DistroPath, "templates", cluster.OperationPhaseDistribution),
+		},
+	)
+
+	if distribution.HasFeature(v.kfdManifest, distribution.FeaturePlugins) {
+		sources = append(sources, cluster.TemplateSource{
+			Phase: cluster.OperationPhasePlugins,
+			Path:  path.Join(v.paths.DistroPath, "templates", cluster.OperationPhasePlugins),
+		})
+	}
+
+	return cluster.SelectTemplateSources(sources, v.phase, startFrom)
+}
+
+// forceMigrations tells whether the unsafe migrations run without a confirmation. A plan applies
+// nothing, and the migrations of a saved plan were reviewed with the plan, so they do not ask.
+func (v *ClusterCreator) forceMigrations() bool {
+	return v.planReport != nil ||
+		v.savedPlan != nil ||
+		cluster.IsForceEnabledForFeature(v.force, cluster.ForceFeatureMigrations)
+}
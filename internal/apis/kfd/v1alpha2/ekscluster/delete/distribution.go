@@ -36,7 +36,6 @@ type Distribution struct {
 
 	awsRunner   *awscli.Runner
 	shellRunner *shell.Runner
-	kubeClient  *kubernetes.Client
 	dryRun      bool
 	paths       cluster.DeleterPaths
 }
@@ -46,6 +45,7 @@ func NewDistribution(
 	kfdManifest config.KFD,
 	infraOutputsPath string,
 	paths cluster.DeleterPaths,
+	stateStore state.Storer,
 	furyctlConf private.EksclusterKfdV1Alpha2,
 ) *Distribution {
 	phase := cluster.NewOperationPhase(
@@ -62,13 +62,7 @@ func NewDistribution(
 			ConfigPath:                         paths.ConfigPath,
 			InfrastructureTerraformOutputsPath: infraOutputsPath,
 			FuryctlConf:                        furyctlConf,
-			StateStore: state.NewStore(
-				paths.DistroPath,
-				paths.ConfigPath,
-				paths.WorkDir,
-				kfdManifest.Tools.Common.Kubectl.Version,
-				paths.BinPath,
-			),
+			StateStore:                         stateStore,
 			TFRunner: terraform.NewRunner(
 				execx.NewStdExecutor(),
 				terraform.Paths{
@@ -87,14 +81,6 @@ func NewDistribution(
 				WorkDir: phase.Path,
 			},
 		),
-		kubeClient: kubernetes.NewClient(
-			phase.KubectlPath,
-			path.Join(phase.Path, "manifests"),
-			true,
-			true,
-			false,
-			execx.NewStdExecutor(),
-		),
 		shellRunner: shell.NewRunner(
 			execx.NewStdExecutor(),
 			shell.Paths{
@@ -133,7 +119,12 @@ func (d *Distribution) Exec() error {
 
 	logrus.Info("Checking cluster connectivity...")
 
-	if _, err := d.kubeClient.ToolVersion(); err != nil {
+	kubeClient, err := kubernetes.NewClient("")
+	if err != nil {
+		return fmt.Errorf("%w: %w", errClusterConnect, err)
+	}
+
+	if _, err := kubeClient.ServerVersion(); err != nil {
 		return fmt.Errorf("%w: %w", errClusterConnect, err)
 	}
 
@@ -146,33 +137,13 @@ func (d *Distribution) Exec() error {
 
 		logrus.Info("The following resources, regardless of the built manifests, are going to be deleted:")
 
-		if _, err := d.kubeClient.ListNamespaceResources("ingress", "all"); err != nil {
-			logrus.Errorf("error while getting list of ingress resources: %v", err)
-		}
-
-		if _, err := d.kubeClient.ListNamespaceResources("prometheus", "monitoring"); err != nil {
-			logrus.Errorf("error while getting list of prometheus resources: %v", err)
-		}
-
-		if _, err := d.kubeClient.ListNamespaceResources("persistentvolumeclaim", "monitoring"); err != nil {
-			logrus.Errorf("error while getting list of persistentvolumeclaim resources: %v", err)
-		}
-
-		if _, err := d.kubeClient.ListNamespaceResources("persistentvolumeclaim", "logging"); err != nil {
-			logrus.Errorf("error while getting list of persistentvolumeclaim resources: %v", err)
-		}
-
-		if _, err := d.kubeClient.ListNamespaceResources("statefulset", "logging"); err != nil {
-			logrus.Errorf("error while getting list of statefulset resources: %v", err)
-		}
-
-		if _, err := d.kubeClient.ListNamespaceResources("logging", "logging"); err != nil {
-			logrus.Errorf("error while getting list of logging resources: %v", err)
-		}
-
-		if _, err := d.kubeClient.ListNamespaceResources("service", "ingress-nginx"); err != nil {
-			logrus.Errorf("error while getting list of service resources: %v", err)
-		}
+		printResources(kubeClient, "ingress", kubernetes.AllNamespaces)
+		printResources(kubeClient, "prometheus", "monitoring")
+		printResources(kubeClient, "persistentvolumeclaim", "monitoring")
+		printResources(kubeClient, "persistentvolumeclaim", "logging")
+		printResources(kubeClient, "statefulset", "logging")
+		printResources(kubeClient, "logging", "logging")
+		printResources(kubeClient, "service", "ingress-nginx")
 
 		logrus.Info("SIGHUP Distribution deleted successfully (dry-run mode)")
 
@@ -195,3 +166,17 @@ func (d *Distribution) Exec() error {
 
 	return nil
 }
+
+// printResources prints the objects of the resource in the namespace, that the deletion removes.
+func printResources(kubeClient *kubernetes.Client, resName, ns string) {
+	resources, err := kubeClient.ListNamespaceResources(resName, ns)
+	if err != nil {
+		logrus.Errorf("error while getting list of %s resources: %v", resName, err)
+
+		return
+	}
+
+	for _, res := range resources {
+		logrus.Infof("- %s %s", res.Kind, res.Name)
+	}
+}
@@ -16,22 +16,37 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/ekscluster/vpn"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/runreport"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 )
 
 type ClusterDeleter struct {
-	paths          cluster.DeleterPaths
-	kfdManifest    config.KFD
-	furyctlConf    private.EksclusterKfdV1Alpha2
-	phase          string
-	skipVpn        bool
-	vpnAutoConnect bool
-	dryRun         bool
+	paths           cluster.DeleterPaths
+	kfdManifest     config.KFD
+	furyctlConf     private.EksclusterKfdV1Alpha2
+	phase           string
+	skipVpn         bool
+	vpnAutoConnect  bool
+	dryRun          bool
+	stateStore      state.Storer
+	encryptionKey   encryption.Key
+	stateBackend    backend.Config
+	strictTemplates cluster.StrictTemplates
 }
 
 func (d *ClusterDeleter) SetProperties(props []cluster.DeleterProperty) {
 	for _, prop := range props {
 		d.SetProperty(prop.Name, prop.Value)
 	}
+
+	d.stateStore = state.NewStore(
+		d.paths.DistroPath,
+		d.paths.ConfigPath,
+		d.encryptionKey,
+		d.stateBackend,
+	)
 }
 
 func (d *ClusterDeleter) SetProperty(name string, value any) {
@@ -56,6 +71,12 @@ func (d *ClusterDeleter) SetProperty(name string, value any) {
 		cluster.SetPropertyValue(value, &d.vpnAutoConnect)
 	case cluster.DeleterPropertyDryRun:
 		cluster.SetPropertyValue(value, &d.dryRun)
+	case cluster.DeleterPropertyEncryptionKey:
+		cluster.SetPropertyValue(value, &d.encryptionKey)
+	case cluster.DeleterPropertyStateBackend:
+		cluster.SetPropertyValue(value, &d.stateBackend)
+	case cluster.DeleterPropertyStrictTemplates:
+		cluster.SetPropertyValue(value, &d.strictTemplates)
 	default:
 		logrus.Debugf("ignoring unknown property %q", name)
 	}
@@ -73,6 +94,7 @@ func (d *ClusterDeleter) Delete() error {
 		d.kfdManifest,
 		infra.Self().TerraformOutputsPath,
 		d.paths,
+		d.stateStore,
 		d.furyctlConf,
 	)
 
@@ -118,13 +140,21 @@ func (d *ClusterDeleter) Delete() error {
 		return fmt.Errorf("error while creating preflight phase: %w", err)
 	}
 
-	if err := preflight.Exec(); err != nil {
+	cluster.SetStrictTemplates(
+		d.strictTemplates,
+		preflight.Self(),
+		infra.Self(),
+		kube.Self(),
+		distro.Self(),
+	)
+
+	if err := runreport.Track(cluster.OperationPhasePreFlight, preflight.Exec); err != nil {
 		return fmt.Errorf("error while executing preflight phase: %w", err)
 	}
 
 	switch d.phase {
 	case cluster.OperationPhaseInfrastructure:
-		if err := infra.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseInfrastructure, infra.Exec); err != nil {
 			return fmt.Errorf("error while deleting infrastructure phase: %w", err)
 		}
 
@@ -140,7 +170,7 @@ func (d *ClusterDeleter) Delete() error {
 		logrus.Warn("Please make sure that the Kubernetes API is reachable before continuing" +
 			" (e.g. check VPN connection is active`), otherwise the deletion will fail.")
 
-		if err := kube.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, kube.Exec); err != nil {
 			return fmt.Errorf("error while deleting kubernetes phase: %w", err)
 		}
 
@@ -153,7 +183,7 @@ func (d *ClusterDeleter) Delete() error {
 			}
 		}
 
-		if err := distro.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, distro.Exec); err != nil {
 			return fmt.Errorf("error while deleting distribution phase: %w", err)
 		}
 
@@ -173,16 +203,16 @@ func (d *ClusterDeleter) Delete() error {
 			}
 		}
 
-		if err := distro.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, distro.Exec); err != nil {
 			return fmt.Errorf("error while deleting distribution phase: %w", err)
 		}
 
-		if err := kube.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, kube.Exec); err != nil {
 			return fmt.Errorf("error while deleting kubernetes phase: %w", err)
 		}
 
 		if d.furyctlConf.Spec.Infrastructure != nil {
-			if err := infra.Exec(); err != nil {
+			if err := runreport.Track(cluster.OperationPhaseInfrastructure, infra.Exec); err != nil {
 				return fmt.Errorf("error while deleting infrastructure phase: %w", err)
 			}
 		}
@@ -8,13 +8,15 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"strings"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/sighupio/furyctl/internal/apis/config"
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/public"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/kubernetes"
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/runreport"
 	"github.com/sighupio/furyctl/internal/state"
 	"github.com/sighupio/furyctl/internal/tool/kubectl"
 	"github.com/sighupio/furyctl/internal/tool/shell"
@@ -43,14 +45,19 @@ type Distribution struct {
 	shellRunner     *shell.Runner
 	kubeRunner      *kubectl.Runner
 	upgrade         *upgrade.Upgrade
+	planReport      *plan.Report
+	savedPlan       *plan.Saved
 }
 
 func NewDistribution(
 	furyctlConf public.ImmutableKfdV1Alpha2,
 	kfdManifest config.KFD,
 	paths cluster.CreatorPaths,
+	stateStore state.Storer,
 	dryRun bool,
 	upgr *upgrade.Upgrade,
+	planReport *plan.Report,
+	savedPlan *plan.Saved,
 ) *Distribution {
 	phase := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhaseDistribution),
@@ -65,13 +72,7 @@ func NewDistribution(
 		paths:           paths,
 		dryRun:          dryRun,
 		furyctlConfPath: paths.ConfigPath,
-		stateStore: state.NewStore(
-			paths.DistroPath,
-			paths.ConfigPath,
-			paths.WorkDir,
-			kfdManifest.Tools.Common.Kubectl.Version,
-			paths.BinPath,
-		),
+		stateStore:      stateStore,
 		shellRunner: shell.NewRunner(
 			execx.NewStdExecutor(),
 			shell.Paths{
@@ -89,7 +90,9 @@ func NewDistribution(
 			true,
 			false,
 		),
-		upgrade: upgr,
+		upgrade:    upgr,
+		planReport: planReport,
+		savedPlan:  savedPlan,
 	}
 }
 
@@ -105,7 +108,24 @@ func (d *Distribution) Exec(rdcs reducers.Reducers, startFrom string, upgradeSta
 		return fmt.Errorf("error preparing distribution phase: %w", err)
 	}
 
+	if err := d.savedPlan.VerifyManifests(
+		cluster.OperationPhaseDistribution,
+		d.KustomizePath,
+		path.Join(d.Path, "manifests"),
+	); err != nil {
+		return fmt.Errorf("error while checking the saved plan: %w", err)
+	}
+
 	if d.dryRun {
+		if err := d.planReport.AddManifests(
+			cluster.OperationPhaseDistribution,
+			d.KustomizePath,
+			d.KubectlPath,
+			path.Join(d.Path, "manifests"),
+		); err != nil {
+			return fmt.Errorf("error while planning distribution manifests: %w", err)
+		}
+
 		logrus.Info("SIGHUP Distribution installed successfully (dry-run mode)")
 
 		return nil
@@ -165,7 +185,12 @@ func (d *Distribution) prepare() (templatex.Config, error) {
 
 	logrus.Info("Checking that the cluster is reachable...")
 
-	if _, err := d.kubeRunner.Version(); err != nil {
+	kubeClient, err := kubernetes.NewClient("")
+	if err != nil {
+		return templatex.Config{}, fmt.Errorf("error connecting to cluster: %w", err)
+	}
+
+	if _, err := kubeClient.ServerVersion(); err != nil {
 		logrus.Debugf("Got error while running cluster reachability check: %s", err)
 
 		return templatex.Config{}, fmt.Errorf("error connecting to cluster: %w", err)
@@ -173,12 +198,12 @@ func (d *Distribution) prepare() (templatex.Config, error) {
 
 	logrus.Info("Checking for a default storage class...")
 
-	getStorageClassesOutput, err := d.kubeRunner.Get(false, "", "storageclasses")
+	storageClasses, err := kubeClient.StorageClasses()
 	if err != nil {
 		return templatex.Config{}, fmt.Errorf("error while checking storage class: %w", err)
 	}
 
-	if getStorageClassesOutput == "No resources found" {
+	if !storageClasses.Available {
 		logrus.Warn(
 			"No storage classes found in the cluster. " +
 				"logging module (if enabled), tracing module (if enabled), dr module (if enabled) " +
@@ -187,10 +212,7 @@ func (d *Distribution) prepare() (templatex.Config, error) {
 		)
 
 		storageClassAvailable = false
-	}
-
-	defaultSC := "(default)"
-	if !strings.Contains(getStorageClassesOutput, defaultSC) && getStorageClassesOutput != "No resources found" {
+	} else if storageClasses.Default == "" {
 		logrus.Warn(
 			"No *default* storage classes found in the cluster. " +
 				"logging module (if enabled), tracing module (if enabled), dr module (if enabled) " +
@@ -330,6 +352,8 @@ func (d *Distribution) runReducers(
 		); err != nil {
 			return fmt.Errorf("error applying manifests: %w", err)
 		}
+
+		runreport.AddReducers(r)
 	}
 
 	return nil
@@ -58,3 +58,25 @@ func VersionVarsForPhase(phasePath, kubeVersion, kubectlBin string) (map[any]any
 
 	return versionVarsFromAssets(kubeVersion, kubectlBin, immutableAssets), nil
 }
+
+// ImageReferences returns the images that the nodes pull outside of the distribution manifests: the
+// sandbox image of containerd, the control plane images of kubeadm and the haproxy of the load balancers.
+// The air-gapped bundle mirrors them next to the images of the distribution.
+func ImageReferences(phasePath, kubeVersion string) ([]string, error) {
+	immutableAssets, err := selectImmutableAssets(phasePath, kubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("error selecting immutable assets: %w", err)
+	}
+
+	refs := []string{fmt.Sprintf("%s/pause:%s", immutableAssets.ImageRegistry, immutableAssets.SandboxTag)}
+
+	for _, component := range []string{"kube-apiserver", "kube-controller-manager", "kube-scheduler", "kube-proxy"} {
+		refs = append(refs, fmt.Sprintf("%s/%s:v%s", immutableAssets.ImageRegistry, component, kubeVersion))
+	}
+
+	if immutableAssets.HaproxyImage != "" && immutableAssets.HaproxyTag != "" {
+		refs = append(refs, immutableAssets.HaproxyImage+":"+immutableAssets.HaproxyTag)
+	}
+
+	return refs, nil
+}
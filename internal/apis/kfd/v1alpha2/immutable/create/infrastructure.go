@@ -5,6 +5,7 @@
 package create
 
 import (
+	"errors"
 	"fmt"
 	"net/url"
 	"path/filepath"
@@ -21,8 +22,11 @@ import (
 	"github.com/sighupio/furyctl/internal/upgrade"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
 	templatex "github.com/sighupio/furyctl/pkg/template"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
+var ErrNodeNotFound = errors.New("node not found in spec.infrastructure.nodes")
+
 // Infrastructure wraps the common infrastructure phase.
 type Infrastructure struct {
 	*cluster.OperationPhase
@@ -35,6 +39,8 @@ type Infrastructure struct {
 	dryRun        bool
 	ansibleRunner *ansible.Runner
 	force         []string
+
+	upgradeStateStore upgrade.Storer
 }
 
 // NewInfrastructure creates a new Infrastructure phase.
@@ -49,6 +55,7 @@ func NewInfrastructure(
 	paths cluster.CreatorPaths,
 	dryRun bool,
 	force []string,
+	upgradeStateStore upgrade.Storer,
 ) *Infrastructure {
 	return &Infrastructure{
 		OperationPhase: phase,
@@ -69,7 +76,8 @@ func NewInfrastructure(
 				filepath.Join(phase.Path, "ansible"),
 			),
 		),
-		force: force,
+		force:             force,
+		upgradeStateStore: upgradeStateStore,
 	}
 }
 
@@ -81,26 +89,58 @@ func (i *Infrastructure) Exec(_ string, upgradeState *upgrade.State) error {
 		return nil
 	}
 
-	if i.upgradeNode != "" {
-		logrus.Debug("node upgrade requested, skipping nodes bootstrap...")
-		logrus.Warn("Upgrade for Infrastructure phase (load balancers) not implemented yet...")
+	if i.upgradeNode != "" || i.upgrade.Enabled {
+		return i.upgradeNodes(upgradeState)
+	}
 
-		return nil
+	if err := i.BootstrapNodes(); err != nil {
+		return fmt.Errorf("preparing for infrastructure phase failed: %w", err)
 	}
 
-	if i.upgrade.Enabled {
-		logrus.Debug("running an upgrade, skipping nodes bootstrap...")
-		logrus.Warn("Upgrade for Infrastructure phase not implemented yet")
+	if err := i.renderAnsibleTemplates(); err != nil {
+		return err
+	}
 
-		upgradeState.Phases.Infrastructure.Status = upgrade.PhaseStatusSuccess
+	// Struct to keep each node's bootstrap status.
+	nodeStatus := lo.SliceToMap(
+		i.furyctlConf.Spec.Infrastructure.Nodes,
+		func(node public.SpecInfrastructureNode) (string, string) {
+			return node.Hostname, serve.StatusPending
+		},
+	)
 
-		return nil
+	// Serve the downloaded assets to the machines.
+	ipxeServer, err := url.Parse(string(i.furyctlConf.Spec.Infrastructure.IpxeServer.Url))
+	ipxeServerPort := ""
+
+	if err != nil {
+		return fmt.Errorf("failed to parse ipxe server URL: %w", err)
 	}
 
-	if err := i.BootstrapNodes(); err != nil {
-		return fmt.Errorf("preparing for infrastructure phase failed: %w", err)
+	ipxeServerHost := lo.FromPtrOr(i.furyctlConf.Spec.Infrastructure.IpxeServer.BindAddress, ipxeServer.Hostname())
+
+	if i.furyctlConf.Spec.Infrastructure.IpxeServer.BindPort != nil {
+		ipxeServerPort = strconv.Itoa(*i.furyctlConf.Spec.Infrastructure.IpxeServer.BindPort)
+	} else {
+		ipxeServerPort = ipxeServer.Port()
+	}
+
+	if err := serve.Path(ipxeServerHost, ipxeServerPort, filepath.Join(i.Path, "server"), nodeStatus); err != nil {
+		return fmt.Errorf("serving assets failed: %w", err)
+	}
+
+	logrus.Info("Applying nodes configuration...")
+
+	// Run apply playbook.
+	if _, err := i.ansibleRunner.Playbook("apply.yaml"); err != nil {
+		return fmt.Errorf("error applying playbook: %w", err)
 	}
 
+	return nil
+}
+
+// renderAnsibleTemplates renders the infrastructure ansible inventory and playbooks.
+func (i *Infrastructure) renderAnsibleTemplates() error {
 	furyctlMerger, err := i.CreateFuryctlMerger(
 		i.paths.DistroPath,
 		i.paths.ConfigPath,
@@ -149,39 +189,130 @@ func (i *Infrastructure) Exec(_ string, upgradeState *upgrade.State) error {
 		return fmt.Errorf("error copying from templates: %w", err)
 	}
 
-	// Struct to keep each node's bootstrap status.
-	nodeStatus := lo.SliceToMap(
+	return nil
+}
+
+// upgradeNodes regenerates the nodes configuration for the new immutable.yaml assets and then rolls it
+// out one node at a time. The progress of each node is recorded in the upgrade state, and stored in the
+// cluster after every node, so an interrupted upgrade resumes from the first node not upgraded yet.
+func (i *Infrastructure) upgradeNodes(upgradeState *upgrade.State) error {
+	logrus.Info("Upgrading nodes configuration...")
+
+	if err := i.BootstrapNodes(); err != nil {
+		return fmt.Errorf("error regenerating nodes configuration: %w", err)
+	}
+
+	if err := i.renderAnsibleTemplates(); err != nil {
+		return err
+	}
+
+	return i.rollNodes(upgradeState)
+}
+
+// rollNodes runs the apply playbook on the nodes to upgrade one at a time, skipping the nodes that an
+// interrupted upgrade already upgraded.
+func (i *Infrastructure) rollNodes(upgradeState *upgrade.State) error {
+	if upgradeState.Phases.Infrastructure == nil {
+		upgradeState.Phases.Infrastructure = &upgrade.Phase{Status: upgrade.PhaseStatusPending}
+	}
+
+	phaseState := upgradeState.Phases.Infrastructure
+
+	if phaseState.Nodes == nil {
+		phaseState.Nodes = make(map[string]upgrade.PhaseStatus)
+	}
+
+	hostnames, err := i.nodesToUpgrade()
+	if err != nil {
+		return err
+	}
+
+	for _, hostname := range hostnames {
+		if _, ok := phaseState.Nodes[hostname]; !ok {
+			phaseState.Nodes[hostname] = upgrade.PhaseStatusPending
+		}
+	}
+
+	for _, hostname := range hostnames {
+		if phaseState.Nodes[hostname] == upgrade.PhaseStatusSuccess && i.upgradeNode == "" {
+			logrus.Infof("Node %s already upgraded, skipping...", hostname)
+
+			continue
+		}
+
+		logrus.Infof("Upgrading node %s...", hostname)
+
+		if _, err := i.ansibleRunner.Playbook("apply.yaml", "--limit", hostname); err != nil {
+			phaseState.Nodes[hostname] = upgrade.PhaseStatusFailed
+			phaseState.Status = upgrade.PhaseStatusFailed
+
+			if sErr := i.storeUpgradeState(upgradeState); sErr != nil {
+				return fmt.Errorf("error upgrading node %s: %w, %w", hostname, err, sErr)
+			}
+
+			return fmt.Errorf("error upgrading node %s: %w", hostname, err)
+		}
+
+		phaseState.Nodes[hostname] = upgrade.PhaseStatusSuccess
+
+		if err := i.storeUpgradeState(upgradeState); err != nil {
+			return err
+		}
+
+		logrus.Infof("Node %s upgraded successfully", hostname)
+	}
+
+	if i.upgrade.Enabled {
+		phaseState.Status = upgrade.PhaseStatusSuccess
+
+		if err := i.storeUpgradeState(upgradeState); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// nodesToUpgrade returns the hostnames to roll, in the order they are declared in the configuration.
+func (i *Infrastructure) nodesToUpgrade() ([]string, error) {
+	hostnames := lo.Map(
 		i.furyctlConf.Spec.Infrastructure.Nodes,
-		func(node public.SpecInfrastructureNode) (string, string) {
-			return node.Hostname, serve.StatusPending
+		func(node public.SpecInfrastructureNode, _ int) string {
+			return node.Hostname
 		},
 	)
 
-	// Serve the downloaded assets to the machines.
-	ipxeServer, err := url.Parse(string(i.furyctlConf.Spec.Infrastructure.IpxeServer.Url))
-	ipxeServerPort := ""
+	if i.upgradeNode == "" {
+		return hostnames, nil
+	}
 
-	if err != nil {
-		return fmt.Errorf("failed to parse ipxe server URL: %w", err)
+	if !lo.Contains(hostnames, i.upgradeNode) {
+		return nil, fmt.Errorf("%w: %s", ErrNodeNotFound, i.upgradeNode)
 	}
 
-	ipxeServerHost := lo.FromPtrOr(i.furyctlConf.Spec.Infrastructure.IpxeServer.BindAddress, ipxeServer.Hostname())
+	return []string{i.upgradeNode}, nil
+}
 
-	if i.furyctlConf.Spec.Infrastructure.IpxeServer.BindPort != nil {
-		ipxeServerPort = strconv.Itoa(*i.furyctlConf.Spec.Infrastructure.IpxeServer.BindPort)
-	} else {
-		ipxeServerPort = ipxeServer.Port()
+// storeUpgradeState saves the upgrade state in the cluster. Single node upgrades are driven by the
+// user and are not tracked, as it happens for the other phases. The phases of the state are merged into the
+// stored ones, as running only the infrastructure phase has a state with the infrastructure phases only.
+func (i *Infrastructure) storeUpgradeState(upgradeState *upgrade.State) error {
+	if !i.upgrade.Enabled || i.upgradeStateStore == nil {
+		return nil
 	}
 
-	if err := serve.Path(ipxeServerHost, ipxeServerPort, filepath.Join(i.Path, "server"), nodeStatus); err != nil {
-		return fmt.Errorf("serving assets failed: %w", err)
+	state := &upgrade.State{}
+
+	if s, err := i.upgradeStateStore.Get(); err != nil {
+		logrus.Debugf("error while getting upgrade state, storing a new one: %v", err)
+	} else if err := yamlx.UnmarshalV3(s, state); err != nil {
+		return fmt.Errorf("error while unmarshalling upgrade state: %w", err)
 	}
 
-	logrus.Info("Applying nodes configuration...")
+	state.Merge(upgradeState)
 
-	// Run apply playbook.
-	if _, err := i.ansibleRunner.Playbook("apply.yaml"); err != nil {
-		return fmt.Errorf("error applying playbook: %w", err)
+	if err := i.upgradeStateStore.Store(state); err != nil {
+		return fmt.Errorf("error storing upgrade state: %w", err)
 	}
 
 	return nil
@@ -0,0 +1,224 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package create
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/public"
+	"github.com/sighupio/furyctl/internal/tool/ansible"
+	"github.com/sighupio/furyctl/internal/upgrade"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+var errStateNotFound = errors.New("upgrade state not found")
+
+// fakeStateStore keeps the upgrade state in memory, as the cluster would.
+type fakeStateStore struct {
+	state []byte
+}
+
+func (s *fakeStateStore) Store(state *upgrade.State) error {
+	out, err := yamlx.MarshalV3(state)
+	if err != nil {
+		return err
+	}
+
+	s.state = out
+
+	return nil
+}
+
+func (s *fakeStateStore) Get() ([]byte, error) {
+	if s.state == nil {
+		return nil, errStateNotFound
+	}
+
+	return s.state, nil
+}
+
+func (s *fakeStateStore) Delete() error {
+	s.state = nil
+
+	return nil
+}
+
+func (*fakeStateStore) GetLatestResumablePhase(_ *upgrade.State) string {
+	return ""
+}
+
+func (s *fakeStateStore) stored(t *testing.T) upgrade.State {
+	t.Helper()
+
+	state := upgrade.State{}
+
+	require.NoError(t, yamlx.UnmarshalV3(s.state, &state))
+
+	return state
+}
+
+func TestInfrastructureNodesToUpgrade(t *testing.T) {
+	t.Parallel()
+
+	conf := public.ImmutableKfdV1Alpha2{
+		Spec: public.Spec{
+			Infrastructure: public.SpecInfrastructure{
+				Nodes: []public.SpecInfrastructureNode{
+					{Hostname: "cp1"},
+					{Hostname: "worker1"},
+					{Hostname: "worker2"},
+				},
+			},
+		},
+	}
+
+	testCases := []struct {
+		desc        string
+		upgradeNode string
+		want        []string
+		wantErr     error
+	}{
+		{
+			desc: "all the nodes in declaration order",
+			want: []string{"cp1", "worker1", "worker2"},
+		},
+		{
+			desc:        "only the requested node",
+			upgradeNode: "worker1",
+			want:        []string{"worker1"},
+		},
+		{
+			desc:        "unknown node",
+			upgradeNode: "worker3",
+			wantErr:     ErrNodeNotFound,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			i := &Infrastructure{furyctlConf: conf, upgradeNode: tC.upgradeNode}
+
+			got, err := i.nodesToUpgrade()
+			if tC.wantErr != nil {
+				require.ErrorIs(t, err, tC.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
+	}
+}
+
+func newRollingInfrastructure(t *testing.T, store upgrade.Storer) *Infrastructure {
+	t.Helper()
+
+	return &Infrastructure{
+		furyctlConf: public.ImmutableKfdV1Alpha2{
+			Spec: public.Spec{
+				Infrastructure: public.SpecInfrastructure{
+					Nodes: []public.SpecInfrastructureNode{
+						{Hostname: "cp1"},
+						{Hostname: "worker1"},
+					},
+				},
+			},
+		},
+		upgrade: &upgrade.Upgrade{Enabled: true},
+		ansibleRunner: ansible.NewRunner(
+			execx.NewFakeExecutor("TestHelperProcessPlaybookFailCp1"),
+			ansible.Paths{AnsiblePlaybook: "ansible-playbook", WorkDir: t.TempDir()},
+		),
+		upgradeStateStore: store,
+	}
+}
+
+func TestInfrastructureRollNodesResume(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeStateStore{}
+
+	// The playbook fails on cp1: a resumed run must not run it there again.
+	upgradeState := &upgrade.State{
+		Phases: upgrade.Phases{
+			Infrastructure: &upgrade.Phase{
+				Status: upgrade.PhaseStatusFailed,
+				Nodes:  map[string]upgrade.PhaseStatus{"cp1": upgrade.PhaseStatusSuccess},
+			},
+		},
+	}
+
+	require.NoError(t, newRollingInfrastructure(t, store).rollNodes(upgradeState))
+
+	stored := store.stored(t)
+
+	assert.Equal(t, upgrade.PhaseStatusSuccess, stored.Phases.Infrastructure.Status)
+	assert.Equal(t, map[string]upgrade.PhaseStatus{
+		"cp1":     upgrade.PhaseStatusSuccess,
+		"worker1": upgrade.PhaseStatusSuccess,
+	}, stored.Phases.Infrastructure.Nodes)
+}
+
+func TestInfrastructureRollNodesFailure(t *testing.T) {
+	t.Parallel()
+
+	store := &fakeStateStore{}
+
+	// The state of a full upgrade, that running only the infrastructure phase must keep.
+	require.NoError(t, store.Store(&upgrade.State{
+		Phases: upgrade.Phases{
+			Infrastructure: &upgrade.Phase{Status: upgrade.PhaseStatusPending},
+			Kubernetes:     &upgrade.Phase{Status: upgrade.PhaseStatusSuccess},
+			Distribution:   &upgrade.Phase{Status: upgrade.PhaseStatusPending},
+		},
+	}))
+
+	upgradeState := &upgrade.State{
+		Phases: upgrade.Phases{
+			PreInfrastructure:  &upgrade.Phase{Status: upgrade.PhaseStatusPending},
+			Infrastructure:     &upgrade.Phase{Status: upgrade.PhaseStatusPending},
+			PostInfrastructure: &upgrade.Phase{Status: upgrade.PhaseStatusPending},
+		},
+	}
+
+	err := newRollingInfrastructure(t, store).rollNodes(upgradeState)
+	require.ErrorContains(t, err, "error upgrading node cp1")
+
+	stored := store.stored(t)
+
+	assert.Equal(t, upgrade.PhaseStatusFailed, stored.Phases.Infrastructure.Status)
+	assert.Equal(t, map[string]upgrade.PhaseStatus{
+		"cp1":     upgrade.PhaseStatusFailed,
+		"worker1": upgrade.PhaseStatusPending,
+	}, stored.Phases.Infrastructure.Nodes)
+	assert.Equal(t, &upgrade.Phase{Status: upgrade.PhaseStatusSuccess}, stored.Phases.Kubernetes)
+	assert.Equal(t, &upgrade.Phase{Status: upgrade.PhaseStatusPending}, stored.Phases.Distribution)
+	assert.Equal(t, &upgrade.Phase{Status: upgrade.PhaseStatusPending}, stored.Phases.PreInfrastructure)
+}
+
+// TestHelperProcessPlaybookFailCp1 simulates an `ansible-playbook apply.yaml --limit <node>` invocation that
+// fails on the cp1 node when spawned as a subprocess; it is a no-op during a normal test run.
+func TestHelperProcessPlaybookFailCp1(t *testing.T) {
+	if len(os.Args) < 7 || os.Args[1] != "-test.run=TestHelperProcessPlaybookFailCp1" {
+		return
+	}
+
+	if os.Args[3] == "ansible-playbook" && os.Args[6] == "cp1" {
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
@@ -17,7 +17,10 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/public"
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/supported"
 	"github.com/sighupio/furyctl/internal/cluster"
+	parserx "github.com/sighupio/furyctl/internal/parser"
+	"github.com/sighupio/furyctl/internal/policy"
 	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	"github.com/sighupio/furyctl/internal/tool/ansible"
 	"github.com/sighupio/furyctl/internal/tool/kubectl"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
@@ -53,6 +56,7 @@ type PreFlight struct {
 	phase          string
 	startFrom      string
 	upgradeEnabled bool
+	policyRules    []policy.Rule
 }
 
 func NewPreFlight(
@@ -65,6 +69,7 @@ func NewPreFlight(
 	phase string,
 	startFrom string,
 	upgradeEnabled bool,
+	policyRules []policy.Rule,
 ) *PreFlight {
 	p := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhasePreFlight),
@@ -97,6 +102,7 @@ func NewPreFlight(
 		phase:          phase,
 		startFrom:      startFrom,
 		upgradeEnabled: upgradeEnabled,
+		policyRules:    policyRules,
 	}
 }
 
@@ -109,6 +115,10 @@ func (p *PreFlight) Exec(renderedConfig map[string]any) (*Status, error) {
 
 	logrus.Info("Running preflight checks...")
 
+	if err := policy.EnforceConfig(p.policyRules, string(p.furyctlConf.Kind), renderedConfig); err != nil {
+		return status, fmt.Errorf("error checking policies: %w", err)
+	}
+
 	if err := p.CreateRootFolder(); err != nil {
 		return status, fmt.Errorf("error creating preflight phase folder: %w", err)
 	}
@@ -164,6 +174,12 @@ func (p *PreFlight) Exec(renderedConfig map[string]any) (*Status, error) {
 		return status, fmt.Errorf("cluster is unreachable, make sure you have access to the cluster: %w", err)
 	}
 
+	// Only a missing state can go on with the migrations forced: a state that cannot be read, for example with
+	// the wrong encryption key, stops the apply before anything runs.
+	if _, err := p.stateStore.GetConfig(); err != nil && !errors.Is(err, backend.ErrNotFound) {
+		return status, fmt.Errorf("error while getting cluster state: %w", err)
+	}
+
 	diffChecker, err := p.CreateDiffChecker(renderedConfig)
 	if err != nil {
 		if !cluster.IsForceEnabledForFeature(p.force, cluster.ForceFeatureMigrations) {
@@ -201,6 +217,10 @@ func (p *PreFlight) Exec(renderedConfig map[string]any) (*Status, error) {
 				return status, fmt.Errorf("error checking reducer diffs: %w", err)
 			}
 
+			if err := policy.EnforceChanges(p.policyRules, string(p.furyctlConf.Kind), renderedConfig, d); err != nil {
+				return status, fmt.Errorf("error checking policies: %w", err)
+			}
+
 			if (p.phase != cluster.OperationPhaseAll || p.startFrom != cluster.OperationPhaseAll) && !p.upgradeEnabled {
 				logrus.Info("Cluster configuration has changed, checking if changes are supported in the phases to apply...")
 
@@ -232,6 +252,14 @@ func (p *PreFlight) CreateDiffChecker(renderedConfig map[string]any) (diffs.Chec
 			return nil, fmt.Errorf("error while unmarshalling rendered config file: %w", err)
 		}
 
+		cfg, err := yamlx.FromFileV3[map[string]any](p.paths.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading config file: %w", err)
+		}
+
+		// The stored configuration has the values of the secrets as they were at the last apply.
+		parserx.RegisterStoredSecrets(clusterCfg, cfg)
+
 		return diffs.NewBaseChecker(clusterCfg, renderedConfig), nil
 	}
 
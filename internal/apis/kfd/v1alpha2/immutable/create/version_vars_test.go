@@ -128,3 +128,24 @@ func TestVersionVarsForPhaseKubectlBin(t *testing.T) {
 	_, ok := noBin["kubectl_bin"]
 	assert.False(t, ok, "kubectl_bin must be omitted when the bin path is empty")
 }
+
+func TestImageReferences(t *testing.T) {
+	t.Parallel()
+
+	phaseDir := writeManifest(t)
+
+	got, err := ImageReferences(phaseDir, "1.34.8")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"registry.sighup.io/fury/on-premises/pause:3.10.1",
+		"registry.sighup.io/fury/on-premises/kube-apiserver:v1.34.8",
+		"registry.sighup.io/fury/on-premises/kube-controller-manager:v1.34.8",
+		"registry.sighup.io/fury/on-premises/kube-scheduler:v1.34.8",
+		"registry.sighup.io/fury/on-premises/kube-proxy:v1.34.8",
+		"registry.sighup.io/fury/on-premises/haproxy:3.0.6",
+	}, got)
+
+	_, err = ImageReferences(phaseDir, "9.99.99")
+	require.ErrorIs(t, err, ErrKubernetesVersionNotFound)
+}
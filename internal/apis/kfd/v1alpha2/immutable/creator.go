@@ -21,7 +21,12 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/supported"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/policy"
+	"github.com/sighupio/furyctl/internal/runreport"
 	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	"github.com/sighupio/furyctl/internal/upgrade"
 	"github.com/sighupio/furyctl/pkg/reducers"
 	premrules "github.com/sighupio/furyctl/pkg/rulesextractor"
@@ -54,6 +59,12 @@ type ClusterCreator struct {
 	externalUpgradesPath string
 	upgradeNode          string
 	postApplyPhases      []string
+	planReport           *plan.Report
+	savedPlan            *plan.Saved
+	encryptionKey        encryption.Key
+	stateBackend         backend.Config
+	strictTemplates      cluster.StrictTemplates
+	policyRules          []policy.Rule
 }
 
 func (c *ClusterCreator) SetProperties(props []cluster.CreatorProperty) {
@@ -64,16 +75,11 @@ func (c *ClusterCreator) SetProperties(props []cluster.CreatorProperty) {
 	c.stateStore = state.NewStore(
 		c.paths.DistroPath,
 		c.paths.ConfigPath,
-		c.paths.WorkDir,
-		c.kfdManifest.Tools.Common.Kubectl.Version,
-		c.paths.BinPath,
+		c.encryptionKey,
+		c.stateBackend,
 	)
 
-	c.upgradeStateStore = upgrade.NewStateStore(
-		c.paths.WorkDir,
-		c.kfdManifest.Tools.Common.Kubectl.Version,
-		c.paths.BinPath,
-	)
+	c.upgradeStateStore = upgrade.NewStateStore(c.encryptionKey, c.stateBackend)
 }
 
 func (c *ClusterCreator) SetProperty(name string, value any) {
@@ -106,6 +112,18 @@ func (c *ClusterCreator) SetProperty(name string, value any) {
 		cluster.SetPropertyValue(value, &c.upgradeNode)
 	case cluster.CreatorPropertyPostApplyPhases:
 		cluster.SetPropertyValue(value, &c.postApplyPhases)
+	case cluster.CreatorPropertyPlanReport:
+		cluster.SetPropertyValue(value, &c.planReport)
+	case cluster.CreatorPropertySavedPlan:
+		cluster.SetPropertyValue(value, &c.savedPlan)
+	case cluster.CreatorPropertyEncryptionKey:
+		cluster.SetPropertyValue(value, &c.encryptionKey)
+	case cluster.CreatorPropertyStateBackend:
+		cluster.SetPropertyValue(value, &c.stateBackend)
+	case cluster.CreatorPropertyStrictTemplates:
+		cluster.SetPropertyValue(value, &c.strictTemplates)
+	case cluster.CreatorPropertyPolicyRules:
+		cluster.SetPropertyValue(value, &c.policyRules)
 	default:
 		logrus.Debugf("ignoring unknown property %q", name)
 	}
@@ -162,8 +180,11 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			c.furyctlConf,
 			c.kfdManifest,
 			c.paths,
+			c.stateStore,
 			c.dryRun,
 			upgr,
+			c.planReport,
+			c.savedPlan,
 		),
 		c.dryRun,
 		upgr,
@@ -174,6 +195,7 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 		c.kfdManifest,
 		string(c.furyctlConf.Kind),
 		c.dryRun,
+		c.planReport,
 	)
 
 	preflight := create.NewPreFlight(
@@ -186,6 +208,16 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 		c.phase,
 		startFrom,
 		c.upgrade,
+		c.policyRules,
+	)
+
+	cluster.SetStrictTemplates(
+		c.strictTemplates,
+		preflight.Self(),
+		infrastructurePhase.Self(),
+		kubernetesPhase.Self(),
+		distributionPhase.Self(),
+		pluginsPhase.Self(),
 	)
 
 	renderedConfig, err := c.RenderConfig()
@@ -193,7 +225,19 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 		return fmt.Errorf("error while rendering config: %w", err)
 	}
 
-	status, err := preflight.Exec(renderedConfig)
+	status, err := runreport.TrackValue(cluster.OperationPhasePreFlight, func() (*create.Status, error) {
+		if err := preflight.Self().CheckTemplates(
+			c.paths.DistroPath,
+			c.paths.ConfigPath,
+			"kfd-v1alpha2",
+			"immutable",
+			c.templateSources(startFrom),
+		); err != nil {
+			return nil, fmt.Errorf("error while checking the templates of the phases: %w", err)
+		}
+
+		return preflight.Exec(renderedConfig)
+	})
 	if err != nil {
 		return fmt.Errorf("error while executing preflight phase: %w", err)
 	}
@@ -240,6 +284,18 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 	unsafeReducers := unsafeReducersInfrastructure
 	unsafeReducers = append(unsafeReducers, unsafeReducersDistribution...)
 
+	if err := c.savedPlan.VerifyState(c.stateStore); err != nil {
+		return fmt.Errorf("error while checking the saved plan: %w", err)
+	}
+
+	if err := c.planReport.RecordState(c.stateStore); err != nil {
+		return fmt.Errorf("error while recording the cluster state in the plan: %w", err)
+	}
+
+	c.planReport.AddConfigChanges(status.Diffs, rulesExtractor)
+	c.planReport.AddReducers(cluster.OperationPhaseInfrastructure, rdcsInfrastructure, unsafeReducersInfrastructure)
+	c.planReport.AddReducers(cluster.OperationPhaseDistribution, rdcsDistribution, unsafeReducersDistribution)
+
 	if len(rdcs) > 0 {
 		logrus.Infof("Differences found from previous cluster configuration, "+
 			"handling the following changes:\n%s", rdcs.ToString())
@@ -260,7 +316,7 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			c.skipNodesUpgrade,
 		)
 
-		if err := preupgradePhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePreUpgrade, preupgradePhase.Exec); err != nil {
 			return fmt.Errorf("error while executing preupgrade phase: %w", err)
 		}
 	}
@@ -284,7 +340,11 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			},
 		}
 
-		if err := infrastructurePhase.Exec(StartFromFlagNotSet, &upgradeState); err != nil {
+		c.resumeInfrastructureNodes(upgr, &upgradeState)
+
+		if err := runreport.Track(cluster.OperationPhaseInfrastructure, func() error {
+			return infrastructurePhase.Exec(StartFromFlagNotSet, &upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing infrastructure phase: %w", err)
 		}
 
@@ -297,7 +357,9 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			},
 		}
 
-		if err := kubernetesPhase.Exec(StartFromFlagNotSet, &upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, func() error {
+			return kubernetesPhase.Exec(StartFromFlagNotSet, &upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing kubernetes phase: %w", err)
 		}
 
@@ -319,7 +381,9 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			},
 		}
 
-		if err := distributionPhase.Exec(rdcsDistribution, StartFromFlagNotSet, &upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+			return distributionPhase.Exec(rdcsDistribution, StartFromFlagNotSet, &upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing distribution phase: %w", err)
 		}
 
@@ -328,7 +392,7 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			return fmt.Errorf("error while executing plugins phase: %w", distribution.ErrPluginsFeatureNotSupported)
 		}
 
-		if err := pluginsPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
 			return fmt.Errorf("error while executing plugins phase: %w", err)
 		}
 
@@ -430,6 +494,7 @@ func createInfrastructurePhase(c *ClusterCreator, upgr *upgrade.Upgrade) *create
 		c.paths,
 		c.dryRun,
 		c.force,
+		c.upgradeStateStore,
 	)
 
 	return infra
@@ -522,7 +587,9 @@ func (c *ClusterCreator) allPhases(
 			return ErrAbortedByUser
 		}
 
-		if err := infrastructurePhase.Exec(c.getInfrastructureSubPhase(startFrom), upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseInfrastructure, func() error {
+			return infrastructurePhase.Exec(c.getInfrastructureSubPhase(startFrom), upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing infrastructure phase: %w", err)
 		}
 	}
@@ -531,7 +598,9 @@ func (c *ClusterCreator) allPhases(
 		startFrom != cluster.OperationPhaseDistribution &&
 		startFrom != cluster.OperationSubPhasePostDistribution &&
 		startFrom != cluster.OperationPhasePlugins {
-		if err := kubernetesPhase.Exec(c.getKubernetesSubPhase(startFrom), upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, func() error {
+			return kubernetesPhase.Exec(c.getKubernetesSubPhase(startFrom), upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing kubernetes phase: %w", err)
 		}
 
@@ -550,13 +619,15 @@ func (c *ClusterCreator) allPhases(
 			return ErrAbortedByUser
 		}
 
-		if err := distributionPhase.Exec(rdcs, c.getDistributionSubPhase(startFrom), upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+			return distributionPhase.Exec(rdcs, c.getDistributionSubPhase(startFrom), upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing distribution phase: %w", err)
 		}
 	}
 
 	if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
-		if err := pluginsPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
 			return fmt.Errorf("error while executing plugins phase: %w", err)
 		}
 	}
@@ -596,20 +667,24 @@ func (c *ClusterCreator) extraPhases(
 		case cluster.OperationPhaseKubernetes:
 			kubernetesPhase.SetUpgrade(false)
 
-			if err := kubernetesPhase.Exec(StartFromFlagNotSet, upgradeState); err != nil {
+			if err := runreport.Track(cluster.OperationPhaseKubernetes, func() error {
+				return kubernetesPhase.Exec(StartFromFlagNotSet, upgradeState)
+			}); err != nil {
 				return fmt.Errorf("error while executing kubernetes phase: %w", err)
 			}
 
 		case cluster.OperationPhaseDistribution:
 			distributionPhase.SetUpgrade(false)
 
-			if err := distributionPhase.Exec(nil, StartFromFlagNotSet, upgradeState); err != nil {
+			if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+				return distributionPhase.Exec(nil, StartFromFlagNotSet, upgradeState)
+			}); err != nil {
 				return fmt.Errorf("error while executing distribution phase: %w", err)
 			}
 
 		case cluster.OperationPhasePlugins:
 			if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
-				if err := pluginsPhase.Exec(); err != nil {
+				if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
 					return fmt.Errorf("error while executing plugins phase: %w", err)
 				}
 			}
@@ -622,6 +697,35 @@ func (c *ClusterCreator) extraPhases(
 	return nil
 }
 
+// resumeInfrastructureNodes carries over the per-node progress of an interrupted infrastructure upgrade,
+// so that running only the infrastructure phase does not roll again the nodes already upgraded.
+func (c *ClusterCreator) resumeInfrastructureNodes(upgr *upgrade.Upgrade, upgradeState *upgrade.State) {
+	if !upgr.Enabled || c.dryRun {
+		return
+	}
+
+	s, err := c.upgradeStateStore.Get()
+	if err != nil {
+		logrus.Debugf("error while getting upgrade state: %v", err)
+
+		return
+	}
+
+	storedState := &upgrade.State{}
+
+	if err := yamlx.UnmarshalV3(s, storedState); err != nil {
+		logrus.Debugf("error while unmarshalling upgrade state: %v", err)
+
+		return
+	}
+
+	if storedState.Phases.Infrastructure != nil && len(storedState.Phases.Infrastructure.Nodes) > 0 {
+		logrus.Info("An infrastructure upgrade is already in progress, resuming from the nodes not upgraded yet.")
+
+		upgradeState.Phases.Infrastructure.Nodes = storedState.Phases.Infrastructure.Nodes
+	}
+}
+
 func (*ClusterCreator) initUpgradeState() *upgrade.State {
 	return &upgrade.State{
 		Phases: upgrade.Phases{
@@ -687,7 +791,7 @@ func (c *ClusterCreator) confirmInfrastructureChanges(
 
 		if askConfirmation {
 			confirm, err := cluster.AskConfirmationWithMessage(
-				cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations),
+				c.forceMigrations(),
 				"\nPotentially unsafe changes or that require manual intervention have been detected. Proceed with caution.",
 			)
 			if err != nil {
@@ -707,7 +811,7 @@ func (c *ClusterCreator) confirmDistributionChanges(
 ) (bool, error) {
 	if len(rdcs) > 0 && len(unsafeReducers) > 0 {
 		if strings.Contains(rdcs.ToString(), ".spec.distribution") {
-			confirm, err := cluster.AskConfirmation(cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations))
+			confirm, err := cluster.AskConfirmation(c.forceMigrations())
 			if err != nil {
 				return false, fmt.Errorf("error while asking for confirmation: %w", err)
 			}
@@ -718,3 +822,39 @@ func (c *ClusterCreator) confirmDistributionChanges(
 
 	return true, nil
 }
+
+// templateSources returns the templates of the phases that the apply runs from startFrom on, that the
+// preflight checks in strict mode before any of them runs.
+func (c *ClusterCreator) templateSources(startFrom string) []cluster.TemplateSource {
+	sources := []cluster.TemplateSource{
+		{
+			Phase: cluster.OperationPhaseInfrastructure,
+			Path:  path.Join(c.paths.DistroPath, "templates", cluster.OperationPhaseInfrastructure, "immutable"),
+		},
+		{
+			Phase: cluster.OperationPhaseKubernetes,
+			Path:  path.Join(c.paths.DistroPath, "templates", cluster.OperationPhaseKubernetes, "immutable"),
+		},
+		{
+			Phase: cluster.OperationPhaseDistribution,
+			Path:  path.Join(c.paths.DistroPath, "templates", cluster.OperationPhaseDistribution),
+		},
+	}
+
+	if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
+		sources = append(sources, cluster.TemplateSource{
+			Phase: cluster.OperationPhasePlugins,
+			Path:  path.Join(c.paths.DistroPath, "templates", cluster.OperationPhasePlugins),
+		})
+	}
+
+	return cluster.SelectTemplateSources(sources, c.phase, startFrom)
+}
+
+// forceMigrations tells whether the unsafe migrations run without a confirmation. A plan applies
+// nothing, and the migrations of a saved plan were reviewed with the plan, so they do not ask.
+func (c *ClusterCreator) forceMigrations() bool {
+	return c.planReport != nil ||
+		c.savedPlan != nil ||
+		cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations)
+}
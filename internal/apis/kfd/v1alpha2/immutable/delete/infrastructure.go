@@ -0,0 +1,94 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//nolint:predeclared // We want to use delete as package name.
+package delete
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/public"
+	"github.com/sighupio/furyctl/internal/cluster"
+)
+
+// Infrastructure removes the per-node files generated by the create infrastructure phase, so that a
+// node booting from the iPXE server after the deletion does not get reinstalled with the old config.
+// Downloaded Flatcar and sysext assets are kept, as they are not node specific.
+type Infrastructure struct {
+	*cluster.OperationPhase
+
+	furyctlConf public.ImmutableKfdV1Alpha2
+	kfdManifest config.KFD
+	paths       cluster.DeleterPaths
+	dryRun      bool
+}
+
+func NewInfrastructure(
+	furyctlConf public.ImmutableKfdV1Alpha2,
+	kfdManifest config.KFD,
+	paths cluster.DeleterPaths,
+	dryRun bool,
+) *Infrastructure {
+	phase := cluster.NewOperationPhase(
+		filepath.Join(paths.WorkDir, cluster.OperationPhaseInfrastructure),
+		kfdManifest.Tools,
+		paths.BinPath,
+	)
+
+	return &Infrastructure{
+		OperationPhase: phase,
+		furyctlConf:    furyctlConf,
+		kfdManifest:    kfdManifest,
+		paths:          paths,
+		dryRun:         dryRun,
+	}
+}
+
+func (i *Infrastructure) Exec() error {
+	logrus.Info("Deleting nodes boot configuration...")
+
+	for _, node := range i.furyctlConf.Spec.Infrastructure.Nodes {
+		for _, p := range i.NodeFiles(node) {
+			if i.dryRun {
+				logrus.Infof("Would remove %s", p)
+
+				continue
+			}
+
+			if err := os.RemoveAll(p); err != nil {
+				return fmt.Errorf("error removing %s for node %s: %w", p, node.Hostname, err)
+			}
+
+			logrus.Debugf("Removed %s", p)
+		}
+	}
+
+	if i.dryRun {
+		logrus.Info("Nodes boot configuration deleted successfully (dry-run mode)")
+
+		return nil
+	}
+
+	logrus.Info("Nodes boot configuration deleted successfully")
+
+	return nil
+}
+
+// NodeFiles returns the ignition, boot and butane files generated for the given node.
+func (i *Infrastructure) NodeFiles(node public.SpecInfrastructureNode) []string {
+	normalizedMAC := strings.ToUpper(strings.ReplaceAll(string(node.MacAddress), ":", "-"))
+
+	return []string{
+		filepath.Join(i.Path, "server", "ignition", normalizedMAC),
+		filepath.Join(i.Path, "server", "boot", normalizedMAC),
+		filepath.Join(i.Path, "butane", "install", node.Hostname+".bu"),
+		filepath.Join(i.Path, "butane", "node-config", node.Hostname+".bu"),
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package delete_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/apis/config"
+	del "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/delete"
+	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/public"
+	"github.com/sighupio/furyctl/internal/cluster"
+)
+
+func newConf() public.ImmutableKfdV1Alpha2 {
+	return public.ImmutableKfdV1Alpha2{
+		Spec: public.Spec{
+			Infrastructure: public.SpecInfrastructure{
+				Nodes: []public.SpecInfrastructureNode{
+					{Hostname: "node1", MacAddress: "52:54:00:aa:bb:01"},
+				},
+			},
+		},
+	}
+}
+
+// writeNodeFiles creates the files that the create infrastructure phase generates for each node.
+func writeNodeFiles(t *testing.T, infra *del.Infrastructure, node public.SpecInfrastructureNode) []string {
+	t.Helper()
+
+	files := infra.NodeFiles(node)
+
+	for _, f := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(f), os.ModePerm))
+		require.NoError(t, os.WriteFile(f, []byte("x"), 0o600))
+	}
+
+	return files
+}
+
+func TestInfrastructure_NodeFiles(t *testing.T) {
+	t.Parallel()
+
+	workDir := t.TempDir()
+	conf := newConf()
+
+	infra := del.NewInfrastructure(conf, config.KFD{}, cluster.DeleterPaths{WorkDir: workDir}, false)
+
+	assert.Equal(t, []string{
+		filepath.Join(workDir, "infrastructure", "server", "ignition", "52-54-00-AA-BB-01"),
+		filepath.Join(workDir, "infrastructure", "server", "boot", "52-54-00-AA-BB-01"),
+		filepath.Join(workDir, "infrastructure", "butane", "install", "node1.bu"),
+		filepath.Join(workDir, "infrastructure", "butane", "node-config", "node1.bu"),
+	}, infra.NodeFiles(conf.Spec.Infrastructure.Nodes[0]))
+}
+
+func TestInfrastructure_Exec(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc      string
+		dryRun    bool
+		wantExist bool
+	}{
+		{
+			desc:      "removes the node files",
+			dryRun:    false,
+			wantExist: false,
+		},
+		{
+			desc:      "keeps the node files in dry-run mode",
+			dryRun:    true,
+			wantExist: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			workDir := t.TempDir()
+			conf := newConf()
+
+			infra := del.NewInfrastructure(conf, config.KFD{}, cluster.DeleterPaths{WorkDir: workDir}, tC.dryRun)
+
+			files := writeNodeFiles(t, infra, conf.Spec.Infrastructure.Nodes[0])
+
+			assetsPath := filepath.Join(workDir, "infrastructure", "server", "assets", "flatcar")
+			require.NoError(t, os.MkdirAll(assetsPath, os.ModePerm))
+
+			require.NoError(t, infra.Exec())
+
+			for _, f := range files {
+				_, err := os.Stat(f)
+				assert.Equal(t, tC.wantExist, err == nil, f)
+			}
+
+			assert.DirExists(t, assetsPath)
+		})
+	}
+}
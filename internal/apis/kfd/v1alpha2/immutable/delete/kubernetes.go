@@ -0,0 +1,131 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//nolint:predeclared // We want to use delete as package name.
+package delete
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/create"
+	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/public"
+	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/tool/ansible"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	templatex "github.com/sighupio/furyctl/pkg/template"
+)
+
+type Kubernetes struct {
+	*cluster.OperationPhase
+
+	furyctlConf   public.ImmutableKfdV1Alpha2
+	kfdManifest   config.KFD
+	paths         cluster.DeleterPaths
+	dryRun        bool
+	ansibleRunner *ansible.Runner
+}
+
+func NewKubernetes(
+	furyctlConf public.ImmutableKfdV1Alpha2,
+	kfdManifest config.KFD,
+	paths cluster.DeleterPaths,
+	dryRun bool,
+) *Kubernetes {
+	phase := cluster.NewOperationPhase(
+		path.Join(paths.WorkDir, cluster.OperationPhaseKubernetes),
+		kfdManifest.Tools,
+		paths.BinPath,
+	)
+
+	return &Kubernetes{
+		OperationPhase: phase,
+		furyctlConf:    furyctlConf,
+		kfdManifest:    kfdManifest,
+		paths:          paths,
+		dryRun:         dryRun,
+		ansibleRunner: ansible.NewRunner(
+			execx.NewStdExecutor(),
+			ansible.PathsForVersion(paths.BinPath, kfdManifest.Tools.Immutable.Ansible.Version, phase.Path),
+		),
+	}
+}
+
+func (k *Kubernetes) Exec() error {
+	logrus.Info("Deleting SIGHUP Distribution cluster...")
+
+	if err := k.CreateRootFolder(); err != nil {
+		return fmt.Errorf("error creating kubernetes phase folder: %w", err)
+	}
+
+	furyctlMerger, err := k.CreateFuryctlMerger(
+		k.paths.DistroPath,
+		k.paths.ConfigPath,
+		"kfd-v1alpha2",
+		"immutable",
+	)
+	if err != nil {
+		return fmt.Errorf("error creating furyctl merger: %w", err)
+	}
+
+	mCfg, err := templatex.NewConfigWithoutData(furyctlMerger, []string{})
+	if err != nil {
+		return fmt.Errorf("error creating template config: %w", err)
+	}
+
+	k.CopyPathsToConfig(&mCfg)
+
+	version := k.kfdManifest.Kubernetes.Immutable.Version
+
+	mCfg.Data["kubernetes"] = map[any]any{
+		"version": version,
+	}
+
+	mCfg.Data["options"]["skipPodsRunningCheck"] = true
+
+	// The immutable inventory renders the version pins inline, the delete playbook shares the same hosts.yaml.
+	versionVars, err := create.VersionVarsForPhase(k.Path, version, k.KubectlPath)
+	if err != nil {
+		return fmt.Errorf("error building version vars: %w", err)
+	}
+
+	mCfg.Data["versions"] = versionVars
+
+	if err := k.CopyFromTemplate(
+		mCfg,
+		"kubernetes",
+		path.Join(k.paths.DistroPath, "templates", cluster.OperationPhaseKubernetes, "immutable"),
+		k.Path,
+		k.paths.ConfigPath,
+	); err != nil {
+		return fmt.Errorf("error copying from template: %w", err)
+	}
+
+	if k.dryRun {
+		logrus.Info("Kubernetes cluster deleted successfully (dry-run mode)")
+
+		return nil
+	}
+
+	// Check hosts connection.
+	logrus.Info("Checking that the hosts are reachable...")
+
+	if _, err := k.ansibleRunner.Exec("all", "-m", "ping"); err != nil {
+		return fmt.Errorf("error checking hosts: %w", err)
+	}
+
+	logrus.Info("Resetting kubeadm and etcd on the nodes...")
+
+	// Apply delete playbook.
+	if _, err := k.ansibleRunner.Playbook("delete-playbook.yaml"); err != nil {
+		return fmt.Errorf("error applying playbook: %w", err)
+	}
+
+	logrus.Info("Kubernetes cluster deleted successfully")
+
+	return nil
+}
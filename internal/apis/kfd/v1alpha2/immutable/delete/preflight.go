@@ -0,0 +1,141 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//nolint:predeclared // We want to use delete as package name.
+package delete
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/public"
+	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/tool/ansible"
+	"github.com/sighupio/furyctl/internal/tool/kubectl"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	kubex "github.com/sighupio/furyctl/internal/x/kube"
+	templatex "github.com/sighupio/furyctl/pkg/template"
+)
+
+type Status struct {
+	ClusterExists bool
+}
+
+type PreFlight struct {
+	*cluster.OperationPhase
+
+	furyctlConf   public.ImmutableKfdV1Alpha2
+	paths         cluster.DeleterPaths
+	kubeRunner    *kubectl.Runner
+	ansibleRunner *ansible.Runner
+	kfdManifest   config.KFD
+	dryRun        bool
+}
+
+func NewPreFlight(
+	furyctlConf public.ImmutableKfdV1Alpha2,
+	kfdManifest config.KFD,
+	paths cluster.DeleterPaths,
+	dryRun bool,
+) *PreFlight {
+	phase := cluster.NewOperationPhase(
+		path.Join(paths.WorkDir, cluster.OperationPhasePreFlight),
+		kfdManifest.Tools,
+		paths.BinPath,
+	)
+
+	return &PreFlight{
+		OperationPhase: phase,
+		furyctlConf:    furyctlConf,
+		paths:          paths,
+		ansibleRunner: ansible.NewRunner(
+			execx.NewStdExecutor(),
+			ansible.PathsForVersion(paths.BinPath, kfdManifest.Tools.Immutable.Ansible.Version, phase.Path),
+		),
+		kubeRunner: kubectl.NewRunner(
+			execx.NewStdExecutor(),
+			kubectl.Paths{
+				Kubectl: phase.KubectlPath,
+				WorkDir: phase.Path,
+			},
+			true,
+			true,
+			false,
+		),
+		kfdManifest: kfdManifest,
+		dryRun:      dryRun,
+	}
+}
+
+// Exec checks whether the Kubernetes cluster still exists on the nodes. A missing cluster is not an
+// error: the nodes may have already been reset by a previous, partially failed, deletion.
+func (p *PreFlight) Exec() (*Status, error) {
+	status := &Status{
+		ClusterExists: false,
+	}
+
+	logrus.Info("Running preflight checks...")
+
+	if err := p.CreateRootFolder(); err != nil {
+		return status, fmt.Errorf("error creating preflight phase folder: %w", err)
+	}
+
+	furyctlMerger, err := p.CreateFuryctlMerger(
+		p.paths.DistroPath,
+		p.paths.ConfigPath,
+		"kfd-v1alpha2",
+		"immutable",
+	)
+	if err != nil {
+		return status, fmt.Errorf("error creating furyctl merger: %w", err)
+	}
+
+	mCfg, err := templatex.NewConfigWithoutData(furyctlMerger, []string{})
+	if err != nil {
+		return status, fmt.Errorf("error creating template config: %w", err)
+	}
+
+	p.CopyPathsToConfig(&mCfg)
+
+	mCfg.Data["kubernetes"] = map[any]any{
+		"version": p.kfdManifest.Kubernetes.Immutable.Version,
+	}
+
+	if err := p.CopyFromTemplate(
+		mCfg,
+		"preflight",
+		path.Join(p.paths.DistroPath, "templates", cluster.OperationPhasePreFlight, "immutable"),
+		p.Path,
+		p.paths.ConfigPath,
+	); err != nil {
+		return status, fmt.Errorf("error copying from template: %w", err)
+	}
+
+	if _, err := p.ansibleRunner.Playbook("verify-playbook.yaml"); err != nil {
+		logrus.Debug("Cluster does not exist, skipping cluster checks")
+
+		logrus.Info("Preflight checks completed successfully")
+
+		return status, nil //nolint:nilerr // we want to return nil here
+	}
+
+	status.ClusterExists = true
+
+	if err := kubex.SetConfigEnv(path.Join(p.Path, "admin.conf")); err != nil {
+		return status, fmt.Errorf("error setting kubeconfig env: %w", err)
+	}
+
+	logrus.Info("Checking that the cluster is reachable...")
+
+	if _, err := p.kubeRunner.Version(); err != nil {
+		return status, fmt.Errorf("cluster is unreachable, make sure you have access to the cluster: %w", err)
+	}
+
+	logrus.Info("Preflight checks completed successfully")
+
+	return status, nil
+}
@@ -5,28 +5,46 @@
 package immutable
 
 import (
-	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/sighupio/furyctl/internal/apis/config"
+	commdel "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/common/delete"
+	del "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/delete"
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/immutable/public"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/runreport"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 )
 
-var ErrClusterDeletionNotImplemented = errors.New("cluster deletion not implemented for Immutable kind")
-
 type ClusterDeleter struct {
-	paths       cluster.DeleterPaths
-	furyctlConf public.ImmutableKfdV1Alpha2
-	kfdManifest config.KFD
+	paths           cluster.DeleterPaths
+	furyctlConf     public.ImmutableKfdV1Alpha2
+	kfdManifest     config.KFD
+	phase           string
+	dryRun          bool
+	stateStore      state.Storer
+	encryptionKey   encryption.Key
+	stateBackend    backend.Config
+	strictTemplates cluster.StrictTemplates
 }
 
 func (c *ClusterDeleter) SetProperties(props []cluster.DeleterProperty) {
 	for _, prop := range props {
 		c.SetProperty(prop.Name, prop.Value)
 	}
+
+	c.stateStore = state.NewStore(
+		c.paths.DistroPath,
+		c.paths.ConfigPath,
+		c.encryptionKey,
+		c.stateBackend,
+	)
 }
 
 func (c *ClusterDeleter) SetProperty(name string, value any) {
@@ -43,11 +61,121 @@ func (c *ClusterDeleter) SetProperty(name string, value any) {
 		cluster.SetPropertyValue(value, &c.furyctlConf)
 	case cluster.DeleterPropertyKfdManifest:
 		cluster.SetPropertyValue(value, &c.kfdManifest)
+	case cluster.DeleterPropertyPhase:
+		cluster.SetPropertyValue(value, &c.phase)
+	case cluster.DeleterPropertyDryRun:
+		cluster.SetPropertyValue(value, &c.dryRun)
+	case cluster.DeleterPropertyEncryptionKey:
+		cluster.SetPropertyValue(value, &c.encryptionKey)
+	case cluster.DeleterPropertyStateBackend:
+		cluster.SetPropertyValue(value, &c.stateBackend)
+	case cluster.DeleterPropertyStrictTemplates:
+		cluster.SetPropertyValue(value, &c.strictTemplates)
 	default:
 		logrus.Debugf("ignoring unknown property %q", name)
 	}
 }
 
-func (*ClusterDeleter) Delete() error {
-	return ErrClusterDeletionNotImplemented
+func (c *ClusterDeleter) Delete() error {
+	logrus.Warn("This process will reset the Kubernetes cluster on the nodes and remove their boot configuration, " +
+		"the nodes will need to be reinstalled to be used again.")
+
+	infrastructurePhase := del.NewInfrastructure(
+		c.furyctlConf,
+		c.kfdManifest,
+		c.paths,
+		c.dryRun,
+	)
+
+	kubernetesPhase := del.NewKubernetes(
+		c.furyctlConf,
+		c.kfdManifest,
+		c.paths,
+		c.dryRun,
+	)
+
+	distributionPhase := commdel.NewDistribution(
+		c.paths,
+		c.stateStore,
+		c.kfdManifest,
+		string(c.furyctlConf.Kind),
+		c.dryRun,
+	)
+
+	pluginsPhase := commdel.NewPlugins(
+		c.paths,
+		c.kfdManifest,
+		string(c.furyctlConf.Kind),
+		c.dryRun,
+	)
+
+	preflight := del.NewPreFlight(c.furyctlConf, c.kfdManifest, c.paths, c.dryRun)
+
+	cluster.SetStrictTemplates(
+		c.strictTemplates,
+		preflight.Self(),
+		infrastructurePhase.Self(),
+		kubernetesPhase.Self(),
+		distributionPhase.Self(),
+		pluginsPhase.Self(),
+	)
+
+	status, err := runreport.TrackValue(cluster.OperationPhasePreFlight, preflight.Exec)
+	if err != nil {
+		return fmt.Errorf("error while executing preflight phase: %w", err)
+	}
+
+	switch c.phase {
+	case cluster.OperationPhaseInfrastructure:
+		if err := runreport.Track(cluster.OperationPhaseInfrastructure, infrastructurePhase.Exec); err != nil {
+			return fmt.Errorf("error while deleting infrastructure phase: %w", err)
+		}
+
+	case cluster.OperationPhaseKubernetes:
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, kubernetesPhase.Exec); err != nil {
+			return fmt.Errorf("error while deleting kubernetes phase: %w", err)
+		}
+
+	case cluster.OperationPhaseDistribution:
+		if err := runreport.Track(cluster.OperationPhaseDistribution, distributionPhase.Exec); err != nil {
+			return fmt.Errorf("error while deleting distribution phase: %w", err)
+		}
+
+	case cluster.OperationPhasePlugins:
+		if !distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
+			return fmt.Errorf("error while deleting plugins phase: %w", distribution.ErrPluginsFeatureNotSupported)
+		}
+
+		if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
+			return fmt.Errorf("error while deleting plugins phase: %w", err)
+		}
+
+	case cluster.OperationPhaseAll:
+		if status.ClusterExists {
+			if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
+				if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
+					return fmt.Errorf("error while deleting plugins phase: %w", err)
+				}
+			}
+
+			if err := runreport.Track(cluster.OperationPhaseDistribution, distributionPhase.Exec); err != nil {
+				return fmt.Errorf("error while deleting distribution phase: %w", err)
+			}
+
+			if err := runreport.Track(cluster.OperationPhaseKubernetes, kubernetesPhase.Exec); err != nil {
+				return fmt.Errorf("error while deleting kubernetes phase: %w", err)
+			}
+		} else {
+			logrus.Info("Kubernetes cluster not found on the nodes, skipping plugins, distribution and kubernetes phases")
+		}
+
+		if err := runreport.Track(cluster.OperationPhaseInfrastructure, infrastructurePhase.Exec); err != nil {
+			return fmt.Errorf("error while deleting infrastructure phase: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedPhase, c.phase)
+	}
+
+	return nil
 }
@@ -16,6 +16,9 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/config"
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/kfddistribution/public"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/kubernetes"
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/runreport"
 	"github.com/sighupio/furyctl/internal/state"
 	"github.com/sighupio/furyctl/internal/tool/kubectl"
 	"github.com/sighupio/furyctl/internal/tool/shell"
@@ -44,14 +47,19 @@ type Distribution struct {
 	shellRunner *shell.Runner
 	upgrade     *upgrade.Upgrade
 	paths       cluster.CreatorPaths
+	planReport  *plan.Report
+	savedPlan   *plan.Saved
 }
 
 func NewDistribution(
 	paths cluster.CreatorPaths,
+	stateStore state.Storer,
 	furyctlConf public.KfddistributionKfdV1Alpha2,
 	kfdManifest config.KFD,
 	dryRun bool,
 	upgr *upgrade.Upgrade,
+	planReport *plan.Report,
+	savedPlan *plan.Saved,
 ) *Distribution {
 	phaseOp := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhaseDistribution),
@@ -62,13 +70,7 @@ func NewDistribution(
 	return &Distribution{
 		OperationPhase: phaseOp,
 		furyctlConf:    furyctlConf,
-		stateStore: state.NewStore(
-			paths.DistroPath,
-			paths.ConfigPath,
-			paths.WorkDir,
-			kfdManifest.Tools.Common.Kubectl.Version,
-			paths.BinPath,
-		),
+		stateStore:     stateStore,
 		kubeRunner: kubectl.NewRunner(
 			execx.NewStdExecutor(),
 			kubectl.Paths{
@@ -86,9 +88,11 @@ func NewDistribution(
 				WorkDir: path.Join(phaseOp.Path, "manifests"),
 			},
 		),
-		dryRun:  dryRun,
-		upgrade: upgr,
-		paths:   paths,
+		dryRun:     dryRun,
+		upgrade:    upgr,
+		paths:      paths,
+		planReport: planReport,
+		savedPlan:  savedPlan,
 	}
 }
 
@@ -114,8 +118,25 @@ func (d *Distribution) Exec(rdcs reducers.Reducers, startFrom string, upgradeSta
 		return fmt.Errorf("error preparing distribution phase: %w", err)
 	}
 
+	if err := d.savedPlan.VerifyManifests(
+		cluster.OperationPhaseDistribution,
+		d.KustomizePath,
+		path.Join(d.Path, "manifests"),
+	); err != nil {
+		return fmt.Errorf("error while checking the saved plan: %w", err)
+	}
+
 	// Stop if dry run is enabled.
 	if d.dryRun {
+		if err := d.planReport.AddManifests(
+			cluster.OperationPhaseDistribution,
+			d.KustomizePath,
+			d.KubectlPath,
+			path.Join(d.Path, "manifests"),
+		); err != nil {
+			return fmt.Errorf("error while planning distribution manifests: %w", err)
+		}
+
 		logrus.Info("SIGHUP Distribution installed successfully (dry-run mode)")
 
 		return nil
@@ -202,7 +223,12 @@ func (d *Distribution) prepare() (templatex.Config, error) {
 
 	logrus.Info("Checking that the cluster is reachable...")
 
-	if _, err := d.kubeRunner.Version(); err != nil {
+	kubeClient, err := kubernetes.NewClient("")
+	if err != nil {
+		return templatex.Config{}, fmt.Errorf("error connecting to cluster: %w", err)
+	}
+
+	if _, err := kubeClient.ServerVersion(); err != nil {
 		logrus.Debugf("Got error while running cluster reachability check: %s", err)
 
 		return templatex.Config{}, fmt.Errorf("error connecting to cluster: %w", err)
@@ -210,12 +236,12 @@ func (d *Distribution) prepare() (templatex.Config, error) {
 
 	logrus.Info("Checking for a default storage class...")
 
-	getStorageClassesOutput, err := d.kubeRunner.Get(false, "", "storageclasses")
+	storageClasses, err := kubeClient.StorageClasses()
 	if err != nil {
 		return templatex.Config{}, fmt.Errorf("error while checking storage class: %w", err)
 	}
 
-	if getStorageClassesOutput == "No resources found" {
+	if !storageClasses.Available {
 		logrus.Warn(
 			"No storage classes found in the cluster. " +
 				"logging module (if enabled), tracing module (if enabled), dr module (if enabled) " +
@@ -224,10 +250,7 @@ func (d *Distribution) prepare() (templatex.Config, error) {
 		)
 
 		storageClassAvailable = false
-	}
-
-	defaultSC := "(default)"
-	if !strings.Contains(getStorageClassesOutput, defaultSC) && getStorageClassesOutput != "No resources found" {
+	} else if storageClasses.Default == "" {
 		logrus.Warn(
 			"No *default* storage classes found in the cluster. " +
 				"logging module (if enabled), tracing module (if enabled), dr module (if enabled) " +
@@ -264,19 +287,13 @@ func (d *Distribution) prepare() (templatex.Config, error) {
 	if d.furyctlConf.Spec.Distribution.Modules.Networking.Type == "none" {
 		logrus.Info("Checking if all nodes are ready...")
 
-		getNotReadyNodesOutput, err := d.kubeRunner.Get(
-			false,
-			"",
-			"nodes",
-			"--output",
-			"jsonpath=\"{range .items[*]}{.spec.taints[?(@.key==\"node.kubernetes.io/not-ready\")]}{end}\"",
-		)
+		notReadyNodes, err := kubeClient.NotReadyNodes()
 		if err != nil {
 			return templatex.Config{}, fmt.Errorf("error while checking nodes: %w", err)
 		}
 
-		if getNotReadyNodesOutput != "\"\"" {
-			return templatex.Config{}, errNodesNotReady
+		if len(notReadyNodes) > 0 {
+			return templatex.Config{}, fmt.Errorf("%w: %s", errNodesNotReady, strings.Join(notReadyNodes, ", "))
 		}
 	}
 
@@ -389,6 +406,8 @@ func (d *Distribution) runReducers(
 		); err != nil {
 			return fmt.Errorf("error applying manifests: %w", err)
 		}
+
+		runreport.AddReducers(r)
 	}
 
 	return nil
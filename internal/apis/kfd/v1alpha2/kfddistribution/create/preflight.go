@@ -19,7 +19,9 @@ import (
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/distribution"
 	parserx "github.com/sighupio/furyctl/internal/parser"
+	"github.com/sighupio/furyctl/internal/policy"
 	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	"github.com/sighupio/furyctl/internal/tool/kubectl"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
 	kubex "github.com/sighupio/furyctl/internal/x/kube"
@@ -54,6 +56,7 @@ type PreFlight struct {
 	phase           string
 	startFrom       string
 	upgradeEnabled  bool
+	policyRules     []policy.Rule
 }
 
 func NewPreFlight(
@@ -66,6 +69,7 @@ func NewPreFlight(
 	phase string,
 	startFrom string,
 	upgradeEnabled bool,
+	policyRules []policy.Rule,
 ) *PreFlight {
 	p := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhasePreFlight),
@@ -96,6 +100,7 @@ func NewPreFlight(
 		phase:          phase,
 		startFrom:      startFrom,
 		upgradeEnabled: upgradeEnabled,
+		policyRules:    policyRules,
 	}
 }
 
@@ -111,6 +116,10 @@ func (p *PreFlight) Exec(renderedConfig map[string]any) (*Status, error) {
 
 	logrus.Info("Running preflight checks...")
 
+	if err := policy.EnforceConfig(p.policyRules, string(p.furyctlConf.Kind), renderedConfig); err != nil {
+		return status, fmt.Errorf("error checking policies: %w", err)
+	}
+
 	if err := p.CreateRootFolder(); err != nil {
 		return status, fmt.Errorf("error creating preflight phase folder: %w", err)
 	}
@@ -140,6 +149,12 @@ func (p *PreFlight) Exec(renderedConfig map[string]any) (*Status, error) {
 
 	storedCfg, err := p.stateStore.GetConfig()
 	if err != nil {
+		// Only a missing state means that there is nothing to check: a state that cannot be read, for example
+		// with the wrong encryption key, stops the apply before anything runs.
+		if !errors.Is(err, backend.ErrNotFound) {
+			return status, fmt.Errorf("error while getting cluster state: %w", err)
+		}
+
 		logrus.Debug("error while getting cluster state: ", err)
 
 		logrus.Info("Cannot find state in cluster, skipping...")
@@ -186,6 +201,10 @@ func (p *PreFlight) Exec(renderedConfig map[string]any) (*Status, error) {
 				return status, fmt.Errorf("error checking reducer diffs: %w", err)
 			}
 
+			if err := policy.EnforceChanges(p.policyRules, string(p.furyctlConf.Kind), renderedConfig, d); err != nil {
+				return status, fmt.Errorf("error checking policies: %w", err)
+			}
+
 			if (p.phase != cluster.OperationPhaseAll || p.startFrom != cluster.OperationPhaseAll) && !p.upgradeEnabled {
 				logrus.Info("Cluster configuration has changed, checking if changes are supported in the phases to apply...")
 
@@ -212,6 +231,14 @@ func (p *PreFlight) CreateDiffChecker(storedCfgStr []byte, renderedConfig map[st
 			return nil, fmt.Errorf("error while unmarshalling rendered config file: %w", err)
 		}
 
+		cfg, err := yamlx.FromFileV3[map[string]any](p.paths.ConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading config file: %w", err)
+		}
+
+		// The stored configuration has the values of the secrets as they were at the last apply.
+		parserx.RegisterStoredSecrets(clusterCfg, cfg)
+
 		return diffs.NewBaseChecker(clusterCfg, renderedConfig), nil
 	}
 
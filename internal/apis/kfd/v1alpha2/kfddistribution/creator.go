@@ -19,7 +19,12 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/kfddistribution/supported"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/policy"
+	"github.com/sighupio/furyctl/internal/runreport"
 	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	"github.com/sighupio/furyctl/internal/upgrade"
 	"github.com/sighupio/furyctl/pkg/reducers"
 	distrorules "github.com/sighupio/furyctl/pkg/rulesextractor"
@@ -51,6 +56,12 @@ type ClusterCreator struct {
 	upgrade              bool
 	externalUpgradesPath string
 	postApplyPhases      []string
+	planReport           *plan.Report
+	savedPlan            *plan.Saved
+	encryptionKey        encryption.Key
+	stateBackend         backend.Config
+	strictTemplates      cluster.StrictTemplates
+	policyRules          []policy.Rule
 }
 
 func (c *ClusterCreator) SetProperties(props []cluster.CreatorProperty) {
@@ -61,16 +72,11 @@ func (c *ClusterCreator) SetProperties(props []cluster.CreatorProperty) {
 	c.stateStore = state.NewStore(
 		c.paths.DistroPath,
 		c.paths.ConfigPath,
-		c.paths.WorkDir,
-		c.kfdManifest.Tools.Common.Kubectl.Version,
-		c.paths.BinPath,
+		c.encryptionKey,
+		c.stateBackend,
 	)
 
-	c.upgradeStateStore = upgrade.NewStateStore(
-		c.paths.WorkDir,
-		c.kfdManifest.Tools.Common.Kubectl.Version,
-		c.paths.BinPath,
-	)
+	c.upgradeStateStore = upgrade.NewStateStore(c.encryptionKey, c.stateBackend)
 }
 
 func (c *ClusterCreator) SetProperty(name string, value any) {
@@ -99,6 +105,18 @@ func (c *ClusterCreator) SetProperty(name string, value any) {
 		cluster.SetPropertyValue(value, &c.externalUpgradesPath)
 	case cluster.CreatorPropertyPostApplyPhases:
 		cluster.SetPropertyValue(value, &c.postApplyPhases)
+	case cluster.CreatorPropertyPlanReport:
+		cluster.SetPropertyValue(value, &c.planReport)
+	case cluster.CreatorPropertySavedPlan:
+		cluster.SetPropertyValue(value, &c.savedPlan)
+	case cluster.CreatorPropertyEncryptionKey:
+		cluster.SetPropertyValue(value, &c.encryptionKey)
+	case cluster.CreatorPropertyStateBackend:
+		cluster.SetPropertyValue(value, &c.stateBackend)
+	case cluster.CreatorPropertyStrictTemplates:
+		cluster.SetPropertyValue(value, &c.strictTemplates)
+	case cluster.CreatorPropertyPolicyRules:
+		cluster.SetPropertyValue(value, &c.policyRules)
 	default:
 		logrus.Debugf("ignoring unknown property %q", name)
 	}
@@ -126,10 +144,13 @@ func (c *ClusterCreator) Create(startFrom string, _, _ int) error {
 		c.upgradeStateStore,
 		create.NewDistribution(
 			c.paths,
+			c.stateStore,
 			c.furyctlConf,
 			c.kfdManifest,
 			c.dryRun,
 			upgr,
+			c.planReport,
+			c.savedPlan,
 		),
 		c.dryRun,
 		upgr,
@@ -140,6 +161,7 @@ func (c *ClusterCreator) Create(startFrom string, _, _ int) error {
 		c.kfdManifest,
 		string(c.furyctlConf.Kind),
 		c.dryRun,
+		c.planReport,
 	)
 
 	preflight := create.NewPreFlight(
@@ -152,6 +174,14 @@ func (c *ClusterCreator) Create(startFrom string, _, _ int) error {
 		c.phase,
 		startFrom,
 		c.upgrade,
+		c.policyRules,
+	)
+
+	cluster.SetStrictTemplates(
+		c.strictTemplates,
+		preflight.Self(),
+		distributionPhase.Self(),
+		pluginsPhase.Self(),
 	)
 
 	renderedConfig, err := c.RenderConfig()
@@ -159,7 +189,19 @@ func (c *ClusterCreator) Create(startFrom string, _, _ int) error {
 		return fmt.Errorf("error while rendering config: %w", err)
 	}
 
-	status, err := preflight.Exec(renderedConfig)
+	status, err := runreport.TrackValue(cluster.OperationPhasePreFlight, func() (*create.Status, error) {
+		if err := preflight.Self().CheckTemplates(
+			c.paths.DistroPath,
+			c.paths.ConfigPath,
+			"kfd-v1alpha2",
+			"kfddistribution",
+			c.templateSources(startFrom),
+		); err != nil {
+			return nil, fmt.Errorf("error while checking the templates of the phases: %w", err)
+		}
+
+		return preflight.Exec(renderedConfig)
+	})
 	if err != nil {
 		return fmt.Errorf("error while executing preflight phase: %w", err)
 	}
@@ -184,6 +226,17 @@ func (c *ClusterCreator) Create(startFrom string, _, _ int) error {
 		status.Diffs,
 	)
 
+	if err := c.savedPlan.VerifyState(c.stateStore); err != nil {
+		return fmt.Errorf("error while checking the saved plan: %w", err)
+	}
+
+	if err := c.planReport.RecordState(c.stateStore); err != nil {
+		return fmt.Errorf("error while recording the cluster state in the plan: %w", err)
+	}
+
+	c.planReport.AddConfigChanges(status.Diffs, r)
+	c.planReport.AddReducers(cluster.OperationPhaseDistribution, rdcs, unsafeReducers)
+
 	if len(rdcs) > 0 {
 		logrus.Infof("Differences found from previous cluster configuration, "+
 			"handling the following changes:\n%s", rdcs.ToString())
@@ -204,7 +257,7 @@ func (c *ClusterCreator) Create(startFrom string, _, _ int) error {
 			false,
 		)
 
-		if err := preupgradePhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePreUpgrade, preupgradePhase.Exec); err != nil {
 			return fmt.Errorf("error while executing preupgrade phase: %w", err)
 		}
 	}
@@ -212,7 +265,7 @@ func (c *ClusterCreator) Create(startFrom string, _, _ int) error {
 	switch c.phase {
 	case cluster.OperationPhaseDistribution:
 		if len(rdcs) > 0 && len(unsafeReducers) > 0 {
-			confirm, err := cluster.AskConfirmation(cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations))
+			confirm, err := cluster.AskConfirmation(c.forceMigrations())
 			if err != nil {
 				return fmt.Errorf("error while asking for confirmation: %w", err)
 			}
@@ -230,7 +283,9 @@ func (c *ClusterCreator) Create(startFrom string, _, _ int) error {
 			},
 		}
 
-		if err := distributionPhase.Exec(rdcs, StartFromFlagNotSet, &upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+			return distributionPhase.Exec(rdcs, StartFromFlagNotSet, &upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing distribution phase: %w", err)
 		}
 
@@ -239,7 +294,7 @@ func (c *ClusterCreator) Create(startFrom string, _, _ int) error {
 			return fmt.Errorf("error while executing plugins phase: %w", distribution.ErrPluginsFeatureNotSupported)
 		}
 
-		if err := pluginsPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
 			return fmt.Errorf("error while executing plugins phase: %w", err)
 		}
 
@@ -351,7 +406,7 @@ func (c *ClusterCreator) allPhases(
 
 	if startFrom != cluster.OperationPhasePlugins {
 		if len(rdcs) > 0 && len(unsafeReducers) > 0 {
-			confirm, err := cluster.AskConfirmation(cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations))
+			confirm, err := cluster.AskConfirmation(c.forceMigrations())
 			if err != nil {
 				return fmt.Errorf("error while asking for confirmation: %w", err)
 			}
@@ -361,13 +416,15 @@ func (c *ClusterCreator) allPhases(
 			}
 		}
 
-		if err := distributionPhase.Exec(rdcs, c.getDistributionSubPhase(startFrom), upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+			return distributionPhase.Exec(rdcs, c.getDistributionSubPhase(startFrom), upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing distribution phase: %w", err)
 		}
 	}
 
 	if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
-		if err := pluginsPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
 			return fmt.Errorf("error while executing plugins phase: %w", err)
 		}
 	}
@@ -400,13 +457,15 @@ func (c *ClusterCreator) extraPhases(
 		case cluster.OperationPhaseDistribution:
 			distributionPhase.SetUpgrade(false)
 
-			if err := distributionPhase.Exec(reducers.Reducers{}, StartFromFlagNotSet, upgradeState); err != nil {
+			if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+				return distributionPhase.Exec(reducers.Reducers{}, StartFromFlagNotSet, upgradeState)
+			}); err != nil {
 				return fmt.Errorf("error while executing distribution phase: %w", err)
 			}
 
 		case cluster.OperationPhasePlugins:
 			if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
-				if err := pluginsPhase.Exec(); err != nil {
+				if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
 					return fmt.Errorf("error while executing plugins phase: %w", err)
 				}
 			}
@@ -440,3 +499,31 @@ func (*ClusterCreator) getDistributionSubPhase(startFrom string) string {
 		return ""
 	}
 }
+
+// templateSources returns the templates of the phases that the apply runs from startFrom on, that the
+// preflight checks in strict mode before any of them runs.
+func (c *ClusterCreator) templateSources(startFrom string) []cluster.TemplateSource {
+	sources := []cluster.TemplateSource{
+		{
+			Phase: cluster.OperationPhaseDistribution,
+			Path:  path.Join(c.paths.DistroPath, "templates", cluster.OperationPhaseDistribution),
+		},
+	}
+
+	if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
+		sources = append(sources, cluster.TemplateSource{
+			Phase: cluster.OperationPhasePlugins,
+			Path:  path.Join(c.paths.DistroPath, "templates", cluster.OperationPhasePlugins),
+		})
+	}
+
+	return cluster.SelectTemplateSources(sources, c.phase, startFrom)
+}
+
+// forceMigrations tells whether the unsafe migrations run without a confirmation. A plan applies
+// nothing, and the migrations of a saved plan were reviewed with the plan, so they do not ask.
+func (c *ClusterCreator) forceMigrations() bool {
+	return c.planReport != nil ||
+		c.savedPlan != nil ||
+		cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations)
+}
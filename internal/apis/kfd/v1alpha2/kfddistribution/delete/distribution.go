@@ -14,6 +14,7 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/config"
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/kfddistribution/public"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/kubernetes"
 	"github.com/sighupio/furyctl/internal/state"
 	"github.com/sighupio/furyctl/internal/tool/kubectl"
 	"github.com/sighupio/furyctl/internal/tool/shell"
@@ -44,6 +45,7 @@ func NewDistribution(
 	dryRun bool,
 	kfdManifest config.KFD,
 	paths cluster.DeleterPaths,
+	stateStore state.Storer,
 ) *Distribution {
 	phaseOp := cluster.NewOperationPhase(
 		path.Join(paths.WorkDir, cluster.OperationPhaseDistribution),
@@ -71,15 +73,9 @@ func NewDistribution(
 				WorkDir: path.Join(phaseOp.Path, "manifests"),
 			},
 		),
-		dryRun: dryRun,
-		paths:  paths,
-		stateStore: state.NewStore(
-			paths.DistroPath,
-			paths.ConfigPath,
-			paths.WorkDir,
-			kfdManifest.Tools.Common.Kubectl.Version,
-			paths.BinPath,
-		),
+		dryRun:     dryRun,
+		paths:      paths,
+		stateStore: stateStore,
 	}
 }
 
@@ -118,7 +114,12 @@ func (d *Distribution) Exec() error {
 
 	logrus.Info("Checking that the cluster is reachable...")
 
-	if _, err := d.kubeRunner.Version(); err != nil {
+	kubeClient, err := kubernetes.NewClient("")
+	if err != nil {
+		return fmt.Errorf("error connecting to cluster: %w", err)
+	}
+
+	if _, err := kubeClient.ServerVersion(); err != nil {
 		logrus.Debugf("Got error while running cluster reachability check: %s", err)
 
 		return fmt.Errorf("error connecting to cluster: %w", err)
@@ -126,12 +127,12 @@ func (d *Distribution) Exec() error {
 
 	logrus.Info("Checking storage classes...")
 
-	getStorageClassesOutput, err := d.kubeRunner.Get(false, "", "storageclasses")
+	storageClasses, err := kubeClient.StorageClasses()
 	if err != nil {
 		return fmt.Errorf("error while checking storage class: %w", err)
 	}
 
-	if getStorageClassesOutput == "No resources found" {
+	if !storageClasses.Available {
 		storageClassAvailable = false
 	}
 
@@ -14,20 +14,35 @@ import (
 	del "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/kfddistribution/delete"
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/kfddistribution/public"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/runreport"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 )
 
 type ClusterDeleter struct {
-	paths       cluster.DeleterPaths
-	kfdManifest config.KFD
-	furyctlConf public.KfddistributionKfdV1Alpha2
-	phase       string
-	dryRun      bool
+	paths           cluster.DeleterPaths
+	kfdManifest     config.KFD
+	furyctlConf     public.KfddistributionKfdV1Alpha2
+	phase           string
+	dryRun          bool
+	stateStore      state.Storer
+	encryptionKey   encryption.Key
+	stateBackend    backend.Config
+	strictTemplates cluster.StrictTemplates
 }
 
 func (d *ClusterDeleter) SetProperties(props []cluster.DeleterProperty) {
 	for _, prop := range props {
 		d.SetProperty(prop.Name, prop.Value)
 	}
+
+	d.stateStore = state.NewStore(
+		d.paths.DistroPath,
+		d.paths.ConfigPath,
+		d.encryptionKey,
+		d.stateBackend,
+	)
 }
 
 func (d *ClusterDeleter) SetProperty(name string, value any) {
@@ -48,6 +63,12 @@ func (d *ClusterDeleter) SetProperty(name string, value any) {
 		cluster.SetPropertyValue(value, &d.phase)
 	case cluster.DeleterPropertyDryRun:
 		cluster.SetPropertyValue(value, &d.dryRun)
+	case cluster.DeleterPropertyEncryptionKey:
+		cluster.SetPropertyValue(value, &d.encryptionKey)
+	case cluster.DeleterPropertyStateBackend:
+		cluster.SetPropertyValue(value, &d.stateBackend)
+	case cluster.DeleterPropertyStrictTemplates:
+		cluster.SetPropertyValue(value, &d.strictTemplates)
 	default:
 		logrus.Debugf("ignoring unknown property %q", name)
 	}
@@ -60,13 +81,15 @@ func (d *ClusterDeleter) Delete() error {
 
 	preflight := del.NewPreFlight(d.furyctlConf, d.kfdManifest, d.paths)
 
-	if err := preflight.Exec(); err != nil {
+	distro := del.NewDistribution(d.furyctlConf, d.dryRun, d.kfdManifest, d.paths, d.stateStore)
+
+	cluster.SetStrictTemplates(d.strictTemplates, preflight.Self(), distro.Self())
+
+	if err := runreport.Track(cluster.OperationPhasePreFlight, preflight.Exec); err != nil {
 		return fmt.Errorf("error while executing preflight phase: %w", err)
 	}
 
-	distro := del.NewDistribution(d.furyctlConf, d.dryRun, d.kfdManifest, d.paths)
-
-	if err := distro.Exec(); err != nil {
+	if err := runreport.Track(cluster.OperationPhaseDistribution, distro.Exec); err != nil {
 		return fmt.Errorf("error while deleting distribution: %w", err)
 	}
 
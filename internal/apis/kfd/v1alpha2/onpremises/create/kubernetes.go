@@ -15,6 +15,7 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/config"
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/onpremises/public"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/runreport"
 	"github.com/sighupio/furyctl/internal/tool/ansible"
 	"github.com/sighupio/furyctl/internal/upgrade"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
@@ -168,6 +169,8 @@ func (k *Kubernetes) runMigrations(rdcs reducers.Reducers) error {
 		); err != nil {
 			return fmt.Errorf("error running migration playbook for lifecycle %s: %w", lifecycle, err)
 		}
+
+		runreport.AddReducers(rdcs.ByLifecycle(lifecycle))
 	}
 
 	return nil
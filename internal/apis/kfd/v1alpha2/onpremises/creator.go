@@ -19,7 +19,12 @@ import (
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/onpremises/supported"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/policy"
+	"github.com/sighupio/furyctl/internal/runreport"
 	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	"github.com/sighupio/furyctl/internal/upgrade"
 	"github.com/sighupio/furyctl/pkg/diffs"
 	"github.com/sighupio/furyctl/pkg/reducers"
@@ -55,6 +60,12 @@ type ClusterCreator struct {
 	externalUpgradesPath string
 	upgradeNode          string
 	postApplyPhases      []string
+	planReport           *plan.Report
+	savedPlan            *plan.Saved
+	encryptionKey        encryption.Key
+	stateBackend         backend.Config
+	strictTemplates      cluster.StrictTemplates
+	policyRules          []policy.Rule
 }
 
 func (c *ClusterCreator) SetProperties(props []cluster.CreatorProperty) {
@@ -65,16 +76,11 @@ func (c *ClusterCreator) SetProperties(props []cluster.CreatorProperty) {
 	c.stateStore = state.NewStore(
 		c.paths.DistroPath,
 		c.paths.ConfigPath,
-		c.paths.WorkDir,
-		c.kfdManifest.Tools.Common.Kubectl.Version,
-		c.paths.BinPath,
+		c.encryptionKey,
+		c.stateBackend,
 	)
 
-	c.upgradeStateStore = upgrade.NewStateStore(
-		c.paths.WorkDir,
-		c.kfdManifest.Tools.Common.Kubectl.Version,
-		c.paths.BinPath,
-	)
+	c.upgradeStateStore = upgrade.NewStateStore(c.encryptionKey, c.stateBackend)
 }
 
 func (c *ClusterCreator) SetProperty(name string, value any) {
@@ -107,6 +113,18 @@ func (c *ClusterCreator) SetProperty(name string, value any) {
 		cluster.SetPropertyValue(value, &c.upgradeNode)
 	case cluster.CreatorPropertyPostApplyPhases:
 		cluster.SetPropertyValue(value, &c.postApplyPhases)
+	case cluster.CreatorPropertyPlanReport:
+		cluster.SetPropertyValue(value, &c.planReport)
+	case cluster.CreatorPropertySavedPlan:
+		cluster.SetPropertyValue(value, &c.savedPlan)
+	case cluster.CreatorPropertyEncryptionKey:
+		cluster.SetPropertyValue(value, &c.encryptionKey)
+	case cluster.CreatorPropertyStateBackend:
+		cluster.SetPropertyValue(value, &c.stateBackend)
+	case cluster.CreatorPropertyStrictTemplates:
+		cluster.SetPropertyValue(value, &c.strictTemplates)
+	case cluster.CreatorPropertyPolicyRules:
+		cluster.SetPropertyValue(value, &c.policyRules)
 	default:
 		logrus.Debugf("ignoring unknown property %q", name)
 	}
@@ -156,8 +174,11 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			c.furyctlConf,
 			c.kfdManifest,
 			c.paths,
+			c.stateStore,
 			c.dryRun,
 			upgr,
+			c.planReport,
+			c.savedPlan,
 		),
 		c.dryRun,
 		upgr,
@@ -168,6 +189,7 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 		c.kfdManifest,
 		string(c.furyctlConf.Kind),
 		c.dryRun,
+		c.planReport,
 	)
 
 	preflight := create.NewPreFlight(
@@ -180,6 +202,15 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 		c.phase,
 		startFrom,
 		c.upgrade,
+		c.policyRules,
+	)
+
+	cluster.SetStrictTemplates(
+		c.strictTemplates,
+		preflight.Self(),
+		kubernetesPhase.Self(),
+		distributionPhase.Self(),
+		pluginsPhase.Self(),
 	)
 
 	renderedConfig, err := c.RenderConfig()
@@ -187,7 +218,19 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 		return fmt.Errorf("error while rendering config: %w", err)
 	}
 
-	status, err := preflight.Exec(renderedConfig)
+	status, err := runreport.TrackValue(cluster.OperationPhasePreFlight, func() (*create.Status, error) {
+		if err := preflight.Self().CheckTemplates(
+			c.paths.DistroPath,
+			c.paths.ConfigPath,
+			"kfd-v1alpha2",
+			"onpremises",
+			c.templateSources(startFrom),
+		); err != nil {
+			return nil, fmt.Errorf("error while checking the templates of the phases: %w", err)
+		}
+
+		return preflight.Exec(renderedConfig)
+	})
 	if err != nil {
 		return fmt.Errorf("error while executing preflight phase: %w", err)
 	}
@@ -229,6 +272,18 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 		diffs.ExpandMapChanges(status.Diffs),
 	)
 
+	if err := c.savedPlan.VerifyState(c.stateStore); err != nil {
+		return fmt.Errorf("error while checking the saved plan: %w", err)
+	}
+
+	if err := c.planReport.RecordState(c.stateStore); err != nil {
+		return fmt.Errorf("error while recording the cluster state in the plan: %w", err)
+	}
+
+	c.planReport.AddConfigChanges(status.Diffs, r)
+	c.planReport.AddReducers(cluster.OperationPhaseKubernetes, kubeRdcs, unsafeKubeReducers)
+	c.planReport.AddReducers(cluster.OperationPhaseDistribution, rdcs, unsafeReducers)
+
 	if len(rdcs) > 0 {
 		logrus.Infof("Differences found from previous cluster configuration, "+
 			"handling the following changes:\n%s", rdcs.ToString())
@@ -249,7 +304,7 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			c.skipNodesUpgrade,
 		)
 
-		if err := preupgradePhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePreUpgrade, preupgradePhase.Exec); err != nil {
 			return fmt.Errorf("error while executing preupgrade phase: %w", err)
 		}
 	}
@@ -257,7 +312,7 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 	switch c.phase {
 	case cluster.OperationPhaseKubernetes:
 		if len(kubeRdcs) > 0 && len(unsafeKubeReducers) > 0 {
-			confirm, err := cluster.AskConfirmation(cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations))
+			confirm, err := cluster.AskConfirmation(c.forceMigrations())
 			if err != nil {
 				return fmt.Errorf("error while asking for confirmation: %w", err)
 			}
@@ -275,13 +330,15 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			},
 		}
 
-		if err := kubernetesPhase.Exec(kubeRdcs, StartFromFlagNotSet, &upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, func() error {
+			return kubernetesPhase.Exec(kubeRdcs, StartFromFlagNotSet, &upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing kubernetes phase: %w", err)
 		}
 
 	case cluster.OperationPhaseDistribution:
 		if len(rdcs) > 0 && len(unsafeReducers) > 0 {
-			confirm, err := cluster.AskConfirmation(cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations))
+			confirm, err := cluster.AskConfirmation(c.forceMigrations())
 			if err != nil {
 				return fmt.Errorf("error while asking for confirmation: %w", err)
 			}
@@ -299,7 +356,9 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			},
 		}
 
-		if err := distributionPhase.Exec(rdcs, StartFromFlagNotSet, &upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+			return distributionPhase.Exec(rdcs, StartFromFlagNotSet, &upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing distribution phase: %w", err)
 		}
 
@@ -308,7 +367,7 @@ func (c *ClusterCreator) Create(startFrom string, _, podRunningCheckTimeout int)
 			return fmt.Errorf("error while executing plugins phase: %w", distribution.ErrPluginsFeatureNotSupported)
 		}
 
-		if err := pluginsPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
 			return fmt.Errorf("error while executing plugins phase: %w", err)
 		}
 
@@ -429,7 +488,7 @@ func (c *ClusterCreator) allPhases(
 		startFrom != cluster.OperationSubPhasePostDistribution &&
 		startFrom != cluster.OperationPhasePlugins {
 		if len(kubeRdcs) > 0 && len(unsafeKubeReducers) > 0 {
-			confirm, err := cluster.AskConfirmation(cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations))
+			confirm, err := cluster.AskConfirmation(c.forceMigrations())
 			if err != nil {
 				return fmt.Errorf("error while asking for confirmation: %w", err)
 			}
@@ -439,7 +498,9 @@ func (c *ClusterCreator) allPhases(
 			}
 		}
 
-		if err := kubernetesPhase.Exec(kubeRdcs, c.getKubernetesSubPhase(startFrom), upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, func() error {
+			return kubernetesPhase.Exec(kubeRdcs, c.getKubernetesSubPhase(startFrom), upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing kubernetes phase: %w", err)
 		}
 
@@ -450,7 +511,7 @@ func (c *ClusterCreator) allPhases(
 
 	if startFrom != cluster.OperationPhasePlugins {
 		if len(rdcs) > 0 && len(unsafeReducers) > 0 {
-			confirm, err := cluster.AskConfirmation(cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations))
+			confirm, err := cluster.AskConfirmation(c.forceMigrations())
 			if err != nil {
 				return fmt.Errorf("error while asking for confirmation: %w", err)
 			}
@@ -460,13 +521,15 @@ func (c *ClusterCreator) allPhases(
 			}
 		}
 
-		if err := distributionPhase.Exec(rdcs, c.getDistributionSubPhase(startFrom), upgradeState); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+			return distributionPhase.Exec(rdcs, c.getDistributionSubPhase(startFrom), upgradeState)
+		}); err != nil {
 			return fmt.Errorf("error while executing distribution phase: %w", err)
 		}
 	}
 
 	if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
-		if err := pluginsPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
 			return fmt.Errorf("error while executing plugins phase: %w", err)
 		}
 	}
@@ -506,20 +569,24 @@ func (c *ClusterCreator) extraPhases(
 		case cluster.OperationPhaseKubernetes:
 			kubernetesPhase.SetUpgrade(false)
 
-			if err := kubernetesPhase.Exec(nil, StartFromFlagNotSet, upgradeState); err != nil {
+			if err := runreport.Track(cluster.OperationPhaseKubernetes, func() error {
+				return kubernetesPhase.Exec(nil, StartFromFlagNotSet, upgradeState)
+			}); err != nil {
 				return fmt.Errorf("error while executing kubernetes phase: %w", err)
 			}
 
 		case cluster.OperationPhaseDistribution:
 			distributionPhase.SetUpgrade(false)
 
-			if err := distributionPhase.Exec(nil, StartFromFlagNotSet, upgradeState); err != nil {
+			if err := runreport.Track(cluster.OperationPhaseDistribution, func() error {
+				return distributionPhase.Exec(nil, StartFromFlagNotSet, upgradeState)
+			}); err != nil {
 				return fmt.Errorf("error while executing distribution phase: %w", err)
 			}
 
 		case cluster.OperationPhasePlugins:
 			if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
-				if err := pluginsPhase.Exec(); err != nil {
+				if err := runreport.Track(cluster.OperationPhasePlugins, pluginsPhase.Exec); err != nil {
 					return fmt.Errorf("error while executing plugins phase: %w", err)
 				}
 			}
@@ -568,3 +635,35 @@ func (*ClusterCreator) getDistributionSubPhase(startFrom string) string {
 		return ""
 	}
 }
+
+// templateSources returns the templates of the phases that the apply runs from startFrom on, that the
+// preflight checks in strict mode before any of them runs.
+func (c *ClusterCreator) templateSources(startFrom string) []cluster.TemplateSource {
+	sources := []cluster.TemplateSource{
+		{
+			Phase: cluster.OperationPhaseKubernetes,
+			Path:  path.Join(c.paths.DistroPath, "templates", cluster.OperationPhaseKubernetes, "onpremises"),
+		},
+		{
+			Phase: cluster.OperationPhaseDistribution,
+			Path:  path.Join(c.paths.DistroPath, "templates", cluster.OperationPhaseDistribution),
+		},
+	}
+
+	if distribution.HasFeature(c.kfdManifest, distribution.FeaturePlugins) {
+		sources = append(sources, cluster.TemplateSource{
+			Phase: cluster.OperationPhasePlugins,
+			Path:  path.Join(c.paths.DistroPath, "templates", cluster.OperationPhasePlugins),
+		})
+	}
+
+	return cluster.SelectTemplateSources(sources, c.phase, startFrom)
+}
+
+// forceMigrations tells whether the unsafe migrations run without a confirmation. A plan applies
+// nothing, and the migrations of a saved plan were reviewed with the plan, so they do not ask.
+func (c *ClusterCreator) forceMigrations() bool {
+	return c.planReport != nil ||
+		c.savedPlan != nil ||
+		cluster.IsForceEnabledForFeature(c.force, cluster.ForceFeatureMigrations)
+}
@@ -11,23 +11,39 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/sighupio/furyctl/internal/apis/config"
+	commdel "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/common/delete"
 	del "github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/onpremises/delete"
 	"github.com/sighupio/furyctl/internal/apis/kfd/v1alpha2/onpremises/public"
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/runreport"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
 )
 
 type ClusterDeleter struct {
-	paths       cluster.DeleterPaths
-	furyctlConf public.OnpremisesKfdV1Alpha2
-	kfdManifest config.KFD
-	phase       string
-	dryRun      bool
+	paths           cluster.DeleterPaths
+	furyctlConf     public.OnpremisesKfdV1Alpha2
+	kfdManifest     config.KFD
+	phase           string
+	dryRun          bool
+	stateStore      state.Storer
+	encryptionKey   encryption.Key
+	stateBackend    backend.Config
+	strictTemplates cluster.StrictTemplates
 }
 
 func (d *ClusterDeleter) SetProperties(props []cluster.DeleterProperty) {
 	for _, prop := range props {
 		d.SetProperty(prop.Name, prop.Value)
 	}
+
+	d.stateStore = state.NewStore(
+		d.paths.DistroPath,
+		d.paths.ConfigPath,
+		d.encryptionKey,
+		d.stateBackend,
+	)
 }
 
 func (d *ClusterDeleter) SetProperty(name string, value any) {
@@ -48,6 +64,12 @@ func (d *ClusterDeleter) SetProperty(name string, value any) {
 		cluster.SetPropertyValue(value, &d.phase)
 	case cluster.CreatorPropertyDryRun:
 		cluster.SetPropertyValue(value, &d.dryRun)
+	case cluster.DeleterPropertyEncryptionKey:
+		cluster.SetPropertyValue(value, &d.encryptionKey)
+	case cluster.DeleterPropertyStateBackend:
+		cluster.SetPropertyValue(value, &d.stateBackend)
+	case cluster.DeleterPropertyStrictTemplates:
+		cluster.SetPropertyValue(value, &d.strictTemplates)
 	default:
 		logrus.Debugf("ignoring unknown property %q", name)
 	}
@@ -64,36 +86,44 @@ func (d *ClusterDeleter) Delete() error {
 		d.dryRun,
 	)
 
-	distributionPhase := del.NewDistribution(
-		d.furyctlConf,
-		d.kfdManifest,
+	distributionPhase := commdel.NewDistribution(
 		d.paths,
+		d.stateStore,
+		d.kfdManifest,
+		string(d.furyctlConf.Kind),
 		d.dryRun,
 	)
 
 	preflight := del.NewPreFlight(d.furyctlConf, d.kfdManifest, d.paths, d.dryRun)
 
-	if err := preflight.Exec(); err != nil {
+	cluster.SetStrictTemplates(
+		d.strictTemplates,
+		preflight.Self(),
+		kubernetesPhase.Self(),
+		distributionPhase.Self(),
+	)
+
+	if err := runreport.Track(cluster.OperationPhasePreFlight, preflight.Exec); err != nil {
 		return fmt.Errorf("error while executing preflight phase: %w", err)
 	}
 
 	switch d.phase {
 	case cluster.OperationPhaseKubernetes:
-		if err := kubernetesPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, kubernetesPhase.Exec); err != nil {
 			return fmt.Errorf("error while deleting kubernetes phase: %w", err)
 		}
 
 	case cluster.OperationPhaseDistribution:
-		if err := distributionPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, distributionPhase.Exec); err != nil {
 			return fmt.Errorf("error while deleting distribution phase: %w", err)
 		}
 
 	case cluster.OperationPhaseAll:
-		if err := distributionPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseDistribution, distributionPhase.Exec); err != nil {
 			return fmt.Errorf("error while deleting distribution phase: %w", err)
 		}
 
-		if err := kubernetesPhase.Exec(); err != nil {
+		if err := runreport.Track(cluster.OperationPhaseKubernetes, kubernetesPhase.Exec); err != nil {
 			return fmt.Errorf("error while deleting kubernetes phase: %w", err)
 		}
 
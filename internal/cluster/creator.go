@@ -10,6 +10,8 @@ import (
 	"strings"
 
 	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
@@ -20,6 +22,7 @@ const (
 	CreatorPropertyKfdManifest          = "kfdmanifest"
 	CreatorPropertyDistroPath           = "distropath"
 	CreatorPropertyBinPath              = "binpath"
+	CreatorPropertyPolicyRules          = "policyrules"
 	CreatorPropertyPhase                = "phase"
 	CreatorPropertySkipVpn              = "skipvpn"
 	CreatorPropertySkipNodesUpgrade     = "skipnodesupgrade"
@@ -30,6 +33,11 @@ const (
 	CreatorPropertyExternalUpgradesPath = "externalupgradespath"
 	CreatorPropertyUpgradeNode          = "upgradenode"
 	CreatorPropertyPostApplyPhases      = "postapplyphases"
+	CreatorPropertyPlanReport           = "planreport"
+	CreatorPropertySavedPlan            = "savedplan"
+	CreatorPropertyEncryptionKey        = "encryptionkey"
+	CreatorPropertyStateBackend         = "statebackend"
+	CreatorPropertyStrictTemplates      = "stricttemplates"
 )
 
 var (
@@ -74,6 +82,9 @@ func NewCreator(
 	externalUpgradesPath,
 	upgradeNode string,
 	postApplyPhases []string,
+	encryptionKey encryption.Key,
+	stateBackend backend.Config,
+	strictTemplates StrictTemplates,
 ) (Creator, error) {
 	lcAPIVersion := strings.ToLower(minimalConf.APIVersion)
 	lcResourceType := strings.ToLower(minimalConf.Kind)
@@ -136,6 +147,18 @@ func NewCreator(
 				Name:  CreatorPropertyPostApplyPhases,
 				Value: postApplyPhases,
 			},
+			{
+				Name:  CreatorPropertyEncryptionKey,
+				Value: encryptionKey,
+			},
+			{
+				Name:  CreatorPropertyStateBackend,
+				Value: stateBackend,
+			},
+			{
+				Name:  CreatorPropertyStrictTemplates,
+				Value: strictTemplates,
+			},
 		})
 	}
 
@@ -9,20 +9,25 @@ import (
 	"strings"
 
 	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
 const (
-	DeleterPropertyConfigPath     = "configpath"
-	DeleterPropertyFuryctlConf    = "furyctlconf"
-	DeleterPropertyPhase          = "phase"
-	DeleterPropertyWorkDir        = "workdir"
-	DeleterPropertyKfdManifest    = "kfdmanifest"
-	DeleterPropertyDistroPath     = "distropath"
-	DeleterPropertyBinPath        = "binpath"
-	DeleterPropertySkipVpn        = "skipvpn"
-	DeleterPropertyVpnAutoConnect = "vpnautoconnect"
-	DeleterPropertyDryRun         = "dryrun"
+	DeleterPropertyConfigPath      = "configpath"
+	DeleterPropertyFuryctlConf     = "furyctlconf"
+	DeleterPropertyPhase           = "phase"
+	DeleterPropertyWorkDir         = "workdir"
+	DeleterPropertyKfdManifest     = "kfdmanifest"
+	DeleterPropertyDistroPath      = "distropath"
+	DeleterPropertyBinPath         = "binpath"
+	DeleterPropertySkipVpn         = "skipvpn"
+	DeleterPropertyVpnAutoConnect  = "vpnautoconnect"
+	DeleterPropertyDryRun          = "dryrun"
+	DeleterPropertyEncryptionKey   = "encryptionkey"
+	DeleterPropertyStateBackend    = "statebackend"
+	DeleterPropertyStrictTemplates = "stricttemplates"
 )
 
 var delFactories = make(map[string]map[string]DeleterFactory) //nolint:gochecknoglobals, lll // This patterns requires factories
@@ -56,6 +61,9 @@ func NewDeleter(
 	skipVpn,
 	vpnAutoConnect,
 	dryRun bool,
+	encryptionKey encryption.Key,
+	stateBackend backend.Config,
+	strictTemplates StrictTemplates,
 ) (Deleter, error) {
 	lcAPIVersion := strings.ToLower(minimalConf.APIVersion)
 	lcResourceType := strings.ToLower(minimalConf.Kind)
@@ -94,6 +102,18 @@ func NewDeleter(
 				Name:  DeleterPropertyDryRun,
 				Value: dryRun,
 			},
+			{
+				Name:  DeleterPropertyEncryptionKey,
+				Value: encryptionKey,
+			},
+			{
+				Name:  DeleterPropertyStateBackend,
+				Value: stateBackend,
+			},
+			{
+				Name:  DeleterPropertyStrictTemplates,
+				Value: strictTemplates,
+			},
 		})
 	}
 
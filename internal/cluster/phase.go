@@ -195,7 +195,9 @@ type OperationPhase struct {
 	AnsiblePlaybookPath    string
 	AnsiblePythonPath      string
 	AnsibleCollectionsPath string
-	binPath                string
+	// StrictTemplates is the strict rendering of the templates of the phase, none by default.
+	StrictTemplates StrictTemplates
+	binPath         string
 }
 
 type OperationPhaseOption struct {
@@ -295,7 +297,7 @@ func (op *OperationPhase) CreateTerraformFolderStructure() error {
 	return nil
 }
 
-func (*OperationPhase) CopyFromTemplate(
+func (op *OperationPhase) CopyFromTemplate(
 	cfg templatex.Config,
 	prefix,
 	sourcePath,
@@ -334,6 +336,8 @@ func (*OperationPhase) CopyFromTemplate(
 		return fmt.Errorf("error creating template model: %w", err)
 	}
 
+	op.StrictTemplates.Apply(templateModel, prefix)
+
 	err = templateModel.Generate()
 	if err != nil {
 		return fmt.Errorf("error generating from template files: %w", err)
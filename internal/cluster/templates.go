@@ -0,0 +1,200 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cluster
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+
+	iox "github.com/sighupio/furyctl/internal/x/io"
+	templatex "github.com/sighupio/furyctl/pkg/template"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+// StrictTemplatesAll renders the templates of all the phases in strict mode.
+const StrictTemplatesAll = "all"
+
+var ErrUnsupportedStrictTemplatesPhase = errors.New("unsupported strict templates phase")
+
+// StrictTemplates is the strict rendering of the templates: the templates of the phases stop the phase
+// before any tool runs when they read keys that the configuration does not set, but the allowed ones.
+type StrictTemplates struct {
+	Phases []string
+	// Allow are patterns of the keys that can be missing: * stands for a key, ** for any number of keys.
+	Allow []string
+}
+
+// StrictTemplatesPhases returns the phases that can render their templates in strict mode.
+func StrictTemplatesPhases() []string {
+	return []string{
+		StrictTemplatesAll,
+		OperationPhasePreFlight,
+		OperationPhaseInfrastructure,
+		OperationPhaseKubernetes,
+		OperationPhaseDistribution,
+		OperationPhasePlugins,
+	}
+}
+
+// Validate checks that the phases are supported.
+func (st StrictTemplates) Validate() error {
+	for _, p := range st.Phases {
+		if !slices.Contains(StrictTemplatesPhases(), p) {
+			return fmt.Errorf("%w: got '%s', must be one of: %s",
+				ErrUnsupportedStrictTemplatesPhase, p, strings.Join(StrictTemplatesPhases(), ", "))
+		}
+	}
+
+	return nil
+}
+
+// Apply sets the strict mode of a template model of the phase. The phase can have a prefix, as in
+// immutable-infrastructure.
+func (st StrictTemplates) Apply(tm *templatex.Model, phase string) {
+	tm.Strict = st.strict(phase)
+	tm.AllowedMissingKeys = st.Allow
+}
+
+func (st StrictTemplates) strict(phase string) bool {
+	return slices.ContainsFunc(st.Phases, func(p string) bool {
+		return p == StrictTemplatesAll || p == phase || strings.HasSuffix(phase, "-"+p)
+	})
+}
+
+// TemplateSource is a folder of the templates that a phase renders.
+type TemplateSource struct {
+	Phase string
+	Path  string
+}
+
+// SelectTemplateSources returns the sources of the phases that an apply of phase runs, in the order of
+// sources: the ones of phase, or the ones of all the phases from the phase of startFrom on.
+func SelectTemplateSources(sources []TemplateSource, phase, startFrom string) []TemplateSource {
+	if phase != OperationPhaseAll {
+		return lo.Filter(sources, func(s TemplateSource, _ int) bool {
+			return s.Phase == phase
+		})
+	}
+
+	// The sub-phases, as pre-kubernetes, start from their phase.
+	startPhase := strings.TrimPrefix(strings.TrimPrefix(startFrom, "pre-"), "post-")
+
+	if i := slices.IndexFunc(sources, func(s TemplateSource) bool { return s.Phase == startPhase }); i > 0 {
+		return sources[i:]
+	}
+
+	return sources
+}
+
+// CheckTemplates checks in strict mode the templates of the sources whose phase is strict, before any of the
+// phases runs: the keys that they read from the configuration, merged with the defaults of the
+// distribution, must be there, see templatex.Model.CheckConfigMissingKeys. The missing keys of all the
+// phases are a single *templatex.MissingKeysError, with the templates relative to the distribution.
+func (op *OperationPhase) CheckTemplates(
+	distroPath,
+	furyctlConfPath,
+	apiVersion,
+	kind string,
+	sources []TemplateSource,
+) error {
+	sources = lo.Filter(sources, func(s TemplateSource, _ int) bool {
+		return op.StrictTemplates.strict(s.Phase)
+	})
+
+	if len(sources) == 0 {
+		return nil
+	}
+
+	furyctlMerger, err := op.CreateFuryctlMerger(distroPath, furyctlConfPath, apiVersion, kind)
+	if err != nil {
+		return fmt.Errorf("error creating furyctl merger: %w", err)
+	}
+
+	cfg, err := templatex.NewConfigWithoutData(furyctlMerger, []string{})
+	if err != nil {
+		return fmt.Errorf("error creating template config: %w", err)
+	}
+
+	outYaml, err := yamlx.MarshalV2(cfg)
+	if err != nil {
+		return fmt.Errorf("error marshaling template config: %w", err)
+	}
+
+	outDirPath, err := os.MkdirTemp("", "furyctl-templates-")
+	if err != nil {
+		return fmt.Errorf("error creating temp dir: %w", err)
+	}
+
+	defer os.RemoveAll(outDirPath)
+
+	confPath := filepath.Join(outDirPath, "config.yaml")
+
+	if err = os.WriteFile(confPath, outYaml, iox.FullRWPermAccess); err != nil {
+		return fmt.Errorf("error writing config file: %w", err)
+	}
+
+	keys := []templatex.MissingKey{}
+
+	for _, s := range sources {
+		// A distribution without the templates of a phase.
+		if _, err := os.Stat(s.Path); os.IsNotExist(err) {
+			continue
+		}
+
+		templateModel, err := templatex.NewTemplateModel(
+			s.Path,
+			filepath.Join(outDirPath, "target"),
+			confPath,
+			outDirPath,
+			furyctlConfPath,
+			".tpl",
+			false,
+			true,
+		)
+		if err != nil {
+			return fmt.Errorf("error creating template model: %w", err)
+		}
+
+		templateModel.AllowedMissingKeys = op.StrictTemplates.Allow
+
+		err = templateModel.CheckConfigMissingKeys()
+
+		missing := &templatex.MissingKeysError{}
+		if errors.As(err, &missing) {
+			for _, k := range missing.Keys {
+				if rel, err := filepath.Rel(distroPath, filepath.Join(s.Path, k.Template)); err == nil {
+					k.Template = rel
+				}
+
+				keys = append(keys, k)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("error checking the templates of the %s phase: %w", s.Phase, err)
+		}
+	}
+
+	if len(keys) > 0 {
+		return &templatex.MissingKeysError{Keys: keys}
+	}
+
+	return nil
+}
+
+// SetStrictTemplates sets the strict rendering of the templates of the phases.
+func SetStrictTemplates(st StrictTemplates, phases ...*OperationPhase) {
+	for _, op := range phases {
+		op.StrictTemplates = st
+	}
+}
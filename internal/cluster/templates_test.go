@@ -0,0 +1,223 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package cluster_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/cluster"
+	templatex "github.com/sighupio/furyctl/pkg/template"
+)
+
+func TestStrictTemplates_Apply(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc   string
+		phases []string
+		phase  string
+		want   bool
+	}{
+		{
+			desc:   "phase named",
+			phases: []string{cluster.OperationPhaseDistribution},
+			phase:  cluster.OperationPhaseDistribution,
+			want:   true,
+		},
+		{
+			desc:   "other phase named",
+			phases: []string{cluster.OperationPhaseKubernetes},
+			phase:  cluster.OperationPhaseDistribution,
+		},
+		{
+			desc:   "all",
+			phases: []string{cluster.StrictTemplatesAll},
+			phase:  cluster.OperationPhasePlugins,
+			want:   true,
+		},
+		{
+			desc:   "phase with a prefix",
+			phases: []string{cluster.OperationPhaseInfrastructure},
+			phase:  "immutable-infrastructure",
+			want:   true,
+		},
+		{
+			desc:  "none",
+			phase: cluster.OperationPhasePreFlight,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			st := cluster.StrictTemplates{Phases: tc.phases, Allow: []string{".spec.plugins.**"}}
+			tm := &templatex.Model{}
+
+			st.Apply(tm, tc.phase)
+
+			assert.Equal(t, tc.want, tm.Strict)
+			assert.Equal(t, []string{".spec.plugins.**"}, tm.AllowedMissingKeys)
+		})
+	}
+}
+
+func TestStrictTemplates_Validate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, cluster.StrictTemplates{Phases: []string{"all", "distribution"}}.Validate())
+
+	err := cluster.StrictTemplates{Phases: []string{"distro"}}.Validate()
+	require.ErrorIs(t, err, cluster.ErrUnsupportedStrictTemplatesPhase)
+}
+
+func TestSetStrictTemplates(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	source := filepath.Join(dir, "source")
+
+	require.NoError(t, os.MkdirAll(source, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(source, "main.yaml.tpl"), []byte("tags: {{ .spec.tags }}\n"), 0o600))
+
+	// A null key renders, but in strict mode.
+	cfg := templatex.Config{Data: map[string]map[any]any{"spec": {"tags": nil}}}
+
+	lax := cluster.NewOperationPhase(filepath.Join(dir, "lax"), config.KFDTools{}, "")
+	strict := cluster.NewOperationPhase(filepath.Join(dir, "strict"), config.KFDTools{}, "")
+
+	cluster.SetStrictTemplates(cluster.StrictTemplates{Phases: []string{cluster.StrictTemplatesAll}}, strict)
+
+	require.NoError(t, lax.CopyFromTemplate(cfg, cluster.OperationPhaseDistribution, source, lax.Path, "furyctl.yaml"))
+
+	err := strict.CopyFromTemplate(cfg, cluster.OperationPhaseDistribution, source, strict.Path, "furyctl.yaml")
+	require.ErrorIs(t, err, templatex.ErrMissingKeys)
+}
+
+func TestSelectTemplateSources(t *testing.T) {
+	t.Parallel()
+
+	sources := []cluster.TemplateSource{
+		{Phase: cluster.OperationPhaseInfrastructure, Path: "infrastructure"},
+		{Phase: cluster.OperationPhaseKubernetes, Path: "kubernetes"},
+		{Phase: cluster.OperationPhaseDistribution, Path: "distribution"},
+		{Phase: cluster.OperationPhasePlugins, Path: "plugins"},
+	}
+
+	testCases := []struct {
+		desc      string
+		phase     string
+		startFrom string
+		want      []string
+	}{
+		{
+			desc:  "all the phases",
+			phase: cluster.OperationPhaseAll,
+			want:  []string{"infrastructure", "kubernetes", "distribution", "plugins"},
+		},
+		{
+			desc:  "a single phase",
+			phase: cluster.OperationPhaseDistribution,
+			want:  []string{"distribution"},
+		},
+		{
+			desc:      "start from a phase",
+			phase:     cluster.OperationPhaseAll,
+			startFrom: cluster.OperationPhaseDistribution,
+			want:      []string{"distribution", "plugins"},
+		},
+		{
+			desc:      "start from a sub-phase",
+			phase:     cluster.OperationPhaseAll,
+			startFrom: "pre-" + cluster.OperationPhaseKubernetes,
+			want:      []string{"kubernetes", "distribution", "plugins"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := cluster.SelectTemplateSources(sources, tc.phase, tc.startFrom)
+
+			paths := make([]string, 0, len(got))
+			for _, s := range got {
+				paths = append(paths, s.Path)
+			}
+
+			assert.Equal(t, tc.want, paths)
+		})
+	}
+}
+
+func TestOperationPhase_CheckTemplates(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	distroPath := filepath.Join(dir, "distro")
+	furyctlConfPath := filepath.Join(dir, "furyctl.yaml")
+
+	files := map[string]string{
+		filepath.Join(distroPath, "defaults", "kfddistribution-kfd-v1alpha2.yaml"): "data:\n  modules:\n    dr:\n      type: none\n",
+		filepath.Join(distroPath, "templates", "kubernetes", "main.yaml.tpl"): "region: {{ .spec.regon }}\n" +
+			"vpc: {{ .kubernetes.vpcId }}\n",
+		filepath.Join(distroPath, "templates", "distribution", "main.yaml.tpl"): "dr: {{ .spec.distribution.modules.dr.type }}\n" +
+			"name: {{ .metadata.name }}\n" +
+			"auth: {{ .spec.distribution.modules.auth.provider }}\n",
+		furyctlConfPath: "apiVersion: kfd.sighup.io/v1alpha2\nkind: KFDDistribution\nmetadata:\n  name: test\n" +
+			"spec:\n  region: eu-west-1\n  distribution: {}\n",
+	}
+
+	for p, content := range files {
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+	}
+
+	sources := []cluster.TemplateSource{
+		{Phase: cluster.OperationPhaseKubernetes, Path: filepath.Join(distroPath, "templates", "kubernetes")},
+		{Phase: cluster.OperationPhaseDistribution, Path: filepath.Join(distroPath, "templates", "distribution")},
+		{Phase: cluster.OperationPhasePlugins, Path: filepath.Join(distroPath, "templates", "plugins")},
+	}
+
+	op := cluster.NewOperationPhase(filepath.Join(dir, "phase"), config.KFDTools{}, "")
+
+	// No phase is strict.
+	require.NoError(t, op.CheckTemplates(distroPath, furyctlConfPath, "kfd-v1alpha2", "kfddistribution", sources))
+
+	cluster.SetStrictTemplates(cluster.StrictTemplates{Phases: []string{cluster.StrictTemplatesAll}}, op)
+
+	err := op.CheckTemplates(distroPath, furyctlConfPath, "kfd-v1alpha2", "kfddistribution", sources)
+	require.ErrorIs(t, err, templatex.ErrMissingKeys)
+
+	var missing *templatex.MissingKeysError
+	require.ErrorAs(t, err, &missing)
+
+	// The keys of the configuration of all the phases, but not the ones that a phase adds when it runs, as
+	// .kubernetes, and not the ones of the defaults.
+	assert.Equal(t, []templatex.MissingKey{
+		{Template: filepath.Join("templates", "kubernetes", "main.yaml.tpl"), Line: 1, Path: ".spec.regon"},
+		{
+			Template: filepath.Join("templates", "distribution", "main.yaml.tpl"),
+			Line:     3,
+			Path:     ".spec.distribution.modules.auth.provider",
+		},
+	}, missing.Keys)
+
+	// The allowed keys and the phases that are not strict.
+	cluster.SetStrictTemplates(cluster.StrictTemplates{
+		Phases: []string{cluster.OperationPhaseDistribution},
+		Allow:  []string{".spec.distribution.modules.auth.**"},
+	}, op)
+
+	require.NoError(t, op.CheckTemplates(distroPath, furyctlConfPath, "kfd-v1alpha2", "kfddistribution", sources))
+}
@@ -18,11 +18,14 @@ const (
 	ForceFeatureMigrations       string = "migrations"
 	ForceFeatureUpgrades         string = "upgrades"
 	ForceFeaturePodsRunningCheck string = "pods-running-check"
+	// ForceFeatureUnverifiedDownloads lets the tools that fail the checksum or signature verification
+	// through. "all" does not include it: it must be named explicitly.
+	ForceFeatureUnverifiedDownloads string = "unverified-downloads"
 )
 
 func IsForceEnabledForFeature(force []string, feature string) bool {
 	return slices.ContainsFunc(force, func(f string) bool {
-		return f == "all" || f == feature
+		return (f == ForceFeatureAll && feature != ForceFeatureUnverifiedDownloads) || f == feature
 	})
 }
 
@@ -0,0 +1,63 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package cluster_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sighupio/furyctl/internal/cluster"
+)
+
+func TestIsForceEnabledForFeature(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc    string
+		force   []string
+		feature string
+		want    bool
+	}{
+		{
+			desc:    "feature named",
+			force:   []string{cluster.ForceFeatureUpgrades},
+			feature: cluster.ForceFeatureUpgrades,
+			want:    true,
+		},
+		{
+			desc:    "other feature named",
+			force:   []string{cluster.ForceFeatureMigrations},
+			feature: cluster.ForceFeatureUpgrades,
+		},
+		{
+			desc:    "all includes the upgrades",
+			force:   []string{cluster.ForceFeatureAll},
+			feature: cluster.ForceFeatureUpgrades,
+			want:    true,
+		},
+		{
+			desc:    "all does not include the unverified downloads",
+			force:   []string{cluster.ForceFeatureAll},
+			feature: cluster.ForceFeatureUnverifiedDownloads,
+		},
+		{
+			desc:    "unverified downloads named",
+			force:   []string{cluster.ForceFeatureAll, cluster.ForceFeatureUnverifiedDownloads},
+			feature: cluster.ForceFeatureUnverifiedDownloads,
+			want:    true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tC.want, cluster.IsForceEnabledForFeature(tC.force, tC.feature))
+		})
+	}
+}
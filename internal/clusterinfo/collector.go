@@ -5,8 +5,6 @@
 package clusterinfo
 
 import (
-	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io/fs"
@@ -17,14 +15,15 @@ import (
 	"time"
 
 	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/sighupio/furyctl/configs"
 	distroconf "github.com/sighupio/furyctl/internal/apis/config"
 	"github.com/sighupio/furyctl/internal/cluster"
 	"github.com/sighupio/furyctl/internal/distribution"
-	"github.com/sighupio/furyctl/internal/tool/kubectl"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/kubernetes"
 	"github.com/sighupio/furyctl/internal/upgrade"
-	execx "github.com/sighupio/furyctl/internal/x/exec"
 	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
@@ -56,21 +55,16 @@ var (
 // Collector reads cluster information from the Kubernetes secrets and configmaps
 // that furyctl maintains during cluster lifecycle operations.
 type Collector struct {
-	KubectlRunner *kubectl.Runner
-}
+	Client *kubernetes.Client
 
-// NewCollector creates a Collector using the given kubectl binary and working directory.
-func NewCollector(kubectlBin, workDir string) *Collector {
-	runner := kubectl.NewRunner(
-		execx.NewStdExecutor(),
-		kubectl.Paths{
-			Kubectl: kubectlBin,
-			WorkDir: workDir,
-		},
-		false, true, false,
-	)
+	// Key decrypts the configuration and the upgrade state that furyctl stores encrypted.
+	Key encryption.Key
+}
 
-	return &Collector{KubectlRunner: runner}
+// NewCollector creates a Collector that reads the cluster with the given client, and decrypts with the
+// given key.
+func NewCollector(client *kubernetes.Client, key encryption.Key) *Collector {
+	return &Collector{Client: client, Key: key}
 }
 
 // Collect gathers all available cluster information and returns a populated struct.
@@ -131,70 +125,52 @@ func (c *Collector) Collect() (*Info, error) {
 }
 
 func (c *Collector) fetchSecret(secretName, dataKey string) ([]byte, time.Time, error) {
-	out, err := c.KubectlRunner.Get(
-		true,
-		kubeSystemNamespace,
-		"secret", secretName,
-		"-o", "yaml",
-		"--show-managed-fields",
-	)
+	secret, err := c.Client.GetSecret(kubeSystemNamespace, secretName)
 	if err != nil {
 		return nil, time.Time{}, fmt.Errorf("error reading secret %s: %w", secretName, err)
 	}
 
-	raw := map[string]any{}
-	if err := yamlx.UnmarshalV3([]byte(out), &raw); err != nil {
-		return nil, time.Time{}, fmt.Errorf("error parsing secret %s: %w", secretName, err)
-	}
-
-	data, ok := raw["data"].(map[string]any)
-	if !ok {
+	if len(secret.Data) == 0 {
 		return nil, time.Time{}, fmt.Errorf("%w: %s", ErrSecretNoData, secretName)
 	}
 
-	encoded, ok := data[dataKey].(string)
+	data, ok := secret.Data[dataKey]
 	if !ok {
 		return nil, time.Time{}, fmt.Errorf("%w %q in %s", ErrSecretMissingKey, dataKey, secretName)
 	}
 
-	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	opened, err := encryption.Open(c.Key, data)
 	if err != nil {
-		return nil, time.Time{}, fmt.Errorf("error decoding secret %s key %q: %w", secretName, dataKey, err)
+		return nil, time.Time{}, fmt.Errorf("error decrypting secret %s key %q: %w", secretName, dataKey, err)
 	}
 
-	return decoded, latestManagedFieldTime(raw), nil
+	return opened, latestManagedFieldTime(secret.ObjectMeta), nil
 }
 
 // fetchOngoingUpgrade reads the upgrade state configmap and returns an OngoingUpgrade
 // when an upgrade is currently in progress or has a failed phase.
 func (c *Collector) fetchOngoingUpgrade() (*OngoingUpgrade, error) {
-	out, err := c.KubectlRunner.Get(
-		false,
-		kubeSystemNamespace,
-		"cm", upgradeStateConfigMap,
-		"-o", "yaml",
-	)
+	configMap, err := c.Client.GetConfigMap(kubeSystemNamespace, upgradeStateConfigMap)
 	if err != nil {
 		return nil, fmt.Errorf("upgrade state configmap not found: %w", err)
 	}
 
-	raw := map[string]any{}
-	if err := yamlx.UnmarshalV3([]byte(out), &raw); err != nil {
-		return nil, fmt.Errorf("error parsing upgrade state: %w", err)
-	}
-
-	cmData, ok := raw["data"].(map[string]any)
-	if !ok {
+	if len(configMap.Data) == 0 {
 		return nil, fmt.Errorf("%w", ErrUpgradeStateNoData)
 	}
 
-	stateYAML, ok := cmData["state"].(string)
+	stateYAML, ok := configMap.Data["state"]
 	if !ok {
 		return nil, fmt.Errorf("%w", ErrUpgradeStateMissing)
 	}
 
+	openedState, err := encryption.Open(c.Key, []byte(stateYAML))
+	if err != nil {
+		return nil, fmt.Errorf("error decrypting upgrade state: %w", err)
+	}
+
 	state := &upgrade.State{}
-	if err := yamlx.UnmarshalV3([]byte(stateYAML), state); err != nil {
+	if err := yamlx.UnmarshalV3(openedState, state); err != nil {
 		return nil, fmt.Errorf("error parsing upgrade state YAML: %w", err)
 	}
 
@@ -203,68 +179,22 @@ func (c *Collector) fetchOngoingUpgrade() (*OngoingUpgrade, error) {
 
 // fetchKubernetesVersion retrieves the Kubernetes server version.
 func (c *Collector) fetchKubernetesVersion() (string, error) {
-	out, err := c.KubectlRunner.Version()
+	version, err := c.Client.ServerVersion()
 	if err != nil {
 		return "", fmt.Errorf("error getting kubernetes version: %w", err)
 	}
 
-	type versionInfo struct {
-		GitVersion string `json:"gitVersion"`
-	}
-
-	type kubectlVersion struct {
-		ServerVersion versionInfo `json:"serverVersion"`
-	}
-
-	var info kubectlVersion
-	if err := json.Unmarshal([]byte(out), &info); err != nil {
-		return "", fmt.Errorf("error parsing kubernetes version response: %w", err)
-	}
-
-	return info.ServerVersion.GitVersion, nil
+	return version, nil
 }
 
 // fetchNodes summarizes node capacity by role to report cluster shape.
 func (c *Collector) fetchNodes() (*NodesSummary, error) {
-	out, err := c.KubectlRunner.Get(
-		false,
-		"all",
-		"nodes",
-		"-o", "json",
-	)
+	nodes, err := c.Client.ListNodes()
 	if err != nil {
 		return nil, fmt.Errorf("error getting nodes: %w", err)
 	}
 
-	type nodeResource struct {
-		CPU    string `json:"cpu"`
-		Memory string `json:"memory"`
-	}
-
-	type nodeStatus struct {
-		Capacity nodeResource `json:"capacity"`
-	}
-
-	type nodeMetadata struct {
-		Name   string            `json:"name"`
-		Labels map[string]string `json:"labels"`
-	}
-
-	type nodeItem struct {
-		Metadata nodeMetadata `json:"metadata"`
-		Status   nodeStatus   `json:"status"`
-	}
-
-	type nodeList struct {
-		Items []nodeItem `json:"items"`
-	}
-
-	var list nodeList
-	if err := json.Unmarshal([]byte(out), &list); err != nil {
-		return nil, fmt.Errorf("error parsing nodes JSON: %w", err)
-	}
-
-	if len(list.Items) == 0 {
+	if len(nodes) == 0 {
 		return &NodesSummary{}, nil
 	}
 
@@ -274,10 +204,10 @@ func (c *Collector) fetchNodes() (*NodesSummary, error) {
 
 	totals := NodeTotals{}
 
-	for _, item := range list.Items {
-		role := primaryRole(item.Metadata.Labels)
-		vcpu := parseCPU(item.Status.Capacity.CPU)
-		ramGb := parseMemoryGb(item.Status.Capacity.Memory)
+	for _, node := range nodes {
+		role := primaryRole(node.Labels)
+		vcpu := parseCPU(node.Status.Capacity.Cpu().String())
+		ramGb := parseMemoryGb(node.Status.Capacity.Memory().String())
 
 		if _, exists := groups[role]; !exists {
 			groups[role] = &NodeRoleGroup{Role: role}
@@ -304,49 +234,20 @@ func (c *Collector) fetchNodes() (*NodesSummary, error) {
 
 // latestManagedFieldTime returns the most recent managedFields[].time,
 // falling back to creationTimestamp.
-func latestManagedFieldTime(raw map[string]any) time.Time {
-	metadata, ok := raw["metadata"].(map[string]any)
-	if !ok {
-		return time.Time{}
-	}
-
-	if fields, ok := metadata["managedFields"].([]any); ok {
-		var latest time.Time
+func latestManagedFieldTime(meta metav1.ObjectMeta) time.Time {
+	var latest time.Time
 
-		for _, f := range fields {
-			field, ok := f.(map[string]any)
-			if !ok {
-				continue
-			}
-
-			ts, ok := field["time"].(string)
-			if !ok {
-				continue
-			}
-
-			t, err := time.Parse(time.RFC3339, ts)
-			if err != nil {
-				continue
-			}
-
-			if t.After(latest) {
-				latest = t
-			}
-		}
-
-		if !latest.IsZero() {
-			return latest
+	for _, field := range meta.ManagedFields {
+		if field.Time != nil && field.Time.After(latest) {
+			latest = field.Time.Time
 		}
 	}
 
-	if ts, ok := metadata["creationTimestamp"].(string); ok {
-		t, err := time.Parse(time.RFC3339, ts)
-		if err == nil {
-			return t
-		}
+	if !latest.IsZero() {
+		return latest
 	}
 
-	return time.Time{}
+	return meta.CreationTimestamp.Time
 }
 
 // upgradeInfoFromState returns the first pending/failed phase in canonical
@@ -5,14 +5,18 @@
 package clusterinfo_test
 
 import (
-	"encoding/base64"
-	"fmt"
-	"os"
+	"errors"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
 	"github.com/sighupio/furyctl/internal/clusterinfo"
-	"github.com/sighupio/furyctl/internal/tool/kubectl"
-	execx "github.com/sighupio/furyctl/internal/x/exec"
+	"github.com/sighupio/furyctl/internal/kubernetes"
 )
 
 const (
@@ -72,80 +76,77 @@ func TestCollector_Collect(t *testing.T) {
 	}
 }
 
-//nolint:paralleltest // TestHelperProcess is a subprocess helper used by execx.NewFakeExecutor, not a real test.
-func TestHelperProcess(t *testing.T) {
-	args := os.Args
+func TestCollector_Collect_Nodes(t *testing.T) {
+	t.Parallel()
 
-	if len(args) < 3 || args[1] != "-test.run=TestHelperProcess" {
-		return
+	info, err := FakeCollector(t).Collect()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
 	}
 
-	cmd, subcmd := args[3], args[4]
-
-	switch cmd {
-	case "kubectl":
-		switch subcmd {
-		case "version":
-			fmt.Fprintf(os.Stdout, `{"clientVersion":{"gitVersion":"v1.34.4"},"serverVersion":{"gitVersion":"v1.34.4"}}`)
-
-		case "get":
-			if args[5] == "-A" {
-				// Nodes call: kubectl get -A nodes -o json.
-				fmt.Fprintf(os.Stdout, `{"items":[{"metadata":{"name":"node-1","labels":{"node-role.kubernetes.io/control-plane":""}},"status":{"capacity":{"cpu":"4","memory":"8Gi"}}}]}`)
-
-				os.Exit(0)
-			}
-
-			// Namespaced call: kubectl get -n kube-system <type> <name> -o <format>.
-			if len(args) < 9 {
-				os.Exit(1)
-			}
-
-			resourceType := args[7]
-			resourceName := args[8]
-
-			switch resourceType {
-			case "secret":
-				switch resourceName {
-				case "furyctl-config":
-					encoded := base64.StdEncoding.EncodeToString([]byte(minimalFuryctlYAML))
-					fmt.Fprintf(os.Stdout, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: furyctl-config\n  namespace: kube-system\ndata:\n  config: %s\n", encoded)
+	if info.Nodes == nil || info.Nodes.Totals.Quantity != 1 {
+		t.Fatalf("expected 1 node, got %+v", info.Nodes)
+	}
 
-				case "furyctl-kfd":
-					encoded := base64.StdEncoding.EncodeToString([]byte(minimalKFDYAML))
-					fmt.Fprintf(os.Stdout, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: furyctl-kfd\n  namespace: kube-system\ndata:\n  kfd: %s\n", encoded)
+	if info.Nodes.Totals.VCPU != 4 {
+		t.Errorf("expected 4 vCPU, got %d", info.Nodes.Totals.VCPU)
+	}
 
-				default:
-					os.Exit(1)
-				}
+	if info.Nodes.Totals.RAMGb != 8 {
+		t.Errorf("expected 8 GB of RAM, got %f", info.Nodes.Totals.RAMGb)
+	}
 
-			default:
-				os.Exit(1)
-			}
+	if info.Nodes.Roles[0].Role != "control-plane" {
+		t.Errorf("expected role %q, got %q", "control-plane", info.Nodes.Roles[0].Role)
+	}
+}
 
-		default:
-			os.Exit(1)
-		}
+func TestCollector_Collect_MissingConfig(t *testing.T) {
+	t.Parallel()
 
-	default:
-		fmt.Fprintf(os.Stdout, "command not found")
+	collector := &clusterinfo.Collector{
+		Client: kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(), nil, nil),
 	}
 
-	os.Exit(0)
+	if _, err := collector.Collect(); !errors.Is(err, clusterinfo.ErrConfigSecretNotFound) {
+		t.Fatalf("expected %v, got %v", clusterinfo.ErrConfigSecretNotFound, err)
+	}
 }
 
 func FakeCollector(t *testing.T) *clusterinfo.Collector {
 	t.Helper()
 
-	return &clusterinfo.Collector{
-		KubectlRunner: kubectl.NewRunner(
-			execx.NewFakeExecutor("TestHelperProcess"),
-			kubectl.Paths{
-				Kubectl: "kubectl",
+	clientset := fake.NewSimpleClientset(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "furyctl-config", Namespace: "kube-system"},
+			Data:       map[string][]byte{"config": []byte(minimalFuryctlYAML)},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "furyctl-kfd", Namespace: "kube-system"},
+			Data:       map[string][]byte{"kfd": []byte(minimalKFDYAML)},
+		},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node-1",
+				Labels: map[string]string{"node-role.kubernetes.io/control-plane": ""},
 			},
-			false,
-			true,
-			false,
-		),
+			Status: corev1.NodeStatus{
+				Capacity: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				},
+			},
+		},
+	)
+
+	discovery, ok := clientset.Discovery().(*fakediscovery.FakeDiscovery)
+	if !ok {
+		t.Fatal("expected a fake discovery client")
+	}
+
+	discovery.FakedServerVersion = &version.Info{GitVersion: "v1.34.4"}
+
+	return &clusterinfo.Collector{
+		Client: kubernetes.NewClientWithInterfaces(clientset, nil, nil),
 	}
 }
@@ -15,6 +15,7 @@ import (
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestPrimaryRole(t *testing.T) {
@@ -471,36 +472,29 @@ func TestPluginNames(t *testing.T) {
 func TestLatestManagedFieldTime(t *testing.T) {
 	t.Parallel()
 
-	// No metadata.
-	require.True(t, latestManagedFieldTime(map[string]any{}).IsZero(), "expected zero time when no metadata present")
+	// No managedFields and no creationTimestamp.
+	require.True(t, latestManagedFieldTime(metav1.ObjectMeta{}).IsZero(), "expected zero time when no metadata present")
 
 	// ManagedFields with multiple timestamps.
 	t1, _ := time.Parse(time.RFC3339, "2024-01-01T10:00:00Z")
 	t2, _ := time.Parse(time.RFC3339, "2024-01-02T10:00:00Z")
-	raw := map[string]any{
-		"metadata": map[string]any{
-			"managedFields": []any{
-				map[string]any{"time": t1.Format(time.RFC3339)},
-				map[string]any{"time": t2.Format(time.RFC3339)},
-			},
+	meta := metav1.ObjectMeta{
+		ManagedFields: []metav1.ManagedFieldsEntry{
+			{Time: &metav1.Time{Time: t1}},
+			{Time: &metav1.Time{Time: t2}},
 		},
 	}
 
-	got := latestManagedFieldTime(raw)
+	got := latestManagedFieldTime(meta)
 	require.True(t, got.Equal(t2), "expected latest managedFields time %v, got %v", t2, got)
 
-	// Malformed managedFields, fallback to creationTimestamp.
+	// ManagedFields without a time, fallback to creationTimestamp.
 	ct, _ := time.Parse(time.RFC3339, "2024-02-02T10:00:00Z")
-	raw2 := map[string]any{
-		"metadata": map[string]any{
-			"managedFields":     []any{map[string]any{"time": "not-a-time"}},
-			"creationTimestamp": ct.Format(time.RFC3339),
-		},
+	meta2 := metav1.ObjectMeta{
+		ManagedFields:     []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		CreationTimestamp: metav1.Time{Time: ct},
 	}
 
-	got = latestManagedFieldTime(raw2)
+	got = latestManagedFieldTime(meta2)
 	require.True(t, got.Equal(ct), "expected creationTimestamp fallback %v, got %v", ct, got)
-
-	raw3 := map[string]any{"metadata": map[string]any{"creationTimestamp": "bad"}}
-	require.True(t, latestManagedFieldTime(raw3).IsZero(), "expected zero when creationTimestamp is malformed")
 }
@@ -0,0 +1,586 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package compose builds a furyctl.yaml out of the base files that it extends and of the overlays of an
+// environment.
+//
+// A remote base file must be an https URL that pins the sha256 checksum of the file in its checksum query
+// parameter, as https://example.com/furyctl.yaml?checksum=sha256:<hex>, and it cannot set the flags of the
+// commands nor read secrets with a secret provider, as {exec://...}: furyctl would run them on the machine
+// that composes the configuration.
+//
+// The composed configuration is written away from the files it comes from, so the relative paths of these
+// files become absolute: the ones of the dynamic values, as {file://./ca.crt}, {path://../ssh} or the
+// location of a secret provider, and the string values out of the flags field that start with ./ or ../,
+// that the templates resolve against the folder of the configuration. Any other value is copied as it is:
+// a relative path of the flags field, for example, resolves against the working directory.
+package compose
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/go-getter"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	parserx "github.com/sighupio/furyctl/internal/parser"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+	"github.com/sighupio/furyctl/pkg/merge"
+	netx "github.com/sighupio/furyctl/pkg/x/net"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+const (
+	// KeyExtends lists the base files of a furyctl.yaml, merged in order under the file itself.
+	KeyExtends = "extends"
+	// KeyIncludes is an alias of KeyExtends, its files merge after the ones of KeyExtends.
+	KeyIncludes = "includes"
+	// KeyOverlays maps an environment to the files merged over the file when furyctl runs for it.
+	KeyOverlays = "overlays"
+
+	// keyFlags holds the flags of the commands, their relative paths resolve against the working directory.
+	keyFlags = "flags"
+
+	// checksumParam is the query parameter of a remote base file that pins its checksum, as sha256:<hex>,
+	// the same as the one of go-getter.
+	checksumParam = "checksum"
+)
+
+var (
+	ErrCycle               = errors.New("the configuration extends itself")
+	ErrInvalidComposition  = errors.New("invalid composition")
+	ErrUnknownEnvironment  = errors.New("no overlay for the environment")
+	ErrCannotDownloadBase  = errors.New("cannot download base file")
+	ErrComposedNotWritable = errors.New("cannot write the composed configuration")
+	ErrInsecureBase        = errors.New("a remote base file must be an https URL")
+	ErrUnpinnedBase        = errors.New("a remote base file must pin its checksum")
+	ErrBaseChecksum        = errors.New("checksum mismatch of remote base file")
+	ErrUnsafeBase          = errors.New("a remote base file cannot set the flags or read secrets")
+)
+
+// The keys that identify the items of the lists of furyctl.yaml, in order of precedence: the nodes and
+// the node pools, the plugin releases and the kustomize projects by name, the nodes of the Immutable
+// kind by hostname.
+//
+//nolint:gochecknoglobals // read-only.
+var identityKeys = []string{"name", "hostname"}
+
+// remoteClient downloads the remote base files.
+type remoteClient interface {
+	DownloadWithMode(src, dst string, mode getter.ClientMode, decompressors map[string]getter.Decompressor) error
+}
+
+// IsComposed tells if the furyctl.yaml at path extends other files or has overlays.
+func IsComposed(path string) (bool, error) {
+	doc, err := yamlx.FromFileV3[map[string]any](path)
+	if err != nil {
+		return false, err
+	}
+
+	return isComposed(doc), nil
+}
+
+func isComposed(doc map[string]any) bool {
+	for _, key := range []string{KeyExtends, KeyIncludes, KeyOverlays} {
+		if _, ok := doc[key]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ComposedPath returns the path in dir of the composed configuration of the furyctl.yaml at path. The name
+// has a hash of the absolute path, so that the configurations of different folders do not overwrite each
+// other in the same dir.
+func ComposedPath(path, dir string) (string, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("error while getting absolute path of %s: %w", path, err)
+	}
+
+	ext := filepath.Ext(absPath)
+	name := strings.TrimSuffix(filepath.Base(absPath), ext)
+	sum := sha256.Sum256([]byte(absPath))
+
+	return filepath.Join(dir, fmt.Sprintf("%s-%x.composed%s", name, sum[:4], ext)), nil
+}
+
+// Compose returns the furyctl.yaml at path merged over its base files, and with the overlays of the
+// environment merged over it. The lists merge by the identity key of their items.
+func Compose(path, environment string) (map[string]any, error) {
+	conf, _, err := compose(path, environment, netx.NewGoGetterClient())
+
+	return conf, err
+}
+
+// compose returns the composition of the furyctl.yaml at path, with its source map. The client downloads
+// the remote base files.
+func compose(path, environment string, client remoteClient) (map[string]any, SourceMap, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while getting absolute path of %s: %w", path, err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "furyctl-compose-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while creating temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	c := &composer{
+		environment: environment,
+		root:        path,
+		rootPath:    absPath,
+		rootDir:     filepath.Dir(absPath),
+		tmpDir:      tmpDir,
+		client:      client,
+	}
+
+	conf, origins, err := c.compose(absPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if environment != "" && !c.environmentFound {
+		return nil, nil, fmt.Errorf("%w %q", ErrUnknownEnvironment, environment)
+	}
+
+	return conf, sourceMap(origins, Location{File: path, Line: 1, Column: 1}), nil
+}
+
+// Prepare composes the furyctl.yaml at path, if it extends other files or has overlays, and writes the
+// result to its composed path in dir, that it returns, with the source map of the result next to it, see
+// ReadSourceMap. It returns path for any other configuration.
+func Prepare(path, environment, dir string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		// The commands that need a configuration report the missing file.
+		return path, nil //nolint:nilerr // nothing to compose.
+	}
+
+	composed, err := IsComposed(path)
+	if err != nil {
+		return "", fmt.Errorf("error while reading %s: %w", path, err)
+	}
+
+	if !composed {
+		if environment != "" {
+			logrus.Debugf("%s has no overlays, ignoring the %s environment", path, environment)
+		}
+
+		return path, nil
+	}
+
+	conf, sm, err := compose(path, environment, netx.NewGoGetterClient())
+	if err != nil {
+		return "", fmt.Errorf("error while composing %s: %w", path, err)
+	}
+
+	out, err := yamlx.MarshalV3(conf)
+	if err != nil {
+		return "", fmt.Errorf("error while marshalling the composed configuration: %w", err)
+	}
+
+	composedPath, err := ComposedPath(path, dir)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, iox.FullPermAccess); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrComposedNotWritable, err)
+	}
+
+	if err := os.WriteFile(composedPath, out, iox.FullRWPermAccess); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrComposedNotWritable, err)
+	}
+
+	if err := sm.write(SourceMapPath(composedPath)); err != nil {
+		return "", err
+	}
+
+	logrus.Debugf("Composed %s into %s", path, composedPath)
+
+	return composedPath, nil
+}
+
+type composer struct {
+	environment      string
+	environmentFound bool
+	root             string
+	rootPath         string
+	rootDir          string
+	tmpDir           string
+	client           remoteClient
+	stack            []string
+	downloads        int
+}
+
+// compose returns the file at source, a local path or an http(s) URL, merged over its bases and with the
+// overlays of the environment merged over it, with its origin tree, see originTree.
+func (c *composer) compose(source string) (map[string]any, map[string]any, error) {
+	if slices.Contains(c.stack, source) {
+		return nil, nil, fmt.Errorf("%w: %s", ErrCycle, strings.Join(append(c.stack, source), " -> "))
+	}
+
+	c.stack = append(c.stack, source)
+	defer func() { c.stack = c.stack[:len(c.stack)-1] }()
+
+	doc, docOrigins, err := c.load(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bases, err := stringList(doc, KeyExtends)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	includes, err := stringList(doc, KeyIncludes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	overlays, err := c.overlays(doc)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", source, err)
+	}
+
+	for _, key := range []string{KeyExtends, KeyIncludes, KeyOverlays} {
+		delete(doc, key)
+		deleteOrigin(docOrigins, key)
+	}
+
+	conf := map[string]any{}
+	origins := map[string]any{}
+
+	for _, ref := range append(bases, includes...) {
+		base, baseOrigins, err := c.compose(resolve(ref, source))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		conf = merge.MergeByKey(conf, base, identityKeys...)
+		origins = merge.MergeByKey(origins, baseOrigins, identityKeys...)
+	}
+
+	// The relative paths of a remote file resolve against the folder of the configuration, as before
+	// the composition.
+	dir := c.rootDir
+	if !isURL(source) {
+		dir = filepath.Dir(source)
+	}
+
+	// The origin tree is rebased too, so that its identity keys stay the ones of the document.
+	for k, v := range doc {
+		doc[k] = rebase(v, dir, k != keyFlags)
+		docOrigins[k] = rebase(docOrigins[k], dir, k != keyFlags)
+	}
+
+	conf = merge.MergeByKey(conf, doc, identityKeys...)
+	origins = merge.MergeByKey(origins, docOrigins, identityKeys...)
+
+	for _, ref := range overlays {
+		overlay, overlayOrigins, err := c.compose(resolve(ref, source))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		conf = merge.MergeByKey(conf, overlay, identityKeys...)
+		origins = merge.MergeByKey(origins, overlayOrigins, identityKeys...)
+	}
+
+	return conf, origins, nil
+}
+
+// overlays returns the files of the overlays of the environment in doc.
+func (c *composer) overlays(doc map[string]any) ([]string, error) {
+	raw, ok := doc[KeyOverlays]
+	if !ok || c.environment == "" {
+		return nil, nil
+	}
+
+	envs, ok := raw.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s must map the environments to their files", ErrInvalidComposition, KeyOverlays)
+	}
+
+	if _, ok := envs[c.environment]; !ok {
+		return nil, nil
+	}
+
+	c.environmentFound = true
+
+	return stringList(envs, c.environment)
+}
+
+// load returns the document of the file at source, with its origin tree.
+func (c *composer) load(source string) (map[string]any, map[string]any, error) {
+	path := source
+
+	if isURL(source) {
+		var err error
+
+		if path, err = c.download(source); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while reading %s: %w", source, err)
+	}
+
+	node := yaml.Node{}
+
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return nil, nil, fmt.Errorf("error while reading %s: %w", source, err)
+	}
+
+	doc := map[string]any{}
+	origins := map[string]any{}
+
+	if len(node.Content) > 0 {
+		if err := node.Content[0].Decode(&doc); err != nil {
+			return nil, nil, fmt.Errorf("error while reading %s: %w", source, err)
+		}
+
+		if tree, ok := originTree(node.Content[0], c.displayPath(source)).(map[string]any); ok {
+			origins = tree
+		}
+	}
+
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	if isURL(source) {
+		if err := checkRemote(doc); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", source, err)
+		}
+	}
+
+	return doc, origins, nil
+}
+
+// download downloads the remote base file at source, an https URL with the checksum of the file in its
+// checksum query parameter, and returns its path once the checksum matches.
+func (c *composer) download(source string) (string, error) {
+	if !strings.HasPrefix(source, "https://") {
+		return "", fmt.Errorf("%w: %s", ErrInsecureBase, source)
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("%w %s: %w", ErrCannotDownloadBase, source, err)
+	}
+
+	query := u.Query()
+
+	want, ok := strings.CutPrefix(strings.ToLower(query.Get(checksumParam)), "sha256:")
+	if !ok || want == "" {
+		return "", fmt.Errorf("%w, add ?%s=sha256:<hex> to %s", ErrUnpinnedBase, checksumParam, source)
+	}
+
+	query.Del(checksumParam)
+	u.RawQuery = query.Encode()
+
+	c.downloads++
+
+	path := filepath.Join(c.tmpDir, fmt.Sprintf("%d-%s", c.downloads, filepath.Base(u.Path)))
+
+	logrus.Debugf("Downloading base file %s", source)
+
+	// A single file, that go-getter must not decompress.
+	if err := c.client.DownloadWithMode(u.String(), path, getter.ClientModeFile, map[string]getter.Decompressor{}); err != nil {
+		return "", fmt.Errorf("%w %s: %w", ErrCannotDownloadBase, source, err)
+	}
+
+	got, err := iox.Sha256File(path)
+	if err != nil {
+		return "", fmt.Errorf("error while reading %s: %w", source, err)
+	}
+
+	if got != want {
+		return "", fmt.Errorf("%w %s: got sha256:%s", ErrBaseChecksum, source, got)
+	}
+
+	return path, nil
+}
+
+// checkRemote returns an error if the document of a remote base file sets the flags of the commands, as
+// force, or has a dynamic value of a secret provider, as {exec://...}, that furyctl would resolve on the
+// machine that runs it.
+func checkRemote(doc map[string]any) error {
+	if _, ok := doc[keyFlags]; ok {
+		return fmt.Errorf("%w: it has the %s field", ErrUnsafeBase, keyFlags)
+	}
+
+	providers := parserx.SecretProviders()
+
+	var walk func(value any) error
+
+	walk = func(value any) error {
+		switch v := value.(type) {
+		case map[string]any:
+			for _, item := range v {
+				if err := walk(item); err != nil {
+					return err
+				}
+			}
+
+		case []any:
+			for _, item := range v {
+				if err := walk(item); err != nil {
+					return err
+				}
+			}
+
+		case string:
+			for _, dynamicValue := range parserx.DynamicRegexp.FindAllString(v, -1) {
+				scheme, _, ok := strings.Cut(strings.Trim(dynamicValue, "{}"), "://")
+				if ok && slices.Contains(providers, scheme) {
+					return fmt.Errorf("%w: it has the %s dynamic value", ErrUnsafeBase, dynamicValue)
+				}
+			}
+
+		default:
+			// The other values are not dynamic.
+		}
+
+		return nil
+	}
+
+	return walk(doc)
+}
+
+// displayPath returns the file at source as the diagnostics show it: the path of the configuration as the
+// user wrote it, the path of a local file relative to the working directory when it is in it, an URL.
+func (c *composer) displayPath(source string) string {
+	if isURL(source) {
+		return source
+	}
+
+	if source == c.rootPath {
+		return c.root
+	}
+
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, source); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+
+	return source
+}
+
+// stringList returns the value of key in doc, a string or a list of strings.
+func stringList(doc map[string]any, key string) ([]string, error) {
+	switch v := doc[key].(type) {
+	case nil:
+		return nil, nil
+
+	case string:
+		return []string{v}, nil
+
+	case []any:
+		refs := make([]string, 0, len(v))
+
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%w: %s must be a list of paths or URLs", ErrInvalidComposition, key)
+			}
+
+			refs = append(refs, s)
+		}
+
+		return refs, nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s must be a list of paths or URLs", ErrInvalidComposition, key)
+	}
+}
+
+// resolve returns the source of ref, a path or an URL, relative to the file at from.
+func resolve(ref, from string) string {
+	if isURL(ref) {
+		return ref
+	}
+
+	if isURL(from) {
+		base, err := url.Parse(from)
+		if err == nil {
+			if u, err := base.Parse(ref); err == nil {
+				return u.String()
+			}
+		}
+
+		return ref
+	}
+
+	if filepath.IsAbs(ref) {
+		return filepath.Clean(ref)
+	}
+
+	return filepath.Join(filepath.Dir(from), ref)
+}
+
+func isURL(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
+}
+
+// rebase makes absolute the relative paths of the values of a file in dir: the ones of its dynamic values
+// and, with plain, the string values that start with ./ or ../.
+func rebase(value any, dir string, plain bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+
+		for k, item := range v {
+			out[k] = rebase(item, dir, plain)
+		}
+
+		return out
+
+	case []any:
+		out := make([]any, len(v))
+
+		for i, item := range v {
+			out[i] = rebase(item, dir, plain)
+		}
+
+		return out
+
+	case string:
+		if plain && parserx.RelativePathRegexp.MatchString(v) {
+			return filepath.Join(dir, filepath.Clean(v))
+		}
+
+		return parserx.DynamicRegexp.ReplaceAllStringFunc(v, func(dynamicValue string) string {
+			source, sourceValue, ok := strings.Cut(strings.Trim(dynamicValue, "{}"), "://")
+			if !ok {
+				return dynamicValue
+			}
+
+			relative := parserx.RelativePathRegexp.MatchString(sourceValue) ||
+				(source == parserx.Path && !filepath.IsAbs(sourceValue))
+			if !relative {
+				return dynamicValue
+			}
+
+			return "{" + source + "://" + filepath.Join(dir, filepath.Clean(sourceValue)) + "}"
+		})
+
+	default:
+		return value
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package compose_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/compose"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+const baseConfig = `apiVersion: kfd.sighup.io/v1alpha2
+kind: OnPremises
+spec:
+  distributionVersion: v1.31.0
+  kubernetes:
+    ssh:
+      keyPath: "{path://./ssh/id_ed25519}"
+    caCert: "{file://./pki/ca.crt}"
+    nodes:
+      - name: infra
+        size: 2
+      - name: worker
+        size: 3
+  plugins:
+    helm:
+      releases:
+        - name: cert-exporter
+          chart: cert/exporter
+`
+
+const clusterConfig = `extends:
+  - ../base/furyctl.yaml
+overlays:
+  production:
+    - ./production.yaml
+metadata:
+  name: cluster-a
+spec:
+  kubernetes:
+    nodes:
+      - name: worker
+        size: 5
+`
+
+const productionOverlay = `spec:
+  kubernetes:
+    nodes:
+      - name: ingress
+        size: 2
+  plugins:
+    helm:
+      releases:
+        - name: cert-exporter
+          values: ./prod-values.yaml
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	return dir
+}
+
+func TestCompose(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"base/furyctl.yaml":       baseConfig,
+		"cluster/furyctl.yaml":    clusterConfig,
+		"cluster/production.yaml": productionOverlay,
+	})
+
+	conf, err := compose.Compose(filepath.Join(dir, "cluster", "furyctl.yaml"), "production")
+	require.NoError(t, err)
+
+	out, err := yamlx.MarshalV3(conf)
+	require.NoError(t, err)
+
+	// The relative paths become absolute, the composed configuration is written away from its files.
+	assert.YAMLEq(t, `apiVersion: kfd.sighup.io/v1alpha2
+kind: OnPremises
+metadata:
+  name: cluster-a
+spec:
+  distributionVersion: v1.31.0
+  kubernetes:
+    ssh:
+      keyPath: "{path://`+dir+`/base/ssh/id_ed25519}"
+    caCert: "{file://`+dir+`/base/pki/ca.crt}"
+    nodes:
+      - name: infra
+        size: 2
+      - name: worker
+        size: 5
+      - name: ingress
+        size: 2
+  plugins:
+    helm:
+      releases:
+        - name: cert-exporter
+          chart: cert/exporter
+          values: `+dir+`/cluster/prod-values.yaml
+`, string(out))
+}
+
+func TestCompose_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"base/furyctl.yaml":       baseConfig,
+		"cluster/furyctl.yaml":    clusterConfig,
+		"cluster/production.yaml": productionOverlay,
+		"a.yaml":                  "extends: ./b.yaml\n",
+		"b.yaml":                  "includes: [./a.yaml]\n",
+	})
+
+	_, err := compose.Compose(filepath.Join(dir, "cluster", "furyctl.yaml"), "staging")
+	require.ErrorIs(t, err, compose.ErrUnknownEnvironment)
+
+	_, err = compose.Compose(filepath.Join(dir, "a.yaml"), "")
+	require.ErrorIs(t, err, compose.ErrCycle)
+}
+
+func TestCompose_InsecureRemoteBase(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"furyctl.yaml": "extends: http://example.com/furyctl.yaml?checksum=sha256:00\n",
+	})
+
+	_, err := compose.Compose(filepath.Join(dir, "furyctl.yaml"), "")
+	require.ErrorIs(t, err, compose.ErrInsecureBase)
+}
+
+func TestPrepare(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"base/furyctl.yaml":    baseConfig,
+		"cluster/furyctl.yaml": clusterConfig,
+		"plain/furyctl.yaml":   baseConfig,
+	})
+
+	outDir := filepath.Join(t.TempDir(), "compose")
+
+	// A configuration that is not composed is read as it is.
+	plain := filepath.Join(dir, "plain", "furyctl.yaml")
+
+	path, err := compose.Prepare(plain, "production", outDir)
+	require.NoError(t, err)
+	assert.Equal(t, plain, path)
+	assert.NoDirExists(t, outDir)
+
+	source := filepath.Join(dir, "cluster", "furyctl.yaml")
+
+	path, err = compose.Prepare(source, "", outDir)
+	require.NoError(t, err)
+
+	// The composed configuration goes to the given directory, the folder of the configuration stays as it is.
+	want, err := compose.ComposedPath(source, outDir)
+	require.NoError(t, err)
+	assert.Equal(t, want, path)
+	assert.Equal(t, outDir, filepath.Dir(path))
+	assert.NoFileExists(t, filepath.Join(dir, "cluster", ".furyctl.composed.yaml"))
+
+	composed, err := yamlx.FromFileV3[map[string]any](path)
+	require.NoError(t, err)
+	assert.NotContains(t, composed, compose.KeyExtends)
+	assert.NotContains(t, composed, compose.KeyOverlays)
+	assert.Equal(t, "OnPremises", composed["kind"])
+
+	// Two configurations with the same name do not overwrite each other.
+	other, err := compose.ComposedPath(filepath.Join(dir, "base", "furyctl.yaml"), outDir)
+	require.NoError(t, err)
+	assert.NotEqual(t, path, other)
+}
+
+func TestPrepare_SourceMap(t *testing.T) {
+	t.Parallel()
+
+	dir := writeFiles(t, map[string]string{
+		"base/furyctl.yaml":       baseConfig,
+		"cluster/furyctl.yaml":    clusterConfig,
+		"cluster/production.yaml": productionOverlay,
+		"list/base.yaml":          "spec:\n  list:\n    - a\n    - b\n",
+		"list/furyctl.yaml":       "extends: ./base.yaml\nspec:\n  list:\n    - c\n",
+	})
+
+	source := filepath.Join(dir, "cluster", "furyctl.yaml")
+	base := filepath.Join(dir, "base", "furyctl.yaml")
+	overlay := filepath.Join(dir, "cluster", "production.yaml")
+
+	path, err := compose.Prepare(source, "production", t.TempDir())
+	require.NoError(t, err)
+	assert.FileExists(t, compose.SourceMapPath(path))
+
+	sm, err := compose.ReadSourceMap(path)
+	require.NoError(t, err)
+
+	// Each value is in the last file that sets it, the items of the lists that merge by key too.
+	assert.Equal(t, compose.Location{File: source, Line: 1, Column: 1}, sm.Lookup(""))
+	assert.Equal(t, compose.Location{File: base, Line: 2, Column: 1}, sm.Lookup("/kind"))
+	assert.Equal(t, compose.Location{File: source, Line: 7, Column: 3}, sm.Lookup("/metadata/name"))
+	assert.Equal(t, compose.Location{File: base, Line: 11, Column: 9}, sm.Lookup("/spec/kubernetes/nodes/0/size"))
+	assert.Equal(t, compose.Location{File: source, Line: 12, Column: 9}, sm.Lookup("/spec/kubernetes/nodes/1/size"))
+	assert.Equal(t, compose.Location{File: overlay, Line: 4, Column: 9}, sm.Lookup("/spec/kubernetes/nodes/2"))
+	assert.Equal(t, compose.Location{File: overlay, Line: 10, Column: 11}, sm.Lookup("/spec/plugins/helm/releases/0/values"))
+
+	// A value that is not in the files is at its closest ancestor.
+	assert.Equal(t, compose.Location{File: overlay, Line: 5, Column: 9}, sm.Lookup("/spec/kubernetes/nodes/2/size/unknown"))
+
+	// A list that does not merge by key is the one of the last file.
+	path, err = compose.Prepare(filepath.Join(dir, "list", "furyctl.yaml"), "", t.TempDir())
+	require.NoError(t, err)
+
+	sm, err = compose.ReadSourceMap(path)
+	require.NoError(t, err)
+	assert.Equal(t, compose.Location{File: filepath.Join(dir, "list", "furyctl.yaml"), Line: 4, Column: 7}, sm.Lookup("/spec/list/0"))
+
+	// A configuration that is not composed has no source map.
+	sm, err = compose.ReadSourceMap(source)
+	require.NoError(t, err)
+	assert.Nil(t, sm)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+//nolint:testpackage // compose takes the client of the remote base files, that is unexported.
+package compose
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-getter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errNotFound = errors.New("not found")
+
+// fakeRemoteClient serves the remote base files from memory, by URL.
+type fakeRemoteClient map[string]string
+
+func (f fakeRemoteClient) DownloadWithMode(src, dst string, _ getter.ClientMode, _ map[string]getter.Decompressor) error {
+	content, ok := f[src]
+	if !ok {
+		return fmt.Errorf("%w: %s", errNotFound, src)
+	}
+
+	return os.WriteFile(dst, []byte(content), 0o600)
+}
+
+func pin(content string) string {
+	return fmt.Sprintf("?checksum=sha256:%x", sha256.Sum256([]byte(content)))
+}
+
+func TestCompose_RemoteBase(t *testing.T) {
+	t.Parallel()
+
+	const (
+		common = "kind: KFDDistribution\n"
+		base   = "extends: ./common.yaml"
+	)
+
+	baseContent := base + pin(common) + "\nspec:\n  distributionVersion: v1.31.0\n"
+
+	files := fakeRemoteClient{
+		"https://example.com/bases/furyctl.yaml": baseContent,
+		"https://example.com/bases/common.yaml":  common,
+		"https://example.com/bases/flags.yaml":   "flags:\n  apply:\n    force: all\n",
+		"https://example.com/bases/exec.yaml":    "spec:\n  token: \"Bearer {exec://./token.sh}\"\n",
+		"https://example.com/bases/env.yaml":     "spec:\n  region: \"{env://AWS_REGION}\"\n",
+	}
+
+	testCases := []struct {
+		desc    string
+		extends string
+		want    map[string]any
+		wantErr error
+	}{
+		{
+			desc:    "pinned",
+			extends: "https://example.com/bases/furyctl.yaml" + pin(baseContent),
+			want: map[string]any{
+				"kind":     "KFDDistribution",
+				"metadata": map[string]any{"name": "remote"},
+				"spec":     map[string]any{"distributionVersion": "v1.31.0"},
+			},
+		},
+		{
+			desc:    "dynamic value of a non-secret source",
+			extends: "https://example.com/bases/env.yaml" + pin(files["https://example.com/bases/env.yaml"]),
+			want: map[string]any{
+				"metadata": map[string]any{"name": "remote"},
+				"spec":     map[string]any{"region": "{env://AWS_REGION}"},
+			},
+		},
+		{
+			desc:    "not pinned",
+			extends: "https://example.com/bases/furyctl.yaml",
+			wantErr: ErrUnpinnedBase,
+		},
+		{
+			desc:    "wrong checksum",
+			extends: "https://example.com/bases/furyctl.yaml" + pin(common),
+			wantErr: ErrBaseChecksum,
+		},
+		{
+			desc:    "flags",
+			extends: "https://example.com/bases/flags.yaml" + pin(files["https://example.com/bases/flags.yaml"]),
+			wantErr: ErrUnsafeBase,
+		},
+		{
+			desc:    "secret provider",
+			extends: "https://example.com/bases/exec.yaml" + pin(files["https://example.com/bases/exec.yaml"]),
+			wantErr: ErrUnsafeBase,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			path := filepath.Join(dir, "furyctl.yaml")
+
+			require.NoError(t, os.WriteFile(path, []byte("extends: "+tc.extends+"\nmetadata:\n  name: remote\n"), 0o600))
+
+			conf, _, err := compose(path, "", files)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, conf)
+		})
+	}
+}
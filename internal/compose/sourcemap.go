@@ -0,0 +1,213 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package compose
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+// originKey prefixes the keys of an origin tree that hold the location of a value instead of a value.
+const originKey = "\x00"
+
+// Location is the file, a path or an URL, and the line and column, both starting at 1, that a value of
+// the composed configuration comes from.
+type Location struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+// SourceMap maps the JSON pointer of each value of a composed configuration to its location: the one of
+// the key for the value of a mapping, the one of the item for an element of a sequence.
+type SourceMap map[string]Location
+
+// SourceMapPath returns the path of the source map of the composed configuration at composedPath.
+func SourceMapPath(composedPath string) string {
+	return strings.TrimSuffix(composedPath, filepath.Ext(composedPath)) + ".sourcemap.json"
+}
+
+// ReadSourceMap returns the source map of the composed configuration at composedPath, or nil if the file
+// at composedPath is not a composed configuration.
+func ReadSourceMap(composedPath string) (SourceMap, error) {
+	content, err := os.ReadFile(SourceMapPath(composedPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the source map of %s: %w", composedPath, err)
+	}
+
+	sm := SourceMap{}
+
+	if err := json.Unmarshal(content, &sm); err != nil {
+		return nil, fmt.Errorf("error while parsing the source map of %s: %w", composedPath, err)
+	}
+
+	return sm, nil
+}
+
+// Lookup returns the location of the value at pointer, or the one of its closest ancestor that is in the
+// source map: a value that a dynamic value expands to has the location of the dynamic value.
+func (sm SourceMap) Lookup(pointer string) Location {
+	for {
+		if loc, ok := sm[pointer]; ok {
+			return loc
+		}
+
+		i := strings.LastIndex(pointer, "/")
+		if i < 0 {
+			return sm[""]
+		}
+
+		pointer = pointer[:i]
+	}
+}
+
+func (sm SourceMap) write(path string) error {
+	out, err := json.Marshal(sm)
+	if err != nil {
+		return fmt.Errorf("error while marshalling the source map: %w", err)
+	}
+
+	if err := os.WriteFile(path, out, iox.FullRWPermAccess); err != nil {
+		return fmt.Errorf("%w: %w", ErrComposedNotWritable, err)
+	}
+
+	return nil
+}
+
+// An origin tree has the shape of a document, with the same keys and the same scalars, so that it merges
+// like the document does, and with the location of each value next to it: the one of the value of key in
+// originKey+key of its mapping, the one of a mapping of a sequence in its originKey key, the one of the
+// other items of a sequence at key in originKey+key+originKey+index of the mapping of the sequence.
+
+// originTree returns the origin tree of a YAML node of file.
+func originTree(node *yaml.Node, file string) any {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		return originTree(node.Alias, file)
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		tree := make(map[string]any, len(node.Content))
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+
+			tree[key.Value] = originTree(value, file)
+			tree[originKey+key.Value] = Location{File: file, Line: key.Line, Column: key.Column}
+
+			if items, ok := tree[key.Value].([]any); ok {
+				for j, item := range value.Content {
+					if _, ok := items[j].(map[string]any); !ok {
+						tree[originKey+key.Value+originKey+strconv.Itoa(j)] = Location{
+							File: file, Line: item.Line, Column: item.Column,
+						}
+					}
+				}
+			}
+		}
+
+		return tree
+
+	case yaml.SequenceNode:
+		tree := make([]any, len(node.Content))
+
+		for i, item := range node.Content {
+			tree[i] = originTree(item, file)
+
+			if m, ok := tree[i].(map[string]any); ok {
+				m[originKey] = Location{File: file, Line: item.Line, Column: item.Column}
+			}
+		}
+
+		return tree
+
+	default:
+		var value any
+
+		if err := node.Decode(&value); err != nil {
+			return nil
+		}
+
+		return value
+	}
+}
+
+// deleteOrigin deletes key and its locations from the origin tree of a mapping.
+func deleteOrigin(tree map[string]any, key string) {
+	delete(tree, key)
+	delete(tree, originKey+key)
+
+	for k := range tree {
+		if strings.HasPrefix(k, originKey+key+originKey) {
+			delete(tree, k)
+		}
+	}
+}
+
+// sourceMap flattens the merged origin tree of a configuration into its source map.
+func sourceMap(tree map[string]any, root Location) SourceMap {
+	sm := SourceMap{"": root}
+
+	addOrigins(sm, "", tree)
+
+	return sm
+}
+
+func addOrigins(sm SourceMap, pointer string, tree map[string]any) {
+	for key, value := range tree {
+		if strings.HasPrefix(key, originKey) {
+			continue
+		}
+
+		child := pointer + "/" + escapePointer(key)
+
+		if loc, ok := tree[originKey+key].(Location); ok {
+			sm[child] = loc
+		}
+
+		switch v := value.(type) {
+		case map[string]any:
+			addOrigins(sm, child, v)
+
+		case []any:
+			for i, item := range v {
+				itemPointer := child + "/" + strconv.Itoa(i)
+
+				m, ok := item.(map[string]any)
+				if !ok {
+					if loc, ok := tree[originKey+key+originKey+strconv.Itoa(i)].(Location); ok {
+						sm[itemPointer] = loc
+					}
+
+					continue
+				}
+
+				if loc, ok := m[originKey].(Location); ok {
+					sm[itemPointer] = loc
+				}
+
+				addOrigins(sm, itemPointer, m)
+			}
+		}
+	}
+}
+
+// escapePointer escapes a key as a token of a JSON pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/compose"
+	"github.com/sighupio/furyctl/internal/flags"
+)
+
+const (
+	// KeywordExtraSchema is the keyword of the violations of the extra schema rules of a kind, that are
+	// checks of furyctl and not of the JSON schema of the distribution.
+	KeywordExtraSchema = "extraSchema"
+
+	// KeywordInvalid is the keyword of the errors that are not a violation of a rule, for example a file
+	// that cannot be read.
+	KeywordInvalid = "invalid"
+)
+
+var (
+	ErrSchemaValidation = errors.New("the configuration does not validate against the schema")
+	ErrExtraSchema      = errors.New("the configuration does not validate against the extra schema rules")
+)
+
+var (
+	// The name of the first property of an additionalProperties violation.
+	//nolint:gochecknoglobals // compiled once.
+	additionalPropertyRegex = regexp.MustCompile(`'((?:[^'\\]|\\.)*)'`)
+
+	// The first path of furyctl.yaml in the message of an extra schema rule, eg: .spec.kubernetes.nodePools[0].
+	//nolint:gochecknoglobals // compiled once.
+	configPathRegex = regexp.MustCompile(`\.spec(?:\.[A-Za-z0-9_-]+|\[\d*\])*`)
+)
+
+// Diagnostic is a violation of furyctl.yaml, with its position in the file.
+type Diagnostic struct {
+	File string `json:"file"`
+	Position
+	// Path is the JSON pointer of the value that violates the rule.
+	Path    string `json:"path"`
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+	Snippet string `json:"snippet,omitempty"`
+	// Severity is the one of a policy rule, the other violations are always fatal.
+	Severity flags.ValidationSeverity `json:"severity,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+	var sb strings.Builder
+
+	sb.WriteString(d.File)
+
+	if d.Line > 0 {
+		fmt.Fprintf(&sb, ":%d:%d", d.Line, d.Column)
+	}
+
+	if d.Path != "" {
+		fmt.Fprintf(&sb, ": %s", d.Path)
+	}
+
+	fmt.Fprintf(&sb, ": %s (%s)", d.Message, d.Keyword)
+
+	if d.Severity == flags.ValidationSeverityWarning {
+		sb.WriteString(" [warning]")
+	}
+
+	if d.Snippet != "" {
+		sb.WriteString("\n")
+		sb.WriteString(strings.TrimSuffix(d.Snippet, "\n"))
+	}
+
+	return sb.String()
+}
+
+// ValidationError is the error of Validate for a configuration that violates the schema or the extra
+// schema rules. Each violation is a Diagnostic.
+type ValidationError struct {
+	Diagnostics []Diagnostic
+	err         error
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, 0, len(e.Diagnostics)+1)
+	lines = append(lines, fmt.Sprintf("%v, %d violations:", e.err, len(e.Diagnostics)))
+
+	for _, d := range e.Diagnostics {
+		lines = append(lines, d.String())
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.err
+}
+
+// Diagnostics returns the diagnostics of an error of Validate: the ones of a ValidationError, or a
+// diagnostic without a position for any other error. The diagnostic of an error of a composed configuration
+// is in the furyctl.yaml that it comes from.
+func Diagnostics(path string, err error) []Diagnostic {
+	if err == nil {
+		return []Diagnostic{}
+	}
+
+	verr := &ValidationError{}
+	if errors.As(err, &verr) {
+		return verr.Diagnostics
+	}
+
+	file := path
+
+	if sm, serr := compose.ReadSourceMap(path); serr == nil && sm != nil {
+		file = sm.Lookup("").File
+	}
+
+	return []Diagnostic{{File: file, Keyword: KeywordInvalid, Message: err.Error()}}
+}
+
+// locator finds the values of furyctl.yaml in the source. The values of a composed configuration are in
+// the files that it comes from, that its source map tells.
+type locator struct {
+	file      string
+	index     positionIndex
+	lines     []string
+	sourceMap compose.SourceMap
+	sources   map[string][]string
+}
+
+func newLocator(path string) locator {
+	l := locator{file: path}
+
+	sm, err := compose.ReadSourceMap(path)
+	if err != nil {
+		logrus.Debugf("Cannot read the source map of %s to locate the violations: %v", path, err)
+	}
+
+	if sm != nil {
+		l.sourceMap = sm
+		l.sources = map[string][]string{}
+
+		return l
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		logrus.Debugf("Cannot read %s to locate the violations: %v", path, err)
+
+		return l
+	}
+
+	if l.index, err = indexPositions(content); err != nil {
+		logrus.Debugf("Cannot parse %s to locate the violations: %v", path, err)
+	}
+
+	l.lines = sourceLines(content)
+
+	return l
+}
+
+func (l locator) diagnostic(pointer, keyword, message string) Diagnostic {
+	d := Diagnostic{File: l.file, Path: pointer, Keyword: keyword, Message: message}
+
+	switch {
+	case l.sourceMap != nil:
+		loc := l.sourceMap.Lookup(pointer)
+
+		d.File = loc.File
+		d.Position = Position{Line: loc.Line, Column: loc.Column}
+		d.Snippet = snippet(l.source(loc.File), d.Position)
+
+	case l.index != nil:
+		d.Position = l.index.lookup(pointer)
+		d.Snippet = snippet(l.lines, d.Position)
+	}
+
+	return d
+}
+
+// source returns the lines of a file that a composed configuration comes from, none for a remote file.
+func (l locator) source(file string) []string {
+	if lines, ok := l.sources[file]; ok {
+		return lines
+	}
+
+	var lines []string
+
+	if !strings.HasPrefix(file, "http://") && !strings.HasPrefix(file, "https://") {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			logrus.Debugf("Cannot read %s to show the violations: %v", file, err)
+		} else {
+			lines = sourceLines(content)
+		}
+	}
+
+	l.sources[file] = lines
+
+	return lines
+}
+
+func sourceLines(content []byte) []string {
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+// schemaError returns the ValidationError of an error of the JSON schema.
+func (l locator) schemaError(err error) error {
+	verr := &jsonschema.ValidationError{}
+	if !errors.As(err, &verr) {
+		return err
+	}
+
+	diags := []Diagnostic{}
+	seen := map[string]bool{}
+
+	var walk func(ve *jsonschema.ValidationError)
+
+	walk = func(ve *jsonschema.ValidationError) {
+		for _, c := range ve.Causes {
+			walk(c)
+		}
+
+		if len(ve.Causes) > 0 {
+			return
+		}
+
+		pointer := unescapeSchemaPointer(ve.InstanceLocation)
+		keyword := ve.KeywordLocation[strings.LastIndex(ve.KeywordLocation, "/")+1:]
+
+		// The violation is the first property that is not allowed, not the object that has it.
+		if keyword == "additionalProperties" {
+			if m := additionalPropertyRegex.FindStringSubmatch(ve.Message); m != nil {
+				pointer += "/" + escapePointer(strings.ReplaceAll(m[1], `\'`, `'`))
+			}
+		}
+
+		if key := pointer + "\x00" + ve.Message; !seen[key] {
+			seen[key] = true
+
+			diags = append(diags, l.diagnostic(pointer, keyword, ve.Message))
+		}
+	}
+
+	walk(verr)
+
+	return &ValidationError{Diagnostics: sortDiagnostics(diags), err: ErrSchemaValidation}
+}
+
+// extraSchemaError returns the ValidationError of an error of the extra schema rules: each rule that
+// errors.Join put together is a violation, at the first path of furyctl.yaml that its message names.
+func (l locator) extraSchemaError(err error) error {
+	errs := []error{err}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint // splits errors.Join.
+		errs = joined.Unwrap()
+	}
+
+	diags := make([]Diagnostic, 0, len(errs))
+
+	for _, e := range errs {
+		diags = append(diags, l.diagnostic(configPathPointer(e.Error()), KeywordExtraSchema, e.Error()))
+	}
+
+	return &ValidationError{Diagnostics: sortDiagnostics(diags), err: ErrExtraSchema}
+}
+
+// configPathPointer returns the JSON pointer of the first path of furyctl.yaml in a message, or the root.
+func configPathPointer(message string) string {
+	path := configPathRegex.FindString(message)
+	if path == "" {
+		return ""
+	}
+
+	path = strings.ReplaceAll(path, "[]", "")
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+
+	return strings.ReplaceAll(path, ".", "/")
+}
+
+// unescapeSchemaPointer turns a location of the JSON schema validator, that escapes the tokens of the
+// pointer for a URL too, into a JSON pointer.
+func unescapeSchemaPointer(pointer string) string {
+	tokens := strings.Split(pointer, "/")
+
+	for i, t := range tokens {
+		if u, err := url.PathUnescape(t); err == nil {
+			tokens[i] = u
+		}
+	}
+
+	return strings.Join(tokens, "/")
+}
+
+func sortDiagnostics(diags []Diagnostic) []Diagnostic {
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].File != diags[j].File {
+			return diags[i].File < diags[j].File
+		}
+
+		if diags[i].Line != diags[j].Line {
+			return diags[i].Line < diags[j].Line
+		}
+
+		return diags[i].Column < diags[j].Column
+	})
+
+	return diags
+}
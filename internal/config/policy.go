@@ -0,0 +1,50 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/policy"
+)
+
+// KeywordPolicy prefixes the keyword of the violations of a policy rule, that is followed by its name.
+const KeywordPolicy = "policy/"
+
+// ValidatePolicies checks the furyctl.yaml file at path, with its dynamic values expanded, against the
+// policy rules rls. It returns the diagnostics of all the violations, and a *ValidationError with the
+// fatal ones.
+func ValidatePolicies(path, kind string, rls []policy.Rule) ([]Diagnostic, error) {
+	if len(rls) == 0 {
+		return []Diagnostic{}, nil
+	}
+
+	conf, err := Render(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while rendering configuration file: %w", err)
+	}
+
+	loc := newLocator(path)
+	diags := []Diagnostic{}
+	fatal := []Diagnostic{}
+
+	for _, v := range policy.CheckConfig(rls, kind, conf) {
+		d := loc.diagnostic(unescapeSchemaPointer(v.Path), KeywordPolicy+v.Rule, v.Message)
+		d.Severity = v.Severity
+
+		diags = append(diags, d)
+
+		if v.Severity == flags.ValidationSeverityFatal {
+			fatal = append(fatal, d)
+		}
+	}
+
+	if len(fatal) > 0 {
+		return sortDiagnostics(diags), &ValidationError{Diagnostics: sortDiagnostics(fatal), err: policy.ErrPolicyViolation}
+	}
+
+	return sortDiagnostics(diags), nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The lines of the source that a snippet shows before and after the line of a diagnostic.
+const snippetContext = 1
+
+// Position is a line and a column of furyctl.yaml, both starting at 1.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// positionIndex maps the JSON pointer of each value of furyctl.yaml to its position: the position of the
+// key for the value of a mapping, the position of the item for an element of a sequence.
+type positionIndex map[string]Position
+
+// indexPositions returns the position index of a YAML document.
+func indexPositions(content []byte) (positionIndex, error) {
+	root := yaml.Node{}
+
+	if err := yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("error while parsing yaml: %w", err)
+	}
+
+	idx := positionIndex{"": {Line: 1, Column: 1}}
+
+	if len(root.Content) > 0 {
+		idx.add("", root.Content[0])
+	}
+
+	return idx, nil
+}
+
+func (idx positionIndex) add(pointer string, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := pointer + "/" + escapePointer(key.Value)
+
+			idx[child] = Position{Line: key.Line, Column: key.Column}
+			idx.add(child, value)
+		}
+
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			child := pointer + "/" + strconv.Itoa(i)
+
+			idx[child] = Position{Line: item.Line, Column: item.Column}
+			idx.add(child, item)
+		}
+
+	default:
+		// The scalars have their position from their parent. The children of an alias are the ones of
+		// its anchor: the alias itself is the closest position.
+	}
+}
+
+// lookup returns the position of the value at pointer, or the one of its closest ancestor that is in the
+// file: a value that a dynamic value (eg: {file://...}) expands to has the position of the dynamic value,
+// a missing property the one of its parent.
+func (idx positionIndex) lookup(pointer string) Position {
+	for {
+		if pos, ok := idx[pointer]; ok {
+			return pos
+		}
+
+		i := strings.LastIndex(pointer, "/")
+		if i < 0 {
+			return idx[""]
+		}
+
+		pointer = pointer[:i]
+	}
+}
+
+// escapePointer escapes a key as a token of a JSON pointer (RFC 6901).
+func escapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// snippet returns the lines of the source around the position, with a caret under its column.
+func snippet(lines []string, pos Position) string {
+	if pos.Line < 1 || pos.Line > len(lines) {
+		return ""
+	}
+
+	first := max(pos.Line-snippetContext, 1)
+	last := min(pos.Line+snippetContext, len(lines))
+	width := len(strconv.Itoa(last))
+
+	var sb strings.Builder
+
+	for n := first; n <= last; n++ {
+		fmt.Fprintf(&sb, "%*d | %s\n", width, n, lines[n-1])
+
+		if n == pos.Line {
+			fmt.Fprintf(&sb, "%*s | %s^\n", width, "", strings.Repeat(" ", max(pos.Column-1, 0)))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/sighupio/furyctl/internal/flags"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolURI = "https://github.com/sighupio/furyctl"
+)
+
+// The subset of SARIF (Static Analysis Results Interchange Format) that editors and CI systems read to
+// annotate a file.
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+
+	sarifRule struct {
+		ID string `json:"id"`
+	}
+
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+
+	sarifRegion struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+	}
+)
+
+// SARIF returns the diagnostics as a SARIF log, with a rule for each keyword.
+func SARIF(diags []Diagnostic, furyctlVersion string) ([]byte, error) {
+	keywords := map[string]bool{}
+	results := make([]sarifResult, 0, len(diags))
+
+	for _, d := range diags {
+		keywords[d.Keyword] = true
+
+		message := d.Message
+		if d.Path != "" {
+			message = d.Path + ": " + message
+		}
+
+		loc := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(d.File)},
+		}}
+
+		if d.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+		}
+
+		level := "error"
+		if d.Severity == flags.ValidationSeverityWarning {
+			level = "warning"
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    d.Keyword,
+			Level:     level,
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	rules := make([]sarifRule, 0, len(keywords))
+
+	for k := range keywords {
+		rules = append(rules, sarifRule{ID: k})
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	out, err := json.MarshalIndent(sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "furyctl",
+				Version:        furyctlVersion,
+				InformationURI: sarifToolURI,
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error while marshalling sarif log: %w", err)
+	}
+
+	return out, nil
+}
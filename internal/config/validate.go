@@ -101,7 +101,8 @@ func createNewEmptyConfigFile(path string) (*os.File, error) {
 	return out, nil
 }
 
-// Validate the furyctl.yaml file using preprocessing approach to handle flags section.
+// Validate the furyctl.yaml file using preprocessing approach to handle flags section. The violations of
+// the schema and of the extra schema rules are a *ValidationError, with the position of each one in the file.
 func Validate(path, repoPath string) error {
 	miniConf, err := loadFromFile(path)
 	if err != nil {
@@ -144,15 +145,18 @@ func Validate(path, repoPath string) error {
 		return fmt.Errorf("error expanding dynamic values: %w", err)
 	}
 
+	// The expanded configuration has no positions: the violations are located in the source file.
+	loc := newLocator(path)
+
 	// Validate expanded configuration against the schema.
 	if err = schema.Validate(expandedConf); err != nil {
-		return fmt.Errorf("error while validating against schema: %w", err)
+		return fmt.Errorf("error while validating against schema: %w", loc.schemaError(err))
 	}
 
 	// Run additional schema validation rules.
 	esv := apis.NewExtraSchemaValidatorFactory(miniConf.APIVersion, miniConf.Kind)
 	if err = esv.Validate(path); err != nil {
-		return fmt.Errorf("error while validating against extra schema rules: %w", err)
+		return fmt.Errorf("error while validating against extra schema rules: %w", loc.extraSchemaError(err))
 	}
 
 	// Validate configuration between kfd.yaml and furyctl.yaml files for Terraform/OpenTofu.
@@ -184,6 +188,22 @@ func ValidatePKI(path string) error {
 	return nil
 }
 
+// Render returns the furyctl.yaml file at path as furyctl reads it: without the flags section, and with
+// the dynamic values expanded.
+func Render(path string) (map[string]any, error) {
+	rawConf, err := yamlx.FromFileV3[map[string]any](path)
+	if err != nil {
+		return nil, err
+	}
+
+	renderedConf, err := expandDynamicValues(createCleanConfigForSchemaValidation(rawConf), filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("error expanding dynamic values: %w", err)
+	}
+
+	return renderedConf, nil
+}
+
 // checkSchemaSupportsFlags determines if the schema includes support for the flags field.
 // This allows furyctl to work with both old schemas (without flags) and new schemas (with flags).
 func checkSchemaSupportsFlags(schemaPath string) bool {
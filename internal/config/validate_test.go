@@ -0,0 +1,243 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package config //nolint:testpackage // exercises the unexported location of the extra schema violations.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/compose"
+	"github.com/sighupio/furyctl/internal/policy"
+)
+
+const testSchema = `{
+  "type": "object",
+  "properties": {
+    "spec": {
+      "type": "object",
+      "additionalProperties": false,
+      "required": ["distributionVersion", "distribution"],
+      "properties": {
+        "distributionVersion": {"type": "string"},
+        "secret": {"type": "string", "minLength": 10},
+        "distribution": {
+          "type": "object",
+          "properties": {
+            "modules": {
+              "type": "object",
+              "properties": {
+                "ingress": {
+                  "type": "object",
+                  "required": ["baseDomain"],
+                  "properties": {
+                    "nginx": {
+                      "type": "object",
+                      "properties": {"type": {"enum": ["none", "single", "dual"]}}
+                    }
+                  }
+                }
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+const testConfig = `apiVersion: kfd.sighup.io/v1alpha2
+kind: KFDDistribution
+metadata:
+  name: test
+spec:
+  distributionVersion: v1.31.0
+  secret: "{file://./secret.txt}"
+  unknown: true
+  distribution:
+    modules:
+      ingress:
+        nginx:
+          type: triple
+`
+
+// writeDistribution writes a distribution with the schema of the KFDDistribution kind, and the
+// configuration next to the file of its dynamic value.
+func writeDistribution(t *testing.T) (string, string) {
+	t.Helper()
+
+	repo := t.TempDir()
+	schemas := filepath.Join(repo, "schemas", "public")
+
+	require.NoError(t, os.MkdirAll(schemas, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(schemas, "kfddistribution-kfd-v1alpha2.json"), []byte(testSchema), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "kfd.yaml"), []byte("version: v1.31.0\n"), 0o644))
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("short"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "furyctl.yaml"), []byte(testConfig), 0o644))
+
+	return repo, filepath.Join(dir, "furyctl.yaml")
+}
+
+func TestValidate_LocatesViolations(t *testing.T) {
+	t.Parallel()
+
+	repo, path := writeDistribution(t)
+
+	err := Validate(path, repo)
+	require.ErrorIs(t, err, ErrSchemaValidation)
+
+	diags := Diagnostics(path, err)
+
+	got := []string{}
+	for _, d := range diags {
+		got = append(got, fmt.Sprintf("%d:%d %s %s", d.Line, d.Column, d.Path, d.Keyword))
+	}
+
+	assert.Equal(t, []string{
+		// The expanded value of the dynamic value is at the position of its key.
+		"7:3 /spec/secret minLength",
+		// The property that is not allowed, not the object that has it.
+		"8:3 /spec/unknown additionalProperties",
+		// A missing property is at the position of its parent.
+		"11:7 /spec/distribution/modules/ingress required",
+		"13:11 /spec/distribution/modules/ingress/nginx/type enum",
+	}, got)
+
+	assert.Equal(t, "12 |         nginx:\n13 |           type: triple\n   |           ^\n", diags[3].Snippet)
+	assert.Contains(t, err.Error(), path+":13:11: /spec/distribution/modules/ingress/nginx/type: ")
+
+	out, err := SARIF(diags, "0.33.0")
+	require.NoError(t, err)
+
+	log := sarifLog{}
+	require.NoError(t, json.Unmarshal(out, &log))
+	require.Len(t, log.Runs[0].Results, 4)
+	assert.Equal(t, "enum", log.Runs[0].Results[3].RuleID)
+	assert.Equal(t, &sarifRegion{StartLine: 13, StartColumn: 11}, log.Runs[0].Results[3].Locations[0].PhysicalLocation.Region)
+	assert.Len(t, log.Runs[0].Tool.Driver.Rules, 4)
+}
+
+func TestValidate_LocatesViolationsOfAComposedConfiguration(t *testing.T) {
+	t.Parallel()
+
+	repo, base := writeDistribution(t)
+	source := filepath.Join(filepath.Dir(base), "cluster.yaml")
+
+	require.NoError(t, os.WriteFile(source, []byte(`extends: ./furyctl.yaml
+spec:
+  distribution:
+    modules:
+      ingress:
+        nginx:
+          type: quadruple
+`), 0o644))
+
+	path, err := compose.Prepare(source, "", t.TempDir())
+	require.NoError(t, err)
+
+	err = Validate(path, repo)
+	require.ErrorIs(t, err, ErrSchemaValidation)
+
+	diags := Diagnostics(path, err)
+
+	got := []string{}
+	for _, d := range diags {
+		got = append(got, fmt.Sprintf("%s:%d:%d %s", filepath.Base(d.File), d.Line, d.Column, d.Keyword))
+	}
+
+	// The violations are in the files that the values come from, not in the composed configuration.
+	assert.Equal(t, []string{
+		"cluster.yaml:5:7 required",
+		"cluster.yaml:7:11 enum",
+		"furyctl.yaml:7:3 minLength",
+		"furyctl.yaml:8:3 additionalProperties",
+	}, got)
+
+	assert.Equal(t, "6 |         nginx:\n7 |           type: quadruple\n  |           ^\n", diags[1].Snippet)
+	assert.Contains(t, err.Error(), source+":7:11: /spec/distribution/modules/ingress/nginx/type: ")
+
+	// The other errors are in the configuration that the user wrote.
+	assert.Equal(t, source, Diagnostics(path, errors.New("cannot read"))[0].File)
+}
+
+func Test_extraSchemaError(t *testing.T) {
+	t.Parallel()
+
+	_, path := writeDistribution(t)
+
+	err := newLocator(path).extraSchemaError(errors.Join(
+		errors.New(".spec.distribution.modules.ingress.nginx.type is not supported"),
+		errors.New("element 0 is invalid"),
+	))
+	require.ErrorIs(t, err, ErrExtraSchema)
+
+	diags := Diagnostics(path, err)
+	require.Len(t, diags, 2)
+
+	// A message without a path of the configuration is at the start of the file.
+	assert.Equal(t, Position{Line: 1, Column: 1}, diags[0].Position)
+	assert.Equal(t, "", diags[0].Path)
+	assert.Equal(t, Position{Line: 13, Column: 11}, diags[1].Position)
+	assert.Equal(t, KeywordExtraSchema, diags[1].Keyword)
+
+	assert.Equal(t, "/spec/kubernetes/nodePools/0", configPathPointer("invalid size at .spec.kubernetes.nodePools[0]"))
+	assert.Equal(t, "/spec/kubernetes/nodeGroups/nodes", configPathPointer("see .spec.kubernetes.nodeGroups[].nodes or"))
+}
+
+func TestValidatePolicies(t *testing.T) {
+	t.Parallel()
+
+	_, path := writeDistribution(t)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(`rules:
+  - name: ingress-type
+    path: .spec.distribution.modules.ingress.nginx.type
+    config: config.spec.distribution.modules.ingress.nginx.type in ['single', 'dual']
+  - name: secret-length
+    severity: warning
+    path: .spec.secret
+    config: size(config.spec.secret) >= 10
+`), 0o644))
+
+	rls, err := policy.Load(dir)
+	require.NoError(t, err)
+
+	diags, err := ValidatePolicies(path, "KFDDistribution", rls)
+	require.ErrorIs(t, err, policy.ErrPolicyViolation)
+	require.Len(t, diags, 2)
+
+	// The warning is at the position of the dynamic value that expands to the value.
+	assert.Equal(t, "7:3 /spec/secret policy/secret-length warning", fmt.Sprintf("%d:%d %s %s %s",
+		diags[0].Line, diags[0].Column, diags[0].Path, diags[0].Keyword, diags[0].Severity))
+	assert.Equal(t, Position{Line: 13, Column: 11}, diags[1].Position)
+
+	// Only the fatal violations fail the validation.
+	fatal := Diagnostics(path, err)
+	require.Len(t, fatal, 1)
+	assert.Equal(t, KeywordPolicy+"ingress-type", fatal[0].Keyword)
+
+	out, err := SARIF(diags, "0.33.0")
+	require.NoError(t, err)
+
+	log := sarifLog{}
+	require.NoError(t, json.Unmarshal(out, &log))
+	assert.Equal(t, "warning", log.Runs[0].Results[0].Level)
+	assert.Equal(t, "error", log.Runs[0].Results[1].Level)
+
+	diags, err = ValidatePolicies(path, "KFDDistribution", nil)
+	require.NoError(t, err)
+	assert.Empty(t, diags)
+}
@@ -0,0 +1,450 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package drift
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/tool/helmfile"
+	"github.com/sighupio/furyctl/internal/tool/kubectl"
+	"github.com/sighupio/furyctl/internal/tool/kustomize"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+// handManagerPrefix is the prefix of the field managers of kubectl: an object that one of them manages
+// was created or edited by hand, the controllers have their own managers.
+const handManagerPrefix = "kubectl"
+
+var ErrUnsupportedPhase = errors.New("drift is supported for the distribution and plugins phases only")
+
+// Detector compares the manifests that a dry run of the apply rendered with the live objects of the
+// cluster. It writes the manifests that it builds in its work folder.
+type Detector struct {
+	workDir string
+}
+
+func NewDetector(workDir string) *Detector {
+	return &Detector{workDir: workDir}
+}
+
+// build is a set of manifests that the apply applies at once, with the module of each object.
+type build struct {
+	path    string
+	objects []plan.ManifestChange
+	modules map[string]string
+	module  string
+}
+
+func (b build) moduleOf(obj plan.ManifestChange) string {
+	if module, ok := b.modules[key(obj)]; ok {
+		return module
+	}
+
+	return b.module
+}
+
+// Detect compares the manifests that the phase rendered with the cluster and records the resources
+// that drifted in the report.
+func (d *Detector) Detect(report *Report, rendered plan.RenderedPhase) error {
+	var (
+		builds []build
+		err    error
+	)
+
+	switch rendered.Phase {
+	case cluster.OperationPhaseDistribution:
+		builds, err = d.buildDistribution(rendered)
+
+	case cluster.OperationPhasePlugins:
+		builds, err = d.buildPlugins(rendered)
+
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedPhase, rendered.Phase)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	kubeRunner := kubectl.NewRunner(execx.NewStdExecutor(), kubectl.Paths{
+		Kubectl: rendered.KubectlPath,
+		WorkDir: d.workDir,
+	}, true, true, false)
+
+	for _, b := range builds {
+		resources, err := d.compare(kubeRunner, b)
+		if err != nil {
+			return err
+		}
+
+		for _, module := range b.modules {
+			report.AddModule(module)
+		}
+
+		report.AddModule(b.module)
+		report.Add(resources...)
+	}
+
+	return nil
+}
+
+// buildDistribution builds the kustomization of the distribution as the apply does, and each of the
+// modules it lists on its own, to know which module renders each object.
+func (d *Detector) buildDistribution(rendered plan.RenderedPhase) ([]build, error) {
+	kustomizeRunner := kustomize.NewRunner(execx.NewStdExecutor(), kustomize.Paths{
+		Kustomize: rendered.KustomizePath,
+		WorkDir:   rendered.Dir,
+	})
+
+	b, err := d.kustomizeBuild(kustomizeRunner, ".", cluster.OperationPhaseDistribution)
+	if err != nil {
+		return nil, err
+	}
+
+	b.module = DistributionModule
+	b.modules = map[string]string{}
+
+	kustomization := struct {
+		Resources []string `yaml:"resources"`
+	}{}
+
+	content, err := os.ReadFile(filepath.Join(rendered.Dir, "kustomization.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("error while reading distribution kustomization: %w", err)
+	}
+
+	if err := yamlx.UnmarshalV3(content, &kustomization); err != nil {
+		return nil, fmt.Errorf("error while parsing distribution kustomization: %w", err)
+	}
+
+	for _, module := range kustomization.Resources {
+		if !isKustomization(filepath.Join(rendered.Dir, module)) {
+			continue
+		}
+
+		mb, err := d.kustomizeBuild(kustomizeRunner, module, cluster.OperationPhaseDistribution+"-"+filepath.Base(module))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, obj := range mb.objects {
+			b.modules[key(obj)] = filepath.Base(module)
+		}
+	}
+
+	return []build{b}, nil
+}
+
+// buildPlugins renders the helm releases with helmfile and builds the kustomize plugins.
+func (d *Detector) buildPlugins(rendered plan.RenderedPhase) ([]build, error) {
+	builds := []build{}
+
+	if rendered.HelmReleases {
+		helmfileRunner := helmfile.NewRunner(execx.NewStdExecutor(), helmfile.Paths{
+			Helmfile: rendered.HelmfilePath,
+			WorkDir:  rendered.Dir,
+		})
+
+		out, err := helmfileRunner.Template()
+		if err != nil {
+			return nil, fmt.Errorf("error while rendering helm plugins: %w", err)
+		}
+
+		b := build{
+			path:   filepath.Join(d.workDir, "plugins-helm.yaml"),
+			module: PluginsModule,
+		}
+
+		if err := iox.WriteFile(b.path, []byte(out)); err != nil {
+			return nil, fmt.Errorf("error while writing helm plugins manifests: %w", err)
+		}
+
+		if b.objects, err = plan.ParseManifests([]byte(out)); err != nil {
+			return nil, err
+		}
+
+		builds = append(builds, b)
+	}
+
+	kustomizeRunner := kustomize.NewRunner(execx.NewStdExecutor(), kustomize.Paths{
+		Kustomize: rendered.KustomizePath,
+		WorkDir:   rendered.Dir,
+	})
+
+	for _, folder := range rendered.Kustomizations {
+		module := PluginsModule + "/" + filepath.Base(folder)
+
+		b, err := d.kustomizeBuild(kustomizeRunner, folder, strings.ReplaceAll(module, "/", "-"))
+		if err != nil {
+			return nil, err
+		}
+
+		b.module = module
+		builds = append(builds, b)
+	}
+
+	return builds, nil
+}
+
+func (d *Detector) kustomizeBuild(runner *kustomize.Runner, kustomizationPath, name string) (build, error) {
+	b := build{path: filepath.Join(d.workDir, name+".yaml")}
+
+	if err := runner.Build(kustomizationPath, b.path); err != nil {
+		return build{}, fmt.Errorf("error while building %s manifests: %w", name, err)
+	}
+
+	content, err := os.ReadFile(b.path)
+	if err != nil {
+		return build{}, fmt.Errorf("error while reading %s manifests: %w", name, err)
+	}
+
+	if b.objects, err = plan.ParseManifests(content); err != nil {
+		return build{}, err
+	}
+
+	return b, nil
+}
+
+// compare diffs the manifests with the cluster with a server-side dry run, so the fields that the API
+// server defaults and the fields that other managers own do not show as changes, and looks for the
+// objects added next to the rendered ones.
+func (d *Detector) compare(kubeRunner *kubectl.Runner, b build) ([]Resource, error) {
+	resources := []Resource{}
+
+	if len(b.objects) == 0 {
+		return resources, nil
+	}
+
+	out, err := kubeRunner.Diff(b.path, "--server-side", "--force-conflicts")
+	if err != nil {
+		return nil, fmt.Errorf("error while comparing %s manifests with the cluster: %w", b.module, err)
+	}
+
+	changes := plan.ParseKubectlDiff(out, b.objects)
+	diffs := splitDiff(out)
+
+	for i, change := range changes {
+		res := Resource{
+			Module:     b.moduleOf(change),
+			Status:     StatusModified,
+			APIVersion: change.APIVersion,
+			Kind:       change.Kind,
+			Namespace:  change.Namespace,
+			Name:       change.Name,
+		}
+
+		if change.Action == plan.ManifestActionCreate {
+			res.Status = StatusMissing
+		} else if i < len(diffs) {
+			res.Diff = diffs[i]
+		}
+
+		resources = append(resources, res)
+	}
+
+	extra, err := d.extra(kubeRunner, b)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(resources, extra...), nil
+}
+
+type liveObject struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		OwnerReferences []json.RawMessage `json:"ownerReferences"`
+		ManagedFields   []struct {
+			Manager string `json:"manager"`
+		} `json:"managedFields"`
+	} `json:"metadata"`
+}
+
+// extra returns the objects in the namespaces that the manifests create, of the kinds that the manifests
+// contain there, that the manifests do not contain and that were created or edited by hand. The objects
+// that a controller creates have an owner or their own field manager.
+func (*Detector) extra(kubeRunner *kubectl.Runner, b build) ([]Resource, error) {
+	rendered := map[string]bool{}
+	// The extra objects belong to the module that creates their namespace.
+	namespaces := map[string]string{}
+
+	for _, obj := range b.objects {
+		rendered[key(obj)] = true
+
+		if obj.APIVersion == "v1" && obj.Kind == "Namespace" {
+			namespaces[obj.Name] = b.moduleOf(obj)
+		}
+	}
+
+	kinds := map[string]map[string]bool{}
+
+	for _, obj := range b.objects {
+		if _, ok := namespaces[obj.Namespace]; !ok {
+			continue
+		}
+
+		if kinds[obj.Namespace] == nil {
+			kinds[obj.Namespace] = map[string]bool{}
+		}
+
+		kinds[obj.Namespace][kindArg(obj)] = true
+	}
+
+	resources := []Resource{}
+
+	for _, ns := range sortedKeys(kinds) {
+		for _, kind := range sortedKeys(kinds[ns]) {
+			out, err := kubeRunner.Get(true, ns, kind, "--show-managed-fields", "-o", "json")
+			if err != nil {
+				// The kind can be missing, for example a CRD that is not installed yet.
+				logrus.Debugf("Skipping the extra %s objects in namespace %s: %v", kind, ns, err)
+
+				continue
+			}
+
+			list := struct {
+				Items []liveObject `json:"items"`
+			}{}
+
+			// kubectl can print warnings before the list.
+			if i := strings.Index(out, "{"); i > 0 {
+				out = out[i:]
+			}
+
+			if err := json.Unmarshal([]byte(out), &list); err != nil {
+				return nil, fmt.Errorf("error while parsing the %s objects in namespace %s: %w", kind, ns, err)
+			}
+
+			for _, item := range list.Items {
+				obj := plan.ManifestChange{
+					APIVersion: item.APIVersion,
+					Kind:       item.Kind,
+					Namespace:  item.Metadata.Namespace,
+					Name:       item.Metadata.Name,
+				}
+
+				if rendered[key(obj)] || !handManaged(item) {
+					continue
+				}
+
+				resources = append(resources, Resource{
+					Module:     namespaces[ns],
+					Status:     StatusExtra,
+					APIVersion: obj.APIVersion,
+					Kind:       obj.Kind,
+					Namespace:  obj.Namespace,
+					Name:       obj.Name,
+				})
+			}
+		}
+	}
+
+	return resources, nil
+}
+
+// handManaged tells whether the object was created or edited by hand and not by a controller.
+func handManaged(obj liveObject) bool {
+	if len(obj.Metadata.OwnerReferences) > 0 {
+		return false
+	}
+
+	return slices.ContainsFunc(obj.Metadata.ManagedFields, func(f struct {
+		Manager string `json:"manager"`
+	},
+	) bool {
+		return strings.HasPrefix(f.Manager, handManagerPrefix)
+	})
+}
+
+// splitDiff splits the output of kubectl diff into the diff of each object, in the order of the output.
+func splitDiff(out string) []string {
+	diffs := []string{}
+
+	var current *strings.Builder
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<20) //nolint:mnd // long lines in ConfigMaps.
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "diff ") {
+			if current != nil {
+				diffs = append(diffs, current.String())
+			}
+
+			current = &strings.Builder{}
+
+			continue
+		}
+
+		if current != nil {
+			current.WriteString(line + "\n")
+		}
+	}
+
+	if current != nil {
+		diffs = append(diffs, current.String())
+	}
+
+	return diffs
+}
+
+// key identifies an object, the group of the API is enough.
+func key(obj plan.ManifestChange) string {
+	group, _, _ := strings.Cut(obj.APIVersion, "/")
+	if !strings.Contains(obj.APIVersion, "/") {
+		group = ""
+	}
+
+	return strings.Join([]string{group, obj.Kind, obj.Namespace, obj.Name}, "|")
+}
+
+// kindArg is the name of the kind of the object for kubectl get, with its version and group.
+func kindArg(obj plan.ManifestChange) string {
+	group, version, found := strings.Cut(obj.APIVersion, "/")
+	if !found {
+		return strings.ToLower(obj.Kind)
+	}
+
+	return strings.ToLower(obj.Kind) + "." + version + "." + group
+}
+
+func isKustomization(dir string) bool {
+	for _, name := range []string{"kustomization.yaml", "kustomization.yml", "Kustomization"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package drift_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/drift"
+	"github.com/sighupio/furyctl/internal/plan"
+)
+
+const (
+	ingressManifests = `apiVersion: v1
+kind: Namespace
+metadata:
+  name: ingress-nginx
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: controller
+  namespace: ingress-nginx
+`
+	monitoringManifests = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: prometheus-config
+  namespace: monitoring
+`
+	clusterRoleManifests = `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: sighup-admin
+`
+
+	kubectlDiffOutput = `diff -u -N /tmp/LIVE-1/apps.v1.Deployment.ingress-nginx.controller /tmp/MERGED-2/apps.v1.Deployment.ingress-nginx.controller
+--- /tmp/LIVE-1/apps.v1.Deployment.ingress-nginx.controller
++++ /tmp/MERGED-2/apps.v1.Deployment.ingress-nginx.controller
+@@ -5,7 +5,7 @@
+ spec:
+-  replicas: 1
++  replicas: 2
+diff -u -N /tmp/LIVE-1/v1.ConfigMap.monitoring.prometheus-config /tmp/MERGED-2/v1.ConfigMap.monitoring.prometheus-config
+--- /tmp/LIVE-1/v1.ConfigMap.monitoring.prometheus-config
++++ /tmp/MERGED-2/v1.ConfigMap.monitoring.prometheus-config
+@@ -0,0 +1,5 @@
++apiVersion: v1
++kind: ConfigMap
+`
+
+	liveDeployments = `Warning: some deprecation
+{
+  "items": [
+    {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "controller", "namespace": "ingress-nginx",
+      "managedFields": [{"manager": "kubectl-client-side-apply"}]}},
+    {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "debug", "namespace": "ingress-nginx",
+      "managedFields": [{"manager": "kubectl-create"}]}},
+    {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "operator-made", "namespace": "ingress-nginx",
+      "managedFields": [{"manager": "operator"}]}},
+    {"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "owned", "namespace": "ingress-nginx",
+      "ownerReferences": [{"kind": "Something"}], "managedFields": [{"manager": "kubectl-edit"}]}}
+  ]
+}
+`
+)
+
+// writeFile writes the file and its folder.
+func writeFile(t *testing.T, path, content string, perm os.FileMode) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), perm))
+}
+
+// fakeTools writes a kustomize that copies the rendered.yaml of the kustomization folder, and a kubectl
+// that prints the diff and the live deployments.
+func fakeTools(t *testing.T, diff string) (string, string) {
+	t.Helper()
+
+	binDir := t.TempDir()
+	dataDir := t.TempDir()
+
+	writeFile(t, filepath.Join(dataDir, "diff.txt"), diff, 0o644)
+	writeFile(t, filepath.Join(dataDir, "deployments.json"), liveDeployments, 0o644)
+
+	kustomizePath := filepath.Join(binDir, "kustomize")
+	writeFile(t, kustomizePath, "#!/bin/sh\ncp \"$6/rendered.yaml\" \"$5\"\n", 0o700)
+
+	kubectlPath := filepath.Join(binDir, "kubectl")
+	writeFile(t, kubectlPath, `#!/bin/sh
+case "$*" in
+  "diff --server-side --force-conflicts -f "*) cat "`+dataDir+`/diff.txt"; exit 1 ;;
+  "get -n ingress-nginx deployment.v1.apps --show-managed-fields -o json") cat "`+dataDir+`/deployments.json" ;;
+  *) echo "unexpected arguments: $*" >&2; exit 2 ;;
+esac
+`, 0o700)
+
+	return kustomizePath, kubectlPath
+}
+
+func TestDetector_DetectDistribution(t *testing.T) {
+	t.Parallel()
+
+	kustomizePath, kubectlPath := fakeTools(t, kubectlDiffOutput)
+
+	manifestsDir := t.TempDir()
+	writeFile(t, filepath.Join(manifestsDir, "kustomization.yaml"), "resources:\n  - ingress\n  - monitoring\n  - cluster-role.yaml\n", 0o644)
+	writeFile(t, filepath.Join(manifestsDir, "rendered.yaml"), ingressManifests+"---\n"+monitoringManifests+"---\n"+clusterRoleManifests, 0o644)
+	writeFile(t, filepath.Join(manifestsDir, "ingress", "kustomization.yaml"), "resources: []\n", 0o644)
+	writeFile(t, filepath.Join(manifestsDir, "ingress", "rendered.yaml"), ingressManifests, 0o644)
+	writeFile(t, filepath.Join(manifestsDir, "monitoring", "kustomization.yaml"), "resources: []\n", 0o644)
+	writeFile(t, filepath.Join(manifestsDir, "monitoring", "rendered.yaml"), monitoringManifests, 0o644)
+	writeFile(t, filepath.Join(manifestsDir, "cluster-role.yaml"), clusterRoleManifests, 0o644)
+
+	report := drift.NewReport("test")
+
+	err := drift.NewDetector(t.TempDir()).Detect(report, plan.RenderedPhase{
+		Phase:         "distribution",
+		Dir:           manifestsDir,
+		KubectlPath:   kubectlPath,
+		KustomizePath: kustomizePath,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"distribution", "ingress", "monitoring"}, report.Modules)
+	require.Len(t, report.Resources, 3)
+
+	assert.Equal(t, drift.Resource{
+		Module:     "ingress",
+		Status:     drift.StatusModified,
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "ingress-nginx",
+		Name:       "controller",
+		Diff: "--- /tmp/LIVE-1/apps.v1.Deployment.ingress-nginx.controller\n" +
+			"+++ /tmp/MERGED-2/apps.v1.Deployment.ingress-nginx.controller\n" +
+			"@@ -5,7 +5,7 @@\n spec:\n-  replicas: 1\n+  replicas: 2\n",
+	}, report.Resources[0])
+
+	assert.Equal(t, drift.Resource{
+		Module:     "ingress",
+		Status:     drift.StatusExtra,
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Namespace:  "ingress-nginx",
+		Name:       "debug",
+	}, report.Resources[1])
+
+	assert.Equal(t, drift.Resource{
+		Module:     "monitoring",
+		Status:     drift.StatusMissing,
+		APIVersion: "v1",
+		Kind:       "ConfigMap",
+		Namespace:  "monitoring",
+		Name:       "prometheus-config",
+	}, report.Resources[2])
+}
+
+func TestDetector_DetectPlugins(t *testing.T) {
+	t.Parallel()
+
+	// The diff of the deployment only.
+	kustomizePath, kubectlPath := fakeTools(t, kubectlDiffOutput[:strings.Index(kubectlDiffOutput, "diff -u -N /tmp/LIVE-1/v1.ConfigMap")])
+
+	pluginsDir := t.TempDir()
+	writeFile(t, filepath.Join(pluginsDir, "kustomize", "ingress", "rendered.yaml"), ingressManifests, 0o644)
+
+	report := drift.NewReport("test")
+
+	err := drift.NewDetector(t.TempDir()).Detect(report, plan.RenderedPhase{
+		Phase:          "plugins",
+		Dir:            pluginsDir,
+		KubectlPath:    kubectlPath,
+		KustomizePath:  kustomizePath,
+		Kustomizations: []string{"kustomize/ingress"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"plugins/ingress"}, report.Modules)
+	require.Len(t, report.Resources, 2)
+
+	assert.Equal(t, drift.StatusModified, report.Resources[0].Status)
+	assert.Equal(t, "plugins/ingress", report.Resources[0].Module)
+	assert.Equal(t, drift.StatusExtra, report.Resources[1].Status)
+	assert.Equal(t, "debug", report.Resources[1].Name)
+}
+
+func TestDetector_DetectUnsupportedPhase(t *testing.T) {
+	t.Parallel()
+
+	err := drift.NewDetector(t.TempDir()).Detect(drift.NewReport("test"), plan.RenderedPhase{Phase: "kubernetes"})
+	require.ErrorIs(t, err, drift.ErrUnsupportedPhase)
+}
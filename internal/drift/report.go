@@ -0,0 +1,155 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package drift finds the changes made to a cluster out of band: the objects that the distribution and
+// the plugins manage and that were deleted or edited in the cluster, and the objects that were added by
+// hand next to them.
+package drift
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const (
+	// StatusMissing is an object that the apply renders and that is not in the cluster.
+	StatusMissing = "missing"
+	// StatusModified is an object whose live version differs from the rendered one.
+	StatusModified = "modified"
+	// StatusExtra is an object that the apply does not render, added by hand next to the rendered ones.
+	StatusExtra = "extra"
+
+	// DistributionModule is the module of the objects that no module of the distribution renders on its own.
+	DistributionModule = "distribution"
+	// PluginsModule is the module of the objects of the helm plugins, the kustomize plugins are modules
+	// named after their folder.
+	PluginsModule = "plugins"
+)
+
+// Resource is an object of the cluster that drifted from the rendered manifests.
+type Resource struct {
+	Module     string `json:"module"`
+	Status     string `json:"status"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	// Diff is the output of kubectl diff for the modified objects.
+	Diff string `json:"diff,omitempty"`
+}
+
+func (r Resource) String() string {
+	if r.Namespace == "" {
+		return fmt.Sprintf("%s %s", r.Kind, r.Name)
+	}
+
+	return fmt.Sprintf("%s %s/%s", r.Kind, r.Namespace, r.Name)
+}
+
+// Report is the result of a drift check.
+type Report struct {
+	Cluster   string     `json:"cluster"`
+	CheckedAt time.Time  `json:"checkedAt"`
+	Modules   []string   `json:"modules"`
+	Resources []Resource `json:"resources"`
+}
+
+func NewReport(cluster string) *Report {
+	return &Report{
+		Cluster:   cluster,
+		CheckedAt: time.Now().UTC(),
+		Modules:   []string{},
+		Resources: []Resource{},
+	}
+}
+
+// AddModule records that the module was checked, even if nothing drifted.
+func (r *Report) AddModule(module string) {
+	if !slices.Contains(r.Modules, module) {
+		r.Modules = append(r.Modules, module)
+		slices.Sort(r.Modules)
+	}
+}
+
+// Add records the resources that drifted, sorted by module.
+func (r *Report) Add(resources ...Resource) {
+	for _, res := range resources {
+		r.AddModule(res.Module)
+	}
+
+	r.Resources = append(r.Resources, resources...)
+
+	slices.SortStableFunc(r.Resources, func(a, b Resource) int {
+		return strings.Compare(a.Module, b.Module)
+	})
+}
+
+// HasDrift tells whether any resource drifted.
+func (r *Report) HasDrift() bool {
+	return len(r.Resources) > 0
+}
+
+// JSON returns the indented JSON representation of the report.
+func (r *Report) JSON() ([]byte, error) {
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error while marshalling drift report: %w", err)
+	}
+
+	return append(out, '\n'), nil
+}
+
+// String renders the report for humans, without the diffs.
+func (r *Report) String() string {
+	return r.Text(false)
+}
+
+// Text renders the report for humans, with the diffs of the modified resources if showDiff is set.
+func (r *Report) Text(showDiff bool) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Drift of cluster %s, checked at %s\n", r.Cluster, r.CheckedAt.Format(time.RFC3339))
+
+	if !r.HasDrift() {
+		fmt.Fprintf(&b, "\nNo drift. The cluster matches the rendered manifests of %s.\n", strings.Join(r.Modules, ", "))
+
+		return b.String()
+	}
+
+	counts := lo.CountValuesBy(r.Resources, func(res Resource) string {
+		return res.Status
+	})
+
+	fmt.Fprintf(&b, "\n%d resources drifted: %d missing, %d modified, %d extra\n",
+		len(r.Resources), counts[StatusMissing], counts[StatusModified], counts[StatusExtra])
+
+	for _, module := range r.Modules {
+		resources := lo.Filter(r.Resources, func(res Resource, _ int) bool {
+			return res.Module == module
+		})
+
+		if len(resources) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\nModule %s:\n", module)
+
+		for _, res := range resources {
+			fmt.Fprintf(&b, "  %-8s %s\n", res.Status, res)
+
+			if showDiff && res.Diff != "" {
+				for line := range strings.SplitSeq(strings.TrimRight(res.Diff, "\n"), "\n") {
+					fmt.Fprintf(&b, "      %s\n", line)
+				}
+			}
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package drift_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/drift"
+)
+
+func TestReport_NoDrift(t *testing.T) {
+	t.Parallel()
+
+	r := drift.NewReport("test")
+	r.AddModule("monitoring")
+	r.AddModule("ingress")
+	r.Add()
+
+	assert.False(t, r.HasDrift())
+	assert.Equal(t, []string{"ingress", "monitoring"}, r.Modules)
+	assert.Contains(t, r.String(), "No drift. The cluster matches the rendered manifests of ingress, monitoring.")
+}
+
+func TestReport_Text(t *testing.T) {
+	t.Parallel()
+
+	r := drift.NewReport("test")
+	r.Add(
+		drift.Resource{Module: "monitoring", Status: drift.StatusMissing, APIVersion: "v1", Kind: "ConfigMap", Namespace: "monitoring", Name: "prometheus-config"},
+		drift.Resource{Module: "ingress", Status: drift.StatusModified, APIVersion: "apps/v1", Kind: "Deployment", Namespace: "ingress-nginx", Name: "controller", Diff: "-  replicas: 1\n+  replicas: 2\n"},
+		drift.Resource{Module: "distribution", Status: drift.StatusExtra, APIVersion: "rbac.authorization.k8s.io/v1", Kind: "ClusterRole", Name: "debug"},
+	)
+
+	assert.True(t, r.HasDrift())
+
+	text := r.Text(false)
+	assert.Contains(t, text, "3 resources drifted: 1 missing, 1 modified, 1 extra")
+	assert.Contains(t, text, "Module ingress:\n  modified Deployment ingress-nginx/controller\n")
+	assert.Contains(t, text, "Module monitoring:\n  missing  ConfigMap monitoring/prometheus-config\n")
+	assert.Contains(t, text, "Module distribution:\n  extra    ClusterRole debug\n")
+	assert.NotContains(t, text, "replicas")
+
+	assert.Contains(t, r.Text(true), "  modified Deployment ingress-nginx/controller\n      -  replicas: 1\n      +  replicas: 2\n")
+}
+
+func TestReport_JSON(t *testing.T) {
+	t.Parallel()
+
+	r := drift.NewReport("test")
+	r.Add(drift.Resource{Module: "ingress", Status: drift.StatusMissing, APIVersion: "v1", Kind: "Service", Namespace: "ingress-nginx", Name: "controller"})
+
+	out, err := r.JSON()
+	require.NoError(t, err)
+
+	parsed := drift.Report{}
+	require.NoError(t, json.Unmarshal(out, &parsed))
+
+	assert.Equal(t, "test", parsed.Cluster)
+	assert.Equal(t, []string{"ingress"}, parsed.Modules)
+	assert.Equal(t, r.Resources, parsed.Resources)
+}
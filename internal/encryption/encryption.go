@@ -0,0 +1,324 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package encryption seals the payloads that furyctl stores in the cluster with envelope encryption: each
+// payload is encrypted with its own random data key, and the data key is encrypted with the key of the
+// user, either an AES-256 key or an age X25519 identity.
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+const (
+	SchemeAES = "aes-256-gcm"
+	SchemeAge = "age"
+
+	// Prefix starts every sealed payload, what follows it is the JSON envelope.
+	Prefix = "furyctl-envelope/v1:"
+
+	keySize = 32
+
+	agePrivateKeyPrefix = "AGE-SECRET-KEY-"
+)
+
+var (
+	ErrInvalidKey  = errors.New("invalid encryption key, it must be an age identity or a base64 or hex encoded 32 bytes AES key")
+	ErrKeyRequired = errors.New("the payload is encrypted: set the key with --encryption-key-file or the " +
+		"FURYCTL_ENCRYPTION_KEY environment variable")
+	ErrWrongKey           = errors.New("the payload is encrypted with another key")
+	ErrInvalidEnvelope    = errors.New("invalid encrypted payload")
+	ErrKeyFileAndEnvBoth  = errors.New("the encryption key is set both with a file and with an environment variable")
+	errCiphertextTooShort = errors.New("ciphertext too short")
+)
+
+// Key encrypts and decrypts the data keys of the envelopes.
+type Key interface {
+	// ID identifies the key without disclosing it, the envelopes record it.
+	ID() string
+	Scheme() string
+
+	wrap(dataKey []byte) ([]byte, error)
+	unwrap(wrapped []byte) ([]byte, error)
+}
+
+type envelope struct {
+	Scheme     string `json:"scheme"`
+	KeyID      string `json:"keyId"`
+	WrappedKey string `json:"wrappedKey"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// LoadKey reads the key from the file, or from the content of the environment variable. Without both,
+// it returns a nil key.
+func LoadKey(keyFile, keyEnv string) (Key, error) {
+	switch {
+	case keyFile != "" && keyEnv != "":
+		return nil, ErrKeyFileAndEnvBoth
+
+	case keyFile != "":
+		data, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading encryption key file: %w", err)
+		}
+
+		return ParseKey(data)
+
+	case keyEnv != "":
+		return ParseKey([]byte(keyEnv))
+
+	default:
+		return nil, nil //nolint:nilnil // without a key the payloads are stored in clear.
+	}
+}
+
+// ParseKey parses an age identity, as written by age-keygen, or a base64 or hex encoded AES-256 key.
+func ParseKey(data []byte) (Key, error) {
+	text := strings.TrimSpace(string(data))
+
+	if strings.Contains(text, agePrivateKeyPrefix) {
+		identities, err := age.ParseIdentities(strings.NewReader(text))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+		}
+
+		for _, id := range identities {
+			if x, ok := id.(*age.X25519Identity); ok {
+				return &ageKey{identity: x}, nil
+			}
+		}
+
+		return nil, ErrInvalidKey
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == keySize {
+		return &aesKey{key: key}, nil
+	}
+
+	if key, err := hex.DecodeString(text); err == nil && len(key) == keySize {
+		return &aesKey{key: key}, nil
+	}
+
+	return nil, ErrInvalidKey
+}
+
+// GenerateAESKey returns a new random AES-256 key, base64 encoded.
+func GenerateAESKey() (string, error) {
+	key := make([]byte, keySize)
+
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return "", fmt.Errorf("error while generating encryption key: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsSealed tells whether the payload is an envelope.
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Prefix))
+}
+
+// Seal encrypts the payload into an envelope. With a nil key, it returns the payload as is.
+func Seal(k Key, plaintext []byte) ([]byte, error) {
+	if k == nil {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, keySize)
+
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, fmt.Errorf("error while generating data key: %w", err)
+	}
+
+	ciphertext, err := gcmSeal(dataKey, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := k.wrap(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("error while encrypting data key: %w", err)
+	}
+
+	out, err := json.Marshal(envelope{
+		Scheme:     k.Scheme(),
+		KeyID:      k.ID(),
+		WrappedKey: base64.StdEncoding.EncodeToString(wrapped),
+		Ciphertext: base64.StdEncoding.EncodeToString(ciphertext),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while marshalling encrypted payload: %w", err)
+	}
+
+	return append([]byte(Prefix), out...), nil
+}
+
+// Open decrypts an envelope. A payload that is not an envelope is returned as is, so that the payloads
+// stored in clear by the previous versions can still be read.
+func Open(k Key, data []byte) ([]byte, error) {
+	if !IsSealed(data) {
+		return data, nil
+	}
+
+	if k == nil {
+		return nil, ErrKeyRequired
+	}
+
+	env := envelope{}
+	if err := json.Unmarshal(data[len(Prefix):], &env); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+
+	if env.Scheme != k.Scheme() || env.KeyID != k.ID() {
+		return nil, fmt.Errorf("%w: %s key %s", ErrWrongKey, env.Scheme, env.KeyID)
+	}
+
+	wrapped, err := base64.StdEncoding.DecodeString(env.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(env.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
+	}
+
+	dataKey, err := k.unwrap(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting data key: %w", err)
+	}
+
+	return gcmOpen(dataKey, ciphertext)
+}
+
+func gcmSeal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("error while generating nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func gcmOpen(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, errCiphertextTooShort)
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting payload: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating cipher: %w", err)
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating cipher: %w", err)
+	}
+
+	return gcm, nil
+}
+
+type aesKey struct {
+	key []byte
+}
+
+func (k *aesKey) ID() string {
+	sum := sha256.Sum256(k.key)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+func (*aesKey) Scheme() string {
+	return SchemeAES
+}
+
+func (k *aesKey) wrap(dataKey []byte) ([]byte, error) {
+	return gcmSeal(k.key, dataKey)
+}
+
+func (k *aesKey) unwrap(wrapped []byte) ([]byte, error) {
+	return gcmOpen(k.key, wrapped)
+}
+
+type ageKey struct {
+	identity *age.X25519Identity
+}
+
+// ID is the public key of the identity.
+func (k *ageKey) ID() string {
+	return k.identity.Recipient().String()
+}
+
+func (*ageKey) Scheme() string {
+	return SchemeAge
+}
+
+func (k *ageKey) wrap(dataKey []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	w, err := age.Encrypt(&buf, k.identity.Recipient())
+	if err != nil {
+		return nil, fmt.Errorf("error while encrypting with age: %w", err)
+	}
+
+	if _, err := w.Write(dataKey); err != nil {
+		return nil, fmt.Errorf("error while encrypting with age: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error while encrypting with age: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (k *ageKey) unwrap(wrapped []byte) ([]byte, error) {
+	r, err := age.Decrypt(bytes.NewReader(wrapped), k.identity)
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting with age: %w", err)
+	}
+
+	dataKey, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting with age: %w", err)
+	}
+
+	return dataKey, nil
+}
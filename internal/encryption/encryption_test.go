@@ -0,0 +1,187 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package encryption_test
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/encryption"
+)
+
+func newAESKey(t *testing.T) encryption.Key {
+	t.Helper()
+
+	encoded, err := encryption.GenerateAESKey()
+	require.NoError(t, err)
+
+	k, err := encryption.ParseKey([]byte(encoded))
+	require.NoError(t, err)
+
+	return k
+}
+
+func newAgeKey(t *testing.T) encryption.Key {
+	t.Helper()
+
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	// The format of age-keygen, with the comments.
+	k, err := encryption.ParseKey([]byte("# created: 2026-10-16T10:00:00Z\n# public key: " +
+		id.Recipient().String() + "\n" + id.String() + "\n"))
+	require.NoError(t, err)
+
+	return k
+}
+
+func TestParseKey(t *testing.T) {
+	t.Parallel()
+
+	tcs := []struct {
+		desc       string
+		key        string
+		wantScheme string
+		wantErr    error
+	}{
+		{
+			desc:       "base64 AES key",
+			key:        "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8=\n",
+			wantScheme: encryption.SchemeAES,
+		},
+		{
+			desc:       "hex AES key",
+			key:        hex.EncodeToString([]byte("0123456789abcdef0123456789abcdef")),
+			wantScheme: encryption.SchemeAES,
+		},
+		{
+			desc:    "short AES key",
+			key:     "AAECAwQFBgcICQoLDA0ODw==",
+			wantErr: encryption.ErrInvalidKey,
+		},
+		{
+			desc:    "invalid age identity",
+			key:     "AGE-SECRET-KEY-1INVALID",
+			wantErr: encryption.ErrInvalidKey,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			k, err := encryption.ParseKey([]byte(tc.key))
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantScheme, k.Scheme())
+		})
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	t.Parallel()
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	key := "AAECAwQFBgcICQoLDA0ODxAREhMUFRYXGBkaGxwdHh8="
+
+	require.NoError(t, os.WriteFile(keyFile, []byte(key), 0o600))
+
+	fromFile, err := encryption.LoadKey(keyFile, "")
+	require.NoError(t, err)
+
+	fromEnv, err := encryption.LoadKey("", key)
+	require.NoError(t, err)
+
+	assert.Equal(t, fromFile.ID(), fromEnv.ID())
+
+	none, err := encryption.LoadKey("", "")
+	require.NoError(t, err)
+	assert.Nil(t, none)
+
+	_, err = encryption.LoadKey(keyFile, key)
+	require.ErrorIs(t, err, encryption.ErrKeyFileAndEnvBoth)
+}
+
+func TestSealOpen(t *testing.T) {
+	t.Parallel()
+
+	plaintext := []byte("spec:\n  oidc:\n    clientSecret: s3cr3t\n")
+
+	for _, k := range []encryption.Key{newAESKey(t), newAgeKey(t)} {
+		t.Run(k.Scheme(), func(t *testing.T) {
+			t.Parallel()
+
+			sealed, err := encryption.Seal(k, plaintext)
+			require.NoError(t, err)
+
+			assert.True(t, encryption.IsSealed(sealed))
+			assert.NotContains(t, string(sealed), "s3cr3t")
+
+			opened, err := encryption.Open(k, sealed)
+			require.NoError(t, err)
+			assert.Equal(t, plaintext, opened)
+
+			// Each payload has its own data key.
+			sealedAgain, err := encryption.Seal(k, plaintext)
+			require.NoError(t, err)
+			assert.NotEqual(t, sealed, sealedAgain)
+		})
+	}
+}
+
+func TestSeal_WithoutKey(t *testing.T) {
+	t.Parallel()
+
+	plaintext := []byte("kind: KFDDistribution\n")
+
+	sealed, err := encryption.Seal(nil, plaintext)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, sealed)
+}
+
+func TestOpen_Errors(t *testing.T) {
+	t.Parallel()
+
+	k := newAESKey(t)
+
+	sealed, err := encryption.Seal(k, []byte("config"))
+	require.NoError(t, err)
+
+	// A payload in clear is returned as is, with or without a key.
+	opened, err := encryption.Open(k, []byte("config"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("config"), opened)
+
+	_, err = encryption.Open(nil, sealed)
+	require.ErrorIs(t, err, encryption.ErrKeyRequired)
+
+	_, err = encryption.Open(newAESKey(t), sealed)
+	require.ErrorIs(t, err, encryption.ErrWrongKey)
+
+	_, err = encryption.Open(newAgeKey(t), sealed)
+	require.ErrorIs(t, err, encryption.ErrWrongKey)
+
+	_, err = encryption.Open(k, []byte(encryption.Prefix+"{"))
+	require.ErrorIs(t, err, encryption.ErrInvalidEnvelope)
+
+	tampered := strings.Replace(string(sealed), `"ciphertext":"`, `"ciphertext":"AAAA`, 1)
+
+	_, err = encryption.Open(k, []byte(tampered))
+	require.Error(t, err)
+}
@@ -15,10 +15,17 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 
+	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/compose"
+	"github.com/sighupio/furyctl/internal/encryption"
 	parserx "github.com/sighupio/furyctl/internal/parser"
+	"github.com/sighupio/furyctl/internal/state/backend"
 )
 
 // Static error definitions for linting compliance.
+// composedConfigKey is the viper key of the path of the composed configuration, next to the one of --config.
+const composedConfigKey = "composed-config"
+
 var (
 	ErrFlagsValidationFailed       = errors.New("flags validation failed")
 	ErrGlobalFlagsValidationFailed = errors.New("global flags validation failed")
@@ -192,6 +199,78 @@ func GetConfigPathFromViper() string {
 	return configPath
 }
 
+// GetComposedConfigPathFromViper gets the path of the configuration that the commands read: the composed one
+// when the configuration of --config extends other files or has overlays, the one of --config otherwise.
+func GetComposedConfigPathFromViper() string {
+	if composedPath := viper.GetString(composedConfigKey); composedPath != "" {
+		return composedPath
+	}
+
+	return viper.GetString("config")
+}
+
+// IsConfigComposed tells if the configuration of --config extends other files or has overlays, so that the
+// commands read a composed configuration instead of it.
+func IsConfigComposed() bool {
+	return viper.GetString(composedConfigKey) != ""
+}
+
+// GetStateBackendFromViper gets the backend where the stores keep the state of the cluster from viper. The
+// root command already made the state directory absolute.
+func GetStateBackendFromViper() backend.Config {
+	return backend.Config{
+		Type:       viper.GetString("state-backend"),
+		Dir:        viper.GetString("state-dir"),
+		S3Bucket:   viper.GetString("state-s3-bucket"),
+		S3Prefix:   viper.GetString("state-s3-prefix"),
+		S3Endpoint: viper.GetString("state-s3-endpoint"),
+		S3Region:   viper.GetString("state-s3-region"),
+	}
+}
+
+// GetEncryptionKeyFromViper loads the key that encrypts the configuration stored in the cluster. The key file
+// comes from the flags, the key itself only from the environment, so that it does not end up in the history.
+func GetEncryptionKeyFromViper() (encryption.Key, error) {
+	key, err := encryption.LoadKey(viper.GetString("encryption-key-file"), viper.GetString("encryption-key"))
+	if err != nil {
+		return nil, fmt.Errorf("error while loading the encryption key: %w", err)
+	}
+
+	return key, nil
+}
+
+// ClusterStateFlags are the flags shared by the commands that read and write the state of a cluster: the key
+// that encrypts the stored configuration, the backend that keeps it, and the phases whose templates stop on
+// the keys that the configuration does not set.
+type ClusterStateFlags struct {
+	EncryptionKey   encryption.Key
+	StateBackend    backend.Config
+	StrictTemplates cluster.StrictTemplates
+}
+
+// GetClusterStateFlagsFromViper gets the flags of the state of a cluster from viper.
+func GetClusterStateFlagsFromViper() (ClusterStateFlags, error) {
+	strictTemplates := cluster.StrictTemplates{
+		Phases: viper.GetStringSlice("strict-templates"),
+		Allow:  viper.GetStringSlice("strict-templates-allow"),
+	}
+
+	if err := strictTemplates.Validate(); err != nil {
+		return ClusterStateFlags{}, fmt.Errorf("%s: %w", "strict-templates", err)
+	}
+
+	encryptionKey, err := GetEncryptionKeyFromViper()
+	if err != nil {
+		return ClusterStateFlags{}, err
+	}
+
+	return ClusterStateFlags{
+		EncryptionKey:   encryptionKey,
+		StateBackend:    GetStateBackendFromViper(),
+		StrictTemplates: strictTemplates,
+	}, nil
+}
+
 // isCriticalError determines if an error should cause the flags loading to fail
 // rather than just log a warning.
 func isCriticalError(err error) bool {
@@ -270,8 +349,27 @@ func (*Manager) handleValidationErrors(validationErrors []ValidationError, fatal
 }
 
 // LoadAndMergeCommandFlags loads and merges flags for a specific command with proper error handling.
+// A furyctl.yaml that extends other files or has overlays is composed first, under the outdir: the command
+// and its flags read the composed configuration, see GetComposedConfigPathFromViper.
 func LoadAndMergeCommandFlags(command string) error {
-	configPath := GetConfigPathFromViper()
+	sourcePath := GetConfigPathFromViper()
+
+	configPath, err := compose.Prepare(
+		sourcePath,
+		viper.GetString("environment"),
+		filepath.Join(viper.GetString("outdir"), ".furyctl", "compose"),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to compose configuration: %w", err)
+	}
+
+	composedPath := ""
+	if configPath != sourcePath {
+		composedPath = configPath
+	}
+
+	viper.Set(composedConfigKey, composedPath)
+
 	flagsManager := NewManager(filepath.Dir(configPath))
 
 	if err := flagsManager.LoadAndMergeFlags(configPath, command); err != nil {
@@ -282,8 +380,10 @@ func LoadAndMergeCommandFlags(command string) error {
 	return nil
 }
 
-// LoadAndMergeGlobalFlagsFromArgs loads global flags from command line --config argument.
-// This is called early in PersistentPreRun before log file creation.
+// LoadAndMergeGlobalFlagsFromArgs loads global flags from command line --config argument, furyctl.yaml
+// by default. This is called early in PersistentPreRun before log file creation. As for the command
+// flags, a furyctl.yaml that extends other files or has overlays is composed first, so that the global
+// flags of its bases and of the overlays of the environment apply too.
 func LoadAndMergeGlobalFlagsFromArgs() error {
 	flagsManager := NewManager(".")
 
@@ -306,8 +406,34 @@ func LoadAndMergeGlobalFlagsFromArgs() error {
 		}
 	}
 
-	if configPath != "" {
-		if err := flagsManager.LoadAndMergeGlobalFlags(configPath); err != nil {
+	explicitConfig := configPath != ""
+	if !explicitConfig {
+		configPath = "furyctl.yaml"
+	}
+
+	// The outdir can come from the global flags themselves, the composed configuration of the global flags
+	// goes to a temporary directory.
+	composeDir, err := os.MkdirTemp("", "furyctl-compose-")
+	if err != nil {
+		return fmt.Errorf("error while creating temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(composeDir)
+
+	composedPath, err := compose.Prepare(configPath, viper.GetString("environment"), composeDir)
+	if err != nil {
+		// The commands that need the default configuration report the error when they compose it.
+		if explicitConfig {
+			return fmt.Errorf("failed to compose configuration %s: %w", configPath, err)
+		}
+
+		logrus.Debugf("Failed to compose %s: %v", configPath, err)
+
+		composedPath = ""
+	}
+
+	if composedPath != "" {
+		if err := flagsManager.LoadAndMergeGlobalFlags(composedPath); err != nil {
 			// Critical flag expansion errors should be fatal before log file creation
 			// to prevent directory creation with unexpanded dynamic values.
 			if strings.Contains(err.Error(), "cannot parse dynamic value") ||
@@ -0,0 +1,100 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package flags_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/flags"
+)
+
+// The global flags of the base files and of the overlays of the environment apply as the ones of
+// furyctl.yaml do.
+//
+//nolint:paralleltest // The test changes os.Args and the global viper.
+func TestLoadAndMergeGlobalFlagsFromArgs_Composed(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"base.yaml": "flags:\n  global:\n    stateBackend: local\n    policyDir: ./policies\n",
+		"furyctl.yaml": "extends: ./base.yaml\n" +
+			"overlays:\n  production:\n    - ./production.yaml\n" +
+			"flags:\n  global:\n    policyDir: ./cluster-policies\n",
+		"production.yaml": "flags:\n  global:\n    strictTemplates:\n      - all\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	args := os.Args
+
+	t.Cleanup(func() {
+		os.Args = args
+	})
+
+	os.Args = []string{"furyctl", "apply", "--config", filepath.Join(dir, "furyctl.yaml")}
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	viper.Set("environment", "production")
+
+	require.NoError(t, flags.LoadAndMergeGlobalFlagsFromArgs())
+
+	assert.Equal(t, "local", viper.GetString("state-backend"))
+	assert.Equal(t, "./cluster-policies", viper.GetString("policy-dir"))
+	assert.Equal(t, []string{"all"}, viper.GetStringSlice("strict-templates"))
+}
+
+// The commands read the composed configuration from the outdir, --config keeps the path of the user.
+//
+//nolint:paralleltest // The test changes the global viper.
+func TestLoadAndMergeCommandFlags_Composed(t *testing.T) {
+	dir := t.TempDir()
+	outDir := t.TempDir()
+
+	files := map[string]string{
+		"base.yaml":    "kind: KFDDistribution\n",
+		"furyctl.yaml": "extends: ./base.yaml\nmetadata:\n  name: composed\n",
+		"plain.yaml":   "kind: KFDDistribution\n",
+	}
+
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	configPath := filepath.Join(dir, "furyctl.yaml")
+
+	viper.Set("config", configPath)
+	viper.Set("outdir", outDir)
+
+	require.NoError(t, flags.LoadAndMergeCommandFlags("apply"))
+
+	assert.Equal(t, configPath, viper.GetString("config"))
+	assert.True(t, flags.IsConfigComposed())
+	assert.Equal(t, filepath.Join(outDir, ".furyctl", "compose"), filepath.Dir(flags.GetComposedConfigPathFromViper()))
+	assert.NoFileExists(t, filepath.Join(dir, ".furyctl.composed.yaml"))
+
+	plainPath := filepath.Join(dir, "plain.yaml")
+
+	viper.Set("config", plainPath)
+
+	require.NoError(t, flags.LoadAndMergeCommandFlags("apply"))
+
+	assert.False(t, flags.IsConfigComposed())
+	assert.Equal(t, plainPath, flags.GetComposedConfigPathFromViper())
+}
@@ -28,6 +28,10 @@ const (
 	CommandConnect  = "connect"
 	CommandRenew    = "renew"
 	CommandDump     = "dump"
+	CommandPlan     = "plan"
+	CommandDrift    = "drift"
+	CommandAirgap   = "airgap"
+	CommandConfig   = "config"
 )
 
 // Static error definitions for linting compliance.
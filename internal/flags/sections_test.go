@@ -45,6 +45,8 @@ func TestMergeIntoViper_CommandSections(t *testing.T) {
 		{flags.CommandConnect, "profile", "profile"},
 		{flags.CommandRenew, "distroLocation", "distro-location"},
 		{flags.CommandDump, "distroPatches", "distro-patches"},
+		{flags.CommandAirgap, "registry", "registry"},
+		{flags.CommandConfig, "upgradePathLocation", "upgrade-path-location"},
 	}
 
 	for _, tc := range tests {
@@ -70,13 +70,29 @@ func (e ValidationError) Error() string {
 func GetSupportedFlags() SupportedFlags {
 	return SupportedFlags{
 		CommandGlobal: {
-			"debug":            FlagTypeBool,
-			"disableAnalytics": FlagTypeBool,
-			"noTty":            FlagTypeBool,
-			"workdir":          FlagTypeString,
-			"outdir":           FlagTypeString,
-			"log":              FlagTypeString,
-			"gitProtocol":      FlagTypeString,
+			"debug":                FlagTypeBool,
+			"disableAnalytics":     FlagTypeBool,
+			"noTty":                FlagTypeBool,
+			"workdir":              FlagTypeString,
+			"outdir":               FlagTypeString,
+			"log":                  FlagTypeString,
+			"gitProtocol":          FlagTypeString,
+			"otlpEndpoint":         FlagTypeString,
+			"otlpInsecure":         FlagTypeBool,
+			"analyticsSink":        FlagTypeString,
+			"analyticsFile":        FlagTypeString,
+			"analyticsWebhookUrl":  FlagTypeString,
+			"encryptionKeyFile":    FlagTypeString,
+			"environment":          FlagTypeString,
+			"policyDir":            FlagTypeString,
+			"stateBackend":         FlagTypeString,
+			"stateDir":             FlagTypeString,
+			"stateS3Bucket":        FlagTypeString,
+			"stateS3Prefix":        FlagTypeString,
+			"stateS3Endpoint":      FlagTypeString,
+			"stateS3Region":        FlagTypeString,
+			"strictTemplates":      FlagTypeStringSlice,
+			"strictTemplatesAllow": FlagTypeStringSlice,
 		},
 		CommandApply: {
 			"phase":                  FlagTypeString,
@@ -98,7 +114,10 @@ func GetSupportedFlags() SupportedFlags {
 			"upgradePathLocation":    FlagTypeString,
 			"upgradeNode":            FlagTypeString,
 			"airgapBundle":           FlagTypeString,
+			"airgapDelta":            FlagTypeStringSlice,
 			"forceExtract":           FlagTypeBool,
+			"lockBackend":            FlagTypeString,
+			"storeRunReport":         FlagTypeBool,
 		},
 		CommandDelete: {
 			"phase":               FlagTypeString,
@@ -110,7 +129,9 @@ func GetSupportedFlags() SupportedFlags {
 			"skipVpnConfirmation": FlagTypeBool,
 			"autoApprove":         FlagTypeBool,
 			"airgapBundle":        FlagTypeString,
+			"airgapDelta":         FlagTypeStringSlice,
 			"forceExtract":        FlagTypeBool,
+			"lockBackend":         FlagTypeString,
 		},
 		CommandCreate: {
 			"name":         FlagTypeString,
@@ -126,6 +147,7 @@ func GetSupportedFlags() SupportedFlags {
 			"skipDepsDownload":   FlagTypeBool,
 			"skipDepsValidation": FlagTypeBool,
 			"airgapBundle":       FlagTypeString,
+			"airgapDelta":        FlagTypeStringSlice,
 			"forceExtract":       FlagTypeBool,
 		},
 		CommandDiff: {
@@ -135,24 +157,32 @@ func GetSupportedFlags() SupportedFlags {
 			"binPath":             FlagTypeString,
 			"upgradePathLocation": FlagTypeString,
 			"airgapBundle":        FlagTypeString,
+			"airgapDelta":         FlagTypeStringSlice,
 			"forceExtract":        FlagTypeBool,
+			"output":              FlagTypeString,
 		},
 		CommandValidate: {
 			"distroLocation": FlagTypeString,
 			"distroPatches":  FlagTypeString,
 			"binPath":        FlagTypeString,
+			"output":         FlagTypeString,
 		},
 		CommandDownload: {
 			"binPath":        FlagTypeString,
 			"distroLocation": FlagTypeString,
 			"distroPatches":  FlagTypeString,
 			"bundleOutput":   FlagTypeString,
+			"skipImages":     FlagTypeBool,
+			"base":           FlagTypeString,
+			"signingKey":     FlagTypeString,
+			"imagePlatforms": FlagTypeStringSlice,
 		},
 		CommandConnect: {
 			"profile": FlagTypeString,
 		},
 		CommandRenew: {
 			"airgapBundle":       FlagTypeString,
+			"airgapDelta":        FlagTypeStringSlice,
 			"forceExtract":       FlagTypeBool,
 			"binPath":            FlagTypeString,
 			"distroLocation":     FlagTypeString,
@@ -165,6 +195,50 @@ func GetSupportedFlags() SupportedFlags {
 			"dryRun":         FlagTypeBool,
 			"noOverwrite":    FlagTypeBool,
 			"skipValidation": FlagTypeBool,
+			"rendered":       FlagTypeBool,
+		},
+		CommandPlan: {
+			"phase":               FlagTypeString,
+			"distroLocation":      FlagTypeString,
+			"distroPatches":       FlagTypeString,
+			"binPath":             FlagTypeString,
+			"skipDepsDownload":    FlagTypeBool,
+			"skipDepsValidation":  FlagTypeBool,
+			"timeout":             FlagTypeInt,
+			"upgrade":             FlagTypeBool,
+			"upgradePathLocation": FlagTypeString,
+			"airgapBundle":        FlagTypeString,
+			"airgapDelta":         FlagTypeStringSlice,
+			"forceExtract":        FlagTypeBool,
+			"output":              FlagTypeString,
+			"reportDir":           FlagTypeString,
+		},
+		CommandDrift: {
+			"distroLocation":     FlagTypeString,
+			"distroPatches":      FlagTypeString,
+			"binPath":            FlagTypeString,
+			"skipDepsDownload":   FlagTypeBool,
+			"skipDepsValidation": FlagTypeBool,
+			"timeout":            FlagTypeInt,
+			"airgapBundle":       FlagTypeString,
+			"airgapDelta":        FlagTypeStringSlice,
+			"forceExtract":       FlagTypeBool,
+			"output":             FlagTypeString,
+			"showDiff":           FlagTypeBool,
+		},
+		CommandAirgap: {
+			"registry":         FlagTypeString,
+			"rewritePrefix":    FlagTypeStringSlice,
+			"imagesDir":        FlagTypeString,
+			"insecureRegistry": FlagTypeBool,
+			"registryUsername": FlagTypeString,
+			"airgapBundle":     FlagTypeString,
+			"airgapDelta":      FlagTypeStringSlice,
+			"forceExtract":     FlagTypeBool,
+			"publicKey":        FlagTypeString,
+		},
+		CommandConfig: {
+			"upgradePathLocation": FlagTypeString,
 		},
 	}
 }
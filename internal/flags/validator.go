@@ -12,12 +12,19 @@ import (
 	"strings"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/analytics"
+	"github.com/sighupio/furyctl/internal/lock"
+	"github.com/sighupio/furyctl/internal/state/backend"
 )
 
 // Static error definitions for linting compliance.
 var (
 	ErrInvalidProtocol       = errors.New("invalid git protocol")
 	ErrInvalidForceOption    = errors.New("invalid force option")
+	ErrInvalidLockBackend    = errors.New("invalid lock backend")
+	ErrInvalidAnalyticsSink  = errors.New("invalid analytics sink")
+	ErrInvalidStateBackend   = errors.New("invalid state backend")
 	ErrMustBePositiveInteger = errors.New("must be a positive integer")
 	ErrConflictingFlags      = errors.New("conflicting flags detected")
 	ErrInvalidBooleanValue   = errors.New("invalid boolean value")
@@ -186,6 +193,36 @@ func (*Validator) validateSpecificFlag(flagName string, value any) error {
 		}
 		return nil
 
+	case "lockBackend":
+		if str, ok := value.(string); ok {
+			if slices.Contains(lock.Backends(), str) {
+				return nil
+			}
+
+			return fmt.Errorf("%w: got '%s', must be one of: %s", ErrInvalidLockBackend, str, strings.Join(lock.Backends(), ", "))
+		}
+		return nil
+
+	case "analyticsSink":
+		if str, ok := value.(string); ok {
+			if slices.Contains(analytics.Sinks(), str) {
+				return nil
+			}
+
+			return fmt.Errorf("%w: got '%s', must be one of: %s", ErrInvalidAnalyticsSink, str, strings.Join(analytics.Sinks(), ", "))
+		}
+		return nil
+
+	case "stateBackend":
+		if str, ok := value.(string); ok {
+			if slices.Contains(backend.Types(), str) {
+				return nil
+			}
+
+			return fmt.Errorf("%w: got '%s', must be one of: %s", ErrInvalidStateBackend, str, strings.Join(backend.Types(), ", "))
+		}
+		return nil
+
 	case "timeout", "podRunningCheckTimeout":
 		if val, ok := value.(int); ok {
 			if val <= 0 {
@@ -244,6 +281,9 @@ func getValidationSeverity(flagName string, err error) ValidationSeverity {
 	// Critical errors that should stop execution.
 	if errors.Is(err, ErrInvalidProtocol) ||
 		errors.Is(err, ErrInvalidForceOption) ||
+		errors.Is(err, ErrInvalidLockBackend) ||
+		errors.Is(err, ErrInvalidAnalyticsSink) ||
+		errors.Is(err, ErrInvalidStateBackend) ||
 		errors.Is(err, ErrMustBePositiveInteger) ||
 		errors.Is(err, ErrConflictingFlags) {
 		return ValidationSeverityFatal
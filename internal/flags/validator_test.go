@@ -51,6 +51,17 @@ func TestValidator_Validate(t *testing.T) {
 			},
 			expectedErrors: 2, // Two unsupported flags
 		},
+		{
+			// The secret is committed with furyctl.yaml and stored with it in the cluster.
+			name: "analytics webhook secret",
+			flags: flags.FlagsConfig{
+				flags.CommandGlobal: {
+					"analyticsWebhookUrl":    "https://events.example.com/furyctl",
+					"analyticsWebhookSecret": "secret",
+				},
+			},
+			expectedErrors: 1,
+		},
 		{
 			name: "invalid git protocol",
 			flags: flags.FlagsConfig{
@@ -69,6 +80,33 @@ func TestValidator_Validate(t *testing.T) {
 			},
 			expectedErrors: 1,
 		},
+		{
+			name: "invalid lock backend",
+			flags: flags.FlagsConfig{
+				flags.CommandDelete: {
+					"lockBackend": "etcd",
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "invalid analytics sink",
+			flags: flags.FlagsConfig{
+				flags.CommandGlobal: {
+					"analyticsSink": "syslog",
+				},
+			},
+			expectedErrors: 1,
+		},
+		{
+			name: "invalid state backend",
+			flags: flags.FlagsConfig{
+				flags.CommandGlobal: {
+					"stateBackend": "etcd",
+				},
+			},
+			expectedErrors: 1,
+		},
 		{
 			name: "invalid timeout",
 			flags: flags.FlagsConfig{
@@ -0,0 +1,96 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package images
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// imageKey is the field that holds an image reference in the containers of the pod templates, and in
+// the custom resources that run pods, for example the Prometheus and Alertmanager of the operator.
+const imageKey = "image"
+
+// Set is a set of image references, keyed by their normalized form.
+type Set map[string]Reference
+
+// Add parses the references and adds them to the set. The values that are not references, for example a
+// template placeholder, are skipped.
+func (s Set) Add(refs ...string) {
+	for _, r := range refs {
+		ref, err := ParseReference(r)
+		if err != nil {
+			logrus.Debugf("Skipping image %q: %v", r, err)
+
+			continue
+		}
+
+		s[ref.String()] = ref
+	}
+}
+
+// Sorted returns the references of the set sorted by name.
+func (s Set) Sorted() []Reference {
+	refs := make([]Reference, 0, len(s))
+
+	for _, ref := range s {
+		refs = append(refs, ref)
+	}
+
+	sort.Slice(refs, func(i, j int) bool { return refs[i].String() < refs[j].String() })
+
+	return refs
+}
+
+// FromManifests returns the values of the image fields of the objects of a multi-document YAML.
+func FromManifests(data []byte) ([]string, error) {
+	refs := []string{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var doc any
+
+		if err := dec.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("error while parsing manifests: %w", err)
+		}
+
+		refs = walk(doc, refs)
+	}
+
+	return refs, nil
+}
+
+func walk(node any, refs []string) []string {
+	switch n := node.(type) {
+	case map[string]any:
+		for k, v := range n {
+			if s, ok := v.(string); ok && k == imageKey && s != "" {
+				refs = append(refs, s)
+
+				continue
+			}
+
+			refs = walk(v, refs)
+		}
+
+	case []any:
+		for _, v := range n {
+			refs = walk(v, refs)
+		}
+	}
+
+	return refs
+}
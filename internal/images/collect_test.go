@@ -0,0 +1,72 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package images_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/images"
+)
+
+const testManifests = `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: ingress-nginx-controller
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: registry.sighup.io/fury/busybox:1.36
+      containers:
+        - name: controller
+          image: registry.sighup.io/fury/ingress-nginx/controller:v1.11.2
+        - name: sidecar
+          image: registry.sighup.io/fury/busybox:1.36
+---
+apiVersion: monitoring.coreos.com/v1
+kind: Prometheus
+metadata:
+  name: k8s
+spec:
+  image: registry.sighup.io/fury/prometheus/prometheus:v2.54.1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: values
+data:
+  image: "{{ .Values.image }}"
+`
+
+func TestFromManifests(t *testing.T) {
+	t.Parallel()
+
+	refs, err := images.FromManifests([]byte(testManifests))
+	require.NoError(t, err)
+
+	set := images.Set{}
+	set.Add(refs...)
+
+	got := []string{}
+	for _, ref := range set.Sorted() {
+		got = append(got, ref.String())
+	}
+
+	assert.Equal(t, []string{
+		"registry.sighup.io/fury/busybox:1.36",
+		"registry.sighup.io/fury/ingress-nginx/controller:v1.11.2",
+		"registry.sighup.io/fury/prometheus/prometheus:v2.54.1",
+	}, got)
+
+	_, err = images.FromManifests([]byte("kind: [unclosed"))
+	require.Error(t, err)
+}
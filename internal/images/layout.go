@@ -0,0 +1,308 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package images
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+const (
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+
+	// AnnotationRefName is the tag of an image in the index of the layout.
+	AnnotationRefName = "org.opencontainers.image.ref.name"
+	// AnnotationImageName is the full reference of an image in the index of the layout, as containerd and
+	// skopeo write it.
+	AnnotationImageName = "io.containerd.image.name"
+
+	layoutFile    = "oci-layout"
+	indexFile     = "index.json"
+	layoutVersion = `{"imageLayoutVersion":"1.0.0"}`
+)
+
+var (
+	ErrDigestMismatch  = errors.New("digest mismatch")
+	ErrNotImageLayout  = errors.New("not an OCI image layout")
+	ErrUnsupportedBlob = errors.New("unsupported digest algorithm")
+	ErrInvalidPlatform = errors.New("invalid platform")
+)
+
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+func (p Platform) String() string {
+	if p.Variant != "" {
+		return p.OS + "/" + p.Architecture + "/" + p.Variant
+	}
+
+	return p.OS + "/" + p.Architecture
+}
+
+// ParsePlatform parses a platform in the <os>/<arch>[/<variant>] form, for example linux/arm64.
+func ParsePlatform(s string) (Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return Platform{}, fmt.Errorf("%w %q, it must be <os>/<arch>", ErrInvalidPlatform, s)
+	}
+
+	p := Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+
+	return p, nil
+}
+
+// Descriptor points to a blob of the layout or of a registry.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Platform    *Platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Index is an OCI image index, or a Docker manifest list.
+type Index struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Manifests     []Descriptor `json:"manifests"`
+}
+
+// Manifest is an OCI image manifest, or a Docker image manifest.
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+func isIndex(mediaType string) bool {
+	return mediaType == MediaTypeOCIIndex || mediaType == MediaTypeDockerManifestList
+}
+
+func isManifest(mediaType string) bool {
+	return mediaType == MediaTypeOCIManifest || mediaType == MediaTypeDockerManifest
+}
+
+// Layout is an OCI image layout on disk: the blobs are content addressed in blobs/sha256, and index.json
+// lists the images with their full reference.
+type Layout struct {
+	Dir string
+
+	mu sync.Mutex
+}
+
+// NewLayout creates the layout in dir, or opens the one that is there.
+func NewLayout(dir string) (*Layout, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), iox.FullPermAccess); err != nil {
+		return nil, fmt.Errorf("error while creating image layout: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, layoutFile), []byte(layoutVersion), iox.RWPermAccess); err != nil {
+		return nil, fmt.Errorf("error while creating image layout: %w", err)
+	}
+
+	l := &Layout{Dir: dir}
+
+	if _, err := os.Stat(filepath.Join(dir, indexFile)); errors.Is(err, os.ErrNotExist) {
+		if err := l.writeIndex(Index{SchemaVersion: 2, MediaType: MediaTypeOCIIndex, Manifests: []Descriptor{}}); err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+// OpenLayout opens an existing layout.
+func OpenLayout(dir string) (*Layout, error) {
+	if _, err := os.Stat(filepath.Join(dir, layoutFile)); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotImageLayout, dir)
+	}
+
+	return &Layout{Dir: dir}, nil
+}
+
+// Images returns the descriptors of the images of the layout.
+func (l *Layout) Images() ([]Descriptor, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	idx, err := l.readIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	return idx.Manifests, nil
+}
+
+// AddImage records the image in index.json, in place of the image with the same reference if any.
+func (l *Layout) AddImage(ref Reference, desc Descriptor) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	idx, err := l.readIndex()
+	if err != nil {
+		return err
+	}
+
+	desc.Platform = nil
+	desc.Annotations = map[string]string{AnnotationImageName: ref.String()}
+
+	if ref.Tag != "" {
+		desc.Annotations[AnnotationRefName] = ref.Tag
+	}
+
+	manifests := []Descriptor{}
+
+	for _, d := range idx.Manifests {
+		if d.Annotations[AnnotationImageName] != ref.String() {
+			manifests = append(manifests, d)
+		}
+	}
+
+	idx.Manifests = append(manifests, desc)
+
+	return l.writeIndex(idx)
+}
+
+func (l *Layout) readIndex() (Index, error) {
+	content, err := os.ReadFile(filepath.Join(l.Dir, indexFile))
+	if err != nil {
+		return Index{}, fmt.Errorf("error while reading image layout index: %w", err)
+	}
+
+	idx := Index{}
+	if err := json.Unmarshal(content, &idx); err != nil {
+		return Index{}, fmt.Errorf("error while parsing image layout index: %w", err)
+	}
+
+	return idx, nil
+}
+
+func (l *Layout) writeIndex(idx Index) error {
+	content, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error while marshalling image layout index: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(l.Dir, indexFile), content, iox.RWPermAccess); err != nil {
+		return fmt.Errorf("error while writing image layout index: %w", err)
+	}
+
+	return nil
+}
+
+func (l *Layout) blobPath(digest string) (string, error) {
+	hexDigest, ok := strings.CutPrefix(digest, "sha256:")
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedBlob, digest)
+	}
+
+	return filepath.Join(l.Dir, "blobs", "sha256", hexDigest), nil
+}
+
+// HasBlob tells whether the layout has the blob.
+func (l *Layout) HasBlob(digest string) bool {
+	p, err := l.blobPath(digest)
+	if err != nil {
+		return false
+	}
+
+	_, err = os.Stat(p)
+
+	return err == nil
+}
+
+// WriteBlob stores the content in the layout, and fails when it does not match the digest.
+func (l *Layout) WriteBlob(digest string, r io.Reader) error {
+	p, err := l.blobPath(digest)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".blob-")
+	if err != nil {
+		return fmt.Errorf("error while writing blob %s: %w", digest, err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+
+	if _, err := io.Copy(io.MultiWriter(tmp, h), r); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("error while writing blob %s: %w", digest, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error while writing blob %s: %w", digest, err)
+	}
+
+	if got := "sha256:" + hex.EncodeToString(h.Sum(nil)); got != digest {
+		return fmt.Errorf("%w: want %s, got %s", ErrDigestMismatch, digest, got)
+	}
+
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		return fmt.Errorf("error while writing blob %s: %w", digest, err)
+	}
+
+	return nil
+}
+
+// ReadBlob returns the content of a blob.
+func (l *Layout) ReadBlob(digest string) ([]byte, error) {
+	p, err := l.blobPath(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading blob %s: %w", digest, err)
+	}
+
+	return content, nil
+}
+
+// OpenBlob opens a blob for reading.
+func (l *Layout) OpenBlob(digest string) (*os.File, error) {
+	p, err := l.blobPath(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening blob %s: %w", digest, err)
+	}
+
+	return f, nil
+}
+
+// Digest returns the sha256 digest of the content.
+func Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package images
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrNoMatchingPlatform = errors.New("the image has no manifest for the platforms")
+
+// Pushed is an image of the layout and the reference it has on the target registry.
+type Pushed struct {
+	Source Reference
+	Target Reference
+}
+
+// Pull copies the image of the reference into the layout. The index of a multi-platform image keeps the
+// manifests of the platforms only, unless the reference pins a digest: filtering the index would change
+// its digest. The blobs that the layout already has are not downloaded again.
+func Pull(c *RegistryClient, l *Layout, ref Reference, platforms []Platform) error {
+	content, mediaType, digest, err := c.GetManifest(ref)
+	if err != nil {
+		return err
+	}
+
+	if isIndex(mediaType) {
+		content, digest, err = pullIndex(c, l, ref, content, platforms)
+	} else {
+		err = pullManifest(c, l, ref, content)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := l.WriteBlob(digest, bytes.NewReader(content)); err != nil {
+		return err
+	}
+
+	return l.AddImage(ref, Descriptor{MediaType: mediaType, Digest: digest, Size: int64(len(content))})
+}
+
+func pullIndex(c *RegistryClient, l *Layout, ref Reference, content []byte, platforms []Platform) ([]byte, string, error) {
+	idx := Index{}
+	if err := json.Unmarshal(content, &idx); err != nil {
+		return nil, "", fmt.Errorf("error while parsing index of %s: %w", ref, err)
+	}
+
+	children := idx.Manifests
+	if ref.Digest == "" && len(platforms) > 0 {
+		children = []Descriptor{}
+
+		for _, d := range idx.Manifests {
+			if matchPlatform(d.Platform, platforms) {
+				children = append(children, d)
+			}
+		}
+
+		if len(children) == 0 {
+			return nil, "", fmt.Errorf("%w %v: %s", ErrNoMatchingPlatform, platforms, ref)
+		}
+	}
+
+	for _, d := range children {
+		child := ref
+		child.Tag = ""
+		child.Digest = d.Digest
+
+		childContent, mediaType, _, err := c.GetManifest(child)
+		if err != nil {
+			return nil, "", err
+		}
+
+		if !isManifest(mediaType) {
+			return nil, "", fmt.Errorf("%w %q in the index of %s", ErrUnsupportedManifest, mediaType, ref)
+		}
+
+		if err := pullManifest(c, l, child, childContent); err != nil {
+			return nil, "", err
+		}
+
+		if err := l.WriteBlob(d.Digest, bytes.NewReader(childContent)); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if len(children) == len(idx.Manifests) {
+		return content, Digest(content), nil
+	}
+
+	// Re-encode the index from its raw form, so the fields furyctl does not model are kept.
+	raw := map[string]any{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, "", fmt.Errorf("error while parsing index of %s: %w", ref, err)
+	}
+
+	raw["manifests"] = children
+
+	filtered, err := json.Marshal(raw)
+	if err != nil {
+		return nil, "", fmt.Errorf("error while marshalling index of %s: %w", ref, err)
+	}
+
+	return filtered, Digest(filtered), nil
+}
+
+func pullManifest(c *RegistryClient, l *Layout, ref Reference, content []byte) error {
+	m := Manifest{}
+	if err := json.Unmarshal(content, &m); err != nil {
+		return fmt.Errorf("error while parsing manifest of %s: %w", ref, err)
+	}
+
+	for _, d := range append([]Descriptor{m.Config}, m.Layers...) {
+		if l.HasBlob(d.Digest) {
+			continue
+		}
+
+		if err := pullBlob(c, l, ref, d.Digest); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func pullBlob(c *RegistryClient, l *Layout, ref Reference, digest string) error {
+	blob, err := c.GetBlob(ref, digest)
+	if err != nil {
+		return err
+	}
+
+	defer blob.Close()
+
+	return l.WriteBlob(digest, blob)
+}
+
+func matchPlatform(p *Platform, platforms []Platform) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, want := range platforms {
+		if p.OS == want.OS && p.Architecture == want.Architecture && (want.Variant == "" || p.Variant == want.Variant) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Push uploads the images of the layout to the target registry of the rewrite. The manifests and blobs
+// are pushed unchanged, so the digests of the images are the same on the target registry.
+func Push(c *RegistryClient, l *Layout, rw Rewrite) ([]Pushed, error) {
+	descs, err := l.Images()
+	if err != nil {
+		return nil, err
+	}
+
+	pushed := make([]Pushed, 0, len(descs))
+
+	for _, d := range descs {
+		src, err := ParseReference(d.Annotations[AnnotationImageName])
+		if err != nil {
+			return pushed, fmt.Errorf("error while reading image layout index: %w", err)
+		}
+
+		target, err := rw.Apply(src)
+		if err != nil {
+			return pushed, err
+		}
+
+		// The tag is what the pods reference; the digest is the same since the content is the same.
+		top := target
+		if top.Tag != "" {
+			top.Digest = ""
+		}
+
+		if err := pushTree(c, l, top, d); err != nil {
+			return pushed, err
+		}
+
+		pushed = append(pushed, Pushed{Source: src, Target: target})
+	}
+
+	return pushed, nil
+}
+
+func pushTree(c *RegistryClient, l *Layout, target Reference, d Descriptor) error {
+	content, err := l.ReadBlob(d.Digest)
+	if err != nil {
+		return err
+	}
+
+	if isIndex(d.MediaType) {
+		idx := Index{}
+		if err := json.Unmarshal(content, &idx); err != nil {
+			return fmt.Errorf("error while parsing index of %s: %w", target, err)
+		}
+
+		for _, child := range idx.Manifests {
+			childTarget := target
+			childTarget.Tag = ""
+			childTarget.Digest = child.Digest
+
+			if err := pushTree(c, l, childTarget, child); err != nil {
+				return err
+			}
+		}
+
+		return c.PutManifest(target, d.MediaType, content)
+	}
+
+	m := Manifest{}
+	if err := json.Unmarshal(content, &m); err != nil {
+		return fmt.Errorf("error while parsing manifest of %s: %w", target, err)
+	}
+
+	for _, blob := range append([]Descriptor{m.Config}, m.Layers...) {
+		if err := pushBlob(c, l, target, blob); err != nil {
+			return err
+		}
+	}
+
+	return c.PutManifest(target, d.MediaType, content)
+}
+
+func pushBlob(c *RegistryClient, l *Layout, target Reference, d Descriptor) error {
+	exists, err := c.HasBlob(target, d.Digest)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	f, err := l.OpenBlob(d.Digest)
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return c.PutBlob(target, d.Digest, d.Size, f)
+}
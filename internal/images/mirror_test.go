@@ -0,0 +1,307 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package images_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/images"
+)
+
+const testToken = "t0ken"
+
+type storedManifest struct {
+	mediaType string
+	content   []byte
+}
+
+// fakeRegistry is an in-memory stand-in of a registry that serves the distribution API (v2) and asks for
+// a bearer token, as the public registries do.
+type fakeRegistry struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	manifests map[string]storedManifest
+	blobs     map[string][]byte
+	uploads   int
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+
+	r := &fakeRegistry{
+		manifests: map[string]storedManifest{},
+		blobs:     map[string][]byte{},
+	}
+
+	r.Server = httptest.NewServer(http.HandlerFunc(r.serve))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func (r *fakeRegistry) host() string {
+	return strings.TrimPrefix(r.URL, "http://")
+}
+
+func (r *fakeRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/token" {
+		fmt.Fprintf(w, `{"token":%q}`, testToken)
+
+		return
+	}
+
+	if req.Header.Get("Authorization") != "Bearer "+testToken {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="fake"`, r.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+
+	switch {
+	case strings.Contains(path, "/manifests/"):
+		name, ref, _ := strings.Cut(path, "/manifests/")
+		r.serveManifest(w, req, name, ref)
+
+	case strings.Contains(path, "/blobs/uploads/"):
+		name, _, _ := strings.Cut(path, "/blobs/uploads/")
+		r.serveUpload(w, req, name)
+
+	case strings.Contains(path, "/blobs/"):
+		name, digest, _ := strings.Cut(path, "/blobs/")
+
+		content, ok := r.blobs[name+"@"+digest]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		if req.Method == http.MethodGet {
+			w.Write(content)
+		}
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *fakeRegistry) serveManifest(w http.ResponseWriter, req *http.Request, name, ref string) {
+	if req.Method == http.MethodPut {
+		content, _ := io.ReadAll(req.Body)
+		m := storedManifest{mediaType: req.Header.Get("Content-Type"), content: content}
+
+		r.manifests[name+"@"+images.Digest(content)] = m
+		r.manifests[name+":"+ref] = m
+
+		w.WriteHeader(http.StatusCreated)
+
+		return
+	}
+
+	m, ok := r.manifests[name+":"+ref]
+	if !ok {
+		m, ok = r.manifests[name+"@"+ref]
+	}
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", m.mediaType)
+	w.Write(m.content)
+}
+
+func (r *fakeRegistry) serveUpload(w http.ResponseWriter, req *http.Request, name string) {
+	if req.Method == http.MethodPost {
+		r.uploads++
+
+		w.Header().Set("Location", fmt.Sprintf("/v2/%s/blobs/uploads/%d", name, r.uploads))
+		w.WriteHeader(http.StatusAccepted)
+
+		return
+	}
+
+	content, _ := io.ReadAll(req.Body)
+	digest := req.URL.Query().Get("digest")
+
+	if images.Digest(content) != digest {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	r.blobs[name+"@"+digest] = content
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// addImage stores an image with a layer and a config for the platform, and returns its descriptor.
+func (r *fakeRegistry) addImage(t *testing.T, name string, platform images.Platform) images.Descriptor {
+	t.Helper()
+
+	config := []byte(fmt.Sprintf(`{"architecture":%q,"os":%q}`, platform.Architecture, platform.OS))
+	layer := []byte("layer of " + name + " for " + platform.String())
+
+	r.blobs[name+"@"+images.Digest(config)] = config
+	r.blobs[name+"@"+images.Digest(layer)] = layer
+
+	content, err := json.Marshal(images.Manifest{
+		SchemaVersion: 2,
+		MediaType:     images.MediaTypeOCIManifest,
+		Config:        images.Descriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: images.Digest(config), Size: int64(len(config))},
+		Layers:        []images.Descriptor{{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: images.Digest(layer), Size: int64(len(layer))}},
+	})
+	require.NoError(t, err)
+
+	r.manifests[name+"@"+images.Digest(content)] = storedManifest{mediaType: images.MediaTypeOCIManifest, content: content}
+
+	return images.Descriptor{
+		MediaType: images.MediaTypeOCIManifest,
+		Digest:    images.Digest(content),
+		Size:      int64(len(content)),
+		Platform:  &platform,
+	}
+}
+
+func (r *fakeRegistry) tag(t *testing.T, name, tag string, desc images.Descriptor) {
+	t.Helper()
+
+	r.manifests[name+":"+tag] = r.manifests[name+"@"+desc.Digest]
+}
+
+func (r *fakeRegistry) addIndex(t *testing.T, name, tag string, descs ...images.Descriptor) {
+	t.Helper()
+
+	content, err := json.Marshal(images.Index{SchemaVersion: 2, MediaType: images.MediaTypeOCIIndex, Manifests: descs})
+	require.NoError(t, err)
+
+	r.manifests[name+":"+tag] = storedManifest{mediaType: images.MediaTypeOCIIndex, content: content}
+}
+
+func TestPullAndPush(t *testing.T) {
+	t.Parallel()
+
+	amd64 := images.Platform{OS: "linux", Architecture: "amd64"}
+	arm64 := images.Platform{OS: "linux", Architecture: "arm64"}
+
+	upstream := newFakeRegistry(t)
+	upstream.addIndex(t, "fury/nginx", "1.27.0",
+		upstream.addImage(t, "fury/nginx", amd64),
+		upstream.addImage(t, "fury/nginx", arm64),
+	)
+	upstream.tag(t, "fury/pause", "3.10", upstream.addImage(t, "fury/pause", amd64))
+
+	layout, err := images.NewLayout(t.TempDir())
+	require.NoError(t, err)
+
+	client := images.NewRegistryClient(images.RegistryOptions{Insecure: true})
+
+	for _, r := range []string{"fury/nginx:1.27.0", "fury/pause:3.10"} {
+		ref, err := images.ParseReference(upstream.host() + "/" + r)
+		require.NoError(t, err)
+
+		require.NoError(t, images.Pull(client, layout, ref, []images.Platform{amd64}))
+	}
+
+	assert.True(t, layout.HasBlob(images.Digest([]byte("layer of fury/nginx for linux/amd64"))))
+	assert.False(t, layout.HasBlob(images.Digest([]byte("layer of fury/nginx for linux/arm64"))))
+
+	descs, err := layout.Images()
+	require.NoError(t, err)
+	require.Len(t, descs, 2)
+
+	idxContent, err := layout.ReadBlob(descs[0].Digest)
+	require.NoError(t, err)
+
+	idx := images.Index{}
+	require.NoError(t, json.Unmarshal(idxContent, &idx))
+	require.Len(t, idx.Manifests, 1)
+	assert.Equal(t, amd64, *idx.Manifests[0].Platform)
+
+	internal := newFakeRegistry(t)
+
+	rw, err := images.ParseRewrite(internal.host(), []string{upstream.host() + "/fury=mirror"})
+	require.NoError(t, err)
+
+	pushed, err := images.Push(client, layout, rw)
+	require.NoError(t, err)
+	require.Len(t, pushed, 2)
+
+	assert.Equal(t, internal.host()+"/mirror/nginx:1.27.0", pushed[0].Target.String())
+	assert.Equal(t, internal.host()+"/mirror/pause:3.10", pushed[1].Target.String())
+
+	assert.Equal(t, idxContent, internal.manifests["mirror/nginx:1.27.0"].content)
+	assert.Contains(t, internal.manifests, "mirror/pause:3.10")
+	assert.Contains(t, internal.blobs, "mirror/nginx@"+images.Digest([]byte("layer of fury/nginx for linux/amd64")))
+
+	// The blobs that the registry has are not uploaded again.
+	uploads := internal.uploads
+
+	_, err = images.Push(client, layout, rw)
+	require.NoError(t, err)
+	assert.Equal(t, uploads, internal.uploads)
+}
+
+func TestPull_NoMatchingPlatform(t *testing.T) {
+	t.Parallel()
+
+	upstream := newFakeRegistry(t)
+	upstream.addIndex(t, "fury/nginx", "1.27.0",
+		upstream.addImage(t, "fury/nginx", images.Platform{OS: "linux", Architecture: "arm64"}),
+	)
+
+	layout, err := images.NewLayout(t.TempDir())
+	require.NoError(t, err)
+
+	ref, err := images.ParseReference(upstream.host() + "/fury/nginx:1.27.0")
+	require.NoError(t, err)
+
+	err = images.Pull(
+		images.NewRegistryClient(images.RegistryOptions{Insecure: true}),
+		layout,
+		ref,
+		[]images.Platform{{OS: "linux", Architecture: "amd64"}},
+	)
+	require.ErrorIs(t, err, images.ErrNoMatchingPlatform)
+}
+
+func TestLayout_WriteBlob(t *testing.T) {
+	t.Parallel()
+
+	layout, err := images.NewLayout(t.TempDir())
+	require.NoError(t, err)
+
+	content := "content"
+
+	err = layout.WriteBlob(images.Digest([]byte("other")), strings.NewReader(content))
+	require.ErrorIs(t, err, images.ErrDigestMismatch)
+	assert.False(t, layout.HasBlob(images.Digest([]byte("other"))))
+
+	require.NoError(t, layout.WriteBlob(images.Digest([]byte(content)), strings.NewReader(content)))
+
+	got, err := layout.ReadBlob(images.Digest([]byte(content)))
+	require.NoError(t, err)
+	assert.Equal(t, content, string(got))
+}
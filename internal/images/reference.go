@@ -0,0 +1,190 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package images collects the container images of the distribution, stores them in an OCI image layout
+// and pushes them to a registry, so an air-gapped cluster can pull them from an internal registry.
+package images
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultRegistry = "docker.io"
+	DefaultTag      = "latest"
+
+	dockerHubAPIHost = "registry-1.docker.io"
+)
+
+var (
+	ErrInvalidReference = errors.New("invalid image reference")
+	ErrInvalidRewrite   = errors.New("invalid registry prefix rewrite, it must be <source prefix>=<target prefix>")
+
+	//nolint:gochecknoglobals // compiled once.
+	repositoryRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	//nolint:gochecknoglobals // compiled once.
+	tagRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	//nolint:gochecknoglobals // compiled once.
+	digestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// Reference is a parsed image reference, for example registry.sighup.io/fury/nginx:1.27.0.
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image reference the way the container runtimes do: a reference without a
+// registry is on Docker Hub, a Docker Hub image without a namespace is in library/, and a reference
+// without a tag or digest has the latest tag.
+func ParseReference(s string) (Reference, error) {
+	ref := Reference{}
+	name := s
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+
+		if !digestRegexp.MatchString(ref.Digest) {
+			return Reference{}, fmt.Errorf("%w %q: unsupported digest", ErrInvalidReference, s)
+		}
+	}
+
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i+1:], "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+
+		if !tagRegexp.MatchString(ref.Tag) {
+			return Reference{}, fmt.Errorf("%w %q: invalid tag", ErrInvalidReference, s)
+		}
+	}
+
+	ref.Registry = DefaultRegistry
+	ref.Repository = name
+
+	if i := strings.Index(name, "/"); i >= 0 {
+		host := name[:i]
+
+		if strings.ContainsAny(host, ".:") || host == "localhost" {
+			ref.Registry = host
+			ref.Repository = name[i+1:]
+		}
+	}
+
+	if ref.Registry == DefaultRegistry && !strings.Contains(ref.Repository, "/") {
+		ref.Repository = "library/" + ref.Repository
+	}
+
+	if !repositoryRegexp.MatchString(ref.Repository) {
+		return Reference{}, fmt.Errorf("%w %q: invalid repository", ErrInvalidReference, s)
+	}
+
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = DefaultTag
+	}
+
+	return ref, nil
+}
+
+// Name returns the registry and the repository of the reference.
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// Identifier returns the digest of the reference or, when it has none, its tag.
+func (r Reference) Identifier() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+
+	return r.Tag
+}
+
+func (r Reference) String() string {
+	s := r.Name()
+
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+
+	return s
+}
+
+// apiHost returns the host that serves the registry API of the reference.
+func (r Reference) apiHost() string {
+	if r.Registry == DefaultRegistry {
+		return dockerHubAPIHost
+	}
+
+	return r.Registry
+}
+
+// Rewrite maps the registry prefixes of the references to a target registry. A rule replaces the
+// longest source prefix that matches the reference; the references that no rule matches keep their
+// repository on the target registry.
+type Rewrite struct {
+	Registry string
+	Rules    map[string]string
+}
+
+// ParseRewrite returns the rewrite to the registry with the "<source prefix>=<target prefix>" rules. The
+// target prefix is relative to the registry.
+func ParseRewrite(registry string, rules []string) (Rewrite, error) {
+	rw := Rewrite{
+		Registry: strings.TrimSuffix(registry, "/"),
+		Rules:    map[string]string{},
+	}
+
+	for _, rule := range rules {
+		src, dst, ok := strings.Cut(rule, "=")
+		if !ok || src == "" {
+			return Rewrite{}, fmt.Errorf("%w: %q", ErrInvalidRewrite, rule)
+		}
+
+		rw.Rules[strings.TrimSuffix(src, "/")] = strings.Trim(dst, "/")
+	}
+
+	return rw, nil
+}
+
+// Apply returns the reference on the target registry.
+func (rw Rewrite) Apply(ref Reference) (Reference, error) {
+	name := ref.Name()
+	repository := ref.Repository
+
+	prefixes := make([]string, 0, len(rw.Rules))
+	for src := range rw.Rules {
+		prefixes = append(prefixes, src)
+	}
+
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+
+	for _, src := range prefixes {
+		if name == src || strings.HasPrefix(name, src+"/") {
+			repository = strings.Trim(rw.Rules[src]+strings.TrimPrefix(name, src), "/")
+
+			break
+		}
+	}
+
+	target, err := ParseReference(rw.Registry + "/" + repository)
+	if err != nil {
+		return Reference{}, err
+	}
+
+	target.Tag = ref.Tag
+	target.Digest = ref.Digest
+
+	return target, nil
+}
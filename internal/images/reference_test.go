@@ -0,0 +1,92 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package images_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/images"
+)
+
+const testDigest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestParseReference(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		ref     string
+		want    string
+		wantErr bool
+	}{
+		{ref: "nginx", want: "docker.io/library/nginx:latest"},
+		{ref: "bitnami/redis:7.2", want: "docker.io/bitnami/redis:7.2"},
+		{ref: "registry.sighup.io/fury/nginx:1.27.0", want: "registry.sighup.io/fury/nginx:1.27.0"},
+		{ref: "localhost:5000/pause:3.10", want: "localhost:5000/pause:3.10"},
+		{ref: "quay.io/prometheus/node-exporter@" + testDigest, want: "quay.io/prometheus/node-exporter@" + testDigest},
+		{ref: "quay.io/cilium/cilium:v1.16.0@" + testDigest, want: "quay.io/cilium/cilium:v1.16.0@" + testDigest},
+		{ref: "{{ .spec.image }}", wantErr: true},
+		{ref: "nginx@sha256:abc", wantErr: true},
+		{ref: "Nginx:1.27", wantErr: true},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.ref, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := images.ParseReference(tC.ref)
+			if tC.wantErr {
+				require.ErrorIs(t, err, images.ErrInvalidReference)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got.String())
+		})
+	}
+}
+
+func TestRewrite_Apply(t *testing.T) {
+	t.Parallel()
+
+	rw, err := images.ParseRewrite("registry.internal:5000/", []string{
+		"registry.sighup.io/fury=mirror/fury",
+		"registry.sighup.io/fury/on-premises=kubernetes",
+		"docker.io/library=",
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		ref  string
+		want string
+	}{
+		{ref: "registry.sighup.io/fury/nginx:1.27.0", want: "registry.internal:5000/mirror/fury/nginx:1.27.0"},
+		{ref: "registry.sighup.io/fury/on-premises/pause:3.10", want: "registry.internal:5000/kubernetes/pause:3.10"},
+		{ref: "nginx:1.27", want: "registry.internal:5000/nginx:1.27"},
+		{ref: "quay.io/cilium/cilium@" + testDigest, want: "registry.internal:5000/cilium/cilium@" + testDigest},
+		{ref: "registry.sighup.io/furyctl/tool:1.0", want: "registry.internal:5000/furyctl/tool:1.0"},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.ref, func(t *testing.T) {
+			t.Parallel()
+
+			ref, err := images.ParseReference(tC.ref)
+			require.NoError(t, err)
+
+			got, err := rw.Apply(ref)
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got.String())
+		})
+	}
+
+	_, err = images.ParseRewrite("registry.internal", []string{"registry.sighup.io/fury"})
+	require.ErrorIs(t, err, images.ErrInvalidRewrite)
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package images
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const registryTimeout = 30 * time.Minute
+
+var (
+	ErrRegistry             = errors.New("registry error")
+	ErrUnsupportedManifest  = errors.New("unsupported manifest media type")
+	ErrUnsupportedChallenge = errors.New("unsupported authentication challenge")
+)
+
+//nolint:gochecknoglobals // the media types furyctl accepts, in order of preference.
+var manifestMediaTypes = []string{
+	MediaTypeOCIIndex,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeDockerManifest,
+}
+
+// RegistryOptions configures the access to a registry.
+type RegistryOptions struct {
+	// Insecure reaches the registry with plain HTTP.
+	Insecure bool
+	Username string
+	Password string
+}
+
+// RegistryClient talks to registries with the distribution API (v2). It authenticates with the bearer
+// tokens of the registry, anonymously or with the credentials of the options, or with basic auth.
+type RegistryClient struct {
+	client *http.Client
+	opts   RegistryOptions
+
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func NewRegistryClient(opts RegistryOptions) *RegistryClient {
+	return &RegistryClient{
+		client: &http.Client{Timeout: registryTimeout},
+		opts:   opts,
+		tokens: map[string]string{},
+	}
+}
+
+// GetManifest returns the manifest of the reference, its media type and its digest.
+func (c *RegistryClient) GetManifest(ref Reference) ([]byte, string, string, error) {
+	resp, err := c.do(ref, "pull", http.MethodGet, "/manifests/"+ref.Identifier(), nil, map[string]string{
+		"Accept": strings.Join(manifestMediaTypes, ", "),
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", "", statusError(resp, "getting manifest of "+ref.String())
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("error while reading manifest of %s: %w", ref, err)
+	}
+
+	digest := Digest(content)
+	if ref.Digest != "" && ref.Digest != digest {
+		return nil, "", "", fmt.Errorf("%w: manifest of %s has digest %s", ErrDigestMismatch, ref, digest)
+	}
+
+	mediaType := resp.Header.Get("Content-Type")
+	if i := strings.Index(mediaType, ";"); i >= 0 {
+		mediaType = mediaType[:i]
+	}
+
+	// Some registries answer with a generic content type, the manifest has the media type.
+	if !isIndex(mediaType) && !isManifest(mediaType) {
+		m := struct {
+			MediaType string `json:"mediaType"`
+		}{}
+
+		if err := json.Unmarshal(content, &m); err == nil {
+			mediaType = m.MediaType
+		}
+	}
+
+	if !isIndex(mediaType) && !isManifest(mediaType) {
+		return nil, "", "", fmt.Errorf("%w %q for %s", ErrUnsupportedManifest, mediaType, ref)
+	}
+
+	return content, mediaType, digest, nil
+}
+
+// GetBlob returns the content of a blob of the repository of the reference.
+func (c *RegistryClient) GetBlob(ref Reference, digest string) (io.ReadCloser, error) {
+	resp, err := c.do(ref, "pull", http.MethodGet, "/blobs/"+digest, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+
+		return nil, statusError(resp, "getting blob "+digest+" of "+ref.Name())
+	}
+
+	return resp.Body, nil
+}
+
+// HasBlob tells whether the repository of the reference has the blob.
+func (c *RegistryClient) HasBlob(ref Reference, digest string) (bool, error) {
+	resp, err := c.do(ref, "pull,push", http.MethodHead, "/blobs/"+digest, nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+
+	case http.StatusNotFound:
+		return false, nil
+
+	default:
+		return false, statusError(resp, "checking blob "+digest+" of "+ref.Name())
+	}
+}
+
+// PutBlob uploads a blob to the repository of the reference in a single request.
+func (c *RegistryClient) PutBlob(ref Reference, digest string, size int64, content io.Reader) error {
+	resp, err := c.do(ref, "pull,push", http.MethodPost, "/blobs/uploads/", nil, nil)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return statusError(resp, "starting upload of blob "+digest+" to "+ref.Name())
+	}
+
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return fmt.Errorf("error while parsing upload location of %s: %w", ref.Name(), err)
+	}
+
+	query := location.Query()
+	query.Set("digest", digest)
+	location.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPut, location.String(), content)
+	if err != nil {
+		return fmt.Errorf("error while uploading blob %s: %w", digest, err)
+	}
+
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	c.authorize(req, ref, "pull,push")
+
+	resp, err = c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error while uploading blob %s: %w", digest, err)
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return statusError(resp, "uploading blob "+digest+" to "+ref.Name())
+	}
+
+	return nil
+}
+
+// PutManifest uploads a manifest with the tag or the digest of the reference.
+func (c *RegistryClient) PutManifest(ref Reference, mediaType string, content []byte) error {
+	resp, err := c.do(ref, "pull,push", http.MethodPut, "/manifests/"+ref.Identifier(), content, map[string]string{
+		"Content-Type": mediaType,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return statusError(resp, "uploading manifest of "+ref.String())
+	}
+
+	return nil
+}
+
+// do sends a request to the repository of the reference, and authenticates when the registry asks for it.
+func (c *RegistryClient) do(
+	ref Reference,
+	actions,
+	method,
+	path string,
+	body []byte,
+	headers map[string]string,
+) (*http.Response, error) {
+	u := c.scheme() + "://" + ref.apiHost() + "/v2/" + ref.Repository + path
+
+	send := func() (*http.Response, error) {
+		var r io.Reader
+		if body != nil {
+			r = bytes.NewReader(body)
+		}
+
+		req, err := http.NewRequestWithContext(context.Background(), method, u, r)
+		if err != nil {
+			return nil, fmt.Errorf("error while creating request to %s: %w", ref.Registry, err)
+		}
+
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		c.authorize(req, ref, actions)
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error while reaching registry %s: %w", ref.Registry, err)
+		}
+
+		return resp, nil
+	}
+
+	resp, err := send()
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+
+	resp.Body.Close()
+
+	if err := c.authenticate(ref, actions, resp.Header.Get("WWW-Authenticate")); err != nil {
+		return nil, err
+	}
+
+	return send()
+}
+
+func (c *RegistryClient) scheme() string {
+	if c.opts.Insecure {
+		return "http"
+	}
+
+	return "https"
+}
+
+func tokenKey(ref Reference, actions string) string {
+	return ref.apiHost() + "/" + ref.Repository + ":" + actions
+}
+
+func (c *RegistryClient) authorize(req *http.Request, ref Reference, actions string) {
+	c.mu.Lock()
+	token, ok := c.tokens[tokenKey(ref, actions)]
+	c.mu.Unlock()
+
+	switch {
+	case ok && token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+
+	case ok && c.opts.Username != "":
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+}
+
+// authenticate answers the challenge of the registry: it gets a bearer token for the scope of the
+// reference, or records that the registry wants basic auth.
+func (c *RegistryClient) authenticate(ref Reference, actions, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+
+	switch scheme {
+	case "basic":
+		c.mu.Lock()
+		c.tokens[tokenKey(ref, actions)] = ""
+		c.mu.Unlock()
+
+		return nil
+
+	case "bearer":
+
+	default:
+		return fmt.Errorf("%w %q from %s", ErrUnsupportedChallenge, challenge, ref.Registry)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("%w %q from %s", ErrUnsupportedChallenge, challenge, ref.Registry)
+	}
+
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+
+	query.Set("scope", "repository:"+ref.Repository+":"+actions)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return fmt.Errorf("error while getting token from %s: %w", realm.Host, err)
+	}
+
+	if c.opts.Username != "" {
+		req.SetBasicAuth(c.opts.Username, c.opts.Password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error while getting token from %s: %w", realm.Host, err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError(resp, "getting token for "+ref.Name())
+	}
+
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"` //nolint:tagliatelle // the token API uses snake case.
+	}{}
+
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return fmt.Errorf("error while parsing token from %s: %w", realm.Host, err)
+	}
+
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+
+	c.mu.Lock()
+	c.tokens[tokenKey(ref, actions)] = token.Token
+	c.mu.Unlock()
+
+	return nil
+}
+
+// parseChallenge parses a WWW-Authenticate header, for example
+// `Bearer realm="https://auth.docker.io/token",service="registry.docker.io"`.
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := map[string]string{}
+
+	for rest != "" {
+		var key, value string
+
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+
+		if key != "" {
+			params[strings.ToLower(strings.TrimSpace(key))] = value
+		}
+	}
+
+	return strings.ToLower(scheme), params
+}
+
+func statusError(resp *http.Response, action string) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024)) //nolint:mnd // enough for the error of the registry.
+
+	return fmt.Errorf("%w while %s: %s %s", ErrRegistry, action, resp.Status, strings.TrimSpace(string(msg)))
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package images
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/tool/kustomize"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	templatex "github.com/sighupio/furyctl/pkg/template"
+)
+
+const renderedManifestsFile = "images-manifests.yaml"
+
+// DistributionPaths are the inputs of the offline rendering of the distribution phase.
+type DistributionPaths struct {
+	DistroPath string
+	ConfigPath string
+	// WorkDir is the folder in which the manifests are rendered, it is removed afterwards.
+	WorkDir string
+	BinPath string
+}
+
+// RenderDistribution renders and builds the manifests of the distribution phase of the kind, as the apply
+// does, without connecting to the cluster: the components that depend on a default storage class are
+// rendered too, so their images are collected.
+func RenderDistribution(paths DistributionPaths, kind string, kfd config.KFD) ([]byte, error) {
+	defer os.RemoveAll(paths.WorkDir)
+
+	op := cluster.NewOperationPhase(paths.WorkDir, kfd.Tools, paths.BinPath)
+
+	if err := op.CreateRootFolder(); err != nil {
+		return nil, fmt.Errorf("error while rendering distribution manifests: %w", err)
+	}
+
+	merger, err := op.CreateFuryctlMerger(paths.DistroPath, paths.ConfigPath, "kfd-v1alpha2", strings.ToLower(kind))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating furyctl merger: %w", err)
+	}
+
+	mCfg, err := templatex.NewConfigWithoutData(merger, []string{"terraform", ".gitignore", "manifests/aws"})
+	if err != nil {
+		return nil, fmt.Errorf("error while creating template config: %w", err)
+	}
+
+	op.CopyPathsToConfig(&mCfg)
+
+	mCfg.Data["checks"] = map[any]any{
+		"storageClassAvailable": true,
+	}
+
+	if err := op.CopyFromTemplate(
+		mCfg,
+		"images",
+		filepath.Join(paths.DistroPath, "templates", cluster.OperationPhaseDistribution),
+		op.Path,
+		paths.ConfigPath,
+	); err != nil {
+		return nil, fmt.Errorf("error while rendering distribution manifests: %w", err)
+	}
+
+	manifestsDir := filepath.Join(op.Path, "manifests")
+	builtPath := filepath.Join(op.Path, renderedManifestsFile)
+
+	kustomizeRunner := kustomize.NewRunner(execx.NewStdExecutor(), kustomize.Paths{
+		Kustomize: op.KustomizePath,
+		WorkDir:   manifestsDir,
+	})
+
+	if err := kustomizeRunner.Build(".", builtPath); err != nil {
+		return nil, fmt.Errorf("error while building distribution manifests: %w", err)
+	}
+
+	content, err := os.ReadFile(builtPath)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading distribution manifests: %w", err)
+	}
+
+	return content, nil
+}
@@ -2,82 +2,207 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
+// Package kubernetes reads and writes the objects of the cluster with the Kubernetes API. kubectl is used
+// only where the apply semantics are needed.
 package kubernetes
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
-	"regexp"
-	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+const (
+	// AllNamespaces lists the objects of all the namespaces, as `kubectl get -A`.
+	AllNamespaces = "all"
+
+	requestTimeout = 30 * time.Second
+)
 
-	"github.com/sighupio/furyctl/internal/tool/kubectl"
-	execx "github.com/sighupio/furyctl/internal/x/exec"
+var (
+	ErrNotFound = errors.New("object not found in the cluster")
+	// ErrNoKubeconfig is the error of a client without a kubeconfig, as for a cluster that does not exist yet.
+	ErrNoKubeconfig = errors.New("no kubeconfig to reach the cluster")
 )
 
-// cmdOutRegex is a regexp used to extract the output of the kubectl command, which is wrapped in single quotes.
-var cmdOutRegex = regexp.MustCompile(`'(.*?)'`)
+//nolint:gochecknoglobals // The backoff of the requests that fail for a transient error.
+var defaultBackoff = wait.Backoff{
+	Steps:    5,
+	Duration: 200 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+}
 
 type Resource struct {
 	Name string `json:"name"`
 	Kind string `json:"kind"`
 }
 
+// Client talks with the API server of the cluster. The requests that fail for a transient error, for
+// example a timeout or an unavailable API server, are retried with a backoff.
 type Client struct {
-	kubeRunner *kubectl.Runner
+	clientset k8s.Interface
+	dynamic   dynamic.Interface
+	mapper    meta.RESTMapper
+	backoff   wait.Backoff
 }
 
-func NewClient(
-	binPath,
-	workDir string,
-	serverSide,
-	skipNotFound,
-	clientVersion bool,
-	executor execx.Executor,
-) *Client {
-	return &Client{
-		kubeRunner: kubectl.NewRunner(
-			executor,
-			kubectl.Paths{
-				Kubectl: binPath,
-				WorkDir: workDir,
-			},
-			serverSide,
-			skipNotFound,
-			clientVersion,
-		),
+// NewClient returns a client for the cluster of the kubeconfig. Without a kubeconfig, the client uses the
+// same rules as kubectl: the KUBECONFIG environment variable, then ~/.kube/config, and its current context.
+func NewClient(kubeconfig string) (*Client, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfig
+
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{}).ClientConfig()
+	if clientcmd.IsEmptyConfig(err) {
+		return nil, fmt.Errorf("%w: %w", ErrNoKubeconfig, err)
 	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error while loading kubeconfig: %w", err)
+	}
+
+	return NewClientFromConfig(cfg)
 }
 
-func (c *Client) ListNamespaceResources(resName, ns string) ([]Resource, error) {
-	var result []Resource
+// NewClientFromConfig returns a client for the cluster of the REST configuration.
+func NewClientFromConfig(cfg *rest.Config) (*Client, error) {
+	cfg = rest.CopyConfig(cfg)
+	cfg.Timeout = requestTimeout
+
+	// The warnings of the API server, for example on deprecated APIs, are not useful to the users of furyctl.
+	cfg.WarningHandler = rest.NoWarnings{}
 
-	cmdOut, err := c.kubeRunner.Get(false, ns, resName, "-o",
-		`jsonpath='[{range .items[*]}{"{"}"name": "{.metadata.name}", "kind": "{.kind}"{"}"},{end}]'`)
+	clientset, err := k8s.NewForConfig(cfg)
 	if err != nil {
-		return result, fmt.Errorf("error while reading resources from cluster: %w", err)
+		return nil, fmt.Errorf("error while creating kubernetes client: %w", err)
 	}
 
-	idx := cmdOutRegex.FindStringIndex(cmdOut)
-	if idx == nil {
-		return result, nil
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating kubernetes dynamic client: %w", err)
 	}
 
-	out := cmdOut[idx[0]+1 : idx[1]-1]
+	discovery := memory.NewMemCacheClient(clientset.Discovery())
+	mapper := restmapper.NewShortcutExpander(restmapper.NewDeferredDiscoveryRESTMapper(discovery), discovery, nil)
 
-	out = strings.ReplaceAll(out, ",]", "]")
+	return NewClientWithInterfaces(clientset, dynamicClient, mapper), nil
+}
 
-	if err := json.Unmarshal([]byte(out), &result); err != nil {
-		return result, fmt.Errorf("error while unmarshaling json: %w", err)
+// NewClientWithInterfaces returns a client that uses the given clients, for example the fake ones.
+func NewClientWithInterfaces(clientset k8s.Interface, dynamicClient dynamic.Interface, mapper meta.RESTMapper) *Client {
+	return &Client{
+		clientset: clientset,
+		dynamic:   dynamicClient,
+		mapper:    mapper,
+		backoff:   defaultBackoff,
 	}
+}
 
-	return result, nil
+// ServerVersion returns the version of the API server, and fails when the cluster is not reachable.
+func (c *Client) ServerVersion() (string, error) {
+	var version string
+
+	if err := c.do(func(_ context.Context) error {
+		info, err := c.clientset.Discovery().ServerVersion()
+		if err != nil {
+			return fmt.Errorf("error while getting server version: %w", err)
+		}
+
+		version = info.GitVersion
+
+		return nil
+	}); err != nil {
+		return "", err
+	}
+
+	return version, nil
 }
 
-func (c *Client) ToolVersion() (string, error) {
-	cmdOut, err := c.kubeRunner.Version()
+// ListNamespaceResources returns the objects of a resource in a namespace, or in all the namespaces with
+// AllNamespaces. The resource is a name as kubectl accepts it: plural, singular or short.
+func (c *Client) ListNamespaceResources(resName, ns string) ([]Resource, error) {
+	gvr, err := c.mapper.ResourceFor(schema.GroupVersionResource{Resource: resName})
 	if err != nil {
-		return "", fmt.Errorf("error while getting tool version: %w", err)
+		return nil, fmt.Errorf("error while finding resource %s: %w", resName, err)
+	}
+
+	var list *unstructured.UnstructuredList
+
+	if err := c.do(func(ctx context.Context) error {
+		var resourceClient dynamic.ResourceInterface = c.dynamic.Resource(gvr)
+		if ns != AllNamespaces {
+			resourceClient = c.dynamic.Resource(gvr).Namespace(ns)
+		}
+
+		l, err := resourceClient.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("error while reading resources from cluster: %w", err)
+		}
+
+		list = l
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	result := make([]Resource, 0, len(list.Items))
+
+	for _, item := range list.Items {
+		result = append(result, Resource{Name: item.GetName(), Kind: item.GetKind()})
+	}
+
+	return result, nil
+}
+
+// do runs the request, again while it fails for a transient error.
+func (c *Client) do(request func(ctx context.Context) error) error {
+	//nolint:wrapcheck // The requests wrap their errors.
+	return retry.OnError(c.backoff, isTransient, func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		defer cancel()
+
+		return request(ctx)
+	})
+}
+
+// isTransient tells whether a request that failed with the error can succeed if retried. The conflicts
+// are transient because the writes read the object again before each attempt.
+func isTransient(err error) bool {
+	return apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err) ||
+		apierrors.IsConflict(err) ||
+		apierrors.IsAlreadyExists(err) ||
+		utilnet.IsConnectionReset(err) ||
+		utilnet.IsProbableEOF(err)
+}
+
+// notFound returns ErrNotFound for the not found errors of the API server.
+func notFound(err error, kind, ns, name string) error {
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%w: %s %s/%s", ErrNotFound, kind, ns, name)
 	}
 
-	return cmdOut, nil
+	return fmt.Errorf("error while getting %s %s/%s: %w", kind, ns, name, err)
 }
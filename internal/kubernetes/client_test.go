@@ -5,128 +5,317 @@
 package kubernetes_test
 
 import (
-	"fmt"
-	"os"
 	"testing"
+	"time"
 
-	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
 
 	"github.com/sighupio/furyctl/internal/kubernetes"
-	execx "github.com/sighupio/furyctl/internal/x/exec"
 )
 
 func TestClient_ListNamespaceResources(t *testing.T) {
 	t.Parallel()
 
-	client := FakeClient(t)
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
 
-	resources, err := client.ListNamespaceResources("pod", "default")
-	if err != nil {
-		t.Errorf("expected no error, got %v", err)
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(podGVK, meta.RESTScopeNamespace)
+
+	objs := []runtime.Object{}
+
+	for _, pod := range []struct{ name, ns string }{
+		{"pod-1", "default"},
+		{"pod-2", "default"},
+		{"pod-3", "kube-system"},
+	} {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(podGVK)
+		u.SetName(pod.name)
+		u.SetNamespace(pod.ns)
+
+		objs = append(objs, u)
 	}
 
-	wantedResources := []kubernetes.Resource{
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{{Version: "v1", Resource: "pods"}: "PodList"},
+		objs...,
+	)
+
+	client := kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(), dynamicClient, mapper)
+
+	resources, err := client.ListNamespaceResources("pod", "default")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []kubernetes.Resource{
 		{Kind: "Pod", Name: "pod-1"},
 		{Kind: "Pod", Name: "pod-2"},
-		{Kind: "Pod", Name: "pod-3"},
+	}, resources)
+
+	resources, err = client.ListNamespaceResources("pods", kubernetes.AllNamespaces)
+	require.NoError(t, err)
+	assert.Len(t, resources, 3)
+
+	_, err = client.ListNamespaceResources("ingress", "default")
+	require.Error(t, err)
+}
+
+func TestClient_ServerVersion(t *testing.T) {
+	t.Parallel()
+
+	clientset := fake.NewSimpleClientset()
+
+	discovery, ok := clientset.Discovery().(*fakediscovery.FakeDiscovery)
+	require.True(t, ok)
+
+	discovery.FakedServerVersion = &version.Info{GitVersion: "v1.31.4"}
+
+	v, err := kubernetes.NewClientWithInterfaces(clientset, nil, nil).ServerVersion()
+	require.NoError(t, err)
+	assert.Equal(t, "v1.31.4", v)
+}
+
+func TestClient_Secrets(t *testing.T) {
+	t.Parallel()
+
+	client := kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(), nil, nil)
+
+	_, err := client.GetSecret("kube-system", "furyctl-config")
+	require.ErrorIs(t, err, kubernetes.ErrNotFound)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "furyctl-config", Namespace: "kube-system"},
+		Type:       corev1.SecretTypeOpaque,
+		Data:       map[string][]byte{"config": []byte("kind: OnPremises\n")},
 	}
-	if !cmp.Equal(resources, wantedResources) {
-		t.Errorf("expected resources to be %v, got: %v", wantedResources, resources)
+
+	require.NoError(t, client.ApplySecret(secret))
+
+	// A second apply replaces the data of the existing secret.
+	updated := secret.DeepCopy()
+	updated.Labels = map[string]string{"app": "furyctl"}
+	updated.Data = map[string][]byte{"config": []byte("kind: Immutable\n")}
+
+	require.NoError(t, client.ApplySecret(updated))
+
+	got, err := client.GetSecret("kube-system", "furyctl-config")
+	require.NoError(t, err)
+	assert.Equal(t, "kind: Immutable\n", string(got.Data["config"]))
+	assert.Equal(t, corev1.SecretTypeOpaque, got.Type)
+
+	require.NoError(t, client.ApplySecret(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "kube-system"},
+	}))
+
+	secrets, err := client.ListSecrets("kube-system", "app=furyctl")
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "furyctl-config", secrets[0].Name)
+
+	require.NoError(t, client.DeleteSecrets("kube-system", "furyctl-config", "missing"))
+
+	secrets, err = client.ListSecrets("kube-system", "")
+	require.NoError(t, err)
+	require.Len(t, secrets, 1)
+	assert.Equal(t, "other", secrets[0].Name)
+}
+
+func TestClient_ConfigMaps(t *testing.T) {
+	t.Parallel()
+
+	client := kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(), nil, nil)
+
+	_, err := client.GetConfigMap("kube-system", "furyctl-upgrade-state")
+	require.ErrorIs(t, err, kubernetes.ErrNotFound)
+
+	for _, state := range []string{"phases: {}\n", "phases: {distribution: {status: pending}}\n"} {
+		require.NoError(t, client.ApplyConfigMap(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "furyctl-upgrade-state", Namespace: "kube-system"},
+			Data:       map[string]string{"state": state},
+		}))
 	}
+
+	got, err := client.GetConfigMap("kube-system", "furyctl-upgrade-state")
+	require.NoError(t, err)
+	assert.Equal(t, "phases: {distribution: {status: pending}}\n", got.Data["state"])
+
+	configMaps, err := client.ListConfigMaps("kube-system", "")
+	require.NoError(t, err)
+	assert.Len(t, configMaps, 1)
+
+	require.NoError(t, client.DeleteConfigMaps("kube-system", "furyctl-upgrade-state"))
+
+	_, err = client.GetConfigMap("kube-system", "furyctl-upgrade-state")
+	require.ErrorIs(t, err, kubernetes.ErrNotFound)
 }
 
-func TestClient_ToolVersion(t *testing.T) {
+func TestClient_NotReadyNodes(t *testing.T) {
 	t.Parallel()
 
-	client := FakeClient(t)
+	client := kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+				{Key: "node.kubernetes.io/not-ready", Effect: corev1.TaintEffectNoSchedule},
+			}},
+		},
+	), nil, nil)
+
+	nodes, err := client.ListNodes()
+	require.NoError(t, err)
+	assert.Len(t, nodes, 2)
+
+	notReady, err := client.NotReadyNodes()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"node-2"}, notReady)
+}
 
-	version, err := client.ToolVersion()
-	if err != nil {
-		t.Errorf("expected no error, got %v", err)
+func TestClient_StorageClasses(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc    string
+		classes []runtime.Object
+		want    kubernetes.StorageClasses
+	}{
+		{
+			desc: "no storage classes",
+			want: kubernetes.StorageClasses{},
+		},
+		{
+			desc:    "no default storage class",
+			classes: []runtime.Object{&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "local"}}},
+			want:    kubernetes.StorageClasses{Available: true},
+		},
+		{
+			desc: "default storage class",
+			classes: []runtime.Object{
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "local"}},
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+					Name:        "gp3",
+					Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"},
+				}},
+			},
+			want: kubernetes.StorageClasses{Available: true, Default: "gp3"},
+		},
+		{
+			desc: "beta default storage class",
+			classes: []runtime.Object{&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{
+				Name:        "standard",
+				Annotations: map[string]string{"storageclass.beta.kubernetes.io/is-default-class": "true"},
+			}}},
+			want: kubernetes.StorageClasses{Available: true, Default: "standard"},
+		},
 	}
 
-	if len(version) == 0 {
-		t.Errorf("expected version to be not empty")
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			client := kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(tC.classes...), nil, nil)
+
+			got, err := client.StorageClasses()
+			require.NoError(t, err)
+			assert.Equal(t, tC.want, got)
+		})
 	}
 }
 
-func TestHelperProcess(t *testing.T) {
+func TestClient_WaitForPodsReady(t *testing.T) {
 	t.Parallel()
 
-	t.Helper()
+	pod := func(name string, ready corev1.ConditionStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ingress-nginx", Labels: map[string]string{"app": "nginx"}},
+			Status: corev1.PodStatus{Conditions: []corev1.PodCondition{
+				{Type: corev1.PodReady, Status: ready},
+			}},
+		}
+	}
 
-	args := os.Args
+	clientset := fake.NewSimpleClientset(
+		pod("nginx-1", corev1.ConditionTrue),
+		pod("nginx-2", corev1.ConditionTrue),
+	)
 
-	if len(args) < 3 || args[1] != "-test.run=TestHelperProcess" {
-		return
-	}
+	// A transient error is retried within the same poll.
+	failures := 1
 
-	cmd, subcmd := args[3], args[4]
-
-	switch cmd {
-	case "kubectl":
-		switch subcmd {
-		case "version":
-			fmt.Fprintf(os.Stdout, "{\n"+
-				"\"clientVersion\": {\n"+
-				"\"major\": \"1\",\n"+
-				"\"minor\": \"21\",\n"+
-				"\"gitVersion\": \"v1.21.1\",\n"+
-				"\"gitCommit\": \"xxxxx\",\n"+
-				"\"gitTreeState\": \"clean\",\n"+
-				"\"buildDate\": \"2021-05-12T14:00:00Z\",\n"+
-				"\"goVersion\": \"go1.16.4\",\n"+
-				"\"compiler\": \"gc\",\n"+
-				"\"platform\": \"darwin/amd64\"\n"+
-				"}\n"+
-				"}\n")
-
-		case "get":
-			resType := args[7]
-			if args[5] == "-A" {
-				resType = args[6]
-			}
-
-			switch resType {
-			case "pv":
-				fmt.Fprintf(os.Stdout, "'pv-1 pv-2 pv-3'")
-
-			case "svc":
-				fmt.Fprintf(os.Stdout, "'svc-1 svc-2 svc-3'")
-
-			case "ingress":
-				fmt.Fprintf(os.Stdout,
-					"'[{\"Name\": \"ingress-1\", \"Host\": [\"host-1\"]},"+
-						"{\"Name\": \"ingress-2\", \"Host\": [\"host-2\"]},"+
-						"{\"Name\": \"ingress-3\", \"Host\": [\"host-3\"]}]'")
-
-			case "pod":
-				fmt.Fprintf(os.Stdout,
-					"'[{\"Name\": \"pod-1\", \"Kind\": \"Pod\"},"+
-						"{\"Name\": \"pod-2\", \"Kind\": \"Pod\"},"+
-						"{\"Name\": \"pod-3\", \"Kind\": \"Pod\"}]'")
-			}
-
-		case "delete":
-			fmt.Fprintf(os.Stdout, "res \"res-1\" deleted")
+	clientset.PrependReactor("list", "pods", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+		if failures == 0 {
+			return false, nil, nil
 		}
 
-	default:
-		fmt.Fprintf(os.Stdout, "command not found")
-	}
+		failures--
+
+		return true, nil, apierrors.NewServiceUnavailable("etcd leader changed")
+	})
+
+	client := kubernetes.NewClientWithInterfaces(clientset, nil, nil)
+
+	require.NoError(t, client.WaitForPodsReady("ingress-nginx", "app=nginx", time.Second))
+	assert.Zero(t, failures)
+
+	client = kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(
+		pod("nginx-1", corev1.ConditionTrue),
+		pod("nginx-2", corev1.ConditionFalse),
+	), nil, nil)
 
-	os.Exit(0)
+	err := client.WaitForPodsReady("ingress-nginx", "app=nginx", 100*time.Millisecond)
+	require.ErrorIs(t, err, kubernetes.ErrPodsNotReady)
+	assert.ErrorContains(t, err, "nginx-2")
+
+	// Without pods there is nothing ready yet.
+	err = client.WaitForPodsReady("monitoring", "app=prometheus", 100*time.Millisecond)
+	require.ErrorIs(t, err, kubernetes.ErrPodsNotReady)
 }
 
-func FakeClient(t *testing.T) *kubernetes.Client {
-	t.Helper()
+func TestClient_RetriesTransientErrors(t *testing.T) {
+	t.Parallel()
+
+	clientset := fake.NewSimpleClientset(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "furyctl-config", Namespace: "kube-system"},
+	})
 
-	return kubernetes.NewClient(
-		"kubectl",
-		"",
-		true,
-		true,
-		true,
-		execx.NewFakeExecutor("TestHelperProcess"),
-	)
+	failures := 2
+
+	clientset.PrependReactor("get", "secrets", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+		if failures == 0 {
+			return false, nil, nil
+		}
+
+		failures--
+
+		return true, nil, apierrors.NewServiceUnavailable("etcd leader changed")
+	})
+
+	client := kubernetes.NewClientWithInterfaces(clientset, nil, nil)
+
+	secret, err := client.GetSecret("kube-system", "furyctl-config")
+	require.NoError(t, err)
+	assert.Equal(t, "furyctl-config", secret.Name)
+	assert.Zero(t, failures)
+
+	// The errors that do not go away with a retry are returned at once.
+	clientset.PrependReactor("list", "nodes", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "", nil)
+	})
+
+	_, err = client.ListNodes()
+	require.Error(t, err)
+	assert.True(t, apierrors.IsForbidden(err))
 }
@@ -0,0 +1,167 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	notReadyTaint = "node.kubernetes.io/not-ready"
+
+	defaultStorageClassAnnotation     = "storageclass.kubernetes.io/is-default-class"
+	betaDefaultStorageClassAnnotation = "storageclass.beta.kubernetes.io/is-default-class"
+
+	podsPollInterval = 2 * time.Second
+)
+
+var ErrPodsNotReady = errors.New("pods not ready")
+
+// StorageClasses tells which storage classes the cluster has.
+type StorageClasses struct {
+	// Available is true when the cluster has at least one storage class.
+	Available bool
+	// Default is the name of the default storage class, empty if there is none.
+	Default string
+}
+
+// ListNodes returns the nodes of the cluster.
+func (c *Client) ListNodes() ([]corev1.Node, error) {
+	var nodes []corev1.Node
+
+	if err := c.do(func(ctx context.Context) error {
+		list, err := c.clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("error while listing nodes: %w", err)
+		}
+
+		nodes = list.Items
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return nodes, nil
+}
+
+// NotReadyNodes returns the names of the nodes that have the not-ready taint.
+func (c *Client) NotReadyNodes() ([]string, error) {
+	nodes, err := c.ListNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	notReady := []string{}
+
+	for _, node := range nodes {
+		for _, taint := range node.Spec.Taints {
+			if taint.Key == notReadyTaint {
+				notReady = append(notReady, node.Name)
+
+				break
+			}
+		}
+	}
+
+	return notReady, nil
+}
+
+// StorageClasses returns whether the cluster has storage classes and which one is the default.
+func (c *Client) StorageClasses() (StorageClasses, error) {
+	var classes []storagev1.StorageClass
+
+	if err := c.do(func(ctx context.Context) error {
+		list, err := c.clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("error while listing storage classes: %w", err)
+		}
+
+		classes = list.Items
+
+		return nil
+	}); err != nil {
+		return StorageClasses{}, err
+	}
+
+	result := StorageClasses{Available: len(classes) > 0}
+
+	for _, class := range classes {
+		if class.Annotations[defaultStorageClassAnnotation] == "true" ||
+			class.Annotations[betaDefaultStorageClassAnnotation] == "true" {
+			result.Default = class.Name
+
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// WaitForPodsReady waits until the namespace has pods that match the label selector and all of them are
+// ready, or the timeout expires. Each poll lists the pods with the retries of the other requests.
+func (c *Client) WaitForPodsReady(ns, labelSelector string, timeout time.Duration) error {
+	var notReady []string
+
+	err := wait.PollUntilContextTimeout(
+		context.Background(),
+		podsPollInterval,
+		timeout,
+		true,
+		func(context.Context) (bool, error) {
+			var pods []corev1.Pod
+
+			if err := c.do(func(ctx context.Context) error {
+				list, err := c.clientset.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+				if err != nil {
+					return fmt.Errorf("error while listing pods in %s: %w", ns, err)
+				}
+
+				pods = list.Items
+
+				return nil
+			}); err != nil {
+				return false, err
+			}
+
+			notReady = []string{}
+
+			for _, pod := range pods {
+				if !podReady(pod) {
+					notReady = append(notReady, pod.Name)
+				}
+			}
+
+			return len(pods) > 0 && len(notReady) == 0, nil
+		},
+	)
+	if err != nil {
+		if wait.Interrupted(err) {
+			return fmt.Errorf("%w in %s with selector %q after %s: %v", ErrPodsNotReady, ns, labelSelector, timeout, notReady)
+		}
+
+		return fmt.Errorf("error while waiting for pods in %s: %w", ns, err)
+	}
+
+	return nil
+}
+
+func podReady(pod corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GetSecret returns the secret, or ErrNotFound.
+func (c *Client) GetSecret(ns, name string) (*corev1.Secret, error) {
+	var secret *corev1.Secret
+
+	if err := c.do(func(ctx context.Context) error {
+		s, err := c.clientset.CoreV1().Secrets(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return notFound(err, "secret", ns, name)
+		}
+
+		secret = s
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return secret, nil
+}
+
+// ListSecrets returns the secrets of the namespace that match the label selector.
+func (c *Client) ListSecrets(ns, labelSelector string) ([]corev1.Secret, error) {
+	var secrets []corev1.Secret
+
+	if err := c.do(func(ctx context.Context) error {
+		list, err := c.clientset.CoreV1().Secrets(ns).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return fmt.Errorf("error while listing secrets in %s: %w", ns, err)
+		}
+
+		secrets = list.Items
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// ApplySecret creates the secret, or replaces the labels, the type and the data of the existing one.
+func (c *Client) ApplySecret(secret *corev1.Secret) error {
+	return c.do(func(ctx context.Context) error {
+		secrets := c.clientset.CoreV1().Secrets(secret.Namespace)
+
+		current, err := secrets.Get(ctx, secret.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("error while creating secret %s/%s: %w", secret.Namespace, secret.Name, err)
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("error while getting secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+
+		current.Labels = secret.Labels
+		current.Data = secret.Data
+		current.StringData = secret.StringData
+
+		if secret.Type != "" {
+			current.Type = secret.Type
+		}
+
+		if _, err := secrets.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error while updating secret %s/%s: %w", secret.Namespace, secret.Name, err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteSecrets deletes the secrets, the ones that are not there are ignored.
+func (c *Client) DeleteSecrets(ns string, names ...string) error {
+	for _, name := range names {
+		if err := c.do(func(ctx context.Context) error {
+			err := c.clientset.CoreV1().Secrets(ns).Delete(ctx, name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error while deleting secret %s/%s: %w", ns, name, err)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetConfigMap returns the config map, or ErrNotFound.
+func (c *Client) GetConfigMap(ns, name string) (*corev1.ConfigMap, error) {
+	var configMap *corev1.ConfigMap
+
+	if err := c.do(func(ctx context.Context) error {
+		cm, err := c.clientset.CoreV1().ConfigMaps(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return notFound(err, "config map", ns, name)
+		}
+
+		configMap = cm
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return configMap, nil
+}
+
+// ListConfigMaps returns the config maps of the namespace that match the label selector.
+func (c *Client) ListConfigMaps(ns, labelSelector string) ([]corev1.ConfigMap, error) {
+	var configMaps []corev1.ConfigMap
+
+	if err := c.do(func(ctx context.Context) error {
+		list, err := c.clientset.CoreV1().ConfigMaps(ns).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return fmt.Errorf("error while listing config maps in %s: %w", ns, err)
+		}
+
+		configMaps = list.Items
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return configMaps, nil
+}
+
+// ApplyConfigMap creates the config map, or replaces the labels and the data of the existing one.
+func (c *Client) ApplyConfigMap(configMap *corev1.ConfigMap) error {
+	return c.do(func(ctx context.Context) error {
+		configMaps := c.clientset.CoreV1().ConfigMaps(configMap.Namespace)
+
+		current, err := configMaps.Get(ctx, configMap.Name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			if _, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+				return fmt.Errorf("error while creating config map %s/%s: %w", configMap.Namespace, configMap.Name, err)
+			}
+
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("error while getting config map %s/%s: %w", configMap.Namespace, configMap.Name, err)
+		}
+
+		current.Labels = configMap.Labels
+		current.Data = configMap.Data
+		current.BinaryData = configMap.BinaryData
+
+		if _, err := configMaps.Update(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error while updating config map %s/%s: %w", configMap.Namespace, configMap.Name, err)
+		}
+
+		return nil
+	})
+}
+
+// DeleteConfigMaps deletes the config maps, the ones that are not there are ignored.
+func (c *Client) DeleteConfigMaps(ns string, names ...string) error {
+	for _, name := range names {
+		if err := c.do(func(ctx context.Context) error {
+			err := c.clientset.CoreV1().ConfigMaps(ns).Delete(ctx, name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("error while deleting config map %s/%s: %w", ns, name, err)
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,456 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/tool/kubectl"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+const (
+	LeaseName      = "furyctl-lock"
+	LeaseNamespace = "kube-system"
+
+	annotationHost    = "furyctl.sighup.io/lock-host"
+	annotationPID     = "furyctl.sighup.io/lock-pid"
+	annotationCommand = "furyctl.sighup.io/lock-command"
+
+	// The format of the MicroTime fields of the Kubernetes API.
+	microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+	leasePath = "/apis/coordination.k8s.io/v1/namespaces/" + LeaseNamespace + "/leases/" + LeaseName
+
+	// LeaseDuration is how long the Lease holds the cluster without being renewed. The execution that holds
+	// it renews it every LeaseRenewInterval, so it expires only when the execution stops without releasing it.
+	LeaseDuration      = 60 * time.Second
+	LeaseRenewInterval = LeaseDuration / 3
+)
+
+var errLeaseNotFound = errors.New("lease not found")
+
+type lease struct {
+	APIVersion string        `yaml:"apiVersion"`
+	Kind       string        `yaml:"kind"`
+	Metadata   leaseMetadata `yaml:"metadata"`
+	Spec       leaseSpec     `yaml:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string            `yaml:"name"`
+	Namespace       string            `yaml:"namespace"`
+	ResourceVersion string            `yaml:"resourceVersion,omitempty"`
+	Annotations     map[string]string `yaml:"annotations,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `yaml:"holderIdentity"`
+	AcquireTime          string `yaml:"acquireTime"`
+	RenewTime            string `yaml:"renewTime,omitempty"`
+	LeaseDurationSeconds int    `yaml:"leaseDurationSeconds,omitempty"`
+}
+
+// leaseRecord is the Lease as read from the cluster.
+type leaseRecord struct {
+	holder          *Info
+	resourceVersion string
+	// expiresAt is zero for the Leases that furyctl created before they had a duration.
+	expiresAt time.Time
+}
+
+// Cluster is a coordination.k8s.io/v1 Lease in the kube-system namespace of the cluster. Creating a
+// resource that already exists fails, so the creation of the Lease is the atomic step of the lock.
+type Cluster struct {
+	kubeRunner *kubectl.Runner
+	kubeconfig string
+	info       Info
+	acquired   bool
+	stopRenew  chan struct{}
+	renewDone  chan struct{}
+}
+
+func NewCluster(kubectlPath, kubeconfig, workDir string, info Info) *Cluster {
+	if workDir == "" {
+		workDir = os.TempDir()
+	}
+
+	return &Cluster{
+		kubeRunner: kubectl.NewRunner(
+			execx.NewStdExecutor(),
+			kubectl.Paths{
+				Kubectl: kubectlPath,
+				WorkDir: workDir,
+			},
+			false,
+			true,
+			false,
+		),
+		kubeconfig: kubeconfig,
+		info:       info,
+	}
+}
+
+// Acquire creates the Lease and renews it until Release. A cluster without a kubeconfig, as one that does
+// not exist yet, cannot hold the lock: the execution goes on with the local lock only, as it would for the
+// first apply of a cluster. A cluster whose Lease cannot be read, for example because furyctl cannot reach
+// it or is forbidden, is an error.
+func (c *Cluster) Acquire() error {
+	if !c.hasKubeconfig() {
+		logrus.Warnf("Cannot lock the cluster, only the local lock protects this execution: %v", ErrNoKubeconfig)
+
+		return nil
+	}
+
+	err := c.create()
+	if err == nil {
+		c.hold()
+
+		return nil
+	}
+
+	record, getErr := c.get()
+
+	switch {
+	case errors.Is(getErr, errLeaseNotFound):
+		return fmt.Errorf("error while creating lock lease: %w", err)
+
+	case getErr != nil:
+		return fmt.Errorf("error while creating lock lease: %w: %w", err, getErr)
+
+	case c.isStale(record):
+		logrus.Warnf("Recovering stale lock lease %s/%s, it was %s", LeaseNamespace, LeaseName, record.holder)
+
+		if err := c.delete(record.resourceVersion); err != nil {
+			return err
+		}
+
+		if err := c.create(); err != nil {
+			return fmt.Errorf("error while creating lock lease: %w", err)
+		}
+
+		c.hold()
+
+		return nil
+
+	default:
+		return fmt.Errorf("%w: %s", ErrLocked, record.holder)
+	}
+}
+
+// hasKubeconfig reports whether one of the files of the kubeconfig, a list as the KUBECONFIG environment
+// variable, exists.
+func (c *Cluster) hasKubeconfig() bool {
+	for _, kubeconfig := range filepath.SplitList(c.kubeconfig) {
+		if _, err := os.Stat(kubeconfig); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Release deletes the Lease if this execution still holds it. A Lease that another execution created after
+// a furyctl lock break stays in place.
+func (c *Cluster) Release() error {
+	if !c.acquired {
+		return nil
+	}
+
+	close(c.stopRenew)
+	<-c.renewDone
+
+	record, err := c.get()
+
+	switch {
+	case errors.Is(err, errLeaseNotFound):
+		logrus.Warnf("The lock lease %s/%s was deleted during this execution", LeaseNamespace, LeaseName)
+
+	case err != nil:
+		return err
+
+	case !c.holds(record.holder):
+		logrus.Warnf("Leaving the lock lease %s/%s in place, it is now %s", LeaseNamespace, LeaseName, record.holder)
+
+	default:
+		if err := c.delete(record.resourceVersion); err != nil {
+			return err
+		}
+	}
+
+	c.acquired = false
+
+	return nil
+}
+
+func (c *Cluster) Status() ([]Status, error) {
+	status := Status{Backend: BackendCluster}
+
+	if c.kubeconfig == "" {
+		return nil, ErrNoKubeconfig
+	}
+
+	record, err := c.get()
+	if errors.Is(err, errLeaseNotFound) {
+		return []Status{status}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	status.Holder = record.holder
+	status.Stale = c.isStale(record)
+	status.Locked = !status.Stale
+
+	return []Status{status}, nil
+}
+
+// Break deletes the Lease. There is nothing to break when the Lease does not exist.
+func (c *Cluster) Break() error {
+	if c.kubeconfig == "" {
+		return ErrNoKubeconfig
+	}
+
+	if _, err := c.get(); errors.Is(err, errLeaseNotFound) {
+		return nil
+	}
+
+	if err := c.kubeRunner.Delete(c.withKubeconfig("lease", LeaseName, "-n", LeaseNamespace)...); err != nil {
+		return fmt.Errorf("error while deleting lock lease: %w", err)
+	}
+
+	return nil
+}
+
+// delete deletes the Lease only if it is still at resourceVersion, so that a Lease that another execution
+// created in the meantime stays in place.
+func (c *Cluster) delete(resourceVersion string) error {
+	body, err := json.Marshal(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "DeleteOptions",
+		"preconditions": map[string]string{
+			"resourceVersion": resourceVersion,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error while marshalling lock lease delete options: %w", err)
+	}
+
+	bodyPath, err := writeManifest(body)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(bodyPath)
+
+	if err := c.kubeRunner.Delete(c.withKubeconfig("--raw", leasePath, "-f", bodyPath)...); err != nil {
+		return fmt.Errorf("error while deleting lock lease: %w", err)
+	}
+
+	return nil
+}
+
+// holds reports whether the holder is this execution.
+func (c *Cluster) holds(holder *Info) bool {
+	return holder.Holder == c.info.Holder && holder.Host == c.info.Host && holder.PID == c.info.PID
+}
+
+// isStale reports whether the Lease expired, because its holder stopped renewing it, or whether its holder
+// is an execution of this host that is no longer running.
+func (c *Cluster) isStale(record *leaseRecord) bool {
+	if !record.expiresAt.IsZero() && time.Now().After(record.expiresAt) {
+		return true
+	}
+
+	holder := record.holder
+
+	return holder.Host == c.info.Host && holder.PID != c.info.PID && !pidAlive(holder.PID)
+}
+
+// hold marks the Lease as acquired and starts renewing it.
+func (c *Cluster) hold() {
+	c.acquired = true
+	c.stopRenew = make(chan struct{})
+	c.renewDone = make(chan struct{})
+
+	go c.renewLoop()
+}
+
+// renewLoop renews the Lease every LeaseRenewInterval, until Release stops it or the Lease is no longer
+// held by this execution.
+func (c *Cluster) renewLoop() {
+	defer close(c.renewDone)
+
+	for {
+		select {
+		case <-c.stopRenew:
+			return
+
+		case <-time.After(LeaseRenewInterval):
+			if err := c.renew(); err != nil {
+				logrus.Warnf("Cannot renew the lock lease %s/%s: %v", LeaseNamespace, LeaseName, err)
+
+				if errors.Is(err, ErrLocked) || errors.Is(err, errLeaseNotFound) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// renew moves the renew time of the Lease to now, if this execution still holds it. The patch carries the
+// resource version of the Lease, so it fails if another execution replaced the Lease in the meantime.
+func (c *Cluster) renew() error {
+	record, err := c.get()
+	if err != nil {
+		return err
+	}
+
+	if !c.holds(record.holder) {
+		return fmt.Errorf("%w: %s", ErrLocked, record.holder)
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]string{
+			"resourceVersion": record.resourceVersion,
+		},
+		"spec": map[string]string{
+			"renewTime": time.Now().UTC().Format(microTimeFormat),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error while marshalling lock lease patch: %w", err)
+	}
+
+	if err := c.kubeRunner.Patch(
+		c.withKubeconfig("lease", LeaseName, "-n", LeaseNamespace, "--type", "merge", "-p", string(patch))...,
+	); err != nil {
+		return fmt.Errorf("error while renewing lock lease: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Cluster) create() error {
+	manifest, err := yamlx.MarshalV3(lease{
+		APIVersion: "coordination.k8s.io/v1",
+		Kind:       "Lease",
+		Metadata: leaseMetadata{
+			Name:      LeaseName,
+			Namespace: LeaseNamespace,
+			Annotations: map[string]string{
+				annotationHost:    c.info.Host,
+				annotationPID:     strconv.Itoa(c.info.PID),
+				annotationCommand: c.info.Command,
+			},
+		},
+		Spec: leaseSpec{
+			HolderIdentity:       c.info.Holder,
+			AcquireTime:          c.info.StartTime.UTC().Format(microTimeFormat),
+			RenewTime:            time.Now().UTC().Format(microTimeFormat),
+			LeaseDurationSeconds: int(LeaseDuration.Seconds()),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error while marshalling lock lease: %w", err)
+	}
+
+	manifestPath, err := writeManifest(manifest)
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(manifestPath)
+
+	if err := c.kubeRunner.Create(manifestPath, c.withKubeconfig()...); err != nil {
+		return fmt.Errorf("error while creating lock lease: %w", err)
+	}
+
+	return nil
+}
+
+// get returns the holder of the Lease, its resource version and when it expires.
+func (c *Cluster) get() (*leaseRecord, error) {
+	out, err := c.kubeRunner.Get(false, LeaseNamespace, c.withKubeconfig("lease", LeaseName, "-o", "yaml")...)
+	if err != nil {
+		if strings.Contains(out, "NotFound") {
+			return nil, errLeaseNotFound
+		}
+
+		return nil, fmt.Errorf("error while getting lock lease: %w", err)
+	}
+
+	l := lease{}
+
+	if err := yamlx.UnmarshalV3([]byte(out), &l); err != nil {
+		return nil, fmt.Errorf("error while parsing lock lease: %w", err)
+	}
+
+	info := &Info{
+		Holder:  l.Spec.HolderIdentity,
+		Host:    l.Metadata.Annotations[annotationHost],
+		Command: l.Metadata.Annotations[annotationCommand],
+	}
+
+	if pid, err := strconv.Atoi(l.Metadata.Annotations[annotationPID]); err == nil {
+		info.PID = pid
+	}
+
+	if t, err := time.Parse(microTimeFormat, l.Spec.AcquireTime); err == nil {
+		info.StartTime = t
+	}
+
+	record := &leaseRecord{holder: info, resourceVersion: l.Metadata.ResourceVersion}
+
+	if l.Spec.LeaseDurationSeconds > 0 {
+		renewed := info.StartTime
+
+		if t, err := time.Parse(microTimeFormat, l.Spec.RenewTime); err == nil {
+			renewed = t
+		}
+
+		record.expiresAt = renewed.Add(time.Duration(l.Spec.LeaseDurationSeconds) * time.Second)
+	}
+
+	return record, nil
+}
+
+// writeManifest writes a manifest for kubectl to a temporary file, and returns its path.
+func writeManifest(content []byte) (string, error) {
+	f, err := os.CreateTemp("", "furyctl-lock-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("error while creating lock lease manifest: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+
+		return "", fmt.Errorf("error while creating lock lease manifest: %w", err)
+	}
+
+	if err := iox.WriteFile(f.Name(), content); err != nil {
+		os.Remove(f.Name())
+
+		return "", fmt.Errorf("error while writing lock lease manifest: %w", err)
+	}
+
+	return f.Name(), nil
+}
+
+func (c *Cluster) withKubeconfig(params ...string) []string {
+	return append(params, "--kubeconfig", c.kubeconfig)
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package lock_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/lock"
+)
+
+// fakeKubectl writes a kubectl that records the created Lease in dir/creates, prints the Lease of
+// dir/lease.yaml or a NotFound error when there is none, and records the delete calls, with their body, in
+// dir/deletes. With dir/error, the create and the get fail with its content.
+func fakeKubectl(t *testing.T, dir string) string {
+	t.Helper()
+
+	script := fmt.Sprintf(`#!/bin/sh
+if [ -f %[1]s/error ]; then
+  case "$1" in create|get) cat %[1]s/error; exit 1 ;; esac
+fi
+case "$1" in
+  create)
+    while [ $# -gt 0 ]; do
+      if [ "$1" = "-f" ]; then cat "$2" >> %[1]s/creates; fi
+      shift
+    done ;;
+  get)
+    if [ ! -f %[1]s/lease.yaml ]; then
+      echo 'Error from server (NotFound): leases.coordination.k8s.io "furyctl-lock" not found'
+      exit 1
+    fi
+    cat %[1]s/lease.yaml ;;
+  delete)
+    echo "$@" >> %[1]s/deletes
+    while [ $# -gt 0 ]; do
+      if [ "$1" = "-f" ]; then cat "$2" >> %[1]s/deletes; fi
+      shift
+    done ;;
+esac
+`, dir)
+
+	path := filepath.Join(dir, "kubectl")
+	require.NoError(t, os.WriteFile(path, []byte(script), 0o755))
+
+	return path
+}
+
+// writeKubeconfig writes the kubeconfig of the cluster in dir, the fake kubectl does not read it.
+func writeKubeconfig(t *testing.T, dir string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, "kubeconfig")
+	require.NoError(t, os.WriteFile(path, []byte("apiVersion: v1\nkind: Config\n"), 0o600))
+
+	return path
+}
+
+func writeLease(t *testing.T, dir string, info lock.Info, resourceVersion string) {
+	t.Helper()
+
+	writeLeaseRenewedAt(t, dir, info, resourceVersion, time.Now())
+}
+
+func writeLeaseRenewedAt(t *testing.T, dir string, info lock.Info, resourceVersion string, renewTime time.Time) {
+	t.Helper()
+
+	lease := fmt.Sprintf(`apiVersion: coordination.k8s.io/v1
+kind: Lease
+metadata:
+  name: furyctl-lock
+  namespace: kube-system
+  resourceVersion: "%s"
+  annotations:
+    furyctl.sighup.io/lock-host: %s
+    furyctl.sighup.io/lock-pid: "%d"
+spec:
+  holderIdentity: %s
+  renewTime: "%s"
+  leaseDurationSeconds: %d
+`, resourceVersion, info.Host, info.PID, info.Holder,
+		renewTime.UTC().Format("2006-01-02T15:04:05.000000Z07:00"), int(lock.LeaseDuration.Seconds()))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "lease.yaml"), []byte(lease), 0o600))
+}
+
+func TestCluster_ReleaseDeletesItsLease(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	info := lock.NewInfo()
+
+	c := lock.NewCluster(fakeKubectl(t, dir), writeKubeconfig(t, dir), dir, info)
+
+	require.NoError(t, c.Acquire())
+
+	writeLease(t, dir, info, "42")
+
+	require.NoError(t, c.Release())
+
+	deletes, err := os.ReadFile(filepath.Join(dir, "deletes"))
+	require.NoError(t, err)
+	assert.Contains(t, string(deletes), "--raw /apis/coordination.k8s.io/v1/namespaces/kube-system/leases/furyctl-lock")
+	assert.Contains(t, string(deletes), `"preconditions":{"resourceVersion":"42"}`)
+}
+
+func TestCluster_ReleaseKeepsTheLeaseOfAnotherExecution(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	info := lock.NewInfo()
+
+	c := lock.NewCluster(fakeKubectl(t, dir), writeKubeconfig(t, dir), dir, info)
+
+	require.NoError(t, c.Acquire())
+
+	// The lock was broken and another execution took it.
+	other := info
+	other.Holder = "bob@other-host"
+	other.Host = "other-host"
+
+	writeLease(t, dir, other, "43")
+
+	require.NoError(t, c.Release())
+
+	assert.NoFileExists(t, filepath.Join(dir, "deletes"))
+}
+
+func TestCluster_AcquireCreatesALeaseWithADuration(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	c := lock.NewCluster(fakeKubectl(t, dir), writeKubeconfig(t, dir), dir, lock.NewInfo())
+
+	require.NoError(t, c.Acquire())
+
+	creates, err := os.ReadFile(filepath.Join(dir, "creates"))
+	require.NoError(t, err)
+	assert.Contains(t, string(creates), "leaseDurationSeconds: 60")
+	assert.Contains(t, string(creates), "renewTime:")
+}
+
+func TestCluster_StatusReportsAnExpiredLeaseAsStale(t *testing.T) {
+	t.Parallel()
+
+	other := lock.NewInfo()
+	other.Holder = "ci@runner"
+	other.Host = "runner"
+
+	testCases := []struct {
+		desc      string
+		renewTime time.Time
+		wantStale bool
+	}{
+		{
+			desc:      "renewed within the lease duration",
+			renewTime: time.Now(),
+		},
+		{
+			desc:      "not renewed within the lease duration",
+			renewTime: time.Now().Add(-2 * lock.LeaseDuration),
+			wantStale: true,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+
+			writeLeaseRenewedAt(t, dir, other, "42", tC.renewTime)
+
+			c := lock.NewCluster(fakeKubectl(t, dir), "kubeconfig", dir, lock.NewInfo())
+
+			statuses, err := c.Status()
+			require.NoError(t, err)
+			require.Len(t, statuses, 1)
+			assert.Equal(t, tC.wantStale, statuses[0].Stale)
+			assert.Equal(t, !tC.wantStale, statuses[0].Locked)
+		})
+	}
+}
+
+func TestCluster_BreakWithoutALease(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	c := lock.NewCluster(fakeKubectl(t, dir), "kubeconfig", dir, lock.NewInfo())
+
+	require.NoError(t, c.Break())
+
+	assert.NoFileExists(t, filepath.Join(dir, "deletes"))
+}
+
+func TestCluster_AcquireWithoutAKubeconfig(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// The cluster does not exist yet: the local lock protects the execution.
+	c := lock.NewCluster(fakeKubectl(t, dir), filepath.Join(dir, "kubeconfig"), dir, lock.NewInfo())
+
+	require.NoError(t, c.Acquire())
+
+	assert.NoFileExists(t, filepath.Join(dir, "creates"))
+}
+
+func TestCluster_AcquireALeaseThatCannotBeRead(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	forbidden := `Error from server (Forbidden): leases.coordination.k8s.io "furyctl-lock" is forbidden`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "error"), []byte(forbidden), 0o600))
+
+	c := lock.NewCluster(fakeKubectl(t, dir), writeKubeconfig(t, dir), dir, lock.NewInfo())
+
+	require.Error(t, c.Acquire())
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/process"
+	"github.com/sirupsen/logrus"
+
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+// Local is a lock file in the temporary directory, protected with flock(2). The kernel releases the
+// flock when the holder exits, even when it crashes, so a lock file that is left behind never blocks
+// the next execution: the record in it only tells who ran last.
+type Local struct {
+	Path string
+	// LegacyPath is the PID file that the previous versions of furyctl used as lock.
+	LegacyPath string
+
+	info Info
+	file *os.File
+}
+
+func NewLocal(clusterName string, info Info) *Local {
+	return &Local{
+		Path:       filepath.Join(os.TempDir(), "furyctl-"+clusterName+".lock"),
+		LegacyPath: filepath.Join(os.TempDir(), "furyctl-"+clusterName),
+		info:       info,
+	}
+}
+
+func (l *Local) Acquire() error {
+	if err := l.recoverLegacyLock(); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.Path, os.O_RDWR|os.O_CREATE, iox.RWPermAccessPermissive)
+	if err != nil {
+		return fmt.Errorf("error while opening lock file %s: %w", l.Path, err)
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		switch {
+		case errors.Is(err, syscall.EWOULDBLOCK):
+			defer f.Close()
+
+			holder, rErr := readInfo(f)
+			if rErr != nil || holder == nil {
+				return fmt.Errorf("%w: lock file %s is held by a running process", ErrLocked, l.Path)
+			}
+
+			return fmt.Errorf("%w: %s", ErrLocked, holder)
+
+		case errors.Is(err, syscall.ENOLCK), errors.Is(err, syscall.EOPNOTSUPP):
+			// Some network filesystems do not support flock, the PID in the record is all we have.
+			if err := l.acquireWithoutFlock(f); err != nil {
+				f.Close()
+
+				return err
+			}
+
+		default:
+			f.Close()
+
+			return fmt.Errorf("error while locking %s: %w", l.Path, err)
+		}
+	} else if previous, err := readInfo(f); err == nil && previous != nil {
+		logrus.Warnf("Recovering stale lock %s, it was %s", l.Path, previous)
+	}
+
+	if err := writeInfo(f, l.info); err != nil {
+		f.Close()
+
+		return fmt.Errorf("error while writing lock file %s: %w", l.Path, err)
+	}
+
+	l.file = f
+
+	return nil
+}
+
+// Release empties the lock file and unlocks it. The file stays where it is: removing it while another
+// execution already has it open would let two executions lock two different files with the same name.
+func (l *Local) Release() error {
+	if l.file == nil {
+		return nil
+	}
+
+	defer func() {
+		l.file.Close()
+		l.file = nil
+	}()
+
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("error while emptying lock file %s: %w", l.Path, err)
+	}
+
+	if err := syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN); err != nil {
+		return fmt.Errorf("error while unlocking %s: %w", l.Path, err)
+	}
+
+	return nil
+}
+
+func (l *Local) Status() ([]Status, error) {
+	status := Status{Backend: BackendLocal}
+
+	if l.file != nil {
+		status.Locked = true
+		status.Holder = &l.info
+
+		return []Status{status}, nil
+	}
+
+	f, err := os.Open(l.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error while opening lock file %s: %w", l.Path, err)
+	}
+
+	if err == nil {
+		defer f.Close()
+
+		status.Holder, err = readInfo(f)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading lock file %s: %w", l.Path, err)
+		}
+
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+			if !errors.Is(err, syscall.EWOULDBLOCK) {
+				return nil, fmt.Errorf("error while checking lock %s: %w", l.Path, err)
+			}
+
+			status.Locked = true
+
+			return []Status{status}, nil
+		}
+
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_UN); err != nil {
+			return nil, fmt.Errorf("error while unlocking %s: %w", l.Path, err)
+		}
+
+		status.Stale = status.Holder != nil
+	}
+
+	if pid, ok := l.legacyPID(); ok {
+		status.Holder = &Info{Holder: "unknown", Host: l.info.Host, PID: pid, Command: "unknown"}
+
+		if fi, err := os.Stat(l.LegacyPath); err == nil {
+			status.Holder.StartTime = fi.ModTime()
+		}
+
+		status.Locked = pidAlive(pid)
+		status.Stale = !status.Locked
+	}
+
+	return []Status{status}, nil
+}
+
+// Break removes the lock file and the legacy one. An execution that still holds the lock keeps running,
+// but the next execution creates a new lock file and does not wait for it.
+func (l *Local) Break() error {
+	for _, p := range []string{l.Path, l.LegacyPath} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error while removing lock file %s: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+// recoverLegacyLock removes the PID file of a previous version of furyctl, unless its process is running.
+func (l *Local) recoverLegacyLock() error {
+	pid, ok := l.legacyPID()
+	if !ok {
+		return nil
+	}
+
+	if pidAlive(pid) {
+		return fmt.Errorf("%w: lock file %s is held by PID %d", ErrLocked, l.LegacyPath, pid)
+	}
+
+	logrus.Warnf("Removing stale lock file %s, PID %d is not running", l.LegacyPath, pid)
+
+	if err := os.Remove(l.LegacyPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error while removing stale lock file %s: %w", l.LegacyPath, err)
+	}
+
+	return nil
+}
+
+func (l *Local) legacyPID() (int, bool) {
+	data, err := os.ReadFile(l.LegacyPath)
+	if err != nil {
+		return 0, false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		// A PID file that we cannot parse points to no process, so it is stale.
+		return 0, true
+	}
+
+	return pid, true
+}
+
+func (l *Local) acquireWithoutFlock(f *os.File) error {
+	holder, err := readInfo(f)
+	if err != nil {
+		return fmt.Errorf("error while reading lock file %s: %w", l.Path, err)
+	}
+
+	if holder == nil {
+		return nil
+	}
+
+	if holder.Host == l.info.Host && pidAlive(holder.PID) {
+		return fmt.Errorf("%w: %s", ErrLocked, holder)
+	}
+
+	logrus.Warnf("Recovering stale lock %s, it was %s", l.Path, holder)
+
+	return nil
+}
+
+func pidAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	exists, err := process.PidExists(int32(pid))
+	if err != nil {
+		logrus.Debugf("error while checking if PID %d is running: %v", pid, err)
+
+		// When in doubt, the lock is not stale.
+		return true
+	}
+
+	return exists
+}
+
+func readInfo(f *os.File) (*Info, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error while seeking lock file: %w", err)
+	}
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading lock file: %w", err)
+	}
+
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, nil //nolint:nilnil // an empty lock file has no holder
+	}
+
+	info := Info{}
+
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("error while parsing lock file: %w", err)
+	}
+
+	return &info, nil
+}
+
+func writeInfo(f *os.File, info Info) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return fmt.Errorf("error while marshalling lock info: %w", err)
+	}
+
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("error while emptying lock file: %w", err)
+	}
+
+	if _, err := f.WriteAt(data, 0); err != nil {
+		return fmt.Errorf("error while writing lock file: %w", err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("error while syncing lock file: %w", err)
+	}
+
+	return nil
+}
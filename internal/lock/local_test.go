@@ -0,0 +1,179 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package lock_test
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/lock"
+)
+
+func newLocal(t *testing.T, dir string, info lock.Info) *lock.Local {
+	t.Helper()
+
+	l := lock.NewLocal("test", info)
+	l.Path = filepath.Join(dir, "furyctl-test.lock")
+	l.LegacyPath = filepath.Join(dir, "furyctl-test")
+
+	return l
+}
+
+// deadPID returns the PID of a process that already exited.
+func deadPID(t *testing.T) int {
+	t.Helper()
+
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+
+	return cmd.Process.Pid
+}
+
+func TestLocal_AcquireRelease(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	info := lock.NewInfo()
+
+	first := newLocal(t, dir, info)
+	second := newLocal(t, dir, info)
+
+	require.NoError(t, first.Acquire())
+
+	err := second.Acquire()
+	require.ErrorIs(t, err, lock.ErrLocked)
+	assert.Contains(t, err.Error(), info.Holder)
+
+	statuses, err := second.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.True(t, statuses[0].Locked)
+	require.NotNil(t, statuses[0].Holder)
+	assert.Equal(t, info.PID, statuses[0].Holder.PID)
+
+	require.NoError(t, first.Release())
+	require.NoError(t, second.Acquire())
+	require.NoError(t, second.Release())
+
+	statuses, err = first.Status()
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Locked)
+	assert.False(t, statuses[0].Stale)
+}
+
+func TestLocal_RecoversStaleLock(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l := newLocal(t, dir, lock.NewInfo())
+
+	stale := lock.Info{Holder: "someone@somewhere", Host: "somewhere", PID: deadPID(t), StartTime: time.Now()}
+
+	data, err := json.Marshal(stale)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(l.Path, data, 0o600))
+
+	statuses, err := l.Status()
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Locked)
+	assert.True(t, statuses[0].Stale)
+
+	require.NoError(t, l.Acquire())
+	require.NoError(t, l.Release())
+}
+
+func TestLocal_LegacyLockFile(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc    string
+		pid     func(t *testing.T) int
+		wantErr error
+	}{
+		{
+			desc:    "running process keeps the lock",
+			pid:     func(*testing.T) int { return os.Getpid() },
+			wantErr: lock.ErrLocked,
+		},
+		{
+			desc: "exited process leaves a stale lock",
+			pid:  deadPID,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			l := newLocal(t, dir, lock.NewInfo())
+
+			require.NoError(t, os.WriteFile(l.LegacyPath, []byte(strconv.Itoa(tC.pid(t))), 0o600))
+
+			err := l.Acquire()
+			if tC.wantErr != nil {
+				require.ErrorIs(t, err, tC.wantErr)
+				assert.FileExists(t, l.LegacyPath)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NoFileExists(t, l.LegacyPath)
+			require.NoError(t, l.Release())
+		})
+	}
+}
+
+func TestLocal_Break(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	holder := newLocal(t, dir, lock.NewInfo())
+	other := newLocal(t, dir, lock.NewInfo())
+
+	require.NoError(t, holder.Acquire())
+	require.NoError(t, os.WriteFile(holder.LegacyPath, []byte(strconv.Itoa(os.Getpid())), 0o600))
+
+	require.NoError(t, other.Break())
+
+	assert.NoFileExists(t, holder.Path)
+	assert.NoFileExists(t, holder.LegacyPath)
+
+	require.NoError(t, other.Acquire())
+	require.NoError(t, other.Release())
+	require.NoError(t, holder.Release())
+}
+
+func TestMulti_ReleasesOnFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	info := lock.NewInfo()
+
+	busy := newLocal(t, dir, info)
+	require.NoError(t, busy.Acquire())
+
+	free := newLocal(t, t.TempDir(), info)
+	m := lock.NewMulti(free, newLocal(t, dir, info))
+
+	require.ErrorIs(t, m.Acquire(), lock.ErrLocked)
+
+	statuses, err := free.Status()
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Locked, "the locks acquired before the failure must be released")
+
+	require.NoError(t, busy.Release())
+}
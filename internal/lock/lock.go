@@ -0,0 +1,144 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lock keeps two furyctl executions from operating on the same cluster at the same time.
+// The local backend protects the cluster from the executions on the same machine, the cluster
+// backend from the executions of different operators on different machines.
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	BackendLocal   = "local"
+	BackendCluster = "cluster"
+)
+
+var (
+	ErrLocked             = errors.New("the cluster is locked by another furyctl execution")
+	ErrUnsupportedBackend = errors.New("unsupported lock backend")
+	ErrNoKubeconfig       = errors.New("no kubeconfig to reach the cluster, set the KUBECONFIG environment variable")
+)
+
+// Info describes the furyctl execution that holds a lock.
+type Info struct {
+	Holder    string    `json:"holder"`
+	Host      string    `json:"host"`
+	PID       int       `json:"pid"`
+	Command   string    `json:"command"`
+	StartTime time.Time `json:"startTime"`
+}
+
+// NewInfo returns the Info of the current furyctl execution.
+func NewInfo() Info {
+	host, err := os.Hostname()
+	if err != nil {
+		logrus.Debugf("error while getting hostname: %v", err)
+
+		host = "unknown"
+	}
+
+	holder := "unknown"
+
+	if u, err := user.Current(); err == nil {
+		holder = u.Username
+	}
+
+	return Info{
+		Holder:    holder + "@" + host,
+		Host:      host,
+		PID:       os.Getpid(),
+		Command:   strings.Join(os.Args, " "),
+		StartTime: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf(
+		"held by %s (PID %d) since %s, running \"%s\"",
+		i.Holder,
+		i.PID,
+		i.StartTime.Local().Format(time.RFC3339),
+		i.Command,
+	)
+}
+
+// Status is the state of a lock as seen by one backend.
+type Status struct {
+	Backend string `json:"backend"`
+	Locked  bool   `json:"locked"`
+	// Stale is true when the lock still carries the record of an execution that is no longer running.
+	Stale  bool  `json:"stale"`
+	Holder *Info `json:"holder,omitempty"`
+}
+
+type Locker interface {
+	// Acquire takes the lock, it returns an error wrapping ErrLocked when another execution holds it.
+	Acquire() error
+	// Release gives the lock back. It does nothing when the lock was not acquired.
+	Release() error
+	// Status reports who holds the lock in each backend, without taking it.
+	Status() ([]Status, error)
+	// Break removes the lock, whoever holds it.
+	Break() error
+}
+
+// ClusterOptions configures the cluster backend.
+type ClusterOptions struct {
+	KubectlPath string
+	Kubeconfig  string
+	WorkDir     string
+}
+
+// New returns the locker for the given backend. The cluster backend also takes the local lock, so that
+// the executions on the same machine never need to reach the cluster to find out about each other.
+func New(backend, clusterName string, info Info, opts ClusterOptions) (Locker, error) {
+	switch backend {
+	case BackendLocal:
+		return NewLocal(clusterName, info), nil
+
+	case BackendCluster:
+		return NewMulti(
+			NewLocal(clusterName, info),
+			NewCluster(opts.KubectlPath, opts.Kubeconfig, opts.WorkDir, info),
+		), nil
+
+	default:
+		return nil, fmt.Errorf("%w: %s, must be one of: %s", ErrUnsupportedBackend, backend, strings.Join(Backends(), ", "))
+	}
+}
+
+// Kubeconfig returns the kubeconfig that the cluster backend uses: the one in the KUBECONFIG environment
+// variable or, when it is not set, the one that furyctl copies in the working directory after the
+// kubernetes phase. It returns an empty string when there is none.
+func Kubeconfig() string {
+	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
+		return kubeconfig
+	}
+
+	kubeconfig, err := filepath.Abs("kubeconfig")
+	if err != nil {
+		return ""
+	}
+
+	if _, err := os.Stat(kubeconfig); err != nil {
+		return ""
+	}
+
+	return kubeconfig
+}
+
+// Backends returns the names of the supported backends.
+func Backends() []string {
+	return []string{BackendLocal, BackendCluster}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lock
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Multi holds the lock of every backend. It acquires them in order and releases them in reverse order.
+type Multi struct {
+	lockers  []Locker
+	acquired int
+}
+
+func NewMulti(lockers ...Locker) *Multi {
+	return &Multi{lockers: lockers}
+}
+
+func (m *Multi) Acquire() error {
+	for _, l := range m.lockers {
+		if err := l.Acquire(); err != nil {
+			if rErr := m.Release(); rErr != nil {
+				return errors.Join(err, rErr)
+			}
+
+			return err
+		}
+
+		m.acquired++
+	}
+
+	return nil
+}
+
+// Extend acquires one more lock, that Release gives back together with the others.
+func (m *Multi) Extend(l Locker) error {
+	if err := l.Acquire(); err != nil {
+		return err
+	}
+
+	m.lockers = append(m.lockers[:m.acquired], l)
+	m.acquired++
+
+	return nil
+}
+
+func (m *Multi) Release() error {
+	var errs []error
+
+	for ; m.acquired > 0; m.acquired-- {
+		if err := m.lockers[m.acquired-1].Release(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("error while releasing locks: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+func (m *Multi) Status() ([]Status, error) {
+	statuses := make([]Status, 0, len(m.lockers))
+
+	for _, l := range m.lockers {
+		s, err := l.Status()
+		if err != nil {
+			return nil, err
+		}
+
+		statuses = append(statuses, s...)
+	}
+
+	return statuses, nil
+}
+
+func (m *Multi) Break() error {
+	for _, l := range m.lockers {
+		if err := l.Break(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
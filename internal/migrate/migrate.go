@@ -0,0 +1,287 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package migrate rewrites a furyctl.yaml from a distribution version to another one, with the
+// declarative migration steps of each upgrade path on the way.
+package migrate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Al-Pragliola/go-version"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sighupio/furyctl/internal/semver"
+)
+
+const (
+	// FileName is the name of the file with the migration steps in the folder of an upgrade path,
+	// next to its upgrade scripts.
+	FileName = "migration.yaml"
+
+	// DistributionDir is the folder of a distribution with the migration steps that it ships: a file
+	// <from>-<to>.yaml for each upgrade path, in the folder of its kind.
+	DistributionDir = "migrations"
+)
+
+var (
+	ErrNoUpgradePath = errors.New("no upgrade path")
+	ErrInvalidStep   = errors.New("invalid migration step")
+	ErrConflict      = errors.New("migration conflict")
+)
+
+// Op is the operation of a migration step.
+type Op string
+
+const (
+	// OpMove moves the fields at path to the path in to, where each * is the index of the list item of
+	// the field, in order.
+	OpMove Op = "move"
+	// OpRename renames the fields at path to the key in to.
+	OpRename Op = "rename"
+	// OpDelete deletes the fields at path.
+	OpDelete Op = "delete"
+	// OpSet sets the fields at path to value, creating the maps on the way.
+	OpSet Op = "set"
+)
+
+// File is the content of a migration file.
+type File struct {
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is a migration step. Path is a pattern in the format of the rules of the distribution: the
+// fields separated by dots, * for the items of a list or the keys of a map, ** for any number of fields.
+type Step struct {
+	Op    Op        `yaml:"op"`
+	Path  string    `yaml:"path"`
+	To    string    `yaml:"to,omitempty"`
+	Value yaml.Node `yaml:"value,omitempty"`
+}
+
+// Migration are the steps that rewrite a furyctl.yaml from a distribution version to the next one on
+// an upgrade path.
+type Migration struct {
+	From  string
+	To    string
+	Steps []Step
+}
+
+// Change is a field of furyctl.yaml that a migration changed.
+type Change struct {
+	Op   Op
+	Path string
+	To   string
+}
+
+func (c Change) String() string {
+	switch c.Op {
+	case OpMove:
+		return "moved " + c.Path + " to " + c.To
+
+	case OpRename:
+		return "renamed " + c.Path + " to " + c.To
+
+	case OpDelete:
+		return "deleted " + c.Path
+
+	default:
+		return "set " + c.Path
+	}
+}
+
+// Result lists the changes of a migration.
+type Result struct {
+	From    string
+	To      string
+	Changes []Change
+}
+
+func (s Step) validate() error {
+	if !strings.HasPrefix(s.Path, ".") {
+		return fmt.Errorf("%w: path %q must start with a dot", ErrInvalidStep, s.Path)
+	}
+
+	switch s.Op {
+	case OpMove:
+		if !strings.HasPrefix(s.To, ".") || strings.Contains(s.To, "**") {
+			return fmt.Errorf("%w: move to %q must be a path without **", ErrInvalidStep, s.To)
+		}
+
+	case OpRename:
+		if s.To == "" || strings.ContainsAny(s.To, ".*") {
+			return fmt.Errorf("%w: rename to %q must be a key", ErrInvalidStep, s.To)
+		}
+
+	case OpDelete:
+		if s.To != "" {
+			return fmt.Errorf("%w: delete has no destination", ErrInvalidStep)
+		}
+
+	case OpSet:
+		if s.Value.Kind == 0 {
+			return fmt.Errorf("%w: set of %s has no value", ErrInvalidStep, s.Path)
+		}
+
+		if strings.Contains(s.Path, "**") {
+			return fmt.Errorf("%w: set path %q cannot contain **", ErrInvalidStep, s.Path)
+		}
+
+	default:
+		return fmt.Errorf("%w: unknown operation %q, supported ones are: move, rename, delete, set", ErrInvalidStep, s.Op)
+	}
+
+	return nil
+}
+
+// Plan returns the migrations from a distribution version to another one along the shortest chain of
+// upgrade paths of the kind. upgrades holds a folder for each kind, with a folder for each upgrade path
+// named <from>-<to>, as the embedded upgrades or the --upgrade-path-location of apply. The steps of an
+// upgrade path are the ones that distribution ships in its DistributionDir, if any, or the ones of its
+// folder in upgrades. distribution can be nil.
+func Plan(upgrades, distribution fs.FS, kind, from, to string) ([]Migration, error) {
+	from = semver.EnsureNoPrefix(from)
+	to = semver.EnsureNoPrefix(to)
+
+	if from == to {
+		return []Migration{}, nil
+	}
+
+	kindDir := strings.ToLower(kind)
+
+	entries, err := fs.ReadDir(upgrades, kindDir)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading the upgrade paths of kind %s: %w", kind, err)
+	}
+
+	edges := map[string][]string{}
+
+	for _, e := range entries {
+		a, b, ok := strings.Cut(e.Name(), "-")
+		if !e.IsDir() || !ok {
+			continue
+		}
+
+		edges[a] = append(edges[a], b)
+	}
+
+	hops := shortestPath(edges, from, to)
+	if hops == nil {
+		return nil, fmt.Errorf("%w from %s to %s for kind %s", ErrNoUpgradePath, from, to, kind)
+	}
+
+	migrations := make([]Migration, 0, len(hops)-1)
+
+	for i := range len(hops) - 1 {
+		m, err := loadUpgradePath(upgrades, distribution, kindDir, hops[i]+"-"+hops[i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		m.From, m.To = hops[i], hops[i+1]
+
+		migrations = append(migrations, m)
+	}
+
+	return migrations, nil
+}
+
+// shortestPath returns the versions from the first one to the last one with the fewest upgrades, going
+// through the lowest versions when there are more of them.
+func shortestPath(edges map[string][]string, from, to string) []string {
+	prev := map[string]string{from: ""}
+	queue := []string{from}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur == to {
+			hops := []string{}
+
+			for v := to; v != ""; v = prev[v] {
+				hops = append(hops, v)
+			}
+
+			slices.Reverse(hops)
+
+			return hops
+		}
+
+		next := slices.Clone(edges[cur])
+		slices.SortFunc(next, compareVersions)
+
+		for _, n := range next {
+			if _, ok := prev[n]; ok {
+				continue
+			}
+
+			prev[n] = cur
+			queue = append(queue, n)
+		}
+	}
+
+	return nil
+}
+
+func compareVersions(a, b string) int {
+	va, erra := version.NewVersion(a)
+	vb, errb := version.NewVersion(b)
+
+	if erra != nil || errb != nil {
+		return strings.Compare(a, b)
+	}
+
+	return va.Compare(vb)
+}
+
+// loadUpgradePath reads the steps of an upgrade path of a kind: the ones of the distribution, if it ships
+// them, or the ones of its folder in upgrades.
+func loadUpgradePath(upgrades, distribution fs.FS, kindDir, upgradePath string) (Migration, error) {
+	if distribution != nil {
+		name := path.Join(DistributionDir, kindDir, upgradePath+".yaml")
+
+		if _, err := fs.Stat(distribution, name); err == nil {
+			return load(distribution, name)
+		}
+	}
+
+	return load(upgrades, path.Join(kindDir, upgradePath, FileName))
+}
+
+// load reads the steps of a migration file, an upgrade path without it has no steps.
+func load(fsys fs.FS, name string) (Migration, error) {
+	content, err := fs.ReadFile(fsys, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Migration{Steps: []Step{}}, nil
+	}
+
+	if err != nil {
+		return Migration{}, fmt.Errorf("error while reading migration file %s: %w", name, err)
+	}
+
+	f := File{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return Migration{}, fmt.Errorf("%w: %s: %w", ErrInvalidStep, name, err)
+	}
+
+	for i, s := range f.Steps {
+		if err := s.validate(); err != nil {
+			return Migration{}, fmt.Errorf("%s: step %d: %w", name, i, err)
+		}
+	}
+
+	return Migration{Steps: f.Steps}, nil
+}
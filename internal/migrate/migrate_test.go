@@ -0,0 +1,311 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package migrate_test
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/configs"
+	"github.com/sighupio/furyctl/internal/migrate"
+)
+
+const testConfig = `apiVersion: kfd.sighup.io/v1alpha2
+kind: OnPremises
+metadata:
+  name: test
+spec:
+  # The version of the distribution.
+  distributionVersion: v1.29.4
+  kubernetes:
+    masters:
+      nodes:
+        - name: master1
+          ip: 10.0.0.1 # the first master
+        - name: master2
+          ip: 10.0.0.2
+  distribution:
+    modules:
+      logging:
+        type: loki
+        loki:
+          backend: minio
+          tsdbStartDate: "2024-11-18"
+      monitoring:
+        type: prometheus
+`
+
+const testMigrations = `steps:
+  - op: rename
+    path: .spec.kubernetes.masters.nodes.*.ip
+    to: address
+  - op: move
+    path: .spec.distribution.modules.logging.loki.tsdbStartDate
+    to: .spec.distribution.modules.logging.loki.tsdb.startDate
+  - op: delete
+    path: .spec.distribution.modules.**.backend
+  - op: set
+    path: .spec.distribution.modules.monitoring.prometheus.retentionSize
+    value: 120GB
+`
+
+const migratedConfig = `apiVersion: kfd.sighup.io/v1alpha2
+kind: OnPremises
+metadata:
+  name: test
+spec:
+  # The version of the distribution.
+  distributionVersion: v1.31.1
+  kubernetes:
+    masters:
+      nodes:
+        - name: master1
+          address: 10.0.0.1 # the first master
+        - name: master2
+          address: 10.0.0.2
+  distribution:
+    modules:
+      logging:
+        type: loki
+        loki:
+          tsdb:
+            startDate: "2024-11-18"
+      monitoring:
+        type: prometheus
+        prometheus:
+          retentionSize: 120GB
+`
+
+func testUpgrades() fstest.MapFS {
+	return fstest.MapFS{
+		"onpremises/1.29.4-1.29.5/pre-distribution.sh.tpl": {Data: []byte("echo")},
+		"onpremises/1.29.4-1.30.0/pre-distribution.sh.tpl": {Data: []byte("echo")},
+		"onpremises/1.29.5-1.30.0/pre-distribution.sh.tpl": {Data: []byte("echo")},
+		"onpremises/1.30.0-1.31.1/" + migrate.FileName:     {Data: []byte(testMigrations)},
+		"onpremises/1.30.0-1.31.0/pre-distribution.sh.tpl": {Data: []byte("echo")},
+		"onpremises/1.31.0-1.31.1/pre-distribution.sh.tpl": {Data: []byte("echo")},
+		"ekscluster/1.29.4-1.30.0/" + migrate.FileName:     {Data: []byte("steps: []\n")},
+		"onpremises/1.31.1-1.32.0/" + migrate.FileName:     {Data: []byte("steps:\n  - op: copy\n    path: .spec\n")},
+		"onpremises/1.31.1-1.32.1/" + migrate.FileName:     {Data: []byte("steps:\n  - op: delete\n    path: spec\n")},
+		"onpremises/1.32.1-1.32.2/" + migrate.FileName:     {Data: []byte("steps:\n  - op: delete\n    path: .spec\n    unknown: x\n")},
+		"onpremises/1.32.1-1.33.0/" + migrate.FileName:     {Data: []byte("steps:\n  - op: set\n    path: .spec.a\n")},
+		"onpremises/1.32.0-1.33.0/pre-distribution.sh.tpl": {Data: []byte("echo")},
+		"onpremises/1.33.0-1.33.1/" + migrate.FileName:     {Data: []byte("")},
+		"onpremises/1.33.0-1.33.1/pre-kubernetes.sh.tpl":   {Data: []byte("echo")},
+		"onpremises/1.33.1-1.34.0/" + migrate.FileName:     {Data: []byte("steps:\n  - op: rename\n    path: .spec.a\n    to: b.c\n")},
+		"onpremises/1.33.1-1.34.1/" + migrate.FileName:     {Data: []byte("steps:\n  - op: move\n    path: .spec.a\n    to: .spec.**\n")},
+		"onpremises/1.33.1-1.34.1/pre-distribution.sh.tpl": {Data: []byte("echo")},
+		"onpremises/1.33.1-1.34.2/pre-distribution.sh.tpl": {Data: []byte("echo")},
+		"onpremises/README.md":                             {Data: []byte("not an upgrade path")},
+	}
+}
+
+func TestPlan(t *testing.T) {
+	t.Parallel()
+
+	migrations, err := migrate.Plan(testUpgrades(), nil, "OnPremises", "v1.29.4", "v1.31.1")
+	require.NoError(t, err)
+
+	// The fewest upgrades: not through 1.29.5 nor 1.31.0.
+	require.Len(t, migrations, 2)
+	assert.Equal(t, "1.29.4", migrations[0].From)
+	assert.Equal(t, "1.30.0", migrations[0].To)
+	assert.Empty(t, migrations[0].Steps)
+	assert.Equal(t, "1.30.0", migrations[1].From)
+	assert.Equal(t, "1.31.1", migrations[1].To)
+	assert.Len(t, migrations[1].Steps, 4)
+
+	migrations, err = migrate.Plan(testUpgrades(), nil, "OnPremises", "1.33.0", "1.33.1")
+	require.NoError(t, err)
+	require.Len(t, migrations, 1)
+	assert.Empty(t, migrations[0].Steps)
+
+	migrations, err = migrate.Plan(testUpgrades(), nil, "OnPremises", "1.29.4", "v1.29.4")
+	require.NoError(t, err)
+	assert.Empty(t, migrations)
+
+	_, err = migrate.Plan(testUpgrades(), nil, "OnPremises", "1.31.1", "1.29.4")
+	require.ErrorIs(t, err, migrate.ErrNoUpgradePath)
+
+	_, err = migrate.Plan(testUpgrades(), nil, "KFDDistribution", "1.29.4", "1.30.0")
+	require.Error(t, err)
+}
+
+func TestPlan_DistributionSteps(t *testing.T) {
+	t.Parallel()
+
+	distribution := fstest.MapFS{
+		"migrations/onpremises/1.29.4-1.30.0.yaml": {Data: []byte("steps:\n  - op: delete\n    path: .spec.a\n")},
+		"migrations/ekscluster/1.30.0-1.31.1.yaml": {Data: []byte("steps: []\n")},
+	}
+
+	migrations, err := migrate.Plan(testUpgrades(), distribution, "OnPremises", "1.29.4", "1.31.1")
+	require.NoError(t, err)
+
+	// The steps that the distribution ships, then the ones of the upgrade paths.
+	require.Len(t, migrations, 2)
+	require.Len(t, migrations[0].Steps, 1)
+	assert.Equal(t, migrate.OpDelete, migrations[0].Steps[0].Op)
+	assert.Len(t, migrations[1].Steps, 4)
+}
+
+func TestPlan_InvalidSteps(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc string
+		from string
+		to   string
+	}{
+		{desc: "unknown operation", from: "1.31.1", to: "1.32.0"},
+		{desc: "path without the leading dot", from: "1.31.1", to: "1.32.1"},
+		{desc: "unknown field", from: "1.32.1", to: "1.32.2"},
+		{desc: "set without value", from: "1.32.1", to: "1.33.0"},
+		{desc: "rename to a path", from: "1.33.1", to: "1.34.0"},
+		{desc: "move to a pattern", from: "1.33.1", to: "1.34.1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := migrate.Plan(testUpgrades(), nil, "OnPremises", tc.from, tc.to)
+			require.ErrorIs(t, err, migrate.ErrInvalidStep)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	migrations, err := migrate.Plan(testUpgrades(), nil, "OnPremises", "1.29.4", "1.31.1")
+	require.NoError(t, err)
+
+	out, results, err := migrate.Apply([]byte(testConfig), migrations)
+	require.NoError(t, err)
+
+	assert.Equal(t, migratedConfig, string(out))
+
+	require.Len(t, results, 2)
+	assert.Empty(t, results[0].Changes)
+	assert.Equal(t, "1.31.1", results[1].To)
+	assert.Equal(t, []string{
+		"renamed .spec.kubernetes.masters.nodes.0.ip to .spec.kubernetes.masters.nodes.0.address",
+		"renamed .spec.kubernetes.masters.nodes.1.ip to .spec.kubernetes.masters.nodes.1.address",
+		"moved .spec.distribution.modules.logging.loki.tsdbStartDate to .spec.distribution.modules.logging.loki.tsdb.startDate",
+		"deleted .spec.distribution.modules.logging.loki.backend",
+		"set .spec.distribution.modules.monitoring.prometheus.retentionSize",
+	}, changes(results[1]))
+
+	// A migrated configuration has nothing left to migrate.
+	again, results, err := migrate.Apply(out, migrations)
+	require.NoError(t, err)
+	assert.Equal(t, migratedConfig, string(again))
+	assert.Equal(t, []string{"set .spec.distribution.modules.monitoring.prometheus.retentionSize"}, changes(results[1]))
+}
+
+func TestApply_Conflicts(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc   string
+		config string
+		step   string
+	}{
+		{
+			desc:   "rename to an existing key",
+			config: "spec:\n  a: 1\n  b: 2\n",
+			step:   "steps:\n  - op: rename\n    path: .spec.a\n    to: b\n",
+		},
+		{
+			desc:   "move to an existing key",
+			config: "spec:\n  a: 1\n  b:\n    c: 2\n",
+			step:   "steps:\n  - op: move\n    path: .spec.a\n    to: .spec.b.c\n",
+		},
+		{
+			desc:   "set under a scalar",
+			config: "spec:\n  a: 1\n",
+			step:   "steps:\n  - op: set\n    path: .spec.a.b\n    value: 2\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			upgrades := fstest.MapFS{"onpremises/1.0.0-1.1.0/" + migrate.FileName: {Data: []byte(tc.step)}}
+
+			migrations, err := migrate.Plan(upgrades, nil, "OnPremises", "1.0.0", "1.1.0")
+			require.NoError(t, err)
+
+			_, _, err = migrate.Apply([]byte(tc.config), migrations)
+			require.ErrorIs(t, err, migrate.ErrConflict)
+		})
+	}
+}
+
+func changes(r migrate.Result) []string {
+	out := make([]string, 0, len(r.Changes))
+
+	for _, c := range r.Changes {
+		out = append(out, c.String())
+	}
+
+	return out
+}
+
+func TestApply_ShippedSteps(t *testing.T) {
+	t.Parallel()
+
+	upgrades, err := fs.Sub(configs.Tpl, "upgrades")
+	require.NoError(t, err)
+
+	migrations, err := migrate.Plan(upgrades, nil, "EKSCluster", "v1.32.1", "v1.32.2")
+	require.NoError(t, err)
+
+	out, results, err := migrate.Apply([]byte(`apiVersion: kfd.sighup.io/v1alpha2
+kind: EKSCluster
+metadata:
+  name: test
+spec:
+  distributionVersion: v1.32.1
+  toolsConfiguration:
+    terraform:
+      state:
+        s3:
+          bucketName: furyctl-state # the state of the cluster
+          keyPrefix: test
+          region: eu-west-1
+  region: eu-west-1
+`), migrations)
+	require.NoError(t, err)
+
+	assert.Equal(t, `apiVersion: kfd.sighup.io/v1alpha2
+kind: EKSCluster
+metadata:
+  name: test
+spec:
+  distributionVersion: v1.32.2
+  toolsConfiguration:
+    opentofu:
+      state:
+        s3:
+          bucketName: furyctl-state # the state of the cluster
+          keyPrefix: test
+          region: eu-west-1
+  region: eu-west-1
+`, string(out))
+
+	require.Len(t, results, 1)
+	assert.Equal(t, []string{
+		"renamed .spec.toolsConfiguration.terraform to .spec.toolsConfiguration.opentofu",
+	}, changes(results[0]))
+}
@@ -0,0 +1,369 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package migrate
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sighupio/furyctl/internal/semver"
+	rules "github.com/sighupio/furyctl/pkg/rulesextractor"
+)
+
+// VersionPath is the field of furyctl.yaml with the distribution version.
+const VersionPath = ".spec.distributionVersion"
+
+// The indentation of the rewritten furyctl.yaml, the one of the files that furyctl creates.
+const indent = 2
+
+// field is a key of a map of furyctl.yaml, with its value.
+type field struct {
+	parent *yaml.Node
+	key    *yaml.Node
+	value  *yaml.Node
+	// path has the index of the list items, pattern a * in their place.
+	path    string
+	pattern string
+	indexes []string
+}
+
+// Apply runs the migrations on the content of a furyctl.yaml and sets its distribution version to the
+// last one. The comments and the order of the fields are kept.
+func Apply(content []byte, migrations []Migration) ([]byte, []Result, error) {
+	doc := yaml.Node{}
+
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, nil, fmt.Errorf("error while parsing configuration file: %w", err)
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("%w: the configuration file is not a map", ErrConflict)
+	}
+
+	root := doc.Content[0]
+	results := make([]Result, 0, len(migrations))
+
+	for _, m := range migrations {
+		changes := []Change{}
+
+		for i, s := range m.Steps {
+			c, err := apply(root, s)
+			if err != nil {
+				return nil, nil, fmt.Errorf("error while migrating from %s to %s, step %d: %w", m.From, m.To, i, err)
+			}
+
+			changes = append(changes, c...)
+		}
+
+		results = append(results, Result{From: m.From, To: m.To, Changes: changes})
+	}
+
+	if len(migrations) > 0 {
+		if err := setVersion(root, semver.EnsurePrefix(migrations[len(migrations)-1].To)); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("error while encoding configuration file: %w", err)
+	}
+
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("error while encoding configuration file: %w", err)
+	}
+
+	return buf.Bytes(), results, nil
+}
+
+func apply(root *yaml.Node, s Step) ([]Change, error) {
+	if s.Op == OpSet {
+		return set(root, s)
+	}
+
+	changes := []Change{}
+
+	// The last fields first: the children before their parents, the later keys of a map before the
+	// earlier ones.
+	matches := lo.Filter(fields(root, "", "", nil), func(f field, _ int) bool {
+		return rules.MatchesPattern(f.path, s.Path) || rules.MatchesPattern(f.pattern, s.Path)
+	})
+
+	slices.Reverse(matches)
+
+	for _, f := range matches {
+		switch s.Op {
+		case OpRename:
+			to := f.path[:strings.LastIndex(f.path, ".")+1] + s.To
+
+			if _, ok := lookup(f.parent, s.To); ok {
+				return nil, fmt.Errorf("%w: cannot rename %s, %s already exists", ErrConflict, f.path, to)
+			}
+
+			f.key.Value = s.To
+
+			changes = append(changes, Change{Op: OpRename, Path: f.path, To: to})
+
+		case OpMove:
+			to, err := destination(s.To, f.indexes)
+			if err != nil {
+				return nil, err
+			}
+
+			remove(f.parent, f.key)
+
+			if err := insert(root, to, f.key, f.value); err != nil {
+				return nil, fmt.Errorf("cannot move %s to %s: %w", f.path, to, err)
+			}
+
+			changes = append(changes, Change{Op: OpMove, Path: f.path, To: to})
+
+		case OpDelete:
+			remove(f.parent, f.key)
+
+			changes = append(changes, Change{Op: OpDelete, Path: f.path})
+
+		case OpSet:
+			// Handled by set.
+		}
+	}
+
+	slices.Reverse(changes)
+
+	return changes, nil
+}
+
+// fields returns the fields of the maps under node, in the order of the document.
+func fields(node *yaml.Node, path, pattern string, indexes []string) []field {
+	out := []field{}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			f := field{
+				parent:  node,
+				key:     key,
+				value:   value,
+				path:    path + "." + key.Value,
+				pattern: pattern + "." + key.Value,
+				indexes: indexes,
+			}
+
+			out = append(out, f)
+			out = append(out, fields(value, f.path, f.pattern, indexes)...)
+		}
+
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			idx := strconv.Itoa(i)
+
+			out = append(out, fields(item, path+"."+idx, pattern+".*", append(slices.Clone(indexes), idx))...)
+		}
+
+	default:
+		// The scalars have no fields, an alias has the ones of its anchor.
+	}
+
+	return out
+}
+
+// destination replaces each * of a move destination with the index of the list item of the field.
+func destination(to string, indexes []string) (string, error) {
+	segs := strings.Split(strings.TrimPrefix(to, "."), ".")
+	next := 0
+
+	for i, seg := range segs {
+		if seg != "*" {
+			continue
+		}
+
+		if next >= len(indexes) {
+			return "", fmt.Errorf("%w: move to %s has more * than the path", ErrInvalidStep, to)
+		}
+
+		segs[i] = indexes[next]
+		next++
+	}
+
+	return "." + strings.Join(segs, "."), nil
+}
+
+// set sets the value of a set step to each field that its path selects.
+func set(root *yaml.Node, s Step) ([]Change, error) {
+	changes := []Change{}
+
+	err := walk(root, strings.Split(strings.TrimPrefix(s.Path, "."), "."), "", func(parent *yaml.Node, key, path string) {
+		value := clone(&s.Value)
+
+		if old, ok := lookup(parent, key); ok {
+			value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+			*old = *value
+		} else {
+			parent.Content = append(parent.Content, scalarKey(key), value)
+		}
+
+		changes = append(changes, Change{Op: OpSet, Path: path})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// insert adds a key with its value at the path, that must not exist yet.
+func insert(root *yaml.Node, path string, key, value *yaml.Node) error {
+	var conflict error
+
+	err := walk(root, strings.Split(strings.TrimPrefix(path, "."), "."), "", func(parent *yaml.Node, k, p string) {
+		if _, ok := lookup(parent, k); ok {
+			conflict = fmt.Errorf("%w: %s already exists", ErrConflict, p)
+
+			return
+		}
+
+		key.Value = k
+		parent.Content = append(parent.Content, key, value)
+	})
+	if err != nil {
+		return err
+	}
+
+	return conflict
+}
+
+// setVersion sets the distribution version, keeping the style of the field.
+func setVersion(root *yaml.Node, ver string) error {
+	return walk(root, strings.Split(strings.TrimPrefix(VersionPath, "."), "."), "", func(parent *yaml.Node, key, _ string) {
+		if old, ok := lookup(parent, key); ok && old.Kind == yaml.ScalarNode {
+			old.Value = ver
+
+			return
+		}
+
+		parent.Content = append(parent.Content, scalarKey(key), &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: ver})
+	})
+}
+
+// walk calls fn with the map and the key of each field at the path under node, creating the missing maps
+// on the way. A * segment goes through all the items of a list or the values of a map.
+func walk(node *yaml.Node, segs []string, path string, fn func(parent *yaml.Node, key, path string)) error {
+	if len(segs) == 1 {
+		if node.Kind != yaml.MappingNode {
+			return fmt.Errorf("%w: %s is not a map", ErrConflict, orRoot(path))
+		}
+
+		if segs[0] != "*" {
+			fn(node, segs[0], path+"."+segs[0])
+
+			return nil
+		}
+
+		keys := lo.Filter(node.Content, func(_ *yaml.Node, i int) bool { return i%2 == 0 })
+
+		for _, k := range keys {
+			fn(node, k.Value, path+"."+k.Value)
+		}
+
+		return nil
+	}
+
+	seg := segs[0]
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		if seg == "*" {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if err := walk(node.Content[i+1], segs[1:], path+"."+node.Content[i].Value, fn); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		child, ok := lookup(node, seg)
+		if !ok {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, scalarKey(seg), child)
+		}
+
+		return walk(child, segs[1:], path+"."+seg, fn)
+
+	case yaml.SequenceNode:
+		if seg == "*" {
+			for i, item := range node.Content {
+				if err := walk(item, segs[1:], path+"."+strconv.Itoa(i), fn); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		}
+
+		i, err := strconv.Atoi(seg)
+		if err != nil || i < 0 || i >= len(node.Content) {
+			return fmt.Errorf("%w: %s has no item %s", ErrConflict, orRoot(path), seg)
+		}
+
+		return walk(node.Content[i], segs[1:], path+"."+seg, fn)
+
+	default:
+		return fmt.Errorf("%w: %s is not a map or a list", ErrConflict, orRoot(path))
+	}
+}
+
+// lookup returns the value of a key of a map.
+func lookup(mapping *yaml.Node, key string) (*yaml.Node, bool) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1], true
+		}
+	}
+
+	return nil, false
+}
+
+// remove deletes a key, with its value, from a map.
+func remove(mapping, key *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i] == key {
+			mapping.Content = slices.Delete(mapping.Content, i, i+2)
+
+			return
+		}
+	}
+}
+
+// clone returns a deep copy of a node, each field that a step sets has its own value.
+func clone(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = lo.Map(node.Content, func(n *yaml.Node, _ int) *yaml.Node { return clone(n) })
+
+	return &c
+}
+
+func scalarKey(key string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+}
+
+func orRoot(path string) string {
+	if path == "" {
+		return "the configuration"
+	}
+
+	return path
+}
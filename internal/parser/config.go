@@ -104,7 +104,8 @@ func (p *ConfigParser) ParseMultipleDynamicValues(value string) (string, error)
 
 // parseDynamicString processes a string that may contain dynamic value patterns.
 func (p *ConfigParser) parseDynamicString(strVal string) (string, error) {
-	spl := strings.Split(strVal, "://")
+	// The value of exec:// and http(s):// can hold another "://".
+	spl := strings.SplitN(strVal, "://", 2) //nolint:mnd // The scheme and the value.
 
 	if len(spl) > 1 {
 		source := strings.TrimPrefix(spl[0], "{")
@@ -152,6 +153,10 @@ func (p *ConfigParser) parseDynamicString(strVal string) (string, error) {
 			return strings.TrimRight(string(val), "\n"), nil
 
 		default:
+			if provider, ok := getSecretProvider(source); ok {
+				return resolveSecret(provider, source, p.baseDir, sourceValue)
+			}
+
 			return strVal, nil
 		}
 	}
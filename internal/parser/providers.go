@@ -0,0 +1,287 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parserx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/sighupio/furyctl/internal/kubernetes"
+	"github.com/sighupio/furyctl/internal/redact"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+const (
+	Sops      = "sops"
+	Vault     = "vault"
+	Exec      = "exec"
+	K8sSecret = "k8s-secret"
+)
+
+var (
+	ErrInvalidReference = errors.New("invalid reference")
+	ErrKeyPathNotFound  = errors.New("key path not found")
+
+	//nolint:gochecknoglobals // The secret providers are shared by all the parsers.
+	secretProviders = map[string]SecretProvider{
+		Sops:      &SopsProvider{},
+		Vault:     &VaultProvider{},
+		Exec:      ProviderFunc(resolveExec),
+		K8sSecret: &KubernetesSecretProvider{},
+	}
+	//nolint:gochecknoglobals // The lock guards the secret providers.
+	secretProvidersMu sync.RWMutex
+)
+
+// SecretProvider resolves the dynamic values of a scheme, for example {vault://secret/app#password}.
+// The values that a secret provider resolves are sensitive: furyctl masks them in its logs and outputs.
+type SecretProvider interface {
+	// Resolve returns the value of the reference, the part of the dynamic value after the scheme. The
+	// relative paths in the reference are relative to baseDir, the folder of the configuration file.
+	Resolve(baseDir, ref string) (string, error)
+}
+
+// ProviderFunc is a function that is a SecretProvider.
+type ProviderFunc func(baseDir, ref string) (string, error)
+
+func (f ProviderFunc) Resolve(baseDir, ref string) (string, error) {
+	return f(baseDir, ref)
+}
+
+// RegisterSecretProvider makes the dynamic values of the scheme resolve with the provider, it replaces
+// the provider of the scheme if there is one.
+func RegisterSecretProvider(scheme string, p SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+
+	secretProviders[scheme] = p
+}
+
+// SecretProviders returns the schemes of the secret providers.
+func SecretProviders() []string {
+	secretProvidersMu.RLock()
+	defer secretProvidersMu.RUnlock()
+
+	schemes := make([]string, 0, len(secretProviders))
+	for scheme := range secretProviders {
+		schemes = append(schemes, scheme)
+	}
+
+	slices.Sort(schemes)
+
+	return schemes
+}
+
+// HasSecretReference tells whether the value has a dynamic value of a secret provider.
+func HasSecretReference(value string) bool {
+	return slices.ContainsFunc(SecretProviders(), func(scheme string) bool {
+		return strings.Contains(value, "{"+scheme+"://")
+	})
+}
+
+// RegisterStoredSecrets marks as sensitive the values of the stored configuration at the keys that are a
+// secret provider dynamic value in the new configuration: the stored configuration has the values of the
+// secrets as they were at the last apply, that the providers of this run do not resolve.
+func RegisterStoredSecrets(stored, current any) {
+	switch cur := current.(type) {
+	case map[string]any:
+		st, ok := stored.(map[string]any)
+		if !ok {
+			return
+		}
+
+		for k, v := range cur {
+			RegisterStoredSecrets(st[k], v)
+		}
+
+	case []any:
+		st, ok := stored.([]any)
+		if !ok {
+			return
+		}
+
+		for i, v := range cur {
+			if i < len(st) {
+				RegisterStoredSecrets(st[i], v)
+			}
+		}
+
+	case string:
+		if st, ok := stored.(string); ok && HasSecretReference(cur) {
+			redact.Register(st)
+		}
+
+	default:
+		// The other values are not dynamic values.
+	}
+}
+
+func getSecretProvider(scheme string) (SecretProvider, bool) {
+	secretProvidersMu.RLock()
+	defer secretProvidersMu.RUnlock()
+
+	p, ok := secretProviders[scheme]
+
+	return p, ok
+}
+
+// resolveSecret resolves the reference with the provider and registers the value as sensitive.
+func resolveSecret(p SecretProvider, scheme, baseDir, ref string) (string, error) {
+	val, err := p.Resolve(baseDir, ref)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %w", ErrCannotParseDynamicValue, scheme, err)
+	}
+
+	redact.Register(val)
+
+	return val, nil
+}
+
+// resolveRelativePath makes the relative paths that start with ./ or ../ relative to baseDir, as for file://.
+func resolveRelativePath(baseDir, p string) string {
+	if RelativePathRegexp.MatchString(p) {
+		return filepath.Join(baseDir, filepath.Clean(p))
+	}
+
+	return p
+}
+
+// splitKeyPath splits a reference into the location and the key path after the '#', if any.
+func splitKeyPath(ref string) (string, string) {
+	location, keyPath, _ := strings.Cut(ref, "#")
+
+	return location, keyPath
+}
+
+// selectKeyPath returns the value at the dot separated key path of a YAML or JSON document, the
+// indexes of the lists are numbers, for example spec.users.0.password.
+func selectKeyPath(document []byte, keyPath string) (string, error) {
+	var node any
+
+	if err := yamlx.UnmarshalV3(document, &node); err != nil {
+		return "", fmt.Errorf("error while parsing document: %w", err)
+	}
+
+	for _, key := range strings.Split(keyPath, ".") {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[key]
+			if !ok {
+				return "", fmt.Errorf("%w: %s", ErrKeyPathNotFound, keyPath)
+			}
+
+			node = v
+
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(n) {
+				return "", fmt.Errorf("%w: %s", ErrKeyPathNotFound, keyPath)
+			}
+
+			node = n[idx]
+
+		default:
+			return "", fmt.Errorf("%w: %s", ErrKeyPathNotFound, keyPath)
+		}
+	}
+
+	switch n := node.(type) {
+	case string:
+		return n, nil
+
+	case map[string]any, []any:
+		out, err := yamlx.MarshalV3(n)
+		if err != nil {
+			return "", fmt.Errorf("error while marshalling value: %w", err)
+		}
+
+		return strings.TrimRight(string(out), "\n"), nil
+
+	default:
+		return fmt.Sprint(n), nil
+	}
+}
+
+// runSensitive runs a command whose output is a secret: furyctl neither logs nor records its output.
+func runSensitive(name, workDir string, args ...string) (string, error) {
+	cmd := execx.NewCmd(name, execx.CmdOptions{
+		Args:      args,
+		Executor:  execx.NewStdExecutor(),
+		WorkDir:   workDir,
+		Sensitive: true,
+	})
+
+	err := cmd.Run()
+
+	stdout, ok := cmd.Stdout.(*bytes.Buffer)
+	if !ok {
+		return "", execx.ErrCastingToBuffer
+	}
+
+	if err != nil {
+		stderr, ok := cmd.Stderr.(*bytes.Buffer)
+		if !ok {
+			return "", execx.ErrCastingToBuffer
+		}
+
+		return "", fmt.Errorf("%s: %w: %s", filepath.Base(name), execx.ErrCmdFailed, strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimRight(stdout.String(), "\n"), nil
+}
+
+// resolveExec runs a credential helper, exec://<command> [args...], and returns its standard output.
+func resolveExec(baseDir, ref string) (string, error) {
+	fields := strings.Fields(ref)
+	if len(fields) == 0 {
+		return "", fmt.Errorf("%w: the command is empty", ErrInvalidReference)
+	}
+
+	return runSensitive(resolveRelativePath(baseDir, fields[0]), baseDir, fields[1:]...)
+}
+
+// KubernetesSecretProvider reads a key of a secret in the cluster, k8s-secret://<namespace>/<name>/<key>,
+// with the kubeconfig in the KUBECONFIG environment variable.
+type KubernetesSecretProvider struct {
+	// Client reaches the cluster, by default a client for the cluster of the KUBECONFIG environment variable.
+	Client *kubernetes.Client
+}
+
+func (p *KubernetesSecretProvider) Resolve(_, ref string) (string, error) {
+	parts := strings.Split(ref, "/")
+	if len(parts) != 3 || slices.Contains(parts, "") {
+		return "", fmt.Errorf("%w: %q, the format is <namespace>/<name>/<key>", ErrInvalidReference, ref)
+	}
+
+	client := p.Client
+	if client == nil {
+		var err error
+
+		if client, err = kubernetes.NewClient(""); err != nil {
+			return "", fmt.Errorf("error while creating kubernetes client: %w", err)
+		}
+	}
+
+	namespace, name, key := parts[0], parts[1], parts[2]
+
+	secret, err := client.GetSecret(namespace, name)
+	if err != nil {
+		return "", fmt.Errorf("error while reading secret %s/%s: %w", namespace, name, err)
+	}
+
+	val, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: key %q of secret %s/%s", ErrKeyPathNotFound, key, namespace, name)
+	}
+
+	return string(val), nil
+}
@@ -0,0 +1,213 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package parserx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/sighupio/furyctl/internal/kubernetes"
+	parserx "github.com/sighupio/furyctl/internal/parser"
+	"github.com/sighupio/furyctl/internal/redact"
+)
+
+func TestVaultProvider_Resolve(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root-token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+
+			return
+		}
+
+		if r.URL.Path != "/v1/secret/data/furyctl/oidc" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+
+			return
+		}
+
+		assert.Equal(t, "team-a", r.Header.Get("X-Vault-Namespace"))
+
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"data":     map[string]any{"clientSecret": "vault-oidc-secret", "port": 8443},
+				"metadata": map[string]any{"version": 3},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	provider := &parserx.VaultProvider{Address: srv.URL, Token: "root-token", Namespace: "team-a"}
+
+	val, err := provider.Resolve("", "secret/furyctl/oidc#clientSecret")
+	require.NoError(t, err)
+	assert.Equal(t, "vault-oidc-secret", val)
+
+	val, err = provider.Resolve("", "secret/furyctl/oidc#port")
+	require.NoError(t, err)
+	assert.Equal(t, "8443", val)
+
+	_, err = provider.Resolve("", "secret/furyctl/oidc#missing")
+	require.ErrorIs(t, err, parserx.ErrKeyPathNotFound)
+
+	_, err = provider.Resolve("", "secret/furyctl/other#clientSecret")
+	require.ErrorIs(t, err, parserx.ErrVaultResponse)
+
+	_, err = provider.Resolve("", "secret/furyctl/oidc")
+	require.ErrorIs(t, err, parserx.ErrInvalidReference)
+
+	denied := &parserx.VaultProvider{Address: srv.URL, Token: "wrong", Namespace: "team-a"}
+
+	_, err = denied.Resolve("", "secret/furyctl/oidc#clientSecret")
+	require.ErrorIs(t, err, parserx.ErrVaultResponse)
+	require.ErrorContains(t, err, "permission denied")
+}
+
+//nolint:paralleltest // The age identities come from the environment.
+func TestSopsProvider_ResolveAgeFile(t *testing.T) {
+	id, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	w, err := age.Encrypt(&buf, id.Recipient())
+	require.NoError(t, err)
+
+	_, err = w.Write([]byte("oidc:\n  clientSecret: sops-oidc-secret\nusers:\n  - name: alice\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	baseDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(baseDir, "secrets.yaml.age"), buf.Bytes(), 0o600))
+
+	keyFile := filepath.Join(t.TempDir(), "keys.txt")
+	require.NoError(t, os.WriteFile(keyFile, []byte(id.String()+"\n"), 0o600))
+
+	t.Setenv("SOPS_AGE_KEY_FILE", keyFile)
+	t.Setenv("SOPS_AGE_KEY", "")
+
+	provider := &parserx.SopsProvider{}
+
+	val, err := provider.Resolve(baseDir, "./secrets.yaml.age#oidc.clientSecret")
+	require.NoError(t, err)
+	assert.Equal(t, "sops-oidc-secret", val)
+
+	val, err = provider.Resolve(baseDir, "./secrets.yaml.age#users.0.name")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", val)
+
+	_, err = provider.Resolve(baseDir, "./secrets.yaml.age#users.1.name")
+	require.ErrorIs(t, err, parserx.ErrKeyPathNotFound)
+
+	t.Setenv("SOPS_AGE_KEY_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+
+	_, err = provider.Resolve(baseDir, "./secrets.yaml.age#oidc.clientSecret")
+	require.ErrorIs(t, err, parserx.ErrAgeIdentityNotFound)
+}
+
+func TestKubernetesSecretProvider_Resolve(t *testing.T) {
+	t.Parallel()
+
+	provider := &parserx.KubernetesSecretProvider{
+		Client: kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: "infra"},
+			Data:       map[string][]byte{"accessKey": []byte("s3-secret-key")},
+		}), nil, nil),
+	}
+
+	val, err := provider.Resolve("", "infra/s3/accessKey")
+	require.NoError(t, err)
+	assert.Equal(t, "s3-secret-key", val)
+
+	_, err = provider.Resolve("", "infra/s3/secretKey")
+	require.ErrorIs(t, err, parserx.ErrKeyPathNotFound)
+
+	_, err = provider.Resolve("", "infra/minio/accessKey")
+	require.ErrorIs(t, err, kubernetes.ErrNotFound)
+
+	_, err = provider.Resolve("", "infra/s3")
+	require.ErrorIs(t, err, parserx.ErrInvalidReference)
+}
+
+func TestConfigParser_ParseDynamicValue_SecretProviders(t *testing.T) {
+	t.Parallel()
+
+	baseDir := t.TempDir()
+
+	helper := filepath.Join(baseDir, "helper.sh")
+	require.NoError(t, os.WriteFile(helper, []byte("#!/bin/sh\necho \"exec-secret-$1\"\n"), 0o700))
+
+	parserx.RegisterSecretProvider("test-provider", parserx.ProviderFunc(func(_, ref string) (string, error) {
+		return "test-provider-secret-" + ref, nil
+	}))
+
+	assert.Contains(t, parserx.SecretProviders(), "test-provider")
+	assert.Contains(t, parserx.SecretProviders(), parserx.Vault)
+
+	cfgParser := parserx.NewConfigParser(baseDir)
+
+	val, err := cfgParser.ParseDynamicValue("{exec://./helper.sh https://idp.example.com}")
+	require.NoError(t, err)
+	assert.Equal(t, "exec-secret-https://idp.example.com", val)
+
+	val, err = cfgParser.ParseDynamicValue("token={test-provider://one}")
+	require.NoError(t, err)
+	assert.Equal(t, "token=test-provider-secret-one", val)
+
+	// The resolved values are masked.
+	assert.Equal(t, "token="+redact.Mask, redact.String("token=test-provider-secret-one"))
+	assert.Equal(t, "secret "+redact.Mask, redact.String("secret exec-secret-https://idp.example.com"))
+
+	_, err = cfgParser.ParseDynamicValue("{exec://./missing.sh}")
+	require.ErrorIs(t, err, parserx.ErrCannotParseDynamicValue)
+}
+
+//nolint:paralleltest // the sensitive values are global.
+func TestRegisterStoredSecrets(t *testing.T) {
+	redact.Reset()
+	t.Cleanup(redact.Reset)
+
+	stored := map[string]any{
+		"spec": map[string]any{
+			"password": "old-password",
+			"nodes":    []any{map[string]any{"token": "old-token"}},
+			"region":   "eu-west-1",
+			"env":      "from-env",
+		},
+	}
+
+	current := map[string]any{
+		"spec": map[string]any{
+			"password": "{vault://secret/app#password}",
+			"nodes":    []any{map[string]any{"token": "{exec://./get-token.sh}"}},
+			"region":   "eu-west-1",
+			"env":      "{env://VALUE}",
+		},
+	}
+
+	parserx.RegisterStoredSecrets(stored, current)
+
+	assert.Equal(t,
+		"<redacted> <redacted> eu-west-1 from-env",
+		redact.String("old-password old-token eu-west-1 from-env"),
+	)
+}
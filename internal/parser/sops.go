@@ -0,0 +1,146 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parserx
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+const ageHeader = "age-encryption.org/v1"
+
+var ErrAgeIdentityNotFound = errors.New("no age identity found, set the SOPS_AGE_KEY_FILE or SOPS_AGE_KEY " +
+	"environment variable")
+
+// SopsProvider decrypts a file and returns its content, or the value at a key path of its content,
+// sops://<file>#<key path>. A SOPS file is decrypted with the sops command line, an age file, binary or
+// armored, with the age identities of SOPS: the SOPS_AGE_KEY_FILE and SOPS_AGE_KEY environment variables,
+// or the keys.txt file in the sops/age folder of the user configuration.
+type SopsProvider struct {
+	// Sops is the path of the sops binary, by default the one in PATH.
+	Sops string
+}
+
+func (p *SopsProvider) Resolve(baseDir, ref string) (string, error) {
+	location, keyPath := splitKeyPath(ref)
+	if location == "" {
+		return "", fmt.Errorf("%w: %q, the format is <file>#<key path>", ErrInvalidReference, ref)
+	}
+
+	file := resolveRelativePath(baseDir, location)
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("error while reading encrypted file: %w", err)
+	}
+
+	var plaintext []byte
+
+	if isAgeFile(content) {
+		plaintext, err = decryptAge(content)
+	} else {
+		plaintext, err = p.decryptSops(file)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if keyPath == "" {
+		return strings.TrimRight(string(plaintext), "\n"), nil
+	}
+
+	return selectKeyPath(plaintext, keyPath)
+}
+
+func (p *SopsProvider) decryptSops(file string) ([]byte, error) {
+	sops := p.Sops
+	if sops == "" {
+		sops = "sops"
+	}
+
+	out, err := runSensitive(sops, "", "--decrypt", file)
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting %s with sops: %w", file, err)
+	}
+
+	return []byte(out), nil
+}
+
+func isAgeFile(content []byte) bool {
+	return bytes.HasPrefix(content, []byte(ageHeader)) || bytes.HasPrefix(content, []byte(armor.Header))
+}
+
+func decryptAge(content []byte) ([]byte, error) {
+	identities, err := ageIdentities()
+	if err != nil {
+		return nil, err
+	}
+
+	var src io.Reader = bytes.NewReader(content)
+
+	if bytes.HasPrefix(content, []byte(armor.Header)) {
+		src = armor.NewReader(src)
+	}
+
+	r, err := age.Decrypt(src, identities...)
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting age file: %w", err)
+	}
+
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting age file: %w", err)
+	}
+
+	return plaintext, nil
+}
+
+// ageIdentities reads the age identities from the same places as SOPS.
+func ageIdentities() ([]age.Identity, error) {
+	var identities []age.Identity
+
+	if key := os.Getenv("SOPS_AGE_KEY"); key != "" {
+		ids, err := age.ParseIdentities(strings.NewReader(key))
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing SOPS_AGE_KEY: %w", err)
+		}
+
+		identities = append(identities, ids...)
+	}
+
+	keyFile := os.Getenv("SOPS_AGE_KEY_FILE")
+	if keyFile == "" {
+		if configDir, err := os.UserConfigDir(); err == nil {
+			keyFile = filepath.Join(configDir, "sops", "age", "keys.txt")
+		}
+	}
+
+	if f, err := os.Open(keyFile); err == nil {
+		defer f.Close()
+
+		ids, err := age.ParseIdentities(bufio.NewReader(f))
+		if err != nil {
+			return nil, fmt.Errorf("error while parsing age identities in %s: %w", keyFile, err)
+		}
+
+		identities = append(identities, ids...)
+	}
+
+	if len(identities) == 0 {
+		return nil, ErrAgeIdentityNotFound
+	}
+
+	return identities, nil
+}
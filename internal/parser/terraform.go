@@ -5,75 +5,156 @@
 package parserx
 
 import (
-	"strings"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
 
-	"github.com/sirupsen/logrus"
+const (
+	TfActionCreate = "create"
+	TfActionUpdate = "update"
+	TfActionDelete = "delete"
 )
 
-const MinDiffLineTokensNum = 3
+var ErrTfPlanJSON = errors.New("invalid terraform plan JSON")
 
-type TfPlanParser struct {
-	Plan string
+// TfPlanJSONParser parses the output of `terraform show -json` on a saved plan.
+type TfPlanJSONParser struct {
+	Plan []byte
 }
 
 type TfPlan struct {
-	Destroy []string
-	Add     []string
-	Change  []string
+	Destroy         []string
+	Add             []string
+	Change          []string
+	ResourceChanges []TfResourceChange
 }
 
-func NewTfPlanParser(plan string) *TfPlanParser {
-	return &TfPlanParser{
-		Plan: plan,
+// TfResourceChange is the change that the plan makes to one resource instance.
+type TfResourceChange struct {
+	Address string
+	// PreviousAddress is set when a moved block changes the address of the resource.
+	PreviousAddress string
+	Type            string
+	Actions         []string
+	// ActionReason tells why the resource is replaced or deleted, for example replace_because_tainted.
+	ActionReason string
+	Importing    bool
+}
+
+// Deletes tells whether the change deletes the resource, also to replace it.
+func (c TfResourceChange) Deletes() bool {
+	return slices.Contains(c.Actions, TfActionDelete)
+}
+
+// Replaces tells whether the change deletes the resource and creates it again.
+func (c TfResourceChange) Replaces() bool {
+	return c.Deletes() && slices.Contains(c.Actions, TfActionCreate)
+}
+
+// String returns the address of the resource, with the reason of a deletion or a replacement.
+func (c TfResourceChange) String() string {
+	s := c.Address
+
+	switch {
+	case c.Replaces():
+		s += " (replaced"
+
+	case c.Deletes():
+		s += " (deleted"
+
+	default:
+		return s
 	}
+
+	if c.ActionReason != "" {
+		s += ", " + c.ActionReason
+	}
+
+	return s + ")"
 }
 
-func (p *TfPlanParser) Parse() *TfPlan {
-	pl := TfPlan{
-		Destroy: []string{},
-		Add:     []string{},
-		Change:  []string{},
+type tfPlanJSON struct {
+	FormatVersion   string `json:"format_version"`
+	ResourceChanges []struct {
+		Address         string `json:"address"`
+		PreviousAddress string `json:"previous_address"`
+		Mode            string `json:"mode"`
+		Type            string `json:"type"`
+		ActionReason    string `json:"action_reason"`
+		Change          struct {
+			Actions   []string        `json:"actions"`
+			Importing json.RawMessage `json:"importing"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+}
+
+func NewTfPlanJSONParser(plan []byte) *TfPlanJSONParser {
+	return &TfPlanJSONParser{
+		Plan: plan,
 	}
+}
 
-	planLines := strings.Split(p.Plan, "\n")
+// Parse walks the resource_changes of the plan. Unlike the text output, the JSON format is stable across
+// Terraform and OpenTofu versions. A replacement is both a deletion and a creation.
+func (p *TfPlanJSONParser) Parse() (*TfPlan, error) {
+	var raw tfPlanJSON
 
-	for i, line := range planLines {
-		line = strings.TrimSpace(line)
+	if err := json.Unmarshal(p.Plan, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrTfPlanJSON, err)
+	}
 
-		if !strings.HasPrefix(line, "#") || i+1 >= len(planLines) {
-			continue
-		}
+	if raw.FormatVersion == "" {
+		return nil, fmt.Errorf("%w: format version is missing", ErrTfPlanJSON)
+	}
 
-		diffLineTokens := strings.Split(strings.TrimSpace(planLines[i+1]), " ")
+	pl := TfPlan{
+		Destroy:         []string{},
+		Add:             []string{},
+		Change:          []string{},
+		ResourceChanges: []TfResourceChange{},
+	}
 
-		if len(diffLineTokens) < MinDiffLineTokensNum {
+	for _, rc := range raw.ResourceChanges {
+		// Data sources are read, not changed.
+		if rc.Mode == "data" {
 			continue
 		}
 
-		resourceName := strings.Trim(diffLineTokens[2], "\"")
+		change := TfResourceChange{
+			Address:         rc.Address,
+			PreviousAddress: rc.PreviousAddress,
+			Type:            rc.Type,
+			Actions:         rc.Change.Actions,
+			ActionReason:    rc.ActionReason,
+			Importing:       len(rc.Change.Importing) > 0 && string(rc.Change.Importing) != "null",
+		}
 
-		switch diffLineTokens[0] {
-		case "+":
-			pl.Add = append(pl.Add, resourceName)
+		changed := false
 
-		case "-":
-			pl.Destroy = append(pl.Destroy, resourceName)
+		if change.Deletes() {
+			pl.Destroy = append(pl.Destroy, rc.Type)
+			changed = true
+		}
 
-		case "~":
-			pl.Change = append(pl.Change, resourceName)
+		if slices.Contains(change.Actions, TfActionCreate) {
+			pl.Add = append(pl.Add, rc.Type)
+			changed = true
+		}
 
-		case "-/+":
-			pl.Destroy = append(pl.Destroy, resourceName)
-			pl.Add = append(pl.Add, resourceName)
+		if slices.Contains(change.Actions, TfActionUpdate) {
+			pl.Change = append(pl.Change, rc.Type)
+			changed = true
+		}
 
-		case "+/-":
-			pl.Add = append(pl.Add, resourceName)
-			pl.Destroy = append(pl.Destroy, resourceName)
+		// A moved or imported resource is listed even when its actions are no-op.
+		moved := change.PreviousAddress != "" && change.PreviousAddress != change.Address
 
-		default:
-			logrus.Debugf("ignoring unknown diff prefix %q", diffLineTokens[0])
+		if changed || moved || change.Importing {
+			pl.ResourceChanges = append(pl.ResourceChanges, change)
 		}
 	}
 
-	return &pl
+	return &pl, nil
 }
@@ -0,0 +1,157 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parserx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const vaultTimeout = 30 * time.Second
+
+var (
+	ErrVaultAddressNotSet = errors.New("the address of Vault is not set, set the VAULT_ADDR environment variable")
+	ErrVaultTokenNotSet   = errors.New("the token of Vault is not set, set the VAULT_TOKEN environment variable " +
+		"or log in with the vault command line")
+	ErrVaultResponse = errors.New("unexpected response from Vault")
+)
+
+// VaultProvider reads a key of a secret of a KV version 2 engine with the HTTP API of Vault,
+// vault://<mount>/<path>#<key>. The address, the token and the namespace come from the VAULT_ADDR,
+// VAULT_TOKEN and VAULT_NAMESPACE environment variables, the token falls back to ~/.vault-token.
+type VaultProvider struct {
+	// Address, Token and Namespace take precedence over the environment variables.
+	Address   string
+	Token     string
+	Namespace string
+
+	Client *http.Client
+}
+
+type vaultKVResponse struct {
+	Data struct {
+		Data map[string]any `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+func (p *VaultProvider) Resolve(_, ref string) (string, error) {
+	location, key := splitKeyPath(ref)
+
+	mount, secretPath, ok := strings.Cut(strings.Trim(location, "/"), "/")
+	if !ok || secretPath == "" || key == "" {
+		return "", fmt.Errorf("%w: %q, the format is <mount>/<path>#<key>", ErrInvalidReference, ref)
+	}
+
+	address, token, err := p.credentials()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), vaultTimeout)
+	defer cancel()
+
+	url := strings.TrimRight(address, "/") + "/v1/" + mount + "/data/" + secretPath
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", fmt.Errorf("error while creating Vault request: %w", err)
+	}
+
+	req.Header.Set("X-Vault-Token", token)
+
+	if ns := p.namespace(); ns != "" {
+		req.Header.Set("X-Vault-Namespace", ns)
+	}
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error while reading secret %s from Vault: %w", location, err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error while reading secret %s from Vault: %w", location, err)
+	}
+
+	kv := vaultKVResponse{}
+
+	if err := json.Unmarshal(body, &kv); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("error while decoding secret %s from Vault: %w", location, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: %s for secret %s %s", ErrVaultResponse, resp.Status, location, strings.Join(kv.Errors, ", "))
+	}
+
+	val, ok := kv.Data.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: key %q of secret %s", ErrKeyPathNotFound, key, location)
+	}
+
+	if s, ok := val.(string); ok {
+		return s, nil
+	}
+
+	out, err := json.Marshal(val)
+	if err != nil {
+		return "", fmt.Errorf("error while marshalling key %q of secret %s: %w", key, location, err)
+	}
+
+	return string(out), nil
+}
+
+func (p *VaultProvider) credentials() (string, string, error) {
+	address := p.Address
+	if address == "" {
+		address = os.Getenv("VAULT_ADDR")
+	}
+
+	if address == "" {
+		return "", "", ErrVaultAddressNotSet
+	}
+
+	token := p.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+
+	if token == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			if t, err := os.ReadFile(filepath.Join(home, ".vault-token")); err == nil {
+				token = strings.TrimSpace(string(t))
+			}
+		}
+	}
+
+	if token == "" {
+		return "", "", ErrVaultTokenNotSet
+	}
+
+	return address, token, nil
+}
+
+func (p *VaultProvider) namespace() string {
+	if p.Namespace != "" {
+		return p.Namespace
+	}
+
+	return os.Getenv("VAULT_NAMESPACE")
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/kubernetes"
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/state/backend"
+)
+
+// Fingerprint identifies the inputs of a plan. A saved plan applies only while they do not change.
+type Fingerprint struct {
+	Config              string `json:"config"`
+	DistributionVersion string `json:"distributionVersion"`
+	Distribution        string `json:"distribution"`
+	// State is empty when the cluster stores no configuration, as for a cluster that does not exist yet.
+	State string `json:"state"`
+}
+
+// NewFingerprint hashes the rendered configuration file and the files of the distribution. The state
+// of the cluster is hashed by the creator, that knows how to reach the cluster.
+func NewFingerprint(rendered map[string]any, distroPath, distroVersion string) (Fingerprint, error) {
+	// The keys of the maps are sorted, so the same configuration always has the same hash.
+	renderedJSON, err := json.Marshal(rendered)
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("error while marshalling configuration file: %w", err)
+	}
+
+	distroHash, err := hashDir(distroPath)
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("error while hashing distribution: %w", err)
+	}
+
+	return Fingerprint{
+		Config:              hashBytes(renderedJSON),
+		DistributionVersion: distroVersion,
+		Distribution:        distroHash,
+	}, nil
+}
+
+// RecordState records the hash of the configuration that the cluster stores. A state that cannot be read
+// is an error: only a missing one is recorded as empty.
+func (r *Report) RecordState(store state.Storer) error {
+	if r == nil {
+		return nil
+	}
+
+	hash, err := stateHash(store)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Fingerprint.State = hash
+
+	return nil
+}
+
+// stateHash returns the hash of the configuration that the cluster stores, or an empty string when there is
+// none: the state is not found, or there is no kubeconfig to reach a cluster that does not exist yet. Any
+// other error, as a wrong encryption key or an unreachable backend, is returned.
+func stateHash(store state.Storer) (string, error) {
+	stored, err := store.GetConfig()
+	if errors.Is(err, backend.ErrNotFound) ||
+		errors.Is(err, os.ErrNotExist) ||
+		errors.Is(err, kubernetes.ErrNoKubeconfig) {
+		logrus.Debugf("The cluster stores no configuration: %v", err)
+
+		return "", nil
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("error while reading the configuration stored in the cluster: %w", err)
+	}
+
+	return hashBytes(stored), nil
+}
+
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// hashDir hashes the relative paths and the content of the files in dir, in lexical order.
+func hashDir(dir string) (string, error) {
+	h := sha256.New()
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return fmt.Errorf("error while getting relative path of %s: %w", p, err)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return fmt.Errorf("error while reading %s: %w", p, err)
+		}
+
+		// The size separates the content of a file from the name of the next one.
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), info.Size())
+
+		f, err := os.Open(p)
+		if err != nil {
+			return fmt.Errorf("error while opening %s: %w", p, err)
+		}
+
+		defer f.Close()
+
+		if _, err := io.Copy(h, f); err != nil {
+			return fmt.Errorf("error while reading %s: %w", p, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error while walking %s: %w", dir, err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plan
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/sighupio/furyctl/internal/tool/kubectl"
+	"github.com/sighupio/furyctl/internal/tool/kustomize"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+)
+
+const (
+	ManifestActionCreate = "create"
+	ManifestActionUpdate = "update"
+	ManifestActionPrune  = "prune"
+
+	// BuiltManifestsFile is where the plan writes the output of kustomize, next to the kustomization.
+	BuiltManifestsFile = "plan-out.yaml"
+	// AppliedManifestsFile is where the apply script of the distribution writes the manifests it applies.
+	AppliedManifestsFile = "out.yaml"
+)
+
+// ManifestChange is an object that the apply creates or changes in the cluster, or that the new
+// configuration no longer renders.
+type ManifestChange struct {
+	Phase      string `json:"phase"`
+	Action     string `json:"action"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// RenderedPhase is where a phase rendered the manifests that the apply would apply, with the tools that
+// build them and compare them with the cluster.
+type RenderedPhase struct {
+	Phase string
+	// Dir is the folder of the kustomization of the distribution phase, or the folder of the helmfile of
+	// the plugins phase.
+	Dir           string
+	KubectlPath   string
+	KustomizePath string
+	HelmfilePath  string
+	// Kustomizations are the folders of the kustomize plugins, relative to Dir.
+	Kustomizations []string
+	// HelmReleases tells whether the helmfile has releases.
+	HelmReleases bool
+}
+
+func (m ManifestChange) String() string {
+	if m.Namespace == "" {
+		return fmt.Sprintf("%s %s", m.Kind, m.Name)
+	}
+
+	return fmt.Sprintf("%s %s/%s", m.Kind, m.Namespace, m.Name)
+}
+
+// key identifies the object in the way `kubectl diff` names its files: group.version.Kind.namespace.name.
+func (m ManifestChange) key() string {
+	return strings.ReplaceAll(m.APIVersion, "/", ".") + "." + m.Kind + "." + m.Namespace + "." + m.Name
+}
+
+// AddManifests builds the kustomization in manifestsDir and records the objects that the apply creates
+// or changes, according to `kubectl diff`. When the cluster does not answer, as for a cluster that does
+// not exist yet, every object is a creation. The objects of the last applied build that the new build
+// does not contain are recorded as pruned.
+func (r *Report) AddManifests(phase, kustomizePath, kubectlPath, manifestsDir string) error {
+	if r == nil {
+		return nil
+	}
+
+	builtPath := filepath.Join(manifestsDir, BuiltManifestsFile)
+
+	kustomizeRunner := kustomize.NewRunner(execx.NewStdExecutor(), kustomize.Paths{
+		Kustomize: kustomizePath,
+		WorkDir:   manifestsDir,
+	})
+
+	if err := kustomizeRunner.Build(".", builtPath); err != nil {
+		return fmt.Errorf("error while building %s manifests: %w", phase, err)
+	}
+
+	built, err := readManifests(builtPath)
+	if err != nil {
+		return err
+	}
+
+	changes := []ManifestChange{}
+
+	kubeRunner := kubectl.NewRunner(
+		execx.NewStdExecutor(),
+		kubectl.Paths{
+			Kubectl: kubectlPath,
+			WorkDir: manifestsDir,
+		},
+		false,
+		true,
+		false,
+	)
+
+	if _, err := kubeRunner.Version(); err == nil {
+		out, err := kubeRunner.Diff(builtPath)
+		if err != nil {
+			return fmt.Errorf("error while comparing %s manifests with the cluster: %w", phase, err)
+		}
+
+		changes = ParseKubectlDiff(out, built)
+	} else {
+		logrus.Debugf("Cluster is unreachable, all the %s manifests are new: %v", phase, err)
+
+		for _, m := range built {
+			m.Action = ManifestActionCreate
+			changes = append(changes, m)
+		}
+	}
+
+	applied, err := readManifests(filepath.Join(manifestsDir, AppliedManifestsFile))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	changes = append(changes, Pruned(applied, built)...)
+
+	for i := range changes {
+		changes[i].Phase = phase
+	}
+
+	r.addManifests(phase, builtPath, changes)
+
+	r.RecordRenderedPhase(RenderedPhase{
+		Phase:         phase,
+		Dir:           manifestsDir,
+		KubectlPath:   kubectlPath,
+		KustomizePath: kustomizePath,
+	})
+
+	return nil
+}
+
+// ParseKubectlDiff returns the objects that the unified output of `kubectl diff` creates or changes. An
+// object whose live version is empty is a creation.
+func ParseKubectlDiff(out string, objects []ManifestChange) []ManifestChange {
+	byKey := make(map[string]ManifestChange, len(objects))
+
+	for _, o := range objects {
+		byKey[o.key()] = o
+	}
+
+	changes := []ManifestChange{}
+	current := -1
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), 1<<20) //nolint:mnd // long lines in ConfigMaps.
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case strings.HasPrefix(line, "diff "):
+			fields := strings.Fields(line)
+			name := filepath.Base(fields[len(fields)-1])
+
+			obj, ok := byKey[name]
+			if !ok {
+				obj = unknownObject(name)
+			}
+
+			obj.Action = ManifestActionUpdate
+			changes = append(changes, obj)
+			current = len(changes) - 1
+
+		case current >= 0 && strings.HasPrefix(line, "@@ -0,0 "):
+			changes[current].Action = ManifestActionCreate
+			current = -1
+
+		case strings.HasPrefix(line, "@@"):
+			current = -1
+		}
+	}
+
+	return changes
+}
+
+// Pruned returns the objects of the previous build that the new one does not contain.
+func Pruned(previous, current []ManifestChange) []ManifestChange {
+	keys := make(map[string]bool, len(current))
+
+	for _, o := range current {
+		keys[o.key()] = true
+	}
+
+	pruned := []ManifestChange{}
+
+	for _, o := range previous {
+		if !keys[o.key()] {
+			o.Action = ManifestActionPrune
+			pruned = append(pruned, o)
+		}
+	}
+
+	return pruned
+}
+
+// unknownObject splits the kubectl diff name of an object that is not in the build, as for an object
+// without namespace in the manifests, that kubectl places in the namespace of the kubeconfig.
+func unknownObject(name string) ManifestChange {
+	// The name of the object can contain dots, the other fields cannot.
+	parts := strings.Split(name, ".")
+
+	const minParts = 4
+
+	if len(parts) < minParts {
+		return ManifestChange{Name: name}
+	}
+
+	for i := 1; i < len(parts)-2; i++ {
+		// The kind is the first capitalised part.
+		if parts[i] != "" && strings.ToUpper(parts[i][:1]) == parts[i][:1] {
+			apiVersion := parts[i-1]
+			if i > 1 {
+				apiVersion = strings.Join(parts[:i-1], ".") + "/" + apiVersion
+			}
+
+			return ManifestChange{
+				APIVersion: apiVersion,
+				Kind:       parts[i],
+				Namespace:  parts[i+1],
+				Name:       strings.Join(parts[i+2:], "."),
+			}
+		}
+	}
+
+	return ManifestChange{Name: name}
+}
+
+func readManifests(manifestPath string) ([]ManifestChange, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading manifests: %w", err)
+	}
+
+	return ParseManifests(data)
+}
+
+// ParseManifests returns the identity of the objects in a multi-document YAML.
+func ParseManifests(data []byte) ([]ManifestChange, error) {
+	objects := []ManifestChange{}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		obj := struct {
+			APIVersion string `yaml:"apiVersion"`
+			Kind       string `yaml:"kind"`
+			Metadata   struct {
+				Name      string `yaml:"name"`
+				Namespace string `yaml:"namespace"`
+			} `yaml:"metadata"`
+		}{}
+
+		if err := dec.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+
+			return nil, fmt.Errorf("error while parsing manifests: %w", err)
+		}
+
+		if obj.Kind == "" {
+			continue
+		}
+
+		objects = append(objects, ManifestChange{
+			APIVersion: obj.APIVersion,
+			Kind:       obj.Kind,
+			Namespace:  obj.Metadata.Namespace,
+			Name:       obj.Metadata.Name,
+		})
+	}
+
+	return objects, nil
+}
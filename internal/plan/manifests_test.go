@@ -0,0 +1,129 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package plan_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/plan"
+)
+
+const manifests = `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: logging
+---
+# A comment only document.
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: loki.distributor
+  namespace: logging
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: logging
+`
+
+func TestParseManifests(t *testing.T) {
+	t.Parallel()
+
+	got, err := plan.ParseManifests([]byte(manifests))
+	require.NoError(t, err)
+
+	assert.Equal(t, []plan.ManifestChange{
+		{APIVersion: "v1", Kind: "Namespace", Name: "logging"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "logging", Name: "loki.distributor"},
+		{APIVersion: "v1", Kind: "ConfigMap", Namespace: "logging", Name: "settings"},
+	}, got)
+
+	_, err = plan.ParseManifests([]byte("kind: [\n"))
+	require.Error(t, err)
+}
+
+func TestParseKubectlDiff(t *testing.T) {
+	t.Parallel()
+
+	objects, err := plan.ParseManifests([]byte(manifests))
+	require.NoError(t, err)
+
+	out := `diff -u -N /tmp/LIVE-1/apps.v1.Deployment.logging.loki.distributor /tmp/MERGED-1/apps.v1.Deployment.logging.loki.distributor
+--- /tmp/LIVE-1/apps.v1.Deployment.logging.loki.distributor
++++ /tmp/MERGED-1/apps.v1.Deployment.logging.loki.distributor
+@@ -6,7 +6,7 @@
+-  replicas: 1
++  replicas: 2
+diff -u -N /tmp/LIVE-1/v1.ConfigMap.logging.settings /tmp/MERGED-1/v1.ConfigMap.logging.settings
+--- /tmp/LIVE-1/v1.ConfigMap.logging.settings
++++ /tmp/MERGED-1/v1.ConfigMap.logging.settings
+@@ -0,0 +1,6 @@
++apiVersion: v1
+diff -u -N /tmp/LIVE-1/rbac.authorization.k8s.io.v1.Role.default.reader /tmp/MERGED-1/rbac.authorization.k8s.io.v1.Role.default.reader
+--- /tmp/LIVE-1/rbac.authorization.k8s.io.v1.Role.default.reader
++++ /tmp/MERGED-1/rbac.authorization.k8s.io.v1.Role.default.reader
+@@ -0,0 +1,6 @@
++apiVersion: rbac.authorization.k8s.io/v1
+`
+
+	assert.Equal(t, []plan.ManifestChange{
+		{
+			Action:     plan.ManifestActionUpdate,
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+			Namespace:  "logging",
+			Name:       "loki.distributor",
+		},
+		{
+			Action:     plan.ManifestActionCreate,
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+			Namespace:  "logging",
+			Name:       "settings",
+		},
+		{
+			Action:     plan.ManifestActionCreate,
+			APIVersion: "rbac.authorization.k8s.io/v1",
+			Kind:       "Role",
+			Namespace:  "default",
+			Name:       "reader",
+		},
+	}, plan.ParseKubectlDiff(out, objects))
+
+	assert.Empty(t, plan.ParseKubectlDiff("", objects))
+}
+
+func TestPruned(t *testing.T) {
+	t.Parallel()
+
+	previous := []plan.ManifestChange{
+		{APIVersion: "v1", Kind: "Namespace", Name: "logging"},
+		{APIVersion: "apps/v1", Kind: "StatefulSet", Namespace: "logging", Name: "opensearch"},
+	}
+	current := []plan.ManifestChange{
+		{APIVersion: "v1", Kind: "Namespace", Name: "logging"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "logging", Name: "loki.distributor"},
+	}
+
+	assert.Equal(t, []plan.ManifestChange{
+		{
+			Action:     plan.ManifestActionPrune,
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+			Namespace:  "logging",
+			Name:       "opensearch",
+		},
+	}, plan.Pruned(previous, current))
+
+	assert.Empty(t, plan.Pruned(nil, current))
+}
@@ -0,0 +1,369 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package plan collects what an apply would do into a single report: the configuration changes, the
+// reducers and migrations, the Terraform changes and the manifests to create, change or prune.
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	r3diff "github.com/r3labs/diff/v3"
+	"github.com/samber/lo"
+
+	parserx "github.com/sighupio/furyctl/internal/parser"
+	"github.com/sighupio/furyctl/internal/redact"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+	"github.com/sighupio/furyctl/pkg/diffs"
+	"github.com/sighupio/furyctl/pkg/reducers"
+	rules "github.com/sighupio/furyctl/pkg/rulesextractor"
+)
+
+const (
+	ReportJSONFile = "plan.json"
+	ReportTextFile = "plan.txt"
+)
+
+// Report is the result of a plan. A nil *Report records nothing, so the phases can record into it
+// without checking whether they run for a plan or for an apply.
+type Report struct {
+	Cluster             string           `json:"cluster"`
+	Kind                string           `json:"kind"`
+	DistributionVersion string           `json:"distributionVersion"`
+	CreatedAt           time.Time        `json:"createdAt"`
+	Phase               string           `json:"phase"`
+	Upgrade             bool             `json:"upgrade"`
+	Fingerprint         Fingerprint      `json:"fingerprint"`
+	ConfigChanges       []diffs.Change   `json:"configChanges"`
+	Reducers            []Reducer        `json:"reducers"`
+	Terraform           []TerraformPlan  `json:"terraform"`
+	Manifests           []ManifestChange `json:"manifests"`
+
+	// The files that a saved plan carries, keyed by phase.
+	terraformPlans    map[string]terraformPlanFile
+	renderedManifests map[string]string
+
+	// Where the phases rendered their manifests, keyed by phase.
+	renderedPhases map[string]RenderedPhase
+
+	// The EKS phases run in their own goroutine.
+	mu sync.Mutex
+}
+
+type terraformPlanFile struct {
+	path   string
+	output []byte
+}
+
+// Reducer is a reducer that the apply runs. The unsafe ones are the migrations that the apply runs only
+// after a confirmation, or with `--force migrations`.
+type Reducer struct {
+	Phase     string `json:"phase"`
+	Key       string `json:"key"`
+	Path      string `json:"path"`
+	Lifecycle string `json:"lifecycle"`
+	From      any    `json:"from"`
+	To        any    `json:"to"`
+	Unsafe    bool   `json:"unsafe"`
+}
+
+// TerraformPlan lists the resource types that the plan of a phase adds, changes and destroys.
+type TerraformPlan struct {
+	Phase   string   `json:"phase"`
+	Add     []string `json:"add"`
+	Change  []string `json:"change"`
+	Destroy []string `json:"destroy"`
+}
+
+func New(clusterName, kind, distributionVersion string) *Report {
+	return &Report{
+		Cluster:             clusterName,
+		Kind:                kind,
+		DistributionVersion: distributionVersion,
+		CreatedAt:           time.Now().UTC(),
+		ConfigChanges:       []diffs.Change{},
+		Reducers:            []Reducer{},
+		Terraform:           []TerraformPlan{},
+		Manifests:           []ManifestChange{},
+		terraformPlans:      map[string]terraformPlanFile{},
+		renderedManifests:   map[string]string{},
+		renderedPhases:      map[string]RenderedPhase{},
+	}
+}
+
+// AddConfigChanges records the differences between the configuration stored in the cluster and the new one.
+func (r *Report) AddConfigChanges(changelog r3diff.Changelog, extractor rules.Extractor) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ConfigChanges = append(r.ConfigChanges, diffs.NewChanges(changelog, extractor)...)
+}
+
+// AddReducers records the reducers that the apply runs for the phase, marking the unsafe ones.
+func (r *Report) AddReducers(phase string, rdcs reducers.Reducers, unsafeRules []rules.Rule) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	unsafePaths := rules.Paths(unsafeRules)
+
+	for _, rdc := range lo.Compact(rdcs) {
+		r.Reducers = append(r.Reducers, Reducer{
+			Phase:     phase,
+			Key:       rdc.GetKey(),
+			Path:      rdc.GetPath(),
+			Lifecycle: rdc.GetLifecycle(),
+			From:      rdc.GetFrom(),
+			To:        rdc.GetTo(),
+			Unsafe:    slices.Contains(unsafePaths, rdc.GetPath()),
+		})
+	}
+}
+
+// AddTerraformPlan records the plan of the phase, in the JSON format of `terraform show -json`: the same plan
+// that the apply checks for the deletions of critical resources. The output of `terraform plan` and the plan
+// file are what a saved plan carries.
+func (r *Report) AddTerraformPlan(phase string, planJSON, planOutput []byte, planFile string) error {
+	if r == nil {
+		return nil
+	}
+
+	parsed, err := parserx.NewTfPlanJSONParser(planJSON).Parse()
+	if err != nil {
+		return fmt.Errorf("error while parsing %s terraform plan: %w", phase, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Terraform = append(r.Terraform, TerraformPlan{
+		Phase:   phase,
+		Add:     parsed.Add,
+		Change:  parsed.Change,
+		Destroy: parsed.Destroy,
+	})
+
+	r.terraformPlans[phase] = terraformPlanFile{path: planFile, output: planOutput}
+
+	return nil
+}
+
+// RecordRenderedPhase records where a phase rendered the manifests that the apply would apply.
+func (r *Report) RecordRenderedPhase(rendered RenderedPhase) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.renderedPhases[rendered.Phase] = rendered
+}
+
+// RenderedPhase returns where the phase rendered its manifests, if it ran.
+func (r *Report) RenderedPhase(phase string) (RenderedPhase, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rendered, ok := r.renderedPhases[phase]
+
+	return rendered, ok
+}
+
+func (r *Report) addManifests(phase, builtPath string, changes []ManifestChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Manifests = append(r.Manifests, changes...)
+	r.renderedManifests[phase] = builtPath
+}
+
+// HasChanges tells whether the apply would change anything.
+func (r *Report) HasChanges() bool {
+	return len(r.ConfigChanges) > 0 ||
+		len(r.Reducers) > 0 ||
+		len(r.Manifests) > 0 ||
+		lo.SomeBy(r.Terraform, func(p TerraformPlan) bool {
+			return len(p.Add)+len(p.Change)+len(p.Destroy) > 0
+		})
+}
+
+// JSON returns the indented JSON representation of the report, with the sensitive values masked.
+func (r *Report) JSON() ([]byte, error) {
+	masked := struct {
+		Cluster             string           `json:"cluster"`
+		Kind                string           `json:"kind"`
+		DistributionVersion string           `json:"distributionVersion"`
+		CreatedAt           time.Time        `json:"createdAt"`
+		Phase               string           `json:"phase"`
+		Upgrade             bool             `json:"upgrade"`
+		Fingerprint         Fingerprint      `json:"fingerprint"`
+		ConfigChanges       []diffs.Change   `json:"configChanges"`
+		Reducers            []Reducer        `json:"reducers"`
+		Terraform           []TerraformPlan  `json:"terraform"`
+		Manifests           []ManifestChange `json:"manifests"`
+	}{
+		Cluster:             r.Cluster,
+		Kind:                r.Kind,
+		DistributionVersion: r.DistributionVersion,
+		CreatedAt:           r.CreatedAt,
+		Phase:               r.Phase,
+		Upgrade:             r.Upgrade,
+		Fingerprint:         r.Fingerprint,
+		ConfigChanges:       r.maskedConfigChanges(),
+		Reducers:            r.maskedReducers(),
+		Terraform:           r.Terraform,
+		Manifests:           r.Manifests,
+	}
+
+	var out bytes.Buffer
+
+	enc := json.NewEncoder(&out)
+	enc.SetIndent("", "  ")
+	// The mask stays readable.
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(masked); err != nil {
+		return nil, fmt.Errorf("error while marshalling plan report: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// String renders the report for humans, with the sensitive values masked.
+func (r *Report) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Plan for cluster %s (%s, distribution %s), created at %s\n",
+		r.Cluster, r.Kind, r.DistributionVersion, r.CreatedAt.Format(time.RFC3339))
+
+	if !r.HasChanges() {
+		b.WriteString("\nNo changes. The cluster matches the configuration.\n")
+
+		return b.String()
+	}
+
+	if len(r.ConfigChanges) > 0 {
+		fmt.Fprintf(&b, "\nConfiguration changes (%d):\n", len(r.ConfigChanges))
+
+		for _, c := range r.maskedConfigChanges() {
+			fmt.Fprintf(&b, "  %-6s %s: %v -> %v", c.Type, c.Path, c.From, c.To)
+
+			if c.Immutable {
+				b.WriteString(" [immutable]")
+			}
+
+			if c.ReducerUnsupported {
+				fmt.Fprintf(&b, " [unsupported: %s]", strings.Join(c.Reasons, "; "))
+			}
+
+			b.WriteString("\n")
+		}
+	}
+
+	if len(r.Reducers) > 0 {
+		fmt.Fprintf(&b, "\nReducers and migrations (%d):\n", len(r.Reducers))
+
+		for _, rdc := range r.maskedReducers() {
+			fmt.Fprintf(&b, "  %s/%s %s: %v -> %v", rdc.Phase, rdc.Lifecycle, rdc.Path, rdc.From, rdc.To)
+
+			if rdc.Unsafe {
+				b.WriteString(" [needs confirmation or --force migrations]")
+			}
+
+			b.WriteString("\n")
+		}
+	}
+
+	for _, p := range r.Terraform {
+		fmt.Fprintf(&b, "\nTerraform, %s phase: %d to add, %d to change, %d to destroy\n",
+			p.Phase, len(p.Add), len(p.Change), len(p.Destroy))
+
+		writeList(&b, "+", p.Add)
+		writeList(&b, "~", p.Change)
+		writeList(&b, "-", p.Destroy)
+	}
+
+	if len(r.Manifests) > 0 {
+		counts := lo.CountValuesBy(r.Manifests, func(m ManifestChange) string {
+			return m.Action
+		})
+
+		fmt.Fprintf(&b, "\nManifests: %d to create, %d to change, %d to prune\n",
+			counts[ManifestActionCreate], counts[ManifestActionUpdate], counts[ManifestActionPrune])
+
+		for _, m := range r.Manifests {
+			fmt.Fprintf(&b, "  %-6s %s, %s phase\n", m.Action, m, m.Phase)
+		}
+	}
+
+	return b.String()
+}
+
+// Write saves the report in the given folder, both as JSON and as text, and returns the paths of the files.
+func (r *Report) Write(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, iox.FullPermAccess); err != nil {
+		return nil, fmt.Errorf("error while creating plan folder: %w", err)
+	}
+
+	out, err := r.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	jsonPath := filepath.Join(dir, ReportJSONFile)
+	textPath := filepath.Join(dir, ReportTextFile)
+
+	if err := os.WriteFile(jsonPath, out, iox.FullRWPermAccess); err != nil {
+		return nil, fmt.Errorf("error while writing plan report: %w", err)
+	}
+
+	if err := os.WriteFile(textPath, []byte(r.String()), iox.FullRWPermAccess); err != nil {
+		return nil, fmt.Errorf("error while writing plan report: %w", err)
+	}
+
+	return []string{jsonPath, textPath}, nil
+}
+
+// maskedConfigChanges returns the configuration changes with the secrets masked in their values. The old
+// values come from the configuration stored in the cluster, the new ones from the secret providers.
+func (r *Report) maskedConfigChanges() []diffs.Change {
+	return lo.Map(r.ConfigChanges, func(c diffs.Change, _ int) diffs.Change {
+		c.From = redact.Value(c.From)
+		c.To = redact.Value(c.To)
+
+		return c
+	})
+}
+
+// maskedReducers returns the reducers with the secrets masked in their values.
+func (r *Report) maskedReducers() []Reducer {
+	return lo.Map(r.Reducers, func(rdc Reducer, _ int) Reducer {
+		rdc.From = redact.Value(rdc.From)
+		rdc.To = redact.Value(rdc.To)
+
+		return rdc
+	})
+}
+
+func writeList(b *strings.Builder, symbol string, items []string) {
+	for _, item := range items {
+		fmt.Fprintf(b, "  %s %s\n", symbol, item)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package plan_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	r3diff "github.com/r3labs/diff/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/redact"
+	"github.com/sighupio/furyctl/pkg/reducers"
+	rules "github.com/sighupio/furyctl/pkg/rulesextractor"
+)
+
+const tfPlanOutput = `
+  # aws_eks_cluster.this will be updated in-place
+  ~ resource "aws_eks_cluster" "this" {
+    }
+
+  # aws_security_group.node will be destroyed
+  - resource "aws_security_group" "node" {
+    }
+
+  # aws_iam_role.node will be created
+  + resource "aws_iam_role" "node" {
+    }
+`
+
+// The same plan, as `terraform show -json` prints it.
+const tfPlanJSON = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_eks_cluster.this", "mode": "managed", "type": "aws_eks_cluster", "change": {"actions": ["update"]}},
+    {"address": "aws_security_group.node", "mode": "managed", "type": "aws_security_group", "change": {"actions": ["delete"]}},
+    {"address": "aws_iam_role.node", "mode": "managed", "type": "aws_iam_role", "change": {"actions": ["create"]}},
+    {"address": "data.aws_ami.node", "mode": "data", "type": "aws_ami", "change": {"actions": ["read"]}}
+  ]
+}`
+
+func TestReport_NilRecordsNothing(t *testing.T) {
+	t.Parallel()
+
+	var r *plan.Report
+
+	assert.NotPanics(t, func() {
+		r.AddConfigChanges(r3diff.Changelog{{Type: r3diff.UPDATE, Path: []string{"spec"}}}, nil)
+		r.AddReducers("distribution", reducers.Reducers{reducers.NewBaseReducer("k", 1, 2, "pre-apply", ".spec")}, nil)
+		require.NoError(t, r.AddTerraformPlan("infrastructure", []byte(tfPlanJSON), []byte(tfPlanOutput), ""))
+		require.NoError(t, r.AddManifests("distribution", "kustomize", "kubectl", t.TempDir()))
+		r.RecordRenderedPhase(plan.RenderedPhase{Phase: "plugins"})
+	})
+}
+
+func TestReport_Record(t *testing.T) {
+	t.Parallel()
+
+	r := plan.New("test", "KFDDistribution", "v1.31.0")
+
+	assert.False(t, r.HasChanges())
+	assert.Contains(t, r.String(), "No changes.")
+
+	r.AddConfigChanges(r3diff.Changelog{
+		{
+			Type: r3diff.UPDATE,
+			Path: []string{"spec", "distribution", "modules", "logging", "type"},
+			From: "opensearch",
+			To:   "loki",
+		},
+	}, nil)
+
+	r.AddReducers(
+		"distribution",
+		reducers.Reducers{
+			reducers.NewBaseReducer(
+				"distributionModulesLoggingType",
+				"opensearch",
+				"loki",
+				"pre-apply",
+				".spec.distribution.modules.logging.type",
+			),
+			reducers.NewBaseReducer(
+				"distributionModulesDrType",
+				"none",
+				"on-premises",
+				"pre-apply",
+				".spec.distribution.modules.dr.type",
+			),
+		},
+		[]rules.Rule{{Path: ".spec.distribution.modules.logging.type"}},
+	)
+
+	require.NoError(t, r.AddTerraformPlan("infrastructure", []byte(tfPlanJSON), []byte(tfPlanOutput), ""))
+
+	require.True(t, r.HasChanges())
+
+	require.Len(t, r.ConfigChanges, 1)
+	assert.Equal(t, "distribution", r.ConfigChanges[0].Phase)
+
+	require.Len(t, r.Reducers, 2)
+	assert.True(t, r.Reducers[0].Unsafe)
+	assert.False(t, r.Reducers[1].Unsafe)
+
+	require.Len(t, r.Terraform, 1)
+	assert.Equal(t, plan.TerraformPlan{
+		Phase:   "infrastructure",
+		Add:     []string{"aws_iam_role"},
+		Change:  []string{"aws_eks_cluster"},
+		Destroy: []string{"aws_security_group"},
+	}, r.Terraform[0])
+
+	out := r.String()
+
+	assert.Contains(t, out, "Configuration changes (1):")
+	assert.Contains(t, out, "distribution/pre-apply .spec.distribution.modules.logging.type: opensearch -> loki "+
+		"[needs confirmation or --force migrations]")
+	assert.Contains(t, out, "Terraform, infrastructure phase: 1 to add, 1 to change, 1 to destroy")
+	assert.Contains(t, out, "  - aws_security_group\n")
+}
+
+func TestReport_TerraformPlanReplace(t *testing.T) {
+	t.Parallel()
+
+	r := plan.New("test", "EKSCluster", "v1.31.0")
+
+	require.NoError(t, r.AddTerraformPlan("kubernetes", []byte(`{
+  "format_version": "1.2",
+  "resource_changes": [
+    {"address": "aws_eks_node_group.a", "mode": "managed", "type": "aws_eks_node_group", "change": {"actions": ["delete", "create"]}}
+  ]
+}`), nil, ""))
+
+	require.Len(t, r.Terraform, 1)
+	assert.Equal(t, []string{"aws_eks_node_group"}, r.Terraform[0].Add)
+	assert.Equal(t, []string{"aws_eks_node_group"}, r.Terraform[0].Destroy)
+	assert.Empty(t, r.Terraform[0].Change)
+
+	err := r.AddTerraformPlan("infrastructure", []byte(tfPlanOutput), []byte(tfPlanOutput), "")
+	require.Error(t, err)
+	assert.Len(t, r.Terraform, 1)
+}
+
+func TestReport_Write(t *testing.T) {
+	t.Parallel()
+
+	r := plan.New("test", "OnPremises", "v1.31.0")
+	require.NoError(t, r.AddTerraformPlan("kubernetes", []byte(tfPlanJSON), []byte(tfPlanOutput), ""))
+
+	dir := filepath.Join(t.TempDir(), "plan")
+
+	files, err := r.Write(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, plan.ReportJSONFile), filepath.Join(dir, plan.ReportTextFile)}, files)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+
+	got := plan.Report{}
+	require.NoError(t, json.Unmarshal(data, &got))
+
+	assert.Equal(t, "test", got.Cluster)
+	assert.Equal(t, r.Terraform, got.Terraform)
+	assert.Empty(t, got.Manifests)
+
+	text, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	assert.Equal(t, r.String(), string(text))
+}
+
+//nolint:paralleltest // The sensitive values are global.
+func TestReport_MasksSecrets(t *testing.T) {
+	t.Cleanup(redact.Reset)
+
+	redact.Register("old-s3cr3t", "new-s3cr3t")
+
+	r := plan.New("test", "KFDDistribution", "v1.31.0")
+
+	r.AddConfigChanges(r3diff.Changelog{
+		{
+			Type: r3diff.UPDATE,
+			Path: []string{"spec", "distribution", "modules", "auth", "oidc", "clientSecret"},
+			From: "old-s3cr3t",
+			To:   "new-s3cr3t",
+		},
+	}, nil)
+
+	r.AddReducers(
+		"distribution",
+		reducers.Reducers{
+			reducers.NewBaseReducer(
+				"distributionModulesAuthSecret",
+				map[string]any{"secret": "old-s3cr3t"},
+				map[string]any{"secret": "new-s3cr3t"},
+				"pre-apply",
+				".spec.distribution.modules.auth.secret",
+			),
+		},
+		nil,
+	)
+
+	files, err := r.Write(t.TempDir())
+	require.NoError(t, err)
+
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "s3cr3t", f)
+		assert.Contains(t, string(data), redact.Mask, f)
+	}
+
+	assert.Equal(t, "new-s3cr3t", r.ConfigChanges[0].To, "the report keeps the values")
+}
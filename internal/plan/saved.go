@@ -0,0 +1,314 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package plan
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sighupio/furyctl/internal/state"
+	"github.com/sighupio/furyctl/internal/tool/kustomize"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+// archiveRoot is the folder of the saved plan in the archive. It holds the report, the Terraform plans
+// in terraform/<phase>.plan with their output in terraform/<phase>.log, and the rendered manifests in
+// manifests/<phase>.yaml.
+const archiveRoot = "plan"
+
+var (
+	ErrPlanDrift      = errors.New("the saved plan is out of date")
+	ErrPlanMismatch   = errors.New("the saved plan does not match the apply")
+	ErrPlanIncomplete = errors.New("the saved plan is incomplete")
+)
+
+// Saved is a plan read from an archive, that an apply executes.
+type Saved struct {
+	Report *Report
+
+	terraformPlans map[string]terraformPlan
+	manifests      map[string][]byte
+}
+
+type terraformPlan struct {
+	plan   []byte
+	output []byte
+}
+
+// Save writes the report, the Terraform plans and the rendered manifests of the plan to a tar.gz archive.
+func (r *Report) Save(archivePath string) error {
+	staging, err := os.MkdirTemp("", "furyctl-plan-")
+	if err != nil {
+		return fmt.Errorf("error while creating temporary folder: %w", err)
+	}
+
+	defer os.RemoveAll(staging)
+
+	if _, err := r.Write(staging); err != nil {
+		return err
+	}
+
+	for phase, tf := range r.terraformPlans {
+		plan, err := os.ReadFile(tf.path)
+		if err != nil {
+			return fmt.Errorf("error while reading the Terraform plan of the %s phase: %w", phase, err)
+		}
+
+		if err := writeArchiveFile(staging, filepath.Join("terraform", phase+".plan"), plan); err != nil {
+			return err
+		}
+
+		if err := writeArchiveFile(staging, filepath.Join("terraform", phase+".log"), tf.output); err != nil {
+			return err
+		}
+	}
+
+	for phase, builtPath := range r.renderedManifests {
+		manifests, err := os.ReadFile(builtPath)
+		if err != nil {
+			return fmt.Errorf("error while reading the %s manifests: %w", phase, err)
+		}
+
+		if err := writeArchiveFile(staging, filepath.Join("manifests", phase+".yaml"), manifests); err != nil {
+			return err
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(archivePath), iox.FullPermAccess); err != nil {
+		return fmt.Errorf("error while creating plan folder: %w", err)
+	}
+
+	if err := iox.CreateTarGz(archivePath, []iox.TarGzEntry{{Src: staging, Prefix: archiveRoot}}); err != nil {
+		return fmt.Errorf("error while saving plan: %w", err)
+	}
+
+	return nil
+}
+
+// Open reads a plan that Save wrote.
+func Open(archivePath string) (*Saved, error) {
+	dir, err := os.MkdirTemp("", "furyctl-plan-")
+	if err != nil {
+		return nil, fmt.Errorf("error while creating temporary folder: %w", err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := iox.ExtractTarGz(archivePath, dir); err != nil {
+		return nil, fmt.Errorf("error while extracting plan: %w", err)
+	}
+
+	root := filepath.Join(dir, archiveRoot)
+
+	data, err := os.ReadFile(filepath.Join(root, ReportJSONFile))
+	if err != nil {
+		return nil, fmt.Errorf("error while reading plan report: %w", err)
+	}
+
+	report := &Report{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("error while parsing plan report: %w", err)
+	}
+
+	s := &Saved{
+		Report:         report,
+		terraformPlans: map[string]terraformPlan{},
+		manifests:      map[string][]byte{},
+	}
+
+	tfFiles, err := readArchiveDir(filepath.Join(root, "terraform"))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, plan := range tfFiles {
+		phase, ok := strings.CutSuffix(name, ".plan")
+		if !ok {
+			continue
+		}
+
+		s.terraformPlans[phase] = terraformPlan{plan: plan, output: tfFiles[phase+".log"]}
+	}
+
+	manifestFiles, err := readArchiveDir(filepath.Join(root, "manifests"))
+	if err != nil {
+		return nil, err
+	}
+
+	for name, manifests := range manifestFiles {
+		s.manifests[strings.TrimSuffix(name, ".yaml")] = manifests
+	}
+
+	return s, nil
+}
+
+// Verify refuses a plan whose configuration or distribution changed since it was saved, or that the
+// apply runs with other phase or upgrade flags.
+func (s *Saved) Verify(fp Fingerprint, phase string, upgrade bool) error {
+	saved := s.Report
+
+	if saved.Phase != phase {
+		return fmt.Errorf("%w: the plan covers the phase %q, the apply the phase %q",
+			ErrPlanMismatch, phaseName(saved.Phase), phaseName(phase))
+	}
+
+	if saved.Upgrade != upgrade {
+		return fmt.Errorf("%w: the plan was saved with upgrade set to %t, the apply runs with %t",
+			ErrPlanMismatch, saved.Upgrade, upgrade)
+	}
+
+	drifts := []string{}
+
+	if saved.Fingerprint.Config != fp.Config {
+		drifts = append(drifts, "the configuration file changed")
+	}
+
+	if saved.Fingerprint.DistributionVersion != fp.DistributionVersion {
+		drifts = append(drifts, fmt.Sprintf("the distribution version changed from %s to %s",
+			saved.Fingerprint.DistributionVersion, fp.DistributionVersion))
+	} else if saved.Fingerprint.Distribution != fp.Distribution {
+		drifts = append(drifts, "the distribution files changed")
+	}
+
+	if len(drifts) > 0 {
+		return fmt.Errorf("%w: %s", ErrPlanDrift, strings.Join(drifts, ", "))
+	}
+
+	return nil
+}
+
+// VerifyState refuses a plan when the configuration that the cluster stores changed since the plan was
+// saved, as after another apply, or when it cannot be read.
+func (s *Saved) VerifyState(store state.Storer) error {
+	if s == nil {
+		return nil
+	}
+
+	hash, err := stateHash(store)
+	if err != nil {
+		return err
+	}
+
+	if hash != s.Report.Fingerprint.State {
+		return fmt.Errorf("%w: the configuration stored in the cluster changed", ErrPlanDrift)
+	}
+
+	return nil
+}
+
+// RestoreTerraformPlan writes the saved Terraform plan of the phase where the Terraform runner applies it,
+// and returns the output of the plan. It returns false when there is no saved plan to execute.
+func (s *Saved) RestoreTerraformPlan(phase, planFile string) ([]byte, bool, error) {
+	if s == nil {
+		return nil, false, nil
+	}
+
+	tf, ok := s.terraformPlans[phase]
+	if !ok {
+		return nil, false, fmt.Errorf("%w: it has no Terraform plan for the %s phase", ErrPlanIncomplete, phase)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(planFile), iox.FullPermAccess); err != nil {
+		return nil, false, fmt.Errorf("error while creating Terraform plan folder: %w", err)
+	}
+
+	if err := os.WriteFile(planFile, tf.plan, iox.FullRWPermAccess); err != nil {
+		return nil, false, fmt.Errorf("error while restoring the Terraform plan of the %s phase: %w", phase, err)
+	}
+
+	return tf.output, true, nil
+}
+
+// VerifyManifests builds the kustomization in manifestsDir and refuses a plan whose manifests of the
+// phase differ from the build.
+func (s *Saved) VerifyManifests(phase, kustomizePath, manifestsDir string) error {
+	if s == nil {
+		return nil
+	}
+
+	saved, ok := s.manifests[phase]
+	if !ok {
+		return fmt.Errorf("%w: it has no manifests for the %s phase", ErrPlanIncomplete, phase)
+	}
+
+	builtPath := filepath.Join(manifestsDir, BuiltManifestsFile)
+
+	kustomizeRunner := kustomize.NewRunner(execx.NewStdExecutor(), kustomize.Paths{
+		Kustomize: kustomizePath,
+		WorkDir:   manifestsDir,
+	})
+
+	if err := kustomizeRunner.Build(".", builtPath); err != nil {
+		return fmt.Errorf("error while building %s manifests: %w", phase, err)
+	}
+
+	built, err := os.ReadFile(builtPath)
+	if err != nil {
+		return fmt.Errorf("error while reading %s manifests: %w", phase, err)
+	}
+
+	if !bytes.Equal(built, saved) {
+		return fmt.Errorf("%w: the %s manifests differ from the reviewed ones", ErrPlanDrift, phase)
+	}
+
+	return nil
+}
+
+func phaseName(phase string) string {
+	if phase == "" {
+		return "all"
+	}
+
+	return phase
+}
+
+func writeArchiveFile(root, name string, data []byte) error {
+	p := filepath.Join(root, name)
+
+	if err := os.MkdirAll(filepath.Dir(p), iox.FullPermAccess); err != nil {
+		return fmt.Errorf("error while creating folder for %s: %w", name, err)
+	}
+
+	if err := os.WriteFile(p, data, iox.FullRWPermAccess); err != nil {
+		return fmt.Errorf("error while writing %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// readArchiveDir returns the content of the files in dir, keyed by name. A missing dir has no files.
+func readArchiveDir(dir string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return files, nil
+		}
+
+		return nil, fmt.Errorf("error while reading %s: %w", dir, err)
+	}
+
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error while reading %s: %w", e.Name(), err)
+		}
+
+		files[e.Name()] = data
+	}
+
+	return files, nil
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package plan_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/plan"
+	"github.com/sighupio/furyctl/internal/state/backend"
+)
+
+var (
+	errNoConfig   = fmt.Errorf("%w: no configuration", backend.ErrNotFound)
+	errUnreadable = errors.New("wrong encryption key")
+)
+
+type fakeStore struct {
+	config []byte
+	err    error
+}
+
+func (*fakeStore) StoreKFD() error {
+	return nil
+}
+
+func (*fakeStore) StoreConfig(_ map[string]any) error {
+	return nil
+}
+
+func (s *fakeStore) GetConfig() ([]byte, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if s.config == nil {
+		return nil, errNoConfig
+	}
+
+	return s.config, nil
+}
+
+func (s *fakeStore) GetRenderedConfig() ([]byte, error) {
+	return s.GetConfig()
+}
+
+func newFingerprint(t *testing.T, distroDir, spec string) plan.Fingerprint {
+	t.Helper()
+
+	fp, err := plan.NewFingerprint(map[string]any{"kind": "EKSCluster", "spec": spec}, distroDir, "v1.31.0")
+	require.NoError(t, err)
+
+	return fp
+}
+
+func TestNewFingerprint(t *testing.T) {
+	t.Parallel()
+
+	distroDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(distroDir, "kfd.yaml"), []byte("version: v1.31.0\n"), 0o600))
+
+	fp := newFingerprint(t, distroDir, "a")
+
+	assert.Equal(t, fp, newFingerprint(t, distroDir, "a"))
+	assert.NotEqual(t, fp.Config, newFingerprint(t, distroDir, "b").Config)
+
+	require.NoError(t, os.WriteFile(filepath.Join(distroDir, "kfd.yaml"), []byte("version: v1.31.1\n"), 0o600))
+
+	assert.NotEqual(t, fp.Distribution, newFingerprint(t, distroDir, "a").Distribution)
+}
+
+func TestReport_SaveAndOpen(t *testing.T) {
+	t.Parallel()
+
+	distroDir := t.TempDir()
+	workDir := t.TempDir()
+
+	tfPlanFile := filepath.Join(workDir, "infrastructure", "plan", "terraform.plan")
+	require.NoError(t, os.MkdirAll(filepath.Dir(tfPlanFile), 0o755))
+	require.NoError(t, os.WriteFile(tfPlanFile, []byte("binary plan"), 0o600))
+
+	store := &fakeStore{config: []byte("kind: EKSCluster\n")}
+
+	r := plan.New("test", "EKSCluster", "v1.31.0")
+	r.Upgrade = true
+	r.Fingerprint = newFingerprint(t, distroDir, "a")
+	require.NoError(t, r.RecordState(store))
+	require.NoError(t, r.AddTerraformPlan("infrastructure", []byte(tfPlanJSON), []byte(tfPlanOutput), tfPlanFile))
+
+	archive := filepath.Join(t.TempDir(), "plans", "plan.tgz")
+	require.NoError(t, r.Save(archive))
+
+	saved, err := plan.Open(archive)
+	require.NoError(t, err)
+
+	assert.Equal(t, r.Terraform, saved.Report.Terraform)
+	assert.Equal(t, r.Fingerprint, saved.Report.Fingerprint)
+
+	require.NoError(t, saved.Verify(newFingerprint(t, distroDir, "a"), "", true))
+	require.NoError(t, saved.VerifyState(store))
+
+	restored := filepath.Join(t.TempDir(), "plan", "terraform.plan")
+
+	output, ok, err := saved.RestoreTerraformPlan("infrastructure", restored)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, tfPlanOutput, string(output))
+
+	data, err := os.ReadFile(restored)
+	require.NoError(t, err)
+	assert.Equal(t, "binary plan", string(data))
+
+	_, _, err = saved.RestoreTerraformPlan("kubernetes", restored)
+	require.ErrorIs(t, err, plan.ErrPlanIncomplete)
+
+	err = saved.VerifyManifests("distribution", "kustomize", t.TempDir())
+	require.ErrorIs(t, err, plan.ErrPlanIncomplete)
+}
+
+func TestSaved_Verify(t *testing.T) {
+	t.Parallel()
+
+	distroDir := t.TempDir()
+	workDir := t.TempDir()
+
+	r := plan.New("test", "KFDDistribution", "v1.31.0")
+	r.Phase = "distribution"
+	r.Fingerprint = newFingerprint(t, distroDir, "a")
+	require.NoError(t, r.RecordState(&fakeStore{}))
+
+	archive := filepath.Join(workDir, "plan.tgz")
+	require.NoError(t, r.Save(archive))
+
+	saved, err := plan.Open(archive)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc    string
+		fp      plan.Fingerprint
+		phase   string
+		upgrade bool
+		wantErr error
+	}{
+		{
+			desc:  "same inputs",
+			fp:    newFingerprint(t, distroDir, "a"),
+			phase: "distribution",
+		},
+		{
+			desc:    "other phase",
+			fp:      newFingerprint(t, distroDir, "a"),
+			wantErr: plan.ErrPlanMismatch,
+		},
+		{
+			desc:    "upgrade",
+			fp:      newFingerprint(t, distroDir, "a"),
+			phase:   "distribution",
+			upgrade: true,
+			wantErr: plan.ErrPlanMismatch,
+		},
+		{
+			desc:    "configuration changed",
+			fp:      newFingerprint(t, distroDir, "b"),
+			phase:   "distribution",
+			wantErr: plan.ErrPlanDrift,
+		},
+		{
+			desc: "distribution version changed",
+			fp: plan.Fingerprint{
+				Config:              r.Fingerprint.Config,
+				DistributionVersion: "v1.31.1",
+				Distribution:        r.Fingerprint.Distribution,
+			},
+			phase:   "distribution",
+			wantErr: plan.ErrPlanDrift,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := saved.Verify(tc.fp, tc.phase, tc.upgrade)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+
+	require.NoError(t, saved.VerifyState(&fakeStore{}))
+	require.ErrorIs(t, saved.VerifyState(&fakeStore{config: []byte("kind: KFDDistribution\n")}), plan.ErrPlanDrift)
+
+	// A state that cannot be read is not an empty one.
+	require.ErrorIs(t, saved.VerifyState(&fakeStore{err: errUnreadable}), errUnreadable)
+	require.ErrorIs(t, r.RecordState(&fakeStore{err: errUnreadable}), errUnreadable)
+}
+
+func TestSaved_NilExecutesNothing(t *testing.T) {
+	t.Parallel()
+
+	var s *plan.Saved
+
+	require.NoError(t, s.VerifyState(&fakeStore{}))
+	require.NoError(t, s.VerifyManifests("distribution", "kustomize", t.TempDir()))
+
+	_, ok, err := s.RestoreTerraformPlan("infrastructure", filepath.Join(t.TempDir(), "terraform.plan"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
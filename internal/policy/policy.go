@@ -0,0 +1,410 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package policy checks furyctl.yaml and its changes against the rules of an organisation, on top of the
+// schema and the rules of the distribution. The rules are YAML files in a directory, their conditions are
+// CEL expressions on the configuration and on its changes.
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	r3diff "github.com/r3labs/diff/v3"
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/pkg/diffs"
+	rules "github.com/sighupio/furyctl/pkg/rulesextractor"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+var (
+	ErrPolicyViolation = errors.New("the configuration violates the policies")
+	ErrInvalidRule     = errors.New("invalid policy rule")
+
+	errNotBool = errors.New("the expression must return a bool")
+
+	//nolint:gochecknoglobals // compiled once.
+	numbersToWildcardRegex = regexp.MustCompile(`\.\d+\b`)
+)
+
+// File is a file of policy rules.
+type File struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Rule is a policy rule. The configuration violates it when its Config expression is false, a change
+// violates it when its Change expression is false for the change.
+type Rule struct {
+	Name     string                   `yaml:"name"`
+	Message  string                   `yaml:"message"`
+	Severity flags.ValidationSeverity `yaml:"severity"`
+	// Kinds limits the rule to some kinds, all the kinds when empty.
+	Kinds []string `yaml:"kinds,omitempty"`
+	// Path is the path of the configuration that the rule checks, in the format of the rules of the
+	// distribution (eg: .spec.kubernetes.masters.nodes.*.name). The violations of Config are reported at
+	// it, and Change only checks its changes. The whole configuration when empty.
+	Path string `yaml:"path,omitempty"`
+	// Config is a CEL expression on the configuration, the config variable.
+	Config string `yaml:"config,omitempty"`
+	// Change is a CEL expression on each change of the configuration, the change variable with its type
+	// (create, update or delete), path, from and to values, and on the configuration, the config variable.
+	Change string `yaml:"change,omitempty"`
+
+	configProgram cel.Program
+	changeProgram cel.Program
+}
+
+// Violation is a violation of a rule.
+type Violation struct {
+	Rule     string                   `json:"rule"`
+	Severity flags.ValidationSeverity `json:"severity"`
+	Message  string                   `json:"message"`
+	// Path is the JSON pointer of the value that violates the rule.
+	Path string `json:"path"`
+}
+
+func (v Violation) Error() string {
+	path := v.Path
+	if path == "" {
+		path = "/"
+	}
+
+	return fmt.Sprintf("%s policy %s: %s: %s", v.Severity, v.Rule, path, v.Message)
+}
+
+// Load reads the rules of the YAML files in dir, in the order of their names. An empty dir has no rules.
+func Load(dir string) ([]Rule, error) {
+	if dir == "" {
+		return []Rule{}, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading policies directory: %w", err)
+	}
+
+	configEnv, changeEnv, err := newEnvs()
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := []Rule{}
+
+	for _, e := range entries {
+		fileExt := filepath.Ext(e.Name())
+		if e.IsDir() || (fileExt != ".yaml" && fileExt != ".yml") {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+
+		f, err := yamlx.FromFileV3[File](path)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading policies file: %w", err)
+		}
+
+		for i := range f.Rules {
+			if err := f.Rules[i].compile(configEnv, changeEnv, fmt.Sprintf("%s/rules/%d", path, i)); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+
+		loaded = append(loaded, f.Rules...)
+	}
+
+	return loaded, nil
+}
+
+// newEnvs returns the CEL environments of the Config expressions, with the config variable, and of the
+// Change expressions, with the change variable too. Both have the extensions on lists, sets and strings.
+func newEnvs() (*cel.Env, *cel.Env, error) {
+	configEnv, err := cel.NewEnv(
+		cel.Variable("config", cel.MapType(cel.StringType, cel.DynType)),
+		ext.Lists(),
+		ext.Sets(),
+		ext.Strings(),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while creating CEL environment: %w", err)
+	}
+
+	changeEnv, err := configEnv.Extend(cel.Variable("change", cel.MapType(cel.StringType, cel.DynType)))
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while creating CEL environment: %w", err)
+	}
+
+	return configEnv, changeEnv, nil
+}
+
+func (r *Rule) compile(configEnv, changeEnv *cel.Env, id string) error {
+	if r.Name == "" {
+		return fmt.Errorf("%w: rule %s has no name", ErrInvalidRule, id)
+	}
+
+	switch r.Severity {
+	case "":
+		r.Severity = flags.ValidationSeverityFatal
+
+	case flags.ValidationSeverityFatal, flags.ValidationSeverityWarning:
+
+	default:
+		return fmt.Errorf("%w: %s: severity must be %s or %s", ErrInvalidRule, r.Name,
+			flags.ValidationSeverityFatal, flags.ValidationSeverityWarning)
+	}
+
+	if r.Config == "" && r.Change == "" {
+		return fmt.Errorf("%w: %s: it needs a config or a change expression", ErrInvalidRule, r.Name)
+	}
+
+	var err error
+
+	if r.configProgram, err = compileExpression(configEnv, r.Config); err != nil {
+		return fmt.Errorf("%w: %s: config: %w", ErrInvalidRule, r.Name, err)
+	}
+
+	if r.changeProgram, err = compileExpression(changeEnv, r.Change); err != nil {
+		return fmt.Errorf("%w: %s: change: %w", ErrInvalidRule, r.Name, err)
+	}
+
+	return nil
+}
+
+func compileExpression(env *cel.Env, expr string) (cel.Program, error) {
+	if expr == "" {
+		return nil, nil //nolint:nilnil // no condition.
+	}
+
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, fmt.Errorf("error while compiling expression: %w", iss.Err())
+	}
+
+	// The fields of the configuration are dynamic, their comparisons are bool.
+	if out := ast.OutputType(); !out.IsExactType(cel.BoolType) && !out.IsExactType(cel.DynType) {
+		return nil, fmt.Errorf("%w, got %s", errNotBool, out)
+	}
+
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating program: %w", err)
+	}
+
+	return prg, nil
+}
+
+// eval tells if the program is true with the variables. A program that fails, eg: because it reads a key
+// that the configuration does not have without has(), is not true.
+func eval(prg cel.Program, vars map[string]any) (bool, error) {
+	out, _, err := prg.Eval(vars)
+	if err != nil {
+		return false, fmt.Errorf("error while evaluating expression: %w", err)
+	}
+
+	b, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("%w, got %v", errNotBool, out.Value())
+	}
+
+	return b, nil
+}
+
+func (r Rule) appliesTo(kind string) bool {
+	return len(r.Kinds) == 0 || slices.Contains(r.Kinds, kind)
+}
+
+// CheckConfig returns the violations of the configuration of a kind.
+func CheckConfig(rls []Rule, kind string, conf map[string]any) []Violation {
+	violations := []Violation{}
+	doc := normalize(conf)
+
+	for _, r := range rls {
+		if r.configProgram == nil || !r.appliesTo(kind) {
+			continue
+		}
+
+		ok, err := eval(r.configProgram, map[string]any{"config": doc})
+		if ok {
+			continue
+		}
+
+		detail := fmt.Sprintf("%q is false", strings.Join(strings.Fields(r.Config), " "))
+		if err != nil {
+			detail = err.Error()
+		}
+
+		violations = append(violations, Violation{
+			Rule:     r.Name,
+			Severity: r.Severity,
+			Message:  joinMessage(r.Message, detail),
+			Path:     pathToPointer(r.Path),
+		})
+	}
+
+	return violations
+}
+
+// CheckChanges returns the violations of the changes of the configuration of a kind, conf is the new
+// configuration.
+func CheckChanges(rls []Rule, kind string, conf map[string]any, changelog r3diff.Changelog) []Violation {
+	violations := []Violation{}
+	doc := normalize(conf)
+	leaves := diffs.ExpandMapChanges(changelog)
+
+	for _, r := range rls {
+		if r.changeProgram == nil || !r.appliesTo(kind) {
+			continue
+		}
+
+		for _, change := range leaves {
+			changePath := "." + strings.Join(change.Path, ".")
+
+			if r.Path != "" && !rules.MatchesPattern(numbersToWildcardRegex.ReplaceAllString(changePath, ".*"), r.Path) {
+				continue
+			}
+
+			ok, err := eval(r.changeProgram, map[string]any{
+				"config": doc,
+				"change": map[string]any{
+					"type": change.Type,
+					"path": changePath,
+					"from": normalize(change.From),
+					"to":   normalize(change.To),
+				},
+			})
+			if ok {
+				continue
+			}
+
+			detail := fmt.Sprintf("%s %s from %v to %v", change.Type, changePath, change.From, change.To)
+			if err != nil {
+				detail += ": " + err.Error()
+			}
+
+			violations = append(violations, Violation{
+				Rule:     r.Name,
+				Severity: r.Severity,
+				Message:  joinMessage(r.Message, detail),
+				Path:     "/" + strings.Join(change.Path, "/"),
+			})
+		}
+	}
+
+	return violations
+}
+
+// pathToPointer returns the JSON pointer of a path in the format of the rules of the distribution, up to
+// its first wildcard.
+func pathToPointer(path string) string {
+	pointer := ""
+
+	for _, key := range strings.Split(strings.TrimPrefix(path, "."), ".") {
+		if key == "" || strings.Contains(key, "*") {
+			break
+		}
+
+		pointer += "/" + url.PathEscape(key)
+	}
+
+	return pointer
+}
+
+// Enforce logs the violations with the warning severity and returns an error with the fatal ones.
+func Enforce(violations []Violation) error {
+	fatal := []error{}
+
+	for _, v := range violations {
+		if v.Severity == flags.ValidationSeverityWarning {
+			logrus.Warn(v.Error())
+
+			continue
+		}
+
+		fatal = append(fatal, v)
+	}
+
+	if len(fatal) > 0 {
+		return fmt.Errorf("%w: %w", ErrPolicyViolation, errors.Join(fatal...))
+	}
+
+	return nil
+}
+
+// EnforceConfig checks the configuration of a kind against the rules, if any.
+func EnforceConfig(rls []Rule, kind string, conf map[string]any) error {
+	if len(rls) == 0 {
+		return nil
+	}
+
+	logrus.Info("Checking the configuration against the policies...")
+
+	return Enforce(CheckConfig(rls, kind, conf))
+}
+
+// EnforceChanges checks the changes of the configuration of a kind against the rules, if any.
+func EnforceChanges(rls []Rule, kind string, conf map[string]any, changelog r3diff.Changelog) error {
+	if len(rls) == 0 || len(changelog) == 0 {
+		return nil
+	}
+
+	logrus.Info("Checking the changes of the configuration against the policies...")
+
+	return Enforce(CheckChanges(rls, kind, conf, changelog))
+}
+
+func joinMessage(message, detail string) string {
+	if message == "" {
+		return detail
+	}
+
+	return message + ": " + detail
+}
+
+// normalize turns the maps of YAML v2 (map[any]any) into the maps of JSON, that CEL reads.
+func normalize(value any) any {
+	switch v := value.(type) {
+	case map[any]any:
+		out := make(map[string]any, len(v))
+
+		for k, item := range v {
+			out[fmt.Sprintf("%v", k)] = normalize(item)
+		}
+
+		return out
+
+	case map[string]any:
+		out := make(map[string]any, len(v))
+
+		for k, item := range v {
+			out[k] = normalize(item)
+		}
+
+		return out
+
+	case []any:
+		out := make([]any, len(v))
+
+		for i, item := range v {
+			out[i] = normalize(item)
+		}
+
+		return out
+
+	case nil, bool, string, int, int64, uint64, float64:
+		return v
+
+	default:
+		// Any other scalar, eg: a timestamp, is read as its string.
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package policy_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	r3diff "github.com/r3labs/diff/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/flags"
+	"github.com/sighupio/furyctl/internal/policy"
+)
+
+const testRules = `rules:
+  - name: mandatory-oidc
+    message: OIDC authentication is mandatory
+    path: .spec.distribution.modules.auth
+    config: has(config.spec.distribution.modules.auth.oidcKubernetesAuth)
+  - name: network-policies
+    severity: warning
+    kinds: [OnPremises]
+    path: .spec.distribution.modules.policy.type
+    config: config.spec.distribution.modules.policy.type != 'none'
+  - name: etcd-members
+    path: .spec.kubernetes.nodePools
+    config: >-
+      config.spec.kubernetes.nodePools
+        .filter(p, 'etcd' in p.roles)
+        .map(p, p.hosts)
+        .flatten()
+        .size() >= 3
+  - name: no-master-removal
+    message: the masters cannot be removed
+    path: .spec.kubernetes.masters.nodes.*.name
+    change: change.type != 'delete'
+  - name: approved-registry
+    path: .spec.distribution.common.registry
+    change: change.to.startsWith(config.metadata.registryPrefix)
+`
+
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(content), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a rule"), 0o644))
+
+	return dir
+}
+
+func TestCheckConfig(t *testing.T) {
+	t.Parallel()
+
+	rls, err := policy.Load(writeRules(t, testRules))
+	require.NoError(t, err)
+	require.Len(t, rls, 5)
+
+	// The maps of YAML v2, as in the rendered configuration of the preflight.
+	conf := map[string]any{
+		"spec": map[any]any{
+			"kubernetes": map[any]any{
+				"nodePools": []any{
+					map[any]any{"name": "control-plane", "roles": []any{"master", "etcd"}, "hosts": []any{"cp1"}},
+					map[any]any{"name": "etcd", "roles": []any{"etcd"}, "hosts": []any{"etcd1"}},
+					map[any]any{"name": "workers", "roles": []any{"worker"}, "hosts": []any{"w1", "w2"}},
+				},
+			},
+			"distribution": map[any]any{
+				"modules": map[any]any{
+					"auth":   map[any]any{"provider": map[any]any{"type": "none"}},
+					"policy": map[any]any{"type": "none"},
+				},
+			},
+		},
+	}
+
+	violations := policy.CheckConfig(rls, "OnPremises", conf)
+	require.Len(t, violations, 3)
+
+	assert.Equal(t, "mandatory-oidc", violations[0].Rule)
+	assert.Equal(t, flags.ValidationSeverityFatal, violations[0].Severity)
+	assert.Equal(t, "/spec/distribution/modules/auth", violations[0].Path)
+	assert.Equal(t,
+		`OIDC authentication is mandatory: "has(config.spec.distribution.modules.auth.oidcKubernetesAuth)" is false`,
+		violations[0].Message,
+	)
+
+	assert.Equal(t, "network-policies", violations[1].Rule)
+	assert.Equal(t, flags.ValidationSeverityWarning, violations[1].Severity)
+	assert.Equal(t, "/spec/distribution/modules/policy/type", violations[1].Path)
+
+	// The etcd members of all the pools are 2.
+	assert.Equal(t, "etcd-members", violations[2].Rule)
+	assert.Equal(t, "/spec/kubernetes/nodePools", violations[2].Path)
+
+	// The rules limited to other kinds do not apply.
+	assert.Len(t, policy.CheckConfig(rls, "EKSCluster", conf), 2)
+
+	// Only the fatal violations stop.
+	err = policy.Enforce(violations[1:2])
+	require.NoError(t, err)
+
+	err = policy.Enforce(violations)
+	require.ErrorIs(t, err, policy.ErrPolicyViolation)
+	assert.Contains(t, err.Error(), "fatal policy etcd-members: /spec/kubernetes/nodePools: ")
+	assert.NotContains(t, err.Error(), "network-policies")
+}
+
+func TestCheckConfig_EvaluationError(t *testing.T) {
+	t.Parallel()
+
+	rls, err := policy.Load(writeRules(t, "rules:\n  - name: a\n    config: config.spec.missing == 'x'\n"))
+	require.NoError(t, err)
+
+	// A key that the configuration does not have, read without has(), violates the rule.
+	violations := policy.CheckConfig(rls, "OnPremises", map[string]any{"spec": map[string]any{}})
+	require.Len(t, violations, 1)
+	assert.Contains(t, violations[0].Message, "error while evaluating expression: no such key: missing")
+	assert.Empty(t, violations[0].Path)
+}
+
+func TestCheckChanges(t *testing.T) {
+	t.Parallel()
+
+	rls, err := policy.Load(writeRules(t, testRules))
+	require.NoError(t, err)
+
+	conf := map[string]any{"metadata": map[string]any{"registryPrefix": "registry.example.com/"}}
+
+	changelog := r3diff.Changelog{
+		{Type: r3diff.UPDATE, Path: []string{"spec", "kubernetes", "masters", "nodes", "1", "name"}, From: "master2", To: "master4"},
+		{
+			Type: r3diff.DELETE,
+			Path: []string{"spec", "kubernetes", "masters", "nodes", "2"},
+			From: map[string]any{"name": "master3", "ip": "10.0.0.3"},
+		},
+		{
+			Type: r3diff.UPDATE,
+			Path: []string{"spec", "distribution", "common", "registry"},
+			From: "registry.example.com/fury",
+			To:   "docker.io/fury",
+		},
+	}
+
+	violations := policy.CheckChanges(rls, "OnPremises", conf, changelog)
+	require.Len(t, violations, 2)
+
+	assert.Equal(t, "no-master-removal", violations[0].Rule)
+	assert.Equal(t, "/spec/kubernetes/masters/nodes/2/name", violations[0].Path)
+	assert.Equal(t, "the masters cannot be removed: delete .spec.kubernetes.masters.nodes.2.name from master3 to <nil>",
+		violations[0].Message)
+
+	// The new value of the change is checked against the configuration.
+	assert.Equal(t, "approved-registry", violations[1].Rule)
+	assert.Equal(t, "/spec/distribution/common/registry", violations[1].Path)
+
+	conf["metadata"] = map[string]any{"registryPrefix": "docker.io/"}
+
+	assert.Len(t, policy.CheckChanges(rls, "OnPremises", conf, changelog), 1)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		desc  string
+		rules string
+	}{
+		{
+			desc:  "no name",
+			rules: "rules:\n  - config: has(config.spec)\n",
+		},
+		{
+			desc:  "wrong severity",
+			rules: "rules:\n  - name: a\n    severity: error\n    config: has(config.spec)\n",
+		},
+		{
+			desc:  "no conditions",
+			rules: "rules:\n  - name: a\n",
+		},
+		{
+			desc:  "invalid expression",
+			rules: "rules:\n  - name: a\n    config: config.spec ==\n",
+		},
+		{
+			desc:  "not a bool",
+			rules: "rules:\n  - name: a\n    config: size(config.spec)\n",
+		},
+		{
+			desc:  "change in a config expression",
+			rules: "rules:\n  - name: a\n    config: change.type != 'delete'\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := policy.Load(writeRules(t, tc.rules))
+			require.ErrorIs(t, err, policy.ErrInvalidRule)
+		})
+	}
+
+	rls, err := policy.Load("")
+	require.NoError(t, err)
+	assert.Empty(t, rls)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package redact masks the sensitive values, for example the secrets that the dynamic values resolve,
+// in what furyctl prints and logs.
+package redact
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	// Mask replaces the sensitive values.
+	Mask = "<redacted>"
+
+	// minLength is the length below which a value is not masked: masking a short value would hide
+	// most of the output for no gain.
+	minLength = 4
+)
+
+var (
+	//nolint:gochecknoglobals // The values are shared by the logs, the commands and the outputs of the run.
+	values []string
+	//nolint:gochecknoglobals // The lock guards the values.
+	valuesMu sync.RWMutex
+)
+
+// Register marks the values as sensitive. The lines of the values with more than one line are
+// registered one by one as well. The values shorter than 4 characters are not registered, so they are
+// never masked.
+func Register(vals ...string) {
+	valuesMu.Lock()
+	defer valuesMu.Unlock()
+
+	for _, v := range vals {
+		candidates := append([]string{v}, strings.Split(v, "\n")...)
+
+		for _, c := range candidates {
+			c = strings.TrimSpace(c)
+
+			if len(c) < minLength || slices.Contains(values, c) {
+				continue
+			}
+
+			values = append(values, c)
+		}
+	}
+
+	// The longest values first, so that a value that contains another one is masked as a whole.
+	slices.SortFunc(values, func(a, b string) int {
+		return len(b) - len(a)
+	})
+}
+
+// Reset forgets the sensitive values.
+func Reset() {
+	valuesMu.Lock()
+	defer valuesMu.Unlock()
+
+	values = nil
+}
+
+// String returns the string with the sensitive values masked.
+func String(s string) string {
+	valuesMu.RLock()
+	defer valuesMu.RUnlock()
+
+	for _, v := range values {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+
+	return s
+}
+
+// Bytes is String for a byte slice, with the signature of the transforms of the log writers.
+func Bytes(p []byte) ([]byte, error) {
+	return []byte(String(string(p))), nil
+}
+
+// Value returns the value with the sensitive values masked in its strings, also in the ones of its maps
+// and lists, for example the old and new values of a configuration change. The value is not modified.
+func Value(v any) any {
+	switch val := v.(type) {
+	case string:
+		return String(val)
+
+	case map[string]any:
+		masked := make(map[string]any, len(val))
+
+		for k, item := range val {
+			masked[k] = Value(item)
+		}
+
+		return masked
+
+	case []any:
+		masked := make([]any, len(val))
+
+		for i, item := range val {
+			masked[i] = Value(item)
+		}
+
+		return masked
+
+	default:
+		return v
+	}
+}
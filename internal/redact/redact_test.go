@@ -0,0 +1,81 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package redact_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/redact"
+)
+
+func TestString(t *testing.T) {
+	redact.Reset()
+	t.Cleanup(redact.Reset)
+
+	redact.Register("abc", "s3cr3t", "s3cr3t-longer", "-----BEGIN KEY-----\nline-one\nline-two\n-----END KEY-----")
+
+	tcs := []struct {
+		desc string
+		in   string
+		want string
+	}{
+		{
+			desc: "short values are not masked",
+			in:   "abc",
+			want: "abc",
+		},
+		{
+			desc: "every occurrence",
+			in:   "password=s3cr3t token=s3cr3t",
+			want: "password=<redacted> token=<redacted>",
+		},
+		{
+			desc: "longest value first",
+			in:   "s3cr3t-longer",
+			want: "<redacted>",
+		},
+		{
+			desc: "lines of a multiline value",
+			in:   `{"msg":"line-two"}`,
+			want: `{"msg":"<redacted>"}`,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.desc, func(t *testing.T) {
+			assert.Equal(t, tc.want, redact.String(tc.in))
+		})
+	}
+
+	out, err := redact.Bytes([]byte("s3cr3t"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte(redact.Mask), out)
+}
+
+func TestValue(t *testing.T) {
+	redact.Reset()
+	t.Cleanup(redact.Reset)
+
+	redact.Register("s3cr3t")
+
+	in := map[string]any{
+		"password": "s3cr3t",
+		"replicas": 3,
+		"users":    []any{"alice", "token=s3cr3t"},
+	}
+
+	assert.Equal(t, map[string]any{
+		"password": redact.Mask,
+		"replicas": 3,
+		"users":    []any{"alice", "token=" + redact.Mask},
+	}, redact.Value(in))
+
+	assert.Equal(t, "s3cr3t", in["password"], "the value is not modified")
+}
@@ -0,0 +1,376 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package runreport records the timeline of a furyctl run: when each phase and sub-phase started and
+// ended, how it ended, the tool commands it spawned and the reducers it applied.
+package runreport
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/redact"
+	"github.com/sighupio/furyctl/internal/tracing"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+	"github.com/sighupio/furyctl/pkg/reducers"
+)
+
+const (
+	StatusRunning = "running"
+	StatusSuccess = "success"
+	StatusFailed  = "failed"
+
+	// DirName is the folder of the workdir that holds the run reports.
+	DirName = "run-reports"
+)
+
+var (
+	//nolint:gochecknoglobals // The phases and the commands record into the report of the running command.
+	active *Report
+	//nolint:gochecknoglobals // The lock guards the active report.
+	activeMu sync.Mutex
+)
+
+// Report is the timeline of a furyctl run. A nil *Report records nothing. The errors of the run can hold
+// the output of the commands, so they are recorded with the sensitive values masked.
+type Report struct {
+	Command             string    `json:"command"`
+	Cluster             string    `json:"cluster"`
+	Kind                string    `json:"kind"`
+	DistributionVersion string    `json:"distributionVersion"`
+	StartedAt           time.Time `json:"startedAt"`
+	EndedAt             time.Time `json:"endedAt"`
+	Duration            string    `json:"duration"`
+	Status              string    `json:"status"`
+	Error               string    `json:"error,omitempty"`
+	Phases              []*Phase  `json:"phases"`
+	Commands            []Command `json:"commands"`
+
+	// The workdir of the cluster, where the report is written.
+	workDir string
+
+	// The phases that are running, the innermost last.
+	running []*Phase
+
+	// The EKS phases run in their own goroutine.
+	mu sync.Mutex
+}
+
+// Phase is a phase or a sub-phase of the run. The sub-phases are nested into the phase that runs them.
+type Phase struct {
+	Name      string    `json:"name"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+	Duration  string    `json:"duration"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Commands  []Command `json:"commands"`
+	Reducers  []Reducer `json:"reducers"`
+	Phases    []*Phase  `json:"phases"`
+}
+
+// Command is a tool command spawned by the run.
+type Command = execx.CmdRecord
+
+// Reducer is a reducer that a phase applied.
+type Reducer struct {
+	Key       string `json:"key"`
+	Path      string `json:"path"`
+	Lifecycle string `json:"lifecycle"`
+	From      any    `json:"from"`
+	To        any    `json:"to"`
+}
+
+func New(command, workDir, clusterName, kind, distributionVersion string) *Report {
+	return &Report{
+		workDir:             workDir,
+		Command:             command,
+		Cluster:             clusterName,
+		Kind:                kind,
+		DistributionVersion: distributionVersion,
+		StartedAt:           time.Now().UTC(),
+		Status:              StatusRunning,
+		Phases:              []*Phase{},
+		Commands:            []Command{},
+	}
+}
+
+// Activate makes the report the one that Track, AddReducers and the commands record into.
+func (r *Report) Activate() {
+	if r == nil {
+		return
+	}
+
+	activeMu.Lock()
+	defer activeMu.Unlock()
+
+	active = r
+
+	execx.AddRecorder(r)
+}
+
+// Deactivate stops recording into the report.
+func (r *Report) Deactivate() {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+
+	if active != r {
+		return
+	}
+
+	active = nil
+
+	execx.RemoveRecorder(r)
+}
+
+// Active returns the report that the run records into, if any.
+func Active() *Report {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+
+	return active
+}
+
+// Track runs fn as the named phase of the active report and of the trace of the command. It runs fn as
+// is when there is no active report and no trace.
+func Track(name string, fn func() error) error {
+	r := Active()
+
+	p := r.start(name)
+	endSpan := tracing.StartPhase(name)
+
+	err := fn()
+
+	endSpan(err)
+	r.end(p, err)
+
+	return err
+}
+
+// TrackValue is Track for the phases that return a value.
+func TrackValue[T any](name string, fn func() (T, error)) (T, error) {
+	var v T
+
+	err := Track(name, func() error {
+		var fnErr error
+
+		v, fnErr = fn()
+
+		return fnErr
+	})
+
+	return v, err
+}
+
+// AddReducers records the reducers applied by the running phase of the active report.
+func AddReducers(rdcs reducers.Reducers) {
+	Active().addReducers(rdcs)
+}
+
+// RecordCmd records a command into the running phase, or into the report when no phase is running.
+func (r *Report) RecordCmd(rec execx.CmdRecord) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p := r.current(); p != nil {
+		p.Commands = append(p.Commands, rec)
+
+		return
+	}
+
+	r.Commands = append(r.Commands, rec)
+}
+
+// Finish ends the run, marking it as failed if err is not nil. The phases still running end with it.
+func (r *Report) Finish(err error) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.running) > 0 {
+		r.endLocked(r.running[0], err)
+	}
+
+	r.EndedAt = time.Now().UTC()
+	r.Duration = r.EndedAt.Sub(r.StartedAt).Round(time.Millisecond).String()
+	r.Status = status(err)
+
+	if err != nil {
+		r.Error = redact.String(err.Error())
+	}
+}
+
+// JSON returns the indented JSON representation of the report.
+func (r *Report) JSON() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error while marshalling run report: %w", err)
+	}
+
+	return append(out, '\n'), nil
+}
+
+// Close finishes the report with the outcome of the run, stops recording into it and writes it into the
+// workdir. A report that cannot be written does not fail the run.
+func (r *Report) Close(err error) {
+	if r == nil {
+		return
+	}
+
+	r.Finish(err)
+	r.Deactivate()
+
+	reportPath, wErr := r.Write()
+	if wErr != nil {
+		logrus.Warnf("error while writing the run report: %v", wErr)
+
+		return
+	}
+
+	logrus.Infof("Run report written to %s", reportPath)
+}
+
+// Write writes the report into the run reports folder of the workdir and returns its path.
+func (r *Report) Write() (string, error) {
+	out, err := r.JSON()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(r.workDir, DirName)
+
+	if err := os.MkdirAll(dir, iox.FullPermAccess); err != nil {
+		return "", fmt.Errorf("error while creating run reports folder: %w", err)
+	}
+
+	reportPath := filepath.Join(dir, r.FileName())
+
+	if err := os.WriteFile(reportPath, out, iox.RWPermAccess); err != nil {
+		return "", fmt.Errorf("error while writing run report: %w", err)
+	}
+
+	return reportPath, nil
+}
+
+// FileName is the name of the report file, unique for each run of a command.
+func (r *Report) FileName() string {
+	return fmt.Sprintf("%s-%s.json", r.StartedAt.Format("20060102T150405Z"), r.Command)
+}
+
+func (r *Report) start(name string) *Phase {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := &Phase{
+		Name:      name,
+		StartedAt: time.Now().UTC(),
+		Status:    StatusRunning,
+		Commands:  []Command{},
+		Reducers:  []Reducer{},
+		Phases:    []*Phase{},
+	}
+
+	if parent := r.current(); parent != nil {
+		parent.Phases = append(parent.Phases, p)
+	} else {
+		r.Phases = append(r.Phases, p)
+	}
+
+	r.running = append(r.running, p)
+
+	return p
+}
+
+func (r *Report) end(p *Phase, err error) {
+	if r == nil || p == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.endLocked(p, err)
+}
+
+// endLocked ends the phase and the sub-phases still running inside it.
+func (r *Report) endLocked(p *Phase, err error) {
+	idx := lo.IndexOf(r.running, p)
+	if idx < 0 {
+		return
+	}
+
+	for _, rp := range r.running[idx:] {
+		rp.EndedAt = time.Now().UTC()
+		rp.Duration = rp.EndedAt.Sub(rp.StartedAt).Round(time.Millisecond).String()
+		rp.Status = status(err)
+
+		if err != nil {
+			rp.Error = redact.String(err.Error())
+		}
+	}
+
+	r.running = r.running[:idx]
+}
+
+func (r *Report) addReducers(rdcs reducers.Reducers) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p := r.current()
+	if p == nil {
+		return
+	}
+
+	for _, rdc := range lo.Compact(rdcs) {
+		p.Reducers = append(p.Reducers, Reducer{
+			Key:       rdc.GetKey(),
+			Path:      rdc.GetPath(),
+			Lifecycle: rdc.GetLifecycle(),
+			From:      redact.Value(rdc.GetFrom()),
+			To:        redact.Value(rdc.GetTo()),
+		})
+	}
+}
+
+func (r *Report) current() *Phase {
+	if len(r.running) == 0 {
+		return nil
+	}
+
+	return r.running[len(r.running)-1]
+}
+
+func status(err error) string {
+	if err != nil {
+		return StatusFailed
+	}
+
+	return StatusSuccess
+}
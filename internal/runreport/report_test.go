@@ -0,0 +1,155 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package runreport_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/redact"
+	"github.com/sighupio/furyctl/internal/runreport"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+	"github.com/sighupio/furyctl/pkg/reducers"
+)
+
+var errTest = errors.New("test error")
+
+func TestTrack_NoActiveReport(t *testing.T) {
+	t.Parallel()
+
+	called := false
+
+	err := runreport.Track("distribution", func() error {
+		called = true
+
+		runreport.AddReducers(reducers.Reducers{reducers.NewBaseReducer("k", 1, 2, "pre-apply", ".spec")})
+
+		return errTest
+	})
+
+	assert.True(t, called)
+	require.ErrorIs(t, err, errTest)
+}
+
+//nolint:paralleltest // The active report is global.
+func TestReport_Timeline(t *testing.T) {
+	workDir := t.TempDir()
+
+	r := runreport.New("apply", workDir, "test", "KFDDistribution", "v1.31.0")
+	r.Activate()
+
+	require.NoError(t, execx.NewCmd("true", execx.CmdOptions{Args: []string{"before"}}).Run())
+
+	err := runreport.Track("distribution", func() error {
+		require.NoError(t, execx.NewCmd("true", execx.CmdOptions{Args: []string{"apply"}}).Run())
+		require.NoError(t, execx.NewCmd("true", execx.CmdOptions{Args: []string{"token"}, Sensitive: true}).Run())
+
+		runreport.AddReducers(reducers.Reducers{reducers.NewBaseReducer("k", 1, 2, "pre-apply", ".spec.k")})
+
+		return runreport.Track("post-distribution", func() error {
+			return execx.NewCmd("false", execx.CmdOptions{}).Run()
+		})
+	})
+	require.ErrorIs(t, err, execx.ErrCmdFailed)
+
+	r.Close(err)
+
+	assert.Nil(t, runreport.Active())
+
+	require.NoError(t, execx.NewCmd("true", execx.CmdOptions{Args: []string{"after"}}).Run())
+
+	out, err := os.ReadFile(filepath.Join(workDir, runreport.DirName, r.FileName()))
+	require.NoError(t, err)
+
+	var got runreport.Report
+	require.NoError(t, json.Unmarshal(out, &got))
+
+	assert.Equal(t, runreport.StatusFailed, got.Status)
+	assert.NotEmpty(t, got.Error)
+
+	require.Len(t, got.Commands, 1)
+	assert.Equal(t, []string{"before"}, got.Commands[0].Args)
+
+	require.Len(t, got.Phases, 1)
+
+	distro := got.Phases[0]
+	assert.Equal(t, "distribution", distro.Name)
+	assert.Equal(t, runreport.StatusFailed, distro.Status)
+	assert.False(t, distro.EndedAt.Before(distro.StartedAt))
+
+	require.Len(t, distro.Commands, 2)
+	assert.Equal(t, []string{"apply"}, distro.Commands[0].Args)
+	assert.Equal(t, 0, distro.Commands[0].ExitCode)
+	assert.Equal(t, []string{"<redacted>"}, distro.Commands[1].Args)
+
+	require.Len(t, distro.Reducers, 1)
+	assert.Equal(t, ".spec.k", distro.Reducers[0].Path)
+
+	require.Len(t, distro.Phases, 1)
+
+	post := distro.Phases[0]
+	assert.Equal(t, "post-distribution", post.Name)
+	assert.Equal(t, runreport.StatusFailed, post.Status)
+	require.Len(t, post.Commands, 1)
+	assert.Equal(t, 1, post.Commands[0].ExitCode)
+}
+
+//nolint:paralleltest // The active report is global.
+func TestReport_FinishEndsRunningPhases(t *testing.T) {
+	r := runreport.New("delete-cluster", t.TempDir(), "test", "OnPremises", "v1.31.0")
+	r.Activate()
+
+	defer r.Deactivate()
+
+	_ = runreport.Track("kubernetes", func() error {
+		r.Finish(errTest)
+
+		return nil
+	})
+
+	out, err := r.JSON()
+	require.NoError(t, err)
+
+	var got runreport.Report
+	require.NoError(t, json.Unmarshal(out, &got))
+
+	assert.Equal(t, runreport.StatusFailed, got.Status)
+	require.Len(t, got.Phases, 1)
+	assert.Equal(t, runreport.StatusFailed, got.Phases[0].Status)
+	assert.Equal(t, errTest.Error(), got.Phases[0].Error)
+}
+
+//nolint:paralleltest // The active report and the sensitive values are global.
+func TestReport_RedactsTheErrors(t *testing.T) {
+	t.Cleanup(redact.Reset)
+
+	redact.Register("s3cr3t-value")
+
+	r := runreport.New("apply", t.TempDir(), "test", "KFDDistribution", "v1.31.0")
+	r.Activate()
+
+	err := runreport.Track("distribution", func() error {
+		return fmt.Errorf("error applying manifests: token s3cr3t-value is invalid: %w", errTest)
+	})
+
+	r.Finish(err)
+	r.Deactivate()
+
+	out, err := r.JSON()
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "s3cr3t-value")
+
+	require.Len(t, r.Phases, 1)
+	assert.Equal(t, "error applying manifests: token <redacted> is invalid: test error", r.Phases[0].Error)
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package signature verifies the detached signatures that the distributions publish for their checksums
+// files: minisign signatures, and cosign signatures made with a key pair (cosign sign-blob --key). It also
+// signs the manifests of the air-gapped bundles in the cosign format.
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+const (
+	TypeMinisign = "minisign"
+	TypeCosign   = "cosign"
+
+	minisignAlgLegacy    = "Ed"
+	minisignAlgPrehashed = "ED"
+	minisignKeyIDSize    = 8
+	minisignKeySize      = 2 + minisignKeyIDSize + ed25519.PublicKeySize
+	minisignSigSize      = 2 + minisignKeyIDSize + ed25519.SignatureSize
+
+	untrustedCommentPrefix = "untrusted comment:"
+	trustedCommentPrefix   = "trusted comment: "
+)
+
+var (
+	ErrUnknownType      = errors.New("unknown signature type, it must be minisign or cosign")
+	ErrInvalidPublicKey = errors.New("invalid public key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrKeyMismatch      = errors.New("the signature was made with another key")
+	ErrVerification     = errors.New("signature verification failed")
+	ErrInvalidKey       = errors.New("invalid private key")
+)
+
+// Verify checks that sig is a signature of the type over msg made with the private key of publicKey.
+func Verify(sigType, publicKey string, msg, sig []byte) error {
+	switch sigType {
+	case TypeMinisign:
+		return VerifyMinisign(publicKey, msg, sig)
+
+	case TypeCosign:
+		return VerifyCosign(publicKey, msg, sig)
+
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownType, sigType)
+	}
+}
+
+// VerifyMinisign checks a minisign signature, legacy or prehashed, and its trusted comment. The public key
+// is the base64 key, or the content of the minisign.pub file.
+func VerifyMinisign(publicKey string, msg, sig []byte) error {
+	key, err := base64.StdEncoding.DecodeString(lastLine(publicKey))
+	if err != nil || len(key) != minisignKeySize || string(key[:2]) != minisignAlgLegacy {
+		return fmt.Errorf("%w: not a minisign public key", ErrInvalidPublicKey)
+	}
+
+	lines := []string{}
+
+	for _, line := range strings.Split(string(sig), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, untrustedCommentPrefix) {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) != 3 || !strings.HasPrefix(lines[1], trustedCommentPrefix) {
+		return fmt.Errorf("%w: not a minisign signature", ErrInvalidSignature)
+	}
+
+	rawSig, err := base64.StdEncoding.DecodeString(lines[0])
+	if err != nil || len(rawSig) != minisignSigSize {
+		return fmt.Errorf("%w: not a minisign signature", ErrInvalidSignature)
+	}
+
+	globalSig, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return fmt.Errorf("%w: invalid trusted comment signature", ErrInvalidSignature)
+	}
+
+	if !bytes.Equal(rawSig[2:2+minisignKeyIDSize], key[2:2+minisignKeyIDSize]) {
+		return ErrKeyMismatch
+	}
+
+	pub := ed25519.PublicKey(key[2+minisignKeyIDSize:])
+	signature := rawSig[2+minisignKeyIDSize:]
+
+	switch string(rawSig[:2]) {
+	case minisignAlgLegacy:
+
+	case minisignAlgPrehashed:
+		digest := blake2b.Sum512(msg)
+		msg = digest[:]
+
+	default:
+		return fmt.Errorf("%w: unknown minisign algorithm %q", ErrInvalidSignature, rawSig[:2])
+	}
+
+	if !ed25519.Verify(pub, msg, signature) {
+		return ErrVerification
+	}
+
+	trustedComment := strings.TrimPrefix(lines[1], trustedCommentPrefix)
+
+	if !ed25519.Verify(pub, append(bytes.Clone(signature), trustedComment...), globalSig) {
+		return fmt.Errorf("%w: trusted comment", ErrVerification)
+	}
+
+	return nil
+}
+
+// VerifyCosign checks a signature of cosign sign-blob --key. The public key is the PEM of cosign.pub, and
+// the signature is base64 encoded, as cosign writes it.
+func VerifyCosign(publicKey string, msg, sig []byte) error {
+	block, _ := pem.Decode([]byte(publicKey))
+	if block == nil {
+		return fmt.Errorf("%w: not a PEM encoded cosign public key", ErrInvalidPublicKey)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidPublicKey, err)
+	}
+
+	rawSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("%w: not a base64 encoded cosign signature", ErrInvalidSignature)
+	}
+
+	digest := sha256.Sum256(msg)
+
+	var ok bool
+
+	switch k := pub.(type) {
+	case *ecdsa.PublicKey:
+		ok = ecdsa.VerifyASN1(k, digest[:], rawSig)
+
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(k, msg, rawSig)
+
+	case *rsa.PublicKey:
+		ok = rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], rawSig) == nil
+
+	default:
+		return fmt.Errorf("%w: unsupported key type %T", ErrInvalidPublicKey, pub)
+	}
+
+	if !ok {
+		return ErrVerification
+	}
+
+	return nil
+}
+
+// SignCosign signs msg with a PEM encoded PKCS#8 private key, ed25519, ECDSA or RSA, for example the one of
+// `openssl genpkey -algorithm ed25519`. The signature is base64 encoded, and VerifyCosign checks it with
+// the public key of `openssl pkey -pubout`.
+func SignCosign(privateKey, msg []byte) ([]byte, error) {
+	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return nil, fmt.Errorf("%w: not a PEM encoded private key", ErrInvalidKey)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidKey, err)
+	}
+
+	digest := sha256.Sum256(msg)
+
+	var rawSig []byte
+
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		rawSig = ed25519.Sign(k, msg)
+
+	case *ecdsa.PrivateKey:
+		rawSig, err = ecdsa.SignASN1(rand.Reader, k, digest[:])
+
+	case *rsa.PrivateKey:
+		rawSig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %T", ErrInvalidKey, key)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error while signing: %w", err)
+	}
+
+	return []byte(base64.StdEncoding.EncodeToString(rawSig)), nil
+}
+
+// lastLine returns the last line of a key file that is not a comment.
+func lastLine(s string) string {
+	line := ""
+
+	for _, l := range strings.Split(s, "\n") {
+		if l = strings.TrimSpace(l); l != "" && !strings.HasPrefix(l, untrustedCommentPrefix) {
+			line = l
+		}
+	}
+
+	return line
+}
@@ -0,0 +1,289 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package signature_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/sighupio/furyctl/internal/signature"
+)
+
+const checksums = "0123abcd  kubectl-1.31.4-linux-amd64\n"
+
+type minisignKey struct {
+	id   []byte
+	priv ed25519.PrivateKey
+	pub  string
+}
+
+func newMinisignKey(t *testing.T, id string) minisignKey {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	raw := append([]byte("Ed"), id...)
+	raw = append(raw, pub...)
+
+	return minisignKey{
+		id:   []byte(id),
+		priv: priv,
+		pub:  "untrusted comment: minisign public key\n" + base64.StdEncoding.EncodeToString(raw) + "\n",
+	}
+}
+
+// sign returns a minisign signature file, prehashed unless legacy is set.
+func (k minisignKey) sign(msg []byte, legacy bool) []byte {
+	alg := "ED"
+
+	if legacy {
+		alg = "Ed"
+	} else {
+		digest := blake2b.Sum512(msg)
+		msg = digest[:]
+	}
+
+	sig := ed25519.Sign(k.priv, msg)
+	trustedComment := "timestamp:1760000000\tfile:checksums.txt"
+	globalSig := ed25519.Sign(k.priv, append(append([]byte{}, sig...), trustedComment...))
+
+	raw := append([]byte(alg), k.id...)
+	raw = append(raw, sig...)
+
+	return []byte(fmt.Sprintf(
+		"untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw),
+		trustedComment,
+		base64.StdEncoding.EncodeToString(globalSig),
+	))
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	t.Parallel()
+
+	key := newMinisignKey(t, "12345678")
+	otherKey := newMinisignKey(t, "87654321")
+
+	testCases := []struct {
+		desc    string
+		pubKey  string
+		msg     string
+		sig     []byte
+		wantErr error
+	}{
+		{
+			desc:   "prehashed signature",
+			pubKey: key.pub,
+			msg:    checksums,
+			sig:    key.sign([]byte(checksums), false),
+		},
+		{
+			desc:   "legacy signature",
+			pubKey: key.pub,
+			msg:    checksums,
+			sig:    key.sign([]byte(checksums), true),
+		},
+		{
+			desc:    "tampered message",
+			pubKey:  key.pub,
+			msg:     "ffffffff  kubectl-1.31.4-linux-amd64\n",
+			sig:     key.sign([]byte(checksums), false),
+			wantErr: signature.ErrVerification,
+		},
+		{
+			desc:    "signature of another key",
+			pubKey:  key.pub,
+			msg:     checksums,
+			sig:     otherKey.sign([]byte(checksums), false),
+			wantErr: signature.ErrKeyMismatch,
+		},
+		{
+			desc:    "not a signature",
+			pubKey:  key.pub,
+			msg:     checksums,
+			sig:     []byte("garbage"),
+			wantErr: signature.ErrInvalidSignature,
+		},
+		{
+			desc:    "not a public key",
+			pubKey:  "garbage",
+			msg:     checksums,
+			sig:     key.sign([]byte(checksums), false),
+			wantErr: signature.ErrInvalidPublicKey,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			err := signature.Verify(signature.TypeMinisign, tC.pubKey, []byte(tC.msg), tC.sig)
+			if tC.wantErr == nil {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, tC.wantErr)
+		})
+	}
+}
+
+func TestVerifyMinisign_TamperedTrustedComment(t *testing.T) {
+	t.Parallel()
+
+	key := newMinisignKey(t, "12345678")
+
+	sig := key.sign([]byte(checksums), false)
+	sig = []byte(strings.Replace(string(sig), "file:checksums.txt", "file:other.txt", 1))
+
+	err := signature.VerifyMinisign(key.pub, []byte(checksums), sig)
+	require.ErrorIs(t, err, signature.ErrVerification)
+	assert.ErrorContains(t, err, "trusted comment")
+}
+
+func TestVerifyCosign(t *testing.T) {
+	t.Parallel()
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte(checksums))
+
+	ecSig, err := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+	require.NoError(t, err)
+
+	rsaSig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc    string
+		pub     any
+		msg     string
+		sig     []byte
+		wantErr error
+	}{
+		{
+			desc: "ecdsa signature",
+			pub:  &ecKey.PublicKey,
+			msg:  checksums,
+			sig:  ecSig,
+		},
+		{
+			desc: "rsa signature",
+			pub:  &rsaKey.PublicKey,
+			msg:  checksums,
+			sig:  rsaSig,
+		},
+		{
+			desc: "ed25519 signature",
+			pub:  edPub,
+			msg:  checksums,
+			sig:  ed25519.Sign(edPriv, []byte(checksums)),
+		},
+		{
+			desc:    "tampered message",
+			pub:     &ecKey.PublicKey,
+			msg:     "ffffffff  kubectl-1.31.4-linux-amd64\n",
+			sig:     ecSig,
+			wantErr: signature.ErrVerification,
+		},
+		{
+			desc:    "signature of another key",
+			pub:     &ecKey.PublicKey,
+			msg:     checksums,
+			sig:     rsaSig,
+			wantErr: signature.ErrVerification,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			der, err := x509.MarshalPKIXPublicKey(tC.pub)
+			require.NoError(t, err)
+
+			pubKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+			sig := []byte(base64.StdEncoding.EncodeToString(tC.sig) + "\n")
+
+			err = signature.Verify(signature.TypeCosign, pubKey, []byte(tC.msg), sig)
+			if tC.wantErr == nil {
+				require.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, tC.wantErr)
+		})
+	}
+}
+
+func TestVerify_UnknownType(t *testing.T) {
+	t.Parallel()
+
+	err := signature.Verify("gpg", "", []byte(checksums), nil)
+	require.ErrorIs(t, err, signature.ErrUnknownType)
+}
+
+func TestSignCosign(t *testing.T) {
+	t.Parallel()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	for name, key := range map[string]crypto.Signer{"ed25519": edKey, "ecdsa": ecKey, "rsa": rsaKey} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			require.NoError(t, err)
+
+			pubDer, err := x509.MarshalPKIXPublicKey(key.Public())
+			require.NoError(t, err)
+
+			pub := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDer}))
+
+			sig, err := signature.SignCosign(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), []byte(checksums))
+			require.NoError(t, err)
+
+			require.NoError(t, signature.VerifyCosign(pub, []byte(checksums), sig))
+			require.ErrorIs(t, signature.VerifyCosign(pub, []byte("tampered"), sig), signature.ErrVerification)
+		})
+	}
+
+	_, err = signature.SignCosign([]byte("not a key"), []byte(checksums))
+	require.ErrorIs(t, err, signature.ErrInvalidKey)
+}
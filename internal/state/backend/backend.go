@@ -0,0 +1,149 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package backend holds the places where furyctl keeps the state of a cluster: the configuration, the
+// distribution, its history and the upgrade state. The state is a set of objects, that the cluster
+// backend keeps as secrets and config maps in kube-system, and the other backends as YAML files, so
+// that the state can be read when the API server of the cluster is not reachable.
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sighupio/furyctl/internal/kubernetes"
+)
+
+const (
+	TypeCluster = "cluster"
+	TypeLocal   = "local"
+	TypeS3      = "s3"
+
+	KindSecret    = "Secret"
+	KindConfigMap = "ConfigMap"
+)
+
+var (
+	ErrNotFound        = errors.New("state object not found")
+	ErrUnknownBackend  = errors.New("unknown state backend")
+	ErrMissingDir      = errors.New("the local state backend needs a directory, set --state-dir")
+	ErrMissingBucket   = errors.New("the s3 state backend needs a bucket, set --state-s3-bucket")
+	ErrInvalidObject   = errors.New("invalid state object")
+	errUnsupportedKind = errors.New("unsupported kind")
+)
+
+// Object is a piece of the state, a secret or a config map for the cluster backend. The data is in clear,
+// the backends encode it as they need.
+type Object struct {
+	Kind   string            `yaml:"kind"`
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels,omitempty"`
+	Data   map[string]string `yaml:"data"`
+}
+
+// Backend keeps the objects of the state.
+type Backend interface {
+	// String describes where the backend keeps the state, for the logs.
+	String() string
+
+	// Put creates the object, or replaces it.
+	Put(obj Object) error
+	// Get returns the object, or ErrNotFound.
+	Get(kind, name string) (Object, error)
+	// List returns the objects of the kind that have the label.
+	List(kind, label string) ([]Object, error)
+	// Delete removes the objects, the ones that are not there are ignored.
+	Delete(kind string, names ...string) error
+}
+
+// Config selects the backend and holds its options.
+type Config struct {
+	Type string
+
+	// Dir is the directory of the local backend.
+	Dir string
+
+	// S3Bucket and S3Prefix are where the s3 backend keeps the objects. S3Endpoint is the address of an
+	// S3-compatible service, for example MinIO, empty for AWS.
+	S3Bucket   string
+	S3Prefix   string
+	S3Endpoint string
+	S3Region   string
+}
+
+// Types returns the types of the backends.
+func Types() []string {
+	return []string{TypeCluster, TypeLocal, TypeS3}
+}
+
+func (c Config) Validate() error {
+	switch c.Type {
+	case "", TypeCluster:
+		return nil
+
+	case TypeLocal:
+		if c.Dir == "" {
+			return ErrMissingDir
+		}
+
+		return nil
+
+	case TypeS3:
+		if c.S3Bucket == "" {
+			return ErrMissingBucket
+		}
+
+		return nil
+
+	default:
+		return fmt.Errorf("%w %q, supported values are: %s", ErrUnknownBackend, c.Type, strings.Join(Types(), ", "))
+	}
+}
+
+// New returns the backend of the configuration. The cluster backend uses the client, or a client for the
+// cluster of the kubeconfig in the KUBECONFIG environment variable when it is nil.
+func New(c Config, client *kubernetes.Client) (Backend, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	switch c.Type {
+	case TypeLocal:
+		return NewLocal(c.Dir), nil
+
+	case TypeS3:
+		return NewS3(c)
+
+	default:
+		if client == nil {
+			var err error
+
+			if client, err = kubernetes.NewClient(""); err != nil {
+				return nil, fmt.Errorf("error while creating the client of the cluster state backend: %w", err)
+			}
+		}
+
+		return NewCluster(client), nil
+	}
+}
+
+func hasLabel(obj Object, label string) bool {
+	if label == "" {
+		return true
+	}
+
+	_, ok := obj.Labels[label]
+
+	return ok
+}
+
+func validKind(kind string) error {
+	if !slices.Contains([]string{KindSecret, KindConfigMap}, kind) {
+		return fmt.Errorf("%w: %w %q", ErrInvalidObject, errUnsupportedKind, kind)
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package backend_test
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/sighupio/furyctl/internal/kubernetes"
+	"github.com/sighupio/furyctl/internal/state/backend"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, backend.Config{}.Validate())
+	require.NoError(t, backend.Config{Type: backend.TypeCluster}.Validate())
+	require.NoError(t, backend.Config{Type: backend.TypeLocal, Dir: "state"}.Validate())
+	require.NoError(t, backend.Config{Type: backend.TypeS3, S3Bucket: "furyctl"}.Validate())
+
+	require.ErrorIs(t, backend.Config{Type: backend.TypeLocal}.Validate(), backend.ErrMissingDir)
+	require.ErrorIs(t, backend.Config{Type: backend.TypeS3}.Validate(), backend.ErrMissingBucket)
+	require.ErrorIs(t, backend.Config{Type: "etcd"}.Validate(), backend.ErrUnknownBackend)
+}
+
+func TestCluster(t *testing.T) {
+	t.Parallel()
+
+	testBackend(t, backend.NewCluster(kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(), nil, nil)))
+}
+
+func TestLocal(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "state")
+
+	testBackend(t, backend.NewLocal(dir))
+
+	assert.FileExists(t, filepath.Join(dir, "furyctl-config.yaml"))
+}
+
+func TestS3(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(newFakeS3())
+	defer srv.Close()
+
+	client := s3.New(s3.Options{
+		BaseEndpoint: aws.String(srv.URL),
+		Region:       "us-east-1",
+		UsePathStyle: true,
+		Credentials:  credentials.NewStaticCredentialsProvider("minio", "minio123", ""),
+	})
+
+	b := backend.NewS3WithClient(client, "furyctl", "/clusters/prod/")
+
+	assert.Equal(t, "s3://furyctl/clusters/prod", b.String())
+
+	testBackend(t, b)
+
+	// The objects of other prefixes are not part of the state.
+	other := backend.NewS3WithClient(client, "furyctl", "clusters/dev")
+
+	objs, err := other.List(backend.KindSecret, "")
+	require.NoError(t, err)
+	assert.Empty(t, objs)
+}
+
+// testBackend checks the behaviour every backend shares.
+func testBackend(t *testing.T, b backend.Backend) {
+	t.Helper()
+
+	_, err := b.Get(backend.KindSecret, "furyctl-config")
+	require.ErrorIs(t, err, backend.ErrNotFound)
+
+	objs, err := b.List(backend.KindSecret, "furyctl.sighup.io/config-revision")
+	require.NoError(t, err)
+	assert.Empty(t, objs)
+
+	config := backend.Object{
+		Kind: backend.KindSecret,
+		Name: "furyctl-config",
+		Data: map[string]string{"config": "apiVersion: kfd.sighup.io/v1alpha2\n", "rendered": "kind: OnPremises\n"},
+	}
+
+	require.NoError(t, b.Put(config))
+
+	for _, rev := range []string{"1", "2"} {
+		require.NoError(t, b.Put(backend.Object{
+			Kind:   backend.KindSecret,
+			Name:   "furyctl-config-revision-" + rev,
+			Labels: map[string]string{"furyctl.sighup.io/config-revision": rev},
+			Data:   map[string]string{"metadata": `{"revision":` + rev + `}`},
+		}))
+	}
+
+	require.NoError(t, b.Put(backend.Object{
+		Kind: backend.KindConfigMap,
+		Name: "furyctl-upgrade-state",
+		Data: map[string]string{"state": "phases: {}\n"},
+	}))
+
+	got, err := b.Get(backend.KindSecret, "furyctl-config")
+	require.NoError(t, err)
+	assert.Equal(t, config, got)
+
+	// An object is found only with its kind.
+	_, err = b.Get(backend.KindConfigMap, "furyctl-config")
+	require.ErrorIs(t, err, backend.ErrNotFound)
+
+	_, err = b.Get("Deployment", "furyctl-config")
+	require.ErrorIs(t, err, backend.ErrInvalidObject)
+
+	objs, err = b.List(backend.KindSecret, "furyctl.sighup.io/config-revision")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"furyctl-config-revision-1", "furyctl-config-revision-2"}, names(objs))
+
+	objs, err = b.List(backend.KindConfigMap, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"furyctl-upgrade-state"}, names(objs))
+
+	require.NoError(t, b.Delete(backend.KindSecret, "furyctl-config-revision-1", "furyctl-config-revision-3"))
+
+	objs, err = b.List(backend.KindSecret, "furyctl.sighup.io/config-revision")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"furyctl-config-revision-2"}, names(objs))
+}
+
+func names(objs []backend.Object) []string {
+	n := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		n = append(n, obj.Name)
+	}
+
+	return n
+}
+
+type listBucketResult struct {
+	XMLName  xml.Name `xml:"ListBucketResult"`
+	Name     string   `xml:"Name"`
+	Prefix   string   `xml:"Prefix"`
+	KeyCount int      `xml:"KeyCount"`
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated bool `xml:"IsTruncated"`
+}
+
+// fakeS3 serves the requests of the path style S3 API the backend sends, as MinIO does.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && key == "" && r.URL.Query().Get("list-type") == "2":
+		prefix := r.URL.Query().Get("prefix")
+		res := listBucketResult{Name: bucket, Prefix: prefix}
+
+		keys := []string{}
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) && !strings.Contains(strings.TrimPrefix(k, prefix), "/") {
+				keys = append(keys, k)
+			}
+		}
+
+		slices.Sort(keys)
+
+		for _, k := range keys {
+			res.Contents = append(res.Contents, struct {
+				Key string `xml:"Key"`
+			}{Key: k})
+		}
+
+		res.KeyCount = len(keys)
+
+		w.Header().Set("Content-Type", "application/xml")
+		_ = xml.NewEncoder(w).Encode(res)
+
+	case r.Method == http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		f.objects[key] = body
+
+	case r.Method == http.MethodGet:
+		body, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`))
+
+			return
+		}
+
+		_, _ = w.Write(body)
+
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/sighupio/furyctl/internal/kubernetes"
+)
+
+const namespace = "kube-system"
+
+// Cluster keeps the state in secrets and config maps in the kube-system namespace of the cluster.
+type Cluster struct {
+	client *kubernetes.Client
+}
+
+func NewCluster(client *kubernetes.Client) *Cluster {
+	return &Cluster{client: client}
+}
+
+func (*Cluster) String() string {
+	return "the cluster"
+}
+
+func (c *Cluster) Put(obj Object) error {
+	if err := validKind(obj.Kind); err != nil {
+		return err
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:      obj.Name,
+		Namespace: namespace,
+		Labels:    obj.Labels,
+	}
+
+	var err error
+
+	if obj.Kind == KindSecret {
+		data := make(map[string][]byte, len(obj.Data))
+		for k, v := range obj.Data {
+			data[k] = []byte(v)
+		}
+
+		err = c.client.ApplySecret(&corev1.Secret{ObjectMeta: meta, Type: corev1.SecretTypeOpaque, Data: data})
+	} else {
+		err = c.client.ApplyConfigMap(&corev1.ConfigMap{ObjectMeta: meta, Data: obj.Data})
+	}
+
+	if err != nil {
+		return fmt.Errorf("error while saving %s %s in the cluster: %w", obj.Kind, obj.Name, err)
+	}
+
+	return nil
+}
+
+func (c *Cluster) Get(kind, name string) (Object, error) {
+	if err := validKind(kind); err != nil {
+		return Object{}, err
+	}
+
+	var (
+		obj Object
+		err error
+	)
+
+	if kind == KindSecret {
+		var secret *corev1.Secret
+
+		secret, err = c.client.GetSecret(namespace, name)
+		if err == nil {
+			obj = fromSecret(*secret)
+		}
+	} else {
+		var configMap *corev1.ConfigMap
+
+		configMap, err = c.client.GetConfigMap(namespace, name)
+		if err == nil {
+			obj = fromConfigMap(*configMap)
+		}
+	}
+
+	if errors.Is(err, kubernetes.ErrNotFound) {
+		return Object{}, fmt.Errorf("%w: %s %s", ErrNotFound, kind, name)
+	}
+
+	if err != nil {
+		return Object{}, fmt.Errorf("error while getting %s %s from the cluster: %w", kind, name, err)
+	}
+
+	return obj, nil
+}
+
+func (c *Cluster) List(kind, label string) ([]Object, error) {
+	if err := validKind(kind); err != nil {
+		return nil, err
+	}
+
+	objs := []Object{}
+
+	if kind == KindSecret {
+		secrets, err := c.client.ListSecrets(namespace, label)
+		if err != nil {
+			return nil, fmt.Errorf("error while listing %s objects with label %s: %w", kind, label, err)
+		}
+
+		for _, secret := range secrets {
+			objs = append(objs, fromSecret(secret))
+		}
+
+		return objs, nil
+	}
+
+	configMaps, err := c.client.ListConfigMaps(namespace, label)
+	if err != nil {
+		return nil, fmt.Errorf("error while listing %s objects with label %s: %w", kind, label, err)
+	}
+
+	for _, configMap := range configMaps {
+		objs = append(objs, fromConfigMap(configMap))
+	}
+
+	return objs, nil
+}
+
+func (c *Cluster) Delete(kind string, names ...string) error {
+	if err := validKind(kind); err != nil {
+		return err
+	}
+
+	var err error
+
+	if kind == KindSecret {
+		err = c.client.DeleteSecrets(namespace, names...)
+	} else {
+		err = c.client.DeleteConfigMaps(namespace, names...)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error while deleting %s objects from the cluster: %w", kind, err)
+	}
+
+	return nil
+}
+
+func fromSecret(secret corev1.Secret) Object {
+	obj := Object{
+		Kind:   KindSecret,
+		Name:   secret.Name,
+		Labels: secret.Labels,
+		Data:   make(map[string]string, len(secret.Data)),
+	}
+
+	for k, v := range secret.Data {
+		obj.Data[k] = string(v)
+	}
+
+	return obj
+}
+
+func fromConfigMap(configMap corev1.ConfigMap) Object {
+	obj := Object{
+		Kind:   KindConfigMap,
+		Name:   configMap.Name,
+		Labels: configMap.Labels,
+		Data:   make(map[string]string, len(configMap.Data)),
+	}
+
+	for k, v := range configMap.Data {
+		obj.Data[k] = v
+	}
+
+	return obj
+}
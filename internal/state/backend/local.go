@@ -0,0 +1,160 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	iox "github.com/sighupio/furyctl/internal/x/io"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+const objectExt = ".yaml"
+
+// Local keeps the state in a directory, one YAML file for each object with the data in clear, so that
+// the directory can be versioned with git.
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+func (l *Local) String() string {
+	return l.dir
+}
+
+func (l *Local) Put(obj Object) error {
+	if err := validKind(obj.Kind); err != nil {
+		return err
+	}
+
+	out, err := marshalObject(obj)
+	if err != nil {
+		return err
+	}
+
+	if err := iox.EnsureDir(l.objectPath(obj.Name)); err != nil {
+		return fmt.Errorf("error while creating state directory: %w", err)
+	}
+
+	if err := iox.WriteFile(l.objectPath(obj.Name), out); err != nil {
+		return fmt.Errorf("error while writing %s %s: %w", obj.Kind, obj.Name, err)
+	}
+
+	return nil
+}
+
+func (l *Local) Get(kind, name string) (Object, error) {
+	if err := validKind(kind); err != nil {
+		return Object{}, err
+	}
+
+	content, err := os.ReadFile(l.objectPath(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return Object{}, fmt.Errorf("%w: %s %s", ErrNotFound, kind, name)
+	}
+
+	if err != nil {
+		return Object{}, fmt.Errorf("error while reading %s %s: %w", kind, name, err)
+	}
+
+	obj, err := unmarshalObject(content)
+	if err != nil {
+		return Object{}, err
+	}
+
+	if obj.Kind != kind {
+		return Object{}, fmt.Errorf("%w: %s %s", ErrNotFound, kind, name)
+	}
+
+	return obj, nil
+}
+
+func (l *Local) List(kind, label string) ([]Object, error) {
+	if err := validKind(kind); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(l.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Object{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error while reading state directory: %w", err)
+	}
+
+	objs := []Object{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), objectExt) {
+			continue
+		}
+
+		content, err := os.ReadFile(filepath.Join(l.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error while reading %s: %w", entry.Name(), err)
+		}
+
+		obj, err := unmarshalObject(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		if obj.Kind == kind && hasLabel(obj, label) {
+			objs = append(objs, obj)
+		}
+	}
+
+	return objs, nil
+}
+
+func (l *Local) Delete(kind string, names ...string) error {
+	if err := validKind(kind); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if err := os.Remove(l.objectPath(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("error while deleting %s %s: %w", kind, name, err)
+		}
+	}
+
+	return nil
+}
+
+func (l *Local) objectPath(name string) string {
+	return filepath.Join(l.dir, name+objectExt)
+}
+
+func marshalObject(obj Object) ([]byte, error) {
+	out, err := yamlx.MarshalV3(obj)
+	if err != nil {
+		return nil, fmt.Errorf("error while marshalling %s %s: %w", obj.Kind, obj.Name, err)
+	}
+
+	return out, nil
+}
+
+func unmarshalObject(content []byte) (Object, error) {
+	obj := Object{}
+
+	if err := yamlx.UnmarshalV3(content, &obj); err != nil {
+		return Object{}, fmt.Errorf("%w: %w", ErrInvalidObject, err)
+	}
+
+	if obj.Name == "" || !slices.Contains([]string{KindSecret, KindConfigMap}, obj.Kind) {
+		return Object{}, fmt.Errorf("%w: the kind or the name is missing", ErrInvalidObject)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,221 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+const s3Timeout = 60 * time.Second
+
+// S3 keeps the state in a bucket of S3 or of an S3-compatible service, for example MinIO, one YAML object
+// for each object of the state. The credentials come from the usual AWS environment variables, shared
+// files and roles.
+type S3 struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+// NewS3 returns the backend of the bucket in the configuration. With an endpoint, the requests use the
+// path style that the S3-compatible services support.
+func NewS3(c Config) (*S3, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+
+	opts := []func(*awsconfig.LoadOptions) error{
+		// Some S3-compatible services do not support the checksums of the recent AWS SDKs.
+		awsconfig.WithRequestChecksumCalculation(aws.RequestChecksumCalculationWhenRequired),
+		awsconfig.WithResponseChecksumValidation(aws.ResponseChecksumValidationWhenRequired),
+	}
+
+	if c.S3Region != "" {
+		opts = append(opts, awsconfig.WithRegion(c.S3Region))
+	}
+
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading AWS configuration: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if c.S3Endpoint != "" {
+			o.BaseEndpoint = aws.String(c.S3Endpoint)
+			o.UsePathStyle = true
+		}
+
+		if o.Region == "" {
+			o.Region = "us-east-1"
+		}
+	})
+
+	return NewS3WithClient(client, c.S3Bucket, c.S3Prefix), nil
+}
+
+// NewS3WithClient returns the backend of the bucket with the given client.
+func NewS3WithClient(client *s3.Client, bucket, prefix string) *S3 {
+	return &S3{
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+	}
+}
+
+func (b *S3) String() string {
+	return "s3://" + path.Join(b.bucket, b.prefix)
+}
+
+func (b *S3) Put(obj Object) error {
+	if err := validKind(obj.Kind); err != nil {
+		return err
+	}
+
+	out, err := marshalObject(obj)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+
+	if _, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(b.bucket),
+		Key:         aws.String(b.key(obj.Name)),
+		Body:        bytes.NewReader(out),
+		ContentType: aws.String("application/yaml"),
+	}); err != nil {
+		return fmt.Errorf("error while writing %s %s to %s: %w", obj.Kind, obj.Name, b, err)
+	}
+
+	return nil
+}
+
+func (b *S3) Get(kind, name string) (Object, error) {
+	if err := validKind(kind); err != nil {
+		return Object{}, err
+	}
+
+	obj, err := b.getObject(b.key(name))
+	if err != nil {
+		return Object{}, fmt.Errorf("error while reading %s %s: %w", kind, name, err)
+	}
+
+	if obj.Kind != kind {
+		return Object{}, fmt.Errorf("%w: %s %s", ErrNotFound, kind, name)
+	}
+
+	return obj, nil
+}
+
+func (b *S3) List(kind, label string) ([]Object, error) {
+	if err := validKind(kind); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+
+	prefix := ""
+	if b.prefix != "" {
+		prefix = b.prefix + "/"
+	}
+
+	objs := []Object{}
+
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(b.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error while listing %s: %w", b, err)
+		}
+
+		for _, item := range page.Contents {
+			key := aws.ToString(item.Key)
+			if !strings.HasSuffix(key, objectExt) {
+				continue
+			}
+
+			obj, err := b.getObject(key)
+			if err != nil {
+				return nil, fmt.Errorf("error while reading %s: %w", key, err)
+			}
+
+			if obj.Kind == kind && hasLabel(obj, label) {
+				objs = append(objs, obj)
+			}
+		}
+	}
+
+	return objs, nil
+}
+
+func (b *S3) Delete(kind string, names ...string) error {
+	if err := validKind(kind); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+
+	for _, name := range names {
+		// Deleting a key that is not there is not an error for S3.
+		if _, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+			Bucket: aws.String(b.bucket),
+			Key:    aws.String(b.key(name)),
+		}); err != nil {
+			return fmt.Errorf("error while deleting %s %s from %s: %w", kind, name, b, err)
+		}
+	}
+
+	return nil
+}
+
+func (b *S3) getObject(key string) (Object, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3Timeout)
+	defer cancel()
+
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return Object{}, fmt.Errorf("%w: %s", ErrNotFound, key)
+		}
+
+		return Object{}, fmt.Errorf("error while getting %s: %w", key, err)
+	}
+
+	defer out.Body.Close()
+
+	content, err := io.ReadAll(out.Body)
+	if err != nil {
+		return Object{}, fmt.Errorf("error while reading %s: %w", key, err)
+	}
+
+	return unmarshalObject(content)
+}
+
+func (b *S3) key(name string) string {
+	return path.Join(b.prefix, name+objectExt)
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/lock"
+	"github.com/sighupio/furyctl/internal/state/backend"
+)
+
+const (
+	// HistoryLimit is the number of configuration revisions that the cluster keeps.
+	HistoryLimit = 10
+
+	// RevisionLabel is the label of the secrets of the configuration history, its value is the revision.
+	RevisionLabel = "furyctl.sighup.io/config-revision"
+
+	revisionSecretPrefix = "furyctl-config-revision-"
+)
+
+var ErrRevisionNotFound = errors.New("configuration revision not found")
+
+// Revision is a configuration applied to the cluster, with who applied it and when.
+type Revision struct {
+	Number              int       `json:"revision"`
+	AppliedAt           time.Time `json:"appliedAt"`
+	FuryctlVersion      string    `json:"furyctlVersion"`
+	DistributionVersion string    `json:"distributionVersion"`
+	User                string    `json:"user"`
+	Host                string    `json:"host"`
+
+	// Config is the furyctl.yaml file as it was applied, Rendered is the same file with its dynamic
+	// values resolved.
+	Config   []byte `json:"-"`
+	Rendered []byte `json:"-"`
+}
+
+// SecretName is the name of the secret that holds the revision.
+func (r Revision) SecretName() string {
+	return revisionSecretPrefix + strconv.Itoa(r.Number)
+}
+
+// ListRevisions returns the configuration revisions stored in the state backend, the oldest first.
+func (s *Store) ListRevisions() ([]Revision, error) {
+	b, err := s.backend()
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := b.List(backend.KindSecret, RevisionLabel)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting configuration history: %w", err)
+	}
+
+	revisions := make([]Revision, 0, len(objs))
+
+	for _, obj := range objs {
+		rev := Revision{}
+
+		if err := json.Unmarshal([]byte(obj.Data["metadata"]), &rev); err != nil {
+			return nil, fmt.Errorf("error while unmarshalling revision %s: %w", obj.Name, err)
+		}
+
+		if rev.Config, err = encryption.Open(s.Key, []byte(obj.Data["config"])); err != nil {
+			return nil, fmt.Errorf("error while decrypting revision %s: %w", obj.Name, err)
+		}
+
+		if rev.Rendered, err = encryption.Open(s.Key, []byte(obj.Data["rendered"])); err != nil {
+			return nil, fmt.Errorf("error while decrypting revision %s: %w", obj.Name, err)
+		}
+
+		revisions = append(revisions, rev)
+	}
+
+	slices.SortFunc(revisions, func(a, b Revision) int {
+		return a.Number - b.Number
+	})
+
+	return revisions, nil
+}
+
+// GetRevision returns the configuration revision with the given number.
+func (s *Store) GetRevision(number int) (Revision, error) {
+	revisions, err := s.ListRevisions()
+	if err != nil {
+		return Revision{}, err
+	}
+
+	idx := slices.IndexFunc(revisions, func(r Revision) bool {
+		return r.Number == number
+	})
+	if idx < 0 {
+		return Revision{}, fmt.Errorf("%w: %d", ErrRevisionNotFound, number)
+	}
+
+	return revisions[idx], nil
+}
+
+// storeRevision adds the configuration to the history, as the revision after the last one, and removes
+// the revisions beyond HistoryLimit.
+func (s *Store) storeRevision(config, rendered []byte, distributionVersion string) error {
+	revisions, err := s.ListRevisions()
+	if err != nil {
+		return err
+	}
+
+	info := lock.NewInfo()
+
+	rev := Revision{
+		Number:              1,
+		AppliedAt:           time.Now().UTC().Truncate(time.Second),
+		FuryctlVersion:      app.GetContainerInstance().Version,
+		DistributionVersion: distributionVersion,
+		User:                info.Holder,
+		Host:                info.Host,
+	}
+
+	if len(revisions) > 0 {
+		rev.Number = revisions[len(revisions)-1].Number + 1
+	}
+
+	rev.Config = config
+	rev.Rendered = rendered
+
+	if err := s.applyRevision(rev); err != nil {
+		return err
+	}
+
+	// The revision just stored is the last one.
+	revisions = append(revisions, rev)
+
+	if len(revisions) <= HistoryLimit {
+		return nil
+	}
+
+	names := []string{}
+
+	for _, old := range revisions[:len(revisions)-HistoryLimit] {
+		names = append(names, old.SecretName())
+	}
+
+	b, err := s.backend()
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(backend.KindSecret, names...); err != nil {
+		return fmt.Errorf("error while removing old revisions of the furyctl configuration file: %w", err)
+	}
+
+	return nil
+}
+
+// applyRevision saves the revision in its secret of the state backend, with the configuration encrypted with the key of the store.
+func (s *Store) applyRevision(rev Revision) error {
+	sealedConfig, err := encryption.Seal(s.Key, rev.Config)
+	if err != nil {
+		return fmt.Errorf("error while encrypting revision %d: %w", rev.Number, err)
+	}
+
+	sealedRendered, err := encryption.Seal(s.Key, rev.Rendered)
+	if err != nil {
+		return fmt.Errorf("error while encrypting revision %d: %w", rev.Number, err)
+	}
+
+	metadata, err := json.Marshal(rev)
+	if err != nil {
+		return fmt.Errorf("error while marshalling revision: %w", err)
+	}
+
+	b, err := s.backend()
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Saving revision %d of the furyctl configuration file in %s...", rev.Number, b)
+
+	if err := b.Put(backend.Object{
+		Kind: backend.KindSecret,
+		Name: rev.SecretName(),
+		Labels: map[string]string{
+			RevisionLabel:                  strconv.Itoa(rev.Number),
+			"app.kubernetes.io/managed-by": "furyctl",
+		},
+		Data: map[string]string{
+			"config":   string(sealedConfig),
+			"rendered": string(sealedRendered),
+			"metadata": string(metadata),
+		},
+	}); err != nil {
+		return fmt.Errorf("error while saving revision %d of the furyctl configuration file: %w", rev.Number, err)
+	}
+
+	return nil
+}
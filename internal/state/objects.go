@@ -0,0 +1,73 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sighupio/furyctl/internal/state/backend"
+	"github.com/sighupio/furyctl/internal/upgrade"
+)
+
+// Objects returns the objects of the state kept in the backend: the configuration, the distribution, the
+// report of the last run, the configuration history and the upgrade state. The objects that are not in
+// the backend are skipped. The data is as the backend keeps it, encrypted if the configuration is.
+func Objects(b backend.Backend) ([]backend.Object, error) {
+	objs := []backend.Object{}
+
+	single := []struct{ kind, name string }{
+		{backend.KindSecret, ConfigSecret},
+		{backend.KindSecret, KFDSecret},
+		{backend.KindSecret, RunReportSecret},
+		{backend.KindConfigMap, upgrade.StateConfigMap},
+	}
+
+	for _, o := range single {
+		obj, err := b.Get(o.kind, o.name)
+		if errors.Is(err, backend.ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("error while reading state from %s: %w", b, err)
+		}
+
+		objs = append(objs, obj)
+	}
+
+	revisions, err := b.List(backend.KindSecret, RevisionLabel)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading configuration history from %s: %w", b, err)
+	}
+
+	return append(objs, revisions...), nil
+}
+
+// Copy writes the objects of the state kept in the source backend to the destination one, and returns them.
+func Copy(src, dst backend.Backend) ([]backend.Object, error) {
+	objs, err := Objects(src)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, obj := range objs {
+		// The labels of the cluster objects are not part of the state, apart from the revision one.
+		if rev, ok := obj.Labels[RevisionLabel]; ok {
+			obj.Labels = map[string]string{
+				RevisionLabel:                  rev,
+				"app.kubernetes.io/managed-by": "furyctl",
+			}
+		} else {
+			obj.Labels = nil
+		}
+
+		if err := dst.Put(obj); err != nil {
+			return nil, fmt.Errorf("error while writing state to %s: %w", dst, err)
+		}
+	}
+
+	return objs, nil
+}
@@ -5,7 +5,6 @@
 package state
 
 import (
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"os"
@@ -13,18 +12,20 @@ import (
 
 	"github.com/sirupsen/logrus"
 
-	"github.com/sighupio/furyctl/internal/tool/kubectl"
-	execx "github.com/sighupio/furyctl/internal/x/exec"
-	iox "github.com/sighupio/furyctl/internal/x/io"
-	kubex "github.com/sighupio/furyctl/internal/x/kube"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/kubernetes"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
-var (
-	errSecretDataNotFound      = errors.New("secret data not found")
-	errSecretConfigKeyNotFound = errors.New("secret config key not found")
+const (
+	ConfigSecret    = "furyctl-config"
+	KFDSecret       = "furyctl-kfd"
+	RunReportSecret = "furyctl-run-report"
 )
 
+var errSecretConfigKeyNotFound = errors.New("secret config key not found")
+
 type Storer interface {
 	StoreKFD() error
 	StoreConfig(rendered map[string]any) error
@@ -33,53 +34,66 @@ type Storer interface {
 }
 
 type Store struct {
-	DistroPath    string
-	ConfigPath    string
-	WorkDir       string
-	KubectlRunner *kubectl.Runner
-}
+	DistroPath string
+	ConfigPath string
+
+	// KubeClient reaches the cluster for the cluster backend, without it the store uses the kubeconfig in the
+	// KUBECONFIG environment variable.
+	KubeClient *kubernetes.Client
 
-func NewStore(distroPath, configPath, workDir, kubectlVersion, binPath string) *Store {
-	runner := kubectl.NewRunner(execx.NewStdExecutor(), kubectl.Paths{
-		Kubectl: path.Join(binPath, "kubectl", kubectlVersion, "kubectl"),
-		WorkDir: workDir,
-	}, true, true, false)
+	// Key encrypts the configuration stored in the cluster, without it the configuration is stored in clear.
+	Key encryption.Key
+
+	// BackendConfig selects the backend that keeps the state, by default the cluster.
+	BackendConfig backend.Config
+
+	// Backend keeps the state, without it the store creates the backend of BackendConfig.
+	Backend backend.Backend
+}
 
+func NewStore(distroPath, configPath string, key encryption.Key, backendConf backend.Config) *Store {
 	return &Store{
 		DistroPath:    distroPath,
 		ConfigPath:    configPath,
-		WorkDir:       workDir,
-		KubectlRunner: runner,
+		Key:           key,
+		BackendConfig: backendConf,
 	}
 }
 
-func (s *Store) StoreKFD() error {
-	x, err := os.ReadFile(path.Join(s.DistroPath, "kfd.yaml"))
-	if err != nil {
-		return fmt.Errorf("error while reading config file: %w", err)
-	}
-
-	data := map[string]string{
-		"kfd": base64.StdEncoding.EncodeToString(x),
+func (s *Store) backend() (backend.Backend, error) {
+	if s.Backend != nil {
+		return s.Backend, nil
 	}
 
-	secret, err := kubex.CreateSecret("furyctl-kfd", "kube-system", data)
+	b, err := backend.New(s.BackendConfig, s.KubeClient)
 	if err != nil {
-		return fmt.Errorf("error while creating secret: %w", err)
+		return nil, fmt.Errorf("error while creating state backend: %w", err)
 	}
 
-	secretPath := path.Join(s.WorkDir, "secrets-kfd.yaml")
+	s.Backend = b
 
-	if err := iox.WriteFile(secretPath, secret); err != nil {
-		return fmt.Errorf("error while writing secret: %w", err)
+	return b, nil
+}
+
+func (s *Store) StoreKFD() error {
+	x, err := os.ReadFile(path.Join(s.DistroPath, "kfd.yaml"))
+	if err != nil {
+		return fmt.Errorf("error while reading config file: %w", err)
 	}
 
-	defer os.Remove(secretPath)
+	b, err := s.backend()
+	if err != nil {
+		return err
+	}
 
-	logrus.Info("Saving distribution configuration file in the cluster...")
+	logrus.Infof("Saving distribution configuration file in %s...", b)
 
-	if err := s.KubectlRunner.Apply(secretPath); err != nil {
-		return fmt.Errorf("error while saving distribution configuration file in the cluster: %w", err)
+	if err := b.Put(backend.Object{
+		Kind: backend.KindSecret,
+		Name: KFDSecret,
+		Data: map[string]string{"kfd": string(x)},
+	}); err != nil {
+		return fmt.Errorf("error while saving distribution configuration file: %w", err)
 	}
 
 	return nil
@@ -96,33 +110,177 @@ func (s *Store) StoreConfig(rendered map[string]any) error {
 		return fmt.Errorf("error while marshalling config file: %w", err)
 	}
 
-	data := map[string]string{
-		"config":   base64.StdEncoding.EncodeToString(x),
-		"rendered": base64.StdEncoding.EncodeToString(renderedYaml),
+	if err := s.applyConfigSecret(x, renderedYaml); err != nil {
+		return err
+	}
+
+	if err := s.storeRevision(x, renderedYaml, distributionVersion(rendered)); err != nil {
+		return fmt.Errorf("error while saving furyctl configuration history: %w", err)
+	}
+
+	return nil
+}
+
+// applyConfigSecret saves the configuration file and the rendered configuration in the furyctl-config
+// secret, encrypted with the key of the store.
+func (s *Store) applyConfigSecret(config, rendered []byte) error {
+	sealedConfig, err := encryption.Seal(s.Key, config)
+	if err != nil {
+		return fmt.Errorf("error while encrypting config file: %w", err)
+	}
+
+	sealedRendered, err := encryption.Seal(s.Key, rendered)
+	if err != nil {
+		return fmt.Errorf("error while encrypting config file: %w", err)
+	}
+
+	b, err := s.backend()
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Saving furyctl configuration file in %s...", b)
+
+	if err := b.Put(backend.Object{
+		Kind: backend.KindSecret,
+		Name: ConfigSecret,
+		Data: map[string]string{
+			"config":   string(sealedConfig),
+			"rendered": string(sealedRendered),
+		},
+	}); err != nil {
+		return fmt.Errorf("error while saving furyctl configuration file: %w", err)
+	}
+
+	return nil
+}
+
+// CheckKey checks that the key of the store decrypts the configuration and all its history.
+func (s *Store) CheckKey() error {
+	if _, err := s.GetConfig(); err != nil {
+		return err
+	}
+
+	if _, err := s.GetRenderedConfig(); err != nil {
+		return err
+	}
+
+	if _, err := s.ListRevisions(); err != nil {
+		return err
+	}
+
+	if _, err := s.GetRunReport(); err != nil && !errors.Is(err, backend.ErrNotFound) {
+		return err
+	}
+
+	return nil
+}
+
+// Reencrypt encrypts again the configuration, its history and the run report stored in the cluster with
+// the new key.
+// The store must have the key the configuration is encrypted with, or none if it is stored in clear.
+func (s *Store) Reencrypt(newKey encryption.Key) error {
+	config, err := s.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	rendered, err := s.GetRenderedConfig()
+	if err != nil {
+		return err
 	}
 
-	secret, err := kubex.CreateSecret("furyctl-config", "kube-system", data)
+	revisions, err := s.ListRevisions()
 	if err != nil {
-		return fmt.Errorf("error while creating secret: %w", err)
+		return err
+	}
+
+	report, err := s.GetRunReport()
+	if err != nil && !errors.Is(err, backend.ErrNotFound) {
+		return err
+	}
+
+	s.Key = newKey
+
+	if err := s.applyConfigSecret(config, rendered); err != nil {
+		return err
+	}
+
+	for _, rev := range revisions {
+		if err := s.applyRevision(rev); err != nil {
+			return err
+		}
 	}
 
-	secretPath := path.Join(s.WorkDir, "secrets.yaml")
+	if report != nil {
+		if err := s.StoreRunReport(report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
-	if err := iox.WriteFile(secretPath, secret); err != nil {
-		return fmt.Errorf("error while writing secret: %w", err)
+// StoreRunReport saves the report of the last run in the cluster, next to the furyctl configuration,
+// encrypted with the key of the store.
+func (s *Store) StoreRunReport(report []byte) error {
+	sealedReport, err := encryption.Seal(s.Key, report)
+	if err != nil {
+		return fmt.Errorf("error while encrypting run report: %w", err)
 	}
 
-	defer os.Remove(secretPath)
+	b, err := s.backend()
+	if err != nil {
+		return err
+	}
 
-	logrus.Info("Saving furyctl configuration file in the cluster...")
+	logrus.Infof("Saving run report in %s...", b)
 
-	if err := s.KubectlRunner.Apply(secretPath); err != nil {
-		return fmt.Errorf("error while saving furyctl configuration file in the cluster: %w", err)
+	if err := b.Put(backend.Object{
+		Kind: backend.KindSecret,
+		Name: RunReportSecret,
+		Data: map[string]string{"report": string(sealedReport)},
+	}); err != nil {
+		return fmt.Errorf("error while saving run report: %w", err)
 	}
 
 	return nil
 }
 
+// GetRunReport returns the report of the last run saved in the cluster.
+func (s *Store) GetRunReport() ([]byte, error) {
+	b, err := s.backend()
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := b.Get(backend.KindSecret, RunReportSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting run report: %w", err)
+	}
+
+	report, err := encryption.Open(s.Key, []byte(obj.Data["report"]))
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting run report: %w", err)
+	}
+
+	return report, nil
+}
+
+func distributionVersion(rendered map[string]any) string {
+	spec, ok := rendered["spec"].(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	version, ok := spec["distributionVersion"].(string)
+	if !ok {
+		return ""
+	}
+
+	return version
+}
+
 func (s *Store) GetConfig() ([]byte, error) {
 	return s.getBaseConfig("config")
 }
@@ -132,31 +290,25 @@ func (s *Store) GetRenderedConfig() ([]byte, error) {
 }
 
 func (s *Store) getBaseConfig(key string) ([]byte, error) {
-	secret := map[string]any{}
-
-	out, err := s.KubectlRunner.Get(true, "kube-system", "secret", "furyctl-config", "-o", "yaml")
+	b, err := s.backend()
 	if err != nil {
-		return nil, fmt.Errorf("error while getting current cluster config: %w", err)
-	}
-
-	if err := yamlx.UnmarshalV3([]byte(out), secret); err != nil {
-		return nil, fmt.Errorf("error while unmarshalling current cluster config: %w", err)
+		return nil, err
 	}
 
-	data, ok := secret["data"].(map[string]any)
-	if !ok {
-		return nil, errSecretDataNotFound
+	obj, err := b.Get(backend.KindSecret, ConfigSecret)
+	if err != nil {
+		return nil, fmt.Errorf("error while getting current cluster config: %w", err)
 	}
 
-	configData, ok := data[key].(string)
+	configData, ok := obj.Data[key]
 	if !ok {
 		return nil, fmt.Errorf("%w: %q", errSecretConfigKeyNotFound, key)
 	}
 
-	decodedConfig, err := base64.StdEncoding.DecodeString(configData)
+	openedConfig, err := encryption.Open(s.Key, []byte(configData))
 	if err != nil {
-		return nil, fmt.Errorf("error while decoding current cluster config: %w", err)
+		return nil, fmt.Errorf("error while decrypting current cluster config: %w", err)
 	}
 
-	return decodedConfig, nil
+	return openedConfig, nil
 }
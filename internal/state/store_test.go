@@ -10,23 +10,26 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
 
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/kubernetes"
 	"github.com/sighupio/furyctl/internal/state"
-	"github.com/sighupio/furyctl/internal/tool/kubectl"
-	execx "github.com/sighupio/furyctl/internal/x/exec"
+	"github.com/sighupio/furyctl/internal/state/backend"
 )
 
 func TestStore_GetConfig(t *testing.T) {
 	t.Parallel()
 
 	store := state.Store{
-		DistroPath:    "",
-		ConfigPath:    "",
-		WorkDir:       "",
-		KubectlRunner: FakeClient(t),
+		KubeClient: FakeClient(t),
 	}
 
 	cfg, err := store.GetConfig()
@@ -37,14 +40,39 @@ func TestStore_GetConfig(t *testing.T) {
 	require.Equal(t, []byte("test string"), cfg)
 }
 
+func TestStore_GetConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	backendConf := backend.Config{Type: backend.TypeLocal, Dir: t.TempDir()}
+
+	key, err := encryption.ParseKey([]byte(strings.Repeat("ab", 32)))
+	require.NoError(t, err)
+
+	otherKey, err := encryption.ParseKey([]byte(strings.Repeat("cd", 32)))
+	require.NoError(t, err)
+
+	store := state.NewStore("", path.Join("test_data", "furyctl.yaml"), key, backendConf)
+
+	// The preflights skip their checks only when the state is missing.
+	_, err = store.GetConfig()
+	require.ErrorIs(t, err, backend.ErrNotFound)
+
+	require.NoError(t, store.StoreConfig(map[string]any{}))
+
+	_, err = state.NewStore("", "", otherKey, backendConf).GetConfig()
+	require.ErrorIs(t, err, encryption.ErrWrongKey)
+	require.NotErrorIs(t, err, backend.ErrNotFound)
+
+	_, err = state.NewStore("", "", nil, backendConf).GetConfig()
+	require.ErrorIs(t, err, encryption.ErrKeyRequired)
+}
+
 func TestStore_StoreConfig(t *testing.T) {
 	t.Parallel()
 
 	store := state.Store{
-		DistroPath:    "",
-		ConfigPath:    path.Join("test_data", "furyctl.yaml"),
-		WorkDir:       "",
-		KubectlRunner: FakeClient(t),
+		ConfigPath: path.Join("test_data", "furyctl.yaml"),
+		KubeClient: FakeClient(t),
 	}
 
 	renderedConfig := map[string]any{}
@@ -52,72 +80,125 @@ func TestStore_StoreConfig(t *testing.T) {
 	if err := store.StoreConfig(renderedConfig); err != nil {
 		t.Errorf("expected no error, got %v", err)
 	}
+
+	want, err := os.ReadFile(path.Join("test_data", "furyctl.yaml"))
+	require.NoError(t, err)
+
+	cfg, err := store.GetConfig()
+	require.NoError(t, err)
+	require.Equal(t, want, cfg)
 }
 
-func TestStore_StoreKFD(t *testing.T) {
+func TestStore_ListRevisions(t *testing.T) {
 	t.Parallel()
 
 	store := state.Store{
-		DistroPath:    path.Join("test_data"),
-		ConfigPath:    "",
-		WorkDir:       path.Join("test_data"),
-		KubectlRunner: FakeClient(t),
+		KubeClient: FakeClient(t),
 	}
 
-	err := store.StoreKFD()
-	if err != nil {
-		t.Errorf("expected no error, got %v", err)
+	revisions, err := store.ListRevisions()
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+
+	require.Equal(t, 1, revisions[0].Number)
+	require.Equal(t, "v1.31.0", revisions[0].DistributionVersion)
+	require.Equal(t, []byte("config 1"), revisions[0].Config)
+	require.Equal(t, []byte("rendered 1"), revisions[0].Rendered)
+
+	require.Equal(t, 2, revisions[1].Number)
+	require.Equal(t, "alice@host", revisions[1].User)
+	require.Equal(t, "furyctl-config-revision-2", revisions[1].SecretName())
+}
+
+func TestStore_GetRevision(t *testing.T) {
+	t.Parallel()
+
+	store := state.Store{
+		KubeClient: FakeClient(t),
 	}
+
+	rev, err := store.GetRevision(2)
+	require.NoError(t, err)
+	require.Equal(t, "v1.31.1", rev.DistributionVersion)
+
+	_, err = store.GetRevision(3)
+	require.ErrorIs(t, err, state.ErrRevisionNotFound)
 }
 
-func TestHelperProcess(t *testing.T) {
+func TestStore_StoreRunReport(t *testing.T) {
 	t.Parallel()
 
-	t.Helper()
+	backendConf := backend.Config{Type: backend.TypeLocal, Dir: t.TempDir()}
 
-	args := os.Args
+	key, err := encryption.ParseKey([]byte(strings.Repeat("ab", 32)))
+	require.NoError(t, err)
 
-	if len(args) < 3 || args[1] != "-test.run=TestHelperProcess" {
-		return
-	}
+	store := state.NewStore("", "", key, backendConf)
+
+	report := []byte(`{"command":"apply","status":"success"}`)
 
-	cmd, subcmd := args[3], args[4]
+	require.NoError(t, store.StoreRunReport(report))
 
-	switch cmd {
-	case "kubectl":
-		switch subcmd {
-		case "apply":
-			fmt.Fprintf(os.Stdout, "")
+	b, err := backend.New(backendConf, nil)
+	require.NoError(t, err)
 
-		case "get":
-			resType := args[7]
-			if args[5] == "-A" {
-				resType = args[6]
-			}
+	obj, err := b.Get(backend.KindSecret, state.RunReportSecret)
+	require.NoError(t, err)
+	require.True(t, encryption.IsSealed([]byte(obj.Data["report"])))
 
-			if resType == "secret" {
-				fmt.Fprintf(os.Stdout,
-					"apiVersion: v1\ndata:\n config: dGVzdCBzdHJpbmc=\nkind: Secret\nmetadata:\n name: secret-1\n type: Opaque\n namespace: kube-system")
-			}
-		}
+	got, err := store.GetRunReport()
+	require.NoError(t, err)
+	require.Equal(t, report, got)
+}
 
-	default:
-		fmt.Fprintf(os.Stdout, "command not found")
+func TestStore_StoreKFD(t *testing.T) {
+	t.Parallel()
+
+	store := state.Store{
+		DistroPath: path.Join("test_data"),
+		KubeClient: FakeClient(t),
 	}
 
-	os.Exit(0)
+	err := store.StoreKFD()
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
 }
 
-func FakeClient(t *testing.T) *kubectl.Runner {
+// FakeClient returns a client for a cluster that has the furyctl-config secret and the secrets of the
+// configuration history: revision 1 and revision 2.
+func FakeClient(t *testing.T) *kubernetes.Client {
 	t.Helper()
 
-	return kubectl.NewRunner(
-		execx.NewFakeExecutor("TestHelperProcess"),
-		kubectl.Paths{
-			Kubectl: "kubectl",
+	objs := []runtime.Object{
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: state.ConfigSecret, Namespace: "kube-system"},
+			Type:       corev1.SecretTypeOpaque,
+			Data:       map[string][]byte{"config": []byte("test string")},
 		},
-		true,
-		true,
-		true,
-	)
+	}
+
+	for rev, distributionVersion := range []string{"v1.31.0", "v1.31.1"} {
+		number := rev + 1
+
+		objs = append(objs, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("furyctl-config-revision-%d", number),
+				Namespace: "kube-system",
+				Labels:    map[string]string{"furyctl.sighup.io/config-revision": fmt.Sprint(number)},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				"config":   []byte(fmt.Sprintf("config %d", number)),
+				"rendered": []byte(fmt.Sprintf("rendered %d", number)),
+				"metadata": []byte(fmt.Sprintf(
+					`{"revision": %d, "appliedAt": "2026-10-0%dT10:00:00Z", "furyctlVersion": "v0.33.0", `+
+						`"distributionVersion": %q, "user": "alice@host", "host": "host"}`,
+					number, number, distributionVersion,
+				)),
+			},
+		})
+	}
+
+	return kubernetes.NewClientWithInterfaces(fake.NewSimpleClientset(objs...), nil, nil)
 }
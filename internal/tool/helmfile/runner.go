@@ -5,6 +5,7 @@
 package helmfile
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"os"
@@ -98,6 +99,38 @@ func (r *Runner) Apply() error {
 	return nil
 }
 
+// Template returns the manifests of the releases, as helmfile apply would install them. The manifests can
+// hold secrets, so they are not logged.
+func (r *Runner) Template() (string, error) {
+	cmd, id := r.newCmd([]string{"--quiet", "template", "--skip-tests"})
+	defer r.deleteCmd(id)
+
+	var stdout, stderr bytes.Buffer
+
+	cmd.Sensitive = true
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error running helmfile template: %w: %s", err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+func (r *Runner) Destroy() error {
+	args := []string{"destroy"}
+
+	cmd, id := r.newCmd(args)
+	defer r.deleteCmd(id)
+
+	if _, err := execx.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("error running helmfile destroy: %w", err)
+	}
+
+	return nil
+}
+
 func (r *Runner) Version() (string, error) {
 	cmd, id := r.newCmd([]string{"version", "-o=short"})
 	defer r.deleteCmd(id)
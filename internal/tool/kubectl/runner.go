@@ -6,6 +6,8 @@ package kubectl
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/google/uuid"
 
@@ -37,6 +39,16 @@ func NewRunner(executor execx.Executor, paths Paths, serverSide, skipNotFound, c
 	}
 }
 
+// FindBin returns the kubectl that furyctl downloaded in the bin folder, or the one in PATH.
+func FindBin(binPath string) string {
+	matches, err := filepath.Glob(filepath.Join(binPath, "kubectl", "*", "kubectl"))
+	if err == nil && len(matches) > 0 {
+		return matches[len(matches)-1]
+	}
+
+	return "kubectl"
+}
+
 func (r *Runner) CmdPath() string {
 	return r.paths.Kubectl
 }
@@ -64,6 +76,50 @@ func (r *Runner) Apply(manifestPath string, params ...string) error {
 	return nil
 }
 
+// Create creates the resources in the manifest. Unlike Apply, it fails when a resource already exists.
+func (r *Runner) Create(manifestPath string, params ...string) error {
+	args := []string{"create"}
+
+	if len(params) > 0 {
+		args = append(args, params...)
+	}
+
+	args = append(args, "-f", manifestPath)
+
+	cmd, id := r.newCmd(args, false)
+	defer r.deleteCmd(id)
+
+	if _, err := execx.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("error creating manifests: %w", err)
+	}
+
+	return nil
+}
+
+// Diff returns the unified diff between the objects in the cluster and the ones in the manifest. kubectl
+// exits with 1 when it finds differences, that is not an error here.
+func (r *Runner) Diff(manifestPath string, params ...string) (string, error) {
+	args := []string{"diff"}
+
+	if len(params) > 0 {
+		args = append(args, params...)
+	}
+
+	args = append(args, "-f", manifestPath)
+
+	cmd, id := r.newCmd(args, false)
+	defer r.deleteCmd(id)
+
+	// The callers parse the output of diff -u, whatever the user configured.
+	cmd.Env = append(os.Environ(), "KUBECTL_EXTERNAL_DIFF=diff -u -N")
+
+	if err := cmd.Run(); err != nil && (cmd.ProcessState == nil || cmd.ProcessState.ExitCode() != 1) {
+		return cmd.Log.Out.String(), fmt.Errorf("error diffing manifests: %w", err)
+	}
+
+	return cmd.Log.Out.String(), nil
+}
+
 func (r *Runner) Get(sensitive bool, ns string, params ...string) (string, error) {
 	args := []string{"get"}
 
@@ -107,6 +163,20 @@ func (r *Runner) Delete(params ...string) error {
 	return nil
 }
 
+// Patch updates the fields of a resource in the cluster.
+func (r *Runner) Patch(params ...string) error {
+	args := append([]string{"patch"}, params...)
+
+	cmd, id := r.newCmd(args, false)
+	defer r.deleteCmd(id)
+
+	if _, err := execx.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("error patching resource: %w", err)
+	}
+
+	return nil
+}
+
 func (r *Runner) Version() (string, error) {
 	args := []string{"version"}
 
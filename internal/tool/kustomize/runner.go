@@ -49,6 +49,19 @@ func (r *Runner) Version() (string, error) {
 	return out, nil
 }
 
+func (r *Runner) Build(kustomizationPath, outPath string) error {
+	args := []string{"build", "--load-restrictor", "LoadRestrictionsNone", "-o", outPath, kustomizationPath}
+
+	cmd, id := r.newCmd(args)
+	defer r.deleteCmd(id)
+
+	if _, err := execx.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("error running kustomize build: %w", err)
+	}
+
+	return nil
+}
+
 func (r *Runner) Stop() error {
 	for _, cmd := range r.cmds {
 		if err := cmd.Stop(); err != nil {
@@ -68,9 +68,9 @@ func DownloadURL(goos, goarch string) (string, error) {
 		return "", err
 	}
 
-	sum, ok := binChecksums[goos+"/"+goarch]
-	if !ok {
-		return "", fmt.Errorf("%w: %s/%s", ErrUnsupportedPlatform, goos, goarch)
+	sum, err := Checksum(goos, goarch)
+	if err != nil {
+		return "", err
 	}
 
 	return fmt.Sprintf(
@@ -79,6 +79,16 @@ func DownloadURL(goos, goarch string) (string, error) {
 	), nil
 }
 
+// Checksum returns the pinned SHA-256 of the mise binary for the platform.
+func Checksum(goos, goarch string) (string, error) {
+	sum, ok := binChecksums[goos+"/"+goarch]
+	if !ok {
+		return "", fmt.Errorf("%w: %s/%s", ErrUnsupportedPlatform, goos, goarch)
+	}
+
+	return sum, nil
+}
+
 // Downloader is the minimal client EnsureBinary needs to fetch the mise binary.
 type Downloader interface {
 	Download(src, dst string) error
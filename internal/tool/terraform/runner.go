@@ -13,10 +13,14 @@ import (
 	"github.com/google/uuid"
 	tfjson "github.com/hashicorp/terraform-json"
 
+	"github.com/sighupio/furyctl/internal/redact"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
 	iox "github.com/sighupio/furyctl/internal/x/io"
 )
 
+// planFile is where Plan saves the plan and where Apply reads it, relative to the working directory.
+const planFile = "plan/terraform.plan"
+
 type OutputJSON map[string]*tfjson.StateOutput
 
 type Paths struct {
@@ -45,6 +49,11 @@ func (r *Runner) CmdPath() string {
 	return r.paths.Terraform
 }
 
+// PlanFile returns the path of the plan that Plan saves and that Apply applies.
+func (r *Runner) PlanFile() string {
+	return path.Join(r.paths.WorkDir, planFile)
+}
+
 func (r *Runner) Init() error {
 	args := []string{"init", "-upgrade"}
 
@@ -70,7 +79,7 @@ func (r *Runner) Plan(timestamp int64, params ...string) ([]byte, error) {
 		args = append(args, params...)
 	}
 
-	args = append(args, "-no-color", "-out", "plan/terraform.plan")
+	args = append(args, "-no-color", "-out", planFile)
 
 	cmd, id := r.newCmd(args)
 	defer r.deleteCmd(id)
@@ -92,8 +101,39 @@ func (r *Runner) Plan(timestamp int64, params ...string) ([]byte, error) {
 	return out, nil
 }
 
+// ShowPlan returns the plan that Plan saved in the JSON format of `terraform show -json`, with the values
+// that Terraform marks as sensitive and the secrets of the dynamic values masked. The JSON has the values
+// of every resource, so it is not logged.
+func (r *Runner) ShowPlan(timestamp int64) ([]byte, error) {
+	cmd, id := r.newCmd([]string{"show", "-no-color", "-json", planFile})
+	defer r.deleteCmd(id)
+
+	cmd.Stdout = cmd.Log.Out
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("command execution failed: %w", err)
+	}
+
+	masked, err := maskSensitive(cmd.Log.Out.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	out := []byte(redact.String(string(masked)))
+
+	if err := os.WriteFile(
+		path.Join(r.paths.Plan, fmt.Sprintf("plan-%d.json", timestamp)),
+		out,
+		iox.FullRWPermAccess,
+	); err != nil {
+		return nil, fmt.Errorf("error writing terraform plan json: %w", err)
+	}
+
+	return out, nil
+}
+
 func (r *Runner) Apply(timestamp int64) error {
-	cmd, applyID := r.newCmd([]string{"apply", "-no-color", "-json", "plan/terraform.plan"})
+	cmd, applyID := r.newCmd([]string{"apply", "-no-color", "-json", planFile})
 	defer r.deleteCmd(applyID)
 
 	if err := cmd.Run(); err != nil {
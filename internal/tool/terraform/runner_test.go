@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/sighupio/furyctl/internal/redact"
 	"github.com/sighupio/furyctl/internal/test"
 	"github.com/sighupio/furyctl/internal/tool/terraform"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
@@ -49,6 +50,58 @@ func Test_Runner_Plan(t *testing.T) {
 	assert.NotZero(t, info.Size(), "expected file to be not empty")
 }
 
+func Test_Runner_ShowPlan(t *testing.T) {
+	paths := terraform.Paths{
+		Terraform: "terraform",
+		WorkDir:   test.MkdirTemp(t),
+		Logs:      test.MkdirTemp(t),
+		Outputs:   test.MkdirTemp(t),
+		Plan:      test.MkdirTemp(t),
+	}
+
+	r := terraform.NewRunner(execx.NewFakeExecutor("TestHelperProcess"), paths)
+
+	got, err := r.ShowPlan(42)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{"format_version":"1.2","resource_changes":[{"address":"aws_db_instance.main",`+
+		`"change":{"actions":["update"],"before":{"name":"db","password":"<redacted>"},`+
+		`"after":{"name":"db","password":"<redacted>"},`+
+		`"before_sensitive":{"password":true},"after_sensitive":{"password":true}}}],`+
+		`"planned_values":{"outputs":{"token":{"sensitive":true,"value":"<redacted>"}}}}`, string(got))
+
+	out, err := os.ReadFile(filepath.Join(paths.Plan, "plan-42.json"))
+	require.NoError(t, err)
+	assert.Equal(t, got, out)
+}
+
+func Test_Runner_ShowPlanRedacts(t *testing.T) {
+	t.Cleanup(redact.Reset)
+
+	paths := terraform.Paths{
+		Terraform: "terraform",
+		WorkDir:   test.MkdirTemp(t),
+		Logs:      test.MkdirTemp(t),
+		Outputs:   test.MkdirTemp(t),
+		Plan:      test.MkdirTemp(t),
+	}
+
+	redact.Register("resource_changes")
+
+	r := terraform.NewRunner(execx.NewFakeExecutor("TestHelperProcess"), paths)
+
+	got, err := r.ShowPlan(42)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(got), "resource_changes")
+	assert.Contains(t, string(got), `"<redacted>":[`)
+
+	out, err := os.ReadFile(filepath.Join(paths.Plan, "plan-42.json"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "resource_changes")
+	assert.NotContains(t, string(out), "new-password")
+}
+
 func Test_Runner_Apply(t *testing.T) {
 	paths := terraform.Paths{
 		Terraform: "terraform",
@@ -120,6 +173,12 @@ func TestHelperProcess(t *testing.T) {
 			fmt.Fprintf(os.Stdout, "initialized")
 		case "plan":
 			fmt.Fprintf(os.Stdout, "planned")
+		case "show":
+			fmt.Fprint(os.Stdout, `{"format_version":"1.2","resource_changes":[{"address":"aws_db_instance.main",`+
+				`"change":{"actions":["update"],"before":{"name":"db","password":"old-password"},`+
+				`"after":{"name":"db","password":"new-password"},`+
+				`"before_sensitive":{"password":true},"after_sensitive":{"password":true}}}],`+
+				`"planned_values":{"outputs":{"token":{"sensitive":true,"value":"plan-token"}}}}`)
 		case "apply":
 			fmt.Fprintf(os.Stdout, `{"outputs":{"foo":{"sensitive":false,"value":"bar"}}}`)
 		case "version":
@@ -0,0 +1,142 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terraform
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/sighupio/furyctl/internal/redact"
+)
+
+// sensitivePairs are the fields of the JSON plan that hold values, with the field that tells which of
+// their attributes Terraform marks as sensitive.
+//
+//nolint:gochecknoglobals // The pairs are the ones of the JSON format of terraform show.
+var sensitivePairs = [][2]string{
+	{"before", "before_sensitive"},
+	{"after", "after_sensitive"},
+	{"values", "sensitive_values"},
+}
+
+// maskSensitive masks in a plan in the JSON format of `terraform show -json` the values that Terraform
+// marks as sensitive: the attributes of the resources, the outputs and the variables.
+func maskSensitive(plan []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(plan))
+	dec.UseNumber()
+
+	var root any
+
+	if err := dec.Decode(&root); err != nil {
+		return nil, fmt.Errorf("error while parsing terraform plan json: %w", err)
+	}
+
+	maskVariables(root)
+	maskNode(root)
+
+	var out bytes.Buffer
+
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(root); err != nil {
+		return nil, fmt.Errorf("error while encoding terraform plan json: %w", err)
+	}
+
+	return bytes.TrimSuffix(out.Bytes(), []byte("\n")), nil
+}
+
+// maskNode masks the values of the node that the sibling fields mark as sensitive, then walks its children.
+func maskNode(node any) {
+	switch n := node.(type) {
+	case map[string]any:
+		for _, pair := range sensitivePairs {
+			value, ok := n[pair[0]]
+			if !ok {
+				continue
+			}
+
+			if sensitive, ok := n[pair[1]]; ok {
+				n[pair[0]] = maskValue(value, sensitive)
+			}
+		}
+
+		// The outputs of the planned values and of the prior state.
+		if sensitive, ok := n["sensitive"].(bool); ok && sensitive {
+			if _, ok := n["value"]; ok {
+				n["value"] = redact.Mask
+			}
+		}
+
+		for _, child := range n {
+			maskNode(child)
+		}
+
+	case []any:
+		for _, child := range n {
+			maskNode(child)
+		}
+	}
+}
+
+// maskValue masks the parts of the value that are true in sensitive, a tree with the shape of the value.
+func maskValue(value, sensitive any) any {
+	switch s := sensitive.(type) {
+	case bool:
+		if s && value != nil {
+			return redact.Mask
+		}
+
+	case map[string]any:
+		if v, ok := value.(map[string]any); ok {
+			for k, sv := range s {
+				if child, ok := v[k]; ok {
+					v[k] = maskValue(child, sv)
+				}
+			}
+		}
+
+	case []any:
+		if v, ok := value.([]any); ok {
+			for i := range min(len(s), len(v)) {
+				v[i] = maskValue(v[i], s[i])
+			}
+		}
+	}
+
+	return value
+}
+
+// maskVariables masks the values of the root module variables that the configuration declares sensitive.
+func maskVariables(root any) {
+	r, ok := root.(map[string]any)
+	if !ok {
+		return
+	}
+
+	variables, ok := r["variables"].(map[string]any)
+	if !ok {
+		return
+	}
+
+	configuration, _ := r["configuration"].(map[string]any)
+	rootModule, _ := configuration["root_module"].(map[string]any)
+	declared, _ := rootModule["variables"].(map[string]any)
+
+	for name, variable := range variables {
+		decl, _ := declared[name].(map[string]any)
+
+		if sensitive, _ := decl["sensitive"].(bool); !sensitive {
+			continue
+		}
+
+		if v, ok := variable.(map[string]any); ok {
+			if _, ok := v["value"]; ok {
+				v["value"] = redact.Mask
+			}
+		}
+	}
+}
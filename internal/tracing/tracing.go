@@ -0,0 +1,276 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tracing exports the runs of furyctl to an OpenTelemetry collector: the command is the root
+// span, the phases and sub-phases are its children and the tool commands they run are the leaves.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/sighupio/furyctl/internal/redact"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+)
+
+const (
+	ServiceName = "furyctl"
+
+	// DefaultURLPath is the path of the OTLP/HTTP traces endpoint, used when the endpoint has no path.
+	DefaultURLPath = "/v1/traces"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+var (
+	ErrInvalidEndpoint = errors.New("invalid OTLP endpoint")
+
+	//nolint:gochecknoglobals // The phases and the commands add their spans to the trace of the running command.
+	current *tracer
+	//nolint:gochecknoglobals // The lock guards the running trace.
+	currentMu sync.Mutex
+)
+
+// Config tells where the spans go. Without an endpoint, the standard OTEL_EXPORTER_OTLP_ENDPOINT and
+// OTEL_EXPORTER_OTLP_TRACES_ENDPOINT environment variables enable the export.
+type Config struct {
+	Endpoint string
+	Insecure bool
+}
+
+// Enabled tells whether the configuration asks to export the spans.
+func (c Config) Enabled() bool {
+	return c.Endpoint != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != "" ||
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") != ""
+}
+
+type tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	root     trace.Span
+
+	// The contexts of the running spans, the innermost last.
+	spans []context.Context
+
+	mu sync.Mutex
+}
+
+// Start sets up the exporter and starts the root span of the command. It does nothing when the
+// configuration does not enable the export.
+func Start(cfg Config, command, version string) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	opts, err := exporterOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return fmt.Errorf("error while creating OTLP exporter: %w", err)
+	}
+
+	res := resource.NewSchemaless(
+		attribute.String("service.name", ServiceName),
+		attribute.String("service.version", version),
+	)
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	// The export must not add noise to the output of furyctl.
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logrus.Debugf("error while exporting traces: %v", err)
+	}))
+
+	t := &tracer{
+		provider: provider,
+		tracer:   provider.Tracer(ServiceName),
+	}
+
+	ctx, root := t.tracer.Start(context.Background(), command, trace.WithSpanKind(trace.SpanKindInternal))
+
+	t.root = root
+	t.spans = []context.Context{ctx}
+
+	currentMu.Lock()
+	defer currentMu.Unlock()
+
+	current = t
+
+	execx.AddRecorder(t)
+
+	return nil
+}
+
+// Stop ends the root span with the outcome of the command and sends the spans to the collector.
+func Stop(err error) {
+	currentMu.Lock()
+	t := current
+	current = nil
+	currentMu.Unlock()
+
+	if t == nil {
+		return
+	}
+
+	execx.RemoveRecorder(t)
+
+	t.mu.Lock()
+
+	// The phases still running end with the command.
+	for i := len(t.spans) - 1; i > 0; i-- {
+		endSpan(trace.SpanFromContext(t.spans[i]), err)
+	}
+
+	t.spans = nil
+
+	t.mu.Unlock()
+
+	endSpan(t.root, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := t.provider.Shutdown(ctx); err != nil {
+		logrus.Debugf("error while sending traces: %v", err)
+	}
+}
+
+// StartPhase starts the span of a phase, as a child of the running phase or of the command. The
+// returned function ends it.
+func StartPhase(name string) func(err error) {
+	currentMu.Lock()
+	t := current
+	currentMu.Unlock()
+
+	if t == nil {
+		return func(error) {}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.spans) == 0 {
+		return func(error) {}
+	}
+
+	ctx, span := t.tracer.Start(
+		t.spans[len(t.spans)-1],
+		name,
+		trace.WithAttributes(attribute.String("furyctl.phase", name)),
+	)
+
+	t.spans = append(t.spans, ctx)
+
+	return func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		for i := len(t.spans) - 1; i > 0; i-- {
+			if t.spans[i] == ctx {
+				t.spans = t.spans[:i]
+
+				endSpan(span, err)
+
+				return
+			}
+		}
+	}
+}
+
+// RecordCmd adds a span for a command that ran, as a child of the running phase.
+func (t *tracer) RecordCmd(rec execx.CmdRecord) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.spans) == 0 {
+		return
+	}
+
+	_, span := t.tracer.Start(
+		t.spans[len(t.spans)-1],
+		filepath.Base(rec.Name),
+		trace.WithTimestamp(rec.StartedAt),
+		trace.WithAttributes(
+			attribute.String("process.executable.path", rec.Name),
+			attribute.StringSlice("process.command_args", rec.Args),
+			attribute.Int("process.exit.code", rec.ExitCode),
+			attribute.String("process.working_directory", rec.WorkDir),
+		),
+	)
+
+	if rec.ExitCode != 0 || rec.Error != "" {
+		span.SetStatus(codes.Error, rec.Error)
+	}
+
+	span.End(trace.WithTimestamp(rec.EndedAt))
+}
+
+// endSpan ends the span, with the error that stopped it. The error can hold the output of the commands, so
+// it is exported with the sensitive values masked.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		msg := redact.String(err.Error())
+
+		span.RecordError(errors.New(msg))
+		span.SetStatus(codes.Error, msg)
+	}
+
+	span.End()
+}
+
+func exporterOptions(cfg Config) ([]otlptracehttp.Option, error) {
+	opts := []otlptracehttp.Option{}
+
+	if cfg.Endpoint != "" {
+		scheme := "https://"
+		if cfg.Insecure {
+			scheme = "http://"
+		}
+
+		endpoint := cfg.Endpoint
+		if !strings.Contains(endpoint, "://") {
+			endpoint = scheme + endpoint
+		}
+
+		u, err := url.Parse(endpoint)
+		if err != nil || u.Host == "" {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidEndpoint, cfg.Endpoint)
+		}
+
+		if u.Path == "" || u.Path == "/" {
+			u.Path = DefaultURLPath
+		}
+
+		opts = append(opts, otlptracehttp.WithEndpointURL(u.String()))
+	}
+
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	return opts, nil
+}
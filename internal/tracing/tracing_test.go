@@ -0,0 +1,181 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package tracing_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/sighupio/furyctl/internal/tracing"
+	execx "github.com/sighupio/furyctl/internal/x/exec"
+)
+
+var errTest = errors.New("test error")
+
+// collector is a stand-in for an OTLP/HTTP collector, that keeps the spans it receives.
+type collector struct {
+	mu    sync.Mutex
+	paths []string
+	spans []*tracepb.Span
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	req := &coltracepb.ExportTraceServiceRequest{}
+	if err := proto.Unmarshal(body, req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.paths = append(c.paths, r.URL.Path)
+
+	for _, rs := range req.GetResourceSpans() {
+		for _, ss := range rs.GetScopeSpans() {
+			c.spans = append(c.spans, ss.GetSpans()...)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *collector) span(t *testing.T, name string) *tracepb.Span {
+	t.Helper()
+
+	for _, s := range c.spans {
+		if s.GetName() == name {
+			return s
+		}
+	}
+
+	t.Fatalf("span %q not found", name)
+
+	return nil
+}
+
+func attr(s *tracepb.Span, key string) any {
+	for _, kv := range s.GetAttributes() {
+		if kv.GetKey() != key {
+			continue
+		}
+
+		switch v := kv.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			return v.StringValue
+
+		case *commonpb.AnyValue_IntValue:
+			return v.IntValue
+
+		case *commonpb.AnyValue_ArrayValue:
+			values := []string{}
+			for _, av := range v.ArrayValue.GetValues() {
+				values = append(values, av.GetStringValue())
+			}
+
+			return values
+		}
+	}
+
+	return nil
+}
+
+func TestConfig_Enabled(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+
+	assert.False(t, tracing.Config{}.Enabled())
+	assert.True(t, tracing.Config{Endpoint: "localhost:4318"}.Enabled())
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://localhost:4318")
+
+	assert.True(t, tracing.Config{}.Enabled())
+}
+
+func TestStart_InvalidEndpoint(t *testing.T) {
+	t.Parallel()
+
+	err := tracing.Start(tracing.Config{Endpoint: "http://"}, "furyctl apply", "v0.0.0")
+	require.ErrorIs(t, err, tracing.ErrInvalidEndpoint)
+}
+
+//nolint:paralleltest // The running trace is global.
+func TestTrace(t *testing.T) {
+	c := &collector{}
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	require.NoError(t, tracing.Start(tracing.Config{Endpoint: srv.URL, Insecure: true}, "furyctl apply", "v0.0.0"))
+
+	endDistribution := tracing.StartPhase("distribution")
+	endPreDistribution := tracing.StartPhase("pre-distribution")
+
+	require.NoError(t, execx.NewCmd("true", execx.CmdOptions{Args: []string{"apply", "-f", "x"}}).Run())
+
+	endPreDistribution(nil)
+
+	require.Error(t, execx.NewCmd("false", execx.CmdOptions{Args: []string{"token"}, Sensitive: true}).Run())
+
+	endDistribution(errTest)
+
+	tracing.Stop(errTest)
+
+	// A command that runs after the trace is not exported.
+	require.NoError(t, execx.NewCmd("echo", execx.CmdOptions{}).Run())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	require.NotEmpty(t, c.paths)
+	assert.Equal(t, tracing.DefaultURLPath, c.paths[0])
+	assert.Len(t, c.spans, 5)
+
+	root := c.span(t, "furyctl apply")
+	distribution := c.span(t, "distribution")
+	preDistribution := c.span(t, "pre-distribution")
+	trueCmd := c.span(t, "true")
+	falseCmd := c.span(t, "false")
+
+	assert.Empty(t, root.GetParentSpanId())
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, root.GetStatus().GetCode())
+
+	assert.Equal(t, root.GetSpanId(), distribution.GetParentSpanId())
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, distribution.GetStatus().GetCode())
+
+	assert.Equal(t, distribution.GetSpanId(), preDistribution.GetParentSpanId())
+	assert.NotEqual(t, tracepb.Status_STATUS_CODE_ERROR, preDistribution.GetStatus().GetCode())
+
+	assert.Equal(t, preDistribution.GetSpanId(), trueCmd.GetParentSpanId())
+	assert.Equal(t, []string{"apply", "-f", "x"}, attr(trueCmd, "process.command_args"))
+	assert.Equal(t, int64(0), attr(trueCmd, "process.exit.code"))
+
+	assert.Equal(t, distribution.GetSpanId(), falseCmd.GetParentSpanId())
+	assert.Equal(t, []string{"<redacted>"}, attr(falseCmd, "process.command_args"))
+	assert.Equal(t, int64(1), attr(falseCmd, "process.exit.code"))
+	assert.Equal(t, tracepb.Status_STATUS_CODE_ERROR, falseCmd.GetStatus().GetCode())
+}
@@ -7,29 +7,28 @@ package upgrade
 import (
 	"errors"
 	"fmt"
-	"os"
-	"path"
 	"reflect"
 
 	"github.com/sirupsen/logrus"
 
 	"github.com/sighupio/furyctl/internal/cluster"
-	"github.com/sighupio/furyctl/internal/tool/kubectl"
-	execx "github.com/sighupio/furyctl/internal/x/exec"
-	iox "github.com/sighupio/furyctl/internal/x/io"
-	kubex "github.com/sighupio/furyctl/internal/x/kube"
+	"github.com/sighupio/furyctl/internal/encryption"
+	"github.com/sighupio/furyctl/internal/kubernetes"
+	"github.com/sighupio/furyctl/internal/state/backend"
 	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
 )
 
-var (
-	errStateDataNotFound = errors.New("upgrade state data not found")
-	errStateKeyNotFound  = errors.New("upgrade state key not found")
-)
+// StateConfigMap is the name of the config map that holds the upgrade state.
+const StateConfigMap = "furyctl-upgrade-state"
+
+var errStateKeyNotFound = errors.New("upgrade state key not found")
 
 type PhaseStatus string
 
 type Phase struct {
 	Status PhaseStatus `yaml:"status"`
+	// Nodes tracks the progress of phases that roll through the nodes one at a time, keyed by hostname.
+	Nodes map[string]PhaseStatus `yaml:"nodes,omitempty"`
 }
 
 type Phases struct {
@@ -48,6 +47,18 @@ type State struct {
 	Phases Phases `yaml:"phases"`
 }
 
+// Merge sets the phases that other has, and keeps the other phases of the state.
+func (s *State) Merge(other *State) {
+	dst := reflect.ValueOf(&s.Phases).Elem()
+	src := reflect.ValueOf(other.Phases)
+
+	for i := range src.NumField() {
+		if !src.Field(i).IsNil() {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
 type Storer interface {
 	Store(state *State) error
 	Get() ([]byte, error)
@@ -56,8 +67,18 @@ type Storer interface {
 }
 
 type StateStore struct {
-	WorkDir       string
-	KubectlRunner *kubectl.Runner
+	// KubeClient reaches the cluster for the cluster backend, without it the store uses the kubeconfig in the
+	// KUBECONFIG environment variable.
+	KubeClient *kubernetes.Client
+
+	// Key encrypts the upgrade state stored in the cluster, without it the state is stored in clear.
+	Key encryption.Key
+
+	// BackendConfig selects the backend that keeps the upgrade state, by default the cluster.
+	BackendConfig backend.Config
+
+	// Backend keeps the upgrade state, without it the store creates the backend of BackendConfig.
+	Backend backend.Backend
 }
 
 const (
@@ -66,15 +87,10 @@ const (
 	PhaseStatusPending PhaseStatus = "pending"
 )
 
-func NewStateStore(workDir, kubectlVersion, binPath string) *StateStore {
-	runner := kubectl.NewRunner(execx.NewStdExecutor(), kubectl.Paths{
-		Kubectl: path.Join(binPath, "kubectl", kubectlVersion, "kubectl"),
-		WorkDir: workDir,
-	}, true, true, false)
-
+func NewStateStore(key encryption.Key, backendConf backend.Config) *StateStore {
 	return &StateStore{
-		WorkDir:       workDir,
-		KubectlRunner: runner,
+		Key:           key,
+		BackendConfig: backendConf,
 	}
 }
 
@@ -84,55 +100,115 @@ func (s *StateStore) Store(state *State) error {
 		return fmt.Errorf("error while marshalling upgrade state: %w", err)
 	}
 
-	configMap, err := kubex.CreateConfigMap(x, "furyctl-upgrade-state", "state", "kube-system")
+	return s.apply(x)
+}
+
+// apply saves the upgrade state in the state backend, encrypted with the key of the store.
+func (s *StateStore) apply(x []byte) error {
+	x, err := encryption.Seal(s.Key, x)
+	if err != nil {
+		return fmt.Errorf("error while encrypting upgrade state: %w", err)
+	}
+
+	b, err := s.backend()
 	if err != nil {
-		return fmt.Errorf("error while creating configMap: %w", err)
+		return err
 	}
 
-	cmPath := path.Join(s.WorkDir, "furyctl-upgrade-state.yaml")
+	logrus.Infof("Saving furyctl upgrade state file in %s...", b)
 
-	if err := iox.WriteFile(cmPath, configMap); err != nil {
-		return fmt.Errorf("error while writing secret: %w", err)
+	if err := b.Put(backend.Object{
+		Kind: backend.KindConfigMap,
+		Name: StateConfigMap,
+		Data: map[string]string{"state": string(x)},
+	}); err != nil {
+		return fmt.Errorf("error while saving furyctl upgrade state file: %w", err)
 	}
 
-	defer os.Remove(cmPath)
+	return nil
+}
+
+func (s *StateStore) Get() ([]byte, error) {
+	state, err := s.get()
+	if err != nil {
+		return nil, fmt.Errorf("error while getting current cluster upgrade state: %w", err)
+	}
 
-	logrus.Info("Saving furyctl upgrade state file in the cluster...")
+	return state, nil
+}
 
-	if err := s.KubectlRunner.Apply(cmPath, "--force-conflicts"); err != nil {
-		return fmt.Errorf("error while saving furyctl upgrade state file in the cluster: %w", err)
+// CheckKey checks that the key of the store decrypts the upgrade state, when there is one.
+func (s *StateStore) CheckKey() error {
+	if _, err := s.get(); err != nil && !errors.Is(err, backend.ErrNotFound) {
+		return fmt.Errorf("error while getting current cluster upgrade state: %w", err)
 	}
 
 	return nil
 }
 
-func (s *StateStore) Get() ([]byte, error) {
-	configMap := map[string]any{}
+// Reencrypt encrypts again the upgrade state stored in the state backend with the new key, if an upgrade
+// left one. The store must have the key the state is encrypted with, or none if it is stored in clear.
+func (s *StateStore) Reencrypt(newKey encryption.Key) error {
+	state, err := s.get()
+	if errors.Is(err, backend.ErrNotFound) {
+		return nil
+	}
 
-	out, err := s.KubectlRunner.Get(true, "kube-system", "cm", "furyctl-upgrade-state", "-o", "yaml")
 	if err != nil {
-		return nil, fmt.Errorf("error while getting current cluster upgrade state: %w", err)
+		return fmt.Errorf("error while getting current cluster upgrade state: %w", err)
 	}
 
-	if err := yamlx.UnmarshalV3([]byte(out), configMap); err != nil {
-		return nil, fmt.Errorf("error while unmarshalling current cluster upgrade state: %w", err)
+	s.Key = newKey
+
+	return s.apply(state)
+}
+
+func (s *StateStore) get() ([]byte, error) {
+	b, err := s.backend()
+	if err != nil {
+		return nil, err
 	}
 
-	data, ok := configMap["data"].(map[string]any)
-	if !ok {
-		return nil, errStateDataNotFound
+	obj, err := b.Get(backend.KindConfigMap, StateConfigMap)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading upgrade state: %w", err)
 	}
 
-	configData, ok := data["state"].(string)
+	configData, ok := obj.Data["state"]
 	if !ok {
 		return nil, errStateKeyNotFound
 	}
 
-	return []byte(configData), nil
+	state, err := encryption.Open(s.Key, []byte(configData))
+	if err != nil {
+		return nil, fmt.Errorf("error while decrypting current cluster upgrade state: %w", err)
+	}
+
+	return state, nil
+}
+
+func (s *StateStore) backend() (backend.Backend, error) {
+	if s.Backend != nil {
+		return s.Backend, nil
+	}
+
+	b, err := backend.New(s.BackendConfig, s.KubeClient)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating state backend: %w", err)
+	}
+
+	s.Backend = b
+
+	return b, nil
 }
 
 func (s *StateStore) Delete() error {
-	if err := s.KubectlRunner.Delete("configmap", "furyctl-upgrade-state", "-n", "kube-system"); err != nil {
+	b, err := s.backend()
+	if err != nil {
+		return err
+	}
+
+	if err := b.Delete(backend.KindConfigMap, StateConfigMap); err != nil {
 		return fmt.Errorf("error while deleting current cluster upgrade state: %w", err)
 	}
 
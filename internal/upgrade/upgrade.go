@@ -12,6 +12,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/runreport"
 	"github.com/sighupio/furyctl/internal/semver"
 	"github.com/sighupio/furyctl/internal/tool/shell"
 	execx "github.com/sighupio/furyctl/internal/x/exec"
@@ -26,11 +27,19 @@ type Upgrade struct {
 	To      string
 }
 
+// Exec runs the upgrade script of the sub-phase when an upgrade is in progress. The sub-phase is recorded
+// in the run report and in the trace of every apply, also when there is no upgrade to run.
 func (u *Upgrade) Exec(workdir, phase string) error {
-	if !u.Enabled {
-		return nil
-	}
+	return runreport.Track(phase, func() error {
+		if !u.Enabled {
+			return nil
+		}
+
+		return u.exec(workdir, phase)
+	})
+}
 
+func (u *Upgrade) exec(workdir, phase string) error {
 	logrus.Infof(
 		"Running %s upgrade from %s to %s...",
 		phase,
@@ -0,0 +1,34 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package upgrade_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/cluster"
+	"github.com/sighupio/furyctl/internal/runreport"
+	"github.com/sighupio/furyctl/internal/upgrade"
+)
+
+//nolint:paralleltest // The active report is global.
+func TestUpgrade_ExecTracksTheSubPhaseWithoutAnUpgrade(t *testing.T) {
+	r := runreport.New("apply", t.TempDir(), "test", "OnPremises", "v1.31.0")
+	r.Activate()
+
+	defer r.Deactivate()
+
+	u := upgrade.New(cluster.CreatorPaths{WorkDir: t.TempDir()}, "OnPremises")
+
+	require.NoError(t, u.Exec(t.TempDir(), cluster.OperationSubPhasePreKubernetes))
+
+	require.Len(t, r.Phases, 1)
+	assert.Equal(t, cluster.OperationSubPhasePreKubernetes, r.Phases[0].Name)
+	assert.Equal(t, runreport.StatusSuccess, r.Phases[0].Status)
+}
@@ -12,9 +12,12 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/sighupio/furyctl/internal/redact"
 	bytesx "github.com/sighupio/furyctl/internal/x/bytes"
 	iox "github.com/sighupio/furyctl/internal/x/io"
 )
@@ -26,8 +29,45 @@ var (
 	ErrCmdFailed       = errors.New("command failed")
 	ErrCmdTimeout      = errors.New("command timed out")
 	ErrCastingToBuffer = errors.New("error casting stdout to bytes.Buffer")
+
+	recorders   []CmdRecorder //nolint:gochecknoglobals // The recorders are shared between all the command instances.
+	recordersMu sync.Mutex    //nolint:gochecknoglobals // The lock guards the recorders.
 )
 
+// CmdRecorder receives the records of the commands that furyctl runs.
+type CmdRecorder interface {
+	RecordCmd(rec CmdRecord)
+}
+
+// AddRecorder makes the recorder receive a record of every command that runs.
+func AddRecorder(r CmdRecorder) {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+
+	recorders = append(recorders, r)
+}
+
+// RemoveRecorder stops sending the records of the commands to the recorder.
+func RemoveRecorder(r CmdRecorder) {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+
+	recorders = slices.DeleteFunc(recorders, func(rec CmdRecorder) bool {
+		return rec == r
+	})
+}
+
+// CmdRecord describes a command that ran. The arguments of a Sensitive command are redacted.
+type CmdRecord struct {
+	Name      string    `json:"name"`
+	Args      []string  `json:"args"`
+	WorkDir   string    `json:"workDir,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+	ExitCode  int       `json:"exitCode"`
+	Error     string    `json:"error,omitempty"`
+}
+
 func NewErrCmdFailed(name string, args []string, err error, res *CmdLog) error {
 	return fmt.Errorf("%s %s: %w - %v\n%s", name, strings.Join(args, " "), ErrCmdFailed, err, res)
 }
@@ -50,12 +90,13 @@ func NewCmd(name string, opts CmdOptions) *Cmd {
 		cmd := strings.Split(name, "/")
 		cmdArgs := strings.Join(opts.Args, " ")
 
-		action := cmd[len(cmd)-1] + " " + cmdArgs
+		action := redact.String(cmd[len(cmd)-1] + " " + cmdArgs)
 
 		stripColor := iox.WriterTransform{
 			W: LogFile,
 			Transforms: []bytesx.TransformFunc{
 				bytesx.StripColor,
+				redact.Bytes,
 				bytesx.ToJSONLogFormat("debug", action),
 				bytesx.AppendNewLine,
 			},
@@ -109,13 +150,58 @@ func NewCmd(name string, opts CmdOptions) *Cmd {
 }
 
 func (c *Cmd) Run() error {
-	if err := c.Cmd.Run(); err != nil {
+	startedAt := time.Now()
+
+	err := c.Cmd.Run()
+
+	c.record(startedAt, c.ProcessState, err)
+
+	if err != nil {
 		return NewErrCmdFailed(c.Path, c.Args, err, c.Log)
 	}
 
 	return nil
 }
 
+func (c *Cmd) record(startedAt time.Time, state *os.ProcessState, err error) {
+	recordersMu.Lock()
+	defer recordersMu.Unlock()
+
+	if len(recorders) == 0 {
+		return
+	}
+
+	args := make([]string, 0, len(c.Args)-1)
+	for _, arg := range c.Args[1:] {
+		args = append(args, redact.String(arg))
+	}
+
+	if c.Sensitive {
+		args = []string{redact.Mask}
+	}
+
+	rec := CmdRecord{
+		Name:      c.Path,
+		Args:      args,
+		WorkDir:   c.Dir,
+		StartedAt: startedAt.UTC(),
+		EndedAt:   time.Now().UTC(),
+		ExitCode:  -1,
+	}
+
+	if state != nil {
+		rec.ExitCode = state.ExitCode()
+	}
+
+	if err != nil && !c.Sensitive {
+		rec.Error = redact.String(err.Error())
+	}
+
+	for _, r := range recorders {
+		r.RecordCmd(rec)
+	}
+}
+
 func (c *Cmd) Stop() error {
 	if c.Process == nil {
 		return nil
@@ -144,8 +230,12 @@ func (c *Cmd) RunWithTimeout(timeout time.Duration) error {
 	cmdCtx.Stdout = c.Stdout
 	cmdCtx.Stderr = c.Stderr
 
+	startedAt := time.Now()
+
 	err := cmdCtx.Run()
 
+	c.record(startedAt, cmdCtx.ProcessState, err)
+
 	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 		return fmt.Errorf(
 			"%w after %s: %s %s", ErrCmdTimeout, timeout, c.Path, strings.Join(c.Args, " "),
@@ -11,6 +11,20 @@ import (
 )
 
 func CreateConfigMap(data []byte, name, key, namespace string) ([]byte, error) {
+	return CreateConfigMapWithLabels(name, namespace, nil, map[string]string{key: string(data)})
+}
+
+// CreateConfigMapWithLabels is CreateConfigMap for a config map that has labels and more than one key.
+func CreateConfigMapWithLabels(name, namespace string, labels, data map[string]string) ([]byte, error) {
+	metadata := map[string]any{
+		"name":      name,
+		"namespace": namespace,
+	}
+
+	if len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+
 	configMap := struct {
 		APIVersion string            `yaml:"apiVersion"`
 		Kind       string            `yaml:"kind"`
@@ -19,19 +33,14 @@ func CreateConfigMap(data []byte, name, key, namespace string) ([]byte, error) {
 	}{
 		APIVersion: "v1",
 		Kind:       "ConfigMap",
-		Metadata: map[string]any{
-			"name":      name,
-			"namespace": namespace,
-		},
-		Data: map[string]string{
-			key: string(data),
-		},
+		Metadata:   metadata,
+		Data:       data,
 	}
 
-	data, err := yamlx.MarshalV3(configMap)
+	out, err := yamlx.MarshalV3(configMap)
 	if err != nil {
 		return []byte{}, fmt.Errorf("%w", err)
 	}
 
-	return data, nil
+	return out, nil
 }
@@ -14,6 +14,20 @@ import (
 var ErrCannotCreateSecret = errors.New("cannot create secret")
 
 func CreateSecret(name, namespace string, data map[string]string) ([]byte, error) {
+	return CreateSecretWithLabels(name, namespace, nil, data)
+}
+
+// CreateSecretWithLabels is CreateSecret for a secret that has labels.
+func CreateSecretWithLabels(name, namespace string, labels, data map[string]string) ([]byte, error) {
+	metadata := map[string]any{
+		"name":      name,
+		"namespace": namespace,
+	}
+
+	if len(labels) > 0 {
+		metadata["labels"] = labels
+	}
+
 	secret := struct {
 		APIVersion string            `yaml:"apiVersion"`
 		Kind       string            `yaml:"kind"`
@@ -23,12 +37,9 @@ func CreateSecret(name, namespace string, data map[string]string) ([]byte, error
 	}{
 		APIVersion: "v1",
 		Kind:       "Secret",
-		Metadata: map[string]any{
-			"name":      name,
-			"namespace": namespace,
-		},
-		Type: "Opaque",
-		Data: data,
+		Metadata:   metadata,
+		Type:       "Opaque",
+		Data:       data,
 	}
 
 	secretYaml, err := yamlx.MarshalV3(secret)
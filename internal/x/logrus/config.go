@@ -10,6 +10,8 @@ import (
 	"os"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/sighupio/furyctl/internal/redact"
 )
 
 type LogFormat struct {
@@ -26,6 +28,8 @@ type formatterHook struct {
 }
 
 func (hook *formatterHook) Fire(entry *logrus.Entry) error {
+	entry.Message = redact.String(entry.Message)
+
 	line, err := hook.Formatter.Format(entry)
 	if err != nil {
 		return fmt.Errorf("error while formatting log entry: %w", err)
@@ -86,3 +90,15 @@ func InitLog(logFile *os.File, debug, disableColors bool) {
 
 	logrus.AddHook(logFileHook)
 }
+
+// RedirectStdout sends the log lines that go to the standard output to the given writer instead. The
+// commands with a machine-readable output use it, so that the standard output carries only the output.
+func RedirectStdout(w io.Writer) {
+	for _, hooks := range logrus.StandardLogger().Hooks {
+		for _, hook := range hooks {
+			if fh, ok := hook.(*formatterHook); ok && fh.Writer == os.Stdout {
+				fh.Writer = w
+			}
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/sighupio/furyctl/cmd"
 	"github.com/sighupio/furyctl/internal/app"
+	"github.com/sighupio/furyctl/internal/tracing"
 )
 
 var (
@@ -76,13 +77,15 @@ func exec() int {
 
 	defer wg.Wait()
 
-	if _, err := cmd.NewRootCmd().ExecuteC(); err != nil {
-		log.Error(err)
+	_, err = cmd.NewRootCmd().ExecuteC()
+
+	tracing.Stop(err)
 
-		return 1
+	if err != nil {
+		log.Error(err)
 	}
 
-	return 0
+	return cmd.ExitCode(err)
 }
 
 func checkNewRelease(v string) {
@@ -39,10 +39,12 @@ var (
 )
 
 type Downloader struct {
-	client      netx.Client
-	basePath    string
-	binPath     string
-	gitProtocol git.Protocol
+	client          netx.Client
+	basePath        string
+	binPath         string
+	gitProtocol     git.Protocol
+	allowUnverified bool
+	report          *Report
 }
 
 func NewCachingDownloader(client netx.Client, outDir, basePath, binPath string, gitProtocol git.Protocol) *Downloader {
@@ -61,9 +63,22 @@ func NewDownloader(client netx.Client, basePath, binPath string, gitProtocol git
 		basePath:    basePath,
 		binPath:     binPath,
 		gitProtocol: gitProtocol,
+		report:      NewReport(),
 	}
 }
 
+// AllowUnverifiedDownloads makes the downloader warn, instead of failing, when a tool does not match its
+// pinned checksum or its checksums file has an invalid signature. Only apply allows it, with --force
+// unverified-downloads: the downloaders of the other commands always fail.
+func (dd *Downloader) AllowUnverifiedDownloads() {
+	dd.allowUnverified = true
+}
+
+// Report returns what the downloader fetched so far and how it verified it.
+func (dd *Downloader) Report() *Report {
+	return dd.report
+}
+
 func (dd *Downloader) DownloadAll(kfd config.KFD, kind string) ([]error, []string) {
 	var errs []error
 	var uts []string
@@ -106,6 +121,10 @@ func (dd *Downloader) DownloadAll(kfd config.KFD, kind string) ([]error, []strin
 	}()
 
 	go func() {
+		defer func() {
+			doneCh <- true
+		}()
+
 		uts, err := dd.DownloadTools(kfd, kind)
 		if err != nil {
 			errCh <- err
@@ -116,8 +135,6 @@ func (dd *Downloader) DownloadAll(kfd config.KFD, kind string) ([]error, []strin
 		for _, ut := range uts {
 			utsCh <- ut
 		}
-
-		doneCh <- true
 	}()
 
 	done := 0
@@ -136,6 +153,8 @@ func (dd *Downloader) DownloadAll(kfd config.KFD, kind string) ([]error, []strin
 			done++
 
 			if done == todo {
+				dd.writeReport()
+
 				if len(errs) > 0 {
 					if errClear := dd.client.Clear(); errClear != nil {
 						logrus.Error(errClear)
@@ -148,6 +167,8 @@ func (dd *Downloader) DownloadAll(kfd config.KFD, kind string) ([]error, []strin
 		case <-time.After(downloadsTimeout):
 			errs = append(errs, fmt.Errorf("%w dependencies", ErrDownloadTimeout))
 
+			dd.writeReport()
+
 			if errClear := dd.client.Clear(); errClear != nil {
 				logrus.Error(errClear)
 			}
@@ -200,6 +221,7 @@ func (dd *Downloader) DownloadModules(kfd config.KFD, gitPrefix, kind string) er
 			retries := map[string]int{}
 
 			dst := filepath.Join(dd.basePath, "vendor", "modules", name)
+			source := ""
 
 			for _, prefix := range []string{oldPrefix, newPrefix} {
 				src := fmt.Sprintf("git::%s/%s-%s?ref=%s&depth=1", gitPrefix, prefix, name, version)
@@ -261,6 +283,7 @@ func (dd *Downloader) DownloadModules(kfd config.KFD, gitPrefix, kind string) er
 				}
 
 				errs = []error{}
+				source = src
 
 				break
 			}
@@ -276,6 +299,8 @@ func (dd *Downloader) DownloadModules(kfd config.KFD, gitPrefix, kind string) er
 
 				return
 			}
+
+			dd.recordFolder(ArtifactKindModule, name, version, source, dst)
 		}()
 	}
 
@@ -333,6 +358,8 @@ func (dd *Downloader) DownloadInstallers(installers config.KFDKubernetes, gitPre
 		if err != nil {
 			return fmt.Errorf("error removing .git subfolder: %w", err)
 		}
+
+		dd.recordFolder(ArtifactKindInstaller, name, version, src, dst)
 	}
 
 	return nil
@@ -341,6 +368,7 @@ func (dd *Downloader) DownloadInstallers(installers config.KFDKubernetes, gitPre
 // DownloadTools installs the tools needed by the cluster kind using the bundled mise, then
 // materializes them into the legacy <binPath>/<tool>/<version>/<bin> layout (via relative symlinks)
 // so the rest of furyctl (phase paths, runners, templates, validator) keeps working unchanged.
+// Every binary is verified against the checksums pinned in kfd.yaml before it is materialized.
 // Returns the host tools (uts) that mise does not manage and the operator must provide (e.g. awscli).
 func (dd *Downloader) DownloadTools(kfd config.KFD, kind string) ([]string, error) {
 	managed, uts := miseToolsForKind(kfd, kind)
@@ -353,6 +381,8 @@ func (dd *Downloader) DownloadTools(kfd config.KFD, kind string) ([]string, erro
 		return uts, fmt.Errorf("error ensuring mise binary: %w", err)
 	}
 
+	dd.recordMise(misePath)
+
 	// The mise dir lives under binPath (next to the mise binary), NOT under vendor: vendor is wiped
 	// on every DownloadAll, so keeping the installed tools here lets them cache across runs (and keeps
 	// them around for air-gapped reuse).
@@ -397,6 +427,8 @@ func (dd *Downloader) DownloadTools(kfd config.KFD, kind string) ([]string, erro
 
 	logrus.Infof("Tools ready (%d installed via mise)", len(managed))
 
+	pins := toolPinsForKind(kfd, kind)
+
 	for name, version := range managed {
 		// Ansible needs special handling: resolve the real pipx venv entrypoints + python and install
 		// the galaxy collections (a single Bin symlink is not enough).
@@ -405,6 +437,15 @@ func (dd *Downloader) DownloadTools(kfd config.KFD, kind string) ([]string, erro
 				return uts, err
 			}
 
+			// The ansible venv is not a single binary, there is no checksum to verify it against.
+			dd.report.Add(Artifact{
+				Kind:    ArtifactKindTool,
+				Name:    name,
+				Version: version,
+				Source:  "mise",
+				Status:  StatusUnverified,
+			})
+
 			continue
 		}
 
@@ -415,6 +456,10 @@ func (dd *Downloader) DownloadTools(kfd config.KFD, kind string) ([]string, erro
 			return uts, fmt.Errorf("error resolving tool '%s' via mise: %w", name, err)
 		}
 
+		if err := dd.verifyTool(name, version, pins[name], realPath); err != nil {
+			return uts, err
+		}
+
 		if err := materializeTool(dd.binPath, name, version, t.Bin, realPath); err != nil {
 			return uts, err
 		}
@@ -8,12 +8,16 @@
 package dependencies
 
 import (
+	"errors"
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/git"
 )
 
 func tool(v string) config.KFDTool { return config.KFDTool{Version: v} }
@@ -123,3 +127,49 @@ func Test_miseToolsForKind(t *testing.T) {
 		})
 	}
 }
+
+var errUnreachable = errors.New("unreachable")
+
+// failingClient fails every download.
+type failingClient struct{}
+
+func (failingClient) Clear() error { return nil }
+
+func (failingClient) ClearItem(_ string) error { return nil }
+
+func (failingClient) Download(_, _ string) error { return errUnreachable }
+
+func TestDownloader_DownloadAll_ToolsError(t *testing.T) {
+	t.Parallel()
+
+	kfd := config.KFD{
+		Tools: config.KFDTools{
+			Common: config.KFDToolsCommon{Kubectl: tool("1.34.4")},
+		},
+	}
+
+	dd := NewDownloader(failingClient{}, t.TempDir(), t.TempDir(), git.ProtocolHTTPS)
+
+	done := make(chan []error)
+
+	go func() {
+		errs, _ := dd.DownloadAll(kfd, "KFDDistribution")
+
+		done <- errs
+	}()
+
+	// The error of the tools ends their download: DownloadAll does not wait for the downloads timeout.
+	select {
+	case errs := <-done:
+		require.NotEmpty(t, errs)
+
+		for _, err := range errs {
+			require.NotErrorIs(t, err, ErrDownloadTimeout)
+		}
+
+		assert.True(t, slices.ContainsFunc(errs, func(err error) bool { return errors.Is(err, errUnreachable) }))
+
+	case <-time.After(time.Minute):
+		t.Fatal("DownloadAll waited for the downloads timeout")
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dependencies
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+// ReportFile is the name of the download report that DownloadAll writes in the base path.
+const ReportFile = "download-report.json"
+
+const (
+	ArtifactKindTool      = "tool"
+	ArtifactKindModule    = "module"
+	ArtifactKindInstaller = "installer"
+
+	// StatusVerified means the artifact matches a pinned or signed checksum.
+	StatusVerified = "verified"
+	// StatusUnverified means there is nothing to verify the artifact against.
+	StatusUnverified = "unverified"
+	// StatusRejected means the verification failed and furyctl refused the artifact.
+	StatusRejected = "rejected"
+	// StatusForced means the verification failed but --force unverified-downloads let the artifact through.
+	StatusForced = "forced"
+)
+
+// Artifact is an entry of the download report. SHA256 is the digest of a tool binary, or the dirhash
+// (h1:) of a module or installer folder.
+type Artifact struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Version   string `json:"version"`
+	Source    string `json:"source,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+	Expected  string `json:"expected,omitempty"`
+	Signature string `json:"signature,omitempty"`
+	Status    string `json:"status"`
+	Message   string `json:"message,omitempty"`
+}
+
+// Report records what the downloader fetched and how it verified it. It is safe for concurrent use.
+type Report struct {
+	mu sync.Mutex
+
+	GeneratedAt time.Time  `json:"generatedAt"`
+	Platform    string     `json:"platform"`
+	Artifacts   []Artifact `json:"artifacts"`
+}
+
+func NewReport() *Report {
+	return &Report{
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Artifacts: []Artifact{},
+	}
+}
+
+func (r *Report) Add(a Artifact) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.Artifacts = append(r.Artifacts, a)
+}
+
+// Write stores the report as JSON, with the artifacts sorted by kind and name.
+func (r *Report) Write(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.GeneratedAt = time.Now().UTC()
+
+	sort.SliceStable(r.Artifacts, func(i, j int) bool {
+		if r.Artifacts[i].Kind != r.Artifacts[j].Kind {
+			return r.Artifacts[i].Kind < r.Artifacts[j].Kind
+		}
+
+		return r.Artifacts[i].Name < r.Artifacts[j].Name
+	})
+
+	out, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error while marshalling download report: %w", err)
+	}
+
+	if err := os.WriteFile(path, out, iox.RWPermAccess); err != nil {
+		return fmt.Errorf("error while writing download report: %w", err)
+	}
+
+	return nil
+}
+
+// writeReport writes the download report in the base path. A report that cannot be written does not
+// fail the download.
+func (dd *Downloader) writeReport() {
+	if err := dd.report.Write(filepath.Join(dd.basePath, ReportFile)); err != nil {
+		logrus.Warnf("Error while writing the download report: %v", err)
+	}
+}
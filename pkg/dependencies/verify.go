@@ -0,0 +1,260 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dependencies
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/mod/sumdb/dirhash"
+
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/distribution"
+	"github.com/sighupio/furyctl/internal/signature"
+	"github.com/sighupio/furyctl/internal/tool/mise"
+	iox "github.com/sighupio/furyctl/internal/x/io"
+)
+
+var (
+	ErrChecksumMismatch  = errors.New("checksum mismatch")
+	ErrInvalidSignature  = errors.New("invalid signature")
+	ErrChecksumNotSigned = errors.New("the signed checksums file has no entry")
+)
+
+// toolPinsForKind returns the kfd.yaml entry of every tool of the sections needed by the kind, keyed
+// by tool name. As in miseToolsForKind, the eks entry wins over the common one.
+func toolPinsForKind(kfd config.KFD, kind string) map[string]config.KFDTool {
+	pins := map[string]config.KFDTool{}
+
+	for sectionField, sectionValue := range reflect.ValueOf(kfd.Tools).Fields() {
+		if !distribution.ToolSectionNeededForKind(strings.ToLower(sectionField.Name), kind) {
+			continue
+		}
+
+		for field, value := range sectionValue.Fields() {
+			if toolCfg, ok := reflect.TypeAssert[config.KFDTool](value); ok && toolCfg.Version != "" {
+				pins[strings.ToLower(field.Name)] = toolCfg
+			}
+		}
+	}
+
+	return pins
+}
+
+// verifyTool checks the binary that mise installed for a tool against the checksum that kfd.yaml pins
+// for the host platform and, when the tool has a signature, against its signed checksums file. The
+// outcome goes in the download report. A tool without any checksum is recorded as unverified, so the
+// distributions that do not pin checksums keep working.
+func (dd *Downloader) verifyTool(name, version string, pin config.KFDTool, realPath string) error {
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+
+	sum, err := iox.Sha256File(realPath)
+	if err != nil {
+		return fmt.Errorf("error while verifying tool '%s': %w", name, err)
+	}
+
+	artifact := Artifact{
+		Kind:     ArtifactKindTool,
+		Name:     name,
+		Version:  version,
+		Source:   "mise",
+		SHA256:   sum,
+		Expected: strings.ToLower(pin.Checksums[platform]),
+	}
+
+	if pin.Signature.Type != "" {
+		artifact.Signature = pin.Signature.Type
+
+		signed, err := dd.signedChecksum(name, version, platform, pin.Signature)
+		if err != nil {
+			return dd.refuse(artifact, fmt.Errorf("%w for tool '%s': %w", ErrInvalidSignature, name, err))
+		}
+
+		if artifact.Expected != "" && artifact.Expected != signed {
+			return dd.refuse(artifact, fmt.Errorf(
+				"%w for tool '%s': kfd.yaml pins %s but the signed checksums file has %s",
+				ErrChecksumMismatch, name, artifact.Expected, signed,
+			))
+		}
+
+		artifact.Expected = signed
+	}
+
+	if artifact.Expected == "" {
+		logrus.Debugf("Tool %s %s has no checksum for %s, skipping verification", name, version, platform)
+
+		artifact.Status = StatusUnverified
+		dd.report.Add(artifact)
+
+		return nil
+	}
+
+	if sum != artifact.Expected {
+		return dd.refuse(artifact, fmt.Errorf(
+			"%w for tool '%s' %s (%s): got %s, want %s",
+			ErrChecksumMismatch, name, version, platform, sum, artifact.Expected,
+		))
+	}
+
+	logrus.Debugf("Verified tool %s %s against checksum %s", name, version, sum)
+
+	artifact.Status = StatusVerified
+	dd.report.Add(artifact)
+
+	return nil
+}
+
+// refuse records an artifact that failed the verification. It returns the error, unless unverified
+// downloads are allowed: in that case it only warns.
+func (dd *Downloader) refuse(artifact Artifact, err error) error {
+	artifact.Message = err.Error()
+
+	if !dd.allowUnverified {
+		artifact.Status = StatusRejected
+		dd.report.Add(artifact)
+
+		return err
+	}
+
+	logrus.Warnf("%v, continuing because unverified downloads are allowed", err)
+
+	artifact.Status = StatusForced
+	dd.report.Add(artifact)
+
+	return nil
+}
+
+// signedChecksum downloads the checksums file of a tool and its signature, verifies the signature and
+// returns the checksum of the "<tool>-<version>-<os>-<arch>" entry.
+func (dd *Downloader) signedChecksum(name, version, platform string, sig config.KFDToolSignature) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "furyctl-checksums-")
+	if err != nil {
+		return "", fmt.Errorf("error while creating temporary directory: %w", err)
+	}
+
+	defer os.RemoveAll(tmpDir)
+
+	checksums, err := dd.downloadFile(sig.ChecksumsURL, filepath.Join(tmpDir, "checksums"))
+	if err != nil {
+		return "", err
+	}
+
+	signatureFile, err := dd.downloadFile(sig.SignatureURL, filepath.Join(tmpDir, "signature"))
+	if err != nil {
+		return "", err
+	}
+
+	if err := signature.Verify(sig.Type, sig.PublicKey, checksums, signatureFile); err != nil {
+		return "", fmt.Errorf("error while verifying %s: %w", sig.ChecksumsURL, err)
+	}
+
+	entry := fmt.Sprintf("%s-%s-%s", name, version, strings.ReplaceAll(platform, "/", "-"))
+
+	sum, ok := parseChecksums(checksums)[entry]
+	if !ok {
+		return "", fmt.Errorf("%w for %s", ErrChecksumNotSigned, entry)
+	}
+
+	return sum, nil
+}
+
+// downloadFile downloads a single file in dst, a folder that must not exist, and returns its content.
+func (dd *Downloader) downloadFile(src, dst string) ([]byte, error) {
+	if err := dd.client.Download(src, dst); err != nil {
+		return nil, fmt.Errorf("error while downloading %s: %w", src, err)
+	}
+
+	entries, err := os.ReadDir(dst)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading %s: %w", dst, err)
+	}
+
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			content, err := os.ReadFile(filepath.Join(dst, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("error while reading %s: %w", entry.Name(), err)
+			}
+
+			return content, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", os.ErrNotExist, src)
+}
+
+// parseChecksums reads a checksums file in the sha256sum format: "<sha256>  <file>" lines, where the
+// file name can have the "*" binary mode prefix.
+func parseChecksums(content []byte) map[string]string {
+	sums := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+
+		sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+
+	return sums
+}
+
+// recordFolder adds a module or installer to the download report with the dirhash of its folder.
+// Nothing pins the content of the git archives, so they are recorded as unverified.
+func (dd *Downloader) recordFolder(kind, name, version, src, dir string) {
+	artifact := Artifact{
+		Kind:    kind,
+		Name:    name,
+		Version: version,
+		Source:  src,
+		Status:  StatusUnverified,
+	}
+
+	sum, err := dirhash.HashDir(dir, name+"@"+version, dirhash.Hash1)
+	if err != nil {
+		logrus.Debugf("Error while hashing %s %s: %v", kind, name, err)
+	} else {
+		artifact.SHA256 = sum
+	}
+
+	dd.report.Add(artifact)
+}
+
+// recordMise adds the bundled mise binary to the download report. The go-getter checksum query already
+// verified it against the checksum that furyctl pins.
+func (dd *Downloader) recordMise(misePath string) {
+	artifact := Artifact{
+		Kind:    ArtifactKindTool,
+		Name:    "mise",
+		Version: mise.Version,
+		Source:  "furyctl",
+		Status:  StatusUnverified,
+	}
+
+	if sum, err := iox.Sha256File(misePath); err == nil {
+		artifact.SHA256 = sum
+	}
+
+	if expected, err := mise.Checksum(runtime.GOOS, runtime.GOARCH); err == nil {
+		artifact.Expected = expected
+
+		if artifact.SHA256 == expected {
+			artifact.Status = StatusVerified
+		}
+	}
+
+	dd.report.Add(artifact)
+}
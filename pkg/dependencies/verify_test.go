@@ -0,0 +1,221 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+//nolint:testpackage // verifyTool is unexported.
+package dependencies
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/internal/apis/config"
+	"github.com/sighupio/furyctl/internal/git"
+)
+
+const (
+	checksumsURL = "https://example.com/kubectl-1.31.4.sha256sum"
+	signatureURL = "https://example.com/kubectl-1.31.4.sha256sum.sig"
+)
+
+// fakeClient serves the files of a map, keyed by URL, in the destination folder as go-getter does.
+type fakeClient struct {
+	files map[string][]byte
+}
+
+func (c *fakeClient) Download(src, dst string) error {
+	content, ok := c.files[src]
+	if !ok {
+		return fmt.Errorf("%w: %s", os.ErrNotExist, src)
+	}
+
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dst, path.Base(src)), content, 0o644)
+}
+
+func (*fakeClient) Clear() error { return nil }
+
+func (*fakeClient) ClearItem(string) error { return nil }
+
+// newBinary writes a fake tool binary and returns its path and checksum.
+func newBinary(t *testing.T) (string, string) {
+	t.Helper()
+
+	bin := filepath.Join(t.TempDir(), "kubectl")
+	content := []byte("#!/bin/sh\necho kubectl\n")
+
+	require.NoError(t, os.WriteFile(bin, content, 0o755))
+
+	sum := sha256.Sum256(content)
+
+	return bin, hex.EncodeToString(sum[:])
+}
+
+// signedChecksums returns a cosign signature over the checksums file and its public key.
+func signedChecksums(t *testing.T, checksums []byte) (config.KFDToolSignature, []byte) {
+	t.Helper()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	return config.KFDToolSignature{
+		Type:         "cosign",
+		ChecksumsURL: checksumsURL,
+		SignatureURL: signatureURL,
+		PublicKey:    string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+	}, []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(priv, checksums)))
+}
+
+func TestDownloader_verifyTool(t *testing.T) {
+	t.Parallel()
+
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	entry := fmt.Sprintf("kubectl-1.31.4-%s-%s", runtime.GOOS, runtime.GOARCH)
+
+	bin, sum := newBinary(t)
+	wrongSum := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	goodChecksums := []byte(fmt.Sprintf("%s  other-1.0.0-linux-amd64\n%s *%s\n", wrongSum, sum, entry))
+	goodSig, goodSigFile := signedChecksums(t, goodChecksums)
+
+	tamperedChecksums := []byte(fmt.Sprintf("%s  %s\n", wrongSum, entry))
+
+	testCases := []struct {
+		desc            string
+		pin             config.KFDTool
+		files           map[string][]byte
+		allowUnverified bool
+		wantErr         error
+		wantStatus      string
+	}{
+		{
+			desc:       "no checksum",
+			pin:        config.KFDTool{Version: "1.31.4"},
+			wantStatus: StatusUnverified,
+		},
+		{
+			desc:       "pinned checksum",
+			pin:        config.KFDTool{Version: "1.31.4", Checksums: map[string]string{platform: sum}},
+			wantStatus: StatusVerified,
+		},
+		{
+			desc:       "pinned checksum mismatch",
+			pin:        config.KFDTool{Version: "1.31.4", Checksums: map[string]string{platform: wrongSum}},
+			wantErr:    ErrChecksumMismatch,
+			wantStatus: StatusRejected,
+		},
+		{
+			desc:            "pinned checksum mismatch with unverified downloads allowed",
+			pin:             config.KFDTool{Version: "1.31.4", Checksums: map[string]string{platform: wrongSum}},
+			allowUnverified: true,
+			wantStatus:      StatusForced,
+		},
+		{
+			desc:       "signed checksum",
+			pin:        config.KFDTool{Version: "1.31.4", Signature: goodSig},
+			files:      map[string][]byte{checksumsURL: goodChecksums, signatureURL: goodSigFile},
+			wantStatus: StatusVerified,
+		},
+		{
+			desc: "signed and pinned checksums differ",
+			pin: config.KFDTool{
+				Version:   "1.31.4",
+				Checksums: map[string]string{platform: wrongSum},
+				Signature: goodSig,
+			},
+			files:      map[string][]byte{checksumsURL: goodChecksums, signatureURL: goodSigFile},
+			wantErr:    ErrChecksumMismatch,
+			wantStatus: StatusRejected,
+		},
+		{
+			desc:       "tampered checksums file",
+			pin:        config.KFDTool{Version: "1.31.4", Signature: goodSig},
+			files:      map[string][]byte{checksumsURL: tamperedChecksums, signatureURL: goodSigFile},
+			wantErr:    ErrInvalidSignature,
+			wantStatus: StatusRejected,
+		},
+		{
+			desc:       "signature not found",
+			pin:        config.KFDTool{Version: "1.31.4", Signature: goodSig},
+			files:      map[string][]byte{checksumsURL: goodChecksums},
+			wantErr:    ErrInvalidSignature,
+			wantStatus: StatusRejected,
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			dd := NewDownloader(&fakeClient{files: tC.files}, t.TempDir(), t.TempDir(), git.ProtocolHTTPS)
+			if tC.allowUnverified {
+				dd.AllowUnverifiedDownloads()
+			}
+
+			err := dd.verifyTool("kubectl", "1.31.4", tC.pin, bin)
+			if tC.wantErr != nil {
+				require.ErrorIs(t, err, tC.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			require.Len(t, dd.Report().Artifacts, 1)
+
+			artifact := dd.Report().Artifacts[0]
+			assert.Equal(t, tC.wantStatus, artifact.Status)
+			assert.Equal(t, sum, artifact.SHA256)
+		})
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	t.Parallel()
+
+	modDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(modDir, "README.md"), []byte("ingress"), 0o644))
+
+	basePath := t.TempDir()
+
+	dd := NewDownloader(&fakeClient{}, basePath, t.TempDir(), git.ProtocolHTTPS)
+	dd.report.Add(Artifact{Kind: ArtifactKindTool, Name: "kubectl", Version: "1.31.4", Status: StatusVerified})
+	dd.recordFolder(ArtifactKindModule, "ingress", "v4.0.0", "git::https://github.com/sighupio/module-ingress", modDir)
+	dd.writeReport()
+
+	content, err := os.ReadFile(filepath.Join(basePath, ReportFile))
+	require.NoError(t, err)
+
+	got := Report{}
+	require.NoError(t, json.Unmarshal(content, &got))
+
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, got.Platform)
+	assert.False(t, got.GeneratedAt.IsZero())
+	require.Len(t, got.Artifacts, 2)
+
+	assert.Equal(t, "ingress", got.Artifacts[0].Name)
+	assert.Equal(t, StatusUnverified, got.Artifacts[0].Status)
+	assert.Regexp(t, "^h1:", got.Artifacts[0].SHA256)
+	assert.Equal(t, "kubectl", got.Artifacts[1].Name)
+}
@@ -111,32 +111,38 @@ func (*BaseChecker) AssertReducerUnsupportedViolations(diffs r3diff.Changelog, r
 	diffs = ExpandMapChanges(diffs)
 
 	for _, diff := range diffs {
-		for _, rule := range reducerRules {
-			joinedPath := "." + strings.Join(diff.Path, ".")
-			changePath := numbersToWildcardRegex.ReplaceAllString(joinedPath, ".*")
-
-			if rule.Path == changePath && rule.Unsupported != nil && len(*rule.Unsupported) > 0 {
-				if reason, unsupported := isDiffUnsupported(diff, *rule.Unsupported); unsupported {
-					unsupportedGenericErrMsg := fmt.Sprintf(
-						"changing %s from %v to %v is not supported",
-						changePath,
-						diff.From,
-						diff.To,
-					)
-
-					if reason != "" {
-						unsupportedGenericErrMsg = reason
-					}
-
-					errs = append(errs, fmt.Errorf("%w: %s", errUnsupported, unsupportedGenericErrMsg))
-				}
-			}
+		for _, reason := range unsupportedReasons(diff, reducerRules) {
+			errs = append(errs, fmt.Errorf("%w: %s", errUnsupported, reason))
 		}
 	}
 
 	return errs
 }
 
+// unsupportedReasons returns a message for each rule that does not support the given leaf change.
+func unsupportedReasons(diff r3diff.Change, reducerRules []rules.Rule) []string {
+	var reasons []string
+
+	joinedPath := "." + strings.Join(diff.Path, ".")
+	changePath := numbersToWildcardRegex.ReplaceAllString(joinedPath, ".*")
+
+	for _, rule := range reducerRules {
+		if rule.Path != changePath || rule.Unsupported == nil || len(*rule.Unsupported) == 0 {
+			continue
+		}
+
+		if reason, unsupported := isDiffUnsupported(diff, *rule.Unsupported); unsupported {
+			if reason == "" {
+				reason = fmt.Sprintf("changing %s from %v to %v is not supported", changePath, diff.From, diff.To)
+			}
+
+			reasons = append(reasons, reason)
+		}
+	}
+
+	return reasons
+}
+
 func isDiffUnsupported(diff r3diff.Change, conditions []rules.Unsupported) (string, bool) {
 	for _, condition := range conditions {
 		if (condition.From == nil || diff.From == *condition.From) &&
@@ -0,0 +1,175 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diffs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	r3diff "github.com/r3labs/diff/v3"
+	"github.com/samber/lo"
+
+	"github.com/sighupio/furyctl/internal/cluster"
+	rules "github.com/sighupio/furyctl/pkg/rulesextractor"
+	yamlx "github.com/sighupio/furyctl/pkg/x/yaml"
+)
+
+const (
+	colorReset = "\033[0m"
+	colorBold  = "\033[1m"
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorCyan  = "\033[36m"
+)
+
+// Change is one entry of the machine-readable changelog of a configuration diff.
+type Change struct {
+	Path               string   `json:"path"               yaml:"path"`
+	Type               string   `json:"type"               yaml:"type"`
+	From               any      `json:"from"               yaml:"from"`
+	To                 any      `json:"to"                 yaml:"to"`
+	Phase              string   `json:"phase,omitempty"    yaml:"phase,omitempty"`
+	Immutable          bool     `json:"immutable"          yaml:"immutable"`
+	ReducerUnsupported bool     `json:"reducerUnsupported" yaml:"reducerUnsupported"`
+	Reasons            []string `json:"reasons,omitempty"  yaml:"reasons,omitempty"`
+}
+
+// NewChanges annotates each change of the changelog with the phase that owns its path and with the
+// verdict of the immutable and unsupported rules of the distribution. A nil extractor, as when the
+// distribution has no rules file, leaves every change mutable and supported.
+func NewChanges(changelog r3diff.Changelog, extractor rules.Extractor) []Change {
+	return lo.Map(changelog, func(c r3diff.Change, _ int) Change {
+		change := Change{
+			Path:  "." + strings.Join(c.Path, "."),
+			Type:  c.Type,
+			From:  c.From,
+			To:    c.To,
+			Phase: PhaseForPath(c.Path),
+		}
+
+		if extractor == nil || change.Phase == "" {
+			return change
+		}
+
+		immutablePaths := rules.Paths(
+			extractor.FilterSafeImmutableRules(extractor.GetImmutableRules(change.Phase), changelog),
+		)
+
+		change.Immutable = isImmutablePathChanged(c, immutablePaths)
+
+		unsupportedRules := extractor.GetUnsupportedRules(change.Phase)
+
+		// A change of a whole object carries the unsupported transitions of its leaves.
+		for _, leaf := range expandChange(c) {
+			change.Reasons = append(change.Reasons, unsupportedReasons(leaf, unsupportedRules)...)
+		}
+
+		change.ReducerUnsupported = len(change.Reasons) > 0
+
+		return change
+	})
+}
+
+// PhaseForPath returns the phase that applies the given path of the configuration, or an empty string
+// for the paths that no phase owns, such as the metadata.
+func PhaseForPath(path []string) string {
+	if len(path) < 2 || path[0] != "spec" {
+		return ""
+	}
+
+	phase := path[1]
+
+	if !slices.Contains([]string{
+		cluster.OperationPhaseInfrastructure,
+		cluster.OperationPhaseKubernetes,
+		cluster.OperationPhaseDistribution,
+		cluster.OperationPhasePlugins,
+	}, phase) {
+		return ""
+	}
+
+	return phase
+}
+
+// UnifiedDiff renders the current and the new configuration as YAML and returns their unified diff,
+// limited to the given phase path when it is not empty. Colored output uses ANSI escape sequences.
+func UnifiedDiff(currentConfig, newConfig map[string]any, phasePath string, colored bool) (string, error) {
+	current, err := yamlx.MarshalV3(subtree(currentConfig, phasePath))
+	if err != nil {
+		return "", fmt.Errorf("error while marshalling current config: %w", err)
+	}
+
+	updated, err := yamlx.MarshalV3(subtree(newConfig, phasePath))
+	if err != nil {
+		return "", fmt.Errorf("error while marshalling new config: %w", err)
+	}
+
+	out, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(current)),
+		B:        difflib.SplitLines(string(updated)),
+		FromFile: "cluster",
+		ToFile:   "furyctl.yaml",
+		Context:  3, //nolint:mnd // the context lines of diff -u.
+	})
+	if err != nil {
+		return "", fmt.Errorf("error while generating unified diff: %w", err)
+	}
+
+	if !colored {
+		return out, nil
+	}
+
+	return colorize(out), nil
+}
+
+// subtree returns the value at the given dotted path, or nil when the path is absent.
+func subtree(cfg map[string]any, phasePath string) any {
+	var current any = cfg
+
+	for _, key := range strings.Split(strings.TrimPrefix(phasePath, "."), ".") {
+		if key == "" {
+			continue
+		}
+
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil
+		}
+
+		current = m[key]
+	}
+
+	return current
+}
+
+func colorize(unified string) string {
+	lines := strings.SplitAfter(unified, "\n")
+
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = paint(line, colorBold)
+
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = paint(line, colorCyan)
+
+		case strings.HasPrefix(line, "+"):
+			lines[i] = paint(line, colorGreen)
+
+		case strings.HasPrefix(line, "-"):
+			lines[i] = paint(line, colorRed)
+		}
+	}
+
+	return strings.Join(lines, "")
+}
+
+func paint(line, color string) string {
+	text := strings.TrimSuffix(line, "\n")
+
+	return color + text + colorReset + line[len(text):]
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package diffs_test
+
+import (
+	"testing"
+
+	diffx "github.com/r3labs/diff/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sighupio/furyctl/pkg/diffs"
+	rules "github.com/sighupio/furyctl/pkg/rulesextractor"
+)
+
+func TestNewChanges(t *testing.T) {
+	t.Parallel()
+
+	extractor := rules.NewBaseExtractor(rules.Spec{
+		Kubernetes: &[]rules.Rule{
+			{Path: ".spec.kubernetes.svcCidr", Immutable: true},
+			{
+				Path: ".spec.kubernetes.advanced.kubeProxy.type",
+				Unsupported: &[]rules.Unsupported{
+					{To: new(any("none")), Reason: new("disabling kube-proxy is not supported")},
+				},
+			},
+		},
+	})
+
+	changelog := diffx.Changelog{
+		{Type: diffx.UPDATE, Path: []string{"spec", "kubernetes", "svcCidr"}, From: "10.0.0.0/16", To: "10.1.0.0/16"},
+		{Type: diffx.CREATE, Path: []string{"spec", "kubernetes", "advanced", "kubeProxy"}, To: map[string]any{"type": "none"}},
+		{Type: diffx.UPDATE, Path: []string{"spec", "distribution", "modules", "dr", "type"}, From: "none", To: "on-premises"},
+		{Type: diffx.UPDATE, Path: []string{"metadata", "name"}, From: "foo", To: "bar"},
+	}
+
+	testCases := []struct {
+		desc      string
+		extractor rules.Extractor
+		want      []diffs.Change
+	}{
+		{
+			desc:      "with rules",
+			extractor: extractor,
+			want: []diffs.Change{
+				{
+					Path:      ".spec.kubernetes.svcCidr",
+					Type:      diffx.UPDATE,
+					From:      "10.0.0.0/16",
+					To:        "10.1.0.0/16",
+					Phase:     "kubernetes",
+					Immutable: true,
+				},
+				{
+					Path:               ".spec.kubernetes.advanced.kubeProxy",
+					Type:               diffx.CREATE,
+					To:                 map[string]any{"type": "none"},
+					Phase:              "kubernetes",
+					ReducerUnsupported: true,
+					Reasons:            []string{"disabling kube-proxy is not supported"},
+				},
+				{
+					Path:  ".spec.distribution.modules.dr.type",
+					Type:  diffx.UPDATE,
+					From:  "none",
+					To:    "on-premises",
+					Phase: "distribution",
+				},
+				{
+					Path: ".metadata.name",
+					Type: diffx.UPDATE,
+					From: "foo",
+					To:   "bar",
+				},
+			},
+		},
+		{
+			desc: "without rules",
+			want: []diffs.Change{
+				{Path: ".spec.kubernetes.svcCidr", Type: diffx.UPDATE, From: "10.0.0.0/16", To: "10.1.0.0/16", Phase: "kubernetes"},
+				{Path: ".spec.kubernetes.advanced.kubeProxy", Type: diffx.CREATE, To: map[string]any{"type": "none"}, Phase: "kubernetes"},
+				{Path: ".spec.distribution.modules.dr.type", Type: diffx.UPDATE, From: "none", To: "on-premises", Phase: "distribution"},
+				{Path: ".metadata.name", Type: diffx.UPDATE, From: "foo", To: "bar"},
+			},
+		},
+	}
+
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tC.want, diffs.NewChanges(changelog, tC.extractor))
+		})
+	}
+}
+
+func TestPhaseForPath(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		path []string
+		want string
+	}{
+		{path: []string{"spec", "infrastructure", "vpc"}, want: "infrastructure"},
+		{path: []string{"spec", "kubernetes"}, want: "kubernetes"},
+		{path: []string{"spec", "distribution", "modules"}, want: "distribution"},
+		{path: []string{"spec", "plugins", "helm"}, want: "plugins"},
+		{path: []string{"spec", "distributionVersion"}, want: ""},
+		{path: []string{"metadata", "name"}, want: ""},
+		{path: []string{"spec"}, want: ""},
+	}
+
+	for _, tC := range testCases {
+		assert.Equal(t, tC.want, diffs.PhaseForPath(tC.path), tC.path)
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	t.Parallel()
+
+	current := map[string]any{
+		"spec": map[string]any{
+			"distribution": map[string]any{"modules": map[string]any{"dr": map[string]any{"type": "none"}}},
+			"kubernetes":   map[string]any{"svcCidr": "10.0.0.0/16"},
+		},
+	}
+	updated := map[string]any{
+		"spec": map[string]any{
+			"distribution": map[string]any{"modules": map[string]any{"dr": map[string]any{"type": "on-premises"}}},
+			"kubernetes":   map[string]any{"svcCidr": "10.0.0.0/16"},
+		},
+	}
+
+	out, err := diffs.UnifiedDiff(current, updated, "", false)
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "--- cluster\n")
+	assert.Contains(t, out, "+++ furyctl.yaml\n")
+	assert.Contains(t, out, "-                type: none\n")
+	assert.Contains(t, out, "+                type: on-premises\n")
+
+	out, err = diffs.UnifiedDiff(current, updated, ".spec.kubernetes", false)
+	require.NoError(t, err)
+	assert.Empty(t, out)
+
+	out, err = diffs.UnifiedDiff(current, updated, ".spec.distribution", true)
+	require.NoError(t, err)
+	assert.Contains(t, out, "\033[32m+        type: on-premises\033[0m\n")
+}
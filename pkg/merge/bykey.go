@@ -0,0 +1,100 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package merge
+
+import "maps"
+
+// MergeByKey deep merges b into a like the Merger does, but it merges the lists too: when all the items of
+// both lists are maps with the same identity key, the first of keys that they all have, the items of b
+// merge into the items of a with the same value of the key, and the other items of b are appended. Any
+// other list of b replaces the one of a.
+func MergeByKey(a, b map[string]any, keys ...string) map[string]any {
+	out := make(map[string]any, len(a))
+	maps.Copy(out, a)
+
+	for k, v := range b {
+		out[k] = mergeValue(out[k], v, keys)
+	}
+
+	return out
+}
+
+func mergeValue(a, b any, keys []string) any {
+	switch bv := b.(type) {
+	case map[string]any:
+		if av, ok := a.(map[string]any); ok {
+			return MergeByKey(av, bv, keys...)
+		}
+
+	case []any:
+		if av, ok := a.([]any); ok {
+			if key, ok := identityKey(av, bv, keys); ok {
+				return mergeList(av, bv, key, keys)
+			}
+		}
+	}
+
+	return b
+}
+
+// identityKey returns the first of keys that all the items of the lists have, with a scalar value.
+func identityKey(a, b []any, keys []string) (string, bool) {
+	if len(a) == 0 || len(b) == 0 {
+		return "", false
+	}
+
+	for _, key := range keys {
+		if hasKey(a, key) && hasKey(b, key) {
+			return key, true
+		}
+	}
+
+	return "", false
+}
+
+func hasKey(items []any, key string) bool {
+	for _, item := range items {
+		m, ok := item.(map[string]any)
+		if !ok {
+			return false
+		}
+
+		// The key is an identity only if its value is a scalar.
+		switch m[key].(type) {
+		case string, int, int64, uint64, float64, bool:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func mergeList(a, b []any, key string, keys []string) []any {
+	out := make([]any, len(a), len(a)+len(b))
+	copy(out, a)
+
+	index := make(map[any]int, len(a))
+
+	for i, item := range a {
+		index[item.(map[string]any)[key]] = i //nolint:forcetypeassert // checked by identityKey.
+	}
+
+	for _, item := range b {
+		m := item.(map[string]any) //nolint:forcetypeassert // checked by identityKey.
+
+		id := m[key]
+		if i, ok := index[id]; ok {
+			out[i] = MergeByKey(out[i].(map[string]any), m, keys...) //nolint:forcetypeassert // checked by identityKey.
+
+			continue
+		}
+
+		index[id] = len(out)
+		out = append(out, m)
+	}
+
+	return out
+}
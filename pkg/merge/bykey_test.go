@@ -0,0 +1,75 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package merge_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/sighupio/furyctl/pkg/merge"
+)
+
+func Test_MergeByKey(t *testing.T) {
+	t.Parallel()
+
+	base := map[string]any{
+		"nodes": []any{
+			map[string]any{"name": "infra", "size": "small", "labels": map[string]any{"a": "1"}},
+			map[string]any{"name": "worker", "size": "small"},
+		},
+		"hosts": []any{
+			map[string]any{"hostname": "cp-1", "ip": "10.0.0.1"},
+		},
+		"cidrs": []any{"10.0.0.0/16"},
+	}
+
+	custom := map[string]any{
+		"nodes": []any{
+			map[string]any{"name": "worker", "size": "large"},
+			map[string]any{"name": "ingress", "size": "medium"},
+			map[string]any{"name": "infra", "labels": map[string]any{"b": "2"}},
+		},
+		"hosts": []any{
+			map[string]any{"hostname": "cp-1", "ip": "10.0.0.2"},
+			map[string]any{"hostname": "cp-2", "ip": "10.0.0.3"},
+		},
+		"cidrs": []any{"10.1.0.0/16"},
+	}
+
+	assert.Equal(t, map[string]any{
+		// The items keep the order of the base, the new ones follow.
+		"nodes": []any{
+			map[string]any{"name": "infra", "size": "small", "labels": map[string]any{"a": "1", "b": "2"}},
+			map[string]any{"name": "worker", "size": "large"},
+			map[string]any{"name": "ingress", "size": "medium"},
+		},
+		"hosts": []any{
+			map[string]any{"hostname": "cp-1", "ip": "10.0.0.2"},
+			map[string]any{"hostname": "cp-2", "ip": "10.0.0.3"},
+		},
+		// A list without an identity key is replaced.
+		"cidrs": []any{"10.1.0.0/16"},
+	}, merge.MergeByKey(base, custom, "name", "hostname"))
+
+	// The inputs do not change.
+	assert.Len(t, base["nodes"], 2)
+	assert.Equal(t, map[string]any{"a": "1"}, base["nodes"].([]any)[0].(map[string]any)["labels"])
+}
+
+func Test_MergeByKey_MixedItems(t *testing.T) {
+	t.Parallel()
+
+	// An item without the key makes the whole list replaced.
+	got := merge.MergeByKey(
+		map[string]any{"l": []any{map[string]any{"name": "a", "v": 1}}},
+		map[string]any{"l": []any{map[string]any{"v": 2}}},
+		"name",
+	)
+
+	assert.Equal(t, map[string]any{"l": []any{map[string]any{"v": 2}}}, got)
+}
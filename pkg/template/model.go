@@ -55,6 +55,10 @@ type Model struct {
 	FuncMap              FuncMap
 	StopIfTargetNotEmpty bool
 	DryRun               bool
+	// Strict stops the generation before any template renders when the templates read keys that the
+	// context does not have, but the ones that match the patterns of AllowedMissingKeys.
+	Strict             bool
+	AllowedMissingKeys []string
 }
 
 func NewTemplateModel(
@@ -139,6 +143,12 @@ func (tm *Model) Generate() error {
 
 	tm.Context = context
 
+	if tm.Strict {
+		if err := tm.checkMissingKeys(func(MissingKey) bool { return true }); err != nil {
+			return err
+		}
+	}
+
 	if err := filepath.Walk(tm.SourcePath, tm.applyTemplates); err != nil {
 		return fmt.Errorf("error applying templates: %w", err)
 	}
@@ -0,0 +1,311 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package templatex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/samber/lo"
+)
+
+var ErrMissingKeys = errors.New("missing keys in templates")
+
+// MissingKey is a key that a template reads and that the context does not have, or that is null: it
+// renders as <no value>.
+type MissingKey struct {
+	Template string
+	Line     int
+	Path     string
+}
+
+func (k MissingKey) String() string {
+	return fmt.Sprintf("%s:%d: %s", k.Template, k.Line, k.Path)
+}
+
+// MissingKeysError lists the missing keys of all the templates of a strict model.
+type MissingKeysError struct {
+	Keys []MissingKey
+}
+
+func (e *MissingKeysError) Error() string {
+	lines := lo.Map(e.Keys, func(k MissingKey, _ int) string {
+		return "  " + k.String()
+	})
+
+	return fmt.Sprintf("%s, set them in the configuration file or allow them:\n%s", ErrMissingKeys, strings.Join(lines, "\n"))
+}
+
+func (*MissingKeysError) Unwrap() error {
+	return ErrMissingKeys
+}
+
+// CheckConfigMissingKeys reads all the templates of the model with the data of its configuration, before
+// the phase that renders them runs, and returns a *MissingKeysError with their missing keys under the roots
+// of the data, as .spec, but the allowed ones. The keys of the other roots are the ones that the phase adds
+// when it runs, that Generate checks in strict mode.
+func (tm *Model) CheckConfigMissingKeys() error {
+	context, err := tm.generateContext()
+	if err != nil {
+		return err
+	}
+
+	tm.Context = context
+
+	return tm.checkMissingKeys(func(k MissingKey) bool {
+		root, _, _ := strings.Cut(strings.TrimPrefix(k.Path, "."), ".")
+
+		_, ok := context[root]
+
+		return ok
+	})
+}
+
+// checkMissingKeys reads all the templates of the model before any of them renders, and returns a
+// *MissingKeysError with their missing keys that report tells, but the allowed ones.
+func (tm *Model) checkMissingKeys(report func(k MissingKey) bool) error {
+	allowed := lo.Map(tm.AllowedMissingKeys, func(p string, _ int) *regexp.Regexp {
+		return keyPatternToRegex(p)
+	})
+
+	keys := []MissingKey{}
+
+	err := filepath.Walk(tm.SourcePath, func(source string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if tm.isExcluded(source) || info.IsDir() || !strings.HasSuffix(info.Name(), tm.Suffix) {
+			return nil
+		}
+
+		gen := NewGenerator(tm.SourcePath, source, "", tm.Context, tm.FuncMap, tm.DryRun)
+
+		tmpl, err := gen.ProcessTemplate()
+		if err != nil {
+			return err
+		}
+
+		for _, k := range gen.FindMissingKeys(tmpl) {
+			if report(k) && !lo.SomeBy(allowed, func(re *regexp.Regexp) bool { return re.MatchString(k.Path) }) {
+				keys = append(keys, k)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error reading templates: %w", err)
+	}
+
+	if len(keys) > 0 {
+		return &MissingKeysError{Keys: keys}
+	}
+
+	return nil
+}
+
+// FindMissingKeys returns the keys that the template reads from the root of the context and that the
+// context does not have, with the line that reads them. The keys read where the dot is something else,
+// in the body of a range or a with, are not checked: only their $ ones. Neither are the keys that the
+// condition of an if, a range or a with only tests.
+func (g *Generator) FindMissingKeys(tpl *template.Template) []MissingKey {
+	if tpl == nil || tpl.Tree == nil || tpl.Root == nil {
+		return nil
+	}
+
+	name, err := filepath.Rel(g.rootSrc, g.source)
+	if err != nil {
+		name = g.source
+	}
+
+	keys := []MissingKey{}
+	seen := map[string]bool{}
+
+	visit := func(n parse.Node, idents []string) {
+		if len(idents) == 0 {
+			return
+		}
+
+		key := stringsToPath(idents)
+
+		if _, ok := lookupContext(g.context, idents); ok {
+			return
+		}
+
+		line := lineOf(tpl.Tree, n)
+
+		if id := key + "@" + strconv.Itoa(line); !seen[id] {
+			seen[id] = true
+
+			keys = append(keys, MissingKey{Template: name, Line: line, Path: key})
+		}
+	}
+
+	walkFields(tpl.Root, true, visit)
+
+	return keys
+}
+
+// walkFields calls visit with the fields of the nodes that read the root of the context: the fields when
+// the dot is the root, the $ variables always.
+func walkFields(node parse.Node, rootDot bool, visit func(n parse.Node, idents []string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+
+		for _, c := range n.Nodes {
+			walkFields(c, rootDot, visit)
+		}
+
+	case *parse.ActionNode:
+		walkFields(n.Pipe, rootDot, visit)
+
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				walkFields(arg, rootDot, visit)
+			}
+		}
+
+	case *parse.IfNode:
+		walkCondition(n.Pipe, rootDot, visit)
+		walkFields(n.List, rootDot, visit)
+		walkFields(n.ElseList, rootDot, visit)
+
+	case *parse.RangeNode:
+		walkCondition(n.Pipe, rootDot, visit)
+		walkFields(n.List, false, visit)
+		walkFields(n.ElseList, rootDot, visit)
+
+	case *parse.WithNode:
+		walkCondition(n.Pipe, rootDot, visit)
+		walkFields(n.List, false, visit)
+		walkFields(n.ElseList, rootDot, visit)
+
+	case *parse.TemplateNode:
+		walkFields(n.Pipe, rootDot, visit)
+
+	case *parse.FieldNode:
+		if rootDot {
+			visit(n, n.Ident)
+		}
+
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			visit(n, n.Ident[1:])
+		}
+
+	default:
+		// The other nodes do not read the context.
+	}
+}
+
+// walkCondition is walkFields for the pipeline of an if, a range or a with. The fields that the pipeline
+// only tests, alone or with and, or and not, are not visited: a missing key is false.
+func walkCondition(node parse.Node, rootDot bool, visit func(n parse.Node, idents []string)) {
+	switch n := node.(type) {
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+
+		if len(n.Cmds) != 1 {
+			walkFields(n, rootDot, visit)
+
+			return
+		}
+
+		args := n.Cmds[0].Args
+
+		if len(args) == 1 {
+			walkCondition(args[0], rootDot, visit)
+
+			return
+		}
+
+		if id, ok := args[0].(*parse.IdentifierNode); ok && lo.Contains([]string{"and", "or", "not"}, id.Ident) {
+			for _, arg := range args[1:] {
+				walkCondition(arg, rootDot, visit)
+			}
+
+			return
+		}
+
+		walkFields(n, rootDot, visit)
+
+	case *parse.FieldNode, *parse.VariableNode:
+		// The field is only tested.
+
+	default:
+		walkFields(node, rootDot, visit)
+	}
+}
+
+// lookupContext returns the value at the path of keys, a null value is not there.
+func lookupContext(context map[string]map[any]any, idents []string) (any, bool) {
+	root, ok := context[idents[0]]
+	if !ok || root == nil {
+		return nil, false
+	}
+
+	var cur any = root
+
+	for _, key := range idents[1:] {
+		m, ok := cur.(map[any]any)
+		if !ok {
+			return nil, false
+		}
+
+		cur, ok = m[key]
+		if !ok || cur == nil {
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+// lineOf returns the line of a node in its template.
+func lineOf(tree *parse.Tree, n parse.Node) int {
+	location, _ := tree.ErrorContext(n)
+
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 { //nolint:mnd // name:line:col.
+		return 0
+	}
+
+	line, err := strconv.Atoi(parts[len(parts)-2])
+	if err != nil {
+		return 0
+	}
+
+	return line
+}
+
+// keyPatternToRegex turns a pattern of the allowed missing keys into a regexp: * stands for a key,
+// ** for any number of keys.
+func keyPatternToRegex(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "**")
+
+	quoted := lo.Map(parts, func(p string, _ int) string {
+		return strings.ReplaceAll(regexp.QuoteMeta(p), `\*`, `[^.]*`)
+	})
+
+	return regexp.MustCompile("^" + strings.Join(quoted, ".*") + "$")
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2017-present SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unit
+
+package templatex_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+
+	templatex "github.com/sighupio/furyctl/pkg/template"
+)
+
+const strictTemplate = `name: {{ .spec.name }}
+region: {{ .spec.regon }}
+{{- if .spec.tags }}
+tags: {{ .spec.tags }}
+{{- end }}
+{{- range .spec.nodes }}
+node: {{ .name }} in {{ $.spec.zone }}
+{{- end }}
+{{- with .spec.auth }}
+auth: {{ .type }}
+{{- end }}
+{{ .spec.optional.value }}
+{{- if .spec.x.y }}
+x: set
+{{- end }}
+{{- if and .spec.x.z (not $.spec.v) }}{{ end }}
+{{- if printf "%v" .spec.w }}w: set{{ end }}
+`
+
+func newStrictModel(t *testing.T, allowed ...string) (*templatex.Model, string) {
+	t.Helper()
+
+	conf := map[string]any{
+		"data": map[string]any{
+			"spec": map[string]any{
+				"name":  "test",
+				"tags":  nil,
+				"nodes": []any{map[string]any{"name": "node1"}},
+				"auth":  map[string]any{"type": "none"},
+			},
+		},
+	}
+
+	confYaml, err := yaml.Marshal(conf)
+	require.NoError(t, err)
+
+	path := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(path, "source", "nested"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "source", "nested", "main.yaml.tpl"), []byte(strictTemplate), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "source", "other.yaml.tpl"), []byte("other: {{ .spec.missing }}\n"), os.ModePerm))
+	require.NoError(t, os.WriteFile(filepath.Join(path, "configTest.yaml"), confYaml, os.ModePerm))
+
+	tm, err := templatex.NewTemplateModel(
+		filepath.Join(path, "source"),
+		filepath.Join(path, "target"),
+		filepath.Join(path, "configTest.yaml"),
+		path,
+		"dummy/furyctlconf/path/furyctl.yaml",
+		".tpl",
+		false,
+		true,
+	)
+	require.NoError(t, err)
+
+	tm.Strict = true
+	tm.AllowedMissingKeys = allowed
+
+	return tm, filepath.Join(path, "target")
+}
+
+func TestGenerate_Strict(t *testing.T) {
+	t.Parallel()
+
+	tm, target := newStrictModel(t)
+
+	err := tm.Generate()
+	require.ErrorIs(t, err, templatex.ErrMissingKeys)
+
+	var missing *templatex.MissingKeysError
+	require.True(t, errors.As(err, &missing))
+
+	// All the templates, in order, with the null keys too. The keys read where the dot is not the root are
+	// not checked, but the $ ones. The keys that a condition only tests are not checked either.
+	assert.Equal(t, []templatex.MissingKey{
+		{Template: filepath.Join("nested", "main.yaml.tpl"), Line: 2, Path: ".spec.regon"},
+		{Template: filepath.Join("nested", "main.yaml.tpl"), Line: 4, Path: ".spec.tags"},
+		{Template: filepath.Join("nested", "main.yaml.tpl"), Line: 7, Path: ".spec.zone"},
+		{Template: filepath.Join("nested", "main.yaml.tpl"), Line: 12, Path: ".spec.optional.value"},
+		{Template: filepath.Join("nested", "main.yaml.tpl"), Line: 17, Path: ".spec.w"},
+		{Template: "other.yaml.tpl", Line: 1, Path: ".spec.missing"},
+	}, missing.Keys)
+
+	assert.Contains(t, err.Error(), "nested/main.yaml.tpl:2: .spec.regon")
+
+	// Nothing renders.
+	_, statErr := os.Stat(filepath.Join(target, "other.yaml"))
+	require.ErrorIs(t, statErr, os.ErrNotExist)
+}
+
+func TestGenerate_StrictAllowed(t *testing.T) {
+	t.Parallel()
+
+	tm, target := newStrictModel(t, ".spec.regon", ".spec.tags", ".spec.*", ".spec.optional.**")
+
+	require.NoError(t, tm.Generate())
+
+	_, err := os.Stat(filepath.Join(target, "other.yaml"))
+	require.NoError(t, err)
+
+	// A single * does not stand for more keys.
+	tm, _ = newStrictModel(t, ".spec.*")
+
+	err = tm.Generate()
+
+	var missing *templatex.MissingKeysError
+	require.True(t, errors.As(err, &missing))
+	require.Len(t, missing.Keys, 1)
+	assert.Equal(t, ".spec.optional.value", missing.Keys[0].Path)
+}
+
+func TestCheckConfigMissingKeys(t *testing.T) {
+	t.Parallel()
+
+	tm, target := newStrictModel(t, ".spec.optional.**")
+
+	require.NoError(t, os.WriteFile(
+		filepath.Join(tm.SourcePath, "runtime.yaml.tpl"),
+		[]byte("vpc: {{ .kubernetes.vpcId }}\n"),
+		os.ModePerm,
+	))
+
+	err := tm.CheckConfigMissingKeys()
+	require.ErrorIs(t, err, templatex.ErrMissingKeys)
+
+	var missing *templatex.MissingKeysError
+	require.True(t, errors.As(err, &missing))
+
+	// The keys under the roots of the data, but the allowed ones: .kubernetes is not one of them.
+	assert.Equal(t, []templatex.MissingKey{
+		{Template: filepath.Join("nested", "main.yaml.tpl"), Line: 2, Path: ".spec.regon"},
+		{Template: filepath.Join("nested", "main.yaml.tpl"), Line: 4, Path: ".spec.tags"},
+		{Template: filepath.Join("nested", "main.yaml.tpl"), Line: 7, Path: ".spec.zone"},
+		{Template: filepath.Join("nested", "main.yaml.tpl"), Line: 17, Path: ".spec.w"},
+		{Template: "other.yaml.tpl", Line: 1, Path: ".spec.missing"},
+	}, missing.Keys)
+
+	// Nothing renders.
+	_, statErr := os.Stat(target)
+	require.ErrorIs(t, statErr, os.ErrNotExist)
+}